@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"emperror.dev/errors"
+	"golang.org/x/term"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/utils/passwordhash"
+)
+
+// hashPassword will read a password and print its hash.
+// When user is set, result is printed as a htpasswd line.
+func hashPassword(algorithm, user string) error {
+	// Check algorithm
+	if !slices.Contains(passwordhash.SupportedAlgorithms, algorithm) {
+		return errors.Errorf("unsupported algorithm %s", algorithm)
+	}
+
+	// Read password
+	password, err := readPassword()
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Hash password
+	res, err := passwordhash.Hash(algorithm, password)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Check if htpasswd line is asked
+	if user != "" {
+		res = user + ":" + res
+	}
+
+	fmt.Println(res)
+
+	return nil
+}
+
+// readPassword will read password from terminal without echo or from the first line of stdin.
+func readPassword() (string, error) {
+	fd := int(os.Stdin.Fd()) //nolint:gosec // File descriptor fits in int
+
+	// Check if stdin is a terminal
+	if !term.IsTerminal(fd) {
+		// Read first line
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		// Check error
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", errors.WithStack(err)
+		}
+
+		password := strings.TrimRight(line, "\r\n")
+		// Check password
+		if password == "" {
+			return "", errors.New("password cannot be empty")
+		}
+
+		return password, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+
+	password, err := term.ReadPassword(fd)
+	// Check error
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	fmt.Fprint(os.Stderr, "\nConfirm password: ")
+
+	confirm, err := term.ReadPassword(fd)
+	// Check error
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	fmt.Fprintln(os.Stderr)
+
+	// Check password
+	if len(password) == 0 {
+		return "", errors.New("password cannot be empty")
+	}
+
+	// Check confirmation
+	if string(password) != string(confirm) {
+		return "", errors.New("passwords don't match")
+	}
+
+	return string(password), nil
+}
@@ -1,24 +1,41 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"emperror.dev/errors"
 	"github.com/spf13/cobra"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/audit"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/authorization"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/lockout"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/session"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/geoip"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/limiter"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/quota"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/server"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/utils/passwordhash"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/version"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
 )
 
 // Main package
 
+// shutdownTimeout is the maximum time given to in flight requests on shutdown.
+const shutdownTimeout = 30 * time.Second
+
 func startServer(mainConfDir string) {
 	// Create new logger
 	logger := log.NewLogger()
@@ -96,7 +113,7 @@ func startServer(mainConfDir string) {
 	})
 
 	// Create webhook manager
-	webhookManager := webhook.NewManager(cfgManager, metricsCtx)
+	webhookManager := webhook.NewManager(cfgManager, metricsCtx, logger)
 	// Load
 	err = webhookManager.Load()
 	// Check error
@@ -114,27 +131,167 @@ func startServer(mainConfDir string) {
 		}
 	})
 
+	// Create quota manager
+	quotaManager := quota.NewManager(cfgManager, s3clientManager, logger)
+	// Start periodic reconciliation
+	quotaManager.Start()
+
+	// Create limiter manager
+	limiterManager := limiter.NewManager(cfgManager, metricsCtx)
+
+	// Create OIDC session manager
+	sessionManager := session.NewManager(cfgManager)
+	// Load
+	err = sessionManager.Load()
+	// Check error
+	if err != nil {
+		logger.Fatal(err)
+	}
+	// Prepare on reload hook
+	cfgManager.AddOnChangeHook(func() {
+		logger.Info("Reload OIDC session stores")
+		// Load
+		err2 := sessionManager.Load()
+		// Check error
+		if err2 != nil {
+			logger.Fatal(err2)
+		}
+	})
+
+	// Create rego policy manager
+	regoManager := authorization.NewRegoManager(cfgManager, s3clientManager, logger)
+	// Load
+	err = regoManager.Load()
+	// Check error
+	if err != nil {
+		logger.Fatal(err)
+	}
+	// Prepare on reload hook
+	cfgManager.AddOnChangeHook(func() {
+		logger.Info("Reload rego policies")
+		// Load
+		err2 := regoManager.Load()
+		// Check error
+		if err2 != nil {
+			logger.Fatal(err2)
+		}
+	})
+
+	// Create lockout manager
+	lockoutManager := lockout.NewManager()
+
+	// Create audit manager
+	auditManager := audit.NewManager(cfgManager, s3clientManager, logger)
+	// Load
+	err = auditManager.Load()
+	// Check error
+	if err != nil {
+		logger.Fatal(err)
+	}
+	// Prepare on reload hook
+	cfgManager.AddOnChangeHook(func() {
+		logger.Info("Reload audit sinks")
+		// Load
+		err2 := auditManager.Load()
+		// Check error
+		if err2 != nil {
+			logger.Fatal(err2)
+		}
+	})
+
+	// Create GeoIP manager
+	geoipManager := geoip.NewManager(cfgManager, logger)
+	// Load
+	err = geoipManager.Load()
+	// Check error
+	if err != nil {
+		logger.Fatal(err)
+	}
+	// Prepare on reload hook
+	cfgManager.AddOnChangeHook(func() {
+		logger.Info("Reload GeoIP database")
+		// Load
+		err2 := geoipManager.Load()
+		// Check error
+		if err2 != nil {
+			logger.Fatal(err2)
+		}
+	})
+
 	// Create internal server
-	intSvr := server.NewInternalServer(logger, cfgManager, metricsCtx)
+	intSvr := server.NewInternalServer(logger, cfgManager, metricsCtx, lockoutManager, webhookManager)
 	// Generate server
 	err = intSvr.GenerateServer()
 	if err != nil {
 		logger.Fatal(err)
 	}
 	// Create server
-	svr := server.NewServer(logger, cfgManager, metricsCtx, tracingSvc, s3clientManager, webhookManager)
+	svr := server.NewServer(
+		logger,
+		cfgManager,
+		metricsCtx,
+		tracingSvc,
+		s3clientManager,
+		webhookManager,
+		quotaManager,
+		limiterManager,
+		sessionManager,
+		regoManager,
+		lockoutManager,
+		auditManager,
+		geoipManager,
+	)
 	// Generate server
 	err = svr.GenerateServer()
 	if err != nil {
 		logger.Fatal(err)
 	}
 
-	var g errgroup.Group
+	// Stop on interrupt or termination signal
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	g, gctx := errgroup.WithContext(ctx)
 
 	g.Go(svr.Listen)
 	g.Go(intSvr.Listen)
+	g.Go(func() error {
+		// Wait for a signal or a listen error
+		<-gctx.Done()
 
-	if err := g.Wait(); err != nil {
+		logger.Info("Shutting down servers")
+
+		sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		return errors.Append(svr.Shutdown(sctx), intSvr.Shutdown(sctx))
+	})
+
+	err = g.Wait()
+
+	// Flush audit events before exit
+	err2 := auditManager.Close()
+	// Check error
+	if err2 != nil {
+		logger.Error(err2)
+	}
+
+	// Stop webhook durable queue
+	err2 = webhookManager.Close()
+	// Check error
+	if err2 != nil {
+		logger.Error(err2)
+	}
+
+	// Close GeoIP database
+	err2 = geoipManager.Close()
+	// Check error
+	if err2 != nil {
+		logger.Error(err2)
+	}
+
+	// Check error
+	if err != nil {
 		logger.Fatal(err)
 	}
 }
@@ -160,7 +317,29 @@ func main() {
 		},
 	}
 
+	var hashAlgorithm, hashUser string
+
+	hashPasswordCmd := &cobra.Command{
+		Use:   "hash-password",
+		Short: "Hash a password for basic auth credentials or htpasswd files",
+		Long: "Hash a password for basic auth credentials or htpasswd files. " +
+			"Password is read from terminal without echo or from the first line of standard input.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return hashPassword(hashAlgorithm, hashUser)
+		},
+	}
+	hashPasswordCmd.Flags().StringVar(
+		&hashAlgorithm,
+		"algorithm",
+		passwordhash.AlgorithmBcrypt,
+		"Hash algorithm ("+strings.Join(passwordhash.SupportedAlgorithms, ", ")+")",
+	)
+	hashPasswordCmd.Flags().StringVar(&hashUser, "user", "", "User name to output a htpasswd line")
+
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(hashPasswordCmd)
 	rootCmd.PersistentFlags().StringVar(&configFolder, "config", "conf/", "Config folder (default is <Current Working Directory>/conf/)")
 
 	if err := rootCmd.Execute(); err != nil {
@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+)
+
+// Decisions.
+const (
+	DecisionAllow = "allow"
+	DecisionDeny  = "deny"
+)
+
+// Actions done on bucket.
+const (
+	ActionList   = "list"
+	ActionGet    = "get"
+	ActionHead   = "head"
+	ActionPut    = "put"
+	ActionDelete = "delete"
+)
+
+// Event is an audit event describing one operation.
+type Event struct {
+	Time          time.Time `json:"time"`
+	RequestID     string    `json:"requestId"`
+	ClientIP      string    `json:"clientIp"`
+	User          string    `json:"user"`
+	UserType      string    `json:"userType"`
+	Impersonator  string    `json:"impersonator"`
+	Provider      string    `json:"provider"`
+	Target        string    `json:"target"`
+	Method        string    `json:"method"`
+	Path          string    `json:"path"`
+	Action        string    `json:"action"`
+	Bucket        string    `json:"bucket"`
+	Key           string    `json:"key"`
+	Decision      string    `json:"decision"`
+	Reason        string    `json:"reason"`
+	Status        int       `json:"status"`
+	BytesSent     int64     `json:"bytesSent"`
+	BytesReceived int64     `json:"bytesReceived"`
+	DurationMs    int64     `json:"durationMs"`
+}
+
+// Manager will send audit events to sinks declared in configuration.
+//
+//go:generate mockgen -destination=./mocks/mock_Manager.go -package=mocks github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/audit Manager
+type Manager interface {
+	// Load will create sinks from configuration.
+	// Sinks with an unchanged configuration are kept, others are flushed and closed.
+	Load() error
+	// Emit will send an event to all sinks.
+	Emit(ev *Event)
+	// Close will flush and close all sinks.
+	Close() error
+}
+
+// NewManager will return a new audit manager.
+func NewManager(cfgManager config.Manager, s3clientManager s3client.Manager, logger log.Logger) Manager {
+	return &manager{
+		cfgManager:      cfgManager,
+		s3clientManager: s3clientManager,
+		logger:          logger,
+	}
+}
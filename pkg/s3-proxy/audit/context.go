@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+)
+
+type contextKey struct {
+	name string
+}
+
+var eventCtxKey = &contextKey{name: "audit-event"}
+
+// GetEventFromContext will return the audit event of the request or nil if audit isn't enabled.
+func GetEventFromContext(ctx context.Context) *Event {
+	// Get event
+	ev, _ := ctx.Value(eventCtxKey).(*Event)
+
+	return ev
+}
+
+// SetDecision will save the authorization decision and its reason in the request audit event.
+func SetDecision(ctx context.Context, decision, reason string) {
+	// Get event
+	ev := GetEventFromContext(ctx)
+	// Check if audit is enabled
+	if ev == nil {
+		return
+	}
+
+	ev.Decision = decision
+	ev.Reason = reason
+
+	fillIdentity(ctx, ev)
+}
+
+// SetObject will save the bucket action and the resolved object in the request audit event.
+func SetObject(ctx context.Context, action, bucket, key string) {
+	// Get event
+	ev := GetEventFromContext(ctx)
+	// Check if audit is enabled
+	if ev == nil {
+		return
+	}
+
+	ev.Action = action
+	ev.Bucket = bucket
+	ev.Key = key
+
+	fillIdentity(ctx, ev)
+}
+
+// fillIdentity will save the authenticated user, the impersonator and the resource provider found in context.
+// They are added in context by next middlewares, so they cannot be read by the audit middleware.
+func fillIdentity(ctx context.Context, ev *Event) {
+	// Get user
+	user := models.GetAuthenticatedUserFromContext(ctx)
+	// Check if user exists
+	if user != nil {
+		ev.User = user.GetIdentifier()
+		ev.UserType = user.GetType()
+		// Check if user is impersonated
+		if iuser, ok := user.(*models.ImpersonatedUser); ok {
+			ev.Impersonator = iuser.Impersonator.GetIdentifier()
+		}
+	}
+
+	// Get resource
+	res := models.GetRequestResourceFromContext(ctx)
+	// Check if resource exists
+	if res != nil {
+		ev.Provider = res.Provider
+	}
+}
+
+// countingReader will count bytes read from request body.
+type countingReader struct {
+	io.ReadCloser
+	count int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.ReadCloser.Read(p)
+	cr.count += int64(n)
+
+	return n, err //nolint:wrapcheck // Reader errors must be returned as is
+}
+
+// HTTPMiddleware will create an audit event for each request and emit it once answered.
+func HTTPMiddleware(auditManager Manager, targetKey string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get start time
+			start := time.Now()
+			// Create event
+			ev := &Event{
+				Time:      start.UTC(),
+				RequestID: middleware.GetReqID(r.Context()),
+				ClientIP:  middleware.GetClientIP(r.Context()),
+				Target:    targetKey,
+				Method:    r.Method,
+				Path:      r.URL.Path,
+			}
+
+			// Wrap response writer to get status and bytes sent
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			// Wrap body to count bytes received
+			var cr *countingReader
+			// Check if body exists
+			if r.Body != nil {
+				cr = &countingReader{ReadCloser: r.Body}
+				r.Body = cr
+			}
+
+			// Add event to context
+			r = r.WithContext(context.WithValue(r.Context(), eventCtxKey, ev))
+
+			next.ServeHTTP(ww, r)
+
+			// Complete event
+			ev.Status = ww.Status()
+			// Check if status has been set
+			if ev.Status == 0 {
+				ev.Status = http.StatusOK
+			}
+
+			ev.BytesSent = int64(ww.BytesWritten())
+			ev.DurationMs = time.Since(start).Milliseconds()
+			// Check if body has been read
+			if cr != nil {
+				ev.BytesReceived = cr.count
+			}
+
+			// Check if request was rejected before authorization
+			if ev.Decision == "" && (ev.Status == http.StatusUnauthorized || ev.Status == http.StatusForbidden) {
+				ev.Decision = DecisionDeny
+				ev.Reason = "authentication failed"
+			}
+
+			auditManager.Emit(ev)
+		})
+	}
+}
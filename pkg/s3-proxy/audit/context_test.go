@@ -0,0 +1,144 @@
+//go:build unit
+
+package audit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+type recorderManager struct {
+	events []*Event
+}
+
+func (*recorderManager) Load() error { return nil }
+
+func (m *recorderManager) Emit(ev *Event) { m.events = append(m.events, ev) }
+
+func (*recorderManager) Close() error { return nil }
+
+func TestHTTPMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		body       string
+		assertFunc func(t *testing.T, ev *Event)
+	}{
+		{
+			name: "allowed put request",
+			body: "content",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				ctx := models.SetAuthenticatedUserInContext(r.Context(), &models.BasicAuthUser{Username: "user1"})
+				ctx = models.SetRequestResourceInContext(ctx, &config.Resource{Provider: "provider1"})
+
+				SetDecision(ctx, DecisionAllow, "basic-auth")
+				SetObject(ctx, ActionPut, "bucket", "folder/file.txt")
+
+				_, _ = io.ReadAll(r.Body)
+
+				w.WriteHeader(http.StatusNoContent)
+			},
+			assertFunc: func(t *testing.T, ev *Event) {
+				t.Helper()
+
+				assert.Equal(t, "target1", ev.Target)
+				assert.Equal(t, http.MethodPut, ev.Method)
+				assert.Equal(t, "/folder/", ev.Path)
+				assert.Equal(t, "user1", ev.User)
+				assert.Equal(t, "BASIC", ev.UserType)
+				assert.Empty(t, ev.Impersonator)
+				assert.Equal(t, "provider1", ev.Provider)
+				assert.Equal(t, DecisionAllow, ev.Decision)
+				assert.Equal(t, "basic-auth", ev.Reason)
+				assert.Equal(t, ActionPut, ev.Action)
+				assert.Equal(t, "bucket", ev.Bucket)
+				assert.Equal(t, "folder/file.txt", ev.Key)
+				assert.Equal(t, http.StatusNoContent, ev.Status)
+				assert.Equal(t, int64(7), ev.BytesReceived)
+				assert.Equal(t, int64(0), ev.BytesSent)
+				assert.False(t, ev.Time.IsZero())
+			},
+		},
+		{
+			name: "impersonated request",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				ctx := models.SetAuthenticatedUserInContext(r.Context(), &models.ImpersonatedUser{
+					Impersonator: &models.BasicAuthUser{Username: "admin"},
+					Username:     "user1",
+				})
+
+				SetDecision(ctx, DecisionAllow, "basic-auth")
+
+				w.WriteHeader(http.StatusOK)
+			},
+			assertFunc: func(t *testing.T, ev *Event) {
+				t.Helper()
+
+				assert.Equal(t, "user1", ev.User)
+				assert.Equal(t, "IMPERSONATED", ev.UserType)
+				assert.Equal(t, "admin", ev.Impersonator)
+			},
+		},
+		{
+			name: "rejected by authentication",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte("unauthorized"))
+			},
+			assertFunc: func(t *testing.T, ev *Event) {
+				t.Helper()
+
+				assert.Equal(t, DecisionDeny, ev.Decision)
+				assert.Equal(t, "authentication failed", ev.Reason)
+				assert.Equal(t, http.StatusUnauthorized, ev.Status)
+				assert.Equal(t, int64(12), ev.BytesSent)
+				assert.Empty(t, ev.User)
+			},
+		},
+		{
+			name: "default status",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+			},
+			assertFunc: func(t *testing.T, ev *Event) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, ev.Status)
+				assert.Equal(t, int64(2), ev.BytesSent)
+				assert.Empty(t, ev.Decision)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &recorderManager{}
+
+			req := httptest.NewRequest(http.MethodPut, "/folder/", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			HTTPMiddleware(recorder, "target1")(tt.handler).ServeHTTP(w, req)
+
+			require.Len(t, recorder.events, 1)
+			tt.assertFunc(t, recorder.events[0])
+		})
+	}
+}
+
+func TestSetDecision_withoutAudit(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// Must not panic when audit isn't enabled
+	SetDecision(req.Context(), DecisionAllow, "reason")
+	SetObject(req.Context(), ActionGet, "bucket", "key")
+
+	assert.Nil(t, GetEventFromContext(req.Context()))
+}
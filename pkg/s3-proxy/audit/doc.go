@@ -0,0 +1,3 @@
+package audit
+
+// This package will emit structured audit events of access decisions and bucket operations to sinks
@@ -0,0 +1,200 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+const (
+	megabyte                = 1024 * 1024
+	fileSinkPermissions     = 0o600
+	fileSinkDirPermissions  = 0o750
+	fileSinkRotationTimeFmt = "20060102T150405.000000000"
+	fileSinkRotationSep     = "."
+)
+
+// fileSink will write events to a file and rotate it when it reaches the max size.
+type fileSink struct {
+	f          *os.File
+	path       string
+	maxSize    int64
+	size       int64
+	maxBackups int
+	mutex      sync.Mutex
+}
+
+func newFileSink(cfg *config.AuditFileSinkConfig) (*fileSink, error) {
+	s := &fileSink{
+		path:       cfg.Path,
+		maxSize:    int64(cfg.MaxSize) * megabyte,
+		maxBackups: cfg.MaxBackups,
+	}
+
+	// Ensure directory exists
+	err := os.MkdirAll(filepath.Dir(cfg.Path), fileSinkDirPermissions)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Open file
+	err = s.open()
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *fileSink) open() error {
+	// Open file in append mode
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, fileSinkPermissions)
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Get current size
+	info, err := f.Stat()
+	// Check error
+	if err != nil {
+		_ = f.Close()
+
+		return errors.WithStack(err)
+	}
+
+	s.f = f
+	s.size = info.Size()
+
+	return nil
+}
+
+func (s *fileSink) Write(line []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Check if file is closed
+	if s.f == nil {
+		return errors.New("audit file sink is closed")
+	}
+
+	// Check if rotation is needed before writing
+	// Max size equal to 0 disables rotation.
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		err := s.rotate()
+		// Check error
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := s.f.Write(line)
+	s.size += int64(n)
+
+	return errors.WithStack(err)
+}
+
+func (s *fileSink) rotate() error {
+	// Close current file
+	err := s.f.Close()
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.f = nil
+
+	// Rename it with a timestamp
+	err = os.Rename(s.path, s.path+fileSinkRotationSep+time.Now().UTC().Format(fileSinkRotationTimeFmt))
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Remove old backups
+	err = s.pruneBackups()
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	return s.open()
+}
+
+func (s *fileSink) pruneBackups() error {
+	// Max backups equal to 0 keeps all backups
+	if s.maxBackups == 0 {
+		return nil
+	}
+
+	// List backups
+	matches, err := filepath.Glob(s.path + fileSinkRotationSep + "*")
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Keep only rotated files
+	backups := make([]string, 0, len(matches))
+
+	for _, m := range matches {
+		// Check suffix is a rotation timestamp
+		_, err2 := time.Parse(fileSinkRotationTimeFmt, strings.TrimPrefix(m, s.path+fileSinkRotationSep))
+		if err2 == nil {
+			backups = append(backups, m)
+		}
+	}
+
+	// Check if there is too many backups
+	if len(backups) <= s.maxBackups {
+		return nil
+	}
+
+	// Timestamps are sortable, oldest first
+	sort.Strings(backups)
+
+	for _, b := range backups[:len(backups)-s.maxBackups] {
+		// Remove backup
+		err = os.Remove(b)
+		// Check error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+func (s *fileSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Check if already closed
+	if s.f == nil {
+		return nil
+	}
+
+	// Sync data on disk
+	err := s.f.Sync()
+	// Check error
+	if err != nil {
+		_ = s.f.Close()
+		s.f = nil
+
+		return errors.WithStack(err)
+	}
+
+	err = s.f.Close()
+	s.f = nil
+
+	return errors.WithStack(err)
+}
@@ -0,0 +1,65 @@
+//go:build unit
+
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+func Test_fileSink_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "audit.log")
+
+	s, err := newFileSink(&config.AuditFileSinkConfig{Path: path, MaxBackups: 2})
+	require.NoError(t, err)
+
+	// Force a tiny max size to trigger rotations
+	s.maxSize = 20
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.Write([]byte(strings.Repeat("a", 15)+"\n")))
+	}
+
+	require.NoError(t, s.Close())
+
+	// Write after close must fail
+	assert.Error(t, s.Write([]byte("b\n")))
+	// Close must be idempotent
+	assert.NoError(t, s.Close())
+
+	// Current file contains last line
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("a", 15)+"\n", string(b))
+
+	// Only max backups are kept
+	matches, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	assert.Len(t, matches, 2)
+}
+
+func Test_fileSink_Append(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+
+	require.NoError(t, os.WriteFile(path, []byte("existing\n"), 0o600))
+
+	s, err := newFileSink(&config.AuditFileSinkConfig{Path: path})
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), s.size)
+
+	require.NoError(t, s.Write([]byte("new\n")))
+	require.NoError(t, s.Close())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "existing\nnew\n", string(b))
+}
@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sync"
+
+	"emperror.dev/errors"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+)
+
+type manager struct {
+	cfgManager      config.Manager
+	s3clientManager s3client.Manager
+	logger          log.Logger
+	sinks           []*sinkEntry
+	mutex           sync.Mutex
+}
+
+type sinkEntry struct {
+	cfg  *config.AuditSinkConfig
+	sink sink
+}
+
+func (m *manager) Load() error {
+	// Get configuration
+	cfg := m.cfgManager.GetConfig()
+
+	// Get sink configurations
+	var sinkCfgs []*config.AuditSinkConfig
+	// Check if audit is enabled
+	if cfg.Audit != nil && cfg.Audit.Enabled {
+		sinkCfgs = cfg.Audit.Sinks
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Build new sinks, keeping the unchanged ones
+	newSinks := make([]*sinkEntry, 0, len(sinkCfgs))
+	kept := map[*sinkEntry]bool{}
+
+	for _, sinkCfg := range sinkCfgs {
+		// Search for an unchanged sink
+		var entry *sinkEntry
+
+		for _, old := range m.sinks {
+			// Check if sink can be reused
+			if !kept[old] && reflect.DeepEqual(old.cfg, sinkCfg) {
+				entry = old
+				kept[old] = true
+
+				break
+			}
+		}
+
+		// Check if a new sink must be created
+		if entry == nil {
+			// Create sink
+			s, err := m.newSink(sinkCfg)
+			// Check error
+			if err != nil {
+				// Close sinks created during this load
+				for _, ns := range newSinks {
+					// Check if it is a new one
+					if !kept[ns] {
+						_ = ns.sink.Close()
+					}
+				}
+
+				return err
+			}
+
+			entry = &sinkEntry{cfg: sinkCfg, sink: s}
+		}
+
+		newSinks = append(newSinks, entry)
+	}
+
+	// Close removed sinks
+	for _, old := range m.sinks {
+		// Check if sink is kept
+		if kept[old] {
+			continue
+		}
+
+		// Close it
+		err := old.sink.Close()
+		// Check error
+		if err != nil {
+			m.logger.Error(errors.Wrap(err, "cannot close audit sink"))
+		}
+	}
+
+	m.sinks = newSinks
+
+	return nil
+}
+
+func (m *manager) newSink(sinkCfg *config.AuditSinkConfig) (sink, error) {
+	switch sinkCfg.Type {
+	case config.AuditSinkTypeFile:
+		return newFileSink(sinkCfg.File)
+	case config.AuditSinkTypeS3:
+		return newS3Sink(sinkCfg.S3, m.s3clientManager, m.logger), nil
+	default:
+		return newWriterSink(os.Stdout), nil
+	}
+}
+
+func (m *manager) Emit(ev *Event) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Check if there is at least one sink
+	if len(m.sinks) == 0 {
+		return
+	}
+
+	// Marshal event once for all sinks
+	b, err := json.Marshal(ev)
+	// Check error
+	if err != nil {
+		m.logger.Error(errors.Wrap(err, "cannot marshal audit event"))
+
+		return
+	}
+
+	// Add line separator
+	b = append(b, '\n')
+
+	for _, entry := range m.sinks {
+		// Write event
+		err = entry.sink.Write(b)
+		// Check error
+		if err != nil {
+			m.logger.Error(errors.Wrapf(err, "cannot write audit event to %s sink", entry.cfg.Type))
+		}
+	}
+}
+
+func (m *manager) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var res error
+
+	for _, entry := range m.sinks {
+		// Close sink
+		err := entry.sink.Close()
+		// Check error
+		if err != nil {
+			res = errors.Append(res, err)
+		}
+	}
+
+	m.sinks = nil
+
+	return res
+}
@@ -0,0 +1,171 @@
+//go:build unit
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+)
+
+type memorySink struct {
+	lines  [][]byte
+	closed bool
+}
+
+func (s *memorySink) Write(line []byte) error {
+	s.lines = append(s.lines, line)
+
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	s.closed = true
+
+	return nil
+}
+
+func newTestManager(t *testing.T, cfg *config.Config) *manager {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	m, ok := NewManager(cfgManagerMock, nil, log.NewLogger()).(*manager)
+	require.True(t, ok)
+
+	return m
+}
+
+func Test_manager_Emit(t *testing.T) {
+	m := newTestManager(t, &config.Config{})
+
+	// No sink
+	m.Emit(&Event{User: "user1"})
+
+	s1 := &memorySink{}
+	s2 := &memorySink{}
+	m.sinks = []*sinkEntry{
+		{cfg: &config.AuditSinkConfig{Type: "stdout"}, sink: s1},
+		{cfg: &config.AuditSinkConfig{Type: "stdout"}, sink: s2},
+	}
+
+	m.Emit(&Event{
+		Time:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		User:     "user1",
+		Decision: DecisionAllow,
+		Action:   ActionGet,
+		Bucket:   "bucket",
+		Key:      "folder/file.txt",
+		Status:   200,
+	})
+
+	require.Len(t, s1.lines, 1)
+	require.Len(t, s2.lines, 1)
+	assert.Equal(t, s1.lines[0], s2.lines[0])
+	assert.True(t, bytes.HasSuffix(s1.lines[0], []byte("\n")))
+
+	var res map[string]any
+
+	require.NoError(t, json.Unmarshal(s1.lines[0], &res))
+	assert.Equal(t, "2026-01-02T03:04:05Z", res["time"])
+	assert.Equal(t, "user1", res["user"])
+	assert.Equal(t, "allow", res["decision"])
+	assert.Equal(t, "get", res["action"])
+	assert.Equal(t, "bucket", res["bucket"])
+	assert.Equal(t, "folder/file.txt", res["key"])
+	assert.InDelta(t, 200, res["status"], 0)
+
+	require.NoError(t, m.Close())
+	assert.True(t, s1.closed)
+	assert.True(t, s2.closed)
+	assert.Empty(t, m.sinks)
+}
+
+func Test_manager_Load(t *testing.T) {
+	dir := t.TempDir()
+	fileSinkCfg := &config.AuditSinkConfig{
+		Type: config.AuditSinkTypeFile,
+		File: &config.AuditFileSinkConfig{Path: filepath.Join(dir, "audit.log")},
+	}
+	cfg := &config.Config{
+		Audit: &config.AuditConfig{
+			Enabled: true,
+			Sinks:   []*config.AuditSinkConfig{fileSinkCfg},
+		},
+	}
+	m := newTestManager(t, cfg)
+
+	require.NoError(t, m.Load())
+	require.Len(t, m.sinks, 1)
+
+	first := m.sinks[0].sink
+	_, ok := first.(*fileSink)
+	assert.True(t, ok)
+
+	m.Emit(&Event{User: "user1"})
+
+	// Reload with same configuration must keep sink
+	cfg.Audit.Sinks = []*config.AuditSinkConfig{
+		{
+			Type: config.AuditSinkTypeFile,
+			File: &config.AuditFileSinkConfig{Path: filepath.Join(dir, "audit.log")},
+		},
+		{Type: config.AuditSinkTypeStdout},
+	}
+
+	require.NoError(t, m.Load())
+	require.Len(t, m.sinks, 2)
+	assert.Same(t, first, m.sinks[0].sink)
+
+	m.Emit(&Event{User: "user2"})
+
+	// Disable audit must close sinks
+	cfg.Audit.Enabled = false
+
+	require.NoError(t, m.Load())
+	assert.Empty(t, m.sinks)
+
+	// Check file content
+	b, err := os.ReadFile(filepath.Join(dir, "audit.log"))
+	require.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSpace(b), []byte("\n"))
+	require.Len(t, lines, 2)
+	assert.Contains(t, string(lines[0]), `"user":"user1"`)
+	assert.Contains(t, string(lines[1]), `"user":"user2"`)
+}
+
+func Test_manager_Load_error(t *testing.T) {
+	dir := t.TempDir()
+	// Create a file where a directory is expected
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), []byte("x"), 0o600))
+
+	cfg := &config.Config{
+		Audit: &config.AuditConfig{
+			Enabled: true,
+			Sinks: []*config.AuditSinkConfig{
+				{
+					Type: config.AuditSinkTypeFile,
+					File: &config.AuditFileSinkConfig{Path: filepath.Join(dir, "file", "audit.log")},
+				},
+			},
+		},
+	}
+	m := newTestManager(t, cfg)
+
+	assert.Error(t, m.Load())
+	assert.Empty(t, m.sinks)
+}
@@ -0,0 +1,201 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+)
+
+const (
+	s3SinkKeyTimeFmt  = "2006/01/02/150405.000000000"
+	s3SinkRandomBytes = 4
+	s3SinkContentType = "application/x-ndjson"
+)
+
+// s3Sink will buffer events and upload them as batched objects in a bucket.
+type s3Sink struct {
+	s3clientManager s3client.Manager
+	logger          log.Logger
+	stopCh          chan struct{}
+	buffer          [][]byte
+	cfg             *config.AuditS3SinkConfig
+	wg              sync.WaitGroup
+	mutex           sync.Mutex
+	flushMutex      sync.Mutex
+	closed          bool
+}
+
+func newS3Sink(cfg *config.AuditS3SinkConfig, s3clientManager s3client.Manager, logger log.Logger) *s3Sink {
+	s := &s3Sink{
+		cfg:             cfg,
+		s3clientManager: s3clientManager,
+		logger:          logger,
+		stopCh:          make(chan struct{}),
+	}
+
+	// Start periodic flush
+	s.wg.Add(1)
+
+	go s.run()
+
+	return s
+}
+
+func (s *s3Sink) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			// Flush
+			err := s.flush()
+			// Check error
+			if err != nil {
+				s.logger.Error(errors.Wrap(err, "cannot flush audit events to s3"))
+			}
+		}
+	}
+}
+
+func (s *s3Sink) Write(line []byte) error {
+	s.mutex.Lock()
+
+	// Check if sink is closed
+	if s.closed {
+		s.mutex.Unlock()
+
+		return errors.New("audit s3 sink is closed")
+	}
+
+	// Copy line as the caller may reuse it
+	s.buffer = append(s.buffer, append([]byte(nil), line...))
+	full := s.cfg.BatchSize > 0 && len(s.buffer) >= s.cfg.BatchSize
+
+	s.mutex.Unlock()
+
+	// Check if batch is full
+	if full {
+		// Flush in background to avoid blocking the request
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+
+			// Flush
+			err := s.flush()
+			// Check error
+			if err != nil {
+				s.logger.Error(errors.Wrap(err, "cannot flush audit events to s3"))
+			}
+		}()
+	}
+
+	return nil
+}
+
+func (s *s3Sink) flush() error {
+	// Only one flush at a time to keep object order
+	s.flushMutex.Lock()
+	defer s.flushMutex.Unlock()
+
+	s.mutex.Lock()
+	lines := s.buffer
+	s.buffer = nil
+	s.mutex.Unlock()
+
+	// Check if there is something to flush
+	if len(lines) == 0 {
+		return nil
+	}
+
+	// Build body
+	body := bytes.Join(lines, nil)
+
+	// Generate random suffix to avoid collisions between instances
+	rb := make([]byte, s3SinkRandomBytes)
+	_, err := rand.Read(rb)
+	// Check error
+	if err != nil {
+		s.requeue(lines)
+
+		return errors.WithStack(err)
+	}
+
+	// Get client
+	cl := s.s3clientManager.GetClientForTarget(s.cfg.Target)
+	// Check if client exists
+	if cl == nil {
+		s.requeue(lines)
+
+		return errors.Errorf("target %s not found", s.cfg.Target)
+	}
+
+	// Create span
+	span := opentracing.GlobalTracer().StartSpan("audit.s3-flush")
+	defer span.Finish()
+
+	// Create context
+	ctx := log.SetLoggerInContext(
+		opentracing.ContextWithSpan(context.Background(), span),
+		s.logger.WithField("target", s.cfg.Target),
+	)
+
+	// Upload
+	_, err = cl.PutObject(ctx, &s3client.PutInput{
+		Key:         s.cfg.Prefix + time.Now().UTC().Format(s3SinkKeyTimeFmt) + "-" + hex.EncodeToString(rb) + ".jsonl",
+		Body:        bytes.NewReader(body),
+		ContentType: s3SinkContentType,
+		ContentSize: int64(len(body)),
+	})
+	// Check error
+	if err != nil {
+		s.requeue(lines)
+
+		return err
+	}
+
+	return nil
+}
+
+// requeue will put back lines in front of buffer after a failed upload.
+func (s *s3Sink) requeue(lines [][]byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.buffer = append(lines, s.buffer...)
+}
+
+func (s *s3Sink) Close() error {
+	s.mutex.Lock()
+	// Check if already closed
+	if s.closed {
+		s.mutex.Unlock()
+
+		return nil
+	}
+
+	s.closed = true
+	s.mutex.Unlock()
+
+	// Stop periodic flush and wait for running flushes
+	close(s.stopCh)
+	s.wg.Wait()
+
+	// Final flush
+	return s.flush()
+}
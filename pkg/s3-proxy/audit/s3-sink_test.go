@@ -0,0 +1,122 @@
+//go:build unit
+
+package audit
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	s3clientmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client/mocks"
+)
+
+func newTestS3Sink(t *testing.T, batchSize int) (*s3Sink, *s3clientmocks.MockClient) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	s3clientMock := s3clientmocks.NewMockClient(ctrl)
+	s3clientManagerMock := s3clientmocks.NewMockManager(ctrl)
+	s3clientManagerMock.EXPECT().GetClientForTarget("target1").AnyTimes().Return(s3clientMock)
+
+	s := newS3Sink(&config.AuditS3SinkConfig{
+		Target:        "target1",
+		Prefix:        "audit/",
+		BatchSize:     batchSize,
+		FlushInterval: time.Hour,
+	}, s3clientManagerMock, log.NewLogger())
+
+	return s, s3clientMock
+}
+
+func Test_s3Sink_FlushOnClose(t *testing.T) {
+	s, s3clientMock := newTestS3Sink(t, 10)
+
+	var body string
+
+	s3clientMock.EXPECT().PutObject(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, input *s3client.PutInput) (*s3client.ResultInfo, error) {
+			assert.True(t, strings.HasPrefix(input.Key, "audit/"))
+			assert.True(t, strings.HasSuffix(input.Key, ".jsonl"))
+			assert.Equal(t, "application/x-ndjson", input.ContentType)
+
+			b, err := io.ReadAll(input.Body)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(b)), input.ContentSize)
+
+			body = string(b)
+
+			return &s3client.ResultInfo{}, nil
+		})
+
+	require.NoError(t, s.Write([]byte("line1\n")))
+	require.NoError(t, s.Write([]byte("line2\n")))
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, "line1\nline2\n", body)
+	// Write after close must fail
+	assert.Error(t, s.Write([]byte("line3\n")))
+	// Close must be idempotent
+	assert.NoError(t, s.Close())
+}
+
+func Test_s3Sink_BatchSize(t *testing.T) {
+	s, s3clientMock := newTestS3Sink(t, 2)
+
+	done := make(chan string, 1)
+
+	s3clientMock.EXPECT().PutObject(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, input *s3client.PutInput) (*s3client.ResultInfo, error) {
+			b, err := io.ReadAll(input.Body)
+			require.NoError(t, err)
+
+			done <- string(b)
+
+			return &s3client.ResultInfo{}, nil
+		})
+
+	require.NoError(t, s.Write([]byte("line1\n")))
+	require.NoError(t, s.Write([]byte("line2\n")))
+
+	select {
+	case body := <-done:
+		assert.Equal(t, "line1\nline2\n", body)
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch not flushed")
+	}
+
+	// Nothing left to flush on close
+	require.NoError(t, s.Close())
+}
+
+func Test_s3Sink_RequeueOnError(t *testing.T) {
+	s, s3clientMock := newTestS3Sink(t, 0)
+
+	gomock.InOrder(
+		s3clientMock.EXPECT().PutObject(gomock.Any(), gomock.Any()).Times(1).
+			Return(nil, errors.New("fake error")),
+		s3clientMock.EXPECT().PutObject(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, input *s3client.PutInput) (*s3client.ResultInfo, error) {
+				b, err := io.ReadAll(input.Body)
+				require.NoError(t, err)
+				assert.Equal(t, "line1\nline2\n", string(b))
+
+				return &s3client.ResultInfo{}, nil
+			}),
+	)
+
+	require.NoError(t, s.Write([]byte("line1\n")))
+	assert.Error(t, s.flush())
+
+	require.NoError(t, s.Write([]byte("line2\n")))
+	require.NoError(t, s.Close())
+}
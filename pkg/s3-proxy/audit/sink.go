@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"io"
+
+	"emperror.dev/errors"
+)
+
+// sink will receive audit events serialized as JSON lines.
+type sink interface {
+	// Write will write a serialized event.
+	Write(line []byte) error
+	// Close will flush pending events and release resources.
+	Close() error
+}
+
+// writerSink will write events to a writer (used for stdout).
+type writerSink struct {
+	w io.Writer
+}
+
+func newWriterSink(w io.Writer) *writerSink {
+	return &writerSink{w: w}
+}
+
+func (s *writerSink) Write(line []byte) error {
+	_, err := s.w.Write(line)
+
+	return errors.WithStack(err)
+}
+
+func (*writerSink) Close() error {
+	return nil
+}
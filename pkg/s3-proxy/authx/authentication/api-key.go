@@ -0,0 +1,189 @@
+package authentication
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/gobwas/glob"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+)
+
+var (
+	errAPIKeyNotFound = errors.New("no api key detected in request")
+	errAPIKeyInvalid  = errors.New("invalid api key")
+)
+
+// getAPIKey will get api key from configured header or query parameter.
+func getAPIKey(r *http.Request, apiKeyCfg *config.APIKeyAuthConfig) string {
+	// Get header value
+	v := r.Header.Get(apiKeyCfg.Header)
+	// Check if it exists or if query parameter is disabled
+	if v != "" || apiKeyCfg.QueryParam == "" {
+		return v
+	}
+
+	return r.URL.Query().Get(apiKeyCfg.QueryParam)
+}
+
+// isAPIKeyMatchingHash will check if key is matching a "sha256:SALT:HEX_DIGEST" hash in constant time.
+func isAPIKeyMatchingHash(key, hash string) bool {
+	// Parse hash
+	sm := config.APIKeyHashRegexp.FindStringSubmatch(hash)
+	if sm == nil {
+		return false
+	}
+
+	// Decode digest
+	expected, err := hex.DecodeString(sm[2])
+	// Check error
+	if err != nil {
+		return false
+	}
+
+	// Compute digest
+	sum := sha256.Sum256([]byte(sm[1] + key))
+
+	return subtle.ConstantTimeCompare(sum[:], expected) == 1
+}
+
+// findAPIKey will find the api key configuration matching the key.
+func findAPIKey(apiKeyCfg *config.APIKeyAuthConfig, key string) *config.APIKeyConfig {
+	for _, k := range apiKeyCfg.GetAllKeys() {
+		if isAPIKeyMatchingHash(key, k.Hash) {
+			return k
+		}
+	}
+
+	return nil
+}
+
+// isAPIKeyPathAllowed will check if request path is in api key path scope.
+func isAPIKeyPathAllowed(k *config.APIKeyConfig, requestPath string) (bool, error) {
+	// No scope means all paths
+	if len(k.Paths) == 0 {
+		return true, nil
+	}
+
+	for _, p := range k.Paths {
+		// Compile a glob pattern for path matching
+		g, err := glob.Compile(p, '/')
+		// Check if error exists
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		// Check if path is matching
+		if g.Match(requestPath) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *service) apiKeyAuthMiddleware(res *config.Resource) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get api key configuration
+			apiKeyCfg := s.cfg.AuthProviders.APIKey[res.Provider]
+			// Get logger from request
+			logEntry := log.GetLoggerFromContext(r.Context())
+			// Get bucket request context from request
+			brctx := bucket.GetBucketRequestContextFromContext(r.Context())
+			// Get response handler
+			resHan := responsehandler.GetResponseHandlerFromContext(r.Context())
+
+			// Get api key
+			key := getAPIKey(r, apiKeyCfg)
+
+			// Initialize error
+			var err error
+
+			// Find key configuration
+			var k *config.APIKeyConfig
+
+			// Check if key exists
+			if key == "" {
+				err = errors.WithStack(errAPIKeyNotFound)
+			} else {
+				k = findAPIKey(apiKeyCfg, key)
+				// Check if key is valid
+				switch {
+				case k == nil:
+					err = errors.WithStack(errAPIKeyInvalid)
+				case !k.ExpiresAt.IsZero() && time.Now().After(k.ExpiresAt):
+					err = errors.WithStack(fmt.Errorf("api key %s expired", k.ID))
+				}
+			}
+
+			// Check error
+			if err != nil {
+				// Check if bucket request context doesn't exist to use local default files
+				if brctx == nil {
+					responsehandler.GeneralUnauthorizedError(r, w, s.cfgManager, err)
+				} else {
+					resHan.UnauthorizedError(brctx.LoadFileContent, err)
+				}
+
+				return
+			}
+
+			// Create API key user
+			auser := &models.APIKeyUser{
+				KeyID:  k.ID,
+				Owner:  k.Owner,
+				Email:  k.Email,
+				Groups: k.Groups,
+			}
+
+			// Add user to request context by creating a new context
+			ctx := models.SetAuthenticatedUserInContext(r.Context(), auser)
+			// Create new request with new context
+			r = r.WithContext(ctx)
+
+			// Update response handler to have the latest context values
+			resHan.UpdateRequestAndResponse(r, w)
+
+			// Check path scope
+			allowed, err := isAPIKeyPathAllowed(k, r.URL.Path)
+			// Check error
+			if err != nil {
+				// Check if bucket request context doesn't exist to use local default files
+				if brctx == nil {
+					responsehandler.GeneralInternalServerError(r, w, s.cfgManager, err)
+				} else {
+					resHan.InternalServerError(brctx.LoadFileContent, err)
+				}
+
+				return
+			}
+			// Check if path is allowed
+			if !allowed {
+				// Create error
+				err = errors.WithStack(fmt.Errorf("api key %s not allowed on path %s", k.ID, r.URL.Path))
+				// Check if bucket request context doesn't exist to use local default files
+				if brctx == nil {
+					responsehandler.GeneralForbiddenError(r, w, s.cfgManager, err)
+				} else {
+					resHan.ForbiddenError(brctx.LoadFileContent, err)
+				}
+
+				return
+			}
+
+			logEntry.Infof("API key user %s authenticated with key %s", auser.GetIdentifier(), k.ID)
+			s.metricsCl.IncAuthenticated("api-key", res.Provider)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
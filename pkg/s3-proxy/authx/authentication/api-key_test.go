@@ -0,0 +1,118 @@
+//go:build unit
+
+package authentication
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+func hashAPIKey(salt, key string) string {
+	sum := sha256.Sum256([]byte(salt + key))
+
+	return "sha256:" + salt + ":" + hex.EncodeToString(sum[:])
+}
+
+func Test_isAPIKeyMatchingHash(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		hash string
+		want bool
+	}{
+		{name: "matching", key: "secret-key", hash: hashAPIKey("salt", "secret-key"), want: true},
+		{name: "wrong key", key: "other-key", hash: hashAPIKey("salt", "secret-key")},
+		{name: "wrong salt", key: "secret-key", hash: "sha256:other:" + hashAPIKey("salt", "secret-key")[12:]},
+		{name: "invalid hash", key: "secret-key", hash: "secret-key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isAPIKeyMatchingHash(tt.key, tt.hash))
+		})
+	}
+}
+
+func Test_findAPIKey(t *testing.T) {
+	k1 := &config.APIKeyConfig{ID: "k1", Hash: hashAPIKey("s1", "key1")}
+	k2 := &config.APIKeyConfig{ID: "k2", Hash: hashAPIKey("s2", "key2")}
+	cfg := &config.APIKeyAuthConfig{Keys: []*config.APIKeyConfig{k1}, FileKeys: []*config.APIKeyConfig{k2}}
+
+	assert.Same(t, k1, findAPIKey(cfg, "key1"))
+	assert.Same(t, k2, findAPIKey(cfg, "key2"))
+	assert.Nil(t, findAPIKey(cfg, "key3"))
+}
+
+func Test_getAPIKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    *config.APIKeyAuthConfig
+		header string
+		url    string
+		want   string
+	}{
+		{
+			name:   "from header",
+			cfg:    &config.APIKeyAuthConfig{Header: "X-API-Key", QueryParam: "api_key"},
+			header: "key1",
+			url:    "/?api_key=key2",
+			want:   "key1",
+		},
+		{
+			name: "from query param",
+			cfg:  &config.APIKeyAuthConfig{Header: "X-API-Key", QueryParam: "api_key"},
+			url:  "/?api_key=key2",
+			want: "key2",
+		},
+		{
+			name: "query param disabled",
+			cfg:  &config.APIKeyAuthConfig{Header: "X-API-Key"},
+			url:  "/?api_key=key2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.header != "" {
+				r.Header.Set("X-API-Key", tt.header)
+			}
+
+			assert.Equal(t, tt.want, getAPIKey(r, tt.cfg))
+		})
+	}
+}
+
+func Test_isAPIKeyPathAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   []string
+		path    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "no scope", path: "/any/file", want: true},
+		{name: "matching scope", paths: []string{"/other/**", "/ci/**"}, path: "/ci/artifacts/file", want: true},
+		{name: "not matching scope", paths: []string{"/ci/*"}, path: "/ci/artifacts/file"},
+		{name: "invalid scope", paths: []string{"/ci/["}, path: "/ci/file", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := isAPIKeyPathAllowed(&config.APIKeyConfig{Paths: tt.paths}, tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -2,7 +2,10 @@ package authentication
 
 import (
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"emperror.dev/errors"
 	"github.com/thoas/go-funk"
@@ -12,6 +15,7 @@ import (
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
 	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/utils/passwordhash"
 )
 
 func (s *service) basicAuthMiddleware(res *config.Resource) func(http.Handler) http.Handler {
@@ -19,7 +23,7 @@ func (s *service) basicAuthMiddleware(res *config.Resource) func(http.Handler) h
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get data
 			basicConfig := s.cfg.AuthProviders.Basic[res.Provider]
-			basicAuthUserConfigList := res.Basic.Credentials
+			basicAuthUserConfigList := res.Basic.GetAllCredentials()
 			// Get logger from request
 			logEntry := log.GetLoggerFromContext(r.Context())
 			// Get bucket request context from request
@@ -44,6 +48,42 @@ func (s *service) basicAuthMiddleware(res *config.Resource) func(http.Handler) h
 				return
 			}
 
+			// Get client ip
+			clientIP := getClientIP(r)
+			// Get brute force protection configuration
+			bfCfg := basicConfig.BruteForceProtection
+			// Check if brute force protection is enabled
+			bfEnabled := bfCfg != nil && bfCfg.Enabled && s.lockoutManager != nil
+
+			// Check if user or client ip is locked out
+			if bfEnabled {
+				lck := s.lockoutManager.Check(res.Provider, username, clientIP)
+				// Check if lockout exists
+				if lck != nil {
+					// Create error
+					err := fmt.Errorf("%s %s is locked out until %s", lck.Scope, lck.Value, lck.LockedUntil.Format(time.RFC3339))
+					// Add stack trace
+					err = errors.WithStack(err)
+
+					logEntry.WithFields(securityEventFields(securityEventLockedOutRequest, res.Provider, username, clientIP)).
+						Warnf("Basic auth request rejected: %s", err.Error())
+					s.metricsCl.IncLockedOutRequests(res.Provider, lck.Scope)
+
+					// Add header to indicate when a new attempt can be done
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(lck.LockedUntil).Seconds()))))
+					// Add header for basic auth realm
+					w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, basicConfig.Realm))
+					// Check if bucket request context doesn't exist to use local default files
+					if brctx == nil {
+						responsehandler.GeneralUnauthorizedError(r, w, s.cfgManager, err)
+					} else {
+						resHan.UnauthorizedError(brctx.LoadFileContent, err)
+					}
+
+					return
+				}
+			}
+
 			// Create Basic auth user
 			buser := &models.BasicAuthUser{Username: username}
 
@@ -66,6 +106,8 @@ func (s *service) basicAuthMiddleware(res *config.Resource) func(http.Handler) h
 				err := fmt.Errorf("username %s not found in authorized users", username)
 				// Add stack trace
 				err = errors.WithStack(err)
+				// Register failure
+				s.registerBasicAuthFailure(logEntry, res.Provider, bfCfg, bfEnabled, username, clientIP)
 				// Add header for basic auth realm
 				w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, basicConfig.Realm))
 				// Check if bucket request context doesn't exist to use local default files
@@ -80,11 +122,19 @@ func (s *service) basicAuthMiddleware(res *config.Resource) func(http.Handler) h
 
 			// Check password
 			//nolint: forcetypeassert // Ignore this
-			if cred.(*config.BasicAuthUserConfig).Password.Value == "" || cred.(*config.BasicAuthUserConfig).Password.Value != password {
+			valid, err := isBasicAuthPasswordValid(cred.(*config.BasicAuthUserConfig), password)
+			// Check error
+			if err != nil {
+				logEntry.Error(err)
+			}
+
+			if !valid {
 				// Create error
 				err := fmt.Errorf("username %s not authorized", username)
 				// Add stack trace
 				err = errors.WithStack(err)
+				// Register failure
+				s.registerBasicAuthFailure(logEntry, res.Provider, bfCfg, bfEnabled, username, clientIP)
 				// Add header for basic auth realm
 				w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, basicConfig.Realm))
 				// Check if bucket request context doesn't exist to use local default files
@@ -97,6 +147,11 @@ func (s *service) basicAuthMiddleware(res *config.Resource) func(http.Handler) h
 				return
 			}
 
+			// Forget previous failures
+			if bfEnabled {
+				s.lockoutManager.RegisterSuccess(res.Provider, username)
+			}
+
 			logEntry.Infof("Basic auth user %s authenticated", buser.GetIdentifier())
 			s.metricsCl.IncAuthenticated("basic-auth", res.Provider)
 
@@ -104,3 +159,55 @@ func (s *service) basicAuthMiddleware(res *config.Resource) func(http.Handler) h
 		})
 	}
 }
+
+// registerBasicAuthFailure will log and count a failed basic authentication.
+// Failure is registered in lockout manager when brute force protection is enabled.
+func (s *service) registerBasicAuthFailure(
+	logEntry log.Logger,
+	providerKey string,
+	bfCfg *config.BruteForceProtectionConfig,
+	bfEnabled bool,
+	username, clientIP string,
+) {
+	logEntry.WithFields(securityEventFields(securityEventAuthenticationFailure, providerKey, username, clientIP)).
+		Warnf("Basic auth failed for user %s", username)
+	s.metricsCl.IncAuthenticationFailures("basic-auth", providerKey)
+
+	// Check if brute force protection is enabled
+	if !bfEnabled {
+		return
+	}
+
+	// Register failure
+	lcks := s.lockoutManager.RegisterFailure(providerKey, bfCfg, username, clientIP)
+	// Loop over started lockouts
+	for _, lck := range lcks {
+		fields := securityEventFields(securityEventLockoutStarted, providerKey, username, clientIP)
+		fields["lockout_scope"] = lck.Scope
+		fields["locked_until"] = lck.LockedUntil
+
+		logEntry.WithFields(fields).Warnf("Basic auth %s %s locked out until %s", lck.Scope, lck.Value, lck.LockedUntil.Format(time.RFC3339))
+		s.metricsCl.IncLockouts(providerKey, lck.Scope)
+	}
+}
+
+// isBasicAuthPasswordValid will check in constant time that password matches user credential.
+// Password hash is used when declared, otherwise plain text password is used.
+func isBasicAuthPasswordValid(cred *config.BasicAuthUserConfig, password string) (bool, error) {
+	// Check if password hash is declared
+	if cred.PasswordHash != nil {
+		// Check that hash isn't empty
+		if cred.PasswordHash.Value == "" {
+			return false, nil
+		}
+
+		return passwordhash.Verify(cred.PasswordHash.Value, password)
+	}
+
+	// Check that password isn't empty
+	if cred.Password == nil || cred.Password.Value == "" {
+		return false, nil
+	}
+
+	return passwordhash.VerifyPlain(cred.Password.Value, password), nil
+}
@@ -0,0 +1,82 @@
+//go:build unit
+
+package authentication
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+func Test_isBasicAuthPasswordValid(t *testing.T) {
+	sha256Hash := "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5"
+
+	tests := []struct {
+		cred     *config.BasicAuthUserConfig
+		name     string
+		password string
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name:     "plain text password matching",
+			cred:     &config.BasicAuthUserConfig{Password: &config.CredentialConfig{Value: "pass"}},
+			password: "pass",
+			want:     true,
+		},
+		{
+			name:     "plain text password not matching",
+			cred:     &config.BasicAuthUserConfig{Password: &config.CredentialConfig{Value: "pass"}},
+			password: "pass2",
+		},
+		{
+			name:     "empty plain text password",
+			cred:     &config.BasicAuthUserConfig{Password: &config.CredentialConfig{}},
+			password: "",
+		},
+		{
+			name:     "password hash matching",
+			cred:     &config.BasicAuthUserConfig{PasswordHash: &config.CredentialConfig{Value: sha256Hash}},
+			password: "Hello world!",
+			want:     true,
+		},
+		{
+			name:     "password hash not matching",
+			cred:     &config.BasicAuthUserConfig{PasswordHash: &config.CredentialConfig{Value: sha256Hash}},
+			password: "Hello world",
+		},
+		{
+			name: "password hash is used before plain text password",
+			cred: &config.BasicAuthUserConfig{
+				Password:     &config.CredentialConfig{Value: "pass"},
+				PasswordHash: &config.CredentialConfig{Value: sha256Hash},
+			},
+			password: "pass",
+		},
+		{
+			name:     "empty password hash",
+			cred:     &config.BasicAuthUserConfig{PasswordHash: &config.CredentialConfig{}},
+			password: "",
+		},
+		{
+			name:     "unsupported password hash",
+			cred:     &config.BasicAuthUserConfig{PasswordHash: &config.CredentialConfig{Value: "$apr1$salt$hash"}},
+			password: "pass",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := isBasicAuthPasswordValid(tt.cred, tt.password)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
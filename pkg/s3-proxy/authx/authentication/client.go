@@ -7,22 +7,42 @@ import (
 
 	oidc "github.com/coreos/go-oidc/v3/oidc"
 
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/lockout"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/session"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics"
 )
 
 type Client interface {
-	// Middleware will redirect authentication to basic auth or OIDC depending on request path and resources declared
+	// Middleware will redirect authentication to basic auth, OIDC, header, JWT, API key, mTLS, LDAP or HMAC depending on request path and resources declared
 	Middleware(resources []*config.Resource) func(http.Handler) http.Handler
-	// OIDCEndpoints will set OpenID Connect endpoints for authentication and callback
+	// OIDCEndpoints will set OpenID Connect endpoints for authentication, callback and logout
 	OIDCEndpoints(providerKey string, oidcCfg *config.OIDCAuthConfig, mux chi.Router) error
+	// LoadJWTProvider will load JWT provider keys in order to verify bearer tokens
+	LoadJWTProvider(providerKey string, jwtCfg *config.JWTAuthConfig) error
+	// LoadLDAPProvider will prepare LDAP provider in order to authenticate users
+	LoadLDAPProvider(providerKey string, ldapCfg *config.LDAPAuthConfig) error
+	// LoadHMACProvider will prepare HMAC provider in order to verify signed requests
+	LoadHMACProvider(providerKey string, hmacCfg *config.HMACAuthConfig)
 }
 
-func NewAuthenticationService(cfg *config.Config, cfgManager config.Manager, metricsCl metrics.Client) Client {
+func NewAuthenticationService(
+	cfg *config.Config,
+	cfgManager config.Manager,
+	metricsCl metrics.Client,
+	sessionManager session.Manager,
+	lockoutManager lockout.Manager,
+) Client {
 	return &service{
-		allVerifiers: map[string]*oidc.IDTokenVerifier{},
-		cfg:          cfg,
-		cfgManager:   cfgManager,
-		metricsCl:    metricsCl,
+		allVerifiers:           map[string]*oidc.IDTokenVerifier{},
+		allJWTVerifiers:        map[string]*jwtVerifier{},
+		allLDAPAuthenticators:  map[string]*ldapAuthenticator{},
+		allHMACVerifiers:       map[string]*hmacVerifier{},
+		allOIDCSessionHandlers: map[string]*oidcSessionHandler{},
+		cfg:                    cfg,
+		cfgManager:             cfgManager,
+		metricsCl:              metricsCl,
+		sessionManager:         sessionManager,
+		lockoutManager:         lockoutManager,
 	}
 }
@@ -0,0 +1,296 @@
+package authentication
+
+import (
+	"bytes"
+	"container/heap"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/hmacsign"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+)
+
+var (
+	errHMACSignatureNotFound = errors.New("no hmac signature detected in request")
+	errHMACSignatureInvalid  = errors.New("invalid hmac signature")
+)
+
+// hmacVerifier will verify signed requests for a HMAC provider.
+// Used signatures are kept in memory of this process until the end of their replay window.
+type hmacVerifier struct {
+	cfg         *config.HMACAuthConfig
+	now         func() time.Time
+	seen        map[string]time.Time
+	expirations hmacReplayQueue
+	mutex       sync.Mutex
+}
+
+// hmacReplayEntry is a used signature with the end of its replay window.
+type hmacReplayEntry struct {
+	expiresAt time.Time
+	signature string
+}
+
+// hmacReplayQueue is a min heap of used signatures ordered by expiration time.
+type hmacReplayQueue []*hmacReplayEntry
+
+func (q hmacReplayQueue) Len() int { return len(q) }
+
+func (q hmacReplayQueue) Less(i, j int) bool { return q[i].expiresAt.Before(q[j].expiresAt) }
+
+func (q hmacReplayQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *hmacReplayQueue) Push(x any) {
+	*q = append(*q, x.(*hmacReplayEntry)) //nolint:forcetypeassert // Only entries are pushed
+}
+
+func (q *hmacReplayQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+
+	return item
+}
+
+// LoadHMACProvider will prepare a HMAC provider in order to verify signed requests.
+func (s *service) LoadHMACProvider(providerKey string, hmacCfg *config.HMACAuthConfig) {
+	s.allHMACVerifiers[providerKey] = &hmacVerifier{
+		cfg:  hmacCfg,
+		now:  time.Now,
+		seen: map[string]time.Time{},
+	}
+}
+
+// hasHMACSignature will check if request contains a HMAC Authorization header.
+func hasHMACSignature(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Authorization"), hmacsign.Algorithm+" ")
+}
+
+// findKey will find the key configuration matching the key identifier.
+func (v *hmacVerifier) findKey(keyID string) *config.HMACKeyConfig {
+	for _, k := range v.cfg.Keys {
+		if k.ID == keyID {
+			return k
+		}
+	}
+
+	return nil
+}
+
+// verify will verify request signature and return the key used to sign it.
+// Signature is checked with the claimed payload hash before reading the body.
+// Then, when payload is signed, body is read within the configured maximum size, checked and replaced.
+func (v *hmacVerifier) verify(r *http.Request) (*config.HMACKeyConfig, error) {
+	// Check if signature exists
+	if !hasHMACSignature(r) {
+		return nil, errors.WithStack(errHMACSignatureNotFound)
+	}
+
+	// Parse authorization
+	auth, err := hmacsign.ParseAuthorization(r.Header.Get("Authorization"))
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Find key
+	k := v.findKey(auth.KeyID)
+	// Check if key exists
+	if k == nil {
+		return nil, errors.WithStack(fmt.Errorf("hmac key %s not found", auth.KeyID))
+	}
+
+	// Check that required headers are signed
+	for _, h := range append(slices.Clone(hmacsign.RequiredSignedHeaders), v.cfg.SignedHeaders...) {
+		if !slices.Contains(auth.SignedHeaders, strings.ToLower(h)) {
+			return nil, errors.WithStack(fmt.Errorf("header %s must be signed", strings.ToLower(h)))
+		}
+	}
+
+	// Parse date
+	date := r.Header.Get(hmacsign.DateHeader)
+	t, err := time.Parse(hmacsign.TimeFormat, date)
+	// Check error
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid hmac request date")
+	}
+
+	// Check replay window
+	if diff := v.now().Sub(t); diff > v.cfg.MaxClockSkew || diff < -v.cfg.MaxClockSkew {
+		return nil, errors.WithStack(fmt.Errorf("hmac request date %s is outside of the allowed window", date))
+	}
+
+	// Check that nonce exists as it makes signature unique for replay detection
+	if r.Header.Get(hmacsign.NonceHeader) == "" {
+		return nil, errors.New("hmac request nonce is missing")
+	}
+
+	// Get payload hash
+	payloadHash := r.Header.Get(hmacsign.ContentSHA256Header)
+	// Check if unsigned payload is allowed
+	if payloadHash == hmacsign.UnsignedPayload && !v.cfg.AllowUnsignedPayload {
+		return nil, errors.New("hmac unsigned payload isn't allowed")
+	}
+
+	// Compute signature
+	expected := hmacsign.Signature(k.Secret.Value, date, hmacsign.CanonicalRequest(r, auth.SignedHeaders, payloadHash))
+	// Check signature
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(auth.Signature)) != 1 {
+		return nil, errors.WithStack(errHMACSignatureInvalid)
+	}
+
+	// Check replay
+	if v.cfg.RejectReplay == nil || *v.cfg.RejectReplay {
+		err = v.checkReplay(auth.Signature, t)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Check if payload is signed
+	if payloadHash != hmacsign.UnsignedPayload {
+		err = v.checkPayload(r, payloadHash)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+// checkPayload will read request body within the maximum size, check its hash and replace it for next handlers.
+func (v *hmacVerifier) checkPayload(r *http.Request, payloadHash string) error {
+	var body []byte
+	// Check if body exists
+	if r.Body != nil {
+		var err error
+		// Read body with one more byte to detect too large bodies
+		body, err = io.ReadAll(io.LimitReader(r.Body, v.cfg.MaxBodySize+1))
+		// Check error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		_ = r.Body.Close()
+
+		// Check size
+		if int64(len(body)) > v.cfg.MaxBodySize {
+			return errors.Errorf("hmac signed body is larger than %d bytes", v.cfg.MaxBodySize)
+		}
+
+		// Replace body for next handlers
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	// Check body hash
+	if subtle.ConstantTimeCompare([]byte(hmacsign.HashPayload(body)), []byte(payloadHash)) != 1 {
+		return errors.New("hmac payload hash isn't matching request body")
+	}
+
+	return nil
+}
+
+// checkReplay will reject signatures already used in the replay window.
+func (v *hmacVerifier) checkReplay(signature string, t time.Time) error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	// Get now
+	now := v.now()
+	// Clean expired signatures, oldest expirations are first
+	for len(v.expirations) != 0 && now.After(v.expirations[0].expiresAt) {
+		entry := heap.Pop(&v.expirations).(*hmacReplayEntry) //nolint:forcetypeassert // Only entries are pushed
+		delete(v.seen, entry.signature)
+	}
+
+	// Check if signature was already used
+	if _, ok := v.seen[signature]; ok {
+		return errors.New("hmac signature already used")
+	}
+
+	// Save signature until the end of the replay window
+	expiresAt := t.Add(v.cfg.MaxClockSkew)
+	v.seen[signature] = expiresAt
+	heap.Push(&v.expirations, &hmacReplayEntry{signature: signature, expiresAt: expiresAt})
+
+	return nil
+}
+
+func (s *service) hmacAuthMiddleware(res *config.Resource) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get logger from request
+			logEntry := log.GetLoggerFromContext(r.Context())
+			// Get bucket request context from request
+			brctx := bucket.GetBucketRequestContextFromContext(r.Context())
+			// Get response handler
+			resHan := responsehandler.GetResponseHandlerFromContext(r.Context())
+
+			// Get verifier
+			verifier := s.allHMACVerifiers[res.Provider]
+			// Check if verifier exists
+			if verifier == nil {
+				err := errors.Errorf("hmac provider %s not loaded", res.Provider)
+				// Check if bucket request context doesn't exist to use local default files
+				if brctx == nil {
+					responsehandler.GeneralInternalServerError(r, w, s.cfgManager, err)
+				} else {
+					resHan.InternalServerError(brctx.LoadFileContent, err)
+				}
+
+				return
+			}
+
+			// Verify request
+			k, err := verifier.verify(r)
+			// Check error
+			if err != nil {
+				// Check if bucket request context doesn't exist to use local default files
+				if brctx == nil {
+					responsehandler.GeneralUnauthorizedError(r, w, s.cfgManager, err)
+				} else {
+					resHan.UnauthorizedError(brctx.LoadFileContent, err)
+				}
+
+				return
+			}
+
+			// Create HMAC user
+			huser := &models.HMACUser{
+				KeyID:  k.ID,
+				Owner:  k.Owner,
+				Email:  k.Email,
+				Groups: k.Groups,
+			}
+
+			// Add user to request context by creating a new context
+			ctx := models.SetAuthenticatedUserInContext(r.Context(), huser)
+			// Create new request with new context
+			r = r.WithContext(ctx)
+
+			// Update response handler to have the latest context values
+			resHan.UpdateRequestAndResponse(r, w)
+
+			logEntry.Infof("HMAC user %s authenticated with key %s", huser.GetIdentifier(), k.ID)
+			s.metricsCl.IncAuthenticated("hmac", res.Provider)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,378 @@
+//go:build unit
+
+package authentication
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/hmacsign"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+func Test_hmacVerifier_verify(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	key := &config.HMACKeyConfig{ID: "key1", Owner: "backup", Secret: &config.CredentialConfig{Value: "secret"}}
+
+	newSignedRequest := func(signer *hmacsign.Signer, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPut, "http://localhost/mount/file.txt?param=value", strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/plain")
+
+		err := signer.Sign(req)
+		require.NoError(t, err)
+
+		return req
+	}
+	newSigner := func(keyID, secret string, signedAt time.Time) *hmacsign.Signer {
+		s := hmacsign.NewSigner(keyID, secret)
+		s.Now = func() time.Time { return signedAt }
+
+		return s
+	}
+
+	tests := []struct {
+		cfg     *config.HMACAuthConfig
+		req     func() *http.Request
+		name    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			req:  func() *http.Request { return newSignedRequest(newSigner("key1", "secret", now), "content") },
+		},
+		{
+			name: "valid at the end of replay window",
+			req: func() *http.Request {
+				return newSignedRequest(newSigner("key1", "secret", now.Add(-time.Minute)), "content")
+			},
+		},
+		{
+			name: "no signature",
+			req: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "http://localhost/mount/file.txt", nil)
+			},
+			wantErr: "no hmac signature detected in request",
+		},
+		{
+			name: "invalid authorization",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "http://localhost/mount/file.txt", nil)
+				req.Header.Set("Authorization", hmacsign.Algorithm+" Credential=key1")
+
+				return req
+			},
+			wantErr: "invalid hmac authorization header",
+		},
+		{
+			name:    "unknown key",
+			req:     func() *http.Request { return newSignedRequest(newSigner("key2", "secret", now), "content") },
+			wantErr: "hmac key key2 not found",
+		},
+		{
+			name:    "wrong secret",
+			req:     func() *http.Request { return newSignedRequest(newSigner("key1", "other", now), "content") },
+			wantErr: "invalid hmac signature",
+		},
+		{
+			name: "required header not signed",
+			cfg:  &config.HMACAuthConfig{SignedHeaders: []string{"Content-Type"}},
+			req: func() *http.Request {
+				return newSignedRequest(newSigner("key1", "secret", now), "content")
+			},
+			wantErr: "header content-type must be signed",
+		},
+		{
+			name: "required header signed",
+			cfg:  &config.HMACAuthConfig{SignedHeaders: []string{"Content-Type"}},
+			req: func() *http.Request {
+				s := newSigner("key1", "secret", now)
+				s.Headers = []string{"Content-Type"}
+
+				return newSignedRequest(s, "content")
+			},
+		},
+		{
+			name: "modified signed header",
+			req: func() *http.Request {
+				s := newSigner("key1", "secret", now)
+				s.Headers = []string{"Content-Type"}
+				req := newSignedRequest(s, "content")
+				req.Header.Set("Content-Type", "application/json")
+
+				return req
+			},
+			wantErr: "invalid hmac signature",
+		},
+		{
+			name: "modified query string",
+			req: func() *http.Request {
+				req := newSignedRequest(newSigner("key1", "secret", now), "content")
+				req.URL.RawQuery = "param=other"
+
+				return req
+			},
+			wantErr: "invalid hmac signature",
+		},
+		{
+			name: "invalid date",
+			req: func() *http.Request {
+				req := newSignedRequest(newSigner("key1", "secret", now), "content")
+				req.Header.Set(hmacsign.DateHeader, "tomorrow")
+
+				return req
+			},
+			wantErr: `invalid hmac request date: parsing time "tomorrow" as "20060102T150405Z": cannot parse "tomorrow" as "2006"`,
+		},
+		{
+			name: "expired date",
+			req: func() *http.Request {
+				return newSignedRequest(newSigner("key1", "secret", now.Add(-2*time.Minute)), "content")
+			},
+			wantErr: "hmac request date 20260102T030205Z is outside of the allowed window",
+		},
+		{
+			name: "date in the future",
+			req: func() *http.Request {
+				return newSignedRequest(newSigner("key1", "secret", now.Add(2*time.Minute)), "content")
+			},
+			wantErr: "hmac request date 20260102T030605Z is outside of the allowed window",
+		},
+		{
+			name: "modified body",
+			req: func() *http.Request {
+				req := newSignedRequest(newSigner("key1", "secret", now), "content")
+				req.Body = io.NopCloser(strings.NewReader("other"))
+
+				return req
+			},
+			wantErr: "hmac payload hash isn't matching request body",
+		},
+		{
+			name: "modified body with invalid signature isn't read",
+			req: func() *http.Request {
+				req := newSignedRequest(newSigner("key1", "other", now), "content")
+				req.Body = io.NopCloser(&failingReader{})
+
+				return req
+			},
+			wantErr: "invalid hmac signature",
+		},
+		{
+			name: "body too large",
+			cfg:  &config.HMACAuthConfig{MaxBodySize: 3},
+			req: func() *http.Request {
+				return newSignedRequest(newSigner("key1", "secret", now), "content")
+			},
+			wantErr: "hmac signed body is larger than 3 bytes",
+		},
+		{
+			name: "body at maximum size",
+			cfg:  &config.HMACAuthConfig{MaxBodySize: int64(len("content"))},
+			req: func() *http.Request {
+				return newSignedRequest(newSigner("key1", "secret", now), "content")
+			},
+		},
+		{
+			name: "missing nonce",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPut, "http://localhost/mount/file.txt", strings.NewReader("content"))
+				date := now.Format(hmacsign.TimeFormat)
+				payloadHash := hmacsign.HashPayload([]byte("content"))
+				signedHeaders := hmacsign.RequiredSignedHeaders
+				req.Header.Set(hmacsign.DateHeader, date)
+				req.Header.Set(hmacsign.ContentSHA256Header, payloadHash)
+				req.Header.Set("Authorization", (&hmacsign.Authorization{
+					KeyID:         "key1",
+					SignedHeaders: signedHeaders,
+					Signature:     hmacsign.Signature("secret", date, hmacsign.CanonicalRequest(req, signedHeaders, payloadHash)),
+				}).String())
+
+				return req
+			},
+			wantErr: "hmac request nonce is missing",
+		},
+		{
+			name: "nonce not signed",
+			req: func() *http.Request {
+				req := newSignedRequest(newSigner("key1", "secret", now), "content")
+				auth, err := hmacsign.ParseAuthorization(req.Header.Get("Authorization"))
+				require.NoError(t, err)
+
+				auth.SignedHeaders = []string{"host", "x-s3p-content-sha256", "x-s3p-date"}
+				req.Header.Set("Authorization", auth.String())
+
+				return req
+			},
+			wantErr: "header x-s3p-nonce must be signed",
+		},
+		{
+			name: "unsigned payload not allowed",
+			req: func() *http.Request {
+				s := newSigner("key1", "secret", now)
+				s.UnsignedPayload = true
+
+				return newSignedRequest(s, "content")
+			},
+			wantErr: "hmac unsigned payload isn't allowed",
+		},
+		{
+			name: "unsigned payload allowed",
+			cfg:  &config.HMACAuthConfig{AllowUnsignedPayload: true},
+			req: func() *http.Request {
+				s := newSigner("key1", "secret", now)
+				s.UnsignedPayload = true
+
+				return newSignedRequest(s, "content")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if cfg == nil {
+				cfg = &config.HMACAuthConfig{}
+			}
+
+			cfg.Keys = []*config.HMACKeyConfig{key}
+			cfg.MaxClockSkew = time.Minute
+
+			if cfg.MaxBodySize == 0 {
+				cfg.MaxBodySize = config.DefaultHMACMaxBodySize
+			}
+
+			v := &hmacVerifier{cfg: cfg, now: func() time.Time { return now }, seen: map[string]time.Time{}}
+			req := tt.req()
+
+			got, err := v.verify(req)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Same(t, key, got)
+
+			// Check that body is still readable
+			b, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "content", string(b))
+		})
+	}
+}
+
+// failingReader is a body failing when it is read.
+type failingReader struct{}
+
+func (*failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("body must not be read")
+}
+
+func Test_hmacVerifier_checkReplay(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	v := &hmacVerifier{
+		cfg:  &config.HMACAuthConfig{MaxClockSkew: time.Minute},
+		now:  func() time.Time { return now },
+		seen: map[string]time.Time{},
+	}
+
+	require.NoError(t, v.checkReplay("sig1", now))
+	require.NoError(t, v.checkReplay("sig2", now.Add(-30*time.Second)))
+	assert.EqualError(t, v.checkReplay("sig1", now), "hmac signature already used")
+
+	// Move after sig2 replay window
+	now = now.Add(45 * time.Second)
+
+	require.NoError(t, v.checkReplay("sig2", now))
+	assert.EqualError(t, v.checkReplay("sig1", now), "hmac signature already used")
+
+	// Move after all replay windows
+	now = now.Add(2 * time.Minute)
+
+	require.NoError(t, v.checkReplay("sig3", now))
+	assert.Len(t, v.seen, 1)
+	assert.Len(t, v.expirations, 1)
+}
+
+func Test_hmacVerifier_verify_Replay(t *testing.T) {
+	tests := []struct {
+		rejectReplay *bool
+		name         string
+		wantErr      string
+	}{
+		{
+			name:    "rejected by default",
+			wantErr: "hmac signature already used",
+		},
+		{
+			name:         "rejected",
+			rejectReplay: new(true),
+			wantErr:      "hmac signature already used",
+		},
+		{
+			name:         "allowed when disabled",
+			rejectReplay: new(false),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+			cfg := &config.HMACAuthConfig{
+				Keys: []*config.HMACKeyConfig{
+					{ID: "key1", Owner: "alice", Secret: &config.CredentialConfig{Value: "secret1"}},
+				},
+				MaxClockSkew: time.Minute,
+				MaxBodySize:  config.DefaultHMACMaxBodySize,
+				RejectReplay: tt.rejectReplay,
+			}
+			v := &hmacVerifier{cfg: cfg, now: func() time.Time { return now }, seen: map[string]time.Time{}}
+
+			signer := hmacsign.NewSigner("key1", "secret1")
+			signer.Now = func() time.Time { return now }
+
+			signed := httptest.NewRequest(http.MethodGet, "http://localhost/file", nil)
+			require.NoError(t, signer.Sign(signed))
+
+			// send will send a copy of the signed request
+			send := func() error {
+				req := httptest.NewRequest(http.MethodGet, "http://localhost/file", nil)
+				req.Header = signed.Header.Clone()
+
+				_, err := v.verify(req)
+
+				return err
+			}
+
+			require.NoError(t, send())
+
+			// Replay it
+			err := send()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_hasHMACSignature(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/file", nil)
+	assert.False(t, hasHMACSignature(req))
+
+	req.Header.Set("Authorization", "Bearer token")
+	assert.False(t, hasHMACSignature(req))
+
+	req.Header.Set("Authorization", hmacsign.Algorithm+" Credential=key1")
+	assert.True(t, hasHMACSignature(req))
+}
@@ -0,0 +1,340 @@
+package authentication
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	jose "github.com/go-jose/go-jose/v4"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+const (
+	// jwtMinKeysRefreshInterval is the minimal duration between two JWKS refresh attempts.
+	// It limits refreshes triggered by unknown key ids, which are chosen by clients.
+	jwtMinKeysRefreshInterval = 10 * time.Second
+	// jwtKeysRefreshKey is the singleflight key used to share JWKS refreshes.
+	jwtKeysRefreshKey = "refresh"
+	// jwtJWKSFetchTimeout is the JWKS URL fetch timeout.
+	jwtJWKSFetchTimeout = 10 * time.Second
+	// jwtJWKSMaxSize is the maximum JWKS document size.
+	jwtJWKSMaxSize = 1 << 20
+)
+
+var (
+	errJWTNoMatchingKey   = errors.New("no key found matching token key id")
+	errJWTKeysUnavailable = errors.New("jwt keys unavailable")
+)
+
+// jwksFetcher will load a JWKS document.
+type jwksFetcher func(ctx context.Context) (*jose.JSONWebKeySet, error)
+
+// jwtKeySet contains static keys and keys loaded from JWKS sources.
+// JWKS sources are fetched without holding the mutex, so requests using known keys are never blocked by a refresh.
+type jwtKeySet struct {
+	lastFetch       time.Time
+	lastAttempt     time.Time
+	now             func() time.Time
+	fetchers        []jwksFetcher
+	staticKeys      []jose.JSONWebKey
+	fetchedKeys     []jose.JSONWebKey
+	refreshGroup    singleflight.Group
+	refreshInterval time.Duration
+	mutex           sync.Mutex
+}
+
+// newJWTKeySet will create a key set from configuration and load remote keys once.
+func newJWTKeySet(ctx context.Context, jwtCfg *config.JWTAuthConfig) (*jwtKeySet, error) {
+	ks := &jwtKeySet{
+		now:             time.Now,
+		refreshInterval: jwtCfg.JWKSRefreshInterval,
+	}
+
+	// Load static keys
+	for _, sk := range jwtCfg.StaticKeys {
+		key, err := parseJWTStaticKey(sk.Key.Value)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+		// Save key
+		ks.staticKeys = append(ks.staticKeys, jose.JSONWebKey{Key: key, KeyID: sk.KeyID})
+	}
+
+	// Check if JWKS file is set
+	if jwtCfg.JWKSFile != "" {
+		ks.fetchers = append(ks.fetchers, jwksFileFetcher(jwtCfg.JWKSFile))
+	}
+
+	// Check if JWKS url is set
+	if jwtCfg.JWKSURL != "" {
+		ks.fetchers = append(ks.fetchers, jwksURLFetcher(jwtCfg.JWKSURL))
+	}
+
+	// Initial load to fail fast on invalid sources
+	if len(ks.fetchers) != 0 {
+		ks.lastAttempt = ks.now()
+
+		err := ks.refresh(ctx)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ks, nil
+}
+
+// candidates will return all keys that can be used to verify a token with the given key id.
+// JWKS sources are refreshed when keys are too old or when key id is unknown, at most once per minimal refresh interval.
+func (ks *jwtKeySet) candidates(ctx context.Context, kid string) ([]jose.JSONWebKey, error) {
+	ks.mutex.Lock()
+
+	now := ks.now()
+	// Check if a refresh can be started
+	canRefresh := len(ks.fetchers) != 0 && now.Sub(ks.lastAttempt) >= jwtMinKeysRefreshInterval
+	// Check if keys must be refreshed
+	stale := canRefresh && now.Sub(ks.lastFetch) >= ks.refreshInterval
+	// Filter keys
+	res := ks.filter(kid)
+	// Unknown key id may be a rotated key, try a refresh if allowed
+	mustRefresh := stale || (canRefresh && len(res) == 0)
+	// Reserve refresh attempt to make other requests use current keys meanwhile
+	if mustRefresh {
+		ks.lastAttempt = now
+	}
+
+	ks.mutex.Unlock()
+
+	// Check if refresh isn't needed
+	if !mustRefresh {
+		// Check if a key was found
+		if len(res) == 0 {
+			return nil, errors.WithStack(errJWTNoMatchingKey)
+		}
+
+		return res, nil
+	}
+
+	// Refresh keys, concurrent refreshes are shared
+	// Request cancellation is ignored as the result is shared with other requests
+	_, refreshErr, _ := ks.refreshGroup.Do(jwtKeysRefreshKey, func() (any, error) {
+		return nil, ks.refresh(context.WithoutCancel(ctx))
+	})
+
+	// Filter keys
+	ks.mutex.Lock()
+	res = ks.filter(kid)
+	ks.mutex.Unlock()
+
+	// Check if a key was found
+	if len(res) == 0 {
+		// Check if refresh have failed
+		if refreshErr != nil {
+			return nil, errors.WithStack(fmt.Errorf("%w: %w", errJWTKeysUnavailable, refreshErr))
+		}
+
+		return nil, errors.WithStack(errJWTNoMatchingKey)
+	}
+
+	return res, nil
+}
+
+// filter will return keys matching key id. Keys without key id match all tokens and
+// all keys match tokens without key id. Must be called with mutex locked.
+func (ks *jwtKeySet) filter(kid string) []jose.JSONWebKey {
+	res := []jose.JSONWebKey{}
+
+	for _, l := range [][]jose.JSONWebKey{ks.staticKeys, ks.fetchedKeys} {
+		for _, k := range l {
+			if kid == "" || k.KeyID == "" || k.KeyID == kid {
+				res = append(res, k)
+			}
+		}
+	}
+
+	return res
+}
+
+// refresh will reload all JWKS sources. Sources are fetched without mutex.
+// Previous keys are kept when a source fails.
+func (ks *jwtKeySet) refresh(ctx context.Context) error {
+	keys := []jose.JSONWebKey{}
+
+	// Loop over fetchers
+	for _, f := range ks.fetchers {
+		jwks, err := f(ctx)
+		// Check error
+		if err != nil {
+			return err
+		}
+		// Save keys that can be used to verify signatures
+		for _, k := range jwks.Keys {
+			if k.Use == "" || k.Use == "sig" {
+				keys = append(keys, k)
+			}
+		}
+	}
+
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	// Save
+	ks.fetchedKeys = keys
+	ks.lastFetch = ks.now()
+
+	return nil
+}
+
+// jwksFileFetcher will create a fetcher reading a local JWKS file.
+func jwksFileFetcher(path string) jwksFetcher {
+	return func(_ context.Context) (*jose.JSONWebKeySet, error) {
+		// Read file
+		b, err := os.ReadFile(path)
+		// Check error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return parseJWKS(b)
+	}
+}
+
+// jwksURLFetcher will create a fetcher downloading a remote JWKS document.
+func jwksURLFetcher(u string) jwksFetcher {
+	cl := &http.Client{Timeout: jwtJWKSFetchTimeout}
+
+	return func(ctx context.Context) (*jose.JSONWebKeySet, error) {
+		// Build request
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		// Check error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		// Send request
+		resp, err := cl.Do(req)
+		// Check error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		defer resp.Body.Close()
+
+		// Check status code
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Errorf("cannot fetch jwks from %s: status code %d", u, resp.StatusCode)
+		}
+
+		// Read body
+		b, err := io.ReadAll(io.LimitReader(resp.Body, jwtJWKSMaxSize))
+		// Check error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return parseJWKS(b)
+	}
+}
+
+// parseJWKS will parse a JWKS document.
+func parseJWKS(b []byte) (*jose.JSONWebKeySet, error) {
+	jwks := &jose.JSONWebKeySet{}
+	// Parse
+	err := json.Unmarshal(b, jwks)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return jwks, nil
+}
+
+// parseJWTStaticKey will parse a PEM encoded public key or certificate.
+// Values that aren't PEM encoded are considered as HMAC secrets.
+func parseJWTStaticKey(value string) (any, error) {
+	// Check if value is PEM encoded
+	if !strings.Contains(value, "-----BEGIN ") {
+		return []byte(value), nil
+	}
+
+	// Decode PEM
+	// Credentials loaded from files have their new lines removed, so PEM is decoded manually
+	blockType, der, err := decodePEM(value)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	switch blockType {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(der)
+		// Check error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return cert.PublicKey, nil
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(der)
+		// Check error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return key, nil
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(der)
+		// Check error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return key, nil
+	default:
+		return nil, errors.Errorf("unsupported pem block type %s", blockType)
+	}
+}
+
+// decodePEM will decode the first block of a PEM value, ignoring whitespaces.
+func decodePEM(value string) (string, []byte, error) {
+	// Find header
+	_, rest, found := strings.Cut(value, "-----BEGIN ")
+	if !found {
+		return "", nil, errors.New("cannot find pem header")
+	}
+
+	blockType, rest, found := strings.Cut(rest, "-----")
+	if !found {
+		return "", nil, errors.New("invalid pem header")
+	}
+
+	// Find footer
+	body, _, found := strings.Cut(rest, "-----END "+blockType+"-----")
+	if !found {
+		return "", nil, errors.New("cannot find pem footer")
+	}
+
+	// Remove whitespaces
+	body = strings.Join(strings.Fields(body), "")
+
+	// Decode
+	der, err := base64.StdEncoding.DecodeString(body)
+	// Check error
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+
+	return blockType, der, nil
+}
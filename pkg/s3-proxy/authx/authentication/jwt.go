@@ -0,0 +1,280 @@
+package authentication
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	jose "github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+)
+
+const bearerAuthorizationPrefix = "bearer "
+
+var (
+	errJWTNoToken          = errors.New("no bearer token detected in request")
+	errJWTMissingExpiry    = errors.New("token must have an expiration time")
+	errJWTInvalidSignature = errors.New("token signature cannot be verified with any key")
+	errJWTNoAudience       = errors.New("jwt provider must have at least one audience to verify tokens")
+)
+
+// jwtVerifier will verify bearer tokens for a JWT provider.
+type jwtVerifier struct {
+	cfg    *config.JWTAuthConfig
+	keySet *jwtKeySet
+	now    func() time.Time
+	algs   []jose.SignatureAlgorithm
+}
+
+// LoadJWTProvider will load keys of a JWT provider in order to verify bearer tokens.
+func (s *service) LoadJWTProvider(providerKey string, jwtCfg *config.JWTAuthConfig) error {
+	// Create key set
+	ks, err := newJWTKeySet(context.Background(), jwtCfg)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Build allowed algorithms
+	algs := make([]jose.SignatureAlgorithm, 0, len(jwtCfg.AllowedAlgorithms))
+	for _, alg := range jwtCfg.AllowedAlgorithms {
+		algs = append(algs, jose.SignatureAlgorithm(alg))
+	}
+
+	// Store verifier
+	s.allJWTVerifiers[providerKey] = &jwtVerifier{
+		cfg:    jwtCfg,
+		keySet: ks,
+		now:    time.Now,
+		algs:   algs,
+	}
+
+	return nil
+}
+
+// verify will verify a raw token and map its claims onto a JWT user.
+func (v *jwtVerifier) verify(ctx context.Context, raw string) (*models.JWTUser, error) {
+	// Parse token
+	tok, err := jwt.ParseSigned(raw, v.algs)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Get key id
+	kid := ""
+	if len(tok.Headers) != 0 {
+		kid = tok.Headers[0].KeyID
+	}
+
+	// Get candidate keys
+	keys, err := v.keySet.candidates(ctx, kid)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify signature with candidate keys
+	var (
+		claims    *jwt.Claims
+		allClaims map[string]any
+	)
+
+	for _, k := range keys {
+		c := &jwt.Claims{}
+		all := map[string]any{}
+		// Verify and decode
+		err = tok.Claims(k, c, &all)
+		// Check if it is verified
+		if err == nil {
+			claims = c
+			allClaims = all
+
+			break
+		}
+	}
+
+	// Check if token is verified
+	if claims == nil {
+		return nil, errors.WithStack(errJWTInvalidSignature)
+	}
+
+	// Check expiration presence
+	if claims.Expiry == nil {
+		return nil, errors.WithStack(errJWTMissingExpiry)
+	}
+
+	// Check audiences presence as tokens issued for other clients of the issuer would be accepted otherwise
+	if len(v.cfg.Audiences) == 0 {
+		return nil, errors.WithStack(errJWTNoAudience)
+	}
+
+	// Validate claims
+	err = claims.ValidateWithLeeway(jwt.Expected{
+		Issuer:      v.cfg.Issuer,
+		AnyAudience: v.cfg.Audiences,
+		Time:        v.now(),
+	}, v.cfg.ClockSkew)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Map claims
+	emailVerified, _ := allClaims["email_verified"].(bool)
+
+	return &models.JWTUser{
+		Claims:        allClaims,
+		Subject:       claims.Subject,
+		Username:      getStringClaim(allClaims, v.cfg.Claims.Username),
+		Email:         getStringClaim(allClaims, v.cfg.Claims.Email),
+		Name:          getStringClaim(allClaims, v.cfg.Claims.Name),
+		Groups:        getStringListClaim(allClaims, v.cfg.Claims.Groups),
+		EmailVerified: emailVerified,
+	}, nil
+}
+
+// getClaim will get a claim value using a dotted path (example: "realm_access.roles").
+// A claim containing dots at first level is also supported.
+func getClaim(claims map[string]any, path string) any {
+	// Check if claim exists directly
+	if v, ok := claims[path]; ok {
+		return v
+	}
+
+	var cur any = claims
+	// Loop over path parts
+	for part := range strings.SplitSeq(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		cur = m[part]
+	}
+
+	return cur
+}
+
+// getStringClaim will get a string claim value.
+func getStringClaim(claims map[string]any, path string) string {
+	v, _ := getClaim(claims, path).(string)
+
+	return v
+}
+
+// getStringListClaim will get a string list claim value.
+// A single string value is considered as a list with one element.
+func getStringListClaim(claims map[string]any, path string) []string {
+	switch v := getClaim(claims, path).(type) {
+	case string:
+		return []string{v}
+	case []any:
+		res := make([]string, 0, len(v))
+		// Keep only strings
+		for _, it := range v {
+			if s, ok := it.(string); ok {
+				res = append(res, s)
+			}
+		}
+
+		return res
+	default:
+		return nil
+	}
+}
+
+// getBearerToken will get bearer token from Authorization header.
+func getBearerToken(r *http.Request) string {
+	// Get header
+	h := r.Header.Get("Authorization")
+	// Check prefix
+	if len(h) < len(bearerAuthorizationPrefix) || !strings.EqualFold(h[:len(bearerAuthorizationPrefix)], bearerAuthorizationPrefix) {
+		return ""
+	}
+
+	return strings.TrimSpace(h[len(bearerAuthorizationPrefix):])
+}
+
+func (s *service) jwtAuthMiddleware(res *config.Resource) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get verifier
+			verifier := s.allJWTVerifiers[res.Provider]
+			// Get logger from request
+			logEntry := log.GetLoggerFromContext(r.Context())
+			// Get bucket request context from request
+			brctx := bucket.GetBucketRequestContextFromContext(r.Context())
+			// Get response handler
+			resHan := responsehandler.GetResponseHandlerFromContext(r.Context())
+
+			// Get token
+			raw := getBearerToken(r)
+
+			// Initialize error
+			var err error
+
+			// Initialize user
+			var juser *models.JWTUser
+
+			// Check if token exists
+			if raw == "" {
+				err = errors.WithStack(errJWTNoToken)
+				// Add header for bearer authentication
+				w.Header().Add("WWW-Authenticate", "Bearer")
+			} else {
+				// Verify token
+				juser, err = verifier.verify(r.Context(), raw)
+				// Check if keys cannot be loaded
+				if errors.Is(err, errJWTKeysUnavailable) {
+					// Check if bucket request context doesn't exist to use local default files
+					if brctx == nil {
+						responsehandler.GeneralInternalServerError(r, w, s.cfgManager, err)
+					} else {
+						resHan.InternalServerError(brctx.LoadFileContent, err)
+					}
+
+					return
+				}
+				// Check if token is invalid
+				if err != nil {
+					// Add header for bearer authentication
+					w.Header().Add("WWW-Authenticate", `Bearer error="invalid_token"`)
+				}
+			}
+
+			// Check error
+			if err != nil {
+				// Check if bucket request context doesn't exist to use local default files
+				if brctx == nil {
+					responsehandler.GeneralUnauthorizedError(r, w, s.cfgManager, err)
+				} else {
+					resHan.UnauthorizedError(brctx.LoadFileContent, err)
+				}
+
+				return
+			}
+
+			// Add user to request context by creating a new context
+			ctx := models.SetAuthenticatedUserInContext(r.Context(), juser)
+			// Create new request with new context
+			r = r.WithContext(ctx)
+
+			// Update response handler to have the latest context values
+			resHan.UpdateRequestAndResponse(r, w)
+
+			logEntry.Infof("JWT user %s authenticated", juser.GetIdentifier())
+			s.metricsCl.IncAuthenticated("jwt", res.Provider)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
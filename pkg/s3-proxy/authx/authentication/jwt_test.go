@@ -0,0 +1,407 @@
+//go:build unit
+
+package authentication
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+func signJWT(t *testing.T, alg jose.SignatureAlgorithm, key any, kid string, claims any) string {
+	t.Helper()
+
+	opts := &jose.SignerOptions{}
+	if kid != "" {
+		opts = opts.WithHeader(jose.HeaderKey("kid"), kid)
+	}
+
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, opts.WithType("JWT"))
+	require.NoError(t, err)
+
+	raw, err := jwt.Signed(sig).Claims(claims).Serialize()
+	require.NoError(t, err)
+
+	return raw
+}
+
+func newJWTTestConfig(mutate func(cfg *config.JWTAuthConfig)) *config.JWTAuthConfig {
+	cfg := &config.JWTAuthConfig{
+		Issuer:              "https://issuer.example.com",
+		Audiences:           []string{"s3-proxy"},
+		AllowedAlgorithms:   config.DefaultJWTAllowedAlgorithms,
+		ClockSkew:           config.DefaultJWTClockSkew,
+		JWKSRefreshInterval: config.DefaultJWTJWKSRefreshInterval,
+		Claims: &config.JWTClaimsConfig{
+			Username: config.DefaultJWTUsernameClaim,
+			Email:    config.DefaultJWTEmailClaim,
+			Name:     config.DefaultJWTNameClaim,
+			Groups:   config.DefaultJWTGroupsClaim,
+		},
+	}
+	if mutate != nil {
+		mutate(cfg)
+	}
+
+	return cfg
+}
+
+func Test_jwtVerifier_verify(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	hmacSecret := "0123456789abcdefghijklmnopqrstuv"
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherRsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	// Write JWKS file
+	jwksB, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: rsaKey.Public(), KeyID: "rsa1", Algorithm: "RS256", Use: "sig"},
+		{Key: otherRsaKey.Public(), KeyID: "enc", Use: "enc"},
+	}})
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, jwksB, 0o600))
+
+	// PEM public key with new lines removed like credentials loaded from files
+	ecDer, err := x509.MarshalPKIXPublicKey(ecKey.Public())
+	require.NoError(t, err)
+
+	ecPem := strings.ReplaceAll(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: ecDer})), "\n", "")
+
+	baseClaims := func() map[string]any {
+		return map[string]any{
+			"iss":   "https://issuer.example.com",
+			"sub":   "subject",
+			"aud":   []string{"s3-proxy"},
+			"exp":   now.Add(time.Hour).Unix(),
+			"iat":   now.Unix(),
+			"email": "user@example.com",
+		}
+	}
+	withClaims := func(mutate func(c map[string]any)) map[string]any {
+		c := baseClaims()
+		mutate(c)
+
+		return c
+	}
+
+	tests := []struct {
+		cfg     *config.JWTAuthConfig
+		want    *models.JWTUser
+		name    string
+		token   string
+		wantErr string
+	}{
+		{
+			name:  "should validate a token signed with a jwks file key",
+			cfg:   newJWTTestConfig(func(cfg *config.JWTAuthConfig) { cfg.JWKSFile = jwksFile }),
+			token: signJWT(t, jose.RS256, rsaKey, "rsa1", baseClaims()),
+			want:  &models.JWTUser{Subject: "subject", Username: "subject", Email: "user@example.com"},
+		},
+		{
+			name: "should validate a token signed with a static pem key and map claims",
+			cfg: newJWTTestConfig(func(cfg *config.JWTAuthConfig) {
+				cfg.StaticKeys = []*config.JWTStaticKeyConfig{{Key: &config.CredentialConfig{Value: ecPem}}}
+				cfg.Claims.Groups = "realm_access.roles"
+				cfg.Claims.Username = "preferred_username"
+			}),
+			token: signJWT(t, jose.ES256, ecKey, "", withClaims(func(c map[string]any) {
+				c["preferred_username"] = "user"
+				c["name"] = "User"
+				c["email_verified"] = true
+				c["realm_access"] = map[string]any{"roles": []any{"admin", 1, "dev"}}
+			})),
+			want: &models.JWTUser{
+				Subject:       "subject",
+				Username:      "user",
+				Email:         "user@example.com",
+				Name:          "User",
+				Groups:        []string{"admin", "dev"},
+				EmailVerified: true,
+			},
+		},
+		{
+			name: "should validate a token signed with a static hmac secret",
+			cfg: newJWTTestConfig(func(cfg *config.JWTAuthConfig) {
+				cfg.AllowedAlgorithms = []string{"HS256"}
+				cfg.StaticKeys = []*config.JWTStaticKeyConfig{{Key: &config.CredentialConfig{Value: hmacSecret}, KeyID: "hmac"}}
+			}),
+			token: signJWT(t, jose.HS256, []byte(hmacSecret), "hmac", withClaims(func(c map[string]any) {
+				c["groups"] = "group1"
+			})),
+			want: &models.JWTUser{Subject: "subject", Username: "subject", Email: "user@example.com", Groups: []string{"group1"}},
+		},
+		{
+			name: "should accept an expired token within clock skew",
+			cfg:  newJWTTestConfig(func(cfg *config.JWTAuthConfig) { cfg.JWKSFile = jwksFile }),
+			token: signJWT(t, jose.RS256, rsaKey, "rsa1", withClaims(func(c map[string]any) {
+				c["exp"] = now.Add(-30 * time.Second).Unix()
+			})),
+			want: &models.JWTUser{Subject: "subject", Username: "subject", Email: "user@example.com"},
+		},
+		{
+			name: "should reject an expired token",
+			cfg:  newJWTTestConfig(func(cfg *config.JWTAuthConfig) { cfg.JWKSFile = jwksFile }),
+			token: signJWT(t, jose.RS256, rsaKey, "rsa1", withClaims(func(c map[string]any) {
+				c["exp"] = now.Add(-2 * time.Minute).Unix()
+			})),
+			wantErr: "token is expired",
+		},
+		{
+			name: "should reject a token without expiration",
+			cfg:  newJWTTestConfig(func(cfg *config.JWTAuthConfig) { cfg.JWKSFile = jwksFile }),
+			token: signJWT(t, jose.RS256, rsaKey, "rsa1", withClaims(func(c map[string]any) {
+				delete(c, "exp")
+			})),
+			wantErr: errJWTMissingExpiry.Error(),
+		},
+		{
+			name: "should reject a token with another issuer",
+			cfg:  newJWTTestConfig(func(cfg *config.JWTAuthConfig) { cfg.JWKSFile = jwksFile }),
+			token: signJWT(t, jose.RS256, rsaKey, "rsa1", withClaims(func(c map[string]any) {
+				c["iss"] = "https://other.example.com"
+			})),
+			wantErr: "invalid issuer claim",
+		},
+		{
+			name: "should reject a token when no audience is configured",
+			cfg: newJWTTestConfig(func(cfg *config.JWTAuthConfig) {
+				cfg.JWKSFile = jwksFile
+				cfg.Audiences = nil
+			}),
+			token:   signJWT(t, jose.RS256, rsaKey, "rsa1", baseClaims()),
+			wantErr: "jwt provider must have at least one audience to verify tokens",
+		},
+		{
+			name: "should reject a token without expected audience",
+			cfg: newJWTTestConfig(func(cfg *config.JWTAuthConfig) {
+				cfg.JWKSFile = jwksFile
+				cfg.Audiences = []string{"other"}
+			}),
+			token:   signJWT(t, jose.RS256, rsaKey, "rsa1", baseClaims()),
+			wantErr: "invalid audience claim",
+		},
+		{
+			name:    "should reject a token signed with a not allowed algorithm",
+			cfg:     newJWTTestConfig(func(cfg *config.JWTAuthConfig) { cfg.JWKSFile = jwksFile }),
+			token:   signJWT(t, jose.HS256, []byte(hmacSecret), "rsa1", baseClaims()),
+			wantErr: "unexpected signature algorithm",
+		},
+		{
+			name:    "should reject a token signed with an unknown key",
+			cfg:     newJWTTestConfig(func(cfg *config.JWTAuthConfig) { cfg.JWKSFile = jwksFile }),
+			token:   signJWT(t, jose.RS256, otherRsaKey, "rsa1", baseClaims()),
+			wantErr: errJWTInvalidSignature.Error(),
+		},
+		{
+			name:    "should not use encryption keys",
+			cfg:     newJWTTestConfig(func(cfg *config.JWTAuthConfig) { cfg.JWKSFile = jwksFile }),
+			token:   signJWT(t, jose.RS256, otherRsaKey, "enc", baseClaims()),
+			wantErr: errJWTNoMatchingKey.Error(),
+		},
+		{
+			name:    "should reject a malformed token",
+			cfg:     newJWTTestConfig(func(cfg *config.JWTAuthConfig) { cfg.JWKSFile = jwksFile }),
+			token:   "not-a-token",
+			wantErr: "compact JWS format must have three parts",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &service{allJWTVerifiers: map[string]*jwtVerifier{}}
+			require.NoError(t, s.LoadJWTProvider("provider", tt.cfg))
+
+			v := s.allJWTVerifiers["provider"]
+			v.now = func() time.Time { return now }
+
+			got, err := v.verify(context.TODO(), tt.token)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			got.Claims = nil
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_jwtKeySet_refreshOnUnknownKid(t *testing.T) {
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key2, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keys := []jose.JSONWebKey{{Key: key1.Public(), KeyID: "k1"}}
+	calls := 0
+	fail := false
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: keys})
+	}))
+	defer ts.Close()
+
+	now := time.Now()
+
+	ks, err := newJWTKeySet(context.TODO(), newJWTTestConfig(func(cfg *config.JWTAuthConfig) { cfg.JWKSURL = ts.URL }))
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	ks.now = func() time.Time { return now }
+	ks.lastFetch = now
+	ks.lastAttempt = now
+
+	// Rotate keys
+	keys = append(keys, jose.JSONWebKey{Key: key2.Public(), KeyID: "k2"})
+
+	// Refresh is rate limited
+	_, err = ks.candidates(context.TODO(), "k2")
+	require.ErrorIs(t, err, errJWTNoMatchingKey)
+	assert.Equal(t, 1, calls)
+
+	// Refresh is allowed after minimal interval
+	now = now.Add(jwtMinKeysRefreshInterval)
+
+	got, err := ks.candidates(context.TODO(), "k2")
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "k2", got[0].KeyID)
+	assert.Equal(t, 2, calls)
+
+	// Known keys don't trigger a refresh
+	_, err = ks.candidates(context.TODO(), "k1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	// Source failure keeps previous keys
+	fail = true
+	now = now.Add(config.DefaultJWTJWKSRefreshInterval)
+
+	got, err = ks.candidates(context.TODO(), "k1")
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, 3, calls)
+
+	// Unknown key id with a failing source is reported as unavailable keys
+	now = now.Add(jwtMinKeysRefreshInterval)
+
+	_, err = ks.candidates(context.TODO(), "k3")
+	require.ErrorIs(t, err, errJWTKeysUnavailable)
+}
+
+func Test_jwtKeySet_slowRefresh(t *testing.T) {
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var calls atomic.Int32
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	now := time.Now()
+	ks := &jwtKeySet{
+		now:             func() time.Time { return now },
+		refreshInterval: config.DefaultJWTJWKSRefreshInterval,
+		lastFetch:       now,
+		fetchedKeys:     []jose.JSONWebKey{{Key: key1.Public(), KeyID: "k1"}},
+		fetchers: []jwksFetcher{
+			func(_ context.Context) (*jose.JSONWebKeySet, error) {
+				// Block until test releases the fetch
+				if calls.Add(1) == 1 {
+					close(started)
+				}
+
+				<-release
+
+				return &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key1.Public(), KeyID: "k1"}}}, nil
+			},
+		},
+	}
+
+	// Unknown key id triggers a slow refresh
+	var wg sync.WaitGroup
+
+	wg.Go(func() {
+		_, err := ks.candidates(context.TODO(), "unknown")
+		assert.ErrorIs(t, err, errJWTNoMatchingKey)
+	})
+
+	<-started
+
+	// Known keys are still available during the refresh
+	got, err := ks.candidates(context.TODO(), "k1")
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+
+	// Other unknown key ids don't start another refresh
+	_, err = ks.candidates(context.TODO(), "unknown2")
+	require.ErrorIs(t, err, errJWTNoMatchingKey)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func Test_getBearerToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "no header"},
+		{name: "basic header", header: "Basic dXNlcjpwYXNz"},
+		{name: "bearer header", header: "Bearer TOKEN", want: "TOKEN"},
+		{name: "lower case bearer header", header: "bearer TOKEN", want: "TOKEN"},
+		{name: "empty bearer header", header: "Bearer "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+
+			assert.Equal(t, tt.want, getBearerToken(r))
+		})
+	}
+}
@@ -0,0 +1,444 @@
+package authentication
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	ldap "github.com/go-ldap/ldap/v3"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+)
+
+// ldapMatchingRuleInChain is the Active Directory matching rule used to resolve nested group membership.
+const ldapMatchingRuleInChain = "1.2.840.113556.1.4.1941"
+
+var errLDAPInvalidCredentials = errors.New("invalid credentials")
+
+// ldapConn is the subset of a LDAP connection used to authenticate users.
+type ldapConn interface {
+	Bind(username, password string) error
+	Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// ldapCacheEntry is a successful authentication saved in cache.
+type ldapCacheEntry struct {
+	expiresAt time.Time
+	user      *models.LDAPUser
+}
+
+// ldapAuthenticator will authenticate users against a LDAP directory for a LDAP provider.
+type ldapAuthenticator struct {
+	cfg   *config.LDAPAuthConfig
+	dial  func() (ldapConn, error)
+	now   func() time.Time
+	cache map[string]*ldapCacheEntry
+	mutex sync.Mutex
+}
+
+// LoadLDAPProvider will prepare a LDAP provider in order to authenticate users.
+func (s *service) LoadLDAPProvider(providerKey string, ldapCfg *config.LDAPAuthConfig) error {
+	// Create tls configuration
+	tlsCfg, err := newLDAPTLSConfig(ldapCfg)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Store authenticator
+	s.allLDAPAuthenticators[providerKey] = &ldapAuthenticator{
+		cfg: ldapCfg,
+		dial: func() (ldapConn, error) {
+			return dialLDAP(ldapCfg, tlsCfg)
+		},
+		now:   time.Now,
+		cache: map[string]*ldapCacheEntry{},
+	}
+
+	return nil
+}
+
+// newLDAPTLSConfig will create the tls configuration used for ldaps and StartTLS.
+func newLDAPTLSConfig(ldapCfg *config.LDAPAuthConfig) (*tls.Config, error) {
+	// Parse url
+	u, err := url.Parse(ldapCfg.URL)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	tlsCfg := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: ldapCfg.InsecureSkipVerify, //nolint:gosec // Explicitly asked in configuration
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	// Check if a CA certificate is declared
+	if ldapCfg.CACertificate != nil && ldapCfg.CACertificate.Value != "" {
+		pool := x509.NewCertPool()
+		// Add certificates
+		if !pool.AppendCertsFromPEM([]byte(ldapCfg.CACertificate.Value)) {
+			return nil, errors.New("unable to load ldap CA certificate: no valid PEM certificate found")
+		}
+
+		tlsCfg.RootCAs = pool
+	}
+
+	return tlsCfg, nil
+}
+
+// dialLDAP will open a connection to LDAP server.
+func dialLDAP(ldapCfg *config.LDAPAuthConfig, tlsCfg *tls.Config) (ldapConn, error) {
+	// Dial
+	conn, err := ldap.DialURL(
+		ldapCfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: ldapCfg.Timeout}),
+		ldap.DialWithTLSConfig(tlsCfg),
+	)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	conn.SetTimeout(ldapCfg.Timeout)
+
+	// Check if StartTLS is enabled
+	if ldapCfg.StartTLS {
+		err = conn.StartTLS(tlsCfg)
+		// Check error
+		if err != nil {
+			_ = conn.Close()
+
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return conn, nil
+}
+
+// getLDAPCacheKey will compute the cache key of credentials. Password is never stored in clear text.
+func getLDAPCacheKey(username, password string) string {
+	sum := sha256.Sum256([]byte(username + "\x00" + password))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// authenticate will authenticate a user with its password and resolve its groups.
+// errLDAPInvalidCredentials is returned when user cannot be authenticated.
+func (a *ldapAuthenticator) authenticate(username, password string) (*models.LDAPUser, error) {
+	// Check credentials to avoid unauthenticated binds
+	if username == "" || password == "" {
+		return nil, errors.WithStack(errLDAPInvalidCredentials)
+	}
+
+	// Get cache key
+	cacheKey := getLDAPCacheKey(username, password)
+
+	// Check cache
+	a.mutex.Lock()
+	entry := a.cache[cacheKey]
+	a.mutex.Unlock()
+
+	if entry != nil && a.now().Before(entry.expiresAt) {
+		return entry.user, nil
+	}
+
+	// Open connection
+	conn, err := a.dial()
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	// Find and bind user
+	user, err := a.bindUser(conn, username, password)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if group search is enabled
+	if a.cfg.GroupSearch != nil {
+		user.Groups, err = a.searchGroups(conn, user.DN)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Save in cache
+	if a.cfg.CacheDuration > 0 {
+		a.mutex.Lock()
+		// Remove expired entries
+		for k, v := range a.cache {
+			if !a.now().Before(v.expiresAt) {
+				delete(a.cache, k)
+			}
+		}
+
+		a.cache[cacheKey] = &ldapCacheEntry{
+			user:      user,
+			expiresAt: a.now().Add(a.cfg.CacheDuration),
+		}
+		a.mutex.Unlock()
+	}
+
+	return user, nil
+}
+
+// bindUser will find user DN, bind with user password and get user entry.
+// When a bind DN is configured, connection is bound again with it for next searches.
+func (a *ldapAuthenticator) bindUser(conn ldapConn, username, password string) (*models.LDAPUser, error) {
+	var (
+		userDN string
+		email  string
+	)
+
+	// Check if user DN can be built directly
+	if a.cfg.UserDNTemplate != "" {
+		userDN = strings.ReplaceAll(a.cfg.UserDNTemplate, config.LDAPUsernamePlaceholder, ldap.EscapeDN(username))
+	} else {
+		// Bind with service account
+		err := a.bindServiceAccount(conn)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+
+		// Search user
+		entry, err := a.searchUser(conn, username)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+
+		userDN = entry.DN
+		email = entry.GetAttributeValue(a.cfg.EmailAttribute)
+	}
+
+	// Bind with user credentials
+	err := conn.Bind(userDN, password)
+	// Check error
+	if err != nil {
+		// Check if credentials are invalid
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, errors.WithStack(errLDAPInvalidCredentials)
+		}
+
+		return nil, errors.WithStack(err)
+	}
+
+	// Check if user entry must be read
+	if a.cfg.UserDNTemplate != "" {
+		// Bind with service account
+		err = a.bindServiceAccount(conn)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+
+		// Read user entry
+		res, err := conn.Search(ldap.NewSearchRequest(
+			userDN, ldap.ScopeBaseObject, ldap.NeverDerefAliases, 1, int(a.cfg.Timeout.Seconds()), false,
+			"(objectClass=*)", []string{a.cfg.EmailAttribute}, nil,
+		))
+		// Check error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if len(res.Entries) != 0 {
+			email = res.Entries[0].GetAttributeValue(a.cfg.EmailAttribute)
+		}
+	} else {
+		// Bind again with service account for group search
+		err = a.bindServiceAccount(conn)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.LDAPUser{
+		Username: username,
+		DN:       userDN,
+		Email:    email,
+		Groups:   []string{},
+	}, nil
+}
+
+// bindServiceAccount will bind connection with configured bind DN. Nothing is done without bind DN.
+func (a *ldapAuthenticator) bindServiceAccount(conn ldapConn) error {
+	// Check if bind DN is configured
+	if a.cfg.BindDN == "" {
+		return nil
+	}
+
+	err := conn.Bind(a.cfg.BindDN, a.cfg.BindPassword.Value)
+	// Check error
+	if err != nil {
+		return errors.Wrap(err, "unable to bind with service account")
+	}
+
+	return nil
+}
+
+// searchUser will search the unique user entry matching username.
+func (a *ldapAuthenticator) searchUser(conn ldapConn, username string) (*ldap.Entry, error) {
+	// Build filter
+	filter := strings.ReplaceAll(a.cfg.UserSearch.Filter, config.LDAPUsernamePlaceholder, ldap.EscapeFilter(username))
+
+	// Search user
+	res, err := conn.Search(ldap.NewSearchRequest(
+		a.cfg.UserSearch.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(a.cfg.Timeout.Seconds()), false,
+		filter, []string{a.cfg.EmailAttribute}, nil,
+	))
+	// Check error
+	if err != nil {
+		// Check if multiple users are matching
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return nil, errors.WithStack(errLDAPInvalidCredentials)
+		}
+
+		return nil, errors.WithStack(err)
+	}
+
+	// Check that user is unique
+	if len(res.Entries) != 1 {
+		return nil, errors.WithStack(errLDAPInvalidCredentials)
+	}
+
+	return res.Entries[0], nil
+}
+
+// getLDAPGroupFilter will build the group search filter for a user DN.
+func getLDAPGroupFilter(groupCfg *config.LDAPGroupSearchConfig, userDN string) string {
+	// Get member attribute
+	attr := groupCfg.MemberAttribute
+	// Check if nested groups must be resolved
+	if groupCfg.Nested {
+		attr += ":" + ldapMatchingRuleInChain + ":"
+	}
+
+	return fmt.Sprintf("(&%s(%s=%s))", groupCfg.Filter, attr, ldap.EscapeFilter(userDN))
+}
+
+// searchGroups will search groups of a user DN.
+func (a *ldapAuthenticator) searchGroups(conn ldapConn, userDN string) ([]string, error) {
+	// Search groups
+	res, err := conn.Search(ldap.NewSearchRequest(
+		a.cfg.GroupSearch.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, int(a.cfg.Timeout.Seconds()), false,
+		getLDAPGroupFilter(a.cfg.GroupSearch, userDN), []string{a.cfg.GroupSearch.NameAttribute}, nil,
+	))
+	// Check error
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to search groups")
+	}
+
+	groups := []string{}
+
+	for _, entry := range res.Entries {
+		// Get group name
+		name := entry.GetAttributeValue(a.cfg.GroupSearch.NameAttribute)
+		// Check if name exists
+		if name != "" {
+			groups = append(groups, name)
+		}
+	}
+
+	return groups, nil
+}
+
+func (s *service) ldapAuthMiddleware(res *config.Resource) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get data
+			ldapCfg := s.cfg.AuthProviders.LDAP[res.Provider]
+			authenticator := s.allLDAPAuthenticators[res.Provider]
+			// Get logger from request
+			logEntry := log.GetLoggerFromContext(r.Context())
+			// Get bucket request context from request
+			brctx := bucket.GetBucketRequestContextFromContext(r.Context())
+			// Get response handler
+			resHan := responsehandler.GetResponseHandlerFromContext(r.Context())
+
+			// Get basic auth information
+			username, password, ok := r.BasicAuth()
+			if !ok {
+				// Create error
+				err := errors.New("no basic auth detected in request")
+				// Add header for basic auth realm
+				w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, ldapCfg.Realm))
+				// Check if bucket request context doesn't exist to use local default files
+				if brctx == nil {
+					responsehandler.GeneralUnauthorizedError(r, w, s.cfgManager, err)
+				} else {
+					resHan.UnauthorizedError(brctx.LoadFileContent, err)
+				}
+
+				return
+			}
+
+			// Authenticate user
+			luser, err := authenticator.authenticate(username, password)
+			// Check error
+			if err != nil {
+				// Check if error isn't linked to credentials
+				if !errors.Is(err, errLDAPInvalidCredentials) {
+					// Check if bucket request context doesn't exist to use local default files
+					if brctx == nil {
+						responsehandler.GeneralInternalServerError(r, w, s.cfgManager, err)
+					} else {
+						resHan.InternalServerError(brctx.LoadFileContent, err)
+					}
+
+					return
+				}
+
+				// Create error
+				err = fmt.Errorf("username %s not authorized", username)
+				// Add stack trace
+				err = errors.WithStack(err)
+				// Add header for basic auth realm
+				w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, ldapCfg.Realm))
+				// Check if bucket request context doesn't exist to use local default files
+				if brctx == nil {
+					responsehandler.GeneralUnauthorizedError(r, w, s.cfgManager, err)
+				} else {
+					resHan.UnauthorizedError(brctx.LoadFileContent, err)
+				}
+
+				return
+			}
+
+			// Add user to request context by creating a new context
+			ctx := models.SetAuthenticatedUserInContext(r.Context(), luser)
+			// Create new request with new context
+			r = r.WithContext(ctx)
+
+			// Update response handler to have the latest context values
+			resHan.UpdateRequestAndResponse(r, w)
+
+			logEntry.Infof("LDAP user %s authenticated", luser.GetIdentifier())
+			s.metricsCl.IncAuthenticated("ldap", res.Provider)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
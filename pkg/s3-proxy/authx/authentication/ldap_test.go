@@ -0,0 +1,285 @@
+//go:build unit
+
+package authentication
+
+import (
+	"testing"
+	"time"
+
+	ldap "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+type fakeLDAPConn struct {
+	passwords map[string]string
+	users     map[string]*ldap.Entry
+	groups    map[string][]*ldap.Entry
+	binds     []string
+}
+
+func (c *fakeLDAPConn) Bind(username, password string) error {
+	c.binds = append(c.binds, username)
+
+	// Check password
+	if c.passwords[username] == "" || c.passwords[username] != password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, nil)
+	}
+
+	return nil
+}
+
+func (c *fakeLDAPConn) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	// Check if user entry is read
+	if req.Scope == ldap.ScopeBaseObject {
+		if e, ok := c.users[req.BaseDN]; ok {
+			return &ldap.SearchResult{Entries: []*ldap.Entry{e}}, nil
+		}
+
+		return nil, ldap.NewError(ldap.LDAPResultNoSuchObject, nil)
+	}
+
+	// Check if groups are searched
+	if gr, ok := c.groups[req.Filter]; ok {
+		return &ldap.SearchResult{Entries: gr}, nil
+	}
+
+	// Search users by filter
+	if e, ok := c.users[req.Filter]; ok {
+		return &ldap.SearchResult{Entries: []*ldap.Entry{e}}, nil
+	}
+
+	return &ldap.SearchResult{}, nil
+}
+
+func (*fakeLDAPConn) Close() error {
+	return nil
+}
+
+func newFakeLDAPConn() *fakeLDAPConn {
+	userEntry := ldap.NewEntry("CN=John Doe,OU=Users,DC=example,DC=com", map[string][]string{
+		"mail": {"jdoe@example.com"},
+	})
+
+	return &fakeLDAPConn{
+		passwords: map[string]string{
+			"CN=svc,DC=example,DC=com":               "svc-pass",
+			"CN=John Doe,OU=Users,DC=example,DC=com": "pass",
+			"uid=jdoe,OU=Users,DC=example,DC=com":    "pass",
+		},
+		users: map[string]*ldap.Entry{
+			"(sAMAccountName=jdoe)":                  userEntry,
+			"CN=John Doe,OU=Users,DC=example,DC=com": userEntry,
+			"uid=jdoe,OU=Users,DC=example,DC=com": ldap.NewEntry("uid=jdoe,OU=Users,DC=example,DC=com", map[string][]string{
+				"mail": {"jdoe@example.org"},
+			}),
+		},
+		groups: map[string][]*ldap.Entry{
+			"(&(objectClass=group)(member:1.2.840.113556.1.4.1941:=CN=John Doe,OU=Users,DC=example,DC=com))": {
+				ldap.NewEntry("CN=developers,OU=Groups,DC=example,DC=com", map[string][]string{"cn": {"developers"}}),
+				ldap.NewEntry("CN=all,OU=Groups,DC=example,DC=com", map[string][]string{"cn": {"all"}}),
+			},
+		},
+	}
+}
+
+func Test_getLDAPGroupFilter(t *testing.T) {
+	tests := []struct {
+		groupCfg *config.LDAPGroupSearchConfig
+		name     string
+		userDN   string
+		want     string
+	}{
+		{
+			name: "direct membership",
+			groupCfg: &config.LDAPGroupSearchConfig{
+				Filter:          "(objectClass=groupOfNames)",
+				MemberAttribute: "member",
+			},
+			userDN: "uid=jdoe,ou=people,dc=example,dc=com",
+			want:   "(&(objectClass=groupOfNames)(member=uid=jdoe,ou=people,dc=example,dc=com))",
+		},
+		{
+			name: "nested membership",
+			groupCfg: &config.LDAPGroupSearchConfig{
+				Filter:          "(objectClass=group)",
+				MemberAttribute: "member",
+				Nested:          true,
+			},
+			userDN: "CN=John Doe,OU=Users,DC=example,DC=com",
+			want:   "(&(objectClass=group)(member:1.2.840.113556.1.4.1941:=CN=John Doe,OU=Users,DC=example,DC=com))",
+		},
+		{
+			name: "escaped user dn",
+			groupCfg: &config.LDAPGroupSearchConfig{
+				Filter:          "(objectClass=group)",
+				MemberAttribute: "member",
+			},
+			userDN: "CN=Doe*(admin),DC=example,DC=com",
+			want:   "(&(objectClass=group)(member=CN=Doe\\2a\\28admin\\29,DC=example,DC=com))",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getLDAPGroupFilter(tt.groupCfg, tt.userDN))
+		})
+	}
+}
+
+func Test_ldapAuthenticator_authenticate(t *testing.T) {
+	searchCfg := &config.LDAPAuthConfig{
+		BindDN:         "CN=svc,DC=example,DC=com",
+		BindPassword:   &config.CredentialConfig{Value: "svc-pass"},
+		UserSearch:     &config.LDAPUserSearchConfig{BaseDN: "DC=example,DC=com", Filter: "(sAMAccountName={username})"},
+		EmailAttribute: "mail",
+		Timeout:        time.Second,
+		GroupSearch: &config.LDAPGroupSearchConfig{
+			BaseDN:          "DC=example,DC=com",
+			Filter:          "(objectClass=group)",
+			MemberAttribute: "member",
+			NameAttribute:   "cn",
+			Nested:          true,
+		},
+	}
+	templateCfg := &config.LDAPAuthConfig{
+		UserDNTemplate: "uid={username},OU=Users,DC=example,DC=com",
+		EmailAttribute: "mail",
+		Timeout:        time.Second,
+	}
+
+	tests := []struct {
+		ldapCfg   *config.LDAPAuthConfig
+		want      *models.LDAPUser
+		name      string
+		username  string
+		password  string
+		wantBinds []string
+		wantErr   error
+	}{
+		{
+			name:     "search then bind with nested groups",
+			ldapCfg:  searchCfg,
+			username: "jdoe",
+			password: "pass",
+			want: &models.LDAPUser{
+				Username: "jdoe",
+				DN:       "CN=John Doe,OU=Users,DC=example,DC=com",
+				Email:    "jdoe@example.com",
+				Groups:   []string{"developers", "all"},
+			},
+			wantBinds: []string{
+				"CN=svc,DC=example,DC=com",
+				"CN=John Doe,OU=Users,DC=example,DC=com",
+				"CN=svc,DC=example,DC=com",
+			},
+		},
+		{
+			name:      "search then bind with wrong password",
+			ldapCfg:   searchCfg,
+			username:  "jdoe",
+			password:  "wrong",
+			wantErr:   errLDAPInvalidCredentials,
+			wantBinds: []string{"CN=svc,DC=example,DC=com", "CN=John Doe,OU=Users,DC=example,DC=com"},
+		},
+		{
+			name:      "search then bind with unknown user",
+			ldapCfg:   searchCfg,
+			username:  "unknown",
+			password:  "pass",
+			wantErr:   errLDAPInvalidCredentials,
+			wantBinds: []string{"CN=svc,DC=example,DC=com"},
+		},
+		{
+			name:     "empty password is rejected without bind",
+			ldapCfg:  searchCfg,
+			username: "jdoe",
+			password: "",
+			wantErr:  errLDAPInvalidCredentials,
+		},
+		{
+			name:     "direct bind",
+			ldapCfg:  templateCfg,
+			username: "jdoe",
+			password: "pass",
+			want: &models.LDAPUser{
+				Username: "jdoe",
+				DN:       "uid=jdoe,OU=Users,DC=example,DC=com",
+				Email:    "jdoe@example.org",
+				Groups:   []string{},
+			},
+			wantBinds: []string{"uid=jdoe,OU=Users,DC=example,DC=com"},
+		},
+		{
+			name:      "direct bind with escaped username",
+			ldapCfg:   templateCfg,
+			username:  "jdoe,OU=Admins",
+			password:  "pass",
+			wantErr:   errLDAPInvalidCredentials,
+			wantBinds: []string{"uid=jdoe\\,OU=Admins,OU=Users,DC=example,DC=com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := newFakeLDAPConn()
+			a := &ldapAuthenticator{
+				cfg:   tt.ldapCfg,
+				dial:  func() (ldapConn, error) { return conn, nil },
+				now:   time.Now,
+				cache: map[string]*ldapCacheEntry{},
+			}
+
+			got, err := a.authenticate(tt.username, tt.password)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantBinds, conn.binds)
+		})
+	}
+}
+
+func Test_ldapAuthenticator_authenticate_cache(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	dials := 0
+	a := &ldapAuthenticator{
+		cfg: &config.LDAPAuthConfig{
+			UserDNTemplate: "uid={username},OU=Users,DC=example,DC=com",
+			EmailAttribute: "mail",
+			Timeout:        time.Second,
+			CacheDuration:  time.Minute,
+		},
+		dial: func() (ldapConn, error) {
+			dials++
+
+			return newFakeLDAPConn(), nil
+		},
+		now:   func() time.Time { return now },
+		cache: map[string]*ldapCacheEntry{},
+	}
+
+	// First call
+	_, err := a.authenticate("jdoe", "pass")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, dials)
+
+	// Cached
+	_, err = a.authenticate("jdoe", "pass")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, dials)
+
+	// Other password isn't cached
+	_, err = a.authenticate("jdoe", "wrong")
+	assert.ErrorIs(t, err, errLDAPInvalidCredentials)
+	assert.Equal(t, 2, dials)
+
+	// Expired
+	now = now.Add(2 * time.Minute)
+	_, err = a.authenticate("jdoe", "pass")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, dials)
+}
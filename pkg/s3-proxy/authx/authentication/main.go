@@ -3,6 +3,8 @@ package authentication
 import (
 	"fmt"
 	"net/http"
+	"net/netip"
+	"strings"
 
 	"emperror.dev/errors"
 	"github.com/gobwas/glob"
@@ -10,27 +12,41 @@ import (
 
 	oidc "github.com/coreos/go-oidc/v3/oidc"
 
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/impersonation"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/lockout"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/session"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/ipfilter"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics"
 	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+	utils "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/utils/generalutils"
 )
 
 var errAuthenticationMiddlewareNotSupported = errors.New("authentication not supported")
 
 type service struct {
-	allVerifiers map[string]*oidc.IDTokenVerifier
-	cfg          *config.Config
-	metricsCl    metrics.Client
+	allVerifiers           map[string]*oidc.IDTokenVerifier
+	allJWTVerifiers        map[string]*jwtVerifier
+	allLDAPAuthenticators  map[string]*ldapAuthenticator
+	allHMACVerifiers       map[string]*hmacVerifier
+	allOIDCSessionHandlers map[string]*oidcSessionHandler
+	cfg                    *config.Config
+	metricsCl              metrics.Client
+	sessionManager         session.Manager
+	lockoutManager         lockout.Manager
 	// This has been saved only for response handler.
 	// Not used inside the service functions because it is better to use fixed configuration to avoid conflict in case of reload and incoming request.
 	cfgManager config.Manager
 }
 
-// Middleware will redirect authentication to basic auth or OIDC depending on request path and resources declared.
+// Middleware will redirect authentication to basic auth, OIDC, header, JWT, API key, mTLS, LDAP or HMAC depending on request path and resources declared.
 func (s *service) Middleware(resources []*config.Resource) func(http.Handler) http.Handler {
+	// Sort resources by priority
+	resources = config.SortResourcesByPriority(resources)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get logger
@@ -50,11 +66,17 @@ func (s *service) Middleware(resources []*config.Resource) func(http.Handler) ht
 			// Get request data
 			requestURI := r.URL.RequestURI()
 			httpMethod := r.Method
+			// Check if it is an impersonation session switch to authenticate it like its confirmation page
+			if impersonation.IsSessionSwitch(s.cfg.Impersonation, r) {
+				httpMethod = http.MethodGet
+			}
+			// Forwarded host headers are only used from trusted proxies to avoid resource selection with a forged host
+			host := utils.GetTrustedRequestHost(r, s.getTrustedProxies())
 
 			// Get bucket request context
 			brctx := bucket.GetBucketRequestContextFromContext(r.Context())
 			// Find resource
-			res, err := findResource(resources, requestURI, httpMethod)
+			res, err := findResource(resources, requestURI, host, httpMethod)
 			if err != nil {
 				// Check if bucket request context doesn't exist to use local default files
 				if brctx == nil {
@@ -85,6 +107,76 @@ func (s *service) Middleware(resources []*config.Resource) func(http.Handler) ht
 
 			// Resource found case
 
+			// Check if resource is a deny resource
+			if res.Deny {
+				// Create error
+				err2 := errors.WithStack(fmt.Errorf("deny resource found for path %s and method %s => Forbidden access", requestURI, httpMethod))
+				// Check if bucket request context doesn't exist to use local default files
+				if brctx == nil {
+					responsehandler.GeneralForbiddenError(r, w, s.cfgManager, err2)
+				} else {
+					resHan.ForbiddenError(brctx.LoadFileContent, err2)
+				}
+
+				return
+			}
+
+			// Check if resource has an ip filter
+			// This is done before authentication to reject clients whatever their credentials are.
+			if res.IPFilter != nil {
+				// Check request
+				err2 := ipfilter.CheckRequest(r, res.IPFilter)
+				// Check error
+				if err2 != nil {
+					// Check if bucket request context doesn't exist to use local default files
+					if brctx == nil {
+						responsehandler.GeneralForbiddenError(r, w, s.cfgManager, err2)
+					} else {
+						resHan.ForbiddenError(brctx.LoadFileContent, err2)
+					}
+
+					return
+				}
+			}
+
+			// Check if resource has multiple providers
+			if len(res.Providers) != 0 {
+				// Select providers used to authenticate request
+				candidates := s.selectProviderResources(r, res)
+				// Check if no provider is found (shouldn't arrive because of validation)
+				if len(candidates) == 0 {
+					err = errAuthenticationMiddlewareNotSupported
+					// Check if bucket request context doesn't exist to use local default files
+					if brctx == nil {
+						responsehandler.GeneralUnauthorizedError(r, w, s.cfgManager, err)
+					} else {
+						resHan.UnauthorizedError(brctx.LoadFileContent, err)
+					}
+
+					return
+				}
+
+				s.providersAuthMiddleware(candidates)(next).ServeHTTP(w, r)
+
+				return
+			}
+
+			s.resourceAuthMiddleware(res)(next).ServeHTTP(w, r)
+		})
+	}
+}
+
+// resourceAuthMiddleware will authenticate a request with the provider of a resource.
+func (s *service) resourceAuthMiddleware(res *config.Resource) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get logger
+			logEntry := log.GetLoggerFromContext(r.Context())
+			// Get response handler
+			resHan := responsehandler.GetResponseHandlerFromContext(r.Context())
+			// Get bucket request context
+			brctx := bucket.GetBucketRequestContextFromContext(r.Context())
+
 			// Add resource to request context in order to keep it ready for authorization
 			ctx := models.SetRequestResourceInContext(r.Context(), res)
 			// Create new request with new context
@@ -106,6 +198,46 @@ func (s *service) Middleware(resources []*config.Resource) func(http.Handler) ht
 				return
 			}
 
+			// Check if JWT auth is enabled
+			if res.JWT != nil {
+				logEntry.Debug("authentication with jwt detected")
+				s.jwtAuthMiddleware(res)(next).ServeHTTP(w, r)
+
+				return
+			}
+
+			// Check if API key auth is enabled
+			if res.APIKey != nil {
+				logEntry.Debug("authentication with api key detected")
+				s.apiKeyAuthMiddleware(res)(next).ServeHTTP(w, r)
+
+				return
+			}
+
+			// Check if mTLS auth is enabled
+			if res.MTLS != nil {
+				logEntry.Debug("authentication with mtls detected")
+				s.mtlsAuthMiddleware(res)(next).ServeHTTP(w, r)
+
+				return
+			}
+
+			// Check if LDAP auth is enabled
+			if res.LDAP != nil {
+				logEntry.Debug("authentication with ldap detected")
+				s.ldapAuthMiddleware(res)(next).ServeHTTP(w, r)
+
+				return
+			}
+
+			// Check if HMAC auth is enabled
+			if res.HMAC != nil {
+				logEntry.Debug("authentication with hmac detected")
+				s.hmacAuthMiddleware(res)(next).ServeHTTP(w, r)
+
+				return
+			}
+
 			// Check if Basic auth is enabled
 			if res.Basic != nil {
 				logEntry.Debug("authentication with basic auth detected")
@@ -123,7 +255,7 @@ func (s *service) Middleware(resources []*config.Resource) func(http.Handler) ht
 			}
 
 			// Error, this case shouldn't arrive
-			err = errAuthenticationMiddlewareNotSupported
+			err := errAuthenticationMiddlewareNotSupported
 			// Check if bucket request context doesn't exist to use local default files
 			if brctx == nil {
 				responsehandler.GeneralUnauthorizedError(r, w, s.cfgManager, err)
@@ -134,7 +266,22 @@ func (s *service) Middleware(resources []*config.Resource) func(http.Handler) ht
 	}
 }
 
-func findResource(resL []*config.Resource, requestURI, httpMethod string) (*config.Resource, error) {
+// getTrustedProxies returns the server trusted proxies.
+func (s *service) getTrustedProxies() []netip.Prefix {
+	// Get configuration
+	cfg := s.cfgManager.GetConfig()
+	// Check if server configuration exists
+	if cfg == nil || cfg.Server == nil {
+		return nil
+	}
+
+	return cfg.Server.TrustedProxyPrefixes
+}
+
+func findResource(resL []*config.Resource, requestURI, host, httpMethod string) (*config.Resource, error) {
+	// Get request path without query string
+	requestPath, _, _ := strings.Cut(requestURI, "?")
+
 	// Loop over the list
 	for _, res := range resL {
 		// Check if http method is declared in resource
@@ -143,6 +290,34 @@ func findResource(resL []*config.Resource, requestURI, httpMethod string) (*conf
 			// Stop here and continue to next resource
 			continue
 		}
+		// Check if host is declared in resource
+		if res.Host != "" {
+			// Compile a glob pattern for host matching
+			g, err := glob.Compile(strings.ToLower(res.Host))
+			// Check if error exists
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			// Check if host match
+			if !g.Match(strings.ToLower(host)) {
+				continue
+			}
+		}
+		// Get value to match
+		value := requestURI
+		// Check if query string must be ignored
+		if res.IgnoreQueryString {
+			value = requestPath
+		}
+		// Check if path is a regexp
+		if res.PathRegex != nil {
+			// Check if request uri match regexp declared in resource
+			if res.PathRegex.MatchString(value) {
+				return res, nil
+			}
+
+			continue
+		}
 		// Compile a glob pattern for uri matching
 		g, err := glob.Compile(res.Path, '/')
 		// Check if error exists
@@ -150,7 +325,7 @@ func findResource(resL []*config.Resource, requestURI, httpMethod string) (*conf
 			return nil, errors.WithStack(err)
 		}
 		// Check if request uri match glob pattern declared in resource
-		if g.Match(requestURI) {
+		if g.Match(value) {
 			return res, nil
 		}
 	}
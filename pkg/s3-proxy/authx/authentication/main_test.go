@@ -4,6 +4,7 @@ package authentication
 
 import (
 	"reflect"
+	"regexp"
 	"testing"
 
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
@@ -13,6 +14,7 @@ func Test_findResource(t *testing.T) {
 	type args struct {
 		resL       []*config.Resource
 		requestURI string
+		host       string
 		httpMethod string
 	}
 	tests := []struct {
@@ -217,10 +219,133 @@ func Test_findResource(t *testing.T) {
 			want:    nil,
 			wantErr: false,
 		},
+		{
+			name: "Shouldn't match glob path with query string by default",
+			args: args{
+				resL: []*config.Resource{
+					{
+						Path:    "/test/*.txt",
+						Methods: []string{"GET"},
+					},
+				},
+				requestURI: "/test/fake.txt?param=value",
+				httpMethod: "GET",
+			},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name: "Should match glob path without query string when it is ignored",
+			args: args{
+				resL: []*config.Resource{
+					{
+						Path:              "/test/*.txt",
+						Methods:           []string{"GET"},
+						IgnoreQueryString: true,
+					},
+				},
+				requestURI: "/test/fake.txt?param=value",
+				httpMethod: "GET",
+			},
+			want: &config.Resource{
+				Path:              "/test/*.txt",
+				Methods:           []string{"GET"},
+				IgnoreQueryString: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Should match regexp path",
+			args: args{
+				resL: []*config.Resource{
+					{
+						Path:      "^/test/.*\\.pdf$",
+						PathRegex: regexp.MustCompile("^/test/.*\\.pdf$"),
+						Regexp:    true,
+						Methods:   []string{"GET"},
+					},
+				},
+				requestURI: "/test/sub/file.pdf",
+				httpMethod: "GET",
+			},
+			want: &config.Resource{
+				Path:      "^/test/.*\\.pdf$",
+				PathRegex: regexp.MustCompile("^/test/.*\\.pdf$"),
+				Regexp:    true,
+				Methods:   []string{"GET"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Shouldn't match regexp path",
+			args: args{
+				resL: []*config.Resource{
+					{
+						Path:      "^/test/.*\\.pdf$",
+						PathRegex: regexp.MustCompile("^/test/.*\\.pdf$"),
+						Regexp:    true,
+						Methods:   []string{"GET"},
+					},
+				},
+				requestURI: "/test/sub/file.txt",
+				httpMethod: "GET",
+			},
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name: "Should match resource with host",
+			args: args{
+				resL: []*config.Resource{
+					{
+						Path:    "/test/*",
+						Host:    "*.example.com",
+						Methods: []string{"GET"},
+					},
+					{
+						Path:    "/test/*",
+						Methods: []string{"GET"},
+					},
+				},
+				requestURI: "/test/fake",
+				host:       "Files.Example.com",
+				httpMethod: "GET",
+			},
+			want: &config.Resource{
+				Path:    "/test/*",
+				Host:    "*.example.com",
+				Methods: []string{"GET"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Should skip resource with another host",
+			args: args{
+				resL: []*config.Resource{
+					{
+						Path:    "/test/*",
+						Host:    "*.example.com",
+						Methods: []string{"GET"},
+					},
+					{
+						Path:    "/test/*",
+						Methods: []string{"GET"},
+					},
+				},
+				requestURI: "/test/fake",
+				host:       "localhost",
+				httpMethod: "GET",
+			},
+			want: &config.Resource{
+				Path:    "/test/*",
+				Methods: []string{"GET"},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := findResource(tt.args.resL, tt.args.requestURI, tt.args.httpMethod)
+			got, err := findResource(tt.args.resL, tt.args.requestURI, tt.args.host, tt.args.httpMethod)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("findResource() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -0,0 +1,201 @@
+package authentication
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"net/http"
+
+	"emperror.dev/errors"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+)
+
+var errMTLSCertificateNotFound = errors.New("no verified client certificate detected in request")
+
+// oidEmailAddress is the PKCS#9 emailAddress attribute that can be found in certificate subjects.
+var oidEmailAddress = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}
+
+// getVerifiedClientCertificate will get the client certificate verified during TLS handshake.
+func getVerifiedClientCertificate(r *http.Request) *x509.Certificate {
+	// Check if a certificate chain was verified
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+
+	return r.TLS.PeerCertificates[0]
+}
+
+// getMTLSEmails will get emails from certificate SAN, or from subject if SAN doesn't contain any.
+func getMTLSEmails(cert *x509.Certificate) []string {
+	// Check SAN emails
+	if len(cert.EmailAddresses) != 0 {
+		return cert.EmailAddresses
+	}
+
+	res := []string{}
+
+	for _, atv := range cert.Subject.Names {
+		// Check if attribute is an email
+		if !atv.Type.Equal(oidEmailAddress) {
+			continue
+		}
+
+		if v, ok := atv.Value.(string); ok && v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+// getMTLSGroups will get groups from configured certificate subject fields, with mappings applied.
+func getMTLSGroups(cert *x509.Certificate, mtlsCfg *config.MTLSAuthConfig) []string {
+	// Get values
+	values := []string{}
+
+	for _, field := range mtlsCfg.GroupsFields {
+		switch field {
+		case config.MTLSFieldOrganizationalUnit:
+			values = append(values, cert.Subject.OrganizationalUnit...)
+		case config.MTLSFieldOrganization:
+			values = append(values, cert.Subject.Organization...)
+		case config.MTLSFieldCommonName:
+			if cert.Subject.CommonName != "" {
+				values = append(values, cert.Subject.CommonName)
+			}
+		}
+	}
+
+	res := []string{}
+	// Store already added groups
+	added := map[string]bool{}
+
+	for _, v := range values {
+		// Get mapped groups
+		groups := []string{}
+
+		for _, m := range mtlsCfg.GroupMappings {
+			if m.Value == v {
+				groups = append(groups, m.Group)
+			}
+		}
+		// Keep value when no mapping exists
+		if len(groups) == 0 {
+			groups = append(groups, v)
+		}
+
+		for _, g := range groups {
+			if !added[g] {
+				added[g] = true
+				res = append(res, g)
+			}
+		}
+	}
+
+	return res
+}
+
+// newMTLSUser will create a user from client certificate.
+func newMTLSUser(cert *x509.Certificate, mtlsCfg *config.MTLSAuthConfig) *models.MTLSUser {
+	u := &models.MTLSUser{
+		Subject:      cert.Subject.String(),
+		Issuer:       cert.Issuer.String(),
+		SerialNumber: cert.SerialNumber.String(),
+		CommonName:   cert.Subject.CommonName,
+		Emails:       getMTLSEmails(cert),
+		DNSNames:     cert.DNSNames,
+		URIs:         []string{},
+		Groups:       getMTLSGroups(cert, mtlsCfg),
+	}
+
+	for _, uri := range cert.URIs {
+		u.URIs = append(u.URIs, uri.String())
+	}
+
+	// Set email
+	if len(u.Emails) != 0 {
+		u.Email = u.Emails[0]
+	}
+
+	// Set username
+	switch mtlsCfg.UsernameField {
+	case config.MTLSFieldEmail:
+		u.Username = u.Email
+	case config.MTLSFieldDNSName:
+		if len(u.DNSNames) != 0 {
+			u.Username = u.DNSNames[0]
+		}
+	case config.MTLSFieldURI:
+		if len(u.URIs) != 0 {
+			u.Username = u.URIs[0]
+		}
+	default:
+		u.Username = u.CommonName
+	}
+
+	return u
+}
+
+func (s *service) mtlsAuthMiddleware(res *config.Resource) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get mtls configuration
+			mtlsCfg := s.cfg.AuthProviders.MTLS[res.Provider]
+			// Get logger from request
+			logEntry := log.GetLoggerFromContext(r.Context())
+			// Get bucket request context from request
+			brctx := bucket.GetBucketRequestContextFromContext(r.Context())
+			// Get response handler
+			resHan := responsehandler.GetResponseHandlerFromContext(r.Context())
+
+			// Get client certificate
+			cert := getVerifiedClientCertificate(r)
+			// Check if certificate exists
+			if cert == nil {
+				// Create error
+				err := errors.WithStack(errMTLSCertificateNotFound)
+				// Check if bucket request context doesn't exist to use local default files
+				if brctx == nil {
+					responsehandler.GeneralUnauthorizedError(r, w, s.cfgManager, err)
+				} else {
+					resHan.UnauthorizedError(brctx.LoadFileContent, err)
+				}
+
+				return
+			}
+
+			// Create mtls user
+			muser := newMTLSUser(cert, mtlsCfg)
+			// Check that user can be identified
+			if muser.GetIdentifier() == "" {
+				// Create error
+				err := errors.Errorf("client certificate %s doesn't contain %s for username", muser.Subject, mtlsCfg.UsernameField)
+				// Check if bucket request context doesn't exist to use local default files
+				if brctx == nil {
+					responsehandler.GeneralUnauthorizedError(r, w, s.cfgManager, err)
+				} else {
+					resHan.UnauthorizedError(brctx.LoadFileContent, err)
+				}
+
+				return
+			}
+
+			// Add user to request context by creating a new context
+			ctx := models.SetAuthenticatedUserInContext(r.Context(), muser)
+			// Create new request with new context
+			r = r.WithContext(ctx)
+
+			// Update response handler to have the latest context values
+			resHan.UpdateRequestAndResponse(r, w)
+
+			logEntry.Infof("mTLS user %s authenticated with certificate %s", muser.GetIdentifier(), muser.Subject)
+			s.metricsCl.IncAuthenticated("mtls", res.Provider)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
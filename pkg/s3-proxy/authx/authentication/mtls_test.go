@@ -0,0 +1,221 @@
+//go:build unit
+
+package authentication
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+func Test_getVerifiedClientCertificate(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "user1"}}
+
+	tests := []struct {
+		tlsState *tls.ConnectionState
+		want     *x509.Certificate
+		name     string
+	}{
+		{
+			name: "no tls",
+		},
+		{
+			name:     "no peer certificate",
+			tlsState: &tls.ConnectionState{},
+		},
+		{
+			name: "peer certificate not verified",
+			tlsState: &tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{cert},
+			},
+		},
+		{
+			name: "verified peer certificate",
+			tlsState: &tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{cert},
+				VerifiedChains:   [][]*x509.Certificate{{cert}},
+			},
+			want: cert,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Request{TLS: tt.tlsState}
+
+			assert.Equal(t, tt.want, getVerifiedClientCertificate(r))
+		})
+	}
+}
+
+func Test_getMTLSEmails(t *testing.T) {
+	tests := []struct {
+		cert *x509.Certificate
+		name string
+		want []string
+	}{
+		{
+			name: "no email",
+			cert: &x509.Certificate{},
+			want: []string{},
+		},
+		{
+			name: "san emails",
+			cert: &x509.Certificate{
+				EmailAddresses: []string{"user1@example.com", "user1@example.org"},
+				Subject: pkix.Name{
+					Names: []pkix.AttributeTypeAndValue{{Type: oidEmailAddress, Value: "subject@example.com"}},
+				},
+			},
+			want: []string{"user1@example.com", "user1@example.org"},
+		},
+		{
+			name: "subject email",
+			cert: &x509.Certificate{
+				Subject: pkix.Name{
+					Names: []pkix.AttributeTypeAndValue{
+						{Type: oidEmailAddress, Value: "subject@example.com"},
+						{Type: oidEmailAddress, Value: ""},
+					},
+				},
+			},
+			want: []string{"subject@example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getMTLSEmails(tt.cert))
+		})
+	}
+}
+
+func Test_getMTLSGroups(t *testing.T) {
+	cert := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:         "user1",
+			Organization:       []string{"org1"},
+			OrganizationalUnit: []string{"ou1", "ou2", "ou1"},
+		},
+	}
+
+	tests := []struct {
+		mtlsCfg *config.MTLSAuthConfig
+		name    string
+		want    []string
+	}{
+		{
+			name:    "no fields",
+			mtlsCfg: &config.MTLSAuthConfig{},
+			want:    []string{},
+		},
+		{
+			name: "organizational units are deduplicated",
+			mtlsCfg: &config.MTLSAuthConfig{
+				GroupsFields: []string{config.MTLSFieldOrganizationalUnit},
+			},
+			want: []string{"ou1", "ou2"},
+		},
+		{
+			name: "multiple fields",
+			mtlsCfg: &config.MTLSAuthConfig{
+				GroupsFields: []string{
+					config.MTLSFieldOrganization,
+					config.MTLSFieldOrganizationalUnit,
+					config.MTLSFieldCommonName,
+				},
+			},
+			want: []string{"org1", "ou1", "ou2", "user1"},
+		},
+		{
+			name: "mappings",
+			mtlsCfg: &config.MTLSAuthConfig{
+				GroupsFields: []string{config.MTLSFieldOrganizationalUnit},
+				GroupMappings: []*config.MTLSGroupMappingConfig{
+					{Value: "ou1", Group: "admins"},
+					{Value: "ou1", Group: "users"},
+					{Value: "ou2", Group: "users"},
+				},
+			},
+			want: []string{"admins", "users"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getMTLSGroups(cert, tt.mtlsCfg))
+		})
+	}
+}
+
+func Test_newMTLSUser(t *testing.T) {
+	uri, _ := url.Parse("spiffe://example.com/user1")
+	cert := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:         "user1",
+			OrganizationalUnit: []string{"ou1"},
+		},
+		Issuer:         pkix.Name{CommonName: "ca"},
+		SerialNumber:   big.NewInt(42),
+		EmailAddresses: []string{"user1@example.com"},
+		DNSNames:       []string{"user1.example.com"},
+		URIs:           []*url.URL{uri},
+	}
+	baseUser := models.MTLSUser{
+		Subject:      "CN=user1,OU=ou1",
+		Issuer:       "CN=ca",
+		SerialNumber: "42",
+		CommonName:   "user1",
+		Email:        "user1@example.com",
+		Emails:       []string{"user1@example.com"},
+		DNSNames:     []string{"user1.example.com"},
+		URIs:         []string{"spiffe://example.com/user1"},
+		Groups:       []string{"ou1"},
+	}
+
+	tests := []struct {
+		name          string
+		usernameField string
+		wantUsername  string
+	}{
+		{
+			name:          "common name",
+			usernameField: config.MTLSFieldCommonName,
+			wantUsername:  "user1",
+		},
+		{
+			name:          "email",
+			usernameField: config.MTLSFieldEmail,
+			wantUsername:  "user1@example.com",
+		},
+		{
+			name:          "dns name",
+			usernameField: config.MTLSFieldDNSName,
+			wantUsername:  "user1.example.com",
+		},
+		{
+			name:          "uri",
+			usernameField: config.MTLSFieldURI,
+			wantUsername:  "spiffe://example.com/user1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := baseUser
+			want.Username = tt.wantUsername
+
+			got := newMTLSUser(cert, &config.MTLSAuthConfig{
+				UsernameField: tt.usernameField,
+				GroupsFields:  []string{config.MTLSFieldOrganizationalUnit},
+			})
+
+			assert.Equal(t, &want, got)
+		})
+	}
+}
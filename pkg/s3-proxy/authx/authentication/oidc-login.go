@@ -0,0 +1,142 @@
+package authentication
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// oidcLoginCookieMaxAge is the maximum duration between login and callback.
+	oidcLoginCookieMaxAge = 10 * time.Minute
+	// oidcLoginRandomLength is the number of random bytes used for state and nonce.
+	oidcLoginRandomLength = 32
+	// oidcLoginCookieSeparator separates payload and signature in login cookie value.
+	oidcLoginCookieSeparator = "."
+)
+
+var (
+	errOIDCLoginCookieInvalid = errors.New("invalid login cookie")
+	errOIDCLoginCookieExpired = errors.New("login cookie has expired")
+)
+
+// oidcLoginState contains per login values saved between login and callback.
+type oidcLoginState struct {
+	ExpiresAt    time.Time `json:"expiresAt"`
+	State        string    `json:"state"`
+	Nonce        string    `json:"nonce"`
+	PKCEVerifier string    `json:"pkceVerifier"`
+	Redirect     string    `json:"redirect"`
+}
+
+// newOIDCLoginState will generate a random state, nonce and PKCE verifier bound to the redirect url.
+func newOIDCLoginState(rdVal string, now time.Time) (*oidcLoginState, error) {
+	// Generate state
+	state, err := generateOIDCLoginRandomString()
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+	// Generate nonce
+	nonce, err := generateOIDCLoginRandomString()
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	return &oidcLoginState{
+		ExpiresAt:    now.Add(oidcLoginCookieMaxAge),
+		State:        state,
+		Nonce:        nonce,
+		PKCEVerifier: oauth2.GenerateVerifier(),
+		Redirect:     rdVal,
+	}, nil
+}
+
+// getOIDCLoginCookieName will return the login cookie name for a state.
+// State is part of the name to support parallel logins.
+func getOIDCLoginCookieName(cookieName, state string) string {
+	return cookieName + "_login_" + state
+}
+
+// signOIDCLoginState will serialize and sign login state with secret.
+func signOIDCLoginState(secret string, st *oidcLoginState) (string, error) {
+	// Marshal
+	payload, err := json.Marshal(st)
+	// Check error
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	// Encode payload
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encodedPayload + oidcLoginCookieSeparator + signOIDCLoginPayload(secret, encodedPayload), nil
+}
+
+// parseOIDCLoginState will verify login cookie signature and expiry and return login state.
+func parseOIDCLoginState(secret, value string, now time.Time) (*oidcLoginState, error) {
+	// Split payload and signature
+	encodedPayload, signature, found := strings.Cut(value, oidcLoginCookieSeparator)
+	// Check format
+	if !found {
+		return nil, errors.WithStack(errOIDCLoginCookieInvalid)
+	}
+
+	// Check signature
+	if !hmac.Equal([]byte(signature), []byte(signOIDCLoginPayload(secret, encodedPayload))) {
+		return nil, errors.WithStack(errOIDCLoginCookieInvalid)
+	}
+
+	// Decode payload
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(errOIDCLoginCookieInvalid)
+	}
+
+	// Parse
+	st := &oidcLoginState{}
+	// Unmarshal
+	err = json.Unmarshal(payload, st)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(errOIDCLoginCookieInvalid)
+	}
+
+	// Check expiry
+	if !now.Before(st.ExpiresAt) {
+		return nil, errors.WithStack(errOIDCLoginCookieExpired)
+	}
+
+	return st, nil
+}
+
+func signOIDCLoginPayload(secret, encodedPayload string) string {
+	// Create mac
+	mac := hmac.New(sha256.New, []byte(secret))
+	// Write payload
+	_, _ = mac.Write([]byte(encodedPayload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func generateOIDCLoginRandomString() (string, error) {
+	// Create buffer
+	b := make([]byte, oidcLoginRandomLength)
+	// Read random bytes
+	_, err := rand.Read(b)
+	// Check error
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,117 @@
+//go:build unit
+
+package authentication
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_newOIDCLoginState(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	st1, err := newOIDCLoginState("https://example.com/file", now)
+	require.NoError(t, err)
+	st2, err := newOIDCLoginState("", now)
+	require.NoError(t, err)
+
+	assert.Equal(t, now.Add(oidcLoginCookieMaxAge), st1.ExpiresAt)
+	assert.Equal(t, "https://example.com/file", st1.Redirect)
+	assert.Len(t, st1.State, 43)
+	assert.Len(t, st1.Nonce, 43)
+	assert.Len(t, st1.PKCEVerifier, 43)
+	// Values must be random for each login
+	assert.NotEqual(t, st1.State, st2.State)
+	assert.NotEqual(t, st1.Nonce, st2.Nonce)
+	assert.NotEqual(t, st1.PKCEVerifier, st2.PKCEVerifier)
+	assert.NotEqual(t, st1.State, st1.Nonce)
+}
+
+func Test_parseOIDCLoginState(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	st := &oidcLoginState{
+		ExpiresAt:    now.Add(oidcLoginCookieMaxAge),
+		State:        "state",
+		Nonce:        "nonce",
+		PKCEVerifier: "verifier",
+		Redirect:     "https://example.com/file",
+	}
+
+	value, err := signOIDCLoginState("secret", st)
+	require.NoError(t, err)
+
+	payload, signature, _ := strings.Cut(value, oidcLoginCookieSeparator)
+	otherValue, err := signOIDCLoginState("secret", &oidcLoginState{
+		ExpiresAt: st.ExpiresAt,
+		State:     "state",
+		Redirect:  "https://evil.com/",
+	})
+	require.NoError(t, err)
+
+	otherPayload, _, _ := strings.Cut(otherValue, oidcLoginCookieSeparator)
+
+	tests := []struct {
+		now     time.Time
+		want    *oidcLoginState
+		wantErr error
+		name    string
+		secret  string
+		value   string
+	}{
+		{
+			name:   "valid",
+			now:    now,
+			secret: "secret",
+			value:  value,
+			want:   st,
+		},
+		{
+			name:    "no signature",
+			now:     now,
+			secret:  "secret",
+			value:   payload,
+			wantErr: errOIDCLoginCookieInvalid,
+		},
+		{
+			name:    "wrong secret",
+			now:     now,
+			secret:  "other",
+			value:   value,
+			wantErr: errOIDCLoginCookieInvalid,
+		},
+		{
+			name:    "tampered payload",
+			now:     now,
+			secret:  "secret",
+			value:   otherPayload + oidcLoginCookieSeparator + signature,
+			wantErr: errOIDCLoginCookieInvalid,
+		},
+		{
+			name:    "expired",
+			now:     now.Add(oidcLoginCookieMaxAge),
+			secret:  "secret",
+			value:   value,
+			wantErr: errOIDCLoginCookieExpired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOIDCLoginState(tt.secret, tt.value, tt.now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_getOIDCLoginCookieName(t *testing.T) {
+	assert.Equal(t, "oidc_login_abc", getOIDCLoginCookieName("oidc", "abc"))
+}
@@ -0,0 +1,404 @@
+package authentication
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"emperror.dev/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+
+	oidc "github.com/coreos/go-oidc/v3/oidc"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/session"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+)
+
+const (
+	// oidcSessionIDLength is the number of random bytes used to generate a session identifier.
+	oidcSessionIDLength = 32
+	// oidcSessionRefreshLeeway is the duration before ID token expiry where a refresh is done.
+	oidcSessionRefreshLeeway = time.Minute
+)
+
+var errOIDCSessionInvalidCookie = errors.New("invalid oidc session cookie")
+
+// oidcSession is the content saved in session store.
+type oidcSession struct {
+	IDTokenExpiry time.Time `json:"idTokenExpiry"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+	IDToken       string    `json:"idToken"`
+	RefreshToken  string    `json:"refreshToken"`
+}
+
+type oidcSessionHandler struct {
+	store      session.Store
+	aead       cipher.AEAD
+	verifier   *oidc.IDTokenVerifier
+	oauthCfg   *oauth2.Config
+	now        func() time.Time
+	cookieName string
+	// Refreshes of a session are shared, keyed by session id, to avoid using a rotated refresh token
+	// twice in this process without blocking refreshes of other sessions.
+	// Other replicas sharing the store are handled by reloading the session on refresh failure.
+	refreshGroup singleflight.Group
+	maxDuration  time.Duration
+}
+
+func newOIDCSessionHandler(
+	oidcCfg *config.OIDCAuthConfig,
+	store session.Store,
+	verifier *oidc.IDTokenVerifier,
+	oauthCfg *oauth2.Config,
+) (*oidcSessionHandler, error) {
+	// Derive a fixed size key from encryption key
+	key := sha256.Sum256([]byte(oidcCfg.Session.EncryptionKey.Value))
+	// Create block cipher
+	block, err := aes.NewCipher(key[:])
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Create AEAD
+	aead, err := cipher.NewGCM(block)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &oidcSessionHandler{
+		store:       store,
+		aead:        aead,
+		verifier:    verifier,
+		oauthCfg:    oauthCfg,
+		now:         time.Now,
+		cookieName:  oidcCfg.CookieName,
+		maxDuration: oidcCfg.Session.MaxDuration,
+	}, nil
+}
+
+// create will save a new session and return the encrypted cookie value.
+func (h *oidcSessionHandler) create(ctx context.Context, rawIDToken string, idToken *oidc.IDToken, oauth2Token *oauth2.Token) (string, time.Time, error) {
+	// Generate session id
+	idB := make([]byte, oidcSessionIDLength)
+	// Read random bytes
+	_, err := rand.Read(idB)
+	// Check error
+	if err != nil {
+		return "", time.Time{}, errors.WithStack(err)
+	}
+	// Encode id
+	id := base64.RawURLEncoding.EncodeToString(idB)
+
+	// Create session
+	sess := &oidcSession{
+		IDTokenExpiry: idToken.Expiry,
+		ExpiresAt:     h.now().Add(h.maxDuration),
+		IDToken:       rawIDToken,
+		RefreshToken:  oauth2Token.RefreshToken,
+	}
+
+	// Save session
+	err = h.save(ctx, id, sess)
+	// Check error
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	// Encrypt session id for cookie
+	cookieValue, err := h.seal([]byte(id), []byte(h.cookieName))
+	// Check error
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(cookieValue), sess.ExpiresAt, nil
+}
+
+// getSessionID will decrypt session id from cookie value.
+func (h *oidcSessionHandler) getSessionID(cookieValue string) (string, error) {
+	// Decode cookie value
+	data, err := base64.RawURLEncoding.DecodeString(cookieValue)
+	// Check error
+	if err != nil {
+		return "", errors.WithStack(errOIDCSessionInvalidCookie)
+	}
+	// Decrypt
+	id, err := h.open(data, []byte(h.cookieName))
+	// Check error
+	if err != nil {
+		return "", errors.WithStack(errOIDCSessionInvalidCookie)
+	}
+
+	return string(id), nil
+}
+
+// load will return the session or nil if it doesn't exist or has expired.
+func (h *oidcSessionHandler) load(ctx context.Context, id string) (*oidcSession, error) {
+	// Get data from store
+	data, err := h.store.Get(ctx, id)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+	// Check if session exists
+	if data == nil {
+		return nil, nil
+	}
+
+	// Decrypt
+	plain, err := h.open(data, []byte(id))
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse
+	sess := &oidcSession{}
+	// Unmarshal
+	err = json.Unmarshal(plain, sess)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Check if session has expired
+	if !h.now().Before(sess.ExpiresAt) {
+		return nil, h.store.Delete(ctx, id)
+	}
+
+	return sess, nil
+}
+
+// save will encrypt and store session until its expiry.
+func (h *oidcSessionHandler) save(ctx context.Context, id string, sess *oidcSession) error {
+	// Marshal
+	plain, err := json.Marshal(sess)
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Encrypt
+	data, err := h.seal(plain, []byte(id))
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	return h.store.Set(ctx, id, data, sess.ExpiresAt.Sub(h.now()))
+}
+
+// getIDToken will return a valid ID token from the session cookie.
+// An empty string is returned when no valid session exists.
+func (h *oidcSessionHandler) getIDToken(ctx context.Context, logEntry log.Logger, cookieValue string) (string, error) {
+	// Get session id
+	id, err := h.getSessionID(cookieValue)
+	// Check error
+	if err != nil {
+		logEntry.Debug(err)
+
+		return "", nil
+	}
+
+	// Load session
+	sess, err := h.load(ctx, id)
+	// Check error
+	if err != nil {
+		return "", err
+	}
+	// Check if session exists
+	if sess == nil {
+		return "", nil
+	}
+
+	// Check if ID token is still valid
+	if h.now().Add(oidcSessionRefreshLeeway).Before(sess.IDTokenExpiry) {
+		return sess.IDToken, nil
+	}
+
+	// Refresh session once for all concurrent requests using it
+	// Context cancellation is ignored as the result is shared with other requests.
+	res, err, _ := h.refreshGroup.Do(id, func() (any, error) {
+		return h.refreshSession(context.WithoutCancel(ctx), logEntry, id)
+	})
+	// Check error
+	if err != nil {
+		return "", err
+	}
+
+	// Cast
+	idToken, _ := res.(string)
+
+	return idToken, nil
+}
+
+// refreshSession will reload the session and refresh its tokens if they are still expired.
+func (h *oidcSessionHandler) refreshSession(ctx context.Context, logEntry log.Logger, id string) (string, error) {
+	// Reload session as another request could have refreshed it
+	sess, err := h.load(ctx, id)
+	// Check error
+	if err != nil {
+		return "", err
+	}
+	// Check if session still exists and has been refreshed
+	if sess == nil {
+		return "", nil
+	}
+
+	if h.now().Add(oidcSessionRefreshLeeway).Before(sess.IDTokenExpiry) {
+		return sess.IDToken, nil
+	}
+
+	// Save used refresh token
+	usedRefreshToken := sess.RefreshToken
+
+	// Refresh tokens
+	err = h.refresh(ctx, sess)
+	// Check error
+	if err != nil {
+		return h.manageRefreshFailure(ctx, logEntry, id, usedRefreshToken, err)
+	}
+
+	// Save refreshed session
+	err = h.save(ctx, id, sess)
+	// Check error
+	if err != nil {
+		return "", err
+	}
+
+	logEntry.Debug("OIDC session refreshed")
+
+	return sess.IDToken, nil
+}
+
+// manageRefreshFailure will reload the session after a refresh failure.
+// Another replica sharing the store could have refreshed the session at the same time
+// and rotated the refresh token: in this case, its refreshed session is used.
+// Otherwise, the session is removed.
+func (h *oidcSessionHandler) manageRefreshFailure(
+	ctx context.Context,
+	logEntry log.Logger,
+	id, usedRefreshToken string,
+	refreshErr error,
+) (string, error) {
+	// Reload session
+	sess, err := h.load(ctx, id)
+	// Check error
+	if err != nil {
+		return "", err
+	}
+	// Check if session still exists
+	if sess == nil {
+		return "", nil
+	}
+
+	// Check if session has been refreshed by another replica
+	if sess.RefreshToken != usedRefreshToken && h.now().Add(oidcSessionRefreshLeeway).Before(sess.IDTokenExpiry) {
+		logEntry.Debug("OIDC session refreshed by another instance")
+
+		return sess.IDToken, nil
+	}
+
+	logEntry.Warnf("OIDC session refresh failed, session will be removed: %v", refreshErr)
+
+	return "", h.store.Delete(ctx, id)
+}
+
+// refresh will use the refresh token to get a new ID token.
+func (h *oidcSessionHandler) refresh(ctx context.Context, sess *oidcSession) error {
+	// Check if refresh token exists
+	if sess.RefreshToken == "" {
+		return errors.New("no refresh token in session")
+	}
+
+	// Get new tokens
+	tok, err := h.oauthCfg.TokenSource(ctx, &oauth2.Token{RefreshToken: sess.RefreshToken}).Token()
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Get ID token
+	rawIDToken, ok := tok.Extra("id_token").(string)
+	if !ok {
+		return errors.New("no id_token field in refreshed token")
+	}
+
+	// Verify it
+	idToken, err := h.verifier.Verify(ctx, rawIDToken)
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Update session
+	sess.IDToken = rawIDToken
+	sess.IDTokenExpiry = idToken.Expiry
+	// Check if refresh token has been rotated
+	if tok.RefreshToken != "" {
+		sess.RefreshToken = tok.RefreshToken
+	}
+
+	return nil
+}
+
+// delete will remove session linked to cookie value and return its ID token.
+func (h *oidcSessionHandler) delete(ctx context.Context, cookieValue string) (string, error) {
+	// Get session id
+	id, err := h.getSessionID(cookieValue)
+	// Check error
+	if err != nil {
+		return "", nil //nolint: nilerr // Invalid cookie means no session to delete
+	}
+
+	// Load session
+	sess, err := h.load(ctx, id)
+	// Check error
+	if err != nil {
+		return "", err
+	}
+	// Check if session exists
+	if sess == nil {
+		return "", nil
+	}
+
+	return sess.IDToken, h.store.Delete(ctx, id)
+}
+
+func (h *oidcSessionHandler) seal(plain, additionalData []byte) ([]byte, error) {
+	// Generate nonce
+	nonce := make([]byte, h.aead.NonceSize())
+	// Read random bytes
+	_, err := rand.Read(nonce)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return h.aead.Seal(nonce, nonce, plain, additionalData), nil
+}
+
+func (h *oidcSessionHandler) open(data, additionalData []byte) ([]byte, error) {
+	// Check size
+	if len(data) < h.aead.NonceSize() {
+		return nil, errors.New("encrypted oidc session data is too short")
+	}
+
+	// Split nonce and cipher text
+	nonce, ciphertext := data[:h.aead.NonceSize()], data[h.aead.NonceSize():]
+	// Decrypt
+	plain, err := h.aead.Open(nil, nonce, ciphertext, additionalData)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return plain, nil
+}
@@ -0,0 +1,470 @@
+//go:build unit
+
+package authentication
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	oidc "github.com/coreos/go-oidc/v3/oidc"
+	jose "github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+)
+
+type fakeSessionStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	ttls map[string]time.Duration
+}
+
+func (s *fakeSessionStore) Get(_ context.Context, id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.data[id], nil
+}
+
+func (s *fakeSessionStore) Set(_ context.Context, id string, data []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[id] = data
+	s.ttls[id] = ttl
+
+	return nil
+}
+
+func (s *fakeSessionStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data, id)
+
+	return nil
+}
+
+func (*fakeSessionStore) Close() error {
+	return nil
+}
+
+type oidcSessionTestEnv struct {
+	handler      *oidcSessionHandler
+	store        *fakeSessionStore
+	key          *rsa.PrivateKey
+	now          *time.Time
+	refreshCalls *int
+	refreshFail  *bool
+	// onRefresh is called by the token endpoint before answering.
+	onRefresh func()
+}
+
+func newOIDCSessionTestEnv(t *testing.T) *oidcSessionTestEnv {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	now := time.Now().Truncate(time.Second)
+	refreshCalls := 0
+	refreshFail := false
+
+	var refreshCallsMutex sync.Mutex
+
+	env := &oidcSessionTestEnv{
+		store:        &fakeSessionStore{data: map[string][]byte{}, ttls: map[string]time.Duration{}},
+		key:          key,
+		now:          &now,
+		refreshCalls: &refreshCalls,
+		refreshFail:  &refreshFail,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshCallsMutex.Lock()
+		refreshCalls++
+		refreshCallsMutex.Unlock()
+
+		require.NoError(t, r.ParseForm())
+
+		if env.onRefresh != nil {
+			env.onRefresh()
+		}
+
+		if refreshFail || r.PostForm.Get("refresh_token") != "refresh1" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  "access2",
+			"token_type":    "Bearer",
+			"refresh_token": "refresh1",
+			"expires_in":    3600,
+			"id_token":      env.signIDToken(t, "refreshed", (*env.now).Add(time.Hour)),
+		})
+	}))
+	t.Cleanup(ts.Close)
+
+	verifier := oidc.NewVerifier(
+		"https://issuer.example.com",
+		&oidc.StaticKeySet{PublicKeys: []crypto.PublicKey{key.Public()}},
+		&oidc.Config{ClientID: "client", Now: func() time.Time { return *env.now }},
+	)
+
+	env.handler, err = newOIDCSessionHandler(
+		&config.OIDCAuthConfig{
+			CookieName: "oidc",
+			Session: &config.OIDCSessionConfig{
+				EncryptionKey: &config.CredentialConfig{Value: "0123456789abcdef0123456789abcdef"},
+				MaxDuration:   24 * time.Hour,
+			},
+		},
+		env.store,
+		verifier,
+		&oauth2.Config{ClientID: "client", Endpoint: oauth2.Endpoint{TokenURL: ts.URL}},
+	)
+	require.NoError(t, err)
+
+	env.handler.now = func() time.Time { return *env.now }
+
+	return env
+}
+
+func (env *oidcSessionTestEnv) signIDToken(t *testing.T, subject string, expiry time.Time) string {
+	t.Helper()
+
+	return signJWT(t, jose.RS256, env.key, "", map[string]any{
+		"iss": "https://issuer.example.com",
+		"aud": "client",
+		"sub": subject,
+		"exp": expiry.Unix(),
+		"iat": (*env.now).Unix(),
+	})
+}
+
+func (env *oidcSessionTestEnv) login(t *testing.T) (string, string) {
+	t.Helper()
+
+	rawIDToken := env.signIDToken(t, "initial", (*env.now).Add(time.Hour))
+	idToken, err := env.handler.verifier.Verify(context.TODO(), rawIDToken)
+	require.NoError(t, err)
+
+	cookieValue, expiresAt, err := env.handler.create(
+		context.TODO(),
+		rawIDToken,
+		idToken,
+		(&oauth2.Token{RefreshToken: "refresh1"}).WithExtra(map[string]any{}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, (*env.now).Add(24*time.Hour), expiresAt)
+
+	return cookieValue, rawIDToken
+}
+
+func Test_oidcSessionHandler_sealOpen(t *testing.T) {
+	env := newOIDCSessionTestEnv(t)
+
+	data, err := env.handler.seal([]byte("secret"), []byte("aad"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+
+	plain, err := env.handler.open(data, []byte("aad"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), plain)
+
+	// Wrong additional data
+	_, err = env.handler.open(data, []byte("other"))
+	assert.Error(t, err)
+
+	// Too short
+	_, err = env.handler.open([]byte("a"), []byte("aad"))
+	assert.EqualError(t, err, "encrypted oidc session data is too short")
+}
+
+func Test_oidcSessionHandler_create(t *testing.T) {
+	env := newOIDCSessionTestEnv(t)
+
+	cookieValue, rawIDToken := env.login(t)
+
+	// Session id must be encrypted in cookie
+	id, err := env.handler.getSessionID(cookieValue)
+	require.NoError(t, err)
+	assert.NotEqual(t, id, cookieValue)
+	assert.Contains(t, env.store.data, id)
+	assert.Equal(t, 24*time.Hour, env.store.ttls[id])
+	// Tokens must be encrypted in store
+	assert.NotContains(t, string(env.store.data[id]), rawIDToken)
+	assert.NotContains(t, string(env.store.data[id]), "refresh1")
+
+	// Get ID token without refresh
+	got, err := env.handler.getIDToken(context.TODO(), log.NewLogger(), cookieValue)
+	require.NoError(t, err)
+	assert.Equal(t, rawIDToken, got)
+	assert.Equal(t, 0, *env.refreshCalls)
+}
+
+func Test_oidcSessionHandler_getIDToken_InvalidCookie(t *testing.T) {
+	env := newOIDCSessionTestEnv(t)
+
+	// Not base64
+	got, err := env.handler.getIDToken(context.TODO(), log.NewLogger(), "%%%")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	// Forged value
+	got, err = env.handler.getIDToken(context.TODO(), log.NewLogger(), "Zm9yZ2VkLXNlc3Npb24taWQtdmFsdWU")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	// Deleted session
+	cookieValue, _ := env.login(t)
+	idToken, err := env.handler.delete(context.TODO(), cookieValue)
+	require.NoError(t, err)
+	assert.NotEmpty(t, idToken)
+	assert.Empty(t, env.store.data)
+
+	got, err = env.handler.getIDToken(context.TODO(), log.NewLogger(), cookieValue)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func Test_oidcSessionHandler_getIDToken_Refresh(t *testing.T) {
+	env := newOIDCSessionTestEnv(t)
+
+	cookieValue, rawIDToken := env.login(t)
+
+	// ID token is near expiry
+	*env.now = (*env.now).Add(time.Hour - 30*time.Second)
+
+	got, err := env.handler.getIDToken(context.TODO(), log.NewLogger(), cookieValue)
+	require.NoError(t, err)
+	assert.NotEqual(t, rawIDToken, got)
+	assert.Equal(t, 1, *env.refreshCalls)
+
+	// Refreshed token is saved
+	got2, err := env.handler.getIDToken(context.TODO(), log.NewLogger(), cookieValue)
+	require.NoError(t, err)
+	assert.Equal(t, got, got2)
+	assert.Equal(t, 1, *env.refreshCalls)
+}
+
+func Test_oidcSessionHandler_getIDToken_ConcurrentRefresh(t *testing.T) {
+	env := newOIDCSessionTestEnv(t)
+
+	cookieValue, rawIDToken := env.login(t)
+
+	// ID token has expired
+	*env.now = (*env.now).Add(2 * time.Hour)
+
+	var wg sync.WaitGroup
+
+	results := make([]string, 10)
+	for i := range results {
+		wg.Go(func() {
+			got, err := env.handler.getIDToken(context.TODO(), log.NewLogger(), cookieValue)
+			assert.NoError(t, err)
+
+			results[i] = got
+		})
+	}
+
+	wg.Wait()
+
+	// Refresh token must be used only once
+	assert.Equal(t, 1, *env.refreshCalls)
+
+	for _, got := range results {
+		assert.NotEmpty(t, got)
+		assert.NotEqual(t, rawIDToken, got)
+		assert.Equal(t, results[0], got)
+	}
+}
+
+func Test_oidcSessionHandler_getIDToken_RefreshNotBlockingOtherSessions(t *testing.T) {
+	env := newOIDCSessionTestEnv(t)
+
+	cookieValue1, _ := env.login(t)
+	cookieValue2, _ := env.login(t)
+
+	// ID tokens have expired
+	*env.now = (*env.now).Add(2 * time.Hour)
+
+	// First refresh is blocked until released
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var blocked atomic.Bool
+
+	env.onRefresh = func() {
+		if blocked.CompareAndSwap(false, true) {
+			close(started)
+			<-release
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Go(func() {
+		got, err := env.handler.getIDToken(context.TODO(), log.NewLogger(), cookieValue1)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, got)
+	})
+
+	<-started
+
+	// Other session must be refreshed while the first refresh is in progress
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		got, err := env.handler.getIDToken(context.TODO(), log.NewLogger(), cookieValue2)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, got)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("refresh of another session is blocked")
+	}
+
+	close(release)
+	wg.Wait()
+	<-done
+
+	assert.Equal(t, 2, *env.refreshCalls)
+}
+
+func Test_oidcSessionHandler_getIDToken_RefreshFailure(t *testing.T) {
+	env := newOIDCSessionTestEnv(t)
+
+	cookieValue, _ := env.login(t)
+
+	// ID token has expired
+	*env.now = (*env.now).Add(2 * time.Hour)
+	*env.refreshFail = true
+
+	got, err := env.handler.getIDToken(context.TODO(), log.NewLogger(), cookieValue)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	// Session must be removed
+	assert.Empty(t, env.store.data)
+}
+
+func Test_oidcSessionHandler_getIDToken_RefreshedByAnotherInstance(t *testing.T) {
+	env := newOIDCSessionTestEnv(t)
+
+	cookieValue, _ := env.login(t)
+	id, err := env.handler.getSessionID(cookieValue)
+	require.NoError(t, err)
+
+	// ID token has expired
+	*env.now = (*env.now).Add(2 * time.Hour)
+
+	// Another instance refreshes the session first and rotates the refresh token
+	refreshedIDToken := env.signIDToken(t, "other-instance", (*env.now).Add(time.Hour))
+	env.onRefresh = func() {
+		*env.refreshFail = true
+
+		assert.NoError(t, env.handler.save(context.TODO(), id, &oidcSession{
+			IDTokenExpiry: (*env.now).Add(time.Hour),
+			ExpiresAt:     (*env.now).Add(time.Hour),
+			IDToken:       refreshedIDToken,
+			RefreshToken:  "refresh2",
+		}))
+	}
+
+	got, err := env.handler.getIDToken(context.TODO(), log.NewLogger(), cookieValue)
+	require.NoError(t, err)
+	assert.Equal(t, refreshedIDToken, got)
+	// Session must be kept
+	assert.Contains(t, env.store.data, id)
+}
+
+func Test_oidcSessionHandler_getIDToken_SessionExpired(t *testing.T) {
+	env := newOIDCSessionTestEnv(t)
+
+	cookieValue, _ := env.login(t)
+
+	// Session has expired
+	*env.now = (*env.now).Add(25 * time.Hour)
+
+	got, err := env.handler.getIDToken(context.TODO(), log.NewLogger(), cookieValue)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	assert.Empty(t, env.store.data)
+	assert.Equal(t, 0, *env.refreshCalls)
+}
+
+func Test_buildOIDCLogoutRedirectURL(t *testing.T) {
+	tests := []struct {
+		oidcCfg            *config.OIDCAuthConfig
+		name               string
+		endSessionEndpoint string
+		idTokenHint        string
+		want               string
+	}{
+		{
+			name:    "no end session endpoint and no post logout redirect url",
+			oidcCfg: &config.OIDCAuthConfig{ClientID: "client"},
+			want:    "/",
+		},
+		{
+			name:    "no end session endpoint",
+			oidcCfg: &config.OIDCAuthConfig{ClientID: "client", PostLogoutRedirectURL: "https://app.example.com/"},
+			want:    "https://app.example.com/",
+		},
+		{
+			name:               "end session endpoint",
+			oidcCfg:            &config.OIDCAuthConfig{ClientID: "client", PostLogoutRedirectURL: "https://app.example.com/"},
+			endSessionEndpoint: "https://idp.example.com/logout?foo=bar",
+			idTokenHint:        "token",
+			want: "https://idp.example.com/logout?" + url.Values{
+				"foo":                      {"bar"},
+				"client_id":                {"client"},
+				"id_token_hint":            {"token"},
+				"post_logout_redirect_uri": {"https://app.example.com/"},
+			}.Encode(),
+		},
+		{
+			name:               "end session endpoint without hint",
+			oidcCfg:            &config.OIDCAuthConfig{ClientID: "client"},
+			endSessionEndpoint: "https://idp.example.com/logout",
+			want:               "https://idp.example.com/logout?client_id=client",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, buildOIDCLogoutRedirectURL(tt.oidcCfg, tt.endSessionEndpoint, tt.idTokenHint))
+		})
+	}
+}
+
+func Test_getOIDCCookieDomain(t *testing.T) {
+	oidcCfg := &config.OIDCAuthConfig{CookieDomains: []string{"example.org", "example.com"}}
+
+	r := httptest.NewRequest(http.MethodGet, "http://app.example.com/", nil)
+	assert.Equal(t, "example.com", getOIDCCookieDomain(oidcCfg, r))
+
+	r = httptest.NewRequest(http.MethodGet, "http://app.example.net/", nil)
+	assert.Empty(t, getOIDCCookieDomain(oidcCfg, r))
+}
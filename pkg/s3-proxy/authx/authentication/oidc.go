@@ -2,11 +2,13 @@ package authentication
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"emperror.dev/errors"
 	"github.com/go-chi/chi/v5"
@@ -22,12 +24,9 @@ import (
 	utils "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/utils/generalutils"
 )
 
-const (
-	redirectQueryKey       = "rd"
-	stateRedirectSeparator = ":"
-)
+const redirectQueryKey = "rd"
 
-// OIDCEndpoints will set OpenID Connect endpoints for authentication and callback.
+// OIDCEndpoints will set OpenID Connect endpoints for authentication, callback and logout.
 func (s *service) OIDCEndpoints(providerKey string, oidcCfg *config.OIDCAuthConfig, mux chi.Router) error {
 	ctx := context.Background()
 
@@ -60,20 +59,83 @@ func (s *service) OIDCEndpoints(providerKey string, oidcCfg *config.OIDCAuthConf
 		mainRedirectURLCallbackPath = mainRedirectURLObject.Path
 	}
 
-	// Store state
-	state := oidcCfg.State
+	// Store state secret used to sign login cookies
+	stateSecret := oidcCfg.LoginStateSecret.Value
 
 	// Store provider verifier in map
 	s.allVerifiers[providerKey] = verifier
 
+	// Initialize session handler
+	var sessionHandler *oidcSessionHandler
+	// Check if server side sessions are enabled
+	if oidcCfg.Session != nil && oidcCfg.Session.Enabled {
+		// Get session store
+		store := s.sessionManager.GetStore(providerKey)
+		// Check if store exists
+		if store == nil {
+			return errors.Errorf("no session store loaded for oidc provider %s", providerKey)
+		}
+
+		// Create session handler
+		// No request is given as redirect url isn't needed for refresh token grant
+		sessionHandler, err = newOIDCSessionHandler(oidcCfg, store, verifier, generateOIDCConfig(oidcCfg, provider, mainRedirectURLStr, nil))
+		// Check error
+		if err != nil {
+			return err
+		}
+
+		// Store session handler in map
+		s.allOIDCSessionHandlers[providerKey] = sessionHandler
+	}
+
+	// Get end session endpoint from provider discovery document
+	var providerClaims struct {
+		EndSessionEndpoint string `json:"end_session_endpoint"`
+	}
+	// Parse claims
+	err = provider.Claims(&providerClaims)
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
 	mux.HandleFunc(oidcCfg.LoginPath, func(w http.ResponseWriter, r *http.Request) {
 		// Parse query params from request
 		qs := r.URL.Query()
 		// Get redirect query from query params
 		rdVal := qs.Get(redirectQueryKey)
-		// Build new state with redirect value
-		// Same solution as here: https://github.com/oauth2-proxy/oauth2-proxy/blob/3fa42edb7350219d317c4bd47faf5da6192dc70f/oauthproxy.go#L751
-		newState := state + stateRedirectSeparator + rdVal
+		// Generate per login state, nonce and PKCE verifier bound to redirect value
+		loginState, err := newOIDCLoginState(rdVal, time.Now())
+		// Check error
+		if err != nil {
+			// Answer
+			responsehandler.GeneralInternalServerError(r, w, s.cfgManager, err)
+
+			return
+		}
+		// Sign login state
+		loginCookieValue, err := signOIDCLoginState(stateSecret, loginState)
+		// Check error
+		if err != nil {
+			// Answer
+			responsehandler.GeneralInternalServerError(r, w, s.cfgManager, err)
+
+			return
+		}
+
+		// Save login state in a short-lived cookie
+		// Lax mode is needed to receive it on callback redirect from identity provider
+		http.SetCookie(w, &http.Cookie{
+			Expires:  loginState.ExpiresAt,
+			MaxAge:   int(oidcLoginCookieMaxAge.Seconds()),
+			Name:     getOIDCLoginCookieName(oidcCfg.CookieName, loginState.State),
+			Value:    loginCookieValue,
+			HttpOnly: true,
+			Secure:   oidcCfg.CookieSecure,
+			SameSite: http.SameSiteLaxMode,
+			Path:     "/",
+			Domain:   getOIDCCookieDomain(oidcCfg, r),
+		})
 
 		// Create OIDC configuration
 		config := generateOIDCConfig(
@@ -83,7 +145,12 @@ func (s *service) OIDCEndpoints(providerKey string, oidcCfg *config.OIDCAuthConf
 			r,
 		)
 
-		http.Redirect(w, r, config.AuthCodeURL(newState), http.StatusFound)
+		http.Redirect(
+			w,
+			r,
+			config.AuthCodeURL(loginState.State, oidc.Nonce(loginState.Nonce), oauth2.S256ChallengeOption(loginState.PKCEVerifier)),
+			http.StatusFound,
+		)
 	})
 
 	mux.HandleFunc(mainRedirectURLCallbackPath, func(w http.ResponseWriter, r *http.Request) {
@@ -106,26 +173,55 @@ func (s *service) OIDCEndpoints(providerKey string, oidcCfg *config.OIDCAuthConf
 			return
 		}
 
-		// Split request query state to get redirect url and original state
-		split := strings.SplitN(reqQueryState, stateRedirectSeparator, 2) //nolint: mnd // Ignoring because create by app just before
-		// Prepare and affect values
-		reqState := split[0]
-		rdVal := ""
-		// Check if length is ok to include a redirect url
-		if len(split) == 2 { //nolint: mnd // No constant for that
-			rdVal = split[1]
+		// Get login cookie name
+		loginCookieName := getOIDCLoginCookieName(oidcCfg.CookieName, reqQueryState)
+		// Get login cookie
+		loginCookie, err := r.Cookie(loginCookieName)
+		// Check error
+		if err != nil {
+			// Create error
+			err = errors.New("login cookie not found for state in request")
+			// Answer
+			responsehandler.GeneralBadRequestError(r, w, s.cfgManager, err)
+
+			return
+		}
+
+		// Clear login cookie as it can be used only once
+		http.SetCookie(w, &http.Cookie{
+			Name:     loginCookieName,
+			Value:    "",
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   oidcCfg.CookieSecure,
+			SameSite: http.SameSiteLaxMode,
+			Path:     "/",
+			Domain:   getOIDCCookieDomain(oidcCfg, r),
+		})
+
+		// Parse and verify login state
+		loginState, err := parseOIDCLoginState(stateSecret, loginCookie.Value, time.Now())
+		// Check error
+		if err != nil {
+			// Answer
+			responsehandler.GeneralBadRequestError(r, w, s.cfgManager, err)
+
+			return
 		}
 
 		// Check state
-		if reqState != state {
+		if subtle.ConstantTimeCompare([]byte(loginState.State), []byte(reqQueryState)) != 1 {
 			// Create error
-			err := errors.New("state did not match")
+			err = errors.New("state did not match")
 			// Answer
 			responsehandler.GeneralBadRequestError(r, w, s.cfgManager, err)
 
 			return
 		}
 
+		// Get redirect value bound to state
+		rdVal := loginState.Redirect
+
 		// Check if rdVal exists and that redirect url value is valid
 		if rdVal != "" {
 			isValid, err := isValidRedirect(rdVal, utils.GetRequestURI(r))
@@ -155,7 +251,7 @@ func (s *service) OIDCEndpoints(providerKey string, oidcCfg *config.OIDCAuthConf
 			r,
 		)
 
-		oauth2Token, err := config.Exchange(ctx, r.URL.Query().Get("code"))
+		oauth2Token, err := config.Exchange(ctx, r.URL.Query().Get("code"), oauth2.VerifierOption(loginState.PKCEVerifier))
 		if err != nil {
 			// Create error
 			err = errors.New("failed to exchange token: " + err.Error())
@@ -185,6 +281,16 @@ func (s *service) OIDCEndpoints(providerKey string, oidcCfg *config.OIDCAuthConf
 			return
 		}
 
+		// Check nonce
+		if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(loginState.Nonce)) != 1 {
+			// Create error
+			err = errors.New("nonce did not match")
+			// Answer
+			responsehandler.GeneralUnauthorizedError(r, w, s.cfgManager, err)
+
+			return
+		}
+
 		var resp map[string]any
 
 		// Try to open JWT token in order to verify that we can open it
@@ -196,30 +302,31 @@ func (s *service) OIDCEndpoints(providerKey string, oidcCfg *config.OIDCAuthConf
 			return
 		}
 
-		// Initialize cookie domain
-		cookieDomain := ""
-		// Get request host of current request
-		reqHost := utils.GetRequestHost(r)
-		// Loop over domains
-		for _, it := range oidcCfg.CookieDomains {
-			// Check if domain asked is matching the one in the request host
-			if strings.HasSuffix(reqHost, it) {
-				// Save cookie domain
-				cookieDomain = it
-				// Break the loop
-				break
+		// Default cookie contains ID token
+		cookieValue := rawIDToken
+		cookieExpiry := idToken.Expiry
+		// Check if server side session must be created
+		if sessionHandler != nil {
+			// Create session
+			cookieValue, cookieExpiry, err = sessionHandler.create(ctx, rawIDToken, idToken, oauth2Token)
+			// Check error
+			if err != nil {
+				// Answer
+				responsehandler.GeneralInternalServerError(r, w, s.cfgManager, err)
+
+				return
 			}
 		}
 
 		// Build cookie
 		cookie := &http.Cookie{
-			Expires:  idToken.Expiry,
+			Expires:  cookieExpiry,
 			Name:     oidcCfg.CookieName,
-			Value:    rawIDToken,
+			Value:    cookieValue,
 			HttpOnly: true,
 			Secure:   oidcCfg.CookieSecure,
 			Path:     "/",
-			Domain:   cookieDomain,
+			Domain:   getOIDCCookieDomain(oidcCfg, r),
 		}
 		http.SetCookie(w, cookie)
 
@@ -232,9 +339,105 @@ func (s *service) OIDCEndpoints(providerKey string, oidcCfg *config.OIDCAuthConf
 		http.Redirect(w, r, rdVal, http.StatusTemporaryRedirect)
 	})
 
+	mux.HandleFunc(oidcCfg.LogoutPath, func(w http.ResponseWriter, r *http.Request) {
+		// Get context
+		ctx := r.Context()
+		// Get logger from request
+		logEntry := log.GetLoggerFromContext(ctx)
+
+		// Initialize ID token hint
+		idTokenHint := ""
+		// Get cookie
+		cookie, err := r.Cookie(oidcCfg.CookieName)
+		// Check if cookie exists
+		if err == nil && cookie.Value != "" {
+			// Check if server side session is enabled
+			if sessionHandler != nil {
+				// Delete session
+				idTokenHint, err = sessionHandler.delete(ctx, cookie.Value)
+				// Check error
+				if err != nil {
+					// Answer
+					responsehandler.GeneralInternalServerError(r, w, s.cfgManager, err)
+
+					return
+				}
+			} else {
+				// Cookie contains ID token
+				idTokenHint = cookie.Value
+			}
+		}
+
+		// Clear cookie
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcCfg.CookieName,
+			Value:    "",
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   oidcCfg.CookieSecure,
+			Path:     "/",
+			Domain:   getOIDCCookieDomain(oidcCfg, r),
+		})
+
+		logEntry.Info("Successful logout detected")
+		http.Redirect(w, r, buildOIDCLogoutRedirectURL(oidcCfg, providerClaims.EndSessionEndpoint, idTokenHint), http.StatusFound)
+	})
+
 	return nil
 }
 
+// buildOIDCLogoutRedirectURL will build the redirect url after logout.
+// IdP end session endpoint is used when available, otherwise post logout redirect url is used.
+func buildOIDCLogoutRedirectURL(oidcCfg *config.OIDCAuthConfig, endSessionEndpoint, idTokenHint string) string {
+	// Check if end session endpoint isn't available
+	if endSessionEndpoint == "" {
+		// Check if post logout redirect url is set
+		if oidcCfg.PostLogoutRedirectURL != "" {
+			return oidcCfg.PostLogoutRedirectURL
+		}
+
+		return "/"
+	}
+
+	// Parse end session endpoint
+	u, err := url.Parse(endSessionEndpoint)
+	// Check error
+	if err != nil {
+		return "/"
+	}
+
+	// Build query
+	qs := u.Query()
+	qs.Set("client_id", oidcCfg.ClientID)
+	// Check if id token hint exists
+	if idTokenHint != "" {
+		qs.Set("id_token_hint", idTokenHint)
+	}
+	// Check if post logout redirect url exists
+	if oidcCfg.PostLogoutRedirectURL != "" {
+		qs.Set("post_logout_redirect_uri", oidcCfg.PostLogoutRedirectURL)
+	}
+	// Save query
+	u.RawQuery = qs.Encode()
+
+	return u.String()
+}
+
+// getOIDCCookieDomain will return the configured cookie domain matching the request host.
+func getOIDCCookieDomain(oidcCfg *config.OIDCAuthConfig, r *http.Request) string {
+	// Get request host of current request
+	reqHost := utils.GetRequestHost(r)
+	// Loop over domains
+	for _, it := range oidcCfg.CookieDomains {
+		// Check if domain asked is matching the one in the request host
+		if strings.HasSuffix(reqHost, it) {
+			return it
+		}
+	}
+
+	return ""
+}
+
 func (s *service) oidcAuthMiddleware(res *config.Resource) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -250,7 +453,7 @@ func (s *service) oidcAuthMiddleware(res *config.Resource) func(http.Handler) ht
 			resHan := responsehandler.GetResponseHandlerFromContext(ctx)
 
 			// Get JWT Token from header or cookie
-			jwtContent, err := getJWTToken(logEntry, r, oidcAuthCfg.CookieName)
+			jwtContent, err := s.getOIDCToken(logEntry, r, res.Provider, oidcAuthCfg.CookieName)
 			// Check if error exists
 			if err != nil {
 				// Check if bucket request context doesn't exist to use local default files
@@ -288,11 +491,13 @@ func (s *service) oidcAuthMiddleware(res *config.Resource) func(http.Handler) ht
 			claims, err := parseAndValidateJWTToken(ctx, jwtContent, s.allVerifiers[res.Provider])
 			if err != nil {
 				logEntry.Error(err)
+				// Invalid token is an authentication failure
+				// This allows next providers to try it on resources with multiple providers.
 				// Check if bucket request context doesn't exist to use local default files
 				if brctx == nil {
-					responsehandler.GeneralInternalServerError(r, w, s.cfgManager, err)
+					responsehandler.GeneralUnauthorizedError(r, w, s.cfgManager, err)
 				} else {
-					resHan.InternalServerError(brctx.LoadFileContent, err)
+					resHan.UnauthorizedError(brctx.LoadFileContent, err)
 				}
 
 				return
@@ -410,6 +615,32 @@ func parseAndValidateJWTToken(
 	return res, nil
 }
 
+// getOIDCToken will get JWT token from header, cookie or server side session.
+func (s *service) getOIDCToken(logEntry log.Logger, r *http.Request, providerKey, cookieName string) (string, error) {
+	// Get session handler
+	sessionHandler := s.allOIDCSessionHandlers[providerKey]
+	// Check if server side sessions aren't enabled or if Authorization header is present
+	if sessionHandler == nil || r.Header.Get("Authorization") != "" {
+		return getJWTToken(logEntry, r, cookieName)
+	}
+
+	logEntry.Debug("Try get session cookie from request")
+	// Try to get session cookie
+	cookie, err := r.Cookie(cookieName)
+	// Check if error exists
+	if err != nil {
+		logEntry.Debug("Can't load session cookie")
+
+		if !errors.Is(err, http.ErrNoCookie) {
+			return "", errors.WithStack(err)
+		}
+
+		return "", nil
+	}
+
+	return sessionHandler.getIDToken(r.Context(), logEntry, cookie.Value)
+}
+
 func getJWTToken(logEntry log.Logger, r *http.Request, cookieName string) (string, error) {
 	logEntry.Debug("Try to get Authorization header from request")
 	// Get Authorization header
@@ -467,10 +698,10 @@ func generateOIDCConfig(
 	}
 
 	// Check if main redirect url is defined
-	// Otherwise build a dynamic one
+	// Otherwise build a dynamic one when request is available
 	if mainRedirectURL != "" {
 		cfg.RedirectURL = mainRedirectURL
-	} else {
+	} else if req != nil {
 		cfg.RedirectURL = fmt.Sprintf(
 			"%s://%s%s",
 			utils.GetRequestScheme(req),
@@ -0,0 +1,228 @@
+package authentication
+
+import (
+	"net/http"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+)
+
+// selectProviderResources will select the providers used to authenticate a request on a resource with multiple providers.
+// Candidates are providers with credentials in the request, in declared order: bearer token, Basic credentials and other
+// request credentials (JWT, OIDC bearer, API key, client certificate, HMAC signature, header, Basic or LDAP),
+// then OIDC providers with their cookie.
+// When no credentials are found, the first Basic provider is used to ask them with a challenge,
+// otherwise the first provider is used.
+// The returned resources are restricted to one provider.
+func (s *service) selectProviderResources(r *http.Request, res *config.Resource) []*config.Resource {
+	// Get provider resources
+	providerResources := make([]*config.Resource, 0, len(res.Providers))
+	// Loop over providers
+	for _, prov := range res.Providers {
+		// Get provider resource
+		pres := res.GetProviderResource(s.cfg.AuthProviders, prov)
+		// Check if it exists (ensured by validation)
+		if pres != nil {
+			providerResources = append(providerResources, pres)
+		}
+	}
+
+	// Check if no provider is found
+	if len(providerResources) == 0 {
+		return nil
+	}
+
+	// Create result
+	candidates := []*config.Resource{}
+
+	// Check bearer token and other request credentials
+	for _, pres := range providerResources {
+		if s.hasRequestCredentials(r, pres) {
+			candidates = append(candidates, pres)
+		}
+	}
+
+	// Check cookies
+	// Authorization header must be empty otherwise OIDC will consider it before cookie
+	if r.Header.Get("Authorization") == "" {
+		for _, pres := range providerResources {
+			if pres.OIDC != nil && hasCookie(r, s.cfg.AuthProviders.OIDC[pres.Provider].CookieName) {
+				candidates = append(candidates, pres)
+			}
+		}
+	}
+
+	// Check if candidates are found
+	if len(candidates) != 0 {
+		return candidates
+	}
+
+	// Check basic providers
+	// The first one will send a challenge because credentials aren't present
+	for _, pres := range providerResources {
+		if pres.Basic != nil || pres.LDAP != nil {
+			return []*config.Resource{pres}
+		}
+	}
+
+	return providerResources[:1]
+}
+
+// hasRequestCredentials will check if request contains bearer token or other credentials supported by provider.
+func (s *service) hasRequestCredentials(r *http.Request, pres *config.Resource) bool {
+	switch {
+	case pres.JWT != nil, pres.OIDC != nil:
+		return getBearerToken(r) != ""
+	case pres.APIKey != nil:
+		return getAPIKey(r, s.cfg.AuthProviders.APIKey[pres.Provider]) != ""
+	case pres.MTLS != nil:
+		return getVerifiedClientCertificate(r) != nil
+	case pres.HMAC != nil:
+		return hasHMACSignature(r)
+	case pres.Header != nil:
+		// Get header configuration
+		headerCfg := s.cfg.AuthProviders.Header[pres.Provider]
+
+		return r.Header.Get(headerCfg.UsernameHeader) != "" || r.Header.Get(headerCfg.EmailHeader) != ""
+	case pres.Basic != nil, pres.LDAP != nil:
+		_, _, ok := r.BasicAuth()
+
+		return ok
+	default:
+		return false
+	}
+}
+
+// hasCookie will check if request contains a non empty cookie.
+func hasCookie(r *http.Request, name string) bool {
+	// Get cookie
+	cookie, err := r.Cookie(name)
+
+	return err == nil && cookie.Value != ""
+}
+
+// providersAuthMiddleware will authenticate a request with the first candidate provider that accepts it.
+// Unauthorized answers of candidates are discarded to try the next one, so only the last candidate answers
+// with its error and its challenge. Other answers (success, redirect, errors) stop the loop.
+func (s *service) providersAuthMiddleware(candidates []*config.Resource) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get logger from request
+			logEntry := log.GetLoggerFromContext(r.Context())
+			// Get response handler
+			resHan := responsehandler.GetResponseHandlerFromContext(r.Context())
+
+			// Loop over candidates
+			for i, pres := range candidates {
+				// Check if it is the last candidate
+				if i == len(candidates)-1 {
+					logEntry.Debugf("provider %s selected for authentication", pres.Provider)
+					s.resourceAuthMiddleware(pres)(next).ServeHTTP(w, r)
+
+					return
+				}
+
+				logEntry.Debugf("trying provider %s for authentication", pres.Provider)
+
+				// Create attempt response writer
+				aw := newAuthAttemptWriter(w)
+				// Update response handler to answer on attempt response writer
+				resHan.UpdateRequestAndResponse(r, aw)
+
+				// Try provider
+				s.resourceAuthMiddleware(pres)(aw.wrap(next)).ServeHTTP(aw, r)
+
+				// Check if provider has answered
+				if !aw.unauthorized {
+					return
+				}
+
+				logEntry.Debugf("authentication with provider %s failed, trying next provider", pres.Provider)
+
+				// Restore response handler with request without values added by the failed provider
+				resHan.UpdateRequestAndResponse(r, w)
+			}
+		})
+	}
+}
+
+// authAttemptWriter is a response writer used to try a provider among several ones.
+// Headers are kept aside until the provider answers. An unauthorized answer is discarded
+// and any other answer is written on the original response writer.
+type authAttemptWriter struct {
+	w            http.ResponseWriter
+	header       http.Header
+	unauthorized bool
+	written      bool
+}
+
+func newAuthAttemptWriter(w http.ResponseWriter) *authAttemptWriter {
+	return &authAttemptWriter{
+		w:      w,
+		header: w.Header().Clone(),
+	}
+}
+
+func (aw *authAttemptWriter) Header() http.Header {
+	// Check if answer is written
+	if aw.written {
+		return aw.w.Header()
+	}
+
+	return aw.header
+}
+
+func (aw *authAttemptWriter) WriteHeader(statusCode int) {
+	// Check if answer is discarded
+	if aw.unauthorized {
+		return
+	}
+	// Check if it is an unauthorized answer
+	if !aw.written && statusCode == http.StatusUnauthorized {
+		aw.unauthorized = true
+
+		return
+	}
+
+	aw.flushHeader()
+	aw.w.WriteHeader(statusCode)
+}
+
+func (aw *authAttemptWriter) Write(b []byte) (int, error) {
+	// Check if answer is discarded
+	if aw.unauthorized {
+		return len(b), nil
+	}
+
+	aw.flushHeader()
+
+	return aw.w.Write(b)
+}
+
+// flushHeader will copy headers set by provider on original response writer.
+func (aw *authAttemptWriter) flushHeader() {
+	// Check if it is already done
+	if aw.written {
+		return
+	}
+
+	// Copy headers
+	for k, v := range aw.header {
+		aw.w.Header()[k] = v
+	}
+
+	aw.written = true
+}
+
+// wrap will return a handler calling next with the original response writer once the provider has authenticated the request.
+func (aw *authAttemptWriter) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		// Keep headers set by provider (cookies, ...)
+		aw.flushHeader()
+		// Update response handler to answer on original response writer
+		responsehandler.GetResponseHandlerFromContext(r.Context()).UpdateRequestAndResponse(r, aw.w)
+
+		next.ServeHTTP(aw.w, r)
+	})
+}
@@ -0,0 +1,387 @@
+//go:build unit
+
+package authentication
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	mmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics/mocks"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+)
+
+func Test_service_selectProviderResources(t *testing.T) {
+	s := &service{
+		cfg: &config.Config{
+			AuthProviders: &config.AuthProviderConfig{
+				Basic:  map[string]*config.BasicAuthConfig{"basic1": {Realm: "realm"}},
+				OIDC:   map[string]*config.OIDCAuthConfig{"oidc1": {CookieName: "oidc"}},
+				JWT:    map[string]*config.JWTAuthConfig{"jwt1": {}},
+				APIKey: map[string]*config.APIKeyAuthConfig{"apikey1": {Header: "X-API-Key"}},
+				MTLS:   map[string]*config.MTLSAuthConfig{"mtls1": {}},
+				Header: map[string]*config.HeaderAuthConfig{"header1": {UsernameHeader: "X-User", EmailHeader: "X-Email"}},
+				HMAC:   map[string]*config.HMACAuthConfig{"hmac1": {}},
+				LDAP:   map[string]*config.LDAPAuthConfig{"ldap1": {}},
+			},
+		},
+	}
+
+	allRes := &config.Resource{
+		Path:      "/*",
+		Methods:   []string{"GET"},
+		Providers: []string{"oidc1", "jwt1", "apikey1", "mtls1", "header1", "basic1"},
+		Basic:     &config.ResourceBasic{},
+		OIDC:      &config.ResourceHeaderOIDC{},
+		JWT:       &config.ResourceHeaderOIDC{},
+		APIKey:    &config.ResourceHeaderOIDC{},
+		MTLS:      &config.ResourceHeaderOIDC{},
+		Header:    &config.ResourceHeaderOIDC{},
+	}
+
+	tests := []struct {
+		res           *config.Resource
+		setup         func(r *http.Request)
+		name          string
+		wantProviders []string
+	}{
+		{
+			name: "bearer token uses bearer providers in declared order",
+			res:  allRes,
+			setup: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer token")
+				r.AddCookie(&http.Cookie{Name: "oidc", Value: "cookie"})
+			},
+			wantProviders: []string{"oidc1", "jwt1"},
+		},
+		{
+			name: "bearer token uses jwt provider first when declared first",
+			res: &config.Resource{
+				Providers: []string{"jwt1", "oidc1"},
+				OIDC:      &config.ResourceHeaderOIDC{},
+				JWT:       &config.ResourceHeaderOIDC{},
+			},
+			setup: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer token")
+			},
+			wantProviders: []string{"jwt1", "oidc1"},
+		},
+		{
+			name: "api key",
+			res:  allRes,
+			setup: func(r *http.Request) {
+				r.Header.Set("X-API-Key", "key")
+			},
+			wantProviders: []string{"apikey1"},
+		},
+		{
+			name: "client certificate",
+			res:  allRes,
+			setup: func(r *http.Request) {
+				cert := &x509.Certificate{}
+				r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}, VerifiedChains: [][]*x509.Certificate{{cert}}}
+			},
+			wantProviders: []string{"mtls1"},
+		},
+		{
+			name: "hmac signature",
+			res: &config.Resource{
+				Providers: []string{"basic1", "hmac1"},
+				Basic:     &config.ResourceBasic{},
+				HMAC:      &config.ResourceHeaderOIDC{},
+			},
+			setup: func(r *http.Request) {
+				r.Header.Set("Authorization", "S3P-HMAC-SHA256 Credential=key1, SignedHeaders=host, Signature=abcd")
+			},
+			wantProviders: []string{"hmac1"},
+		},
+		{
+			name: "header",
+			res:  allRes,
+			setup: func(r *http.Request) {
+				r.Header.Set("X-User", "user")
+			},
+			wantProviders: []string{"header1"},
+		},
+		{
+			name: "cookie",
+			res:  allRes,
+			setup: func(r *http.Request) {
+				r.AddCookie(&http.Cookie{Name: "oidc", Value: "cookie"})
+			},
+			wantProviders: []string{"oidc1"},
+		},
+		{
+			name: "cookie is ignored with basic credentials",
+			res:  allRes,
+			setup: func(r *http.Request) {
+				r.SetBasicAuth("user", "password")
+				r.AddCookie(&http.Cookie{Name: "oidc", Value: "cookie"})
+			},
+			wantProviders: []string{"basic1"},
+		},
+		{
+			name: "basic credentials use basic and ldap providers in declared order",
+			res: &config.Resource{
+				Providers: []string{"basic1", "ldap1"},
+				Basic:     &config.ResourceBasic{},
+				LDAP:      &config.ResourceHeaderOIDC{},
+			},
+			setup: func(r *http.Request) {
+				r.SetBasicAuth("user", "password")
+			},
+			wantProviders: []string{"basic1", "ldap1"},
+		},
+		{
+			name:          "no credentials uses basic challenge",
+			res:           allRes,
+			setup:         func(_ *http.Request) {},
+			wantProviders: []string{"basic1"},
+		},
+		{
+			name: "no credentials without basic provider uses first provider",
+			res: &config.Resource{
+				Providers: []string{"oidc1", "jwt1"},
+				OIDC:      &config.ResourceHeaderOIDC{},
+				JWT:       &config.ResourceHeaderOIDC{},
+			},
+			setup:         func(_ *http.Request) {},
+			wantProviders: []string{"oidc1"},
+		},
+		{
+			name: "no valid provider",
+			res: &config.Resource{
+				Providers: []string{"unknown"},
+				OIDC:      &config.ResourceHeaderOIDC{},
+			},
+			setup: func(_ *http.Request) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://localhost/file", nil)
+			tt.setup(req)
+
+			got := s.selectProviderResources(req, tt.res)
+			if len(tt.wantProviders) == 0 {
+				assert.Empty(t, got)
+
+				return
+			}
+
+			providers := []string{}
+
+			for _, pres := range got {
+				providers = append(providers, pres.Provider)
+
+				assert.Empty(t, pres.Providers)
+			}
+
+			assert.Equal(t, tt.wantProviders, providers)
+		})
+	}
+}
+
+func Test_service_Middleware_providersFallback(t *testing.T) {
+	jwtSecret1 := "0123456789abcdefghijklmnopqrstuv"
+	jwtSecret2 := "vutsrqponmlkjihgfedcba9876543210"
+
+	cfg := &config.Config{
+		AuthProviders: &config.AuthProviderConfig{
+			Basic: map[string]*config.BasicAuthConfig{"basic1": {Realm: "basic"}},
+			LDAP:  map[string]*config.LDAPAuthConfig{"ldap1": {Realm: "ldap"}},
+			JWT: map[string]*config.JWTAuthConfig{
+				"jwt1": newJWTTestConfig(func(cfg *config.JWTAuthConfig) {
+					cfg.Issuer = "https://issuer1.example.com"
+					cfg.AllowedAlgorithms = []string{"HS256"}
+					cfg.StaticKeys = []*config.JWTStaticKeyConfig{{Key: &config.CredentialConfig{Value: jwtSecret1}}}
+				}),
+				"jwt2": newJWTTestConfig(func(cfg *config.JWTAuthConfig) {
+					cfg.Issuer = "https://issuer2.example.com"
+					cfg.AllowedAlgorithms = []string{"HS256"}
+					cfg.StaticKeys = []*config.JWTStaticKeyConfig{{Key: &config.CredentialConfig{Value: jwtSecret2}}}
+				}),
+			},
+		},
+		Templates: &config.TemplateConfig{
+			Helpers: []string{"../../../../templates/_helpers.tpl"},
+			UnauthorizedError: &config.TemplateConfigItem{
+				Path:    "../../../../templates/unauthorized-error.tpl",
+				Headers: map[string]string{"Content-Type": "{{ template \"main.headers.contentType\" . }}"},
+				Status:  "401",
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	metricsMock := mmocks.NewMockClient(ctrl)
+	metricsMock.EXPECT().IncAuthenticated(gomock.Any(), gomock.Any()).AnyTimes()
+	metricsMock.EXPECT().IncAuthenticationFailures(gomock.Any(), gomock.Any()).AnyTimes()
+
+	s := &service{
+		allJWTVerifiers: map[string]*jwtVerifier{},
+		allLDAPAuthenticators: map[string]*ldapAuthenticator{
+			"ldap1": {
+				cfg: &config.LDAPAuthConfig{
+					UserDNTemplate: "uid={username},OU=Users,DC=example,DC=com",
+					EmailAttribute: "mail",
+					Timeout:        time.Second,
+				},
+				dial:  func() (ldapConn, error) { return newFakeLDAPConn(), nil },
+				now:   time.Now,
+				cache: map[string]*ldapCacheEntry{},
+			},
+		},
+		cfg:        cfg,
+		cfgManager: cfgManagerMock,
+		metricsCl:  metricsMock,
+	}
+	require.NoError(t, s.LoadJWTProvider("jwt1", cfg.AuthProviders.JWT["jwt1"]))
+	require.NoError(t, s.LoadJWTProvider("jwt2", cfg.AuthProviders.JWT["jwt2"]))
+
+	claims := func(iss string) map[string]any {
+		return map[string]any{
+			"iss": iss,
+			"sub": "subject-" + iss,
+			"aud": []string{"s3-proxy"},
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	basicLDAPRes := &config.Resource{
+		Path:      "/*",
+		Methods:   []string{"GET"},
+		Providers: []string{"basic1", "ldap1"},
+		Basic: &config.ResourceBasic{
+			Credentials: []*config.BasicAuthUserConfig{{User: "user1", Password: &config.CredentialConfig{Value: "pass1"}}},
+		},
+		LDAP: &config.ResourceHeaderOIDC{},
+	}
+	jwtRes := &config.Resource{
+		Path:      "/*",
+		Methods:   []string{"GET"},
+		Providers: []string{"jwt1", "jwt2"},
+		JWT:       &config.ResourceHeaderOIDC{},
+	}
+
+	tests := []struct {
+		res                 *config.Resource
+		setup               func(r *http.Request)
+		name                string
+		wantUser            string
+		wantWWWAuthenticate []string
+		wantStatus          int
+	}{
+		{
+			name: "token of first jwt provider",
+			res:  jwtRes,
+			setup: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer "+signJWT(t, jose.HS256, []byte(jwtSecret1), "", claims("https://issuer1.example.com")))
+			},
+			wantStatus: http.StatusOK,
+			wantUser:   "subject-https://issuer1.example.com",
+		},
+		{
+			name: "token of second jwt provider",
+			res:  jwtRes,
+			setup: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer "+signJWT(t, jose.HS256, []byte(jwtSecret2), "", claims("https://issuer2.example.com")))
+			},
+			wantStatus: http.StatusOK,
+			wantUser:   "subject-https://issuer2.example.com",
+		},
+		{
+			name: "token of unknown issuer",
+			res:  jwtRes,
+			setup: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer "+signJWT(t, jose.HS256, []byte(jwtSecret2), "", claims("https://issuer3.example.com")))
+			},
+			wantStatus:          http.StatusUnauthorized,
+			wantWWWAuthenticate: []string{`Bearer error="invalid_token"`},
+		},
+		{
+			name: "basic user",
+			res:  basicLDAPRes,
+			setup: func(r *http.Request) {
+				r.SetBasicAuth("user1", "pass1")
+			},
+			wantStatus: http.StatusOK,
+			wantUser:   "user1",
+		},
+		{
+			name: "ldap user after basic provider",
+			res:  basicLDAPRes,
+			setup: func(r *http.Request) {
+				r.SetBasicAuth("jdoe", "pass")
+			},
+			wantStatus: http.StatusOK,
+			wantUser:   "jdoe",
+		},
+		{
+			name: "invalid credentials for all providers",
+			res:  basicLDAPRes,
+			setup: func(r *http.Request) {
+				r.SetBasicAuth("jdoe", "invalid")
+			},
+			wantStatus:          http.StatusUnauthorized,
+			wantWWWAuthenticate: []string{`Basic realm="ldap"`},
+		},
+		{
+			name:                "no credentials",
+			res:                 basicLDAPRes,
+			setup:               func(_ *http.Request) {},
+			wantStatus:          http.StatusUnauthorized,
+			wantWWWAuthenticate: []string{`Basic realm="basic"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "http://localhost/file", nil)
+			tt.setup(req)
+
+			// Add logger and response handler like server middlewares
+			ctx := log.SetLoggerInContext(req.Context(), log.NewLogger())
+			ctx = responsehandler.SetResponseHandlerInContext(ctx, responsehandler.NewHandler(req, w, cfgManagerMock, ""))
+			req = req.WithContext(ctx)
+
+			next := http.HandlerFunc(func(nw http.ResponseWriter, r *http.Request) {
+				// Check that original response writer is used after authentication
+				assert.Same(t, w, nw)
+
+				user := models.GetAuthenticatedUserFromContext(r.Context())
+				// Check that response handler is up to date
+				assert.Equal(t, user, models.GetAuthenticatedUserFromContext(
+					responsehandler.GetResponseHandlerFromContext(r.Context()).GetRequest().Context(),
+				))
+
+				nw.WriteHeader(http.StatusOK)
+				_, _ = nw.Write([]byte(user.GetIdentifier()))
+			})
+
+			s.Middleware([]*config.Resource{tt.res})(next).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantWWWAuthenticate, w.Header().Values("WWW-Authenticate"))
+
+			if tt.wantUser != "" {
+				assert.Equal(t, tt.wantUser, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package authentication
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Security event names added to logs.
+const (
+	securityEventAuthenticationFailure = "authentication_failure"
+	securityEventLockoutStarted        = "lockout_started"
+	securityEventLockedOutRequest      = "locked_out_request"
+)
+
+// securityEventFields will return structured log fields for a security event.
+func securityEventFields(event, providerKey, username, clientIP string) map[string]any {
+	return map[string]any{
+		"security_event": event,
+		"provider":       providerKey,
+		"username":       username,
+		"client_ip":      clientIP,
+	}
+}
+
+// getClientIP will return client ip found by router middlewares or request remote address.
+func getClientIP(r *http.Request) string {
+	// Get client ip from router middlewares
+	ip := middleware.GetClientIP(r.Context())
+	// Check if it exists
+	if ip != "" {
+		return ip
+	}
+
+	// Get host from remote address
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	// Check error
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
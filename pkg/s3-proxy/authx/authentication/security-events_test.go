@@ -0,0 +1,34 @@
+//go:build unit
+
+package authentication
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_getClientIP(t *testing.T) {
+	// Remote address
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/file", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	assert.Equal(t, "10.0.0.1", getClientIP(req))
+
+	// Remote address without port
+	req.RemoteAddr = "10.0.0.1"
+	assert.Equal(t, "10.0.0.1", getClientIP(req))
+
+	// Client ip found by router middleware
+	req.Header.Set("X-Real-IP", "10.0.0.2")
+
+	var got string
+
+	middleware.ClientIPFromHeader("X-Real-IP")(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = getClientIP(r)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "10.0.0.2", got)
+}
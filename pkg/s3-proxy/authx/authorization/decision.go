@@ -0,0 +1,225 @@
+package authorization
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+// Decision represents an authorization policy decision.
+// Policies can return a boolean or an object with this structure.
+type Decision struct {
+	Headers map[string]string `json:"headers"`
+	Reasons []string          `json:"reasons"`
+	Allowed bool              `json:"allowed"`
+}
+
+// parseDecision will transform a policy result into a decision.
+// An undefined result is considered as a denial.
+func parseDecision(result any) (*Decision, error) {
+	// Check result type
+	switch v := result.(type) {
+	case nil:
+		return &Decision{}, nil
+	case bool:
+		return &Decision{Allowed: v}, nil
+	case map[string]any:
+		// Json encode result
+		bb, err := json.Marshal(v)
+		// Check error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		// Parse decision
+		res := &Decision{}
+		// Unmarshal
+		err = json.Unmarshal(bb, res)
+		// Check error
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid policy decision")
+		}
+
+		return res, nil
+	default:
+		return nil, errors.Errorf("unsupported policy result type %T", result)
+	}
+}
+
+// decisionCacheEntry is a decision saved in cache.
+type decisionCacheEntry struct {
+	expiresAt time.Time
+	decision  *Decision
+}
+
+// decisionCache will save policy decisions for a limited duration.
+type decisionCache struct {
+	now     func() time.Time
+	entries map[string]*decisionCacheEntry
+	mutex   sync.Mutex
+}
+
+func newDecisionCache() *decisionCache {
+	return &decisionCache{
+		now:     time.Now,
+		entries: map[string]*decisionCacheEntry{},
+	}
+}
+
+// getDecisionCacheKey will compute the cache key of a decision from policy, user identifier and input.
+// Request headers and remote address are ignored as they change with each request and would prevent cache hits.
+func getDecisionCacheKey(policy, identifier string, input *inputDataOPA) (string, error) {
+	// Copy input to remove per request data
+	keyInput := *input
+	// Check if request data exists
+	if input.Request != nil {
+		reqData := *input.Request
+		reqData.Headers = nil
+		reqData.RemoteAddr = ""
+		keyInput.Request = &reqData
+	}
+
+	// Json encode input
+	bb, err := json.Marshal(&keyInput)
+	// Check error
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	// Hash input with policy
+	h := sha256.New()
+	_, _ = h.Write([]byte(policy))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(bb)
+
+	return identifier + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *decisionCache) get(key string) *Decision {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Get entry
+	entry := c.entries[key]
+	// Check if it exists and isn't expired
+	if entry == nil || !c.now().Before(entry.expiresAt) {
+		return nil
+	}
+
+	return entry.decision
+}
+
+func (c *decisionCache) set(key string, decision *Decision, duration time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Remove expired entries
+	for k, v := range c.entries {
+		if !c.now().Before(v.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = &decisionCacheEntry{
+		decision:  decision,
+		expiresAt: c.now().Add(duration),
+	}
+}
+
+// evaluatePolicy will evaluate the OPA server or Rego policy of a resource.
+// Decisions are saved in cache when a decision cache duration is set.
+func evaluatePolicy(
+	req *http.Request,
+	user models.GenericUser,
+	resource *config.ResourceHeaderOIDC,
+	brctx bucket.Client,
+	regoManager RegoManager,
+	dCache *decisionCache,
+) (*Decision, error) {
+	// Initialize variables
+	var (
+		tags          map[string]string
+		policy        string
+		withMetadata  bool
+		cacheDuration time.Duration
+	)
+
+	// Check if case of opa server
+	if resource.AuthorizationOPAServer != nil {
+		tags = resource.AuthorizationOPAServer.Tags
+		policy = "opa:" + resource.AuthorizationOPAServer.URL
+		withMetadata = resource.AuthorizationOPAServer.IncludeObjectMetadata
+		cacheDuration = resource.AuthorizationOPAServer.DecisionCacheDuration
+	} else {
+		tags = resource.AuthorizationRego.Tags
+		// Policy generation is added to ignore decisions computed before a reload
+		policy = "rego:" + resource.AuthorizationRego.Policy + ":" + strconv.FormatUint(regoManager.Generation(), 10)
+		withMetadata = resource.AuthorizationRego.IncludeObjectMetadata
+		cacheDuration = resource.AuthorizationRego.DecisionCacheDuration
+	}
+
+	// Generate input data
+	input := buildInputDataOPA(req, user, tags)
+	// Check if bucket request context exists to add bucket data
+	if brctx != nil {
+		err := addBucketDataOPA(req, input, brctx, withMetadata)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Check if cache is disabled
+	if cacheDuration <= 0 {
+		return evaluateInputDataOPA(req, input, resource, regoManager)
+	}
+
+	// Get cache key
+	cacheKey, err := getDecisionCacheKey(policy, user.GetIdentifier(), input)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Check cache
+	decision := dCache.get(cacheKey)
+	if decision != nil {
+		return decision, nil
+	}
+
+	// Evaluate
+	decision, err = evaluateInputDataOPA(req, input, resource, regoManager)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Save in cache
+	dCache.set(cacheKey, decision, cacheDuration)
+
+	return decision, nil
+}
+
+func evaluateInputDataOPA(
+	req *http.Request,
+	input *inputDataOPA,
+	resource *config.ResourceHeaderOIDC,
+	regoManager RegoManager,
+) (*Decision, error) {
+	// Check if case of opa server
+	if resource.AuthorizationOPAServer != nil {
+		return isOPAServerAuthorized(req, input, resource)
+	}
+
+	return isRegoAuthorized(req, input, resource, regoManager)
+}
@@ -0,0 +1,243 @@
+//go:build unit
+
+package authorization
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket"
+	bmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+type fakeRegoManager struct {
+	decision   *Decision
+	inputs     []*inputDataOPA
+	generation uint64
+}
+
+func (f *fakeRegoManager) Load() error {
+	f.generation++
+
+	return nil
+}
+
+func (f *fakeRegoManager) Generation() uint64 { return f.generation }
+
+func (f *fakeRegoManager) Evaluate(_ context.Context, _ string, input any) (*Decision, error) {
+	f.inputs = append(f.inputs, input.(*inputDataOPA))
+
+	return f.decision, nil
+}
+
+func Test_parseDecision(t *testing.T) {
+	tests := []struct {
+		result  any
+		want    *Decision
+		name    string
+		wantErr bool
+	}{
+		{
+			name:   "undefined",
+			result: nil,
+			want:   &Decision{},
+		},
+		{
+			name:   "boolean",
+			result: true,
+			want:   &Decision{Allowed: true},
+		},
+		{
+			name: "object",
+			result: map[string]any{
+				"allowed": false,
+				"reasons": []any{"file is locked"},
+				"headers": map[string]any{"X-Reason": "locked"},
+			},
+			want: &Decision{
+				Reasons: []string{"file is locked"},
+				Headers: map[string]string{"X-Reason": "locked"},
+			},
+		},
+		{
+			name:    "invalid object",
+			result:  map[string]any{"allowed": "yes"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported type",
+			result:  "true",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDecision(tt.result)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseDecision() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_decisionCache(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newDecisionCache()
+	c.now = func() time.Time { return now }
+
+	d := &Decision{Allowed: true}
+	c.set("key", d, time.Minute)
+
+	assert.Equal(t, d, c.get("key"))
+	assert.Nil(t, c.get("other"))
+
+	// Expire entry
+	now = now.Add(time.Minute)
+
+	assert.Nil(t, c.get("key"))
+}
+
+func Test_getDecisionCacheKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/file", nil)
+	input1 := buildInputDataOPA(req, &models.OIDCUser{Email: "user@example.com"}, map[string]string{})
+	input2 := buildInputDataOPA(req, &models.OIDCUser{Email: "user@example.com"}, map[string]string{"fake": "tag"})
+
+	k1, err := getDecisionCacheKey("rego:p1", "user", input1)
+	require.NoError(t, err)
+	k2, err := getDecisionCacheKey("rego:p1", "user", input1)
+	require.NoError(t, err)
+	k3, err := getDecisionCacheKey("rego:p1", "user", input2)
+	require.NoError(t, err)
+	k4, err := getDecisionCacheKey("rego:p2", "user", input1)
+	require.NoError(t, err)
+
+	assert.Equal(t, k1, k2)
+	assert.NotEqual(t, k1, k3)
+	assert.NotEqual(t, k1, k4)
+	assert.Contains(t, k1, "user:")
+
+	// Per request headers and remote address are ignored
+	req2 := httptest.NewRequest(http.MethodGet, "http://localhost/file", nil)
+	req2.Header.Set("X-Request-Id", "fake")
+	req2.RemoteAddr = "192.0.2.1:4242"
+	input3 := buildInputDataOPA(req2, &models.OIDCUser{Email: "user@example.com"}, map[string]string{})
+
+	k5, err := getDecisionCacheKey("rego:p1", "user", input3)
+	require.NoError(t, err)
+	assert.Equal(t, k1, k5)
+	assert.NotEmpty(t, input3.Request.Headers, "input must not be modified")
+}
+
+func Test_evaluatePolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	brctxMock := bmocks.NewMockClient(ctrl)
+
+	lastModified := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	brctxMock.EXPECT().ResolveObject(gomock.Any(), "/folder/file name.txt", true).Times(3).Return(&bucket.ObjectInfo{
+		LastModified:  &lastModified,
+		Metadata:      map[string]string{"owner": "user"},
+		Target:        "tgt1",
+		Bucket:        "bucket1",
+		Key:           "root/folder/file name.txt",
+		ContentType:   "text/plain",
+		ContentLength: 10,
+		Exists:        true,
+	}, nil)
+
+	// Create request with remaining route path
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/mount/folder/file%20name.txt", nil)
+	rctx := chi.NewRouteContext()
+	rctx.RoutePath = "/folder/file%20name.txt"
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	resource := &config.ResourceHeaderOIDC{
+		AuthorizationRego: &config.RegoAuthorization{
+			Policy:                "p1",
+			Tags:                  map[string]string{},
+			IncludeObjectMetadata: true,
+			DecisionCacheDuration: time.Minute,
+		},
+	}
+	regoManager := &fakeRegoManager{decision: &Decision{Allowed: true, Headers: map[string]string{"X-Fake": "fake"}}}
+	dCache := newDecisionCache()
+	user := &models.OIDCUser{PreferredUsername: "user"}
+
+	got, err := evaluatePolicy(req, user, resource, brctxMock, regoManager, dCache)
+	require.NoError(t, err)
+	assert.Equal(t, regoManager.decision, got)
+
+	// Check input
+	require.Len(t, regoManager.inputs, 1)
+	input := regoManager.inputs[0]
+	assert.Equal(t, "tgt1", input.Target)
+	assert.Equal(t, http.MethodGet, input.Action)
+	assert.Equal(t, &objectDataOPA{
+		LastModified:  &lastModified,
+		Metadata:      map[string]string{"owner": "user"},
+		Bucket:        "bucket1",
+		Key:           "root/folder/file name.txt",
+		ContentType:   "text/plain",
+		ContentLength: 10,
+		Exists:        true,
+	}, input.Object)
+
+	// Second call must use cache
+	got, err = evaluatePolicy(req, user, resource, brctxMock, regoManager, dCache)
+	require.NoError(t, err)
+	assert.Equal(t, regoManager.decision, got)
+	assert.Len(t, regoManager.inputs, 1)
+
+	// Reload policies with another decision
+	require.NoError(t, regoManager.Load())
+	regoManager.decision = &Decision{}
+
+	// Call after reload must not use cache
+	got, err = evaluatePolicy(req, user, resource, brctxMock, regoManager, dCache)
+	require.NoError(t, err)
+	assert.False(t, got.Allowed)
+	assert.Len(t, regoManager.inputs, 2)
+}
+
+func Test_evaluatePolicy_ListAction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	brctxMock := bmocks.NewMockClient(ctrl)
+	brctxMock.EXPECT().ResolveObject(gomock.Any(), "/folder/", false).Times(2).Return(&bucket.ObjectInfo{
+		Target: "tgt1",
+		Bucket: "bucket1",
+		Key:    "folder/",
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/folder/", nil)
+	rctx := chi.NewRouteContext()
+	rctx.RoutePath = "/folder/"
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	resource := &config.ResourceHeaderOIDC{
+		AuthorizationRego: &config.RegoAuthorization{Policy: "p1", Tags: map[string]string{}},
+	}
+	regoManager := &fakeRegoManager{decision: &Decision{}}
+
+	// Without cache, policy is evaluated each time
+	for range 2 {
+		got, err := evaluatePolicy(req, &models.OIDCUser{}, resource, brctxMock, regoManager, newDecisionCache())
+		require.NoError(t, err)
+		assert.False(t, got.Allowed)
+	}
+
+	require.Len(t, regoManager.inputs, 2)
+	assert.Equal(t, listAction, regoManager.inputs[0].Action)
+}
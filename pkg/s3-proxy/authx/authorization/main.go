@@ -3,9 +3,11 @@ package authorization
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"emperror.dev/errors"
 
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/audit"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
@@ -21,7 +23,15 @@ func Middleware(
 	// Not used inside the service functions because it is better to use fixed configuration to avoid conflict in case of reload and incoming request.
 	cfgManager config.Manager,
 	metricsCl metrics.Client,
+	regoManager RegoManager,
+	// Target key used by rbac authorization. Empty for target list.
+	targetKey string,
 ) func(http.Handler) http.Handler {
+	// Create decision cache shared by all requests of this router
+	dCache := newDecisionCache()
+	// Get rbac configuration at router creation to keep it fixed
+	rbacCfg := cfgManager.GetConfig().RBAC
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get logger from request
@@ -34,6 +44,7 @@ func Middleware(
 				// Resource doesn't exists
 				// In this case, authentication is skipped, need to skip authorization too
 				logger.Debug("no resource found in authorization, means that authentication was skipped => skip authorization too")
+				audit.SetDecision(r.Context(), audit.DecisionAllow, "no resource")
 				next.ServeHTTP(w, r)
 
 				return
@@ -43,6 +54,7 @@ func Middleware(
 			if resource.WhiteList != nil && *resource.WhiteList {
 				// Resource is whitelisted
 				logger.Debug("authorization skipped because resource is whitelisted")
+				audit.SetDecision(r.Context(), audit.DecisionAllow, "whitelisted")
 				next.ServeHTTP(w, r)
 
 				return
@@ -51,62 +63,127 @@ func Middleware(
 			// Get user from context
 			user := models.GetAuthenticatedUserFromContext(r.Context())
 
+			// Get bucket request context
+			brctx := bucket.GetBucketRequestContextFromContext(r.Context())
+			// Get response handler
+			resHan := responsehandler.GetResponseHandlerFromContext(r.Context())
+
+			// Check if resource is basic authentication with rbac
+			if resource.Basic != nil && resource.Basic.AuthorizationRBAC {
+				// Check rbac
+				authorized, err := isRBACAuthorized(r, user, resource.Provider, targetKey, rbacCfg)
+				// Check error
+				if err != nil {
+					audit.SetDecision(r.Context(), audit.DecisionDeny, "basic-auth-rbac: authorization error")
+					// Check if bucket request context doesn't exist to use local default files
+					if brctx == nil {
+						responsehandler.GeneralInternalServerError(r, w, cfgManager, err)
+					} else {
+						resHan.InternalServerError(brctx.LoadFileContent, err)
+					}
+
+					return
+				}
+				// Check if not authorized
+				if !authorized {
+					// Create error
+					err := errors.WithStack(fmt.Errorf("forbidden user %s", user.GetIdentifier()))
+					audit.SetDecision(r.Context(), audit.DecisionDeny, "basic-auth-rbac: no matching role binding")
+					// Check if bucket request context doesn't exist to use local default files
+					if brctx == nil {
+						responsehandler.GeneralForbiddenError(r, w, cfgManager, err)
+					} else {
+						resHan.ForbiddenError(brctx.LoadFileContent, err)
+					}
+
+					return
+				}
+
+				logger.Infof("Basic auth user %s authorized", user.GetIdentifier())
+				metricsCl.IncAuthorized("basic-auth-rbac")
+				audit.SetDecision(r.Context(), audit.DecisionAllow, "basic-auth-rbac")
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
 			// Check if resource is basic authentication
 			if resource.Basic != nil {
-				// Case user in basic auth user
-				buser, _ := user.(*models.BasicAuthUser)
 				// Resource is basic authenticated
 				logger.Debug("authorization for basic authentication => nothing needed")
-				logger.Infof("Basic auth user %s authorized", buser.GetIdentifier())
+				// User can be a basic auth user or an impersonated user
+				logger.Infof("Basic auth user %s authorized", user.GetIdentifier())
 				metricsCl.IncAuthorized("basic-auth")
+				audit.SetDecision(r.Context(), audit.DecisionAllow, "basic-auth")
 				next.ServeHTTP(w, r)
 
 				return
 			}
 
-			// Get bucket request context
-			brctx := bucket.GetBucketRequestContextFromContext(r.Context())
-			// Get response handler
-			resHan := responsehandler.GetResponseHandlerFromContext(r.Context())
-
-			// Check if resource is OIDC or Header
-			if resource.OIDC != nil || resource.Header != nil {
+			// Check if resource is OIDC, Header, JWT, API key, mTLS, LDAP or HMAC
+			if resource.OIDC != nil || resource.Header != nil || resource.JWT != nil || resource.APIKey != nil || resource.MTLS != nil ||
+				resource.LDAP != nil || resource.HMAC != nil {
 				// Initialize variables
 				var authorizationProvider string
 				// Initialize variables
 				var headerOIDCResource *config.ResourceHeaderOIDC
 
-				// Check if resource is OIDC
-				if resource.OIDC != nil {
+				// Check resource type
+				switch {
+				case resource.OIDC != nil:
 					// OIDC case
 					headerOIDCResource = resource.OIDC
-
-					if headerOIDCResource.AuthorizationOPAServer != nil {
-						authorizationProvider = "oidc-opa"
-					} else {
-						authorizationProvider = "oidc-basic"
-					}
-				} else {
+					authorizationProvider = "oidc"
+				case resource.JWT != nil:
+					// JWT case
+					headerOIDCResource = resource.JWT
+					authorizationProvider = "jwt"
+				case resource.APIKey != nil:
+					// API key case
+					headerOIDCResource = resource.APIKey
+					authorizationProvider = "api-key"
+				case resource.MTLS != nil:
+					// mTLS case
+					headerOIDCResource = resource.MTLS
+					authorizationProvider = "mtls"
+				case resource.LDAP != nil:
+					// LDAP case
+					headerOIDCResource = resource.LDAP
+					authorizationProvider = "ldap"
+				case resource.HMAC != nil:
+					// HMAC case
+					headerOIDCResource = resource.HMAC
+					authorizationProvider = "hmac"
+				default:
 					// Header case
 					headerOIDCResource = resource.Header
+					authorizationProvider = "header"
+				}
 
-					if headerOIDCResource.AuthorizationOPAServer != nil {
-						authorizationProvider = "header-opa"
-					} else {
-						authorizationProvider = "header-basic"
-					}
+				// Add authorization type
+				switch {
+				case headerOIDCResource.AuthorizationOPAServer != nil:
+					authorizationProvider += "-opa"
+				case headerOIDCResource.AuthorizationRego != nil:
+					authorizationProvider += "-rego"
+				case headerOIDCResource.AuthorizationRBAC:
+					authorizationProvider += "-rbac"
+				default:
+					authorizationProvider += "-basic"
 				}
 
 				// Authorization part
 
 				var authorized bool
-				// Check if case of opa server
-				if headerOIDCResource.AuthorizationOPAServer != nil {
-					var err error
-
-					authorized, err = isOPAServerAuthorized(r, user, headerOIDCResource)
+				// Initialize variables
+				var reasons []string
+				// Check if case of opa server or embedded rego
+				if headerOIDCResource.AuthorizationOPAServer != nil || headerOIDCResource.AuthorizationRego != nil {
+					// Evaluate policy
+					decision, err := evaluatePolicy(r, user, headerOIDCResource, brctx, regoManager, dCache)
 					// Check error
 					if err != nil {
+						audit.SetDecision(r.Context(), audit.DecisionDeny, authorizationProvider+": authorization error")
 						// Check if bucket request context doesn't exist to use local default files
 						if brctx == nil {
 							responsehandler.GeneralInternalServerError(r, w, cfgManager, err)
@@ -119,6 +196,32 @@ func Middleware(
 
 						return
 					}
+
+					// Add headers asked by policy
+					for k, v := range decision.Headers {
+						w.Header().Set(k, v)
+					}
+
+					// Save decision
+					authorized = decision.Allowed
+					reasons = decision.Reasons
+				} else if headerOIDCResource.AuthorizationRBAC {
+					// Check rbac
+					var err error
+					// Evaluate bindings
+					authorized, err = isRBACAuthorized(r, user, resource.Provider, targetKey, rbacCfg)
+					// Check error
+					if err != nil {
+						audit.SetDecision(r.Context(), audit.DecisionDeny, authorizationProvider+": authorization error")
+						// Check if bucket request context doesn't exist to use local default files
+						if brctx == nil {
+							responsehandler.GeneralInternalServerError(r, w, cfgManager, err)
+						} else {
+							resHan.InternalServerError(brctx.LoadFileContent, err)
+						}
+
+						return
+					}
 				} else {
 					authorized = isHeaderOIDCAuthorizedBasic(
 						user.GetGroups(),
@@ -131,8 +234,14 @@ func Middleware(
 				if !authorized {
 					// Create error
 					err := fmt.Errorf("forbidden user %s", user.GetIdentifier())
+					// Check if policy gave reasons
+					if len(reasons) != 0 {
+						err = fmt.Errorf("forbidden user %s: %s", user.GetIdentifier(), strings.Join(reasons, ", "))
+					}
 					// Add stack trace
 					err = errors.WithStack(err)
+					// Save decision with policy reasons
+					audit.SetDecision(r.Context(), audit.DecisionDeny, auditReason(authorizationProvider, reasons))
 					// Check if bucket request context doesn't exist to use local default files
 					if brctx == nil {
 						responsehandler.GeneralForbiddenError(r, w, cfgManager, err)
@@ -147,6 +256,7 @@ func Middleware(
 
 				logger.Infof("%s user %s authorized", user.GetType(), user.GetIdentifier())
 				metricsCl.IncAuthorized(authorizationProvider)
+				audit.SetDecision(r.Context(), audit.DecisionAllow, auditReason(authorizationProvider, reasons))
 				next.ServeHTTP(w, r)
 
 				return
@@ -154,6 +264,7 @@ func Middleware(
 
 			// Error, this case shouldn't arrive
 			err := errAuthorizationMiddlewareNotSupported
+			audit.SetDecision(r.Context(), audit.DecisionDeny, "authorization not supported")
 			// Check if bucket request context doesn't exist to use local default files
 			if brctx == nil {
 				responsehandler.GeneralInternalServerError(r, w, cfgManager, err)
@@ -163,3 +274,13 @@ func Middleware(
 		})
 	}
 }
+
+// auditReason will build audit reason from authorization provider and policy reasons.
+func auditReason(authorizationProvider string, reasons []string) string {
+	// Check if there is reasons
+	if len(reasons) == 0 {
+		return authorizationProvider
+	}
+
+	return authorizationProvider + ": " + strings.Join(reasons, ", ")
+}
@@ -5,14 +5,21 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
+	"time"
 
 	"emperror.dev/errors"
+	"github.com/go-chi/chi/v5/middleware"
 
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/geoip"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
 	utils "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/utils/generalutils"
 )
 
+// listAction is the action used in input data for folder listing requests.
+const listAction = "LIST"
+
 type inputOPA struct {
 	Input *inputDataOPA `json:"input"`
 }
@@ -21,6 +28,9 @@ type inputDataOPA struct {
 	User    any               `json:"user"`
 	Request *requestDataOPA   `json:"request"`
 	Tags    map[string]string `json:"tags"`
+	Object  *objectDataOPA    `json:"object"`
+	Target  string            `json:"target"`
+	Action  string            `json:"action"`
 }
 
 type requestDataOPA struct {
@@ -28,17 +38,29 @@ type requestDataOPA struct {
 	Method     string            `json:"method"`
 	Protocol   string            `json:"protocol"`
 	RemoteAddr string            `json:"remoteAddr"`
+	ClientIP   string            `json:"clientIp"`
+	Country    string            `json:"country"`
 	Scheme     string            `json:"scheme"`
 	Host       string            `json:"host"`
 	Path       string            `json:"path"`
 	ParsedPath []string          `json:"parsed_path"`
 }
 
+type objectDataOPA struct {
+	LastModified  *time.Time        `json:"lastModified"`
+	Metadata      map[string]string `json:"metadata"`
+	Bucket        string            `json:"bucket"`
+	Key           string            `json:"key"`
+	ContentType   string            `json:"contentType"`
+	ContentLength int64             `json:"contentLength"`
+	Exists        bool              `json:"exists"`
+}
+
 type opaAnswer struct {
-	Result bool `json:"result"`
+	Result any `json:"result"`
 }
 
-func isOPAServerAuthorized(req *http.Request, oidcUser any, resource *config.ResourceHeaderOIDC) (bool, error) {
+func isOPAServerAuthorized(req *http.Request, input *inputDataOPA, resource *config.ResourceHeaderOIDC) (*Decision, error) {
 	// Get trace from request
 	trace := tracing.GetTraceFromContext(req.Context())
 	// Generate child trace
@@ -47,39 +69,10 @@ func isOPAServerAuthorized(req *http.Request, oidcUser any, resource *config.Res
 	// Add data
 	childTrace.SetTag("opa.uri", resource.AuthorizationOPAServer.URL)
 
-	// Transform headers into map
-	headers := make(map[string]string)
-	for k, v := range req.Header {
-		headers[strings.ToLower(k)] = v[0]
-	}
-	// Parse path
-	parsedPath := deleteEmpty(strings.Split(req.RequestURI, "/"))
-	// Calculate scheme
-	scheme := "http"
-	if req.TLS != nil {
-		scheme = "https"
-	}
-	// Generate OPA Server input data
-	input := &inputOPA{
-		Input: &inputDataOPA{
-			User: oidcUser,
-			Tags: resource.AuthorizationOPAServer.Tags,
-			Request: &requestDataOPA{
-				Method:     req.Method,
-				Protocol:   req.Proto,
-				Headers:    headers,
-				RemoteAddr: req.RemoteAddr,
-				Scheme:     scheme,
-				Host:       utils.GetRequestHost(req),
-				ParsedPath: parsedPath,
-				Path:       req.RequestURI,
-			},
-		},
-	}
 	// Json encode body
-	bb, err := json.Marshal(input)
+	bb, err := json.Marshal(&inputOPA{Input: input})
 	if err != nil {
-		return false, errors.WithStack(err)
+		return nil, errors.WithStack(err)
 	}
 
 	// Making request to OPA server
@@ -92,7 +85,7 @@ func isOPAServerAuthorized(req *http.Request, oidcUser any, resource *config.Res
 	)
 	// Check error
 	if err != nil {
-		return false, errors.WithStack(err)
+		return nil, errors.WithStack(err)
 	}
 	// Add content type
 	request.Header.Add("Content-Type", "application/json")
@@ -100,12 +93,12 @@ func isOPAServerAuthorized(req *http.Request, oidcUser any, resource *config.Res
 	err = childTrace.InjectInHTTPHeader(req.Header)
 	// Check error
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	// Making request to OPA server
 	resp, err := http.DefaultClient.Do(request)
 	if err != nil {
-		return false, errors.WithStack(err)
+		return nil, errors.WithStack(err)
 	}
 	// Defer closing body
 	defer resp.Body.Close()
@@ -115,10 +108,82 @@ func isOPAServerAuthorized(req *http.Request, oidcUser any, resource *config.Res
 	// Decode answer
 	err = json.NewDecoder(resp.Body).Decode(&answer)
 	if err != nil {
-		return false, errors.WithStack(err)
+		return nil, errors.WithStack(err)
+	}
+
+	return parseDecision(answer.Result)
+}
+
+// buildInputDataOPA will generate OPA input data from request, user and tags.
+func buildInputDataOPA(req *http.Request, user any, tags map[string]string) *inputDataOPA {
+	// Transform headers into map
+	headers := make(map[string]string)
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = v[0]
+	}
+	// Parse path
+	parsedPath := deleteEmpty(strings.Split(req.RequestURI, "/"))
+	// Calculate scheme
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+
+	return &inputDataOPA{
+		User: user,
+		Tags: tags,
+		Request: &requestDataOPA{
+			Method:     req.Method,
+			Protocol:   req.Proto,
+			Headers:    headers,
+			RemoteAddr: req.RemoteAddr,
+			ClientIP:   middleware.GetClientIP(req.Context()),
+			Country:    geoip.GetCountryFromContext(req.Context()),
+			Scheme:     scheme,
+			Host:       utils.GetRequestHost(req),
+			ParsedPath: parsedPath,
+			Path:       req.RequestURI,
+		},
+	}
+}
+
+// addBucketDataOPA will add target, action and resolved object data in OPA input data.
+// Object metadata are loaded from bucket only when asked.
+func addBucketDataOPA(req *http.Request, input *inputDataOPA, brctx bucket.Client, withMetadata bool) error {
+	// Get request path
+	requestPath, err := getRequestPath(req)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Resolve object
+	obj, err := brctx.ResolveObject(req.Context(), requestPath, withMetadata)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Save data
+	input.Target = obj.Target
+	input.Object = &objectDataOPA{
+		LastModified:  obj.LastModified,
+		Metadata:      obj.Metadata,
+		Bucket:        obj.Bucket,
+		Key:           obj.Key,
+		ContentType:   obj.ContentType,
+		ContentLength: obj.ContentLength,
+		Exists:        obj.Exists,
+	}
+
+	// Compute action
+	input.Action = req.Method
+	// Check if it is a folder listing
+	if req.Method == http.MethodGet && (obj.Key == "" || strings.HasSuffix(obj.Key, "/")) {
+		input.Action = listAction
 	}
 
-	return answer.Result, nil
+	return nil
 }
 
 func deleteEmpty(s []string) []string {
@@ -0,0 +1,231 @@
+package authorization
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/gobwas/glob"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+// EffectivePermission represents a permission granted to a user by a rbac role binding.
+type EffectivePermission struct {
+	Role    string   `json:"role"`
+	Targets []string `json:"targets"`
+	Paths   []string `json:"paths"`
+	Actions []string `json:"actions"`
+}
+
+// RBACSubject represents the user identity used to match rbac bindings.
+type RBACSubject struct {
+	// Provider name used to authenticate user. Bindings only match subjects coming from one of their providers.
+	Provider string
+	// Users contains all user identifiers that can be matched (identifier, username, verified email).
+	Users  []string
+	Groups []string
+}
+
+// GetRBACEffectivePermissions will return all permissions granted to a subject by rbac bindings.
+func GetRBACEffectivePermissions(rbacCfg *config.RBACConfig, subject *RBACSubject) []*EffectivePermission {
+	// Initialize result
+	res := make([]*EffectivePermission, 0)
+	// Check if rbac is configured
+	if rbacCfg == nil {
+		return res
+	}
+
+	// Loop over bindings
+	for _, binding := range rbacCfg.Bindings {
+		// Check if binding is matching subject
+		if !isRBACBindingMatching(binding, subject) {
+			continue
+		}
+
+		// Get role
+		role := rbacCfg.Roles[binding.Role]
+		// Check if role exists
+		if role == nil {
+			continue
+		}
+
+		// Add permissions
+		for _, perm := range role.Permissions {
+			res = append(res, &EffectivePermission{
+				Role:    binding.Role,
+				Targets: perm.Targets,
+				Paths:   perm.Paths,
+				Actions: perm.Actions,
+			})
+		}
+	}
+
+	return res
+}
+
+func isRBACBindingMatching(binding *config.RBACBindingConfig, subject *RBACSubject) bool {
+	// Check provider restriction
+	// Note: Bindings are always scoped to providers, otherwise the same name coming from
+	// another provider (basic auth, header, api key...) would get the same permissions.
+	if subject.Provider == "" || !slices.Contains(binding.Providers, subject.Provider) {
+		return false
+	}
+
+	// Check users
+	for _, u := range subject.Users {
+		if u != "" && slices.Contains(binding.Users, u) {
+			return true
+		}
+	}
+
+	// Check groups
+	for _, g := range subject.Groups {
+		if slices.Contains(binding.Groups, g) {
+			return true
+		}
+	}
+
+	// Not found case
+	return false
+}
+
+// isRBACAuthorized will check if user is authorized by rbac bindings for the current request.
+// An empty target key means that the request is on the target list and in this case,
+// having at least one permission is enough.
+func isRBACAuthorized(req *http.Request, user models.GenericUser, provider, targetKey string, rbacCfg *config.RBACConfig) (bool, error) {
+	// Get effective permissions
+	perms := GetRBACEffectivePermissions(rbacCfg, &RBACSubject{
+		Provider: provider,
+		Users:    getRBACUsers(user),
+		Groups:   user.GetGroups(),
+	})
+
+	// Check if it is the target list
+	if targetKey == "" {
+		return len(perms) != 0, nil
+	}
+
+	// Get request path
+	requestPath, err := getRequestPath(req)
+	// Check error
+	if err != nil {
+		return false, err
+	}
+
+	// Get action
+	action := getRBACAction(req.Method, requestPath)
+	// Check if action is supported
+	if action == "" {
+		return false, nil
+	}
+
+	// Loop over permissions
+	for _, perm := range perms {
+		// Check action
+		if !slices.Contains(perm.Actions, action) {
+			continue
+		}
+
+		// Check target
+		matched, err := isOneGlobMatching(perm.Targets, targetKey)
+		// Check error
+		if err != nil {
+			return false, err
+		}
+		// Check if target isn't matching
+		if !matched {
+			continue
+		}
+
+		// Check path
+		matched, err = isOneGlobMatching(perm.Paths, requestPath)
+		// Check error
+		if err != nil {
+			return false, err
+		}
+		// Check if path is matching
+		if matched {
+			return true, nil
+		}
+	}
+
+	// Not found case
+	return false, nil
+}
+
+// getRBACUsers will return user identifiers that can be matched by rbac bindings.
+// Email is only used when it has been verified, this includes identifier and username falling back on it.
+func getRBACUsers(user models.GenericUser) []string {
+	// Get email
+	email := user.GetEmail()
+	// Check if email can be used
+	if email == "" || user.IsEmailVerified() {
+		return []string{user.GetIdentifier(), user.GetUsername(), email}
+	}
+
+	// Initialize result
+	res := make([]string, 0)
+	// Loop over values
+	for _, it := range []string{user.GetIdentifier(), user.GetUsername()} {
+		// Ignore unverified email
+		if it != email {
+			res = append(res, it)
+		}
+	}
+
+	return res
+}
+
+// getRBACAction will compute the rbac action from request method and path.
+func getRBACAction(method, requestPath string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		// Check if it is a folder listing
+		if requestPath == "" || strings.HasSuffix(requestPath, "/") {
+			return config.RBACListAction
+		}
+
+		return config.RBACReadAction
+	case http.MethodPut:
+		return config.RBACWriteAction
+	case http.MethodDelete:
+		return config.RBACDeleteAction
+	default:
+		return ""
+	}
+}
+
+func isOneGlobMatching(patterns []string, value string) (bool, error) {
+	// Loop over patterns
+	for _, p := range patterns {
+		// Compile a glob pattern
+		g, err := glob.Compile(p, '/')
+		// Check error
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		// Check if matching
+		if g.Match(value) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// getRequestPath will return the unescaped request path inside the target.
+func getRequestPath(req *http.Request) (string, error) {
+	// Wildcard url parameter isn't available yet in middlewares, so the remaining route path is used instead
+	requestPath, err := url.PathUnescape(chi.RouteContext(req.Context()).RoutePath)
+	// Check error
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return requestPath, nil
+}
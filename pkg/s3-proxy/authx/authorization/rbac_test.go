@@ -0,0 +1,267 @@
+//go:build unit
+
+package authorization
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+var testRBACConfig = &config.RBACConfig{
+	Roles: map[string]*config.RBACRoleConfig{
+		"reader": {Permissions: []*config.RBACPermissionConfig{
+			{Targets: []string{"*"}, Paths: []string{"/public/**"}, Actions: []string{"read", "list"}},
+		}},
+		"uploader": {Permissions: []*config.RBACPermissionConfig{
+			{Targets: []string{"team-*"}, Paths: []string{"/uploads/*"}, Actions: []string{"write", "delete"}},
+		}},
+	},
+	Bindings: []*config.RBACBindingConfig{
+		{Role: "reader", Groups: []string{"devs"}, Providers: []string{"provider1"}},
+		{Role: "uploader", Users: []string{"user@example.com"}, Providers: []string{"provider1"}},
+	},
+}
+
+func newRBACTestRequest(method, routePath string) *http.Request {
+	req := httptest.NewRequest(method, "http://localhost/mount"+routePath, nil)
+	rctx := chi.NewRouteContext()
+	rctx.RoutePath = routePath
+
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func Test_GetRBACEffectivePermissions(t *testing.T) {
+	tests := []struct {
+		rbacCfg   *config.RBACConfig
+		subject   *RBACSubject
+		name      string
+		wantRoles []string
+	}{
+		{
+			name:      "no rbac configuration",
+			subject:   &RBACSubject{Provider: "provider1", Groups: []string{"devs"}},
+			wantRoles: []string{},
+		},
+		{
+			name:      "group binding",
+			rbacCfg:   testRBACConfig,
+			subject:   &RBACSubject{Provider: "provider1", Groups: []string{"other", "devs"}},
+			wantRoles: []string{"reader"},
+		},
+		{
+			name:      "user binding with provider",
+			rbacCfg:   testRBACConfig,
+			subject:   &RBACSubject{Provider: "provider1", Users: []string{"user", "user@example.com"}, Groups: []string{"devs"}},
+			wantRoles: []string{"reader", "uploader"},
+		},
+		{
+			name:      "user binding with another provider",
+			rbacCfg:   testRBACConfig,
+			subject:   &RBACSubject{Provider: "provider2", Users: []string{"user@example.com"}},
+			wantRoles: []string{},
+		},
+		{
+			name:      "user binding without provider",
+			rbacCfg:   testRBACConfig,
+			subject:   &RBACSubject{Users: []string{"user@example.com"}, Groups: []string{"devs"}},
+			wantRoles: []string{},
+		},
+		{
+			name: "same user from another provider type",
+			rbacCfg: &config.RBACConfig{Roles: testRBACConfig.Roles, Bindings: []*config.RBACBindingConfig{
+				{Role: "uploader", Users: []string{"alice@corp.com"}, Providers: []string{"oidc1"}},
+				{Role: "reader", Groups: []string{"admins"}, Providers: []string{"oidc1"}},
+			}},
+			subject:   &RBACSubject{Provider: "basic1", Users: []string{"alice@corp.com"}, Groups: []string{"admins"}},
+			wantRoles: []string{},
+		},
+		{
+			name:      "empty user",
+			rbacCfg:   &config.RBACConfig{Roles: testRBACConfig.Roles, Bindings: []*config.RBACBindingConfig{{Role: "reader", Users: []string{""}, Providers: []string{"provider1"}}}},
+			subject:   &RBACSubject{Provider: "provider1", Users: []string{""}},
+			wantRoles: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GetRBACEffectivePermissions(tt.rbacCfg, tt.subject)
+
+			roles := make([]string, 0)
+			for _, it := range got {
+				roles = append(roles, it.Role)
+			}
+
+			assert.Equal(t, tt.wantRoles, roles)
+		})
+	}
+}
+
+func Test_isRBACAuthorized(t *testing.T) {
+	tests := []struct {
+		user      models.GenericUser
+		name      string
+		method    string
+		routePath string
+		provider  string
+		targetKey string
+		want      bool
+	}{
+		{
+			name:      "read allowed by group",
+			user:      &models.OIDCUser{Groups: []string{"devs"}},
+			method:    http.MethodGet,
+			routePath: "/public/file%20name.txt",
+			provider:  "provider1",
+			targetKey: "tgt1",
+			want:      true,
+		},
+		{
+			name:      "list allowed by group",
+			user:      &models.OIDCUser{Groups: []string{"devs"}},
+			method:    http.MethodHead,
+			routePath: "/public/folder/",
+			provider:  "provider1",
+			targetKey: "tgt1",
+			want:      true,
+		},
+		{
+			name:      "read forbidden outside path",
+			user:      &models.OIDCUser{Groups: []string{"devs"}},
+			method:    http.MethodGet,
+			routePath: "/private/file",
+			provider:  "provider1",
+			targetKey: "tgt1",
+			want:      false,
+		},
+		{
+			name:      "write forbidden without action",
+			user:      &models.OIDCUser{Groups: []string{"devs"}},
+			method:    http.MethodPut,
+			routePath: "/public/file",
+			provider:  "provider1",
+			targetKey: "tgt1",
+			want:      false,
+		},
+		{
+			name:      "write allowed by verified email",
+			user:      &models.OIDCUser{Email: "user@example.com", EmailVerified: true, PreferredUsername: "user"},
+			method:    http.MethodPut,
+			routePath: "/uploads/file",
+			provider:  "provider1",
+			targetKey: "team-a",
+			want:      true,
+		},
+		{
+			name:      "write forbidden by unverified email",
+			user:      &models.OIDCUser{Email: "user@example.com"},
+			method:    http.MethodPut,
+			routePath: "/uploads/file",
+			provider:  "provider1",
+			targetKey: "team-a",
+			want:      false,
+		},
+		{
+			name:      "write forbidden with same username from another provider",
+			user:      &models.BasicAuthUser{Username: "user@example.com"},
+			method:    http.MethodPut,
+			routePath: "/uploads/file",
+			provider:  "basic1",
+			targetKey: "team-a",
+			want:      false,
+		},
+		{
+			name:      "delete forbidden on other target",
+			user:      &models.OIDCUser{Email: "user@example.com", EmailVerified: true},
+			method:    http.MethodDelete,
+			routePath: "/uploads/file",
+			provider:  "provider1",
+			targetKey: "tgt1",
+			want:      false,
+		},
+		{
+			name:      "delete forbidden in sub folder",
+			user:      &models.OIDCUser{Email: "user@example.com", EmailVerified: true},
+			method:    http.MethodDelete,
+			routePath: "/uploads/sub/file",
+			provider:  "provider1",
+			targetKey: "team-a",
+			want:      false,
+		},
+		{
+			name:      "target list allowed with one permission",
+			user:      &models.BasicAuthUser{Username: "user@example.com"},
+			method:    http.MethodGet,
+			routePath: "/",
+			provider:  "provider1",
+			want:      true,
+		},
+		{
+			name:      "target list forbidden without permission",
+			user:      &models.BasicAuthUser{Username: "user"},
+			method:    http.MethodGet,
+			routePath: "/",
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRBACTestRequest(tt.method, tt.routePath)
+
+			got, err := isRBACAuthorized(req, tt.user, tt.provider, tt.targetKey, testRBACConfig)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_getRBACUsers(t *testing.T) {
+	tests := []struct {
+		user models.GenericUser
+		name string
+		want []string
+	}{
+		{
+			name: "verified email",
+			user: &models.OIDCUser{PreferredUsername: "user", Email: "user@example.com", EmailVerified: true},
+			want: []string{"user", "user", "user@example.com"},
+		},
+		{
+			name: "unverified email",
+			user: &models.OIDCUser{PreferredUsername: "user", Email: "user@example.com"},
+			want: []string{"user", "user"},
+		},
+		{
+			name: "identifier falling back on unverified email",
+			user: &models.OIDCUser{Email: "user@example.com"},
+			want: []string{""},
+		},
+		{
+			name: "without email",
+			user: &models.BasicAuthUser{Username: "user@example.com"},
+			want: []string{"user@example.com", "user@example.com", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getRBACUsers(tt.user))
+		})
+	}
+}
+
+func Test_getRBACAction(t *testing.T) {
+	assert.Equal(t, config.RBACListAction, getRBACAction(http.MethodGet, "/folder/"))
+	assert.Equal(t, config.RBACListAction, getRBACAction(http.MethodGet, ""))
+	assert.Equal(t, config.RBACReadAction, getRBACAction(http.MethodHead, "/file"))
+	assert.Equal(t, config.RBACWriteAction, getRBACAction(http.MethodPut, "/file"))
+	assert.Equal(t, config.RBACDeleteAction, getRBACAction(http.MethodDelete, "/file"))
+	assert.Empty(t, getRBACAction(http.MethodPost, "/file"))
+}
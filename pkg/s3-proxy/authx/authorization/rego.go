@@ -0,0 +1,361 @@
+package authorization
+
+import (
+	"context"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/open-policy-agent/opa/v1/loader"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+)
+
+// regoMaskedHeaders contains headers that are masked in decision logs.
+var regoMaskedHeaders = []string{"authorization", "proxy-authorization", "cookie"}
+
+// regoBucketFileExtensions contains file extensions downloaded from bucket policy sources.
+var regoBucketFileExtensions = []string{".rego", ".json", ".yaml", ".yml"}
+
+// RegoManager will manage embedded Rego policies.
+type RegoManager interface {
+	// Load will load and compile all Rego policies from files and buckets.
+	Load() error
+	// Evaluate will evaluate a Rego policy query with input and return its decision.
+	Evaluate(ctx context.Context, policyName string, input any) (*Decision, error)
+	// Generation will return the number of successful loads.
+	// It changes each time policies are reloaded and is used to invalidate cached decisions.
+	Generation() uint64
+}
+
+// NewRegoManager will return a new Rego policy manager.
+func NewRegoManager(cfgManager config.Manager, s3clientManager s3client.Manager, logger log.Logger) RegoManager {
+	return &regoManager{
+		cfgManager:      cfgManager,
+		s3clientManager: s3clientManager,
+		logger:          logger,
+		policies:        map[string]*regoPolicy{},
+	}
+}
+
+type regoPolicy struct {
+	cfg   *config.RegoPolicyConfig
+	query rego.PreparedEvalQuery
+}
+
+type regoManager struct {
+	cfgManager      config.Manager
+	s3clientManager s3client.Manager
+	logger          log.Logger
+	policies        map[string]*regoPolicy
+	generation      uint64
+	mutex           sync.RWMutex
+}
+
+func (m *regoManager) Load() error {
+	// Get configuration
+	cfg := m.cfgManager.GetConfig()
+
+	// Create new policies map
+	policies := map[string]*regoPolicy{}
+
+	// Loop over policies
+	for name, policyCfg := range cfg.RegoPolicies {
+		// Log
+		m.logger.Infof("Load rego policy %s", name)
+
+		// Prepare policy
+		query, err := m.preparePolicy(cfg, policyCfg)
+		// Check error
+		if err != nil {
+			return errors.Wrapf(err, "cannot load rego policy %s", name)
+		}
+
+		// Save
+		policies[name] = &regoPolicy{cfg: policyCfg, query: query}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Save
+	m.policies = policies
+	// Increase generation to invalidate decisions computed with previous policies
+	m.generation++
+
+	return nil
+}
+
+func (m *regoManager) Generation() uint64 {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.generation
+}
+
+func (m *regoManager) preparePolicy(cfg *config.Config, policyCfg *config.RegoPolicyConfig) (rego.PreparedEvalQuery, error) {
+	// Get paths to load
+	paths := policyCfg.Files
+
+	// Check if bucket source is declared
+	if policyCfg.Bucket != nil {
+		// Create temporary directory
+		dir, err := os.MkdirTemp("", "s3-proxy-rego-")
+		// Check error
+		if err != nil {
+			return rego.PreparedEvalQuery{}, errors.WithStack(err)
+		}
+		// Clean directory once policies are compiled
+		defer os.RemoveAll(dir)
+
+		// Download policy files
+		err = m.downloadBucketFiles(cfg.Targets[policyCfg.Bucket.Target], policyCfg.Bucket, dir)
+		// Check error
+		if err != nil {
+			return rego.PreparedEvalQuery{}, err
+		}
+
+		// Add directory to paths
+		paths = append(append([]string{}, paths...), dir)
+	}
+
+	// Load policies and data
+	result, err := loader.NewFileLoader().Filtered(paths, nil)
+	// Check error
+	if err != nil {
+		return rego.PreparedEvalQuery{}, errors.WithStack(err)
+	}
+
+	// Compile policies
+	compiler, err := result.Compiler()
+	// Check error
+	if err != nil {
+		return rego.PreparedEvalQuery{}, errors.WithStack(err)
+	}
+
+	// Create data store
+	store, err := result.Store()
+	// Check error
+	if err != nil {
+		return rego.PreparedEvalQuery{}, errors.WithStack(err)
+	}
+
+	// Prepare query
+	query, err := rego.New(
+		rego.Query(policyCfg.Query),
+		rego.Compiler(compiler),
+		rego.Store(store),
+	).PrepareForEval(context.Background())
+	// Check error
+	if err != nil {
+		return rego.PreparedEvalQuery{}, errors.WithStack(err)
+	}
+
+	return query, nil
+}
+
+func (m *regoManager) downloadBucketFiles(tgt *config.TargetConfig, bucketCfg *config.RegoPolicyBucketConfig, dir string) error {
+	// Get S3 client
+	s3cl := m.s3clientManager.GetClientForTarget(tgt.Name)
+	// Check if it exists
+	if s3cl == nil {
+		return errors.Errorf("no s3 client found for target %s", tgt.Name)
+	}
+
+	// Create span
+	span := opentracing.GlobalTracer().StartSpan("rego.load-bucket-policies")
+	defer span.Finish()
+
+	// Create context
+	ctx := log.SetLoggerInContext(opentracing.ContextWithSpan(context.Background(), span), m.logger)
+
+	// Build root key
+	rootKey := tgt.Bucket.GetRootPrefix() + bucketCfg.Prefix
+	// Check if root key ends with a /
+	if rootKey != "" && !strings.HasSuffix(rootKey, "/") {
+		rootKey += "/"
+	}
+
+	return downloadRegoBucketFiles(ctx, s3cl, rootKey, rootKey, dir)
+}
+
+func downloadRegoBucketFiles(ctx context.Context, s3cl s3client.Client, rootKey, key, dir string) error {
+	// List current level
+	elements, _, err := s3cl.ListFilesAndDirectories(ctx, key)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Loop over elements
+	for _, el := range elements {
+		// Check if it is a folder
+		if el.Type == s3client.FolderType {
+			// Download sub folder
+			err = downloadRegoBucketFiles(ctx, s3cl, rootKey, el.Key, dir)
+			// Check error
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		// Check file extension
+		if !isRegoBucketFile(el.Key) {
+			continue
+		}
+
+		// Get relative path
+		relPath := filepath.FromSlash(strings.TrimPrefix(el.Key, rootKey))
+		// Check that path stays in directory
+		if !filepath.IsLocal(relPath) {
+			return errors.Errorf("invalid rego policy file key %s", el.Key)
+		}
+
+		// Download file
+		err = downloadRegoBucketFile(ctx, s3cl, el.Key, filepath.Join(dir, relPath))
+		// Check error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func downloadRegoBucketFile(ctx context.Context, s3cl s3client.Client, key, fpath string) error {
+	// Get object
+	obj, _, err := s3cl.GetObject(ctx, &s3client.GetInput{Key: key})
+	// Check error
+	if err != nil {
+		return err
+	}
+	// Defer closing body
+	defer obj.Body.Close()
+
+	// Create directory
+	err = os.MkdirAll(filepath.Dir(fpath), 0o700) //nolint: mnd // Private temporary directory
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Read content
+	content, err := io.ReadAll(obj.Body)
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Write file
+	return errors.WithStack(os.WriteFile(fpath, content, 0o600)) //nolint: mnd // Private temporary file
+}
+
+func isRegoBucketFile(key string) bool {
+	return slices.Contains(regoBucketFileExtensions, strings.ToLower(filepath.Ext(key)))
+}
+
+func (m *regoManager) Evaluate(ctx context.Context, policyName string, input any) (*Decision, error) {
+	// Get policy
+	m.mutex.RLock()
+	policy := m.policies[policyName]
+	m.mutex.RUnlock()
+
+	// Check if policy exists
+	if policy == nil {
+		return nil, errors.Errorf("rego policy %s isn't loaded", policyName)
+	}
+
+	// Get trace from context
+	trace := tracing.GetTraceFromContext(ctx)
+	// Check if trace exists
+	if trace != nil {
+		// Generate child trace
+		childTrace := trace.GetChildTrace("rego.evaluate")
+		defer childTrace.Finish()
+		// Add data
+		childTrace.SetTag("rego.policy", policyName)
+	}
+
+	// Save start time
+	start := time.Now()
+
+	// Evaluate
+	rs, err := policy.query.Eval(ctx, rego.EvalInput(input))
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Get result
+	var result any
+	// Check if result is defined
+	if len(rs) != 0 && len(rs[0].Expressions) != 0 {
+		result = rs[0].Expressions[0].Value
+	}
+
+	// Get decision
+	decision, err := parseDecision(result)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if decision must be logged
+	if policy.cfg.DecisionLog {
+		log.GetLoggerFromContext(ctx).WithFields(map[string]any{
+			"policy":   policyName,
+			"query":    policy.cfg.Query,
+			"input":    maskRegoDecisionLogInput(input),
+			"result":   decision.Allowed,
+			"reasons":  decision.Reasons,
+			"duration": time.Since(start).String(),
+		}).Info("Rego policy decision")
+	}
+
+	return decision, nil
+}
+
+// maskRegoDecisionLogInput will return input with sensitive headers masked.
+func maskRegoDecisionLogInput(input any) any {
+	// Check input type
+	in, ok := input.(*inputDataOPA)
+	if !ok || in.Request == nil {
+		return input
+	}
+
+	// Copy headers
+	headers := maps.Clone(in.Request.Headers)
+	// Mask sensitive headers
+	for _, k := range regoMaskedHeaders {
+		if _, ok := headers[k]; ok {
+			headers[k] = "***"
+		}
+	}
+
+	// Copy request
+	req := *in.Request
+	req.Headers = headers
+	// Copy input
+	res := *in
+	res.Request = &req
+
+	return &res
+}
+
+func isRegoAuthorized(req *http.Request, input *inputDataOPA, resource *config.ResourceHeaderOIDC, regoManager RegoManager) (*Decision, error) {
+	return regoManager.Evaluate(req.Context(), resource.AuthorizationRego.Policy, input)
+}
@@ -0,0 +1,177 @@
+//go:build unit
+
+package authorization
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	s3mocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client/mocks"
+)
+
+const testRegoPolicy = `package s3proxy.authz
+
+default allowed := false
+
+allowed if {
+	some group in input.user.groups
+	group in data.allowed_groups
+	input.tags.env == "test"
+}
+`
+
+func writeRegoTestFiles(t *testing.T, policy, data string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "policy.rego"), []byte(policy), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data.json"), []byte(data), 0o600))
+
+	return dir
+}
+
+func newRegoTestInput(groups []string, headers map[string]string) *inputDataOPA {
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/folder/file", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	return buildInputDataOPA(req, &models.OIDCUser{Groups: groups}, map[string]string{"env": "test"})
+}
+
+func Test_regoManager_Files(t *testing.T) {
+	dir := writeRegoTestFiles(t, testRegoPolicy, `{"allowed_groups": ["admins"]}`)
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfg := &config.Config{
+		RegoPolicies: map[string]*config.RegoPolicyConfig{
+			"p1": {Files: []string{dir}, Query: "data.s3proxy.authz.allowed", DecisionLog: true},
+		},
+	}
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().DoAndReturn(func() *config.Config { return cfg })
+
+	m := NewRegoManager(cfgManagerMock, nil, log.NewLogger())
+	require.NoError(t, m.Load())
+	assert.Equal(t, uint64(1), m.Generation())
+
+	ctx := log.SetLoggerInContext(context.TODO(), log.NewLogger())
+
+	// Allowed
+	got, err := m.Evaluate(ctx, "p1", newRegoTestInput([]string{"users", "admins"}, nil))
+	require.NoError(t, err)
+	assert.True(t, got.Allowed)
+
+	// Denied
+	got, err = m.Evaluate(ctx, "p1", newRegoTestInput([]string{"users"}, nil))
+	require.NoError(t, err)
+	assert.False(t, got.Allowed)
+
+	// Unknown policy
+	_, err = m.Evaluate(ctx, "p2", newRegoTestInput([]string{"admins"}, nil))
+	assert.EqualError(t, err, "rego policy p2 isn't loaded")
+
+	// Reload with new data
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data.json"), []byte(`{"allowed_groups": ["users"]}`), 0o600))
+	require.NoError(t, m.Load())
+	assert.Equal(t, uint64(2), m.Generation())
+
+	got, err = m.Evaluate(ctx, "p1", newRegoTestInput([]string{"users"}, nil))
+	require.NoError(t, err)
+	assert.True(t, got.Allowed)
+}
+
+func Test_regoManager_Load_InvalidPolicy(t *testing.T) {
+	dir := writeRegoTestFiles(t, "package s3proxy.authz\n\nallowed if {", `{}`)
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().Return(&config.Config{
+		RegoPolicies: map[string]*config.RegoPolicyConfig{
+			"p1": {Files: []string{dir}, Query: "data.s3proxy.authz.allowed"},
+		},
+	})
+
+	m := NewRegoManager(cfgManagerMock, nil, log.NewLogger())
+	err := m.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot load rego policy p1")
+	assert.Equal(t, uint64(0), m.Generation())
+}
+
+func Test_regoManager_Bucket(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().Return(&config.Config{
+		Targets: map[string]*config.TargetConfig{
+			"tgt1": {Name: "tgt1", Bucket: &config.BucketConfig{Prefix: "root"}},
+		},
+		RegoPolicies: map[string]*config.RegoPolicyConfig{
+			"p1": {
+				Bucket: &config.RegoPolicyBucketConfig{Target: "tgt1", Prefix: "policies"},
+				Query:  "data.s3proxy.authz.allowed",
+			},
+		},
+	})
+
+	s3clMock := s3mocks.NewMockClient(ctrl)
+	s3clMock.EXPECT().ListFilesAndDirectories(gomock.Any(), "root/policies/").Return([]*s3client.ListElementOutput{
+		{Type: s3client.FileType, Key: "root/policies/policy.rego"},
+		{Type: s3client.FileType, Key: "root/policies/README.md"},
+		{Type: s3client.FolderType, Key: "root/policies/data/"},
+	}, nil, nil)
+	s3clMock.EXPECT().ListFilesAndDirectories(gomock.Any(), "root/policies/data/").Return([]*s3client.ListElementOutput{
+		{Type: s3client.FileType, Key: "root/policies/data/data.json"},
+	}, nil, nil)
+	s3clMock.EXPECT().GetObject(gomock.Any(), &s3client.GetInput{Key: "root/policies/policy.rego"}).Return(&s3client.GetOutput{
+		Body: io.NopCloser(strings.NewReader(strings.ReplaceAll(testRegoPolicy, "data.allowed_groups", "data.data.allowed_groups"))),
+	}, nil, nil)
+	s3clMock.EXPECT().GetObject(gomock.Any(), &s3client.GetInput{Key: "root/policies/data/data.json"}).Return(&s3client.GetOutput{
+		Body: io.NopCloser(strings.NewReader(`{"allowed_groups": ["admins"]}`)),
+	}, nil, nil)
+
+	s3ManagerMock := s3mocks.NewMockManager(ctrl)
+	s3ManagerMock.EXPECT().GetClientForTarget("tgt1").Return(s3clMock)
+
+	m := NewRegoManager(cfgManagerMock, s3ManagerMock, log.NewLogger())
+	require.NoError(t, m.Load())
+
+	got, err := m.Evaluate(context.TODO(), "p1", newRegoTestInput([]string{"admins"}, nil))
+	require.NoError(t, err)
+	assert.True(t, got.Allowed)
+}
+
+func Test_maskRegoDecisionLogInput(t *testing.T) {
+	input := newRegoTestInput([]string{"admins"}, map[string]string{
+		"Authorization": "Bearer token",
+		"Cookie":        "oidc=token",
+		"X-Custom":      "value",
+	})
+
+	got, ok := maskRegoDecisionLogInput(input).(*inputDataOPA)
+	require.True(t, ok)
+
+	assert.Equal(t, "***", got.Request.Headers["authorization"])
+	assert.Equal(t, "***", got.Request.Headers["cookie"])
+	assert.Equal(t, "value", got.Request.Headers["x-custom"])
+	// Original input mustn't be modified
+	assert.Equal(t, "Bearer token", input.Request.Headers["authorization"])
+
+	// Other inputs are returned as is
+	assert.Equal(t, "fake", maskRegoDecisionLogInput("fake"))
+}
@@ -0,0 +1,328 @@
+// Package hmacsign contains the request signing used by S3-Proxy HMAC authentication providers.
+//
+// Clients can import this package to sign their requests with a Signer or with a Transport:
+//
+//	cl := &http.Client{Transport: hmacsign.NewTransport(hmacsign.NewSigner("key-id", "secret"), nil)}
+package hmacsign
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+const (
+	// Algorithm is the authorization scheme used in Authorization header.
+	Algorithm = "S3P-HMAC-SHA256"
+	// DateHeader is the header containing the request date in TimeFormat.
+	DateHeader = "X-S3P-Date"
+	// ContentSHA256Header is the header containing the hex encoded SHA256 of the request body or UnsignedPayload.
+	ContentSHA256Header = "X-S3P-Content-Sha256"
+	// NonceHeader is the header containing a random value to make each signature unique.
+	NonceHeader = "X-S3P-Nonce"
+	// UnsignedPayload is the ContentSHA256Header value used when body isn't signed.
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+	// TimeFormat is the date format used in DateHeader.
+	TimeFormat = "20060102T150405Z"
+)
+
+// nonceLength is the number of random bytes used in nonce.
+const nonceLength = 16
+
+// RequiredSignedHeaders are headers that must always be signed (in lowercase).
+var RequiredSignedHeaders = []string{
+	"host",
+	strings.ToLower(ContentSHA256Header),
+	strings.ToLower(DateHeader),
+	strings.ToLower(NonceHeader),
+}
+
+// ErrInvalidAuthorization is returned when Authorization header can't be parsed.
+var ErrInvalidAuthorization = errors.New("invalid hmac authorization header")
+
+// Authorization represents a parsed Authorization header.
+type Authorization struct {
+	// KeyID is the identifier of the key used to sign request.
+	KeyID string
+	// Signature is the hex encoded request signature.
+	Signature string
+	// SignedHeaders are the lowercase signed header names in canonical order.
+	SignedHeaders []string
+}
+
+// ParseAuthorization will parse an Authorization header value with the format
+// "S3P-HMAC-SHA256 Credential=KEY_ID, SignedHeaders=host;x-s3p-date, Signature=HEX".
+func ParseAuthorization(value string) (*Authorization, error) {
+	// Check scheme
+	params, found := strings.CutPrefix(value, Algorithm+" ")
+	if !found {
+		return nil, errors.WithStack(ErrInvalidAuthorization)
+	}
+
+	res := &Authorization{}
+	// Loop over parameters
+	for _, it := range strings.Split(params, ",") {
+		// Split key and value
+		k, v, found := strings.Cut(strings.TrimSpace(it), "=")
+		if !found {
+			return nil, errors.WithStack(ErrInvalidAuthorization)
+		}
+
+		switch k {
+		case "Credential":
+			res.KeyID = v
+		case "SignedHeaders":
+			res.SignedHeaders = strings.Split(v, ";")
+		case "Signature":
+			res.Signature = v
+		default:
+			return nil, errors.WithStack(ErrInvalidAuthorization)
+		}
+	}
+
+	// Check that all parameters are set
+	if res.KeyID == "" || res.Signature == "" || len(res.SignedHeaders) == 0 {
+		return nil, errors.WithStack(ErrInvalidAuthorization)
+	}
+
+	return res, nil
+}
+
+// String will format authorization as an Authorization header value.
+func (a *Authorization) String() string {
+	return Algorithm + " Credential=" + a.KeyID + ", SignedHeaders=" + strings.Join(a.SignedHeaders, ";") + ", Signature=" + a.Signature
+}
+
+// HashPayload will return the hex encoded SHA256 of a body.
+func HashPayload(body []byte) string {
+	sum := sha256.Sum256(body)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// CanonicalRequest will build the canonical request that is signed.
+// It contains the method, the escaped path, the sorted query string, the signed headers and the payload hash,
+// separated by new lines.
+func CanonicalRequest(req *http.Request, signedHeaders []string, payloadHash string) string {
+	// Get path
+	p := req.URL.EscapedPath()
+	if p == "" {
+		p = "/"
+	}
+
+	// Build canonical headers
+	var headers strings.Builder
+
+	for _, h := range signedHeaders {
+		headers.WriteString(h + ":" + getCanonicalHeaderValue(req, h) + "\n")
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		p,
+		getCanonicalQuery(req.URL.Query()),
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+// getCanonicalQuery will encode query parameters sorted by key and by value.
+func getCanonicalQuery(query url.Values) string {
+	// Get sorted keys
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	res := make([]string, 0, len(keys))
+	// Loop over keys
+	for _, k := range keys {
+		// Get sorted values
+		values := slices.Clone(query[k])
+		slices.Sort(values)
+
+		for _, v := range values {
+			res = append(res, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+
+	return strings.Join(res, "&")
+}
+
+// getCanonicalHeaderValue will return header values joined by commas with spaces trimmed.
+func getCanonicalHeaderValue(req *http.Request, name string) string {
+	// Host header is stored in request host
+	if name == "host" {
+		// Check if host is overridden
+		if req.Host != "" {
+			return req.Host
+		}
+
+		return req.URL.Host
+	}
+
+	values := req.Header.Values(name)
+	res := make([]string, 0, len(values))
+
+	for _, v := range values {
+		res = append(res, strings.Join(strings.Fields(v), " "))
+	}
+
+	return strings.Join(res, ",")
+}
+
+// Signature will compute the hex encoded signature of a canonical request.
+// The signed string contains the algorithm, the request date and the hex encoded SHA256 of the canonical request.
+func Signature(secret, date, canonicalRequest string) string {
+	// Hash canonical request
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	// Build string to sign
+	stringToSign := Algorithm + "\n" + date + "\n" + hex.EncodeToString(sum[:])
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	// Hash.Write never returns an error
+	_, _ = mac.Write([]byte(stringToSign))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Signer will sign requests with a key.
+type Signer struct {
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+	// KeyID is the identifier of the key declared in S3-Proxy.
+	KeyID string
+	// Secret is the shared secret of the key.
+	Secret string
+	// Headers are additional headers to sign (for example, the ones required by S3-Proxy configuration).
+	Headers []string
+	// UnsignedPayload will skip body hashing. This must be allowed in S3-Proxy configuration.
+	UnsignedPayload bool
+}
+
+// NewSigner will create a new signer.
+func NewSigner(keyID, secret string) *Signer {
+	return &Signer{
+		Now:    time.Now,
+		KeyID:  keyID,
+		Secret: secret,
+	}
+}
+
+// Sign will sign request by setting date, content hash, nonce and Authorization headers.
+// Request body is read and replaced in order to compute its hash.
+func (s *Signer) Sign(req *http.Request) error {
+	// Compute payload hash
+	payloadHash := UnsignedPayload
+	// Check if body must be signed
+	if !s.UnsignedPayload {
+		var body []byte
+		// Check if body exists
+		if req.Body != nil && req.Body != http.NoBody {
+			var err error
+			// Read body
+			body, err = io.ReadAll(req.Body)
+			// Check error
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			_ = req.Body.Close()
+			// Replace body
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			req.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+
+		payloadHash = HashPayload(body)
+	}
+
+	// Generate nonce
+	nonce := make([]byte, nonceLength)
+	// Read random bytes
+	_, err := rand.Read(nonce)
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Get now function
+	now := s.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	date := now().UTC().Format(TimeFormat)
+
+	// Set headers
+	req.Header.Set(DateHeader, date)
+	req.Header.Set(ContentSHA256Header, payloadHash)
+	req.Header.Set(NonceHeader, hex.EncodeToString(nonce))
+
+	// Build signed headers list
+	signedHeaders := slices.Clone(RequiredSignedHeaders)
+
+	for _, h := range s.Headers {
+		signedHeaders = append(signedHeaders, strings.ToLower(h))
+	}
+
+	slices.Sort(signedHeaders)
+	signedHeaders = slices.Compact(signedHeaders)
+
+	// Sign
+	auth := &Authorization{
+		KeyID:         s.KeyID,
+		SignedHeaders: signedHeaders,
+		Signature:     Signature(s.Secret, date, CanonicalRequest(req, signedHeaders, payloadHash)),
+	}
+
+	req.Header.Set("Authorization", auth.String())
+
+	return nil
+}
+
+// Transport is a http.RoundTripper signing all requests.
+type Transport struct {
+	// Base is the transport used to send signed requests. Defaults to http.DefaultTransport.
+	Base   http.RoundTripper
+	Signer *Signer
+}
+
+// NewTransport will create a new signing transport.
+func NewTransport(signer *Signer, base http.RoundTripper) *Transport {
+	return &Transport{Base: base, Signer: signer}
+}
+
+// RoundTrip will sign a copy of the request and send it.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Clone request to avoid modifying the original one
+	r := req.Clone(req.Context())
+	// Sign it
+	err := t.Signer.Sign(r)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Get base transport
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return base.RoundTrip(r)
+}
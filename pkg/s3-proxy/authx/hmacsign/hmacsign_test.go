@@ -0,0 +1,179 @@
+//go:build unit
+
+package hmacsign
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuthorization(t *testing.T) {
+	tests := []struct {
+		want    *Authorization
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			value: "S3P-HMAC-SHA256 Credential=key1, SignedHeaders=host;x-s3p-date, Signature=abcd",
+			want:  &Authorization{KeyID: "key1", SignedHeaders: []string{"host", "x-s3p-date"}, Signature: "abcd"},
+		},
+		{
+			name:    "other scheme",
+			value:   "Bearer token",
+			wantErr: true,
+		},
+		{
+			name:    "missing signature",
+			value:   "S3P-HMAC-SHA256 Credential=key1, SignedHeaders=host",
+			wantErr: true,
+		},
+		{
+			name:    "unknown parameter",
+			value:   "S3P-HMAC-SHA256 Credential=key1, SignedHeaders=host, Signature=abcd, Other=value",
+			wantErr: true,
+		},
+		{
+			name:    "invalid parameter",
+			value:   "S3P-HMAC-SHA256 Credential",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAuthorization(tt.value)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAuthorization)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.value, got.String())
+		})
+	}
+}
+
+func TestCanonicalRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://localhost:8080/mount/folder%20name/file.txt?b=2&a=3&a=1&c", nil)
+	req.Header.Set("X-Custom", "  value   with  spaces ")
+	req.Header.Add("X-Multi", "v1")
+	req.Header.Add("X-Multi", "v2")
+
+	got := CanonicalRequest(req, []string{"host", "x-custom", "x-multi"}, "hash")
+
+	assert.Equal(t, strings.Join([]string{
+		"GET",
+		"/mount/folder%20name/file.txt",
+		"a=1&a=3&b=2&c=",
+		"host:localhost:8080\nx-custom:value with spaces\nx-multi:v1,v2\n",
+		"host;x-custom;x-multi",
+		"hash",
+	}, "\n"), got)
+}
+
+func TestSigner_Sign(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		body            string
+		wantPayloadHash string
+		unsignedPayload bool
+	}{
+		{
+			name:            "empty body",
+			wantPayloadHash: HashPayload(nil),
+		},
+		{
+			name:            "body",
+			body:            "content",
+			wantPayloadHash: HashPayload([]byte("content")),
+		},
+		{
+			name:            "unsigned payload",
+			body:            "content",
+			unsignedPayload: true,
+			wantPayloadHash: UnsignedPayload,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader = http.NoBody
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+
+			req, err := http.NewRequest(http.MethodPut, "http://localhost/mount/file.txt", body)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "text/plain")
+
+			s := NewSigner("key1", "secret")
+			s.Now = func() time.Time { return now }
+			s.Headers = []string{"Content-Type"}
+			s.UnsignedPayload = tt.unsignedPayload
+
+			err = s.Sign(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, "20260102T030405Z", req.Header.Get(DateHeader))
+			assert.Equal(t, tt.wantPayloadHash, req.Header.Get(ContentSHA256Header))
+			assert.Len(t, req.Header.Get(NonceHeader), 2*nonceLength)
+
+			// Check that body is still readable
+			b, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(b))
+
+			// Check signature
+			auth, err := ParseAuthorization(req.Header.Get("Authorization"))
+			require.NoError(t, err)
+			assert.Equal(t, "key1", auth.KeyID)
+			assert.Equal(t, []string{"content-type", "host", "x-s3p-content-sha256", "x-s3p-date", "x-s3p-nonce"}, auth.SignedHeaders)
+
+			expected := Signature("secret", "20260102T030405Z", CanonicalRequest(req, auth.SignedHeaders, tt.wantPayloadHash))
+			assert.Equal(t, expected, auth.Signature)
+		})
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var (
+		gotAuthorization string
+		gotBody          string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuthorization = r.Header.Get("Authorization")
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	cl := &http.Client{Transport: NewTransport(NewSigner("key1", "secret"), nil)}
+
+	req, err := http.NewRequest(http.MethodPut, ts.URL+"/file.txt", strings.NewReader("content"))
+	require.NoError(t, err)
+
+	resp, err := cl.Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(gotAuthorization, Algorithm+" Credential=key1, "))
+	assert.Equal(t, "content", gotBody)
+	// Original request isn't modified
+	assert.Empty(t, req.Header.Get("Authorization"))
+}
@@ -0,0 +1,3 @@
+package impersonation
+
+// This package will allow admins to act as another user for support and debugging
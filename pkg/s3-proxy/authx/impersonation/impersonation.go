@@ -0,0 +1,493 @@
+package impersonation
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/audit"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+)
+
+// Query parameters used to switch impersonation in the browser session.
+const (
+	QueryParameter       = "impersonate"
+	GroupsQueryParameter = "impersonateGroups"
+)
+
+// CSRFTokenFormField is the form field containing the session switch token.
+const CSRFTokenFormField = "csrfToken"
+
+// csrfTokenValidity is the validity of a session switch token.
+const csrfTokenValidity = 10 * time.Minute
+
+// Cookie value keys.
+const (
+	cookieUserKey   = "user"
+	cookieGroupsKey = "groups"
+)
+
+// Signature purposes used to separate csrf tokens and cookie signatures.
+const (
+	csrfTokenPurpose = "csrf"
+	cookiePurpose    = "cookie"
+)
+
+// switchPageTemplate is the confirmation page answered on session switch requests.
+// The switch is only done when the admin submits the form, so it cannot be triggered by a link or an image.
+var switchPageTemplate = template.Must(template.New("impersonation").Parse(`<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8" />
+    <title>Impersonation</title>
+  </head>
+  <body>
+    <form method="post" action="{{ .Action }}">
+      {{- if .User }}
+      <p>Impersonate {{ .User }}{{ if .Groups }} with groups {{ .Groups }}{{ end }}?</p>
+      {{- else }}
+      <p>Stop impersonation?</p>
+      {{- end }}
+      <input type="hidden" name="` + CSRFTokenFormField + `" value="{{ .Token }}" />
+      <button type="submit">Confirm</button>
+    </form>
+  </body>
+</html>
+`))
+
+// IsAdmin checks if a user is allowed to impersonate other users.
+func IsAdmin(cfg *config.ImpersonationConfig, user models.GenericUser) bool {
+	// Check identifier
+	if slices.Contains(cfg.Admins, user.GetIdentifier()) {
+		return true
+	}
+
+	// Check groups
+	for _, g := range user.GetGroups() {
+		if slices.Contains(cfg.AdminGroups, g) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsSessionSwitch checks if a request is a session switch confirmation sent by the confirmation page.
+// Those requests are authenticated like the GET confirmation page because resources cannot declare POST methods.
+func IsSessionSwitch(impCfg *config.ImpersonationConfig, r *http.Request) bool {
+	return impCfg != nil && impCfg.Enabled && r.Method == http.MethodPost && r.URL.Query().Has(QueryParameter)
+}
+
+// isMutatingMethod checks if a http method can modify the bucket.
+func isMutatingMethod(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
+
+// parseGroups will split a comma separated group list and ignore empty groups.
+func parseGroups(value string) []string {
+	var res []string
+
+	for g := range strings.SplitSeq(value, ",") {
+		// Remove spaces
+		g = strings.TrimSpace(g)
+		// Check if group isn't empty
+		if g != "" {
+			res = append(res, g)
+		}
+	}
+
+	return res
+}
+
+// sign will compute the hex encoded HMAC-SHA256 of the given parts with the impersonation secret.
+func sign(secret, purpose string, parts ...string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+
+	for _, p := range parts {
+		// Separate parts to avoid collisions
+		mac.Write([]byte{0})
+		mac.Write([]byte(p))
+	}
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// encodeIdentity will encode an impersonated identity.
+func encodeIdentity(user string, groups []string) string {
+	v := url.Values{}
+	v.Set(cookieUserKey, user)
+	// Check if groups exist
+	if len(groups) != 0 {
+		v.Set(cookieGroupsKey, strings.Join(groups, ","))
+	}
+
+	return v.Encode()
+}
+
+// encodeCookieValue will encode an impersonated identity in a cookie value signed for the impersonator.
+func encodeCookieValue(secret, impersonator, user string, groups []string) string {
+	identity := encodeIdentity(user, groups)
+
+	return sign(secret, cookiePurpose, impersonator, identity) + "." + identity
+}
+
+// decodeCookieValue will decode an impersonated identity from a cookie value signed for the impersonator.
+// An empty user is returned when value is invalid or when signature doesn't match.
+func decodeCookieValue(secret, impersonator, value string) (string, []string) {
+	// Split signature and identity
+	signature, identity, ok := strings.Cut(value, ".")
+	// Check signature
+	if !ok || !hmac.Equal([]byte(signature), []byte(sign(secret, cookiePurpose, impersonator, identity))) {
+		return "", nil
+	}
+
+	// Parse value
+	v, err := url.ParseQuery(identity)
+	// Check error
+	if err != nil {
+		return "", nil
+	}
+
+	return strings.TrimSpace(v.Get(cookieUserKey)), parseGroups(v.Get(cookieGroupsKey))
+}
+
+// newCSRFToken will create a session switch token for the impersonator and the requested identity.
+func newCSRFToken(secret, impersonator, user string, groups []string, now time.Time) string {
+	expires := strconv.FormatInt(now.Add(csrfTokenValidity).Unix(), 10)
+
+	return expires + "." + sign(secret, csrfTokenPurpose, impersonator, encodeIdentity(user, groups), expires)
+}
+
+// checkCSRFToken will check that a session switch token is valid for the impersonator and the requested identity.
+func checkCSRFToken(secret, impersonator, user string, groups []string, token string, now time.Time) bool {
+	// Split expiration and signature
+	expires, signature, ok := strings.Cut(token, ".")
+	// Check format
+	if !ok {
+		return false
+	}
+
+	// Parse expiration
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	// Check error and expiration
+	if err != nil || now.Unix() > exp {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(sign(secret, csrfTokenPurpose, impersonator, encodeIdentity(user, groups), expires)))
+}
+
+// checkImpersonated will check that the identity can be impersonated.
+// Impersonation admins and user isolation admins cannot be impersonated as they would give more rights
+// than the impersonation feature, and groups must be in the allowed groups list.
+func checkImpersonated(impCfg *config.ImpersonationConfig, protected map[string]bool, user string, groups []string) error {
+	// Check identifier
+	if protected[user] || slices.Contains(impCfg.Admins, user) {
+		return errors.Errorf("user %s cannot be impersonated", user)
+	}
+
+	// Check groups
+	for _, g := range groups {
+		if !slices.Contains(impCfg.AllowedGroups, g) {
+			return errors.Errorf("group %s isn't allowed for impersonation", g)
+		}
+	}
+
+	return nil
+}
+
+// getProtectedIdentifiers will list user isolation admins of all targets.
+func getProtectedIdentifiers(cfg *config.Config) map[string]bool {
+	res := map[string]bool{}
+
+	for _, tgt := range cfg.Targets {
+		// Check if GET action configuration exists
+		if tgt.Actions == nil || tgt.Actions.GET == nil || tgt.Actions.GET.Config == nil {
+			continue
+		}
+
+		for _, a := range tgt.Actions.GET.Config.UserIsolationAdmins {
+			res[a] = true
+		}
+	}
+
+	return res
+}
+
+// HTTPMiddleware will replace the authenticated user by the impersonated one when an admin asks for it
+// with headers or with the impersonation cookie.
+// It must be placed after the authentication middleware and before the authorization one.
+func HTTPMiddleware(
+	// This has been saved only for response handler.
+	// Not used inside the service functions because it is better to use fixed configuration to avoid conflict in case of reload and incoming request.
+	cfgManager config.Manager,
+) func(http.Handler) http.Handler {
+	// Get impersonation configuration at router creation to keep it fixed
+	cfg := cfgManager.GetConfig()
+	impCfg := cfg.Impersonation
+
+	return func(next http.Handler) http.Handler {
+		// Check if impersonation is disabled
+		if impCfg == nil || !impCfg.Enabled {
+			return next
+		}
+
+		// Get secret used to sign switch tokens and cookies
+		var secret string
+		if impCfg.CookieSecret != nil {
+			secret = impCfg.CookieSecret.Value
+		}
+
+		// Get identities that cannot be impersonated
+		protected := getProtectedIdentifiers(cfg)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get logger
+			logger := log.GetLoggerFromContext(r.Context())
+
+			// Get user from context
+			user := models.GetAuthenticatedUserFromContext(r.Context())
+			// Check if user exists
+			if user == nil {
+				// Authentication was skipped, nothing to impersonate
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			// Check if it is a session switch
+			if (r.Method == http.MethodGet && r.URL.Query().Has(QueryParameter)) || IsSessionSwitch(impCfg, r) {
+				switchSession(w, r, cfgManager, impCfg, protected, secret, user)
+
+				return
+			}
+
+			// Get impersonated identity from headers
+			impersonated := strings.TrimSpace(r.Header.Get(impCfg.Header))
+			groups := parseGroups(r.Header.Get(impCfg.GroupsHeader))
+			fromHeader := impersonated != ""
+
+			// Check if headers aren't set to get identity from cookie
+			if !fromHeader {
+				// Get cookie
+				cookie, err := r.Cookie(impCfg.CookieName)
+				// Check if cookie exists
+				if err == nil {
+					impersonated, groups = decodeCookieValue(secret, user.GetIdentifier(), cookie.Value)
+				}
+			}
+
+			// Check if impersonation is requested
+			if impersonated == "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			// Check if user is allowed to impersonate
+			if !IsAdmin(impCfg, user) {
+				// Check if it comes from cookie
+				if !fromHeader {
+					// Ignore cookie, it can be a leftover of a previous admin session
+					logger.Debugf("impersonation cookie ignored for user %s which isn't an impersonation admin", user.GetIdentifier())
+					next.ServeHTTP(w, r)
+
+					return
+				}
+
+				audit.SetDecision(r.Context(), audit.DecisionDeny, "impersonation: user isn't an impersonation admin")
+				forbidden(w, r, cfgManager, errors.WithStack(fmt.Errorf("user %s isn't allowed to impersonate => Forbidden access", user.GetIdentifier())))
+
+				return
+			}
+
+			// Check if identity can be impersonated
+			err := checkImpersonated(impCfg, protected, impersonated, groups)
+			// Check error
+			if err != nil {
+				audit.SetDecision(r.Context(), audit.DecisionDeny, "impersonation: identity cannot be impersonated")
+				forbidden(w, r, cfgManager, errors.WithMessage(err, "forbidden access"))
+
+				return
+			}
+
+			// Create impersonated user
+			iuser := &models.ImpersonatedUser{
+				Impersonator: user,
+				Username:     impersonated,
+				Groups:       groups,
+			}
+
+			// Add impersonation fields to logger
+			logger = logger.WithFields(map[string]any{
+				"impersonator":      user.GetIdentifier(),
+				"impersonated_user": impersonated,
+			})
+
+			// Replace user and logger in context
+			ctx := models.SetAuthenticatedUserInContext(r.Context(), iuser)
+			ctx = log.SetLoggerInContext(ctx, logger)
+			// Create new request with new context
+			r = r.WithContext(ctx)
+
+			// Update response handler to have the latest context values
+			responsehandler.GetResponseHandlerFromContext(r.Context()).UpdateRequestAndResponse(r, w)
+
+			// Check if action is mutating
+			if !impCfg.AllowMutations && isMutatingMethod(r.Method) {
+				audit.SetDecision(r.Context(), audit.DecisionDeny, "impersonation: mutating actions aren't allowed")
+				forbidden(w, r, cfgManager, errors.WithStack(fmt.Errorf(
+					"user %s cannot use method %s while impersonating %s => Forbidden access",
+					user.GetIdentifier(), r.Method, impersonated,
+				)))
+
+				return
+			}
+
+			logger.Infof("user %s impersonates %s", user.GetIdentifier(), impersonated)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// switchSession will answer a confirmation page on GET requests. On POST requests with a valid token coming from
+// this page, it will set or remove the impersonation cookie and redirect to the same url without impersonation
+// query parameters.
+func switchSession(
+	w http.ResponseWriter,
+	r *http.Request,
+	cfgManager config.Manager,
+	impCfg *config.ImpersonationConfig,
+	protected map[string]bool,
+	secret string,
+	user models.GenericUser,
+) {
+	// Check if user is allowed to impersonate
+	if !IsAdmin(impCfg, user) {
+		audit.SetDecision(r.Context(), audit.DecisionDeny, "impersonation: user isn't an impersonation admin")
+		forbidden(w, r, cfgManager, errors.WithStack(fmt.Errorf("user %s isn't allowed to impersonate => Forbidden access", user.GetIdentifier())))
+
+		return
+	}
+
+	// Get logger
+	logger := log.GetLoggerFromContext(r.Context())
+
+	// Get query values
+	qs := r.URL.Query()
+	impersonated := strings.TrimSpace(qs.Get(QueryParameter))
+	groups := parseGroups(qs.Get(GroupsQueryParameter))
+
+	// Check if identity can be impersonated
+	if impersonated != "" {
+		err := checkImpersonated(impCfg, protected, impersonated, groups)
+		// Check error
+		if err != nil {
+			audit.SetDecision(r.Context(), audit.DecisionDeny, "impersonation: identity cannot be impersonated")
+			forbidden(w, r, cfgManager, errors.WithMessage(err, "forbidden access"))
+
+			return
+		}
+	}
+
+	// Check if it is a confirmation page request
+	if r.Method == http.MethodGet {
+		audit.SetDecision(r.Context(), audit.DecisionAllow, "impersonation: session switch confirmation")
+
+		// Forbid framing and caching of the page containing the token
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Frame-Options", "DENY")
+		w.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+
+		// Execute template
+		err := switchPageTemplate.Execute(w, map[string]any{
+			"Action": r.URL.RequestURI(),
+			"User":   impersonated,
+			"Groups": strings.Join(groups, ", "),
+			"Token":  newCSRFToken(secret, user.GetIdentifier(), impersonated, groups, time.Now()),
+		})
+		// Check error
+		if err != nil {
+			logger.Error(errors.WithStack(err))
+		}
+
+		return
+	}
+
+	// Check token
+	if !checkCSRFToken(secret, user.GetIdentifier(), impersonated, groups, r.PostFormValue(CSRFTokenFormField), time.Now()) {
+		audit.SetDecision(r.Context(), audit.DecisionDeny, "impersonation: invalid session switch token")
+		forbidden(w, r, cfgManager, errors.New("invalid impersonation session switch token => Forbidden access"))
+
+		return
+	}
+
+	// Create cookie
+	cookie := &http.Cookie{
+		Name:     impCfg.CookieName,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   impCfg.CookieSecure,
+		SameSite: http.SameSiteStrictMode,
+	}
+
+	// Check if impersonation must be stopped
+	if impersonated == "" {
+		cookie.MaxAge = -1
+
+		logger.Infof("user %s stops impersonation", user.GetIdentifier())
+		audit.SetDecision(r.Context(), audit.DecisionAllow, "impersonation: stop")
+	} else {
+		cookie.Value = encodeCookieValue(secret, user.GetIdentifier(), impersonated, groups)
+
+		logger.Infof("user %s starts impersonating %s", user.GetIdentifier(), impersonated)
+		audit.SetDecision(r.Context(), audit.DecisionAllow, "impersonation: start impersonating "+impersonated)
+	}
+
+	// Set cookie
+	http.SetCookie(w, cookie)
+
+	// Remove impersonation query parameters
+	qs.Del(QueryParameter)
+	qs.Del(GroupsQueryParameter)
+
+	// Create redirect url
+	rdURL := *r.URL
+	rdURL.RawQuery = qs.Encode()
+
+	http.Redirect(w, r, rdURL.RequestURI(), http.StatusSeeOther)
+}
+
+// forbidden will answer with a forbidden error using bucket templates when available.
+func forbidden(w http.ResponseWriter, r *http.Request, cfgManager config.Manager, err error) {
+	// Get bucket request context
+	brctx := bucket.GetBucketRequestContextFromContext(r.Context())
+	// Check if bucket request context doesn't exist to use local default files
+	if brctx == nil {
+		responsehandler.GeneralForbiddenError(r, w, cfgManager, err)
+
+		return
+	}
+
+	// Get response handler
+	resHan := responsehandler.GetResponseHandlerFromContext(r.Context())
+	// Answer
+	resHan.ForbiddenError(brctx.LoadFileContent, err)
+}
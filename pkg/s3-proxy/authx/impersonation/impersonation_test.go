@@ -0,0 +1,407 @@
+//go:build unit
+
+package impersonation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+)
+
+// newRequest will create a request with a logger, a response handler and an authenticated user in context.
+func newRequest(url string, user models.GenericUser) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	ctx := log.SetLoggerInContext(req.Context(), log.NewLogger())
+	ctx = models.SetAuthenticatedUserInContext(ctx, user)
+	req = req.WithContext(ctx)
+
+	ctx = responsehandler.SetResponseHandlerInContext(ctx, responsehandler.NewHandler(req, httptest.NewRecorder(), nil, ""))
+
+	return req.WithContext(ctx)
+}
+
+func TestIsAdmin(t *testing.T) {
+	cfg := &config.ImpersonationConfig{
+		Admins:      []string{"admin"},
+		AdminGroups: []string{"support"},
+	}
+
+	tests := []struct {
+		user models.GenericUser
+		name string
+		want bool
+	}{
+		{
+			name: "admin identifier",
+			user: &models.BasicAuthUser{Username: "admin"},
+			want: true,
+		},
+		{
+			name: "admin group",
+			user: &models.HeaderUser{Username: "bob", Groups: []string{"dev", "support"}},
+			want: true,
+		},
+		{
+			name: "not admin",
+			user: &models.HeaderUser{Username: "bob", Groups: []string{"dev"}},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsAdmin(cfg, tt.user))
+		})
+	}
+}
+
+func Test_parseGroups(t *testing.T) {
+	assert.Nil(t, parseGroups(""))
+	assert.Equal(t, []string{"dev", "ops"}, parseGroups(" dev, ,ops ,"))
+}
+
+// testSecret is the impersonation secret used in tests.
+const testSecret = "01234567890123456789012345678901"
+
+// testTemplates are the templates used for forbidden answers in tests.
+var testTemplates = &config.TemplateConfig{
+	Helpers: []string{"../../../../templates/_helpers.tpl"},
+	ForbiddenError: &config.TemplateConfigItem{
+		Path:    "../../../../templates/forbidden-error.tpl",
+		Headers: map[string]string{"Content-Type": "{{ template \"main.headers.contentType\" . }}"},
+		Status:  "403",
+	},
+}
+
+func Test_cookieValue(t *testing.T) {
+	user, groups := decodeCookieValue(testSecret, "admin", encodeCookieValue(testSecret, "admin", "alice", []string{"dev", "ops"}))
+	assert.Equal(t, "alice", user)
+	assert.Equal(t, []string{"dev", "ops"}, groups)
+
+	user, groups = decodeCookieValue(testSecret, "admin", encodeCookieValue(testSecret, "admin", "bob", nil))
+	assert.Equal(t, "bob", user)
+	assert.Nil(t, groups)
+
+	user, groups = decodeCookieValue(testSecret, "admin", "%zz")
+	assert.Empty(t, user)
+	assert.Nil(t, groups)
+
+	// Unsigned value
+	user, _ = decodeCookieValue(testSecret, "admin", encodeIdentity("alice", nil))
+	assert.Empty(t, user)
+
+	// Value signed for another admin
+	user, _ = decodeCookieValue(testSecret, "admin", encodeCookieValue(testSecret, "admin2", "alice", nil))
+	assert.Empty(t, user)
+
+	// Value signed with another secret
+	user, _ = decodeCookieValue(testSecret, "admin", encodeCookieValue("other-secret", "admin", "alice", nil))
+	assert.Empty(t, user)
+
+	// Tampered groups
+	value := encodeCookieValue(testSecret, "admin", "alice", []string{"dev"})
+	user, _ = decodeCookieValue(testSecret, "admin", strings.Replace(value, "dev", "ops", 1))
+	assert.Empty(t, user)
+}
+
+func Test_csrfToken(t *testing.T) {
+	now := time.Now()
+	token := newCSRFToken(testSecret, "admin", "alice", []string{"dev"}, now)
+
+	assert.True(t, checkCSRFToken(testSecret, "admin", "alice", []string{"dev"}, token, now))
+	assert.True(t, checkCSRFToken(testSecret, "admin", "alice", []string{"dev"}, token, now.Add(csrfTokenValidity)))
+	// Expired
+	assert.False(t, checkCSRFToken(testSecret, "admin", "alice", []string{"dev"}, token, now.Add(csrfTokenValidity+time.Second)))
+	// Other impersonator
+	assert.False(t, checkCSRFToken(testSecret, "admin2", "alice", []string{"dev"}, token, now))
+	// Other identity
+	assert.False(t, checkCSRFToken(testSecret, "admin", "bob", []string{"dev"}, token, now))
+	assert.False(t, checkCSRFToken(testSecret, "admin", "alice", []string{"dev", "ops"}, token, now))
+	// Invalid tokens
+	assert.False(t, checkCSRFToken(testSecret, "admin", "alice", []string{"dev"}, "", now))
+	assert.False(t, checkCSRFToken(testSecret, "admin", "alice", []string{"dev"}, "abc.def", now))
+}
+
+func Test_checkImpersonated(t *testing.T) {
+	impCfg := &config.ImpersonationConfig{
+		Admins:        []string{"admin"},
+		AllowedGroups: []string{"dev", "ops"},
+	}
+	protected := getProtectedIdentifiers(&config.Config{
+		Targets: map[string]*config.TargetConfig{
+			"target": {
+				Actions: &config.ActionsConfig{
+					GET: &config.GetActionConfig{
+						Config: &config.GetActionConfigConfig{UserIsolationAdmins: []string{"isolation-admin"}},
+					},
+				},
+			},
+			"no-actions": {},
+		},
+	})
+
+	assert.NoError(t, checkImpersonated(impCfg, protected, "alice", nil))
+	assert.NoError(t, checkImpersonated(impCfg, protected, "alice", []string{"dev", "ops"}))
+	assert.EqualError(t, checkImpersonated(impCfg, protected, "alice", []string{"dev", "admins"}), "group admins isn't allowed for impersonation")
+	assert.EqualError(t, checkImpersonated(impCfg, protected, "admin", nil), "user admin cannot be impersonated")
+	assert.EqualError(t, checkImpersonated(impCfg, protected, "isolation-admin", nil), "user isolation-admin cannot be impersonated")
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	impCfg := &config.ImpersonationConfig{
+		Enabled:       true,
+		Header:        config.DefaultImpersonationHeader,
+		GroupsHeader:  config.DefaultImpersonationGroupsHeader,
+		CookieName:    config.DefaultImpersonationCookieName,
+		CookieSecret:  &config.CredentialConfig{Value: testSecret},
+		Admins:        []string{"admin", "admin2"},
+		AllowedGroups: []string{"dev"},
+	}
+
+	tests := []struct {
+		user       models.GenericUser
+		setup      func(r *http.Request)
+		name       string
+		url        string
+		wantUser   string
+		wantType   string
+		wantStatus int
+	}{
+		{
+			name:       "no impersonation",
+			user:       &models.BasicAuthUser{Username: "admin"},
+			url:        "/file.txt",
+			wantUser:   "admin",
+			wantType:   models.BasicAuthUserType,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "impersonation with headers",
+			user: &models.BasicAuthUser{Username: "admin"},
+			url:  "/file.txt",
+			setup: func(r *http.Request) {
+				r.Header.Set("X-Impersonate-User", "alice")
+				r.Header.Set("X-Impersonate-Groups", "dev")
+			},
+			wantUser:   "alice",
+			wantType:   models.ImpersonatedUserType,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "impersonation with headers and not allowed group",
+			user: &models.BasicAuthUser{Username: "admin"},
+			url:  "/file.txt",
+			setup: func(r *http.Request) {
+				r.Header.Set("X-Impersonate-User", "alice")
+				r.Header.Set("X-Impersonate-Groups", "dev,admins")
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "impersonation of an impersonation admin",
+			user: &models.BasicAuthUser{Username: "admin"},
+			url:  "/file.txt",
+			setup: func(r *http.Request) {
+				r.Header.Set("X-Impersonate-User", "admin2")
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "impersonation with cookie",
+			user: &models.BasicAuthUser{Username: "admin"},
+			url:  "/file.txt",
+			setup: func(r *http.Request) {
+				r.AddCookie(&http.Cookie{Name: "s3-proxy-impersonate", Value: encodeCookieValue(testSecret, "admin", "alice", nil)})
+			},
+			wantUser:   "alice",
+			wantType:   models.ImpersonatedUserType,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "unsigned cookie ignored",
+			user: &models.BasicAuthUser{Username: "admin"},
+			url:  "/file.txt",
+			setup: func(r *http.Request) {
+				r.AddCookie(&http.Cookie{Name: "s3-proxy-impersonate", Value: encodeIdentity("alice", nil)})
+			},
+			wantUser:   "admin",
+			wantType:   models.BasicAuthUserType,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "cookie of another admin ignored",
+			user: &models.BasicAuthUser{Username: "admin"},
+			url:  "/file.txt",
+			setup: func(r *http.Request) {
+				r.AddCookie(&http.Cookie{Name: "s3-proxy-impersonate", Value: encodeCookieValue(testSecret, "admin2", "alice", nil)})
+			},
+			wantUser:   "admin",
+			wantType:   models.BasicAuthUserType,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "cookie ignored for non admin",
+			user: &models.BasicAuthUser{Username: "bob"},
+			url:  "/file.txt",
+			setup: func(r *http.Request) {
+				r.AddCookie(&http.Cookie{Name: "s3-proxy-impersonate", Value: encodeCookieValue(testSecret, "bob", "alice", nil)})
+			},
+			wantUser:   "bob",
+			wantType:   models.BasicAuthUserType,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "session switch confirmation page",
+			user:       &models.BasicAuthUser{Username: "admin"},
+			url:        "/folder/?impersonate=alice&impersonateGroups=dev",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "session switch with not allowed group",
+			user:       &models.BasicAuthUser{Username: "admin"},
+			url:        "/folder/?impersonate=alice&impersonateGroups=admins",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			cfgManagerMock := cmocks.NewMockManager(ctrl)
+			cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(&config.Config{Impersonation: impCfg, Templates: testTemplates})
+
+			var gotUser, gotResHanUser models.GenericUser
+
+			handler := HTTPMiddleware(cfgManagerMock)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				gotUser = models.GetAuthenticatedUserFromContext(r.Context())
+				// Get user seen by response handler for templates
+				gotResHanUser = models.GetAuthenticatedUserFromContext(
+					responsehandler.GetResponseHandlerFromContext(r.Context()).GetRequest().Context(),
+				)
+			}))
+
+			req := newRequest(tt.url, tt.user)
+			// Check if setup exists
+			if tt.setup != nil {
+				tt.setup(req)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			// Check if request must go to next handler
+			if tt.wantUser == "" {
+				assert.Nil(t, gotUser)
+
+				return
+			}
+
+			require.NotNil(t, gotUser)
+			assert.Equal(t, tt.wantUser, gotUser.GetIdentifier())
+			assert.Equal(t, tt.wantType, gotUser.GetType())
+			assert.Equal(t, gotUser, gotResHanUser)
+		})
+	}
+}
+
+// csrfTokenRegexp extracts the session switch token from the confirmation page.
+var csrfTokenRegexp = regexp.MustCompile(`name="csrfToken" value="([^"]+)"`)
+
+func TestHTTPMiddleware_SessionSwitch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(&config.Config{
+		Impersonation: &config.ImpersonationConfig{
+			Enabled:       true,
+			CookieName:    "imp",
+			CookieSecret:  &config.CredentialConfig{Value: testSecret},
+			Admins:        []string{"admin"},
+			AllowedGroups: []string{"dev", "ops"},
+		},
+		Templates: testTemplates,
+	})
+
+	handler := HTTPMiddleware(cfgManagerMock)(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		t.Fatal("next handler must not be called")
+	}))
+
+	// switchRequest will confirm a session switch with the given token.
+	switchRequest := func(url, token string) *httptest.ResponseRecorder {
+		req := newRequest(url, &models.BasicAuthUser{Username: "admin"})
+		req.Method = http.MethodPost
+		req.Body = http.NoBody
+
+		// Check if token must be sent
+		if token != "" {
+			req.Body = httptest.NewRequest(http.MethodPost, url, strings.NewReader(CSRFTokenFormField+"="+token)).Body
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// Confirmation page
+	req := newRequest("/folder/?a=b&impersonate=alice&impersonateGroups=dev,ops", &models.BasicAuthUser{Username: "admin"})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Contains(t, w.Body.String(), `action="/folder/?a=b&amp;impersonate=alice&amp;impersonateGroups=dev,ops"`)
+
+	matches := csrfTokenRegexp.FindStringSubmatch(w.Body.String())
+	require.Len(t, matches, 2)
+
+	token := matches[1]
+
+	// Start without token (cross site request)
+	w = switchRequest("/folder/?a=b&impersonate=alice&impersonateGroups=dev,ops", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+
+	// Start with a token issued for other groups
+	w = switchRequest("/folder/?a=b&impersonate=alice&impersonateGroups=dev", url.QueryEscape(token))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+
+	// Start
+	w = switchRequest("/folder/?a=b&impersonate=alice&impersonateGroups=dev,ops", url.QueryEscape(token))
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/folder/?a=b", w.Header().Get("Location"))
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "imp", cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+
+	user, groups := decodeCookieValue(testSecret, "admin", cookies[0].Value)
+	assert.Equal(t, "alice", user)
+	assert.Equal(t, []string{"dev", "ops"}, groups)
+
+	// Stop
+	w = switchRequest("/folder/?impersonate=", url.QueryEscape(newCSRFToken(testSecret, "admin", "", nil, time.Now())))
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/folder/", w.Header().Get("Location"))
+
+	cookies = w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, -1, cookies[0].MaxAge)
+}
@@ -0,0 +1,50 @@
+package lockout
+
+import (
+	"time"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+// Lockout scopes.
+const (
+	ScopeUser = "user"
+	ScopeIP   = "ip"
+)
+
+// Lockout describes failed attempts tracked for a username or a client ip.
+type Lockout struct {
+	LockedUntil time.Time `json:"lockedUntil"`
+	Provider    string    `json:"provider"`
+	Scope       string    `json:"scope"`
+	Value       string    `json:"value"`
+	Failures    int       `json:"failures"`
+	Lockouts    int       `json:"lockouts"`
+}
+
+// Manager will track failed basic authentication attempts per username and per client ip.
+// State is kept in memory and survives configuration reloads.
+//
+//go:generate mockgen -destination=./mocks/mock_Manager.go -package=mocks github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/lockout Manager
+type Manager interface {
+	// Check will return the active lockout blocking the username or the client ip, nil otherwise.
+	Check(providerKey, username, clientIP string) *Lockout
+	// RegisterFailure will count a failed attempt for the username and the client ip.
+	// Lockouts started by this failure are returned.
+	RegisterFailure(providerKey string, bfCfg *config.BruteForceProtectionConfig, username, clientIP string) []*Lockout
+	// RegisterSuccess will forget failed attempts of the username.
+	RegisterSuccess(providerKey, username string)
+	// List will return all active lockouts.
+	List() []*Lockout
+	// Clear will remove tracked attempts and lockouts matching filters and return the number of removed entries.
+	// Empty filters match everything.
+	Clear(providerKey, scope, value string) int
+}
+
+// NewManager will return a new lockout manager.
+func NewManager() Manager {
+	return &manager{
+		entries: map[entryKey]*entry{},
+		now:     time.Now,
+	}
+}
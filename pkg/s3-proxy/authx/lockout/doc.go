@@ -0,0 +1,3 @@
+package lockout
+
+// This package will track failed authentication attempts and lock out users and client ips
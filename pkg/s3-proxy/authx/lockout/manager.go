@@ -0,0 +1,216 @@
+package lockout
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+// maxBackoffShift will limit the lockout duration exponent to avoid overflows.
+const maxBackoffShift = 30
+
+type entryKey struct {
+	provider string
+	scope    string
+	value    string
+}
+
+type entry struct {
+	lastFailure time.Time
+	lockedUntil time.Time
+	failures    int
+	lockouts    int
+}
+
+type manager struct {
+	entries map[entryKey]*entry
+	now     func() time.Time
+	mutex   sync.Mutex
+}
+
+func (m *manager) Check(providerKey, username, clientIP string) *Lockout {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Get now
+	now := m.now()
+
+	for _, k := range []entryKey{
+		{provider: providerKey, scope: ScopeUser, value: username},
+		{provider: providerKey, scope: ScopeIP, value: clientIP},
+	} {
+		// Get entry
+		e := m.entries[k]
+		// Check if entry is locked
+		if e != nil && now.Before(e.lockedUntil) {
+			return toLockout(k, e)
+		}
+	}
+
+	return nil
+}
+
+func (m *manager) RegisterFailure(providerKey string, bfCfg *config.BruteForceProtectionConfig, username, clientIP string) []*Lockout {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Get now
+	now := m.now()
+	// Clean expired entries
+	m.cleanExpired(now, bfCfg.AttemptsWindow)
+
+	// Result
+	res := make([]*Lockout, 0)
+
+	for _, it := range []struct {
+		key         entryKey
+		maxAttempts int
+	}{
+		{key: entryKey{provider: providerKey, scope: ScopeUser, value: username}, maxAttempts: *bfCfg.MaxAttemptsPerUser},
+		{key: entryKey{provider: providerKey, scope: ScopeIP, value: clientIP}, maxAttempts: *bfCfg.MaxAttemptsPerIP},
+	} {
+		// Check if scope is disabled
+		if it.maxAttempts == 0 {
+			continue
+		}
+
+		// Get entry
+		e := m.entries[it.key]
+		// Check if entry exists
+		if e == nil {
+			e = &entry{}
+			m.entries[it.key] = e
+		}
+
+		// Ignore failures during lockout
+		if now.Before(e.lockedUntil) {
+			continue
+		}
+
+		// Forget failures out of the attempts window
+		if now.Sub(e.lastFailure) > bfCfg.AttemptsWindow {
+			e.failures = 0
+		}
+
+		// Count failure
+		e.failures++
+		e.lastFailure = now
+
+		// Check if lockout must start
+		if e.failures >= it.maxAttempts {
+			e.lockouts++
+			e.failures = 0
+			e.lockedUntil = now.Add(lockoutDuration(bfCfg, e.lockouts))
+
+			res = append(res, toLockout(it.key, e))
+		}
+	}
+
+	return res
+}
+
+func (m *manager) RegisterSuccess(providerKey, username string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.entries, entryKey{provider: providerKey, scope: ScopeUser, value: username})
+}
+
+func (m *manager) List() []*Lockout {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Get now
+	now := m.now()
+	// Result
+	res := make([]*Lockout, 0)
+
+	for k, e := range m.entries {
+		// Check if entry is locked
+		if now.Before(e.lockedUntil) {
+			res = append(res, toLockout(k, e))
+		}
+	}
+
+	// Sort to have a stable output
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Provider != res[j].Provider {
+			return res[i].Provider < res[j].Provider
+		}
+
+		if res[i].Scope != res[j].Scope {
+			return res[i].Scope < res[j].Scope
+		}
+
+		return res[i].Value < res[j].Value
+	})
+
+	return res
+}
+
+func (m *manager) Clear(providerKey, scope, value string) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Counter
+	cleared := 0
+
+	for k := range m.entries {
+		// Check filters
+		if (providerKey != "" && k.provider != providerKey) ||
+			(scope != "" && k.scope != scope) ||
+			(value != "" && k.value != value) {
+			continue
+		}
+
+		delete(m.entries, k)
+
+		cleared++
+	}
+
+	return cleared
+}
+
+// cleanExpired will remove entries without failures nor lockout in the attempts window.
+// Lockout count is kept during this window in order to increase next lockout duration.
+func (m *manager) cleanExpired(now time.Time, window time.Duration) {
+	for k, e := range m.entries {
+		// Get last activity
+		last := e.lastFailure
+		if e.lockedUntil.After(last) {
+			last = e.lockedUntil
+		}
+
+		// Check if entry has expired
+		if now.Sub(last) > window {
+			delete(m.entries, k)
+		}
+	}
+}
+
+// lockoutDuration will compute an exponential lockout duration from the number of lockouts.
+func lockoutDuration(bfCfg *config.BruteForceProtectionConfig, lockouts int) time.Duration {
+	// Compute exponent
+	shift := min(lockouts-1, maxBackoffShift)
+	// Compute duration
+	dur := bfCfg.LockoutDuration << shift
+	// Check max duration and overflow
+	if dur > bfCfg.MaxLockoutDuration || dur < bfCfg.LockoutDuration {
+		return bfCfg.MaxLockoutDuration
+	}
+
+	return dur
+}
+
+func toLockout(k entryKey, e *entry) *Lockout {
+	return &Lockout{
+		LockedUntil: e.lockedUntil,
+		Provider:    k.provider,
+		Scope:       k.scope,
+		Value:       k.value,
+		Failures:    e.failures,
+		Lockouts:    e.lockouts,
+	}
+}
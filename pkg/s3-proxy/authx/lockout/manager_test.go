@@ -0,0 +1,174 @@
+//go:build unit
+
+package lockout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+func newTestManager(now *time.Time) *manager {
+	m, _ := NewManager().(*manager)
+	m.now = func() time.Time { return *now }
+
+	return m
+}
+
+func newTestConfig(maxPerUser, maxPerIP int) *config.BruteForceProtectionConfig {
+	return &config.BruteForceProtectionConfig{
+		Enabled:            true,
+		MaxAttemptsPerUser: &maxPerUser,
+		MaxAttemptsPerIP:   &maxPerIP,
+		AttemptsWindow:     10 * time.Minute,
+		LockoutDuration:    time.Minute,
+		MaxLockoutDuration: 3 * time.Minute,
+	}
+}
+
+func Test_manager_UserLockout(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := newTestManager(&now)
+	bfCfg := newTestConfig(3, 0)
+
+	// Failures before threshold
+	assert.Empty(t, m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1"))
+	assert.Empty(t, m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.2"))
+	assert.Nil(t, m.Check("provider1", "alice", "10.0.0.1"))
+
+	// Failure starting lockout
+	lcks := m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.3")
+	require.Len(t, lcks, 1)
+	assert.Equal(t, &Lockout{
+		LockedUntil: now.Add(time.Minute),
+		Provider:    "provider1",
+		Scope:       ScopeUser,
+		Value:       "alice",
+		Lockouts:    1,
+	}, lcks[0])
+
+	// User is locked from all ips
+	lck := m.Check("provider1", "alice", "10.0.0.4")
+	require.NotNil(t, lck)
+	assert.Equal(t, ScopeUser, lck.Scope)
+	// Other users and providers aren't locked
+	assert.Nil(t, m.Check("provider1", "bob", "10.0.0.4"))
+	assert.Nil(t, m.Check("provider2", "alice", "10.0.0.4"))
+
+	// Failures during lockout don't extend it
+	assert.Empty(t, m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1"))
+	assert.Empty(t, m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1"))
+	assert.Empty(t, m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1"))
+
+	// End of lockout
+	now = now.Add(time.Minute)
+	assert.Nil(t, m.Check("provider1", "alice", "10.0.0.1"))
+
+	// Next lockouts have an exponential duration limited by max lockout duration
+	for _, want := range []time.Duration{2 * time.Minute, 3 * time.Minute} {
+		m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1")
+		m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1")
+		lcks = m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1")
+		require.Len(t, lcks, 1)
+		assert.Equal(t, now.Add(want), lcks[0].LockedUntil)
+
+		now = now.Add(want)
+	}
+
+	// Success resets user
+	m.RegisterSuccess("provider1", "alice")
+	m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1")
+	m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1")
+	lcks = m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1")
+	require.Len(t, lcks, 1)
+	assert.Equal(t, now.Add(time.Minute), lcks[0].LockedUntil)
+}
+
+func Test_manager_IPLockout(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := newTestManager(&now)
+	bfCfg := newTestConfig(0, 2)
+
+	assert.Empty(t, m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1"))
+	lcks := m.RegisterFailure("provider1", bfCfg, "bob", "10.0.0.1")
+	require.Len(t, lcks, 1)
+	assert.Equal(t, ScopeIP, lcks[0].Scope)
+	assert.Equal(t, "10.0.0.1", lcks[0].Value)
+
+	// All users are locked from this ip
+	lck := m.Check("provider1", "charlie", "10.0.0.1")
+	require.NotNil(t, lck)
+	assert.Equal(t, ScopeIP, lck.Scope)
+	assert.Nil(t, m.Check("provider1", "charlie", "10.0.0.2"))
+
+	// Success doesn't reset ip lockout
+	m.RegisterSuccess("provider1", "alice")
+	assert.NotNil(t, m.Check("provider1", "alice", "10.0.0.1"))
+}
+
+func Test_manager_AttemptsWindow(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := newTestManager(&now)
+	bfCfg := newTestConfig(2, 0)
+
+	assert.Empty(t, m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1"))
+
+	// Previous failure is out of the window
+	now = now.Add(11 * time.Minute)
+
+	assert.Empty(t, m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1"))
+	assert.Len(t, m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1"), 1)
+
+	// Lockout count is forgotten after a quiet window
+	now = now.Add(time.Minute + 11*time.Minute)
+
+	m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1")
+	lcks := m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1")
+	require.Len(t, lcks, 1)
+	assert.Equal(t, 1, lcks[0].Lockouts)
+	assert.Equal(t, now.Add(time.Minute), lcks[0].LockedUntil)
+}
+
+func Test_manager_ListAndClear(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := newTestManager(&now)
+	bfCfg := newTestConfig(1, 1)
+
+	m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1")
+	m.RegisterFailure("provider2", bfCfg, "bob", "10.0.0.2")
+
+	lcks := m.List()
+	require.Len(t, lcks, 4)
+	assert.Equal(t, []string{"10.0.0.1", "alice", "10.0.0.2", "bob"}, []string{lcks[0].Value, lcks[1].Value, lcks[2].Value, lcks[3].Value})
+
+	// Clear with filters
+	assert.Equal(t, 1, m.Clear("provider1", ScopeUser, ""))
+	assert.Nil(t, m.Check("provider1", "alice", "10.0.0.3"))
+	assert.NotNil(t, m.Check("provider1", "charlie", "10.0.0.1"))
+	assert.Equal(t, 0, m.Clear("provider1", ScopeUser, ""))
+
+	// Clear all
+	assert.Equal(t, 3, m.Clear("", "", ""))
+	assert.Empty(t, m.List())
+
+	// Expired lockouts aren't listed
+	m.RegisterFailure("provider1", bfCfg, "alice", "10.0.0.1")
+
+	now = now.Add(time.Minute)
+
+	assert.Empty(t, m.List())
+}
+
+func Test_lockoutDuration(t *testing.T) {
+	bfCfg := &config.BruteForceProtectionConfig{LockoutDuration: time.Minute, MaxLockoutDuration: time.Hour}
+
+	assert.Equal(t, time.Minute, lockoutDuration(bfCfg, 1))
+	assert.Equal(t, 2*time.Minute, lockoutDuration(bfCfg, 2))
+	assert.Equal(t, 32*time.Minute, lockoutDuration(bfCfg, 6))
+	assert.Equal(t, time.Hour, lockoutDuration(bfCfg, 7))
+	assert.Equal(t, time.Hour, lockoutDuration(bfCfg, 1000))
+}
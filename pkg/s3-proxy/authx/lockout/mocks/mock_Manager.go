@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/lockout (interfaces: Manager)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_Manager.go -package=mocks github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/lockout Manager
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	lockout "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/lockout"
+	config "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockManager is a mock of Manager interface.
+type MockManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockManagerMockRecorder
+	isgomock struct{}
+}
+
+// MockManagerMockRecorder is the mock recorder for MockManager.
+type MockManagerMockRecorder struct {
+	mock *MockManager
+}
+
+// NewMockManager creates a new mock instance.
+func NewMockManager(ctrl *gomock.Controller) *MockManager {
+	mock := &MockManager{ctrl: ctrl}
+	mock.recorder = &MockManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockManager) EXPECT() *MockManagerMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockManager) Check(providerKey, username, clientIP string) *lockout.Lockout {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", providerKey, username, clientIP)
+	ret0, _ := ret[0].(*lockout.Lockout)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockManagerMockRecorder) Check(providerKey, username, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockManager)(nil).Check), providerKey, username, clientIP)
+}
+
+// Clear mocks base method.
+func (m *MockManager) Clear(providerKey, scope, value string) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clear", providerKey, scope, value)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Clear indicates an expected call of Clear.
+func (mr *MockManagerMockRecorder) Clear(providerKey, scope, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clear", reflect.TypeOf((*MockManager)(nil).Clear), providerKey, scope, value)
+}
+
+// List mocks base method.
+func (m *MockManager) List() []*lockout.Lockout {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]*lockout.Lockout)
+	return ret0
+}
+
+// List indicates an expected call of List.
+func (mr *MockManagerMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockManager)(nil).List))
+}
+
+// RegisterFailure mocks base method.
+func (m *MockManager) RegisterFailure(providerKey string, bfCfg *config.BruteForceProtectionConfig, username, clientIP string) []*lockout.Lockout {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterFailure", providerKey, bfCfg, username, clientIP)
+	ret0, _ := ret[0].([]*lockout.Lockout)
+	return ret0
+}
+
+// RegisterFailure indicates an expected call of RegisterFailure.
+func (mr *MockManagerMockRecorder) RegisterFailure(providerKey, bfCfg, username, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterFailure", reflect.TypeOf((*MockManager)(nil).RegisterFailure), providerKey, bfCfg, username, clientIP)
+}
+
+// RegisterSuccess mocks base method.
+func (m *MockManager) RegisterSuccess(providerKey, username string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RegisterSuccess", providerKey, username)
+}
+
+// RegisterSuccess indicates an expected call of RegisterSuccess.
+func (mr *MockManagerMockRecorder) RegisterSuccess(providerKey, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterSuccess", reflect.TypeOf((*MockManager)(nil).RegisterSuccess), providerKey, username)
+}
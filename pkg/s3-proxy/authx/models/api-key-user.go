@@ -0,0 +1,57 @@
+package models
+
+const APIKeyUserType = "API_KEY"
+
+type APIKeyUser struct {
+	// KeyID is the identifier of the API key used.
+	KeyID string `json:"keyId"`
+	// Owner is the API key owner identity.
+	Owner string `json:"owner"`
+	// Email is the API key owner email.
+	Email string `json:"email"`
+	// Groups are the API key owner groups.
+	Groups []string `json:"groups"`
+}
+
+func (*APIKeyUser) GetType() string {
+	return APIKeyUserType
+}
+
+func (u *APIKeyUser) GetIdentifier() string {
+	return u.Owner
+}
+
+// Get username.
+func (u *APIKeyUser) GetUsername() string {
+	return u.Owner
+}
+
+// Get name (only available for OIDC and JWT user).
+func (*APIKeyUser) GetName() string {
+	return ""
+}
+
+// Get groups.
+func (u *APIKeyUser) GetGroups() []string {
+	return u.Groups
+}
+
+// Get given name (only available for OIDC and JWT user).
+func (*APIKeyUser) GetGivenName() string {
+	return ""
+}
+
+// Get family name (only available for OIDC and JWT user).
+func (*APIKeyUser) GetFamilyName() string {
+	return ""
+}
+
+// Get email.
+func (u *APIKeyUser) GetEmail() string {
+	return u.Email
+}
+
+// Is Email Verified ? (only available for OIDC and JWT user).
+func (*APIKeyUser) IsEmailVerified() bool {
+	return false
+}
@@ -0,0 +1,37 @@
+//go:build unit
+
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAPIKeyUser(t *testing.T) {
+	u := &APIKeyUser{
+		KeyID:  "key1",
+		Owner:  "ci-pipeline",
+		Email:  "ci@example.com",
+		Groups: []string{"ci"},
+	}
+
+	if got := u.GetType(); got != APIKeyUserType {
+		t.Errorf("APIKeyUser.GetType() = %v, want %v", got, APIKeyUserType)
+	}
+
+	if got := u.GetIdentifier(); got != "ci-pipeline" {
+		t.Errorf("APIKeyUser.GetIdentifier() = %v, want %v", got, "ci-pipeline")
+	}
+
+	if got := u.GetUsername(); got != "ci-pipeline" {
+		t.Errorf("APIKeyUser.GetUsername() = %v, want %v", got, "ci-pipeline")
+	}
+
+	if got := u.GetEmail(); got != "ci@example.com" {
+		t.Errorf("APIKeyUser.GetEmail() = %v, want %v", got, "ci@example.com")
+	}
+
+	if got := u.GetGroups(); !reflect.DeepEqual(got, []string{"ci"}) {
+		t.Errorf("APIKeyUser.GetGroups() = %v, want %v", got, []string{"ci"})
+	}
+}
@@ -2,7 +2,7 @@ package models
 
 // Generic user interface used to know specific type of user.
 type GenericUser interface {
-	// Get type of user (OIDC, HEADER or BASIC).
+	// Get type of user (OIDC, HEADER, JWT, API_KEY, MTLS, LDAP, HMAC, BASIC or IMPERSONATED).
 	GetType() string
 	// Get identifier (Username for basic auth user or Username or email for OIDC and HEADER user).
 	GetIdentifier() string
@@ -0,0 +1,57 @@
+package models
+
+const HMACUserType = "HMAC"
+
+type HMACUser struct {
+	// KeyID is the identifier of the HMAC key used to sign request.
+	KeyID string `json:"keyId"`
+	// Owner is the HMAC key owner identity.
+	Owner string `json:"owner"`
+	// Email is the HMAC key owner email.
+	Email string `json:"email"`
+	// Groups are the HMAC key owner groups.
+	Groups []string `json:"groups"`
+}
+
+func (*HMACUser) GetType() string {
+	return HMACUserType
+}
+
+func (u *HMACUser) GetIdentifier() string {
+	return u.Owner
+}
+
+// Get username.
+func (u *HMACUser) GetUsername() string {
+	return u.Owner
+}
+
+// Get name (only available for OIDC and JWT user).
+func (*HMACUser) GetName() string {
+	return ""
+}
+
+// Get groups.
+func (u *HMACUser) GetGroups() []string {
+	return u.Groups
+}
+
+// Get given name (only available for OIDC and JWT user).
+func (*HMACUser) GetGivenName() string {
+	return ""
+}
+
+// Get family name (only available for OIDC and JWT user).
+func (*HMACUser) GetFamilyName() string {
+	return ""
+}
+
+// Get email.
+func (u *HMACUser) GetEmail() string {
+	return u.Email
+}
+
+// Is Email Verified ? (only available for OIDC and JWT user).
+func (*HMACUser) IsEmailVerified() bool {
+	return false
+}
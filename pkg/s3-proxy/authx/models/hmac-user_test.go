@@ -0,0 +1,37 @@
+//go:build unit
+
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHMACUser(t *testing.T) {
+	u := &HMACUser{
+		KeyID:  "key1",
+		Owner:  "backup-job",
+		Email:  "backup@example.com",
+		Groups: []string{"ci"},
+	}
+
+	if got := u.GetType(); got != HMACUserType {
+		t.Errorf("HMACUser.GetType() = %v, want %v", got, HMACUserType)
+	}
+
+	if got := u.GetIdentifier(); got != "backup-job" {
+		t.Errorf("HMACUser.GetIdentifier() = %v, want %v", got, "backup-job")
+	}
+
+	if got := u.GetUsername(); got != "backup-job" {
+		t.Errorf("HMACUser.GetUsername() = %v, want %v", got, "backup-job")
+	}
+
+	if got := u.GetEmail(); got != "backup@example.com" {
+		t.Errorf("HMACUser.GetEmail() = %v, want %v", got, "backup@example.com")
+	}
+
+	if got := u.GetGroups(); !reflect.DeepEqual(got, []string{"ci"}) {
+		t.Errorf("HMACUser.GetGroups() = %v, want %v", got, []string{"ci"})
+	}
+}
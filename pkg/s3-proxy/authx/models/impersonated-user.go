@@ -0,0 +1,57 @@
+package models
+
+const ImpersonatedUserType = "IMPERSONATED"
+
+// ImpersonatedUser is the identity used when an admin impersonates another user.
+// Only the identifier and the groups are known, they are given by the admin.
+type ImpersonatedUser struct {
+	// Impersonator is the authenticated admin.
+	Impersonator GenericUser `json:"impersonator"`
+	// Username is the impersonated user identifier.
+	Username string `json:"username"`
+	// Groups are the impersonated user groups.
+	Groups []string `json:"groups"`
+}
+
+func (*ImpersonatedUser) GetType() string {
+	return ImpersonatedUserType
+}
+
+func (u *ImpersonatedUser) GetIdentifier() string {
+	return u.Username
+}
+
+// Get username.
+func (u *ImpersonatedUser) GetUsername() string {
+	return u.Username
+}
+
+// Get name (only available for OIDC and JWT user).
+func (*ImpersonatedUser) GetName() string {
+	return ""
+}
+
+// Get groups.
+func (u *ImpersonatedUser) GetGroups() []string {
+	return u.Groups
+}
+
+// Get given name (only available for OIDC and JWT user).
+func (*ImpersonatedUser) GetGivenName() string {
+	return ""
+}
+
+// Get family name (only available for OIDC and JWT user).
+func (*ImpersonatedUser) GetFamilyName() string {
+	return ""
+}
+
+// Get email (not known for impersonated user).
+func (*ImpersonatedUser) GetEmail() string {
+	return ""
+}
+
+// Is Email Verified ? (only available for OIDC and JWT user).
+func (*ImpersonatedUser) IsEmailVerified() bool {
+	return false
+}
@@ -0,0 +1,36 @@
+//go:build unit
+
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImpersonatedUser(t *testing.T) {
+	u := &ImpersonatedUser{
+		Impersonator: &BasicAuthUser{Username: "admin"},
+		Username:     "alice",
+		Groups:       []string{"dev"},
+	}
+
+	if got := u.GetType(); got != ImpersonatedUserType {
+		t.Errorf("ImpersonatedUser.GetType() = %v, want %v", got, ImpersonatedUserType)
+	}
+
+	if got := u.GetIdentifier(); got != "alice" {
+		t.Errorf("ImpersonatedUser.GetIdentifier() = %v, want %v", got, "alice")
+	}
+
+	if got := u.GetUsername(); got != "alice" {
+		t.Errorf("ImpersonatedUser.GetUsername() = %v, want %v", got, "alice")
+	}
+
+	if got := u.GetEmail(); got != "" {
+		t.Errorf("ImpersonatedUser.GetEmail() = %v, want empty", got)
+	}
+
+	if got := u.GetGroups(); !reflect.DeepEqual(got, []string{"dev"}) {
+		t.Errorf("ImpersonatedUser.GetGroups() = %v, want %v", got, []string{"dev"})
+	}
+}
@@ -0,0 +1,81 @@
+package models
+
+const JWTUserType = "JWT"
+
+type JWTUser struct {
+	// Claims contains all token claims.
+	Claims map[string]any `json:"claims"`
+	// Subject is the "sub" claim.
+	Subject string `json:"subject"`
+	// Username mapped from configured username claim.
+	Username string `json:"username"`
+	// Email mapped from configured email claim.
+	Email string `json:"email"`
+	// Name mapped from configured name claim.
+	Name string `json:"name"`
+	// Groups mapped from configured groups claim.
+	Groups []string `json:"groups"`
+	// EmailVerified is the "email_verified" claim.
+	EmailVerified bool `json:"emailVerified"`
+}
+
+func (*JWTUser) GetType() string {
+	return JWTUserType
+}
+
+func (u *JWTUser) GetIdentifier() string {
+	if u.Username != "" {
+		return u.Username
+	}
+
+	if u.Email != "" {
+		return u.Email
+	}
+
+	return u.Subject
+}
+
+// Get username.
+func (u *JWTUser) GetUsername() string {
+	return u.Username
+}
+
+// Get name.
+func (u *JWTUser) GetName() string {
+	return u.Name
+}
+
+// Get groups.
+func (u *JWTUser) GetGroups() []string {
+	return u.Groups
+}
+
+// Get given name (only available when "given_name" claim is present).
+func (u *JWTUser) GetGivenName() string {
+	return claimString(u.Claims, "given_name")
+}
+
+// Get family name (only available when "family_name" claim is present).
+func (u *JWTUser) GetFamilyName() string {
+	return claimString(u.Claims, "family_name")
+}
+
+// Get email.
+func (u *JWTUser) GetEmail() string {
+	return u.Email
+}
+
+// Is Email Verified ?
+func (u *JWTUser) IsEmailVerified() bool {
+	return u.EmailVerified
+}
+
+func claimString(claims map[string]any, key string) string {
+	// Get value
+	v, ok := claims[key].(string)
+	if !ok {
+		return ""
+	}
+
+	return v
+}
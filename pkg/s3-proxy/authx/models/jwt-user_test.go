@@ -0,0 +1,85 @@
+//go:build unit
+
+package models
+
+import (
+	"testing"
+)
+
+func TestJWTUser_GetType(t *testing.T) {
+	u := &JWTUser{}
+	if got := u.GetType(); got != JWTUserType {
+		t.Errorf("JWTUser.GetType() = %v, want %v", got, JWTUserType)
+	}
+}
+
+func TestJWTUser_GetIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		user *JWTUser
+		want string
+	}{
+		{
+			name: "all empty",
+			user: &JWTUser{},
+			want: "",
+		},
+		{
+			name: "subject only",
+			user: &JWTUser{Subject: "sub"},
+			want: "sub",
+		},
+		{
+			name: "email and subject",
+			user: &JWTUser{Subject: "sub", Email: "user@example.com"},
+			want: "user@example.com",
+		},
+		{
+			name: "username, email and subject",
+			user: &JWTUser{Subject: "sub", Email: "user@example.com", Username: "user"},
+			want: "user",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.user.GetIdentifier(); got != tt.want {
+				t.Errorf("JWTUser.GetIdentifier() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWTUser_GetGivenAndFamilyName(t *testing.T) {
+	tests := []struct {
+		name           string
+		claims         map[string]any
+		wantGivenName  string
+		wantFamilyName string
+	}{
+		{
+			name: "no claims",
+		},
+		{
+			name:           "valid claims",
+			claims:         map[string]any{"given_name": "John", "family_name": "Doe"},
+			wantGivenName:  "John",
+			wantFamilyName: "Doe",
+		},
+		{
+			name:   "invalid claim types",
+			claims: map[string]any{"given_name": 1, "family_name": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &JWTUser{Claims: tt.claims}
+			if got := u.GetGivenName(); got != tt.wantGivenName {
+				t.Errorf("JWTUser.GetGivenName() = %v, want %v", got, tt.wantGivenName)
+			}
+
+			if got := u.GetFamilyName(); got != tt.wantFamilyName {
+				t.Errorf("JWTUser.GetFamilyName() = %v, want %v", got, tt.wantFamilyName)
+			}
+		})
+	}
+}
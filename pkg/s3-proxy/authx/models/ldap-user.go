@@ -0,0 +1,57 @@
+package models
+
+const LDAPUserType = "LDAP"
+
+type LDAPUser struct {
+	// Username is the login used to authenticate.
+	Username string `json:"username"`
+	// DN is the user distinguished name in directory.
+	DN string `json:"dn"`
+	// Email is the user email found in directory.
+	Email string `json:"email"`
+	// Groups are the user groups found in directory.
+	Groups []string `json:"groups"`
+}
+
+func (*LDAPUser) GetType() string {
+	return LDAPUserType
+}
+
+func (u *LDAPUser) GetIdentifier() string {
+	return u.Username
+}
+
+// Get username.
+func (u *LDAPUser) GetUsername() string {
+	return u.Username
+}
+
+// Get name (only available for OIDC and JWT user).
+func (*LDAPUser) GetName() string {
+	return ""
+}
+
+// Get groups.
+func (u *LDAPUser) GetGroups() []string {
+	return u.Groups
+}
+
+// Get given name (only available for OIDC and JWT user).
+func (*LDAPUser) GetGivenName() string {
+	return ""
+}
+
+// Get family name (only available for OIDC and JWT user).
+func (*LDAPUser) GetFamilyName() string {
+	return ""
+}
+
+// Get email.
+func (u *LDAPUser) GetEmail() string {
+	return u.Email
+}
+
+// Is Email Verified ? (only available for OIDC and JWT user).
+func (*LDAPUser) IsEmailVerified() bool {
+	return false
+}
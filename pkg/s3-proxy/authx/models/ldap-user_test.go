@@ -0,0 +1,37 @@
+//go:build unit
+
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLDAPUser(t *testing.T) {
+	u := &LDAPUser{
+		Username: "jdoe",
+		DN:       "CN=John Doe,OU=Users,DC=example,DC=com",
+		Email:    "jdoe@example.com",
+		Groups:   []string{"developers"},
+	}
+
+	if got := u.GetType(); got != LDAPUserType {
+		t.Errorf("LDAPUser.GetType() = %v, want %v", got, LDAPUserType)
+	}
+
+	if got := u.GetIdentifier(); got != "jdoe" {
+		t.Errorf("LDAPUser.GetIdentifier() = %v, want %v", got, "jdoe")
+	}
+
+	if got := u.GetUsername(); got != "jdoe" {
+		t.Errorf("LDAPUser.GetUsername() = %v, want %v", got, "jdoe")
+	}
+
+	if got := u.GetEmail(); got != "jdoe@example.com" {
+		t.Errorf("LDAPUser.GetEmail() = %v, want %v", got, "jdoe@example.com")
+	}
+
+	if got := u.GetGroups(); !reflect.DeepEqual(got, []string{"developers"}) {
+		t.Errorf("LDAPUser.GetGroups() = %v, want %v", got, []string{"developers"})
+	}
+}
@@ -0,0 +1,73 @@
+package models
+
+const MTLSUserType = "MTLS"
+
+type MTLSUser struct {
+	// Subject is the certificate subject distinguished name.
+	Subject string `json:"subject"`
+	// Issuer is the certificate issuer distinguished name.
+	Issuer string `json:"issuer"`
+	// SerialNumber is the certificate serial number.
+	SerialNumber string `json:"serialNumber"`
+	// CommonName is the certificate subject common name.
+	CommonName string `json:"commonName"`
+	// Username mapped from configured username field.
+	Username string `json:"username"`
+	// Email is the first certificate email.
+	Email string `json:"email"`
+	// Emails are the certificate SAN emails.
+	Emails []string `json:"emails"`
+	// DNSNames are the certificate SAN DNS names.
+	DNSNames []string `json:"dnsNames"`
+	// URIs are the certificate SAN URIs.
+	URIs []string `json:"uris"`
+	// Groups mapped from configured groups fields.
+	Groups []string `json:"groups"`
+}
+
+func (*MTLSUser) GetType() string {
+	return MTLSUserType
+}
+
+func (u *MTLSUser) GetIdentifier() string {
+	if u.Username != "" {
+		return u.Username
+	}
+
+	return u.Email
+}
+
+// Get username.
+func (u *MTLSUser) GetUsername() string {
+	return u.Username
+}
+
+// Get name (only available for OIDC and JWT user).
+func (*MTLSUser) GetName() string {
+	return ""
+}
+
+// Get groups.
+func (u *MTLSUser) GetGroups() []string {
+	return u.Groups
+}
+
+// Get given name (only available for OIDC and JWT user).
+func (*MTLSUser) GetGivenName() string {
+	return ""
+}
+
+// Get family name (only available for OIDC and JWT user).
+func (*MTLSUser) GetFamilyName() string {
+	return ""
+}
+
+// Get email.
+func (u *MTLSUser) GetEmail() string {
+	return u.Email
+}
+
+// Is Email Verified ? (only available for OIDC and JWT user).
+func (*MTLSUser) IsEmailVerified() bool {
+	return false
+}
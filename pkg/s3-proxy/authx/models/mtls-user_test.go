@@ -0,0 +1,40 @@
+//go:build unit
+
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMTLSUser(t *testing.T) {
+	u := &MTLSUser{
+		CommonName: "service-a",
+		Username:   "service-a",
+		Email:      "service-a@example.com",
+		Groups:     []string{"platform"},
+	}
+
+	if got := u.GetType(); got != MTLSUserType {
+		t.Errorf("MTLSUser.GetType() = %v, want %v", got, MTLSUserType)
+	}
+
+	if got := u.GetIdentifier(); got != "service-a" {
+		t.Errorf("MTLSUser.GetIdentifier() = %v, want %v", got, "service-a")
+	}
+
+	if got := u.GetEmail(); got != "service-a@example.com" {
+		t.Errorf("MTLSUser.GetEmail() = %v, want %v", got, "service-a@example.com")
+	}
+
+	if got := u.GetGroups(); !reflect.DeepEqual(got, []string{"platform"}) {
+		t.Errorf("MTLSUser.GetGroups() = %v, want %v", got, []string{"platform"})
+	}
+
+	// Email is used when username is empty
+	u.Username = ""
+
+	if got := u.GetIdentifier(); got != "service-a@example.com" {
+		t.Errorf("MTLSUser.GetIdentifier() = %v, want %v", got, "service-a@example.com")
+	}
+}
@@ -0,0 +1,39 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+// Store will save session data by session identifier.
+type Store interface {
+	// Get will return session data or nil when session doesn't exist or has expired.
+	Get(ctx context.Context, id string) ([]byte, error)
+	// Set will save session data for the given time to live.
+	Set(ctx context.Context, id string, data []byte, ttl time.Duration) error
+	// Delete will remove session.
+	Delete(ctx context.Context, id string) error
+	// Close will release store resources.
+	Close() error
+}
+
+// Manager will manage session stores per OIDC provider.
+//
+//go:generate mockgen -destination=./mocks/mock_Manager.go -package=mocks github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/session Manager
+type Manager interface {
+	// Load will create session stores for all OIDC providers with session enabled.
+	// Stores with an unchanged configuration are kept to avoid losing sessions on reload.
+	Load() error
+	// GetStore will return the session store of an OIDC provider or nil if sessions aren't enabled.
+	GetStore(providerKey string) Store
+}
+
+// NewManager will return a new session manager.
+func NewManager(cfgManager config.Manager) Manager {
+	return &manager{
+		cfgManager: cfgManager,
+		stores:     map[string]*storeEntry{},
+	}
+}
@@ -0,0 +1,3 @@
+package session
+
+// This package will manage OIDC server side session stores
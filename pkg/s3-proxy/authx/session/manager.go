@@ -0,0 +1,109 @@
+package session
+
+import (
+	"reflect"
+	"sync"
+
+	"emperror.dev/errors"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+type storeEntry struct {
+	store Store
+	cfg   *config.OIDCSessionStoreConfig
+}
+
+type manager struct {
+	cfgManager config.Manager
+	stores     map[string]*storeEntry
+	mutex      sync.RWMutex
+}
+
+func (m *manager) GetStore(providerKey string) Store {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	// Get entry
+	entry := m.stores[providerKey]
+	// Check if it exists
+	if entry == nil {
+		return nil
+	}
+
+	return entry.store
+}
+
+func (m *manager) Load() error {
+	// Get configuration
+	cfg := m.cfgManager.GetConfig()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Create new stores map
+	stores := map[string]*storeEntry{}
+
+	// Check if there are oidc providers
+	if cfg.AuthProviders != nil {
+		// Loop over oidc providers
+		for k, oidcCfg := range cfg.AuthProviders.OIDC {
+			// Check if session is enabled
+			if oidcCfg.Session == nil || !oidcCfg.Session.Enabled {
+				continue
+			}
+
+			// Get store configuration
+			storeCfg := oidcCfg.Session.Store
+
+			// Check if store can be kept
+			if old := m.stores[k]; old != nil && reflect.DeepEqual(old.cfg, storeCfg) {
+				stores[k] = old
+
+				continue
+			}
+
+			// Create store
+			st, err := newStore(storeCfg)
+			// Check error
+			if err != nil {
+				return err
+			}
+
+			// Save
+			stores[k] = &storeEntry{store: st, cfg: storeCfg}
+		}
+	}
+
+	// Close removed or replaced stores
+	for k, old := range m.stores {
+		// Check if store is still used
+		if stores[k] == old {
+			continue
+		}
+
+		// Close
+		err := old.store.Close()
+		// Check error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Save
+	m.stores = stores
+
+	return nil
+}
+
+func newStore(storeCfg *config.OIDCSessionStoreConfig) (Store, error) {
+	// Check type
+	switch storeCfg.Type {
+	case config.OIDCSessionStoreRedisType:
+		return newRedisStore(storeCfg.Redis)
+	case config.OIDCSessionStoreMemoryType:
+		return newMemoryStore(), nil
+	default:
+		return nil, errors.Errorf("unsupported session store type %s", storeCfg.Type)
+	}
+}
@@ -0,0 +1,74 @@
+//go:build unit
+
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+)
+
+func newTestOIDCConfig(sessionCfg *config.OIDCSessionConfig) *config.Config {
+	return &config.Config{
+		AuthProviders: &config.AuthProviderConfig{
+			OIDC: map[string]*config.OIDCAuthConfig{
+				"provider1": {Session: sessionCfg},
+				"provider2": {},
+			},
+		},
+	}
+}
+
+func Test_manager_Load(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+
+	cfg := newTestOIDCConfig(&config.OIDCSessionConfig{
+		Enabled: true,
+		Store:   &config.OIDCSessionStoreConfig{Type: config.OIDCSessionStoreMemoryType},
+	})
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().DoAndReturn(func() *config.Config { return cfg })
+
+	m := NewManager(cfgManagerMock)
+
+	// Not loaded
+	assert.Nil(t, m.GetStore("provider1"))
+
+	require.NoError(t, m.Load())
+
+	st := m.GetStore("provider1")
+	assert.IsType(t, &memoryStore{}, st)
+	assert.Nil(t, m.GetStore("provider2"))
+
+	// Reload with same configuration keeps store
+	cfg = newTestOIDCConfig(&config.OIDCSessionConfig{
+		Enabled: true,
+		Store:   &config.OIDCSessionStoreConfig{Type: config.OIDCSessionStoreMemoryType},
+	})
+
+	require.NoError(t, m.Load())
+	assert.Same(t, st, m.GetStore("provider1"))
+
+	// Reload with session disabled removes store
+	cfg = newTestOIDCConfig(&config.OIDCSessionConfig{})
+
+	require.NoError(t, m.Load())
+	assert.Nil(t, m.GetStore("provider1"))
+}
+
+func Test_manager_Load_UnsupportedStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().Return(newTestOIDCConfig(&config.OIDCSessionConfig{
+		Enabled: true,
+		Store:   &config.OIDCSessionStoreConfig{Type: "fake"},
+	}))
+
+	err := NewManager(cfgManagerMock).Load()
+	assert.EqualError(t, err, "unsupported session store type fake")
+}
@@ -0,0 +1,90 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryPurgeInterval is the minimum interval between two purges of expired sessions.
+const memoryPurgeInterval = time.Minute
+
+type memoryEntry struct {
+	expiresAt time.Time
+	data      []byte
+}
+
+type memoryStore struct {
+	lastPurge time.Time
+	now       func() time.Time
+	entries   map[string]*memoryEntry
+	mutex     sync.Mutex
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		now:     time.Now,
+		entries: map[string]*memoryEntry{},
+	}
+}
+
+func (s *memoryStore) Get(_ context.Context, id string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Get entry
+	entry := s.entries[id]
+	// Check if it exists
+	if entry == nil {
+		return nil, nil
+	}
+
+	// Check if it has expired
+	if !s.now().Before(entry.expiresAt) {
+		delete(s.entries, id)
+
+		return nil, nil
+	}
+
+	return entry.data, nil
+}
+
+func (s *memoryStore) Set(_ context.Context, id string, data []byte, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Get now
+	now := s.now()
+
+	// Purge expired entries from time to time
+	if now.Sub(s.lastPurge) >= memoryPurgeInterval {
+		for k, v := range s.entries {
+			if !now.Before(v.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+
+		s.lastPurge = now
+	}
+
+	// Save
+	s.entries[id] = &memoryEntry{
+		expiresAt: now.Add(ttl),
+		data:      data,
+	}
+
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.entries, id)
+
+	return nil
+}
+
+func (*memoryStore) Close() error {
+	return nil
+}
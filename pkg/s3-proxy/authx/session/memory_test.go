@@ -0,0 +1,60 @@
+//go:build unit
+
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_memoryStore(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newMemoryStore()
+	s.now = func() time.Time { return now }
+
+	// Not found
+	data, err := s.Get(context.TODO(), "id1")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	// Set and get
+	require.NoError(t, s.Set(context.TODO(), "id1", []byte("data1"), time.Minute))
+	require.NoError(t, s.Set(context.TODO(), "id2", []byte("data2"), time.Hour))
+
+	data, err = s.Get(context.TODO(), "id1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data1"), data)
+
+	// Expired
+	now = now.Add(2 * time.Minute)
+
+	data, err = s.Get(context.TODO(), "id1")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	// Delete
+	require.NoError(t, s.Delete(context.TODO(), "id2"))
+
+	data, err = s.Get(context.TODO(), "id2")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+}
+
+func Test_memoryStore_Purge(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newMemoryStore()
+	s.now = func() time.Time { return now }
+
+	require.NoError(t, s.Set(context.TODO(), "id1", []byte("data1"), time.Second))
+
+	// Expired entries are purged on next set
+	now = now.Add(2 * memoryPurgeInterval)
+
+	require.NoError(t, s.Set(context.TODO(), "id2", []byte("data2"), time.Hour))
+	assert.Len(t, s.entries, 1)
+	assert.Contains(t, s.entries, "id2")
+}
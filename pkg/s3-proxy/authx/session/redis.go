@@ -0,0 +1,71 @@
+package session
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+type redisStore struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+func newRedisStore(redisCfg *config.OIDCSessionRedisConfig) (*redisStore, error) {
+	// Check configuration
+	if redisCfg == nil {
+		return nil, errors.New("redis session store must have a redis configuration")
+	}
+
+	// Create options
+	opts := &redis.Options{
+		Addr:     redisCfg.Address,
+		Username: redisCfg.Username,
+		DB:       redisCfg.DB,
+	}
+	// Check if password exists
+	if redisCfg.Password != nil {
+		opts.Password = redisCfg.Password.Value
+	}
+	// Check if tls is enabled
+	if redisCfg.TLS {
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	return &redisStore{
+		client:    redis.NewClient(opts),
+		keyPrefix: redisCfg.KeyPrefix,
+	}, nil
+}
+
+func (s *redisStore) Get(ctx context.Context, id string) ([]byte, error) {
+	// Get data
+	data, err := s.client.Get(ctx, s.keyPrefix+id).Bytes()
+	// Check if key doesn't exist
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return data, nil
+}
+
+func (s *redisStore) Set(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	return errors.WithStack(s.client.Set(ctx, s.keyPrefix+id, data, ttl).Err())
+}
+
+func (s *redisStore) Delete(ctx context.Context, id string) error {
+	return errors.WithStack(s.client.Del(ctx, s.keyPrefix+id).Err())
+}
+
+func (s *redisStore) Close() error {
+	return errors.WithStack(s.client.Close())
+}
@@ -0,0 +1,58 @@
+//go:build unit
+
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+func Test_redisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	s, err := newRedisStore(&config.OIDCSessionRedisConfig{
+		Address:   mr.Addr(),
+		KeyPrefix: "prefix:",
+	})
+	require.NoError(t, err)
+
+	defer s.Close()
+
+	// Not found
+	data, err := s.Get(context.TODO(), "id1")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	// Set and get
+	require.NoError(t, s.Set(context.TODO(), "id1", []byte("data1"), time.Minute))
+
+	data, err = s.Get(context.TODO(), "id1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data1"), data)
+	assert.True(t, mr.Exists("prefix:id1"))
+	assert.Equal(t, time.Minute, mr.TTL("prefix:id1"))
+
+	// Expired
+	mr.FastForward(2 * time.Minute)
+
+	data, err = s.Get(context.TODO(), "id1")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	// Delete
+	require.NoError(t, s.Set(context.TODO(), "id2", []byte("data2"), time.Minute))
+	require.NoError(t, s.Delete(context.TODO(), "id2"))
+	assert.False(t, mr.Exists("prefix:id2"))
+}
+
+func Test_newRedisStore_NoConfig(t *testing.T) {
+	_, err := newRedisStore(nil)
+	assert.EqualError(t, err, "redis session store must have a redis configuration")
+}
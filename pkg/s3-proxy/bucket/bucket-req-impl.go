@@ -10,9 +10,11 @@ import (
 
 	"emperror.dev/errors"
 
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/audit"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/quota"
 	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
 	responsehandlermodels "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler/models"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
@@ -23,22 +25,26 @@ import (
 
 // bucketReqImpl Bucket request context.
 type bucketReqImpl struct {
-	s3ClientManager s3client.Manager
-	webhookManager  webhook.Manager
-	targetCfg       *config.TargetConfig
-	mountPath       string
-	generalHelpers  []string
+	s3ClientManager    s3client.Manager
+	webhookManager     webhook.Manager
+	quotaManager       quota.Manager
+	precompressedCache *precompressedCache
+	targetCfg          *config.TargetConfig
+	mountPath          string
+	generalHelpers     []string
 }
 
-// userIsolationSegment returns the "<identifier>/" segment to splice between
+// userIsolationSegment returns the user folder segment to splice between
 // the bucket prefix and the request path when userIsolation is enabled for the
-// current request. The identifier comes from GenericUser.GetIdentifier() so
-// OIDC users without a preferred_username claim still get a usable folder
-// (their email). It returns an empty string for admins or when isolation is
-// off, and errUserIsolationForbidden if isolation is enabled but no user is
+// current request. The folder is rendered from userIsolationPathTemplate, or is
+// GenericUser.GetIdentifier() by default so OIDC users without a
+// preferred_username claim still get a usable folder (their email). It returns
+// an empty string for admins, when isolation is off or when group spaces are
+// enabled (request paths are checked against spaces instead), and
+// errUserIsolationForbidden if isolation is enabled but no user is
 // authenticated.
 func (bri *bucketReqImpl) userIsolationSegment(ctx context.Context) (string, error) {
-	if !bri.isUserIsolationEnabled() {
+	if !bri.isUserIsolationEnabled() || bri.isUserIsolationSpacesEnabled() {
 		return "", nil
 	}
 
@@ -47,12 +53,11 @@ func (bri *bucketReqImpl) userIsolationSegment(ctx context.Context) (string, err
 		return "", errUserIsolationForbidden
 	}
 
-	identifier := user.GetIdentifier()
-	if bri.isUserIsolationAdmin(identifier) {
+	if bri.isUserIsolationAdmin(user.GetIdentifier()) {
 		return "", nil
 	}
 
-	return identifier + "/", nil
+	return bri.userIsolationUserFolder(user)
 }
 
 // generateStartKey will generate start key used in all functions.
@@ -60,6 +65,9 @@ func (bri *bucketReqImpl) userIsolationSegment(ctx context.Context) (string, err
 // transparently injected after the bucket prefix (e.g., data/ + alice/ +
 // file.txt) so that non-admin users are structurally confined to their own
 // folder.
+// When group spaces are enabled, the key must be inside one of the user
+// spaces instead. For folders containing spaces, the key is returned with
+// errUserIsolationVirtualFolder.
 func (bri *bucketReqImpl) generateStartKey(ctx context.Context, requestPath string) (string, error) {
 	seg, err := bri.userIsolationSegment(ctx)
 	if err != nil {
@@ -67,7 +75,16 @@ func (bri *bucketReqImpl) generateStartKey(ctx context.Context, requestPath stri
 	}
 
 	// Trim first / if exists
-	return bri.targetCfg.Bucket.GetRootPrefix() + seg + strings.TrimPrefix(requestPath, "/"), nil
+	key := bri.targetCfg.Bucket.GetRootPrefix() + seg + strings.TrimPrefix(requestPath, "/")
+
+	// Check user spaces
+	err = bri.checkUserIsolationSpaces(ctx, key)
+	// Check error
+	if err != nil {
+		return key, err
+	}
+
+	return key, nil
 }
 
 // displayPrefix returns the S3 key prefix that must be stripped from entry
@@ -91,12 +108,17 @@ func (bri *bucketReqImpl) displayPrefix(ctx context.Context) (string, error) {
 // Centralising this keeps the four request-handling entry points
 // (Get/Put/Delete/listing) in lockstep on what errUserIsolationForbidden
 // means at the HTTP layer.
-func (bri *bucketReqImpl) respondToUserIsolationError(resHan responsehandler.ResponseHandler, err error) bool {
+func (bri *bucketReqImpl) respondToUserIsolationError(
+	ctx context.Context,
+	resHan responsehandler.ResponseHandler,
+	err error,
+) bool {
 	if err == nil {
 		return false
 	}
 
-	if errors.Is(err, errUserIsolationForbidden) {
+	if errors.Is(err, errUserIsolationForbidden) || errors.Is(err, errUserIsolationVirtualFolder) {
+		audit.SetDecision(ctx, audit.DecisionDeny, "user isolation")
 		resHan.ForbiddenError(bri.LoadFileContent, err)
 	} else {
 		resHan.InternalServerError(bri.LoadFileContent, err)
@@ -204,6 +226,55 @@ func (bri *bucketReqImpl) manageKeyRewrite(ctx context.Context, key string) (str
 	return key, nil
 }
 
+func (bri *bucketReqImpl) ResolveObject(ctx context.Context, requestPath string, withMetadata bool) (*ObjectInfo, error) {
+	// Generate start key
+	key, err := bri.generateStartKey(ctx, requestPath)
+	// Check error
+	// Folders containing user spaces are resolved as they can be listed
+	if err != nil && !errors.Is(err, errUserIsolationVirtualFolder) {
+		return nil, err
+	}
+
+	// Manage key rewrite
+	key, err = bri.manageKeyRewrite(ctx, key)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Create result
+	res := &ObjectInfo{
+		Target: bri.targetCfg.Name,
+		Bucket: bri.targetCfg.Bucket.Name,
+		Key:    key,
+	}
+
+	// Check if metadata must be loaded
+	// Folders don't have any metadata
+	if !withMetadata || key == "" || strings.HasSuffix(key, "/") {
+		return res, nil
+	}
+
+	// Head file in bucket
+	headOutput, _, err := bri.s3ClientManager.
+		GetClientForTarget(bri.targetCfg.Name).
+		HeadObject(ctx, key)
+	// Check if error exists and not a not found error
+	if err != nil && !errors.Is(err, s3client.ErrNotFound) {
+		return nil, err
+	}
+	// Check if file exists
+	if headOutput != nil && headOutput.BaseFileOutput != nil {
+		res.Exists = true
+		res.LastModified = &headOutput.LastModified
+		res.Metadata = headOutput.Metadata
+		res.ContentType = headOutput.ContentType
+		res.ContentLength = headOutput.ContentLength
+	}
+
+	return res, nil
+}
+
 // Proxy GET requests.
 func (bri *bucketReqImpl) Get(ctx context.Context, input *GetInput) {
 	bri.internalGetOrHead(ctx, input, false)
@@ -220,7 +291,16 @@ func (bri *bucketReqImpl) internalGetOrHead(ctx context.Context, input *GetInput
 
 	// Generate start key
 	key, err := bri.generateStartKey(ctx, input.RequestPath)
-	if bri.respondToUserIsolationError(resHan, err) {
+	// Check if it is a folder containing user spaces
+	if errors.Is(err, errUserIsolationVirtualFolder) {
+		// Save audit object
+		audit.SetObject(ctx, audit.ActionList, bri.targetCfg.Bucket.Name, key)
+		bri.manageUserIsolationVirtualFolder(ctx, key)
+		// Stop
+		return
+	}
+
+	if bri.respondToUserIsolationError(ctx, resHan, err) {
 		return
 	}
 	// Manage key rewrite
@@ -234,11 +314,20 @@ func (bri *bucketReqImpl) internalGetOrHead(ctx context.Context, input *GetInput
 
 	// Check that the path ends with a / for a directory listing or the main path special case (empty path)
 	if strings.HasSuffix(input.RequestPath, "/") || input.RequestPath == "" {
+		// Save audit object
+		audit.SetObject(ctx, audit.ActionList, bri.targetCfg.Bucket.Name, key)
 		bri.manageGetFolder(ctx, key, input, isHeadReq)
 		// Stop
 		return
 	}
 
+	// Save audit object
+	if isHeadReq {
+		audit.SetObject(ctx, audit.ActionHead, bri.targetCfg.Bucket.Name, key)
+	} else {
+		audit.SetObject(ctx, audit.ActionGet, bri.targetCfg.Bucket.Name, key)
+	}
+
 	// Check if it is a HEAD request or if it is asked to redirect to signed url
 	if isHeadReq || bri.targetCfg.Actions != nil &&
 		bri.targetCfg.Actions.GET != nil &&
@@ -373,6 +462,15 @@ func (bri *bucketReqImpl) manageGetFolder(ctx context.Context, key string, input
 	if bri.targetCfg.Actions != nil && bri.targetCfg.Actions.GET != nil &&
 		bri.targetCfg.Actions.GET.Config != nil &&
 		bri.targetCfg.Actions.GET.Config.DisableListing {
+		// Expose quota usage
+		err := bri.exposeQuotaUsage(ctx, resHan)
+		// Check error
+		if err != nil {
+			resHan.InternalServerError(bri.LoadFileContent, err)
+			// Stop
+			return
+		}
+
 		// Answer directly
 		resHan.FoldersFilesList(
 			bri.LoadFileContent,
@@ -439,12 +537,21 @@ func (bri *bucketReqImpl) manageGetFolder(ctx context.Context, key string, input
 	// Use displayPrefix so the user-facing Path hides the injected identifier
 	// for non-admin users under userIsolation.
 	displayPfx, err := bri.displayPrefix(ctx)
-	if bri.respondToUserIsolationError(resHan, err) {
+	if bri.respondToUserIsolationError(ctx, resHan, err) {
 		return
 	}
 
 	entries := transformS3Entries(s3Entries, bri, displayPfx)
 
+	// Expose quota usage
+	err = bri.exposeQuotaUsage(ctx, resHan)
+	// Check error
+	if err != nil {
+		resHan.InternalServerError(bri.LoadFileContent, err)
+		// Stop
+		return
+	}
+
 	// Answer
 	resHan.FoldersFilesList(
 		bri.LoadFileContent,
@@ -459,7 +566,7 @@ func (bri *bucketReqImpl) Put(ctx context.Context, inp *PutInput) {
 
 	// Generate start key
 	key, err := bri.generateStartKey(ctx, inp.RequestPath)
-	if bri.respondToUserIsolationError(resHan, err) {
+	if bri.respondToUserIsolationError(ctx, resHan, err) {
 		return
 	}
 	// Add / at the end if not present
@@ -477,6 +584,64 @@ func (bri *bucketReqImpl) Put(ctx context.Context, inp *PutInput) {
 		return
 	}
 
+	// Save audit object
+	audit.SetObject(ctx, audit.ActionPut, bri.targetCfg.Bucket.Name, key)
+
+	// Pre-action hooks metadata
+	var preActionMetadata map[string]string
+	// Request path and filename sent to webhooks, updated when a pre-action hook rewrites the path
+	hookRequestPath, hookFilename := inp.RequestPath, inp.Filename
+
+	// Check if pre-action hooks are configured
+	if bri.targetCfg.Actions.PUT != nil &&
+		bri.targetCfg.Actions.PUT.Config != nil &&
+		len(bri.targetCfg.Actions.PUT.Config.PreActionHooks) > 0 {
+		// Get object path relative to target
+		objectPath := "/" + strings.TrimPrefix(inp.RequestPath, "/")
+		// Add / at the end if not present
+		if !strings.HasSuffix(objectPath, "/") {
+			objectPath += "/"
+		}
+		// Add filename
+		objectPath += inp.Filename
+
+		// Execute pre-action hooks
+		preRes := bri.webhookManager.ExecutePUTPreActionHooks(
+			ctx,
+			bri.targetCfg.Name,
+			&webhook.PreActionInput{
+				ResolveKey:  func(p string) (string, error) { return bri.resolvePreActionKey(ctx, p) },
+				RequestPath: inp.RequestPath,
+				Path:        objectPath,
+				Key:         key,
+			},
+			&webhook.PutInputMetadata{
+				Filename:    inp.Filename,
+				ContentType: inp.ContentType,
+				ContentSize: inp.ContentSize,
+			},
+		)
+		// Check if action is denied
+		if !preRes.Allowed {
+			bri.respondPreActionDenied(ctx, resHan, preRes.Reason)
+			// Stop
+			return
+		}
+		// Check if key was rewritten
+		if preRes.Key != key {
+			key = preRes.Key
+			// Update audit object
+			audit.SetObject(ctx, audit.ActionPut, bri.targetCfg.Bucket.Name, key)
+		}
+		// Check if path was rewritten
+		if preRes.Path != objectPath {
+			// Split rewritten path like a PUT request on a folder with a filename
+			hookRequestPath, hookFilename = path.Split(preRes.Path)
+		}
+		// Save metadata
+		preActionMetadata = preRes.Metadata
+	}
+
 	// Create input
 	input := &s3client.PutInput{
 		Key:         key,
@@ -647,24 +812,46 @@ func (bri *bucketReqImpl) Put(ctx context.Context, inp *PutInput) {
 		}
 	}
 
+	// Add pre-action hooks metadata
+	// Those are added last to override configured metadata.
+	for k, v := range preActionMetadata {
+		// Check if map exists
+		if input.Metadata == nil {
+			input.Metadata = map[string]string{}
+		}
+
+		input.Metadata[k] = v
+	}
+
+	// Reserve quota
+	rollbackQuota, stop := bri.reserveQuota(ctx, resHan, key, inp.ContentSize)
+	if stop {
+		return
+	}
+
 	// Put file
 	info, err := bri.s3ClientManager.
 		GetClientForTarget(bri.targetCfg.Name).
 		PutObject(ctx, input)
 		// Check error
 	if err != nil {
+		// Rollback quota reservation
+		rollbackQuota()
 		resHan.InternalServerError(bri.LoadFileContent, err)
 		// Stop
 		return
 	}
 
+	// Forget precompressed variants lookup
+	bri.invalidatePrecompressedEntry(input.Key)
+
 	// Send hook
 	bri.webhookManager.ManagePUTHooks(
 		ctx,
 		bri.targetCfg.Name,
-		inp.RequestPath,
+		hookRequestPath,
 		&webhook.PutInputMetadata{
-			Filename:    inp.Filename,
+			Filename:    hookFilename,
 			ContentType: inp.ContentType,
 			ContentSize: inp.ContentSize,
 		},
@@ -723,7 +910,7 @@ func (bri *bucketReqImpl) Delete(ctx context.Context, requestPath string) {
 
 	// Generate start key
 	key, err := bri.generateStartKey(ctx, requestPath)
-	if bri.respondToUserIsolationError(resHan, err) {
+	if bri.respondToUserIsolationError(ctx, resHan, err) {
 		return
 	}
 	// Manage key rewrite
@@ -735,6 +922,9 @@ func (bri *bucketReqImpl) Delete(ctx context.Context, requestPath string) {
 		return
 	}
 
+	// Save audit object
+	audit.SetObject(ctx, audit.ActionDelete, bri.targetCfg.Bucket.Name, key)
+
 	// Check that the path ends with a / for a directory or the main path special case (empty path)
 	if strings.HasSuffix(requestPath, "/") || requestPath == "" {
 		resHan.InternalServerError(bri.LoadFileContent, ErrRemovalFolder)
@@ -742,6 +932,51 @@ func (bri *bucketReqImpl) Delete(ctx context.Context, requestPath string) {
 		return
 	}
 
+	// Request path sent to webhooks, updated when a pre-action hook rewrites the path
+	hookRequestPath := requestPath
+
+	// Check if pre-action hooks are configured
+	if bri.targetCfg.Actions != nil &&
+		bri.targetCfg.Actions.DELETE != nil &&
+		bri.targetCfg.Actions.DELETE.Config != nil &&
+		len(bri.targetCfg.Actions.DELETE.Config.PreActionHooks) > 0 {
+		// Get object path relative to target
+		objectPath := "/" + strings.TrimPrefix(requestPath, "/")
+		// Execute pre-action hooks
+		preRes := bri.webhookManager.ExecuteDELETEPreActionHooks(
+			ctx,
+			bri.targetCfg.Name,
+			&webhook.PreActionInput{
+				ResolveKey:  func(p string) (string, error) { return bri.resolvePreActionKey(ctx, p) },
+				RequestPath: requestPath,
+				Path:        objectPath,
+				Key:         key,
+			},
+		)
+		// Check if action is denied
+		if !preRes.Allowed {
+			bri.respondPreActionDenied(ctx, resHan, preRes.Reason)
+			// Stop
+			return
+		}
+		// Check if key was rewritten
+		if preRes.Key != key {
+			key = preRes.Key
+			// Update audit object
+			audit.SetObject(ctx, audit.ActionDelete, bri.targetCfg.Bucket.Name, key)
+		}
+		// Check if path was rewritten
+		if preRes.Path != objectPath {
+			hookRequestPath = preRes.Path
+		}
+	}
+
+	// Prepare quota release
+	releaseQuota, stop := bri.prepareQuotaRelease(ctx, resHan, key)
+	if stop {
+		return
+	}
+
 	// Delete object in S3
 	info, err := bri.s3ClientManager.
 		GetClientForTarget(bri.targetCfg.Name).
@@ -753,11 +988,17 @@ func (bri *bucketReqImpl) Delete(ctx context.Context, requestPath string) {
 		return
 	}
 
+	// Forget precompressed variants lookup
+	bri.invalidatePrecompressedEntry(key)
+
+	// Release quota
+	releaseQuota()
+
 	// Send hook
 	bri.webhookManager.ManageDELETEHooks(
 		ctx,
 		bri.targetCfg.Name,
-		requestPath,
+		hookRequestPath,
 		&webhook.S3Metadata{
 			Bucket:     info.Bucket,
 			Region:     info.Region,
@@ -775,6 +1016,36 @@ func (bri *bucketReqImpl) Delete(ctx context.Context, requestPath string) {
 	)
 }
 
+// respondPreActionDenied answers with the forbidden error template when a pre-action hook denies the action.
+func (bri *bucketReqImpl) respondPreActionDenied(ctx context.Context, resHan responsehandler.ResponseHandler, reason string) {
+	// Save audit decision
+	audit.SetDecision(ctx, audit.DecisionDeny, "pre-action hook: "+reason)
+	// Response
+	resHan.ForbiddenError(bri.LoadFileContent, fmt.Errorf("action denied by pre-action hook: %s", reason))
+}
+
+// resolvePreActionKey returns the object key for a path rewritten by a pre-action hook.
+// Path is relative to the target like a request path, so the key is built with the same
+// bucket prefix, user isolation folder, spaces checks and key rewrites as the request key.
+func (bri *bucketReqImpl) resolvePreActionKey(ctx context.Context, p string) (string, error) {
+	// Check dot segments
+	for _, seg := range strings.Split(p, "/") {
+		if seg == "." || seg == ".." {
+			return "", errors.WithStack(errPreActionInvalidPath)
+		}
+	}
+
+	// Generate start key
+	key, err := bri.generateStartKey(ctx, p)
+	// Check error
+	if err != nil {
+		return "", err
+	}
+
+	// Manage key rewrite
+	return bri.manageKeyRewrite(ctx, key)
+}
+
 // userIsolationCfg returns the GET-action config that owns the userIsolation
 // fields, or nil when the chain is incomplete. Centralising the nil walk
 // keeps the two consumers below as one-liners.
@@ -895,6 +1166,37 @@ func (bri *bucketReqImpl) answerHead(
 	// Get response handler from context
 	resHan := responsehandler.GetResponseHandlerFromContext(ctx)
 
+	// Select precompressed variant if possible
+	// This is done like for GET requests to answer with the same headers.
+	variant, err := bri.selectPrecompressedVariant(ctx, hOutput.Key, input)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Check if a precompressed variant is selected
+	if variant.encoding != "" {
+		// Head variant
+		vOutput, vInfo, err2 := bri.s3ClientManager.
+			GetClientForTarget(bri.targetCfg.Name).
+			HeadObject(ctx, variant.key)
+		// Check error
+		if err2 != nil && !errors.Is(err2, s3client.ErrNotFound) {
+			return err2
+		}
+
+		// Check if variant still exists
+		if vOutput != nil {
+			hOutput = vOutput
+			info = vInfo
+		} else {
+			// Variant has been removed, forget lookup and answer with original object
+			bri.invalidatePrecompressedEntry(hOutput.Key)
+
+			variant.encoding = ""
+		}
+	}
+
 	// Send hook
 	bri.webhookManager.ManageHEADHooks(
 		ctx,
@@ -929,6 +1231,9 @@ func (bri *bucketReqImpl) answerHead(
 		Metadata:           hOutput.Metadata,
 	}
 
+	// Apply precompressed variant headers
+	applyPrecompressedSelection(inp, variant)
+
 	// Stream
 	return resHan.StreamFile(bri.LoadFileContent, inp)
 }
@@ -937,18 +1242,40 @@ func (bri *bucketReqImpl) streamFileForResponse(ctx context.Context, key string,
 	// Get response handler from context
 	resHan := responsehandler.GetResponseHandlerFromContext(ctx)
 
+	// Select precompressed variant if possible
+	variant, err := bri.selectPrecompressedVariant(ctx, key, input)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Create get input
+	getInput := &s3client.GetInput{
+		Key:               variant.key,
+		IfModifiedSince:   input.IfModifiedSince,
+		IfMatch:           input.IfMatch,
+		IfNoneMatch:       input.IfNoneMatch,
+		IfUnmodifiedSince: input.IfUnmodifiedSince,
+		Range:             input.Range,
+	}
+
 	// Get object from s3
 	objOutput, info, err := bri.s3ClientManager.
 		GetClientForTarget(bri.targetCfg.Name).
-		GetObject(ctx, &s3client.GetInput{
-			Key:               key,
-			IfModifiedSince:   input.IfModifiedSince,
-			IfMatch:           input.IfMatch,
-			IfNoneMatch:       input.IfNoneMatch,
-			IfUnmodifiedSince: input.IfUnmodifiedSince,
-			Range:             input.Range,
-		})
-		// Check error
+		GetObject(ctx, getInput)
+	// Check if selected variant has been removed
+	if variant.encoding != "" && errors.Is(err, s3client.ErrNotFound) {
+		// Forget lookup and answer with original object
+		bri.invalidatePrecompressedEntry(key)
+
+		variant.encoding = ""
+		getInput.Key = key
+
+		objOutput, info, err = bri.s3ClientManager.
+			GetClientForTarget(bri.targetCfg.Name).
+			GetObject(ctx, getInput)
+	}
+	// Check error
 	if err != nil {
 		return err
 	}
@@ -973,6 +1300,9 @@ func (bri *bucketReqImpl) streamFileForResponse(ctx context.Context, key string,
 		Metadata:           objOutput.Metadata,
 	}
 
+	// Apply precompressed variant headers
+	applyPrecompressedSelection(inp, variant)
+
 	// Stream
 	err = resHan.StreamFile(bri.LoadFileContent, inp)
 	// Check error
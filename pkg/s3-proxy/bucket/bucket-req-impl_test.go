@@ -260,6 +260,10 @@ func Test_requestContext_Delete(t *testing.T) {
 		input4 *webhook.S3Metadata
 		times  int
 	}
+	type webhookManagerExecutePreActionHooksMockResult struct {
+		res   *webhook.PreActionResult
+		times int
+	}
 	type fields struct {
 		targetCfg *config.TargetConfig
 		mountPath string
@@ -268,14 +272,15 @@ func Test_requestContext_Delete(t *testing.T) {
 		requestPath string
 	}
 	tests := []struct {
-		name                                         string
-		fields                                       fields
-		args                                         args
-		s3clManagerClientForTargetMockInput          string
-		responseHandlerDeleteMockResultTimes         responseHandlerDeleteMockResult
-		responseHandlerInternalServerErrorMockResult responseHandlerInternalServerErrorMockResult
-		s3ClientDeleteObjectMockResult               s3ClientDeleteObjectMockResult
-		webhookManagerManageDeleteHooksMockResult    webhookManagerManageDeleteHooksMockResult
+		name                                          string
+		fields                                        fields
+		args                                          args
+		s3clManagerClientForTargetMockInput           string
+		responseHandlerDeleteMockResultTimes          responseHandlerDeleteMockResult
+		responseHandlerInternalServerErrorMockResult  responseHandlerInternalServerErrorMockResult
+		s3ClientDeleteObjectMockResult                s3ClientDeleteObjectMockResult
+		webhookManagerManageDeleteHooksMockResult     webhookManagerManageDeleteHooksMockResult
+		webhookManagerExecutePreActionHooksMockResult webhookManagerExecutePreActionHooksMockResult
 	}{
 		{
 			name: "Can't delete a directory with empty request path",
@@ -405,6 +410,54 @@ func Test_requestContext_Delete(t *testing.T) {
 				times: 1,
 			},
 		},
+		{
+			name: "Delete succeed with path rewritten by pre-action hook",
+			fields: fields{
+				targetCfg: &config.TargetConfig{
+					Name:   "bucket",
+					Bucket: &config.BucketConfig{Prefix: "/"},
+					Actions: &config.ActionsConfig{
+						DELETE: &config.DeleteActionConfig{
+							Config: &config.DeleteActionConfigConfig{
+								PreActionHooks: []*config.PreActionHookConfig{{URL: "http://hook"}},
+							},
+						},
+					},
+				},
+				mountPath: "/mount",
+			},
+			args: args{requestPath: "/file"},
+			webhookManagerExecutePreActionHooksMockResult: webhookManagerExecutePreActionHooksMockResult{
+				res:   &webhook.PreActionResult{Allowed: true, Key: "/trash/file", Path: "/trash/file"},
+				times: 1,
+			},
+			s3clManagerClientForTargetMockInput: "bucket",
+			s3ClientDeleteObjectMockResult: s3ClientDeleteObjectMockResult{
+				input2: "/trash/file",
+				res: &s3client.ResultInfo{
+					Bucket:     "bucket",
+					Key:        "/trash/file",
+					Region:     "region",
+					S3Endpoint: "s3endpoint",
+				},
+				times: 1,
+			},
+			webhookManagerManageDeleteHooksMockResult: webhookManagerManageDeleteHooksMockResult{
+				input2: "bucket",
+				input3: "/trash/file",
+				input4: &webhook.S3Metadata{
+					Bucket:     "bucket",
+					Key:        "/trash/file",
+					Region:     "region",
+					S3Endpoint: "s3endpoint",
+				},
+				times: 1,
+			},
+			responseHandlerDeleteMockResultTimes: responseHandlerDeleteMockResult{
+				input: &responsehandlermodels.DeleteInput{Key: "/trash/file"},
+				times: 1,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -455,6 +508,10 @@ func Test_requestContext_Delete(t *testing.T) {
 					tt.webhookManagerManageDeleteHooksMockResult.input4,
 				).
 				Times(tt.webhookManagerManageDeleteHooksMockResult.times)
+			webhookManagerMock.EXPECT().
+				ExecuteDELETEPreActionHooks(ctx, "bucket", gomock.Any()).
+				Return(tt.webhookManagerExecutePreActionHooksMockResult.res).
+				Times(tt.webhookManagerExecutePreActionHooksMockResult.times)
 
 			rctx := &bucketReqImpl{
 				s3ClientManager: s3clManagerMock,
@@ -495,6 +552,10 @@ func Test_requestContext_Put(t *testing.T) {
 		input5 *webhook.S3Metadata
 		times  int
 	}
+	type webhookManagerExecutePreActionHooksMockResult struct {
+		res   *webhook.PreActionResult
+		times int
+	}
 	type fields struct {
 		targetCfg *config.TargetConfig
 		mountPath string
@@ -503,16 +564,17 @@ func Test_requestContext_Put(t *testing.T) {
 		inp *PutInput
 	}
 	tests := []struct {
-		name                                         string
-		fields                                       fields
-		args                                         args
-		responseHandlerInternalServerErrorMockResult responseHandlerErrorsMockResult
-		responseHandlerForbiddenErrorMockResult      responseHandlerErrorsMockResult
-		responseHandlerPutMockResultTimes            responseHandlerPutMockResult
-		s3clManagerClientForTargetMockInput          string
-		s3ClientHeadObjectMockResult                 s3ClientHeadObjectMockResult
-		s3ClientPutObjectMockResult                  s3ClientPutObjectMockResult
-		webhookManagerManagePutHooksMockResult       webhookManagerManagePutHooksMockResult
+		name                                          string
+		fields                                        fields
+		args                                          args
+		responseHandlerInternalServerErrorMockResult  responseHandlerErrorsMockResult
+		responseHandlerForbiddenErrorMockResult       responseHandlerErrorsMockResult
+		responseHandlerPutMockResultTimes             responseHandlerPutMockResult
+		s3clManagerClientForTargetMockInput           string
+		s3ClientHeadObjectMockResult                  s3ClientHeadObjectMockResult
+		s3ClientPutObjectMockResult                   s3ClientPutObjectMockResult
+		webhookManagerManagePutHooksMockResult        webhookManagerManagePutHooksMockResult
+		webhookManagerExecutePreActionHooksMockResult webhookManagerExecutePreActionHooksMockResult
 	}{
 		{
 			name: "should fail when put object failed and no put configuration exists",
@@ -1518,6 +1580,72 @@ func Test_requestContext_Put(t *testing.T) {
 				times: 1,
 			},
 		},
+		{
+			name: "should be ok with path rewritten by pre-action hook",
+			fields: fields{
+				targetCfg: &config.TargetConfig{
+					Name:   "name",
+					Bucket: &config.BucketConfig{Prefix: "/"},
+					Actions: &config.ActionsConfig{
+						PUT: &config.PutActionConfig{
+							Config: &config.PutActionConfigConfig{
+								PreActionHooks: []*config.PreActionHookConfig{{URL: "http://hook"}},
+								AllowOverride:  true,
+							},
+						},
+					},
+				},
+				mountPath: "/mount",
+			},
+			args: args{
+				inp: &PutInput{
+					RequestPath: "/test",
+					Filename:    "file",
+					ContentType: "content-type",
+				},
+			},
+			webhookManagerExecutePreActionHooksMockResult: webhookManagerExecutePreActionHooksMockResult{
+				res:   &webhook.PreActionResult{Allowed: true, Key: "/scanned/renamed", Path: "/scanned/renamed"},
+				times: 1,
+			},
+			s3ClientPutObjectMockResult: s3ClientPutObjectMockResult{
+				input2: &s3client.PutInput{
+					Key:         "/scanned/renamed",
+					ContentType: "content-type",
+				},
+				res: &s3client.ResultInfo{
+					Bucket:     "bucket",
+					Key:        "/scanned/renamed",
+					Region:     "region",
+					S3Endpoint: "s3endpoint",
+				},
+				times: 1,
+			},
+			webhookManagerManagePutHooksMockResult: webhookManagerManagePutHooksMockResult{
+				input2: "name",
+				input3: "/scanned/",
+				input4: &webhook.PutInputMetadata{
+					Filename:    "renamed",
+					ContentType: "content-type",
+				},
+				input5: &webhook.S3Metadata{
+					Bucket:     "bucket",
+					Key:        "/scanned/renamed",
+					Region:     "region",
+					S3Endpoint: "s3endpoint",
+				},
+				times: 1,
+			},
+			s3clManagerClientForTargetMockInput: "name",
+			responseHandlerPutMockResultTimes: responseHandlerPutMockResult{
+				input: &responsehandlermodels.PutInput{
+					Key:         "/scanned/renamed",
+					Filename:    "file",
+					ContentType: "content-type",
+				},
+				times: 1,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1582,6 +1710,10 @@ func Test_requestContext_Put(t *testing.T) {
 				Times(
 					tt.webhookManagerManagePutHooksMockResult.times,
 				)
+			webhookManagerMock.EXPECT().
+				ExecutePUTPreActionHooks(ctx, "name", gomock.Any(), gomock.Any()).
+				Return(tt.webhookManagerExecutePreActionHooksMockResult.res).
+				Times(tt.webhookManagerExecutePreActionHooksMockResult.times)
 
 			rctx := &bucketReqImpl{
 				s3ClientManager: s3clManagerMock,
@@ -2744,3 +2876,112 @@ func Test_requestContext_manageKeyRewrite(t *testing.T) {
 		})
 	}
 }
+
+func Test_requestContext_ResolveObject(t *testing.T) {
+	lastModified := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	targetCfg := &config.TargetConfig{
+		Name:   "tgt1",
+		Bucket: &config.BucketConfig{Name: "bucket1", Prefix: "data/"},
+		Actions: &config.ActionsConfig{
+			GET: &config.GetActionConfig{Config: &config.GetActionConfigConfig{UserIsolation: true}},
+		},
+		KeyRewriteList: []*config.TargetKeyRewriteConfig{{
+			SourceRegex: regexp.MustCompile(`^data/alice/old/(.*)$`),
+			Target:      "data/alice/new/$1",
+			TargetType:  config.RegexTargetKeyRewriteTargetType,
+		}},
+	}
+
+	tests := []struct {
+		s3ClientHeadObjectMockResult *s3client.HeadOutput
+		s3ClientHeadObjectMockErr    error
+		want                         *ObjectInfo
+		name                         string
+		requestPath                  string
+		withMetadata                 bool
+		wantErr                      bool
+		headObjectCalled             bool
+	}{
+		{
+			name:        "without metadata",
+			requestPath: "/old/file.txt",
+			want:        &ObjectInfo{Target: "tgt1", Bucket: "bucket1", Key: "data/alice/new/file.txt"},
+		},
+		{
+			name:         "folder with metadata",
+			requestPath:  "/folder/",
+			withMetadata: true,
+			want:         &ObjectInfo{Target: "tgt1", Bucket: "bucket1", Key: "data/alice/folder/"},
+		},
+		{
+			name:             "existing file with metadata",
+			requestPath:      "/file.txt",
+			withMetadata:     true,
+			headObjectCalled: true,
+			s3ClientHeadObjectMockResult: &s3client.HeadOutput{
+				BaseFileOutput: &s3client.BaseFileOutput{
+					LastModified:  lastModified,
+					Metadata:      map[string]string{"owner": "alice"},
+					ContentType:   "text/plain",
+					ContentLength: 42,
+				},
+				Key: "data/alice/file.txt",
+			},
+			want: &ObjectInfo{
+				Target:        "tgt1",
+				Bucket:        "bucket1",
+				Key:           "data/alice/file.txt",
+				LastModified:  &lastModified,
+				Metadata:      map[string]string{"owner": "alice"},
+				ContentType:   "text/plain",
+				ContentLength: 42,
+				Exists:        true,
+			},
+		},
+		{
+			name:                      "not found file with metadata",
+			requestPath:               "/file.txt",
+			withMetadata:              true,
+			headObjectCalled:          true,
+			s3ClientHeadObjectMockErr: s3client.ErrNotFound,
+			want:                      &ObjectInfo{Target: "tgt1", Bucket: "bucket1", Key: "data/alice/file.txt"},
+		},
+		{
+			name:                      "head error",
+			requestPath:               "/file.txt",
+			withMetadata:              true,
+			headObjectCalled:          true,
+			s3ClientHeadObjectMockErr: errors.New("fake"),
+			wantErr:                   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s3clManagerMock := s3clientmocks.NewMockManager(ctrl)
+			s3clMock := s3clientmocks.NewMockClient(ctrl)
+
+			if tt.headObjectCalled {
+				s3clManagerMock.EXPECT().GetClientForTarget("tgt1").Return(s3clMock)
+				s3clMock.EXPECT().
+					HeadObject(gomock.Any(), "data/alice/file.txt").
+					Return(tt.s3ClientHeadObjectMockResult, nil, tt.s3ClientHeadObjectMockErr)
+			}
+
+			ctx := log.SetLoggerInContext(context.TODO(), log.NewLogger())
+			ctx = models.SetAuthenticatedUserInContext(ctx, &models.BasicAuthUser{Username: "alice"})
+
+			bri := &bucketReqImpl{targetCfg: targetCfg, s3ClientManager: s3clManagerMock}
+			got, err := bri.ResolveObject(ctx, tt.requestPath, tt.withMetadata)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("bucketReqImpl.ResolveObject() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/limiter"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/quota"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
 )
@@ -20,6 +22,9 @@ var ErrRemovalFolder = errors.New("can't remove folder")
 // errUserIsolationForbidden will be raised when user isolation blocks access.
 var errUserIsolationForbidden = errors.New("user isolation: access denied")
 
+// errPreActionInvalidPath will be raised when a pre-action hook path contains dot segments.
+var errPreActionInvalidPath = errors.New("pre-action hook path can't contain dot segments")
+
 // Client represents a client in order to GET, PUT or DELETE file on a bucket with a html output.
 //
 //go:generate mockgen -destination=./mocks/mock_Client.go -package=mocks github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket Client
@@ -34,6 +39,21 @@ type Client interface {
 	Delete(ctx context.Context, requestPath string)
 	// Load file content. (Should be used internally only).
 	LoadFileContent(ctx context.Context, path string) (string, error)
+	// ResolveObject will return the bucket object targeted by a request path after user isolation and key rewrite.
+	// Object metadata are loaded from bucket only when asked.
+	ResolveObject(ctx context.Context, requestPath string, withMetadata bool) (*ObjectInfo, error)
+}
+
+// ObjectInfo represents the bucket object targeted by a request.
+type ObjectInfo struct {
+	LastModified  *time.Time
+	Metadata      map[string]string
+	Target        string
+	Bucket        string
+	Key           string
+	ContentType   string
+	ContentLength int64
+	Exists        bool
 }
 
 // GetInput represents Get input.
@@ -44,6 +64,7 @@ type GetInput struct {
 	IfNoneMatch       string
 	IfUnmodifiedSince *time.Time
 	Range             string
+	AcceptEncoding    string
 }
 
 // PutInput represents Put input.
@@ -69,11 +90,39 @@ func NewClient(
 	mountPath string,
 	s3clientManager s3client.Manager,
 	wbManager webhook.Manager,
+	quotaManager quota.Manager,
+	limiterManager limiter.Manager,
 ) Client {
-	return &bucketReqImpl{
-		s3ClientManager: s3clientManager,
-		targetCfg:       tgt,
-		mountPath:       mountPath,
-		webhookManager:  wbManager,
+	return newClient(tgt, mountPath, s3clientManager, wbManager, quotaManager, limiterManager, newPrecompressedCache())
+}
+
+// newClient will create a new client with a shared precompressed variants cache.
+func newClient(
+	tgt *config.TargetConfig,
+	mountPath string,
+	s3clientManager s3client.Manager,
+	wbManager webhook.Manager,
+	quotaManager quota.Manager,
+	limiterManager limiter.Manager,
+	pCache *precompressedCache,
+) Client {
+	var cl Client = &bucketReqImpl{
+		s3ClientManager:    s3clientManager,
+		targetCfg:          tgt,
+		mountPath:          mountPath,
+		webhookManager:     wbManager,
+		quotaManager:       quotaManager,
+		precompressedCache: pCache,
 	}
+
+	// Check if operations must be limited
+	if limiterManager != nil {
+		cl = &limitedClient{
+			Client:         cl,
+			limiterManager: limiterManager,
+			targetKey:      tgt.Name,
+		}
+	}
+
+	return cl
 }
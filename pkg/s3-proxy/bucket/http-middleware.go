@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/limiter"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/quota"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
 )
@@ -23,11 +25,16 @@ func HTTPMiddleware(
 	path string,
 	s3clientManager s3client.Manager,
 	wbManager webhook.Manager,
+	quotaManager quota.Manager,
+	limiterManager limiter.Manager,
 ) func(next http.Handler) http.Handler {
+	// Create precompressed variants cache shared by all requests of this target
+	pCache := newPrecompressedCache()
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 			// Generate new bucket client
-			brctx := NewClient(tgt, path, s3clientManager, wbManager)
+			brctx := newClient(tgt, path, s3clientManager, wbManager, quotaManager, limiterManager, pCache)
 			// Add bucket structure to request context by creating a new context
 			ctx := context.WithValue(req.Context(), bucketRequestContextKey, brctx)
 			// Create new request with new context
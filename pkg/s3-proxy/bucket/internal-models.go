@@ -13,3 +13,8 @@ type targetKeyRewriteData struct {
 	Target  *config.TargetConfig
 	Key     string
 }
+
+type userIsolationPathData struct {
+	User  models.GenericUser
+	Group string
+}
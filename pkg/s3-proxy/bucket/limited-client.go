@@ -0,0 +1,76 @@
+package bucket
+
+import (
+	"context"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/limiter"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+)
+
+// limitedClient wraps a bucket client in order to limit the number of in flight operations on target.
+type limitedClient struct {
+	Client
+	limiterManager limiter.Manager
+	targetKey      string
+}
+
+func (lc *limitedClient) Get(ctx context.Context, input *GetInput) {
+	// Acquire slot
+	release, ok := lc.acquire(ctx)
+	if !ok {
+		return
+	}
+	defer release()
+
+	lc.Client.Get(ctx, input)
+}
+
+func (lc *limitedClient) Head(ctx context.Context, input *GetInput) {
+	// Acquire slot
+	release, ok := lc.acquire(ctx)
+	if !ok {
+		return
+	}
+	defer release()
+
+	lc.Client.Head(ctx, input)
+}
+
+func (lc *limitedClient) Put(ctx context.Context, inp *PutInput) {
+	// Acquire slot
+	release, ok := lc.acquire(ctx)
+	if !ok {
+		return
+	}
+	defer release()
+
+	lc.Client.Put(ctx, inp)
+}
+
+func (lc *limitedClient) Delete(ctx context.Context, requestPath string) {
+	// Acquire slot
+	release, ok := lc.acquire(ctx)
+	if !ok {
+		return
+	}
+	defer release()
+
+	lc.Client.Delete(ctx, requestPath)
+}
+
+// acquire will wait for a free slot on target.
+// A service unavailable error is answered when no slot is available.
+func (lc *limitedClient) acquire(ctx context.Context) (func(), bool) {
+	release, err := lc.limiterManager.Acquire(ctx, lc.targetKey)
+	// Check error
+	if err != nil {
+		// Get response handler
+		resHan := responsehandler.GetResponseHandlerFromContext(ctx)
+		// Answer
+		resHan.ServiceUnavailableError(lc.LoadFileContent, err)
+
+		return nil, false
+	}
+
+	return release, true
+}
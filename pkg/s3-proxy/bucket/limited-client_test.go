@@ -0,0 +1,115 @@
+//go:build unit
+
+package bucket
+
+import (
+	"context"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/limiter"
+	lmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/limiter/mocks"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+	responsehandlermocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler/mocks"
+)
+
+// recorderClient records the calls done on a bucket client.
+type recorderClient struct {
+	Client
+	calls []string
+}
+
+func (rc *recorderClient) Get(_ context.Context, _ *GetInput) { rc.calls = append(rc.calls, "GET") }
+
+func (rc *recorderClient) Head(_ context.Context, _ *GetInput) { rc.calls = append(rc.calls, "HEAD") }
+
+func (rc *recorderClient) Put(_ context.Context, _ *PutInput) { rc.calls = append(rc.calls, "PUT") }
+
+func (rc *recorderClient) Delete(_ context.Context, _ string) { rc.calls = append(rc.calls, "DELETE") }
+
+func (*recorderClient) LoadFileContent(_ context.Context, _ string) (string, error) { return "", nil }
+
+func Test_limitedClient(t *testing.T) {
+	tests := []struct {
+		acquireErr error
+		call       func(ctx context.Context, cl Client)
+		name       string
+		wantCalls  []string
+	}{
+		{
+			name: "should call GET when a slot is available",
+			call: func(ctx context.Context, cl Client) {
+				cl.Get(ctx, &GetInput{RequestPath: "/file"})
+			},
+			wantCalls: []string{"GET"},
+		},
+		{
+			name: "should call HEAD when a slot is available",
+			call: func(ctx context.Context, cl Client) {
+				cl.Head(ctx, &GetInput{RequestPath: "/file"})
+			},
+			wantCalls: []string{"HEAD"},
+		},
+		{
+			name: "should call PUT when a slot is available",
+			call: func(ctx context.Context, cl Client) {
+				cl.Put(ctx, &PutInput{RequestPath: "/"})
+			},
+			wantCalls: []string{"PUT"},
+		},
+		{
+			name: "should call DELETE when a slot is available",
+			call: func(ctx context.Context, cl Client) {
+				cl.Delete(ctx, "/file")
+			},
+			wantCalls: []string{"DELETE"},
+		},
+		{
+			name:       "should answer service unavailable when queue is full",
+			acquireErr: errors.WithStack(limiter.ErrQueueFull),
+			call: func(ctx context.Context, cl Client) {
+				cl.Get(ctx, &GetInput{RequestPath: "/file"})
+			},
+		},
+		{
+			name:       "should answer service unavailable when queue timeout is reached",
+			acquireErr: errors.WithStack(limiter.ErrQueueTimeout),
+			call: func(ctx context.Context, cl Client) {
+				cl.Put(ctx, &PutInput{RequestPath: "/"})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			clMock := &recorderClient{}
+			limiterMock := lmocks.NewMockManager(ctrl)
+			resHanMock := responsehandlermocks.NewMockResponseHandler(ctrl)
+
+			ctx := responsehandler.SetResponseHandlerInContext(context.TODO(), resHanMock)
+
+			released := false
+
+			if tt.acquireErr != nil {
+				limiterMock.EXPECT().Acquire(gomock.Any(), "target1").Return(nil, tt.acquireErr)
+				resHanMock.EXPECT().ServiceUnavailableError(gomock.Any(), tt.acquireErr).Times(1)
+			} else {
+				limiterMock.EXPECT().Acquire(gomock.Any(), "target1").Return(func() { released = true }, nil)
+			}
+
+			cl := &limitedClient{
+				Client:         clMock,
+				limiterManager: limiterMock,
+				targetKey:      "target1",
+			}
+
+			tt.call(ctx, cl)
+
+			assert.Equal(t, tt.acquireErr == nil, released)
+			assert.Equal(t, tt.wantCalls, clMock.calls)
+		})
+	}
+}
@@ -103,3 +103,18 @@ func (mr *MockClientMockRecorder) Put(ctx, inp any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockClient)(nil).Put), ctx, inp)
 }
+
+// ResolveObject mocks base method.
+func (m *MockClient) ResolveObject(ctx context.Context, requestPath string, withMetadata bool) (*bucket.ObjectInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveObject", ctx, requestPath, withMetadata)
+	ret0, _ := ret[0].(*bucket.ObjectInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveObject indicates an expected call of ResolveObject.
+func (mr *MockClientMockRecorder) ResolveObject(ctx, requestPath, withMetadata any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveObject", reflect.TypeOf((*MockClient)(nil).ResolveObject), ctx, requestPath, withMetadata)
+}
@@ -0,0 +1,320 @@
+package bucket
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	responsehandlermodels "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+)
+
+// precompressedCacheMaxEntries is the maximum number of objects kept in a precompressed cache.
+const precompressedCacheMaxEntries = 10000
+
+// precompressedEntry stores the result of a precompressed variants lookup for an object.
+type precompressedEntry struct {
+	expiresAt   time.Time
+	encodings   map[string]bool
+	contentType string
+}
+
+// precompressedCache caches precompressed variants lookups for a target.
+type precompressedCache struct {
+	entries map[string]*precompressedEntry
+	mutex   sync.Mutex
+}
+
+func newPrecompressedCache() *precompressedCache {
+	return &precompressedCache{
+		entries: map[string]*precompressedEntry{},
+	}
+}
+
+func (pc *precompressedCache) get(key string, now time.Time) *precompressedEntry {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+
+	// Get entry
+	entry, ok := pc.entries[key]
+	// Check if entry exists and is still valid
+	if !ok || now.After(entry.expiresAt) {
+		return nil
+	}
+
+	return entry
+}
+
+func (pc *precompressedCache) set(key string, entry *precompressedEntry, now time.Time) {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+
+	// Check if cache is full
+	if len(pc.entries) >= precompressedCacheMaxEntries {
+		// Remove expired entries
+		for k, v := range pc.entries {
+			if now.After(v.expiresAt) {
+				delete(pc.entries, k)
+			}
+		}
+		// Check if it is still full
+		if len(pc.entries) >= precompressedCacheMaxEntries {
+			pc.entries = map[string]*precompressedEntry{}
+		}
+	}
+
+	pc.entries[key] = entry
+}
+
+func (pc *precompressedCache) delete(key string) {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+
+	delete(pc.entries, key)
+}
+
+// precompressedSelection is the result of the precompressed variant negotiation.
+type precompressedSelection struct {
+	// Object key to stream.
+	key string
+	// Selected content encoding. Empty when original object is selected.
+	encoding string
+	// Original object content type.
+	contentType string
+	// Response varies on Accept-Encoding when at least one variant exists.
+	vary bool
+}
+
+func (bri *bucketReqImpl) precompressedCfg() *config.PrecompressedConfig {
+	// Check if configuration exists
+	if bri.targetCfg.Actions == nil || bri.targetCfg.Actions.GET == nil ||
+		bri.targetCfg.Actions.GET.Config == nil || bri.targetCfg.Actions.GET.Config.Precompressed == nil ||
+		!bri.targetCfg.Actions.GET.Config.Precompressed.Enabled {
+		return nil
+	}
+
+	return bri.targetCfg.Actions.GET.Config.Precompressed
+}
+
+// selectPrecompressedVariant will select a precompressed variant of the object
+// if it exists and if the client accepts it.
+func (bri *bucketReqImpl) selectPrecompressedVariant(
+	ctx context.Context,
+	key string,
+	input *GetInput,
+) (*precompressedSelection, error) {
+	// Default selection is the object itself
+	res := &precompressedSelection{key: key}
+	// Get configuration
+	cfg := bri.precompressedCfg()
+	// Check if it is enabled
+	if cfg == nil || bri.precompressedCache == nil {
+		return res, nil
+	}
+
+	// Ignore objects that are already precompressed variants
+	for _, enc := range cfg.Encodings {
+		if strings.HasSuffix(key, config.PrecompressedEncodingExtensions[enc]) {
+			return res, nil
+		}
+	}
+
+	// Get available variants
+	entry, err := bri.getPrecompressedEntry(ctx, key, cfg)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if variants exist
+	if len(entry.encodings) == 0 {
+		return res, nil
+	}
+
+	res.vary = true
+
+	// Negotiate encoding
+	enc := negotiatePrecompressedEncoding(input.AcceptEncoding, cfg.Encodings, entry.encodings)
+	// Check if encoding was found
+	if enc != "" {
+		res.key = key + config.PrecompressedEncodingExtensions[enc]
+		res.encoding = enc
+		res.contentType = entry.contentType
+	}
+
+	return res, nil
+}
+
+// invalidatePrecompressedEntry will forget cached variants lookup of an object
+// when this object or one of its variants is modified or removed.
+func (bri *bucketReqImpl) invalidatePrecompressedEntry(key string) {
+	// Check if cache exists
+	if bri.precompressedCache == nil {
+		return
+	}
+
+	// Remove object entry
+	bri.precompressedCache.delete(key)
+
+	// Remove original object entry when key is a variant
+	for _, ext := range config.PrecompressedEncodingExtensions {
+		if base, ok := strings.CutSuffix(key, ext); ok {
+			bri.precompressedCache.delete(base)
+		}
+	}
+}
+
+// getPrecompressedEntry will get available variants for an object from cache or from bucket.
+func (bri *bucketReqImpl) getPrecompressedEntry(
+	ctx context.Context,
+	key string,
+	cfg *config.PrecompressedConfig,
+) (*precompressedEntry, error) {
+	now := time.Now()
+	// Check cache
+	entry := bri.precompressedCache.get(key, now)
+	if entry != nil {
+		return entry, nil
+	}
+
+	// Get S3 client
+	s3cl := bri.s3ClientManager.GetClientForTarget(bri.targetCfg.Name)
+
+	entry = &precompressedEntry{
+		expiresAt: now.Add(cfg.CacheExpiration),
+		encodings: map[string]bool{},
+	}
+
+	// Loop over encodings
+	for _, enc := range cfg.Encodings {
+		// Head variant
+		headOutput, _, err := s3cl.HeadObject(ctx, key+config.PrecompressedEncodingExtensions[enc])
+		// Check error
+		if err != nil && !errors.Is(err, s3client.ErrNotFound) {
+			return nil, err
+		}
+		// Check if variant exists
+		if headOutput != nil {
+			entry.encodings[enc] = true
+		}
+	}
+
+	// Check if at least one variant exists
+	if len(entry.encodings) != 0 {
+		// Head original object to get its content type
+		headOutput, _, err := s3cl.HeadObject(ctx, key)
+		// Check error
+		if err != nil && !errors.Is(err, s3client.ErrNotFound) {
+			return nil, err
+		}
+		// Check if original object exists
+		// If not, variants are ignored to answer as usual
+		if headOutput == nil {
+			entry.encodings = map[string]bool{}
+		} else {
+			entry.contentType = headOutput.ContentType
+		}
+	}
+
+	// Save in cache
+	bri.precompressedCache.set(key, entry, now)
+
+	return entry, nil
+}
+
+// applyPrecompressedSelection will set response headers of a precompressed variant selection.
+func applyPrecompressedSelection(inp *responsehandlermodels.StreamInput, variant *precompressedSelection) {
+	// Check if response depends on client accepted encodings
+	if variant.vary {
+		inp.Vary = "Accept-Encoding"
+	}
+	// Check if a precompressed variant is answered
+	if variant.encoding != "" {
+		inp.ContentEncoding = variant.encoding
+		inp.ContentType = variant.contentType
+	}
+}
+
+// negotiatePrecompressedEncoding will select the available encoding with the highest quality
+// accepted by the client. Ties are broken by configured encodings order.
+func negotiatePrecompressedEncoding(acceptEncoding string, encodings []string, available map[string]bool) string {
+	// Parse header
+	qualities := parseAcceptEncoding(acceptEncoding)
+
+	var (
+		bestEnc string
+		bestQ   float64
+	)
+
+	for _, enc := range encodings {
+		// Check if variant exists
+		if !available[enc] {
+			continue
+		}
+
+		// Get quality for encoding
+		q, ok := qualities[enc]
+		// Manage legacy alias
+		if !ok && enc == config.PrecompressedEncodingGzip {
+			q, ok = qualities["x-gzip"]
+		}
+		// Manage wildcard
+		if !ok {
+			q = qualities["*"]
+		}
+
+		// Check if it is better
+		if q > bestQ {
+			bestEnc = enc
+			bestQ = q
+		}
+	}
+
+	return bestEnc
+}
+
+// parseAcceptEncoding will parse an Accept-Encoding header into a map of coding to quality.
+func parseAcceptEncoding(header string) map[string]float64 {
+	res := map[string]float64{}
+
+	for part := range strings.SplitSeq(header, ",") {
+		// Split coding and parameters
+		coding, params, _ := strings.Cut(part, ";")
+		// Clean coding
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		// Ignore empty values
+		if coding == "" {
+			continue
+		}
+
+		// Default quality
+		q := 1.0
+
+		// Loop over parameters
+		for param := range strings.SplitSeq(params, ";") {
+			k, v, found := strings.Cut(strings.TrimSpace(param), "=")
+			// Check if it is quality parameter
+			if !found || strings.ToLower(strings.TrimSpace(k)) != "q" {
+				continue
+			}
+
+			// Parse quality
+			pq, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			// Invalid values are considered as refused
+			if err != nil {
+				pq = 0
+			}
+
+			q = pq
+		}
+
+		res[coding] = q
+	}
+
+	return res
+}
@@ -0,0 +1,472 @@
+//go:build unit
+
+package bucket
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+	responsehandlermocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler/mocks"
+	responsehandlermodels "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	s3clientmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client/mocks"
+	wmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook/mocks"
+)
+
+func Test_parseAcceptEncoding(t *testing.T) {
+	tests := []struct {
+		want   map[string]float64
+		name   string
+		header string
+	}{
+		{name: "empty", header: "", want: map[string]float64{}},
+		{name: "simple list", header: "gzip, br", want: map[string]float64{"gzip": 1, "br": 1}},
+		{
+			name:   "with qualities",
+			header: "br;q=0.8, GZIP;q=1.0, identity; q=0, *;q=0.1",
+			want:   map[string]float64{"br": 0.8, "gzip": 1, "identity": 0, "*": 0.1},
+		},
+		{name: "invalid quality", header: "br;q=abc", want: map[string]float64{"br": 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseAcceptEncoding(tt.header))
+		})
+	}
+}
+
+func Test_negotiatePrecompressedEncoding(t *testing.T) {
+	all := map[string]bool{"br": true, "gzip": true}
+
+	tests := []struct {
+		available      map[string]bool
+		name           string
+		acceptEncoding string
+		encodings      []string
+		want           string
+	}{
+		{name: "no accept encoding", encodings: []string{"br", "gzip"}, available: all},
+		{name: "both accepted", acceptEncoding: "gzip, br", encodings: []string{"br", "gzip"}, available: all, want: "br"},
+		{name: "configured order", acceptEncoding: "gzip, br", encodings: []string{"gzip", "br"}, available: all, want: "gzip"},
+		{name: "higher quality wins", acceptEncoding: "br;q=0.5, gzip", encodings: []string{"br", "gzip"}, available: all, want: "gzip"},
+		{name: "refused encoding", acceptEncoding: "br;q=0", encodings: []string{"br", "gzip"}, available: all},
+		{name: "wildcard", acceptEncoding: "*", encodings: []string{"br", "gzip"}, available: all, want: "br"},
+		{name: "wildcard with refused", acceptEncoding: "*, br;q=0", encodings: []string{"br", "gzip"}, available: all, want: "gzip"},
+		{name: "legacy alias", acceptEncoding: "x-gzip", encodings: []string{"br", "gzip"}, available: all, want: "gzip"},
+		{
+			name:           "only available variants",
+			acceptEncoding: "br, gzip",
+			encodings:      []string{"br", "gzip"},
+			available:      map[string]bool{"gzip": true},
+			want:           "gzip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, negotiatePrecompressedEncoding(tt.acceptEncoding, tt.encodings, tt.available))
+		})
+	}
+}
+
+func Test_precompressedCache(t *testing.T) {
+	pc := newPrecompressedCache()
+	now := time.Now()
+
+	assert.Nil(t, pc.get("key", now))
+
+	entry := &precompressedEntry{expiresAt: now.Add(time.Minute)}
+	pc.set("key", entry, now)
+
+	assert.Same(t, entry, pc.get("key", now))
+	// Expired entry
+	assert.Nil(t, pc.get("key", now.Add(2*time.Minute)))
+}
+
+func Test_bucketReqImpl_invalidatePrecompressedEntry(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		key      string
+		wantKeys []string
+	}{
+		{name: "original object", key: "app.js", wantKeys: []string{"other.js"}},
+		{name: "brotli variant", key: "app.js.br", wantKeys: []string{"other.js"}},
+		{name: "gzip variant", key: "app.js.gz", wantKeys: []string{"other.js"}},
+		{name: "other object", key: "unknown.js", wantKeys: []string{"app.js", "other.js"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bri := &bucketReqImpl{precompressedCache: newPrecompressedCache()}
+			bri.precompressedCache.set("app.js", &precompressedEntry{expiresAt: now.Add(time.Minute)}, now)
+			bri.precompressedCache.set("other.js", &precompressedEntry{expiresAt: now.Add(time.Minute)}, now)
+
+			bri.invalidatePrecompressedEntry(tt.key)
+
+			keys := []string{}
+
+			for _, k := range []string{"app.js", "other.js"} {
+				if bri.precompressedCache.get(k, now) != nil {
+					keys = append(keys, k)
+				}
+			}
+
+			assert.Equal(t, tt.wantKeys, keys)
+		})
+	}
+}
+
+func Test_bucketReqImpl_selectPrecompressedVariant(t *testing.T) {
+	newTargetCfg := func(pcfg *config.PrecompressedConfig) *config.TargetConfig {
+		return &config.TargetConfig{
+			Name:   "target",
+			Bucket: &config.BucketConfig{Name: "bucket"},
+			Actions: &config.ActionsConfig{
+				GET: &config.GetActionConfig{
+					Enabled: true,
+					Config:  &config.GetActionConfigConfig{Precompressed: pcfg},
+				},
+			},
+		}
+	}
+	enabledCfg := &config.PrecompressedConfig{
+		Enabled:         true,
+		Encodings:       []string{"br", "gzip"},
+		CacheExpiration: time.Minute,
+	}
+	found := &s3client.HeadOutput{BaseFileOutput: &s3client.BaseFileOutput{ContentType: "application/javascript"}}
+
+	tests := []struct {
+		headResults map[string]*s3client.HeadOutput
+		headErr     error
+		targetCfg   *config.TargetConfig
+		want        *precompressedSelection
+		name        string
+		key         string
+		accept      string
+		wantErr     bool
+	}{
+		{
+			name:      "should keep object when disabled",
+			targetCfg: newTargetCfg(nil),
+			key:       "app.js",
+			accept:    "br",
+			want:      &precompressedSelection{key: "app.js"},
+		},
+		{
+			name:      "should keep object when it is already a variant",
+			targetCfg: newTargetCfg(enabledCfg),
+			key:       "app.js.gz",
+			accept:    "br",
+			want:      &precompressedSelection{key: "app.js.gz"},
+		},
+		{
+			name:        "should keep object when no variant exists",
+			targetCfg:   newTargetCfg(enabledCfg),
+			key:         "app.js",
+			accept:      "br, gzip",
+			headResults: map[string]*s3client.HeadOutput{},
+			want:        &precompressedSelection{key: "app.js"},
+		},
+		{
+			name:        "should select brotli variant",
+			targetCfg:   newTargetCfg(enabledCfg),
+			key:         "app.js",
+			accept:      "gzip, br",
+			headResults: map[string]*s3client.HeadOutput{"app.js.br": found, "app.js.gz": found, "app.js": found},
+			want: &precompressedSelection{
+				key:         "app.js.br",
+				encoding:    "br",
+				contentType: "application/javascript",
+				vary:        true,
+			},
+		},
+		{
+			name:        "should select gzip variant",
+			targetCfg:   newTargetCfg(enabledCfg),
+			key:         "app.js",
+			accept:      "gzip",
+			headResults: map[string]*s3client.HeadOutput{"app.js.br": found, "app.js.gz": found, "app.js": found},
+			want: &precompressedSelection{
+				key:         "app.js.gz",
+				encoding:    "gzip",
+				contentType: "application/javascript",
+				vary:        true,
+			},
+		},
+		{
+			name:        "should keep object and vary when client doesn't accept variants",
+			targetCfg:   newTargetCfg(enabledCfg),
+			key:         "app.js",
+			accept:      "",
+			headResults: map[string]*s3client.HeadOutput{"app.js.br": found, "app.js": found},
+			want:        &precompressedSelection{key: "app.js", vary: true},
+		},
+		{
+			name:        "should ignore variants when original object doesn't exist",
+			targetCfg:   newTargetCfg(enabledCfg),
+			key:         "app.js",
+			accept:      "br",
+			headResults: map[string]*s3client.HeadOutput{"app.js.br": found},
+			want:        &precompressedSelection{key: "app.js"},
+		},
+		{
+			name:      "should fail when head fails",
+			targetCfg: newTargetCfg(enabledCfg),
+			key:       "app.js",
+			accept:    "br",
+			headErr:   errors.New("fake"),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			s3ClientMock := s3clientmocks.NewMockClient(ctrl)
+			s3clManagerMock := s3clientmocks.NewMockManager(ctrl)
+			s3clManagerMock.EXPECT().GetClientForTarget("target").AnyTimes().Return(s3ClientMock)
+
+			s3ClientMock.EXPECT().
+				HeadObject(gomock.Any(), gomock.Any()).
+				AnyTimes().
+				DoAndReturn(func(_ context.Context, key string) (*s3client.HeadOutput, *s3client.ResultInfo, error) {
+					if tt.headErr != nil {
+						return nil, nil, tt.headErr
+					}
+
+					res, ok := tt.headResults[key]
+					if !ok {
+						return nil, nil, s3client.ErrNotFound
+					}
+
+					return res, nil, nil
+				})
+
+			bri := &bucketReqImpl{
+				s3ClientManager:    s3clManagerMock,
+				targetCfg:          tt.targetCfg,
+				precompressedCache: newPrecompressedCache(),
+			}
+
+			got, err := bri.selectPrecompressedVariant(context.TODO(), tt.key, &GetInput{AcceptEncoding: tt.accept})
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_bucketReqImpl_selectPrecompressedVariant_Cached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s3ClientMock := s3clientmocks.NewMockClient(ctrl)
+	s3clManagerMock := s3clientmocks.NewMockManager(ctrl)
+	s3clManagerMock.EXPECT().GetClientForTarget("target").AnyTimes().Return(s3ClientMock)
+
+	s3ClientMock.EXPECT().HeadObject(gomock.Any(), "app.js.br").Times(1).Return(nil, nil, s3client.ErrNotFound)
+	s3ClientMock.EXPECT().
+		HeadObject(gomock.Any(), "app.js.gz").
+		Times(1).
+		Return(&s3client.HeadOutput{BaseFileOutput: &s3client.BaseFileOutput{}}, nil, nil)
+	s3ClientMock.EXPECT().
+		HeadObject(gomock.Any(), "app.js").
+		Times(1).
+		Return(&s3client.HeadOutput{BaseFileOutput: &s3client.BaseFileOutput{ContentType: "text/javascript"}}, nil, nil)
+
+	bri := &bucketReqImpl{
+		s3ClientManager: s3clManagerMock,
+		targetCfg: &config.TargetConfig{
+			Name: "target",
+			Actions: &config.ActionsConfig{
+				GET: &config.GetActionConfig{
+					Enabled: true,
+					Config: &config.GetActionConfigConfig{
+						Precompressed: &config.PrecompressedConfig{
+							Enabled:         true,
+							Encodings:       []string{"br", "gzip"},
+							CacheExpiration: time.Minute,
+						},
+					},
+				},
+			},
+		},
+		precompressedCache: newPrecompressedCache(),
+	}
+
+	want := &precompressedSelection{key: "app.js.gz", encoding: "gzip", contentType: "text/javascript", vary: true}
+
+	// Second call must use cache
+	for range 2 {
+		got, err := bri.selectPrecompressedVariant(context.TODO(), "app.js", &GetInput{AcceptEncoding: "gzip, br"})
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+}
+
+// newPrecompressedTestBucketReqImpl returns a bucket request with a cached gzip variant for app.js.
+func newPrecompressedTestBucketReqImpl(ctrl *gomock.Controller) (*bucketReqImpl, *s3clientmocks.MockClient) {
+	s3ClientMock := s3clientmocks.NewMockClient(ctrl)
+	s3clManagerMock := s3clientmocks.NewMockManager(ctrl)
+	s3clManagerMock.EXPECT().GetClientForTarget("target").AnyTimes().Return(s3ClientMock)
+
+	webhookManagerMock := wmocks.NewMockManager(ctrl)
+	webhookManagerMock.EXPECT().ManageGETHooks(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	webhookManagerMock.EXPECT().ManageHEADHooks(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	bri := &bucketReqImpl{
+		s3ClientManager: s3clManagerMock,
+		webhookManager:  webhookManagerMock,
+		targetCfg: &config.TargetConfig{
+			Name: "target",
+			Actions: &config.ActionsConfig{
+				GET: &config.GetActionConfig{
+					Enabled: true,
+					Config: &config.GetActionConfigConfig{
+						Precompressed: &config.PrecompressedConfig{
+							Enabled:         true,
+							Encodings:       []string{"gzip"},
+							CacheExpiration: time.Minute,
+						},
+					},
+				},
+			},
+		},
+		precompressedCache: newPrecompressedCache(),
+	}
+
+	now := time.Now()
+	bri.precompressedCache.set("app.js", &precompressedEntry{
+		expiresAt:   now.Add(time.Minute),
+		encodings:   map[string]bool{"gzip": true},
+		contentType: "text/javascript",
+	}, now)
+
+	return bri, s3ClientMock
+}
+
+func Test_bucketReqImpl_streamFileForResponse_removedVariant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	bri, s3ClientMock := newPrecompressedTestBucketReqImpl(ctrl)
+
+	s3ClientMock.EXPECT().
+		GetObject(gomock.Any(), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(_ context.Context, input *s3client.GetInput) (*s3client.GetOutput, *s3client.ResultInfo, error) {
+			// Variant has been removed since lookup
+			if input.Key != "app.js" {
+				return nil, nil, s3client.ErrNotFound
+			}
+
+			return &s3client.GetOutput{
+				BaseFileOutput: &s3client.BaseFileOutput{ContentType: "text/javascript", ContentLength: 8},
+				Body:           io.NopCloser(strings.NewReader("content!")),
+			}, &s3client.ResultInfo{Key: "app.js"}, nil
+		})
+
+	resHanMock := responsehandlermocks.NewMockResponseHandler(ctrl)
+	resHanMock.EXPECT().StreamFile(gomock.Any(), &responsehandlermodels.StreamInput{
+		Body:          io.NopCloser(strings.NewReader("content!")),
+		ContentType:   "text/javascript",
+		ContentLength: 8,
+		Vary:          "Accept-Encoding",
+	}).Return(nil)
+
+	ctx := responsehandler.SetResponseHandlerInContext(context.TODO(), resHanMock)
+
+	err := bri.streamFileForResponse(ctx, "app.js", &GetInput{AcceptEncoding: "gzip"})
+	require.NoError(t, err)
+
+	// Lookup must be forgotten
+	assert.Nil(t, bri.precompressedCache.get("app.js", time.Now()))
+}
+
+func Test_bucketReqImpl_answerHead_precompressed(t *testing.T) {
+	original := &s3client.HeadOutput{
+		BaseFileOutput: &s3client.BaseFileOutput{ContentType: "text/javascript", ContentLength: 100},
+		Key:            "app.js",
+	}
+
+	tests := []struct {
+		variant   *s3client.HeadOutput
+		want      *responsehandlermodels.StreamInput
+		name      string
+		accept    string
+		wantCache bool
+	}{
+		{
+			name:   "variant selected",
+			accept: "gzip",
+			variant: &s3client.HeadOutput{
+				BaseFileOutput: &s3client.BaseFileOutput{ContentType: "application/gzip", ContentLength: 20},
+				Key:            "app.js.gz",
+			},
+			want: &responsehandlermodels.StreamInput{
+				ContentType:     "text/javascript",
+				ContentEncoding: "gzip",
+				ContentLength:   20,
+				Vary:            "Accept-Encoding",
+			},
+			wantCache: true,
+		},
+		{
+			name:   "variant not accepted",
+			accept: "br",
+			want: &responsehandlermodels.StreamInput{
+				ContentType:   "text/javascript",
+				ContentLength: 100,
+				Vary:          "Accept-Encoding",
+			},
+			wantCache: true,
+		},
+		{
+			name:   "variant removed",
+			accept: "gzip",
+			want: &responsehandlermodels.StreamInput{
+				ContentType:   "text/javascript",
+				ContentLength: 100,
+				Vary:          "Accept-Encoding",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bri, s3ClientMock := newPrecompressedTestBucketReqImpl(ctrl)
+
+			s3ClientMock.EXPECT().
+				HeadObject(gomock.Any(), "app.js.gz").
+				AnyTimes().
+				DoAndReturn(func(_ context.Context, _ string) (*s3client.HeadOutput, *s3client.ResultInfo, error) {
+					if tt.variant == nil {
+						return nil, nil, s3client.ErrNotFound
+					}
+
+					return tt.variant, &s3client.ResultInfo{Key: "app.js.gz"}, nil
+				})
+
+			resHanMock := responsehandlermocks.NewMockResponseHandler(ctrl)
+			resHanMock.EXPECT().StreamFile(gomock.Any(), tt.want).Return(nil)
+
+			ctx := responsehandler.SetResponseHandlerInContext(context.TODO(), resHanMock)
+
+			err := bri.answerHead(ctx, &GetInput{AcceptEncoding: tt.accept}, original, &s3client.ResultInfo{Key: "app.js"})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantCache, bri.precompressedCache.get("app.js", time.Now()) != nil)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package bucket
+
+import (
+	"context"
+	"strings"
+
+	"emperror.dev/errors"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/quota"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+	responsehandlermodels "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+)
+
+// quotaScope returns the user folder (without trailing slash) and the quota
+// limit to enforce on the current request. The last value is false when no
+// quota is tracked for this request: quota not configured, isolation disabled
+// or admin user (admins aren't confined to a folder so there is nothing to
+// account). Group spaces aren't accounted: only the user folder is.
+func (bri *bucketReqImpl) quotaScope(ctx context.Context) (string, *config.QuotaLimitConfig, bool) {
+	cfg := bri.userIsolationCfg()
+	if cfg == nil || !cfg.UserIsolation || cfg.UserIsolationQuota == nil {
+		return "", nil, false
+	}
+
+	user := models.GetAuthenticatedUserFromContext(ctx)
+	if user == nil {
+		return "", nil, false
+	}
+
+	identifier := user.GetIdentifier()
+	if bri.isUserIsolationAdmin(identifier) {
+		return "", nil, false
+	}
+
+	// Get user folder
+	folder, err := bri.userIsolationUserFolder(user)
+	// Check error
+	// Invalid folders are already answered when the start key is generated
+	if err != nil {
+		return "", nil, false
+	}
+
+	return strings.TrimSuffix(folder, "/"), cfg.UserIsolationQuota.ResolveLimit(identifier, user.GetGroups()), true
+}
+
+// quotaScopeForKey returns the quota scope of the current request only when
+// key is inside the user folder. Keys in group spaces aren't accounted.
+func (bri *bucketReqImpl) quotaScopeForKey(ctx context.Context, key string) (string, *config.QuotaLimitConfig, bool) {
+	folder, limit, ok := bri.quotaScope(ctx)
+	if !ok {
+		return "", nil, false
+	}
+
+	// Check if key is inside user folder
+	if !strings.HasPrefix(key, bri.targetCfg.Bucket.GetRootPrefix()+folder+"/") {
+		return "", nil, false
+	}
+
+	return folder, limit, true
+}
+
+// exposeQuotaUsage saves the current quota usage in the response handler so
+// templates can display it. Nothing is done when no quota is tracked.
+func (bri *bucketReqImpl) exposeQuotaUsage(ctx context.Context, resHan responsehandler.ResponseHandler) error {
+	folder, limit, ok := bri.quotaScope(ctx)
+	if !ok {
+		return nil
+	}
+
+	usage, err := bri.quotaManager.GetUsage(ctx, bri.targetCfg.Name, folder)
+	if err != nil {
+		return err
+	}
+
+	resHan.SetQuotaUsage(newQuotaUsage(usage, limit))
+
+	return nil
+}
+
+// reserveQuota reserves the space needed by a PUT on key before the upload.
+// An existing object on the same key is replaced, so only the size
+// difference is accounted in that case. It returns a rollback function to
+// call if the upload fails, and true when it wrote an error response
+// (caller should return early).
+func (bri *bucketReqImpl) reserveQuota(
+	ctx context.Context,
+	resHan responsehandler.ResponseHandler,
+	key string,
+	contentSize int64,
+) (func(), bool) {
+	folder, limit, ok := bri.quotaScopeForKey(ctx, key)
+	if !ok {
+		return func() {}, false
+	}
+
+	// Head object to know if it will be replaced
+	headOutput, _, err := bri.s3ClientManager.
+		GetClientForTarget(bri.targetCfg.Name).
+		HeadObject(ctx, key)
+	// Check if error is not found if exists
+	if err != nil && !errors.Is(err, s3client.ErrNotFound) {
+		resHan.InternalServerError(bri.LoadFileContent, err)
+
+		return nil, true
+	}
+
+	// Compute deltas
+	deltaBytes, deltaObjects := contentSize, int64(1)
+	// Check if object will be replaced
+	if headOutput != nil {
+		deltaBytes -= headOutput.ContentLength
+		deltaObjects = 0
+	}
+
+	// Reserve
+	usage, err := bri.quotaManager.Reserve(ctx, bri.targetCfg.Name, folder, limit, deltaBytes, deltaObjects)
+	// Save usage for templates
+	if usage != nil {
+		resHan.SetQuotaUsage(newQuotaUsage(usage, limit))
+	}
+	// Check error
+	if err != nil {
+		if errors.Is(err, quota.ErrQuotaExceeded) {
+			resHan.QuotaExceededError(bri.LoadFileContent, err)
+		} else {
+			resHan.InternalServerError(bri.LoadFileContent, err)
+		}
+
+		return nil, true
+	}
+
+	return func() {
+		bri.updateQuota(resHan, folder, limit, -deltaBytes, -deltaObjects)
+	}, false
+}
+
+// prepareQuotaRelease looks up the size of the object on key before a
+// DELETE. It returns the function to call once the object is deleted, and
+// true when it wrote an error response (caller should return early).
+func (bri *bucketReqImpl) prepareQuotaRelease(
+	ctx context.Context,
+	resHan responsehandler.ResponseHandler,
+	key string,
+) (func(), bool) {
+	folder, limit, ok := bri.quotaScopeForKey(ctx, key)
+	if !ok {
+		return func() {}, false
+	}
+
+	// Head object to know its size
+	headOutput, _, err := bri.s3ClientManager.
+		GetClientForTarget(bri.targetCfg.Name).
+		HeadObject(ctx, key)
+	// Check if object doesn't exist: nothing to release
+	if errors.Is(err, s3client.ErrNotFound) {
+		return func() {}, false
+	}
+	// Check error
+	if err != nil {
+		resHan.InternalServerError(bri.LoadFileContent, err)
+
+		return nil, true
+	}
+
+	return func() {
+		bri.updateQuota(resHan, folder, limit, -headOutput.ContentLength, -1)
+	}, false
+}
+
+func (bri *bucketReqImpl) updateQuota(
+	resHan responsehandler.ResponseHandler,
+	folder string,
+	limit *config.QuotaLimitConfig,
+	deltaBytes, deltaObjects int64,
+) {
+	usage := bri.quotaManager.Update(bri.targetCfg.Name, folder, deltaBytes, deltaObjects)
+	// Save usage for templates if tracked
+	if usage != nil {
+		resHan.SetQuotaUsage(newQuotaUsage(usage, limit))
+	}
+}
+
+func newQuotaUsage(usage *quota.Usage, limit *config.QuotaLimitConfig) *responsehandlermodels.QuotaUsage {
+	res := &responsehandlermodels.QuotaUsage{
+		UsedBytes:   usage.Bytes,
+		UsedObjects: usage.Objects,
+	}
+
+	if limit != nil {
+		res.MaxBytes = limit.MaxBytes
+		res.MaxObjects = limit.MaxObjects
+	}
+
+	return res
+}
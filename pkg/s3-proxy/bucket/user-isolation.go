@@ -0,0 +1,281 @@
+package bucket
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"emperror.dev/errors"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	utils "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/utils/generalutils"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/utils/templateutils"
+)
+
+// errUserIsolationVirtualFolder will be raised when a key is a folder containing
+// user isolation spaces without being inside one of them. Only listing is allowed
+// on those folders and it is answered without any bucket request.
+var errUserIsolationVirtualFolder = errors.New("user isolation: virtual folder")
+
+// isUserIsolationSpacesEnabled checks if group spaces are configured on the GET action.
+// In this case, users can access multiple folders (their own one and group ones) and
+// request paths aren't rewritten anymore: they must be inside one of those folders.
+func (bri *bucketReqImpl) isUserIsolationSpacesEnabled() bool {
+	cfg := bri.userIsolationCfg()
+
+	return cfg != nil && cfg.UserIsolation && cfg.UserIsolationGroupPathTemplate != ""
+}
+
+// userIsolationUserFolder returns the folder of the user, relative to the bucket
+// root prefix and with a trailing slash. It is rendered with userIsolationPathTemplate
+// when declared, otherwise it is the user identifier.
+// Identifiers must be exactly one folder segment to avoid reaching other user or group folders.
+func (bri *bucketReqImpl) userIsolationUserFolder(user models.GenericUser) (string, error) {
+	cfg := bri.userIsolationCfg()
+
+	// Check identifier
+	err := checkUserIsolationValue(user.GetIdentifier())
+	// Check error
+	if err != nil {
+		return "", err
+	}
+
+	// Check if template is declared
+	if cfg == nil || cfg.UserIsolationPathTemplate == "" {
+		return cleanUserIsolationFolder(user.GetIdentifier())
+	}
+
+	return renderUserIsolationFolder(cfg.UserIsolationPathTemplate, &userIsolationPathData{User: user})
+}
+
+// userIsolationSpaces returns all folders, relative to the bucket root prefix, that
+// the user can access when group spaces are enabled: the user folder first, then a
+// folder for each user group (restricted to userIsolationGroups if declared).
+// Groups that aren't exactly one folder segment and group folders that can't be rendered
+// as a valid folder are ignored.
+func (bri *bucketReqImpl) userIsolationSpaces(user models.GenericUser) ([]string, error) {
+	cfg := bri.userIsolationCfg()
+
+	// Get user folder
+	userFolder, err := bri.userIsolationUserFolder(user)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{userFolder}
+
+	for _, group := range user.GetGroups() {
+		// Check if group is allowed to have a space
+		if len(cfg.UserIsolationGroups) != 0 && !slices.Contains(cfg.UserIsolationGroups, group) {
+			continue
+		}
+
+		// Check that group is one folder segment (nested group names would reach other group folders)
+		if checkUserIsolationValue(group) != nil {
+			continue
+		}
+
+		// Render group folder
+		folder, err := renderUserIsolationFolder(
+			cfg.UserIsolationGroupPathTemplate,
+			&userIsolationPathData{User: user, Group: group},
+		)
+		// Check error
+		if err != nil {
+			// Check if it is an invalid folder
+			if errors.Is(err, errUserIsolationForbidden) {
+				// Ignore this group
+				continue
+			}
+
+			return nil, err
+		}
+
+		// Avoid duplicates
+		if !slices.Contains(res, folder) {
+			res = append(res, folder)
+		}
+	}
+
+	return res, nil
+}
+
+// checkUserIsolationSpaces ensures that a key is inside one of the user spaces when
+// group spaces are enabled. errUserIsolationVirtualFolder is returned for folders
+// containing spaces and errUserIsolationForbidden for all other keys outside spaces.
+// Nothing is checked for admins or when group spaces are disabled.
+func (bri *bucketReqImpl) checkUserIsolationSpaces(ctx context.Context, key string) error {
+	// Check if spaces are enabled
+	if !bri.isUserIsolationSpacesEnabled() {
+		return nil
+	}
+
+	user := models.GetAuthenticatedUserFromContext(ctx)
+	if user == nil {
+		return errUserIsolationForbidden
+	}
+
+	// Check if user is an admin
+	if bri.isUserIsolationAdmin(user.GetIdentifier()) {
+		return nil
+	}
+
+	// Get spaces
+	spaces, err := bri.userIsolationSpaces(user)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Get key relative to bucket root prefix
+	rel := strings.TrimPrefix(key, bri.targetCfg.Bucket.GetRootPrefix())
+
+	// Check if key is inside a space
+	for _, space := range spaces {
+		if strings.HasPrefix(rel, space) {
+			return nil
+		}
+	}
+
+	// Check if key is a folder containing a space
+	if rel == "" || strings.HasSuffix(rel, "/") {
+		for _, space := range spaces {
+			if strings.HasPrefix(space, rel) {
+				return errUserIsolationVirtualFolder
+			}
+		}
+	}
+
+	return errUserIsolationForbidden
+}
+
+// manageUserIsolationVirtualFolder answers the listing of a folder containing user
+// spaces. Only the next folder level leading to user spaces is listed, without any
+// request to the bucket.
+func (bri *bucketReqImpl) manageUserIsolationVirtualFolder(ctx context.Context, key string) {
+	// Get response handler
+	resHan := responsehandler.GetResponseHandlerFromContext(ctx)
+
+	user := models.GetAuthenticatedUserFromContext(ctx)
+	if user == nil {
+		bri.respondToUserIsolationError(ctx, resHan, errUserIsolationForbidden)
+
+		return
+	}
+
+	// Get spaces
+	spaces, err := bri.userIsolationSpaces(user)
+	if bri.respondToUserIsolationError(ctx, resHan, err) {
+		return
+	}
+
+	// Get bucket root prefix
+	rootPrefix := bri.targetCfg.Bucket.GetRootPrefix()
+	// Get key relative to bucket root prefix
+	rel := strings.TrimPrefix(key, rootPrefix)
+
+	// Build folder list
+	names := make([]string, 0, len(spaces))
+
+	for _, space := range spaces {
+		// Check if space is inside this folder
+		if !strings.HasPrefix(space, rel) {
+			continue
+		}
+
+		// Get next folder name
+		name, _, _ := strings.Cut(strings.TrimPrefix(space, rel), "/")
+		// Avoid duplicates
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	// Sort names like bucket listings
+	slices.Sort(names)
+
+	s3Entries := make([]*s3client.ListElementOutput, 0, len(names))
+	for _, name := range names {
+		s3Entries = append(s3Entries, &s3client.ListElementOutput{
+			Type: s3client.FolderType,
+			Key:  key + name + "/",
+			Name: name + "/",
+		})
+	}
+
+	// Expose quota usage
+	err = bri.exposeQuotaUsage(ctx, resHan)
+	// Check error
+	if err != nil {
+		resHan.InternalServerError(bri.LoadFileContent, err)
+		// Stop
+		return
+	}
+
+	// Answer
+	resHan.FoldersFilesList(
+		bri.LoadFileContent,
+		transformS3Entries(s3Entries, bri, rootPrefix),
+	)
+}
+
+// renderUserIsolationFolder will render a user isolation path template and clean the result.
+func renderUserIsolationFolder(tpl string, data *userIsolationPathData) (string, error) {
+	// Execute template
+	buf, err := templateutils.ExecuteTemplate(tpl, data)
+	// Check error
+	if err != nil {
+		return "", err
+	}
+
+	// Remove all new lines
+	str := utils.NewLineMatcherRegex.ReplaceAllString(buf.String(), "")
+
+	return cleanUserIsolationFolder(str)
+}
+
+// checkUserIsolationValue ensures that a user identifier or a group name is exactly one folder segment.
+// Spaces and slashes around the value are ignored because group names can start with a slash.
+// Values containing a slash, empty values and "." or ".." are rejected with errUserIsolationForbidden.
+func checkUserIsolationValue(value string) error {
+	// Trim value
+	v := strings.Trim(strings.TrimSpace(value), "/")
+	// Check value
+	if v == "" || v == "." || v == ".." || strings.Contains(v, "/") {
+		return errors.WithMessagef(errUserIsolationForbidden, "invalid user isolation value %q", value)
+	}
+
+	return nil
+}
+
+// cleanUserIsolationFolder will remove spaces around a folder, remove empty segments and add a trailing slash.
+// Empty folders and folders with "." or ".." segments are rejected with errUserIsolationForbidden
+// because identifiers and groups can come from external identity providers.
+func cleanUserIsolationFolder(folder string) (string, error) {
+	segments := make([]string, 0)
+
+	// Check segments
+	for seg := range strings.SplitSeq(strings.TrimSpace(folder), "/") {
+		// Ignore empty segments (group names can start with a slash)
+		if seg == "" {
+			continue
+		}
+
+		// Check segment
+		if seg == "." || seg == ".." {
+			return "", errors.WithMessagef(errUserIsolationForbidden, "invalid folder %q", folder)
+		}
+
+		segments = append(segments, seg)
+	}
+
+	// Check if folder is empty
+	if len(segments) == 0 {
+		return "", errors.WithMessagef(errUserIsolationForbidden, "invalid folder %q", folder)
+	}
+
+	return strings.Join(segments, "/") + "/", nil
+}
@@ -1497,7 +1497,7 @@ func Test_respondToUserIsolationError(t *testing.T) {
 		// No EXPECT() — any call would fail the test.
 
 		bri := &bucketReqImpl{}
-		if bri.respondToUserIsolationError(resHan, nil) {
+		if bri.respondToUserIsolationError(context.TODO(), resHan, nil) {
 			t.Fatal("nil error must return false")
 		}
 	})
@@ -1510,7 +1510,7 @@ func Test_respondToUserIsolationError(t *testing.T) {
 			Times(1)
 
 		bri := &bucketReqImpl{}
-		if !bri.respondToUserIsolationError(resHan, errUserIsolationForbidden) {
+		if !bri.respondToUserIsolationError(context.TODO(), resHan, errUserIsolationForbidden) {
 			t.Fatal("isolation error must return true")
 		}
 	})
@@ -1524,7 +1524,7 @@ func Test_respondToUserIsolationError(t *testing.T) {
 			Times(1)
 
 		bri := &bucketReqImpl{}
-		if !bri.respondToUserIsolationError(resHan, wrapped) {
+		if !bri.respondToUserIsolationError(context.TODO(), resHan, wrapped) {
 			t.Fatal("wrapped isolation error must return true")
 		}
 	})
@@ -1538,7 +1538,7 @@ func Test_respondToUserIsolationError(t *testing.T) {
 			Times(1)
 
 		bri := &bucketReqImpl{}
-		if !bri.respondToUserIsolationError(resHan, other) {
+		if !bri.respondToUserIsolationError(context.TODO(), resHan, other) {
 			t.Fatal("non-isolation error must return true")
 		}
 	})
@@ -1598,3 +1598,368 @@ func Test_userIsolationCfg(t *testing.T) {
 		})
 	}
 }
+
+func Test_cleanUserIsolationFolder(t *testing.T) {
+	tests := []struct {
+		name    string
+		folder  string
+		want    string
+		wantErr bool
+	}{
+		{name: "simple folder", folder: "alice", want: "alice/"},
+		{name: "nested folder", folder: "teams/dev/users/alice", want: "teams/dev/users/alice/"},
+		{name: "slashes and spaces are trimmed", folder: " /teams/dev/ ", want: "teams/dev/"},
+		{name: "email", folder: "alice@example.com", want: "alice@example.com/"},
+		{name: "empty folder", folder: "", wantErr: true},
+		{name: "only slashes", folder: "//", wantErr: true},
+		{name: "parent segment", folder: "teams/../admin", wantErr: true},
+		{name: "current segment", folder: "./alice", wantErr: true},
+		{name: "empty segments are removed", folder: "teams//alice", want: "teams/alice/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cleanUserIsolationFolder(tt.folder)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, errUserIsolationForbidden)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_generateStartKey_UserIsolationTemplates(t *testing.T) {
+	templateCfg := func(getCfg *config.GetActionConfigConfig) *config.TargetConfig {
+		getCfg.UserIsolation = true
+		getCfg.UserIsolationAdmins = []string{"admin"}
+
+		return &config.TargetConfig{
+			Bucket:  &config.BucketConfig{Prefix: "data/"},
+			Actions: &config.ActionsConfig{GET: &config.GetActionConfig{Config: getCfg}},
+		}
+	}
+	spacesCfg := templateCfg(&config.GetActionConfigConfig{
+		UserIsolationPathTemplate:      "users/{{ .User.GetIdentifier }}",
+		UserIsolationGroupPathTemplate: "teams/{{ .Group }}",
+	})
+	alice := &models.OIDCUser{PreferredUsername: "alice", Groups: []string{"dev", "ops"}}
+
+	tests := []struct {
+		targetCfg   *config.TargetConfig
+		user        models.GenericUser
+		wantErr     error
+		name        string
+		requestPath string
+		wantKey     string
+	}{
+		{
+			name: "path template is injected",
+			targetCfg: templateCfg(&config.GetActionConfigConfig{
+				UserIsolationPathTemplate: "teams/{{ index .User.GetGroups 0 }}/users/{{ .User.GetIdentifier }}",
+			}),
+			user:        alice,
+			requestPath: "/file.txt",
+			wantKey:     "data/teams/dev/users/alice/file.txt",
+		},
+		{
+			name: "invalid rendered path is forbidden",
+			targetCfg: templateCfg(&config.GetActionConfigConfig{
+				UserIsolationPathTemplate: "{{ .User.GetName }}",
+			}),
+			user:        alice,
+			requestPath: "/file.txt",
+			wantErr:     errUserIsolationForbidden,
+		},
+		{
+			name:        "spaces: user folder",
+			targetCfg:   spacesCfg,
+			user:        alice,
+			requestPath: "/users/alice/file.txt",
+			wantKey:     "data/users/alice/file.txt",
+		},
+		{
+			name:        "spaces: group folder",
+			targetCfg:   spacesCfg,
+			user:        alice,
+			requestPath: "/teams/ops/sub/file.txt",
+			wantKey:     "data/teams/ops/sub/file.txt",
+		},
+		{
+			name:        "spaces: other user folder",
+			targetCfg:   spacesCfg,
+			user:        alice,
+			requestPath: "/users/bob/file.txt",
+			wantKey:     "data/users/bob/file.txt",
+			wantErr:     errUserIsolationForbidden,
+		},
+		{
+			name:        "spaces: other group folder",
+			targetCfg:   spacesCfg,
+			user:        alice,
+			requestPath: "/teams/finance/",
+			wantKey:     "data/teams/finance/",
+			wantErr:     errUserIsolationForbidden,
+		},
+		{
+			name:        "spaces: root is a virtual folder",
+			targetCfg:   spacesCfg,
+			user:        alice,
+			requestPath: "/",
+			wantKey:     "data/",
+			wantErr:     errUserIsolationVirtualFolder,
+		},
+		{
+			name:        "spaces: intermediate folder is a virtual folder",
+			targetCfg:   spacesCfg,
+			user:        alice,
+			requestPath: "/teams/",
+			wantKey:     "data/teams/",
+			wantErr:     errUserIsolationVirtualFolder,
+		},
+		{
+			name:        "spaces: file in virtual folder is forbidden",
+			targetCfg:   spacesCfg,
+			user:        alice,
+			requestPath: "/teams/file.txt",
+			wantKey:     "data/teams/file.txt",
+			wantErr:     errUserIsolationForbidden,
+		},
+		{
+			name:        "spaces: admin has access to everything",
+			targetCfg:   spacesCfg,
+			user:        &models.BasicAuthUser{Username: "admin"},
+			requestPath: "/users/bob/file.txt",
+			wantKey:     "data/users/bob/file.txt",
+		},
+		{
+			name:        "spaces: no user",
+			targetCfg:   spacesCfg,
+			requestPath: "/users/alice/file.txt",
+			wantKey:     "data/users/alice/file.txt",
+			wantErr:     errUserIsolationForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			if tt.user != nil {
+				ctx = models.SetAuthenticatedUserInContext(ctx, tt.user)
+			}
+
+			bri := &bucketReqImpl{targetCfg: tt.targetCfg}
+
+			got, err := bri.generateStartKey(ctx, tt.requestPath)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantKey, got)
+		})
+	}
+}
+
+func Test_userIsolationSpaces(t *testing.T) {
+	tests := []struct {
+		getCfg *config.GetActionConfigConfig
+		user   models.GenericUser
+		name   string
+		want   []string
+	}{
+		{
+			name: "user without groups",
+			getCfg: &config.GetActionConfigConfig{
+				UserIsolationGroupPathTemplate: "groups/{{ .Group }}",
+			},
+			user: &models.BasicAuthUser{Username: "alice"},
+			want: []string{"alice/"},
+		},
+		{
+			name: "user with groups",
+			getCfg: &config.GetActionConfigConfig{
+				UserIsolationGroupPathTemplate: "groups/{{ .Group }}",
+			},
+			user: &models.OIDCUser{PreferredUsername: "alice", Groups: []string{"dev", "ops"}},
+			want: []string{"alice/", "groups/dev/", "groups/ops/"},
+		},
+		{
+			name: "groups restricted",
+			getCfg: &config.GetActionConfigConfig{
+				UserIsolationGroupPathTemplate: "groups/{{ .Group }}",
+				UserIsolationGroups:            []string{"ops"},
+			},
+			user: &models.OIDCUser{PreferredUsername: "alice", Groups: []string{"dev", "ops"}},
+			want: []string{"alice/", "groups/ops/"},
+		},
+		{
+			name: "leading slash groups and duplicates",
+			getCfg: &config.GetActionConfigConfig{
+				UserIsolationPathTemplate:      "users/{{ .User.GetIdentifier }}",
+				UserIsolationGroupPathTemplate: "groups/{{ .Group | lower }}",
+			},
+			user: &models.OIDCUser{PreferredUsername: "alice", Groups: []string{"/Dev", "/dev"}},
+			want: []string{"users/alice/", "groups/dev/"},
+		},
+		{
+			name: "nested group names are ignored",
+			getCfg: &config.GetActionConfigConfig{
+				UserIsolationPathTemplate:      "users/{{ .User.GetIdentifier }}",
+				UserIsolationGroupPathTemplate: "groups/{{ .Group }}",
+			},
+			user: &models.OIDCUser{PreferredUsername: "alice", Groups: []string{"/eng", "/eng/sub", "eng/../ops"}},
+			want: []string{"users/alice/", "groups/eng/"},
+		},
+		{
+			name: "invalid group folders are ignored",
+			getCfg: &config.GetActionConfigConfig{
+				UserIsolationGroupPathTemplate: "groups/{{ .Group }}",
+			},
+			user: &models.OIDCUser{PreferredUsername: "alice", Groups: []string{"..", "dev"}},
+			want: []string{"alice/", "groups/dev/"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.getCfg.UserIsolation = true
+
+			bri := &bucketReqImpl{targetCfg: &config.TargetConfig{
+				Actions: &config.ActionsConfig{GET: &config.GetActionConfig{Config: tt.getCfg}},
+			}}
+
+			got, err := bri.userIsolationSpaces(tt.user)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_userIsolationUserFolder_InvalidIdentifier(t *testing.T) {
+	tests := []struct {
+		name       string
+		identifier string
+		template   string
+	}{
+		{name: "identifier reaching a group space", identifier: "teams/eng"},
+		{name: "identifier reaching a group space with template", identifier: "alice/../teams", template: "users/{{ .User.GetIdentifier }}"},
+		{name: "nested identifier with template", identifier: "alice/sub", template: "users/{{ .User.GetIdentifier }}"},
+		{name: "parent identifier", identifier: ".."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bri := &bucketReqImpl{targetCfg: &config.TargetConfig{
+				Actions: &config.ActionsConfig{GET: &config.GetActionConfig{Config: &config.GetActionConfigConfig{
+					UserIsolation:                  true,
+					UserIsolationPathTemplate:      tt.template,
+					UserIsolationGroupPathTemplate: "teams/{{ .Group }}",
+				}}},
+			}}
+
+			_, err := bri.userIsolationUserFolder(&models.BasicAuthUser{Username: tt.identifier})
+			assert.ErrorIs(t, err, errUserIsolationForbidden)
+
+			_, err = bri.userIsolationSpaces(&models.BasicAuthUser{Username: tt.identifier})
+			assert.ErrorIs(t, err, errUserIsolationForbidden)
+		})
+	}
+}
+
+func Test_requestContext_Get_UserIsolationSpaces(t *testing.T) {
+	targetCfg := &config.TargetConfig{
+		Name:   "target",
+		Bucket: &config.BucketConfig{Name: "bucket1", Prefix: "data/"},
+		Actions: &config.ActionsConfig{
+			GET: &config.GetActionConfig{
+				Config: &config.GetActionConfigConfig{
+					UserIsolation:                  true,
+					UserIsolationPathTemplate:      "users/{{ .User.GetIdentifier }}",
+					UserIsolationGroupPathTemplate: "teams/{{ .Group }}",
+				},
+			},
+		},
+	}
+	alice := &models.OIDCUser{PreferredUsername: "alice", Groups: []string{"ops", "dev"}}
+
+	tests := []struct {
+		name        string
+		requestPath string
+		wantEntries []*responsehandlermodels.Entry
+	}{
+		{
+			name:        "root virtual folder",
+			requestPath: "/",
+			wantEntries: []*responsehandlermodels.Entry{
+				{Type: s3client.FolderType, Name: "teams/", Key: "data/teams/", Path: "/mount/teams/"},
+				{Type: s3client.FolderType, Name: "users/", Key: "data/users/", Path: "/mount/users/"},
+			},
+		},
+		{
+			name:        "intermediate virtual folder",
+			requestPath: "/teams/",
+			wantEntries: []*responsehandlermodels.Entry{
+				{Type: s3client.FolderType, Name: "dev/", Key: "data/teams/dev/", Path: "/mount/teams/dev/"},
+				{Type: s3client.FolderType, Name: "ops/", Key: "data/teams/ops/", Path: "/mount/teams/ops/"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			resHandlerMock := responsehandlermocks.NewMockResponseHandler(ctrl)
+			s3clManagerMock := s3clientmocks.NewMockManager(ctrl)
+			webhookManagerMock := wmocks.NewMockManager(ctrl)
+
+			ctx := context.TODO()
+			ctx = responsehandler.SetResponseHandlerInContext(ctx, resHandlerMock)
+			ctx = log.SetLoggerInContext(ctx, log.NewLogger())
+			ctx = models.SetAuthenticatedUserInContext(ctx, alice)
+
+			// No bucket request must be done
+			s3clManagerMock.EXPECT().GetClientForTarget(gomock.Any()).Times(0)
+			webhookManagerMock.EXPECT().ManageGETHooks(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			resHandlerMock.EXPECT().FoldersFilesList(gomock.Any(), tt.wantEntries).Times(1)
+
+			rctx := &bucketReqImpl{
+				s3ClientManager: s3clManagerMock,
+				webhookManager:  webhookManagerMock,
+				targetCfg:       targetCfg,
+				mountPath:       "/mount",
+			}
+			rctx.Get(ctx, &GetInput{RequestPath: tt.requestPath})
+		})
+	}
+}
+
+func Test_requestContext_Put_UserIsolationSpaces_VirtualFolder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	resHandlerMock := responsehandlermocks.NewMockResponseHandler(ctrl)
+
+	ctx := context.TODO()
+	ctx = responsehandler.SetResponseHandlerInContext(ctx, resHandlerMock)
+	ctx = log.SetLoggerInContext(ctx, log.NewLogger())
+	ctx = models.SetAuthenticatedUserInContext(ctx, &models.OIDCUser{PreferredUsername: "alice", Groups: []string{"dev"}})
+
+	resHandlerMock.EXPECT().ForbiddenError(gomock.Any(), errUserIsolationVirtualFolder).Times(1)
+
+	rctx := &bucketReqImpl{
+		targetCfg: &config.TargetConfig{
+			Name:   "target",
+			Bucket: &config.BucketConfig{Name: "bucket1", Prefix: "data/"},
+			Actions: &config.ActionsConfig{
+				GET: &config.GetActionConfig{
+					Config: &config.GetActionConfigConfig{
+						UserIsolation:                  true,
+						UserIsolationGroupPathTemplate: "teams/{{ .Group }}",
+					},
+				},
+			},
+		},
+		mountPath: "/mount",
+	}
+	rctx.Put(ctx, &PutInput{RequestPath: "/teams/", Filename: "file.txt"})
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"net/netip"
 	"regexp"
 	"slices"
 	"strings"
@@ -72,6 +73,12 @@ const DefaultTemplateInternalServerErrorPath = "templates/internal-server-error.
 // DefaultTemplateUnauthorizedErrorPath Default template unauthorized error path.
 const DefaultTemplateUnauthorizedErrorPath = "templates/unauthorized-error.tpl"
 
+// DefaultTemplateQuotaExceededErrorPath Default template quota exceeded error path.
+const DefaultTemplateQuotaExceededErrorPath = "templates/quota-exceeded-error.tpl"
+
+// DefaultTemplateServiceUnavailableErrorPath Default template service unavailable error path.
+const DefaultTemplateServiceUnavailableErrorPath = "templates/service-unavailable-error.tpl"
+
 // DefaultTemplatePutPath Default template put path.
 const DefaultTemplatePutPath = "templates/put.tpl"
 
@@ -110,6 +117,12 @@ const DefaultTemplateStatusUnauthorized = "401"
 // DefaultTemplateStatusInternalServerError Default template for status Internal Server Error.
 const DefaultTemplateStatusInternalServerError = "500"
 
+// DefaultTemplateStatusQuotaExceeded Default template for status quota exceeded.
+const DefaultTemplateStatusQuotaExceeded = "413"
+
+// DefaultTemplateStatusServiceUnavailable Default template for status service unavailable.
+const DefaultTemplateStatusServiceUnavailable = "503"
+
 // DefaultOIDCScopes Default OIDC Scopes.
 var DefaultOIDCScopes = []string{"openid", "profile", "email"}
 
@@ -119,18 +132,209 @@ const DefaultOIDCGroupClaim = "groups"
 // DefaultOIDCCookieName Default OIDC Cookie name.
 const DefaultOIDCCookieName = "oidc"
 
+// DefaultOIDCSessionMaxDuration Default OIDC session maximum duration.
+const DefaultOIDCSessionMaxDuration = 24 * time.Hour
+
+// OIDCSessionEncryptionKeyMinLength Minimum length of OIDC session encryption key.
+const OIDCSessionEncryptionKeyMinLength = 32
+
+// OIDCLoginStateSecretMinLength Minimum length of OIDC login state secret.
+const OIDCLoginStateSecretMinLength = 32
+
+// DefaultOIDCSessionRedisKeyPrefix Default OIDC session key prefix in Redis.
+const DefaultOIDCSessionRedisKeyPrefix = "s3-proxy:oidc-session:"
+
+// OIDC session store types.
+const (
+	OIDCSessionStoreMemoryType = "memory"
+	OIDCSessionStoreRedisType  = "redis"
+)
+
+// DefaultJWTAllowedAlgorithms Default JWT allowed signing algorithms.
+var DefaultJWTAllowedAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// supportedJWTAlgorithms JWT signing algorithms supported.
+var supportedJWTAlgorithms = []string{
+	"HS256", "HS384", "HS512",
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// DefaultJWTClockSkew Default JWT clock skew.
+const DefaultJWTClockSkew = time.Minute
+
+// DefaultJWTJWKSRefreshInterval Default JWT JWKS URL refresh interval.
+const DefaultJWTJWKSRefreshInterval = time.Hour
+
+// Default JWT claims.
+const (
+	DefaultJWTUsernameClaim = "sub"
+	DefaultJWTEmailClaim    = "email"
+	DefaultJWTNameClaim     = "name"
+	DefaultJWTGroupsClaim   = "groups"
+)
+
+// LDAPUsernamePlaceholder Placeholder replaced by the escaped username in LDAP user DN template and user search filter.
+const LDAPUsernamePlaceholder = "{username}"
+
+// Default LDAP values.
+const (
+	DefaultLDAPUserSearchFilter     = "(uid={username})"
+	DefaultLDAPGroupSearchFilter    = "(|(objectClass=group)(objectClass=groupOfNames))"
+	DefaultLDAPGroupMemberAttribute = "member"
+	DefaultLDAPGroupNameAttribute   = "cn"
+	DefaultLDAPEmailAttribute       = "mail"
+	DefaultLDAPTimeout              = 10 * time.Second
+	DefaultLDAPCacheDuration        = 5 * time.Minute
+)
+
+// DefaultAPIKeyHeader Default API key header.
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// DefaultHMACMaxClockSkew Default maximum difference between HMAC signed request date and server time.
+const DefaultHMACMaxClockSkew = 5 * time.Minute
+
+// DefaultHMACMaxBodySize Default maximum size in bytes of a HMAC signed request body.
+const DefaultHMACMaxBodySize int64 = 10 * 1024 * 1024
+
+// DefaultHMACRejectReplay Default HMAC replay rejection.
+var DefaultHMACRejectReplay = true
+
+// Default impersonation values.
+const (
+	DefaultImpersonationHeader       = "X-Impersonate-User"
+	DefaultImpersonationGroupsHeader = "X-Impersonate-Groups"
+	DefaultImpersonationCookieName   = "s3-proxy-impersonate"
+)
+
+// ImpersonationCookieSecretMinLength Minimum length of impersonation cookie secret.
+const ImpersonationCookieSecretMinLength = 32
+
+// Audit sink types.
+const (
+	AuditSinkTypeStdout = "stdout"
+	AuditSinkTypeFile   = "file"
+	AuditSinkTypeS3     = "s3"
+)
+
+// Default audit values.
+const (
+	DefaultAuditFileMaxSize     = 100
+	DefaultAuditFileMaxBackups  = 10
+	DefaultAuditS3Prefix        = "audit/"
+	DefaultAuditS3BatchSize     = 1000
+	DefaultAuditS3FlushInterval = time.Minute
+)
+
+// Default webhook queue values.
+const (
+	DefaultWebhookQueueWorkers        = 4
+	DefaultWebhookQueueMaxAttempts    = 10
+	DefaultWebhookQueueInitialBackoff = time.Second
+	DefaultWebhookQueueMaxBackoff     = 5 * time.Minute
+)
+
+// DefaultWebhookSignatureHeader Default webhook signature header.
+const DefaultWebhookSignatureHeader = "X-S3P-Webhook-Signature"
+
+// Pre-action hook failure policies.
+const (
+	// PreActionHookFailurePolicyOpen allows the action when the hook can't be reached or answers with an error.
+	PreActionHookFailurePolicyOpen = "open"
+	// PreActionHookFailurePolicyClosed denies the action when the hook can't be reached or answers with an error.
+	PreActionHookFailurePolicyClosed = "closed"
+)
+
+// Default pre-action hook values.
+const (
+	DefaultPreActionHookTimeout       = 5 * time.Second
+	DefaultPreActionHookFailurePolicy = PreActionHookFailurePolicyClosed
+)
+
+// Default brute force protection values.
+const (
+	DefaultBruteForceMaxAttemptsPerUser = 5
+	DefaultBruteForceMaxAttemptsPerIP   = 20
+	DefaultBruteForceAttemptsWindow     = 15 * time.Minute
+	DefaultBruteForceLockoutDuration    = time.Minute
+	DefaultBruteForceMaxLockoutDuration = time.Hour
+)
+
+// APIKeyHashRegexp API key hash format: "sha256:SALT:HEX_DIGEST" with digest = sha256(SALT + KEY).
+var APIKeyHashRegexp = regexp.MustCompile(`^sha256:([^:]+):([0-9a-f]{64})$`)
+
+// Mutual TLS user fields.
+const (
+	MTLSFieldCommonName         = "commonName"
+	MTLSFieldEmail              = "email"
+	MTLSFieldDNSName            = "dnsName"
+	MTLSFieldURI                = "uri"
+	MTLSFieldOrganizationalUnit = "organizationalUnit"
+	MTLSFieldOrganization       = "organization"
+)
+
+// DefaultMTLSUsernameField Default mutual TLS username field.
+const DefaultMTLSUsernameField = MTLSFieldCommonName
+
+// DefaultMTLSGroupsFields Default mutual TLS groups fields.
+var DefaultMTLSGroupsFields = []string{MTLSFieldOrganizationalUnit}
+
+// Server SSL client authentication modes.
+const (
+	SSLClientAuthModeVerifyIfGiven = "verifyIfGiven"
+	SSLClientAuthModeRequire       = "require"
+)
+
 // RegexTargetKeyRewriteTargetType Regex target key rewrite Target type.
 const RegexTargetKeyRewriteTargetType = "REGEX"
 
 // TemplateTargetKeyRewriteTargetType Template Target key rewrite Target type.
 const TemplateTargetKeyRewriteTargetType = "TEMPLATE"
 
+// RBACReadAction RBAC read action.
+const RBACReadAction = "read"
+
+// RBACListAction RBAC list action.
+const RBACListAction = "list"
+
+// RBACWriteAction RBAC write action.
+const RBACWriteAction = "write"
+
+// RBACDeleteAction RBAC delete action.
+const RBACDeleteAction = "delete"
+
 // DefaultServerTimeoutsReadHeaderTimeout Server timeouts ReadHeaderTimeout.
 const DefaultServerTimeoutsReadHeaderTimeout = "60s"
 
 // DefaultTargetActionsGETConfigSignedURLExpiration default signed url expiration.
 const DefaultTargetActionsGETConfigSignedURLExpiration = 15 * time.Minute
 
+// DefaultUserIsolationQuotaReconcileInterval default user isolation quota reconcile interval.
+const DefaultUserIsolationQuotaReconcileInterval = time.Hour
+
+// DefaultTargetConcurrencyQueueTimeout default target concurrency queue timeout.
+const DefaultTargetConcurrencyQueueTimeout = 10 * time.Second
+
+// DefaultPrecompressedCacheExpiration default precompressed variants existence cache expiration.
+const DefaultPrecompressedCacheExpiration = time.Minute
+
+// DefaultPrecompressedEncodings default precompressed encodings in preference order.
+var DefaultPrecompressedEncodings = []string{PrecompressedEncodingBrotli, PrecompressedEncodingGzip}
+
+// PrecompressedEncodingBrotli Brotli precompressed encoding.
+const PrecompressedEncodingBrotli = "br"
+
+// PrecompressedEncodingGzip Gzip precompressed encoding.
+const PrecompressedEncodingGzip = "gzip"
+
+// PrecompressedEncodingExtensions maps precompressed encodings to object key extensions.
+var PrecompressedEncodingExtensions = map[string]string{
+	PrecompressedEncodingBrotli: ".br",
+	PrecompressedEncodingGzip:   ".gz",
+}
+
 // ErrMainBucketPathSupportNotValid Error thrown when main bucket path support option isn't valid.
 var ErrMainBucketPathSupportNotValid = errors.New("main bucket path support option can be enabled only when only one bucket is configured")
 
@@ -144,22 +348,150 @@ const (
 	DefaultS3UploadConcurrency       = s3manager.DefaultUploadConcurrency
 )
 
+// Default Download configurations.
+const (
+	DefaultS3DownloadPartSize    int64 = 8
+	DefaultS3DownloadConcurrency       = 4
+)
+
 const (
 	oidcLoginPathTemplate    = "/auth/%s"
 	oidcCallbackPathTemplate = "/auth/%s/callback"
+	oidcLogoutPathTemplate   = "/auth/%s/logout"
 )
 
 // Config Application Configuration.
 type Config struct {
-	Log            *LogConfig               `mapstructure:"log"            json:"log"`
-	Tracing        *TracingConfig           `mapstructure:"tracing"        json:"tracing"`
-	Metrics        *MetricsConfig           `mapstructure:"metrics"        json:"metrics"`
-	Server         *ServerConfig            `mapstructure:"server"         json:"server"`
-	InternalServer *ServerConfig            `mapstructure:"internalServer" json:"internalServer"`
-	Targets        map[string]*TargetConfig `mapstructure:"targets"        json:"targets"`
-	Templates      *TemplateConfig          `mapstructure:"templates"      json:"templates"`
-	AuthProviders  *AuthProviderConfig      `mapstructure:"authProviders"  json:"authProviders"`
-	ListTargets    *ListTargetsConfig       `mapstructure:"listTargets"    json:"listTargets"`
+	Log            *LogConfig                   `mapstructure:"log"            json:"log"`
+	Tracing        *TracingConfig               `mapstructure:"tracing"        json:"tracing"`
+	Metrics        *MetricsConfig               `mapstructure:"metrics"        json:"metrics"`
+	Server         *ServerConfig                `mapstructure:"server"         json:"server"`
+	InternalServer *ServerConfig                `mapstructure:"internalServer" json:"internalServer"`
+	Targets        map[string]*TargetConfig     `mapstructure:"targets"        json:"targets"`
+	Templates      *TemplateConfig              `mapstructure:"templates"      json:"templates"`
+	AuthProviders  *AuthProviderConfig          `mapstructure:"authProviders"  json:"authProviders"`
+	ListTargets    *ListTargetsConfig           `mapstructure:"listTargets"    json:"listTargets"`
+	RegoPolicies   map[string]*RegoPolicyConfig `mapstructure:"regoPolicies"   json:"regoPolicies"   validate:"omitempty,dive"`
+	RBAC           *RBACConfig                  `mapstructure:"rbac"           json:"rbac"           validate:"omitempty"`
+	Audit          *AuditConfig                 `mapstructure:"audit"          json:"audit"          validate:"omitempty"`
+	GeoIP          *GeoIPConfig                 `mapstructure:"geoIP"          json:"geoIP"          validate:"omitempty"`
+	Impersonation  *ImpersonationConfig         `mapstructure:"impersonation"  json:"impersonation"  validate:"omitempty"`
+	WebhookQueue   *WebhookQueueConfig          `mapstructure:"webhookQueue"   json:"webhookQueue"   validate:"omitempty"`
+}
+
+// WebhookQueueConfig Durable webhook delivery queue configuration.
+type WebhookQueueConfig struct {
+	Directory            string        `mapstructure:"directory"      json:"directory"`
+	InitialBackoffString string        `mapstructure:"initialBackoff" json:"initialBackoff"`
+	MaxBackoffString     string        `mapstructure:"maxBackoff"     json:"maxBackoff"`
+	InitialBackoff       time.Duration `                              json:"-"`
+	MaxBackoff           time.Duration `                              json:"-"`
+	Workers              int           `mapstructure:"workers"        json:"workers"        validate:"gte=0"`
+	MaxAttempts          int           `mapstructure:"maxAttempts"    json:"maxAttempts"    validate:"gte=0"`
+	Enabled              bool          `mapstructure:"enabled"        json:"enabled"`
+}
+
+// ImpersonationConfig Admin impersonation configuration.
+type ImpersonationConfig struct {
+	CookieSecret   *CredentialConfig `mapstructure:"cookieSecret"   json:"cookieSecret"`
+	Header         string            `mapstructure:"header"         json:"header"`
+	GroupsHeader   string            `mapstructure:"groupsHeader"   json:"groupsHeader"`
+	CookieName     string            `mapstructure:"cookieName"     json:"cookieName"`
+	Admins         []string          `mapstructure:"admins"         json:"admins"         validate:"omitempty,dive,required"`
+	AdminGroups    []string          `mapstructure:"adminGroups"    json:"adminGroups"    validate:"omitempty,dive,required"`
+	AllowedGroups  []string          `mapstructure:"allowedGroups"  json:"allowedGroups"  validate:"omitempty,dive,required"`
+	CookieSecure   bool              `mapstructure:"cookieSecure"   json:"cookieSecure"`
+	AllowMutations bool              `mapstructure:"allowMutations" json:"allowMutations"`
+	Enabled        bool              `mapstructure:"enabled"        json:"enabled"`
+}
+
+// GeoIPConfig GeoIP database configuration.
+type GeoIPConfig struct {
+	DatabasePath string `mapstructure:"databasePath" validate:"required" json:"databasePath"`
+}
+
+// IPFilterConfig IP filter configuration.
+type IPFilterConfig struct {
+	AllowPrefixes  []netip.Prefix `                                                                 json:"-"`
+	DenyPrefixes   []netip.Prefix `                                                                 json:"-"`
+	Allow          []string       `mapstructure:"allow"          validate:"omitempty,dive,required" json:"allow"`
+	Deny           []string       `mapstructure:"deny"           validate:"omitempty,dive,required" json:"deny"`
+	AllowCountries []string       `mapstructure:"allowCountries" validate:"omitempty,dive,len=2"    json:"allowCountries"`
+	DenyCountries  []string       `mapstructure:"denyCountries"  validate:"omitempty,dive,len=2"    json:"denyCountries"`
+}
+
+// HasCountryRules returns true if the filter contains country rules.
+func (f *IPFilterConfig) HasCountryRules() bool {
+	return len(f.AllowCountries) != 0 || len(f.DenyCountries) != 0
+}
+
+// AuditConfig Audit log configuration.
+type AuditConfig struct {
+	Sinks   []*AuditSinkConfig `mapstructure:"sinks"   validate:"omitempty,dive,required" json:"sinks"`
+	Enabled bool               `mapstructure:"enabled"                                    json:"enabled"`
+}
+
+// AuditSinkConfig Audit sink configuration.
+type AuditSinkConfig struct {
+	File *AuditFileSinkConfig `mapstructure:"file" validate:"omitempty"                     json:"file"`
+	S3   *AuditS3SinkConfig   `mapstructure:"s3"   validate:"omitempty"                     json:"s3"`
+	Type string               `mapstructure:"type" validate:"required,oneof=stdout file s3" json:"type"`
+}
+
+// AuditFileSinkConfig Audit file sink configuration.
+type AuditFileSinkConfig struct {
+	Path       string `mapstructure:"path"       validate:"required" json:"path"`
+	MaxSize    int    `mapstructure:"maxSize"    validate:"gte=0"    json:"maxSize"`
+	MaxBackups int    `mapstructure:"maxBackups" validate:"gte=0"    json:"maxBackups"`
+}
+
+// AuditS3SinkConfig Audit S3 sink configuration.
+type AuditS3SinkConfig struct {
+	Target              string        `mapstructure:"target"        validate:"required" json:"target"`
+	Prefix              string        `mapstructure:"prefix"                            json:"prefix"`
+	FlushIntervalString string        `mapstructure:"flushInterval"                     json:"flushInterval"`
+	FlushInterval       time.Duration `                                                 json:"-"`
+	BatchSize           int           `mapstructure:"batchSize"     validate:"gte=0"    json:"batchSize"`
+}
+
+// RBACConfig Role based access control configuration.
+type RBACConfig struct {
+	Roles    map[string]*RBACRoleConfig `mapstructure:"roles"    validate:"omitempty,dive"          json:"roles"`
+	Bindings []*RBACBindingConfig       `mapstructure:"bindings" validate:"omitempty,dive,required" json:"bindings"`
+}
+
+// RBACRoleConfig Role based access control role configuration.
+type RBACRoleConfig struct {
+	Permissions []*RBACPermissionConfig `mapstructure:"permissions" validate:"required,dive,required" json:"permissions"`
+}
+
+// RBACPermissionConfig Role based access control permission configuration.
+type RBACPermissionConfig struct {
+	Targets []string `mapstructure:"targets" validate:"required,dive,required"                     json:"targets"`
+	Paths   []string `mapstructure:"paths"   validate:"required,dive,required"                     json:"paths"`
+	Actions []string `mapstructure:"actions" validate:"required,dive,oneof=read list write delete" json:"actions"`
+}
+
+// RBACBindingConfig Role based access control binding configuration.
+type RBACBindingConfig struct {
+	Role      string   `mapstructure:"role"      validate:"required" json:"role"`
+	Users     []string `mapstructure:"users"                         json:"users"`
+	Groups    []string `mapstructure:"groups"                        json:"groups"`
+	Providers []string `mapstructure:"providers"                     json:"providers"`
+}
+
+// RegoPolicyConfig Embedded Rego policy configuration.
+type RegoPolicyConfig struct {
+	Bucket      *RegoPolicyBucketConfig `mapstructure:"bucket"      validate:"omitempty"               json:"bucket"`
+	Query       string                  `mapstructure:"query"       validate:"required"                json:"query"`
+	Files       []string                `mapstructure:"files"       validate:"omitempty,dive,required" json:"files"`
+	DecisionLog bool                    `mapstructure:"decisionLog"                                    json:"decisionLog"`
+}
+
+// RegoPolicyBucketConfig Embedded Rego policy bucket source configuration.
+type RegoPolicyBucketConfig struct {
+	Target string `mapstructure:"target" validate:"required" json:"target"`
+	Prefix string `mapstructure:"prefix"                     json:"prefix"`
 }
 
 // MetricsConfig represents the metrics configuration structure.
@@ -192,26 +524,192 @@ type MountConfig struct {
 
 // AuthProviderConfig Authentication provider configurations.
 type AuthProviderConfig struct {
-	Basic  map[string]*BasicAuthConfig  `mapstructure:"basic"  validate:"omitempty" json:"basic"`
-	OIDC   map[string]*OIDCAuthConfig   `mapstructure:"oidc"   validate:"omitempty" json:"oidc"`
-	Header map[string]*HeaderAuthConfig `mapstructure:"header" validate:"omitempty" json:"header"`
+	Basic  map[string]*BasicAuthConfig  `mapstructure:"basic"  validate:"omitempty"      json:"basic"`
+	OIDC   map[string]*OIDCAuthConfig   `mapstructure:"oidc"   validate:"omitempty"      json:"oidc"`
+	Header map[string]*HeaderAuthConfig `mapstructure:"header" validate:"omitempty"      json:"header"`
+	JWT    map[string]*JWTAuthConfig    `mapstructure:"jwt"    validate:"omitempty,dive" json:"jwt"`
+	APIKey map[string]*APIKeyAuthConfig `mapstructure:"apiKey" validate:"omitempty,dive" json:"apiKey"`
+	MTLS   map[string]*MTLSAuthConfig   `mapstructure:"mtls"   validate:"omitempty,dive" json:"mtls"`
+	LDAP   map[string]*LDAPAuthConfig   `mapstructure:"ldap"   validate:"omitempty,dive" json:"ldap"`
+	HMAC   map[string]*HMACAuthConfig   `mapstructure:"hmac"   validate:"omitempty,dive" json:"hmac"`
+}
+
+// HMACAuthConfig HMAC request signing authentication configuration.
+type HMACAuthConfig struct {
+	Keys                 []*HMACKeyConfig `mapstructure:"keys"                 validate:"required,min=1,dive"     json:"keys"`
+	SignedHeaders        []string         `mapstructure:"signedHeaders"        validate:"omitempty,dive,required" json:"signedHeaders"`
+	MaxClockSkewString   string           `mapstructure:"maxClockSkew"                                            json:"maxClockSkew"`
+	MaxClockSkew         time.Duration    `                                                                       json:"-"`
+	RejectReplay         *bool            `mapstructure:"rejectReplay"                                            json:"rejectReplay"`
+	MaxBodySize          int64            `mapstructure:"maxBodySize"          validate:"gte=0"                   json:"maxBodySize"`
+	AllowUnsignedPayload bool             `mapstructure:"allowUnsignedPayload"                                    json:"allowUnsignedPayload"`
+}
+
+// HMACKeyConfig HMAC key configuration.
+type HMACKeyConfig struct {
+	Secret *CredentialConfig `mapstructure:"secret" validate:"required"                json:"secret"`
+	ID     string            `mapstructure:"id"     validate:"required"                json:"id"`
+	Owner  string            `mapstructure:"owner"  validate:"required"                json:"owner"`
+	Email  string            `mapstructure:"email"                                     json:"email"`
+	Groups []string          `mapstructure:"groups" validate:"omitempty,dive,required" json:"groups"`
+}
+
+// LDAPAuthConfig LDAP authentication configuration.
+type LDAPAuthConfig struct {
+	BindPassword        *CredentialConfig      `mapstructure:"bindPassword"       validate:"required_with=BindDN" json:"bindPassword"`
+	CACertificate       *CredentialConfig      `mapstructure:"caCertificate"      validate:"omitempty"            json:"caCertificate"`
+	UserSearch          *LDAPUserSearchConfig  `mapstructure:"userSearch"         validate:"omitempty"            json:"userSearch"`
+	GroupSearch         *LDAPGroupSearchConfig `mapstructure:"groupSearch"        validate:"omitempty"            json:"groupSearch"`
+	Realm               string                 `mapstructure:"realm"              validate:"required"             json:"realm"`
+	URL                 string                 `mapstructure:"url"                validate:"required,url"         json:"url"`
+	BindDN              string                 `mapstructure:"bindDN"                                             json:"bindDN"`
+	UserDNTemplate      string                 `mapstructure:"userDNTemplate"                                     json:"userDNTemplate"`
+	EmailAttribute      string                 `mapstructure:"emailAttribute"                                     json:"emailAttribute"`
+	TimeoutString       string                 `mapstructure:"timeout"                                            json:"timeout"`
+	CacheDurationString string                 `mapstructure:"cacheDuration"                                      json:"cacheDuration"`
+	Timeout             time.Duration          `                                                                  json:"-"`
+	CacheDuration       time.Duration          `                                                                  json:"-"`
+	StartTLS            bool                   `mapstructure:"startTLS"                                           json:"startTLS"`
+	InsecureSkipVerify  bool                   `mapstructure:"insecureSkipVerify"                                 json:"insecureSkipVerify"`
+}
+
+// LDAPUserSearchConfig LDAP user search configuration.
+type LDAPUserSearchConfig struct {
+	BaseDN string `mapstructure:"baseDN" validate:"required" json:"baseDN"`
+	Filter string `mapstructure:"filter"                     json:"filter"`
+}
+
+// LDAPGroupSearchConfig LDAP group search configuration.
+type LDAPGroupSearchConfig struct {
+	BaseDN          string `mapstructure:"baseDN"          validate:"required" json:"baseDN"`
+	Filter          string `mapstructure:"filter"                              json:"filter"`
+	MemberAttribute string `mapstructure:"memberAttribute"                     json:"memberAttribute"`
+	NameAttribute   string `mapstructure:"nameAttribute"                       json:"nameAttribute"`
+	Nested          bool   `mapstructure:"nested"                              json:"nested"`
+}
+
+// MTLSAuthConfig Mutual TLS client certificate authentication configuration.
+type MTLSAuthConfig struct {
+	UsernameField string                    `mapstructure:"usernameField" validate:"omitempty,oneof=commonName email dnsName uri"                    json:"usernameField"`
+	GroupsFields  []string                  `mapstructure:"groupsFields"  validate:"omitempty,dive,oneof=organizationalUnit organization commonName" json:"groupsFields"`
+	GroupMappings []*MTLSGroupMappingConfig `mapstructure:"groupMappings" validate:"omitempty,dive"                                                  json:"groupMappings"`
+}
+
+// MTLSGroupMappingConfig Mutual TLS group mapping configuration.
+type MTLSGroupMappingConfig struct {
+	Value string `mapstructure:"value" validate:"required" json:"value"`
+	Group string `mapstructure:"group" validate:"required" json:"group"`
+}
+
+// APIKeyAuthConfig API key authentication configuration.
+type APIKeyAuthConfig struct {
+	Header     string          `mapstructure:"header"     validate:"required"       json:"header"`
+	QueryParam string          `mapstructure:"queryParam"                           json:"queryParam"`
+	KeysFile   string          `mapstructure:"keysFile"                             json:"keysFile"`
+	Keys       []*APIKeyConfig `mapstructure:"keys"       validate:"omitempty,dive" json:"keys"`
+	FileKeys   []*APIKeyConfig `                                                    json:"-"` // Keys loaded from keys file
+}
+
+// APIKeysFileConfig API keys file content.
+type APIKeysFileConfig struct {
+	Keys []*APIKeyConfig `mapstructure:"keys" validate:"omitempty,dive" json:"keys"`
+}
+
+// APIKeyConfig API key configuration.
+type APIKeyConfig struct {
+	ExpiresAt       time.Time `mapstructure:"-"                                            json:"-"` // Parsed from expiresAt
+	ID              string    `mapstructure:"id"        validate:"required"                json:"id"`
+	Hash            string    `mapstructure:"hash"      validate:"required"                json:"-"` // Salted hash of the key
+	Owner           string    `mapstructure:"owner"     validate:"required"                json:"owner"`
+	Email           string    `mapstructure:"email"                                        json:"email"`
+	ExpiresAtString string    `mapstructure:"expiresAt"                                    json:"expiresAt"`
+	Groups          []string  `mapstructure:"groups"    validate:"omitempty,dive,required" json:"groups"`
+	Paths           []string  `mapstructure:"paths"     validate:"omitempty,dive,required" json:"paths"`
+}
+
+// GetAllKeys returns keys declared in configuration and in keys file.
+func (c *APIKeyAuthConfig) GetAllKeys() []*APIKeyConfig {
+	res := make([]*APIKeyConfig, 0, len(c.Keys)+len(c.FileKeys))
+	res = append(res, c.Keys...)
+	res = append(res, c.FileKeys...)
+
+	return res
+}
+
+// JWTAuthConfig JWT bearer token authentication configuration.
+type JWTAuthConfig struct {
+	Claims                    *JWTClaimsConfig      `mapstructure:"claims"              validate:"omitempty"               json:"claims"`
+	JWKSURL                   string                `mapstructure:"jwksUrl"             validate:"omitempty,url"           json:"jwksUrl"`
+	JWKSFile                  string                `mapstructure:"jwksFile"                                               json:"jwksFile"`
+	Issuer                    string                `mapstructure:"issuer"              validate:"required"                json:"issuer"`
+	ClockSkewString           string                `mapstructure:"clockSkew"                                              json:"clockSkew"`
+	JWKSRefreshIntervalString string                `mapstructure:"jwksRefreshInterval"                                    json:"jwksRefreshInterval"`
+	StaticKeys                []*JWTStaticKeyConfig `mapstructure:"staticKeys"          validate:"omitempty,dive"          json:"staticKeys"`
+	Audiences                 []string              `mapstructure:"audiences"           validate:"omitempty,dive,required" json:"audiences"`
+	AllowedAlgorithms         []string              `mapstructure:"allowedAlgorithms"   validate:"omitempty,dive,required" json:"allowedAlgorithms"`
+	ClockSkew                 time.Duration         `                                                                      json:"-"`
+	JWKSRefreshInterval       time.Duration         `                                                                      json:"-"`
+}
+
+// JWTStaticKeyConfig JWT static key configuration.
+type JWTStaticKeyConfig struct {
+	// Key is a PEM encoded public key or certificate, or a HMAC secret.
+	Key   *CredentialConfig `mapstructure:"key" validate:"required" json:"key"`
+	KeyID string            `mapstructure:"kid"                     json:"kid"`
+}
+
+// JWTClaimsConfig JWT claims mapping configuration.
+type JWTClaimsConfig struct {
+	Username string `mapstructure:"username" json:"username"`
+	Email    string `mapstructure:"email"    json:"email"`
+	Name     string `mapstructure:"name"     json:"name"`
+	Groups   string `mapstructure:"groups"   json:"groups"`
 }
 
 // OIDCAuthConfig OpenID Connect authentication configurations.
 type OIDCAuthConfig struct {
-	ClientSecret  *CredentialConfig `mapstructure:"clientSecret"  validate:"omitempty"     json:"clientSecret"`
-	GroupClaim    string            `mapstructure:"groupClaim"                             json:"groupClaim"`
-	IssuerURL     string            `mapstructure:"issuerUrl"     validate:"required,url"  json:"issuerUrl"`
-	RedirectURL   string            `mapstructure:"redirectUrl"   validate:"omitempty,url" json:"redirectUrl"`
-	State         string            `mapstructure:"state"         validate:"required"      json:"state"`
-	ClientID      string            `mapstructure:"clientID"      validate:"required"      json:"clientID"`
-	CookieName    string            `mapstructure:"cookieName"                             json:"cookieName"`
-	LoginPath     string            `mapstructure:"loginPath"                              json:"loginPath"`
-	CallbackPath  string            `mapstructure:"callbackPath"                           json:"callbackPath"`
-	Scopes        []string          `mapstructure:"scopes"                                 json:"scopes"`
-	CookieDomains []string          `mapstructure:"cookieDomains"                          json:"cookieDomains"`
-	EmailVerified bool              `mapstructure:"emailVerified"                          json:"emailVerified"`
-	CookieSecure  bool              `mapstructure:"cookieSecure"                           json:"cookieSecure"`
+	ClientSecret          *CredentialConfig  `mapstructure:"clientSecret"          validate:"omitempty"     json:"clientSecret"`
+	LoginStateSecret      *CredentialConfig  `mapstructure:"loginStateSecret"      validate:"omitempty"     json:"loginStateSecret"`
+	Session               *OIDCSessionConfig `mapstructure:"session"               validate:"omitempty"     json:"session"`
+	GroupClaim            string             `mapstructure:"groupClaim"                                     json:"groupClaim"`
+	IssuerURL             string             `mapstructure:"issuerUrl"             validate:"required,url"  json:"issuerUrl"`
+	RedirectURL           string             `mapstructure:"redirectUrl"           validate:"omitempty,url" json:"redirectUrl"`
+	State                 string             `mapstructure:"state"                                          json:"state"`
+	ClientID              string             `mapstructure:"clientID"              validate:"required"      json:"clientID"`
+	CookieName            string             `mapstructure:"cookieName"                                     json:"cookieName"`
+	LoginPath             string             `mapstructure:"loginPath"                                      json:"loginPath"`
+	CallbackPath          string             `mapstructure:"callbackPath"                                   json:"callbackPath"`
+	LogoutPath            string             `mapstructure:"logoutPath"                                     json:"logoutPath"`
+	PostLogoutRedirectURL string             `mapstructure:"postLogoutRedirectUrl" validate:"omitempty,url" json:"postLogoutRedirectUrl"`
+	Scopes                []string           `mapstructure:"scopes"                                         json:"scopes"`
+	CookieDomains         []string           `mapstructure:"cookieDomains"                                  json:"cookieDomains"`
+	EmailVerified         bool               `mapstructure:"emailVerified"                                  json:"emailVerified"`
+	CookieSecure          bool               `mapstructure:"cookieSecure"                                   json:"cookieSecure"`
+}
+
+// OIDCSessionConfig OpenID Connect server side session configuration.
+type OIDCSessionConfig struct {
+	EncryptionKey     *CredentialConfig       `mapstructure:"encryptionKey"                      json:"encryptionKey"`
+	Store             *OIDCSessionStoreConfig `mapstructure:"store"         validate:"omitempty" json:"store"`
+	MaxDurationString string                  `mapstructure:"maxDuration"                        json:"maxDuration"`
+	MaxDuration       time.Duration           `                                                  json:"-"`
+	Enabled           bool                    `mapstructure:"enabled"                            json:"enabled"`
+}
+
+// OIDCSessionStoreConfig OpenID Connect session store configuration.
+type OIDCSessionStoreConfig struct {
+	Redis *OIDCSessionRedisConfig `mapstructure:"redis" validate:"omitempty"                    json:"redis"`
+	Type  string                  `mapstructure:"type"  validate:"omitempty,oneof=memory redis" json:"type"`
+}
+
+// OIDCSessionRedisConfig OpenID Connect Redis session store configuration.
+type OIDCSessionRedisConfig struct {
+	Password  *CredentialConfig `mapstructure:"password"                      json:"password"`
+	Address   string            `mapstructure:"address"   validate:"required" json:"address"`
+	Username  string            `mapstructure:"username"                      json:"username"`
+	KeyPrefix string            `mapstructure:"keyPrefix"                     json:"keyPrefix"`
+	DB        int               `mapstructure:"db"        validate:"gte=0"    json:"db"`
+	TLS       bool              `mapstructure:"tls"                           json:"tls"`
 }
 
 // HeaderOIDCAuthorizationAccess OpenID Connect or Header authorization accesses.
@@ -226,7 +724,21 @@ type HeaderOIDCAuthorizationAccess struct {
 
 // BasicAuthConfig Basic auth configurations.
 type BasicAuthConfig struct {
-	Realm string `mapstructure:"realm" validate:"required" json:"realm"`
+	BruteForceProtection *BruteForceProtectionConfig `mapstructure:"bruteForceProtection"                     json:"bruteForceProtection"`
+	Realm                string                      `mapstructure:"realm"                validate:"required" json:"realm"`
+}
+
+// BruteForceProtectionConfig Brute force protection configuration.
+type BruteForceProtectionConfig struct {
+	MaxAttemptsPerUser       *int          `mapstructure:"maxAttemptsPerUser" json:"maxAttemptsPerUser"`
+	MaxAttemptsPerIP         *int          `mapstructure:"maxAttemptsPerIP"   json:"maxAttemptsPerIP"`
+	AttemptsWindowString     string        `mapstructure:"attemptsWindow"     json:"attemptsWindow"`
+	LockoutDurationString    string        `mapstructure:"lockoutDuration"    json:"lockoutDuration"`
+	MaxLockoutDurationString string        `mapstructure:"maxLockoutDuration" json:"maxLockoutDuration"`
+	AttemptsWindow           time.Duration `                                  json:"-"`
+	LockoutDuration          time.Duration `                                  json:"-"`
+	MaxLockoutDuration       time.Duration `                                  json:"-"`
+	Enabled                  bool          `mapstructure:"enabled"            json:"enabled"`
 }
 
 // HeaderAuthConfig Header auth configuration.
@@ -238,8 +750,9 @@ type HeaderAuthConfig struct {
 
 // BasicAuthUserConfig Basic User auth configuration.
 type BasicAuthUserConfig struct {
-	Password *CredentialConfig `mapstructure:"password" validate:"required" json:"password"`
-	User     string            `mapstructure:"user"     validate:"required" json:"user"`
+	Password     *CredentialConfig `mapstructure:"password"     validate:"required_without=PasswordHash" json:"password"`
+	PasswordHash *CredentialConfig `mapstructure:"passwordHash" validate:"required_without=Password"     json:"passwordHash"`
+	User         string            `mapstructure:"user"         validate:"required"                      json:"user"`
 }
 
 // TemplateConfigItem Template configuration item.
@@ -251,27 +764,32 @@ type TemplateConfigItem struct {
 
 // TemplateConfig Templates configuration.
 type TemplateConfig struct {
-	FolderList          *TemplateConfigItem `mapstructure:"folderList"          validate:"required"                     json:"folderList"`
-	TargetList          *TemplateConfigItem `mapstructure:"targetList"          validate:"required"                     json:"targetList"`
-	NotFoundError       *TemplateConfigItem `mapstructure:"notFoundError"       validate:"required"                     json:"notFoundError"`
-	InternalServerError *TemplateConfigItem `mapstructure:"internalServerError" validate:"required"                     json:"internalServerError"`
-	UnauthorizedError   *TemplateConfigItem `mapstructure:"unauthorizedError"   validate:"required"                     json:"unauthorizedError"`
-	ForbiddenError      *TemplateConfigItem `mapstructure:"forbiddenError"      validate:"required"                     json:"forbiddenError"`
-	BadRequestError     *TemplateConfigItem `mapstructure:"badRequestError"     validate:"required"                     json:"badRequestError"`
-	Put                 *TemplateConfigItem `mapstructure:"put"                 validate:"required"                     json:"put"`
-	Delete              *TemplateConfigItem `mapstructure:"delete"              validate:"required"                     json:"delete"`
-	Helpers             []string            `mapstructure:"helpers"             validate:"required,min=1,dive,required" json:"helpers"`
+	FolderList              *TemplateConfigItem `mapstructure:"folderList"              validate:"required"                     json:"folderList"`
+	TargetList              *TemplateConfigItem `mapstructure:"targetList"              validate:"required"                     json:"targetList"`
+	NotFoundError           *TemplateConfigItem `mapstructure:"notFoundError"           validate:"required"                     json:"notFoundError"`
+	InternalServerError     *TemplateConfigItem `mapstructure:"internalServerError"     validate:"required"                     json:"internalServerError"`
+	UnauthorizedError       *TemplateConfigItem `mapstructure:"unauthorizedError"       validate:"required"                     json:"unauthorizedError"`
+	ForbiddenError          *TemplateConfigItem `mapstructure:"forbiddenError"          validate:"required"                     json:"forbiddenError"`
+	BadRequestError         *TemplateConfigItem `mapstructure:"badRequestError"         validate:"required"                     json:"badRequestError"`
+	QuotaExceededError      *TemplateConfigItem `mapstructure:"quotaExceededError"      validate:"required"                     json:"quotaExceededError"`
+	ServiceUnavailableError *TemplateConfigItem `mapstructure:"serviceUnavailableError" validate:"required"                     json:"serviceUnavailableError"`
+	Put                     *TemplateConfigItem `mapstructure:"put"                     validate:"required"                     json:"put"`
+	Delete                  *TemplateConfigItem `mapstructure:"delete"                  validate:"required"                     json:"delete"`
+	Helpers                 []string            `mapstructure:"helpers"                 validate:"required,min=1,dive,required" json:"helpers"`
 }
 
 // ServerConfig Server configuration.
 type ServerConfig struct {
-	Timeouts   *ServerTimeoutsConfig `mapstructure:"timeouts"   validate:"required"  json:"timeouts"`
-	CORS       *ServerCorsConfig     `mapstructure:"cors"       validate:"omitempty" json:"cors"`
-	Cache      *CacheConfig          `mapstructure:"cache"      validate:"omitempty" json:"cache"`
-	Compress   *ServerCompressConfig `mapstructure:"compress"   validate:"omitempty" json:"compress"`
-	SSL        *ServerSSLConfig      `mapstructure:"ssl"        validate:"omitempty" json:"ssl"`
-	ListenAddr string                `mapstructure:"listenAddr"                      json:"listenAddr"`
-	Port       int                   `mapstructure:"port"       validate:"required"  json:"port"`
+	Timeouts             *ServerTimeoutsConfig `mapstructure:"timeouts"       validate:"required"                json:"timeouts"`
+	CORS                 *ServerCorsConfig     `mapstructure:"cors"           validate:"omitempty"               json:"cors"`
+	Cache                *CacheConfig          `mapstructure:"cache"          validate:"omitempty"               json:"cache"`
+	Compress             *ServerCompressConfig `mapstructure:"compress"       validate:"omitempty"               json:"compress"`
+	SSL                  *ServerSSLConfig      `mapstructure:"ssl"            validate:"omitempty"               json:"ssl"`
+	TrustedProxies       []string              `mapstructure:"trustedProxies" validate:"omitempty,dive,required" json:"trustedProxies"`
+	TrustedProxyPrefixes []netip.Prefix        `                                                                 json:"-"`
+	ClientIPHeader       string                `mapstructure:"clientIPHeader"                                    json:"clientIPHeader"`
+	ListenAddr           string                `mapstructure:"listenAddr"                                        json:"listenAddr"`
+	Port                 int                   `mapstructure:"port"           validate:"required"                json:"port"`
 }
 
 // ServerTimeoutsConfig Server timeouts configuration.
@@ -291,12 +809,26 @@ type ServerCompressConfig struct {
 
 // ServerSSLConfig Server SSL configuration.
 type ServerSSLConfig struct {
-	MinTLSVersion       *string                 `mapstructure:"minTLSVersion"       json:"minTLSVersion"`
-	MaxTLSVersion       *string                 `mapstructure:"maxTLSVersion"       json:"maxTLSVersion"`
-	Certificates        []*ServerSSLCertificate `mapstructure:"certificates"        json:"certificates"`
-	SelfSignedHostnames []string                `mapstructure:"selfSignedHostnames" json:"selfSignedHostnames"`
-	CipherSuites        []string                `mapstructure:"cipherSuites"        json:"cipherSuites"`
-	Enabled             bool                    `mapstructure:"enabled"             json:"enabled"`
+	MinTLSVersion       *string                  `mapstructure:"minTLSVersion"       json:"minTLSVersion"`
+	MaxTLSVersion       *string                  `mapstructure:"maxTLSVersion"       json:"maxTLSVersion"`
+	Certificates        []*ServerSSLCertificate  `mapstructure:"certificates"        json:"certificates"`
+	SelfSignedHostnames []string                 `mapstructure:"selfSignedHostnames" json:"selfSignedHostnames"`
+	CipherSuites        []string                 `mapstructure:"cipherSuites"        json:"cipherSuites"`
+	ClientCA            *ServerSSLClientCAConfig `mapstructure:"clientCA"            json:"clientCA"`
+	Enabled             bool                     `mapstructure:"enabled"             json:"enabled"`
+}
+
+// ServerSSLClientCAConfig Server SSL client certificate authorities configuration.
+type ServerSSLClientCAConfig struct {
+	Mode         string                    `mapstructure:"mode"         validate:"omitempty,oneof=verifyIfGiven require" json:"mode"`
+	Certificates []*ServerSSLCACertificate `mapstructure:"certificates" validate:"omitempty,dive"                        json:"certificates"`
+}
+
+// ServerSSLCACertificate Server SSL certificate authority.
+type ServerSSLCACertificate struct {
+	Certificate          *string       `mapstructure:"certificate"          json:"certificate"`
+	CertificateURL       *string       `mapstructure:"certificateUrl"       json:"certificateUrl"`
+	CertificateURLConfig *SSLURLConfig `mapstructure:"certificateUrlConfig" json:"certificateUrlConfig"`
 }
 
 // ServerSSLCertificate Server SSL certificate.
@@ -349,7 +881,17 @@ type TargetConfig struct {
 	Mount          *MountConfig              `validate:"required" json:"mount"          mapstructure:"mount"`
 	Actions        *ActionsConfig            `                    json:"actions"        mapstructure:"actions"`
 	Templates      *TargetTemplateConfig     `                    json:"templates"      mapstructure:"templates"`
+	Concurrency    *TargetConcurrencyConfig  `validate:"omitempty" json:"concurrency"    mapstructure:"concurrency"`
 	KeyRewriteList []*TargetKeyRewriteConfig `                    json:"keyRewriteList" mapstructure:"keyRewriteList"`
+	IPFilter       *IPFilterConfig           `validate:"omitempty" json:"ipFilter"       mapstructure:"ipFilter"`
+}
+
+// TargetConcurrencyConfig Target concurrency configuration.
+type TargetConcurrencyConfig struct {
+	QueueTimeoutString string        `mapstructure:"queueTimeout" json:"queueTimeout"`
+	MaxInFlight        int           `mapstructure:"maxInFlight"  json:"maxInFlight"  validate:"required,gt=0"`
+	MaxQueueSize       int           `mapstructure:"maxQueueSize" json:"maxQueueSize" validate:"gte=0"`
+	QueueTimeout       time.Duration `                            json:"-"`
 }
 
 // TargetKeyRewriteConfig Target key rewrite configuration.
@@ -362,15 +904,17 @@ type TargetKeyRewriteConfig struct {
 
 // TargetTemplateConfig Target templates configuration to override default ones.
 type TargetTemplateConfig struct {
-	FolderList          *TargetTemplateConfigItem `mapstructure:"folderList"          json:"folderList"`
-	NotFoundError       *TargetTemplateConfigItem `mapstructure:"notFoundError"       json:"notFoundError"`
-	InternalServerError *TargetTemplateConfigItem `mapstructure:"internalServerError" json:"internalServerError"`
-	ForbiddenError      *TargetTemplateConfigItem `mapstructure:"forbiddenError"      json:"forbiddenError"`
-	UnauthorizedError   *TargetTemplateConfigItem `mapstructure:"unauthorizedError"   json:"unauthorizedError"`
-	BadRequestError     *TargetTemplateConfigItem `mapstructure:"badRequestError"     json:"badRequestError"`
-	Put                 *TargetTemplateConfigItem `mapstructure:"put"                 json:"put"`
-	Delete              *TargetTemplateConfigItem `mapstructure:"delete"              json:"delete"`
-	Helpers             []*TargetHelperConfigItem `mapstructure:"helpers"             json:"helpers"`
+	FolderList              *TargetTemplateConfigItem `mapstructure:"folderList"              json:"folderList"`
+	NotFoundError           *TargetTemplateConfigItem `mapstructure:"notFoundError"           json:"notFoundError"`
+	InternalServerError     *TargetTemplateConfigItem `mapstructure:"internalServerError"     json:"internalServerError"`
+	ForbiddenError          *TargetTemplateConfigItem `mapstructure:"forbiddenError"          json:"forbiddenError"`
+	UnauthorizedError       *TargetTemplateConfigItem `mapstructure:"unauthorizedError"       json:"unauthorizedError"`
+	BadRequestError         *TargetTemplateConfigItem `mapstructure:"badRequestError"         json:"badRequestError"`
+	QuotaExceededError      *TargetTemplateConfigItem `mapstructure:"quotaExceededError"      json:"quotaExceededError"`
+	ServiceUnavailableError *TargetTemplateConfigItem `mapstructure:"serviceUnavailableError" json:"serviceUnavailableError"`
+	Put                     *TargetTemplateConfigItem `mapstructure:"put"                     json:"put"`
+	Delete                  *TargetTemplateConfigItem `mapstructure:"delete"                  json:"delete"`
+	Helpers                 []*TargetHelperConfigItem `mapstructure:"helpers"                 json:"helpers"`
 }
 
 // TargetHelperConfigItem Target helper configuration item.
@@ -414,7 +958,8 @@ type DeleteActionConfig struct {
 
 // DeleteActionConfigConfig Delete action configuration object configuration.
 type DeleteActionConfigConfig struct {
-	Webhooks []*WebhookConfig `mapstructure:"webhooks" validate:"dive" json:"webhooks"`
+	Webhooks       []*WebhookConfig       `mapstructure:"webhooks"       validate:"dive" json:"webhooks"`
+	PreActionHooks []*PreActionHookConfig `mapstructure:"preActionHooks" validate:"dive" json:"preActionHooks"`
 }
 
 // PutActionConfig Put action configuration.
@@ -430,6 +975,7 @@ type PutActionConfigConfig struct {
 	CannedACL      *string                              `mapstructure:"cannedACL"      json:"cannedACL"`
 	StorageClass   string                               `mapstructure:"storageClass"   json:"storageClass"`
 	Webhooks       []*WebhookConfig                     `mapstructure:"webhooks"       json:"webhooks"       validate:"dive"`
+	PreActionHooks []*PreActionHookConfig               `mapstructure:"preActionHooks" json:"preActionHooks" validate:"dive"`
 	AllowOverride  bool                                 `mapstructure:"allowOverride"  json:"allowOverride"`
 }
 
@@ -450,16 +996,21 @@ type GetActionConfig struct {
 
 // GetActionConfigConfig Get action configuration object configuration.
 type GetActionConfigConfig struct {
-	StreamedFileHeaders                      map[string]string `mapstructure:"streamedFileHeaders"                      json:"streamedFileHeaders"`
-	IndexDocument                            string            `mapstructure:"indexDocument"                            json:"indexDocument"`
-	SignedURLExpirationString                string            `mapstructure:"signedUrlExpiration"                      json:"signedUrlExpiration"`
-	Webhooks                                 []*WebhookConfig  `mapstructure:"webhooks"                                 json:"webhooks"                                 validate:"dive"`
-	SignedURLExpiration                      time.Duration     `                                                        json:"-"`
-	RedirectWithTrailingSlashForNotFoundFile bool              `mapstructure:"redirectWithTrailingSlashForNotFoundFile" json:"redirectWithTrailingSlashForNotFoundFile"`
-	RedirectToSignedURL                      bool              `mapstructure:"redirectToSignedUrl"                      json:"redirectToSignedUrl"`
-	DisableListing                           bool              `mapstructure:"disableListing"                           json:"disableListing"`
-	UserIsolation                            bool              `mapstructure:"userIsolation"                            json:"userIsolation"`
-	UserIsolationAdmins                      []string          `mapstructure:"userIsolationAdmins"                      json:"userIsolationAdmins"                      validate:"omitempty,dive"`
+	StreamedFileHeaders                      map[string]string         `mapstructure:"streamedFileHeaders"                      json:"streamedFileHeaders"`
+	IndexDocument                            string                    `mapstructure:"indexDocument"                            json:"indexDocument"`
+	SignedURLExpirationString                string                    `mapstructure:"signedUrlExpiration"                      json:"signedUrlExpiration"`
+	Webhooks                                 []*WebhookConfig          `mapstructure:"webhooks"                                 json:"webhooks"                                 validate:"dive"`
+	SignedURLExpiration                      time.Duration             `                                                        json:"-"`
+	RedirectWithTrailingSlashForNotFoundFile bool                      `mapstructure:"redirectWithTrailingSlashForNotFoundFile" json:"redirectWithTrailingSlashForNotFoundFile"`
+	RedirectToSignedURL                      bool                      `mapstructure:"redirectToSignedUrl"                      json:"redirectToSignedUrl"`
+	DisableListing                           bool                      `mapstructure:"disableListing"                           json:"disableListing"`
+	UserIsolation                            bool                      `mapstructure:"userIsolation"                            json:"userIsolation"`
+	UserIsolationAdmins                      []string                  `mapstructure:"userIsolationAdmins"                      json:"userIsolationAdmins"                      validate:"omitempty,dive"`
+	UserIsolationGroups                      []string                  `mapstructure:"userIsolationGroups"                      json:"userIsolationGroups"                      validate:"omitempty,dive,required"`
+	UserIsolationPathTemplate                string                    `mapstructure:"userIsolationPathTemplate"                json:"userIsolationPathTemplate"`
+	UserIsolationGroupPathTemplate           string                    `mapstructure:"userIsolationGroupPathTemplate"           json:"userIsolationGroupPathTemplate"`
+	UserIsolationQuota                       *UserIsolationQuotaConfig `mapstructure:"userIsolationQuota"                       json:"userIsolationQuota"                       validate:"omitempty"`
+	Precompressed                            *PrecompressedConfig      `mapstructure:"precompressed"                            json:"precompressed"                            validate:"omitempty"`
 	// userIsolationAdminsSet is a derived O(1) lookup set populated at
 	// config validation time. It is not part of the input schema and is
 	// safe for concurrent reads after validation completes.
@@ -500,10 +1051,88 @@ func (c *GetActionConfigConfig) indexUserIsolationAdmins() {
 	c.userIsolationAdminsSet = set
 }
 
+// UserIsolationQuotaConfig User isolation quota configuration.
+type UserIsolationQuotaConfig struct {
+	Default                 *QuotaLimitConfig            `mapstructure:"default"           json:"default"           validate:"omitempty"`
+	Users                   map[string]*QuotaLimitConfig `mapstructure:"users"             json:"users"             validate:"omitempty,dive,required"`
+	Groups                  map[string]*QuotaLimitConfig `mapstructure:"groups"            json:"groups"            validate:"omitempty,dive,required"`
+	ReconcileIntervalString string                       `mapstructure:"reconcileInterval" json:"reconcileInterval"`
+	ReconcileInterval       time.Duration                `                                 json:"-"`
+}
+
+// PrecompressedConfig Precompressed variants configuration.
+type PrecompressedConfig struct {
+	CacheExpirationString string        `mapstructure:"cacheExpiration" json:"cacheExpiration"`
+	Encodings             []string      `mapstructure:"encodings"       json:"encodings"       validate:"omitempty,dive,oneof=br gzip"`
+	CacheExpiration       time.Duration `                               json:"-"`
+	Enabled               bool          `mapstructure:"enabled"         json:"enabled"`
+}
+
+// QuotaLimitConfig Quota limit configuration.
+// A zero value means that the dimension is unlimited.
+type QuotaLimitConfig struct {
+	MaxBytes   int64 `mapstructure:"maxBytes"   json:"maxBytes"   validate:"gte=0"`
+	MaxObjects int64 `mapstructure:"maxObjects" json:"maxObjects" validate:"gte=0"`
+}
+
+// ResolveLimit returns the quota limit applicable to a user.
+// A limit declared for the user identifier wins. Otherwise, the most
+// permissive limit of all matching groups is used (per dimension, zero
+// being unlimited). Otherwise, the default limit is returned.
+// Nil is returned when no limit applies.
+func (c *UserIsolationQuotaConfig) ResolveLimit(identifier string, groups []string) *QuotaLimitConfig {
+	if c == nil {
+		return nil
+	}
+
+	// Check user specific limit
+	if l, ok := c.Users[identifier]; ok {
+		return l
+	}
+
+	// Merge all matching group limits
+	var res *QuotaLimitConfig
+
+	for _, g := range groups {
+		l, ok := c.Groups[g]
+		if !ok {
+			continue
+		}
+
+		// Check if it is the first one
+		if res == nil {
+			res = &QuotaLimitConfig{MaxBytes: l.MaxBytes, MaxObjects: l.MaxObjects}
+
+			continue
+		}
+
+		res.MaxBytes = mostPermissiveQuotaLimit(res.MaxBytes, l.MaxBytes)
+		res.MaxObjects = mostPermissiveQuotaLimit(res.MaxObjects, l.MaxObjects)
+	}
+
+	// Check if a group limit have been found
+	if res != nil {
+		return res
+	}
+
+	return c.Default
+}
+
+// mostPermissiveQuotaLimit returns the most permissive quota limit value,
+// zero meaning unlimited.
+func mostPermissiveQuotaLimit(a, b int64) int64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+
+	return max(a, b)
+}
+
 // WebhookConfig Webhook configuration.
 type WebhookConfig struct {
 	Headers         map[string]string            `mapstructure:"headers"         json:"headers"`
 	SecretHeaders   map[string]*CredentialConfig `mapstructure:"secretHeaders"   json:"secretHeaders"   validate:"omitempty"`
+	Signature       *WebhookSignatureConfig      `mapstructure:"signature"       json:"signature"       validate:"omitempty"`
 	Method          string                       `mapstructure:"method"          json:"method"          validate:"required,oneof=POST PATCH PUT DELETE"`
 	URL             string                       `mapstructure:"url"             json:"url"             validate:"required,url"`
 	MaxWaitTime     string                       `mapstructure:"maxWaitTime"     json:"maxWaitTime"`
@@ -511,32 +1140,153 @@ type WebhookConfig struct {
 	RetryCount      int                          `mapstructure:"retryCount"      json:"retryCount"      validate:"gte=0"`
 }
 
+// PreActionHookConfig Blocking hook called before an action to allow, deny or modify it.
+type PreActionHookConfig struct {
+	Headers             map[string]string            `mapstructure:"headers"             json:"headers"`
+	SecretHeaders       map[string]*CredentialConfig `mapstructure:"secretHeaders"       json:"secretHeaders"       validate:"omitempty"`
+	Signature           *WebhookSignatureConfig      `mapstructure:"signature"           json:"signature"           validate:"omitempty"`
+	Method              string                       `mapstructure:"method"              json:"method"              validate:"required,oneof=POST PATCH PUT"`
+	URL                 string                       `mapstructure:"url"                 json:"url"                 validate:"required,url"`
+	TimeoutString       string                       `mapstructure:"timeout"             json:"timeout"`
+	FailurePolicy       string                       `mapstructure:"failurePolicy"       json:"failurePolicy"       validate:"omitempty,oneof=open closed"`
+	RewritePathPrefixes []string                     `mapstructure:"rewritePathPrefixes" json:"rewritePathPrefixes"`
+	Timeout             time.Duration                `                                   json:"-"`
+}
+
+// WebhookSignatureConfig Webhook payload signature configuration.
+type WebhookSignatureConfig struct {
+	Header  string              `mapstructure:"header"  json:"header"`
+	Secrets []*CredentialConfig `mapstructure:"secrets" json:"secrets" validate:"required,min=1,dive,required"`
+}
+
 // Resource Resource.
 type Resource struct {
-	WhiteList *bool               `mapstructure:"whiteList" json:"whiteList"`
-	Basic     *ResourceBasic      `mapstructure:"basic"     json:"basic"     validate:"omitempty"`
-	OIDC      *ResourceHeaderOIDC `mapstructure:"oidc"      json:"oidc"      validate:"omitempty"`
-	Header    *ResourceHeaderOIDC `mapstructure:"header"    json:"header"    validate:"omitempty"`
-	Path      string              `mapstructure:"path"      json:"path"      validate:"required"`
-	Provider  string              `mapstructure:"provider"  json:"provider"`
-	Methods   []string            `mapstructure:"methods"   json:"methods"   validate:"required,dive,required"`
+	WhiteList         *bool               `mapstructure:"whiteList"         json:"whiteList"`
+	Basic             *ResourceBasic      `mapstructure:"basic"             json:"basic"             validate:"omitempty"`
+	OIDC              *ResourceHeaderOIDC `mapstructure:"oidc"              json:"oidc"              validate:"omitempty"`
+	Header            *ResourceHeaderOIDC `mapstructure:"header"            json:"header"            validate:"omitempty"`
+	JWT               *ResourceHeaderOIDC `mapstructure:"jwt"               json:"jwt"               validate:"omitempty"`
+	APIKey            *ResourceHeaderOIDC `mapstructure:"apiKey"            json:"apiKey"            validate:"omitempty"`
+	MTLS              *ResourceHeaderOIDC `mapstructure:"mtls"              json:"mtls"              validate:"omitempty"`
+	LDAP              *ResourceHeaderOIDC `mapstructure:"ldap"              json:"ldap"              validate:"omitempty"`
+	HMAC              *ResourceHeaderOIDC `mapstructure:"hmac"              json:"hmac"              validate:"omitempty"`
+	IPFilter          *IPFilterConfig     `mapstructure:"ipFilter"          json:"ipFilter"          validate:"omitempty"`
+	PathRegex         *regexp.Regexp      `                                 json:"-"`
+	Path              string              `mapstructure:"path"              json:"path"              validate:"required"`
+	Host              string              `mapstructure:"host"              json:"host"`
+	Provider          string              `mapstructure:"provider"          json:"provider"`
+	Providers         []string            `mapstructure:"providers"         json:"providers"         validate:"omitempty,dive,required"`
+	Methods           []string            `mapstructure:"methods"           json:"methods"           validate:"required,dive,required"`
+	Priority          int                 `mapstructure:"priority"          json:"priority"`
+	Regexp            bool                `mapstructure:"regexp"            json:"regexp"`
+	IgnoreQueryString bool                `mapstructure:"ignoreQueryString" json:"ignoreQueryString"`
+	Deny              bool                `mapstructure:"deny"              json:"deny"`
+}
+
+// GetProviderResource returns a copy of the resource restricted to the selected provider.
+// Only the authentication configuration linked to the provider type is kept.
+// Nil is returned if the provider isn't declared in authentication providers or if the resource
+// doesn't have the authentication configuration for this provider type.
+func (r *Resource) GetProviderResource(authProviders *AuthProviderConfig, provider string) *Resource {
+	// Check if authentication providers exist
+	if authProviders == nil {
+		return nil
+	}
+
+	// Create result
+	res := &Resource{
+		WhiteList:         r.WhiteList,
+		IPFilter:          r.IPFilter,
+		PathRegex:         r.PathRegex,
+		Path:              r.Path,
+		Host:              r.Host,
+		Provider:          provider,
+		Methods:           r.Methods,
+		Priority:          r.Priority,
+		Regexp:            r.Regexp,
+		IgnoreQueryString: r.IgnoreQueryString,
+		Deny:              r.Deny,
+	}
+
+	// Check provider type
+	switch {
+	case r.OIDC != nil && authProviders.OIDC[provider] != nil:
+		res.OIDC = r.OIDC
+	case r.Header != nil && authProviders.Header[provider] != nil:
+		res.Header = r.Header
+	case r.JWT != nil && authProviders.JWT[provider] != nil:
+		res.JWT = r.JWT
+	case r.APIKey != nil && authProviders.APIKey[provider] != nil:
+		res.APIKey = r.APIKey
+	case r.MTLS != nil && authProviders.MTLS[provider] != nil:
+		res.MTLS = r.MTLS
+	case r.LDAP != nil && authProviders.LDAP[provider] != nil:
+		res.LDAP = r.LDAP
+	case r.HMAC != nil && authProviders.HMAC[provider] != nil:
+		res.HMAC = r.HMAC
+	case r.Basic != nil && authProviders.Basic[provider] != nil:
+		res.Basic = r.Basic
+	default:
+		return nil
+	}
+
+	return res
+}
+
+// SortResourcesByPriority returns a copy of resources sorted by descending priority.
+// Declaration order is kept for resources with the same priority.
+func SortResourcesByPriority(resources []*Resource) []*Resource {
+	// Copy list
+	res := slices.Clone(resources)
+	// Sort
+	slices.SortStableFunc(res, func(a, b *Resource) int {
+		return b.Priority - a.Priority
+	})
+
+	return res
 }
 
 // ResourceBasic Basic auth resource.
 type ResourceBasic struct {
-	Credentials []*BasicAuthUserConfig `mapstructure:"credentials" validate:"omitempty,dive" json:"credentials"`
+	HtpasswdFile        string                 `mapstructure:"htpasswdFile"                                json:"htpasswdFile"`
+	Credentials         []*BasicAuthUserConfig `mapstructure:"credentials"       validate:"omitempty,dive" json:"credentials"`
+	HtpasswdCredentials []*BasicAuthUserConfig `mapstructure:"-"                                           json:"-"`
+	AuthorizationRBAC   bool                   `mapstructure:"authorizationRBAC"                           json:"authorizationRBAC"`
+}
+
+// GetAllCredentials returns credentials declared in configuration and in htpasswd file.
+func (rb *ResourceBasic) GetAllCredentials() []*BasicAuthUserConfig {
+	res := make([]*BasicAuthUserConfig, 0, len(rb.Credentials)+len(rb.HtpasswdCredentials))
+	res = append(res, rb.Credentials...)
+	res = append(res, rb.HtpasswdCredentials...)
+
+	return res
 }
 
 // ResourceHeaderOIDC OIDC or Header auth Resource.
 type ResourceHeaderOIDC struct {
 	AuthorizationOPAServer *OPAServerAuthorization          `mapstructure:"authorizationOPAServer" validate:"omitempty"      json:"authorizationOPAServer"`
+	AuthorizationRego      *RegoAuthorization               `mapstructure:"authorizationRego"      validate:"omitempty"      json:"authorizationRego"`
 	AuthorizationAccesses  []*HeaderOIDCAuthorizationAccess `mapstructure:"authorizationAccesses"  validate:"omitempty,dive" json:"authorizationAccesses"`
+	AuthorizationRBAC      bool                             `mapstructure:"authorizationRBAC"                                json:"authorizationRBAC"`
 }
 
 // OPAServerAuthorization OPA Server authorization.
 type OPAServerAuthorization struct {
-	Tags map[string]string `mapstructure:"tags" json:"tags"`
-	URL  string            `mapstructure:"url"  json:"url"  validate:"required,url"`
+	Tags                        map[string]string `mapstructure:"tags"                  json:"tags"`
+	URL                         string            `mapstructure:"url"                   json:"url"                   validate:"required,url"`
+	DecisionCacheDurationString string            `mapstructure:"decisionCacheDuration" json:"decisionCacheDuration"`
+	DecisionCacheDuration       time.Duration     `                                     json:"-"`
+	IncludeObjectMetadata       bool              `mapstructure:"includeObjectMetadata" json:"includeObjectMetadata"`
+}
+
+// RegoAuthorization Embedded Rego policy authorization.
+type RegoAuthorization struct {
+	Tags                        map[string]string `mapstructure:"tags"                                      json:"tags"`
+	Policy                      string            `mapstructure:"policy"                validate:"required" json:"policy"`
+	DecisionCacheDurationString string            `mapstructure:"decisionCacheDuration"                     json:"decisionCacheDuration"`
+	DecisionCacheDuration       time.Duration     `                                                         json:"-"`
+	IncludeObjectMetadata       bool              `mapstructure:"includeObjectMetadata"                     json:"includeObjectMetadata"`
 }
 
 // BucketConfig Bucket configuration.
@@ -552,6 +1302,9 @@ type BucketConfig struct {
 	S3UploadPartSize          int64                   `mapstructure:"s3UploadPartSize"          validate:"required,gte=5" json:"s3UploadPartSize"`
 	S3UploadConcurrency       int                     `mapstructure:"s3UploadConcurrency"       validate:"required,gte=1" json:"s3UploadConcurrency"`
 	S3UploadLeavePartsOnError bool                    `mapstructure:"s3UploadLeavePartsOnError"                           json:"s3UploadLeavePartsOnError"`
+	S3DownloadParallel        bool                    `mapstructure:"s3DownloadParallel"                                  json:"s3DownloadParallel"`
+	S3DownloadPartSize        int64                   `mapstructure:"s3DownloadPartSize"        validate:"required,gte=1" json:"s3DownloadPartSize"`
+	S3DownloadConcurrency     int                     `mapstructure:"s3DownloadConcurrency"     validate:"required,gte=1" json:"s3DownloadConcurrency"`
 	DisableSSL                bool                    `mapstructure:"disableSSL"                                          json:"disableSSL"`
 	S3ForcePathStyle          *bool                   `mapstructure:"s3ForcePathStyle"                                    json:"s3ForcePathStyle"`
 }
@@ -3,6 +3,7 @@
 package config
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -59,3 +60,145 @@ func TestBucketConfig_GetRootPrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestUserIsolationQuotaConfig_ResolveLimit(t *testing.T) {
+	quotaCfg := &UserIsolationQuotaConfig{
+		Default: &QuotaLimitConfig{MaxBytes: 10, MaxObjects: 1},
+		Users: map[string]*QuotaLimitConfig{
+			"alice": {MaxBytes: 5},
+		},
+		Groups: map[string]*QuotaLimitConfig{
+			"team1": {MaxBytes: 100, MaxObjects: 10},
+			"team2": {MaxBytes: 50, MaxObjects: 0},
+		},
+	}
+	tests := []struct {
+		cfg        *UserIsolationQuotaConfig
+		want       *QuotaLimitConfig
+		name       string
+		identifier string
+		groups     []string
+	}{
+		{
+			name:       "Must return nil without configuration",
+			identifier: "alice",
+		},
+		{
+			name:       "Must return user limit first",
+			cfg:        quotaCfg,
+			identifier: "alice",
+			groups:     []string{"team1"},
+			want:       &QuotaLimitConfig{MaxBytes: 5},
+		},
+		{
+			name:       "Must return group limit",
+			cfg:        quotaCfg,
+			identifier: "bob",
+			groups:     []string{"unknown", "team1"},
+			want:       &QuotaLimitConfig{MaxBytes: 100, MaxObjects: 10},
+		},
+		{
+			name:       "Must return most permissive group limits",
+			cfg:        quotaCfg,
+			identifier: "bob",
+			groups:     []string{"team1", "team2"},
+			want:       &QuotaLimitConfig{MaxBytes: 100, MaxObjects: 0},
+		},
+		{
+			name:       "Must return default limit",
+			cfg:        quotaCfg,
+			identifier: "bob",
+			groups:     []string{"unknown"},
+			want:       &QuotaLimitConfig{MaxBytes: 10, MaxObjects: 1},
+		},
+		{
+			name:       "Must return nil without default",
+			cfg:        &UserIsolationQuotaConfig{},
+			identifier: "bob",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.ResolveLimit(tt.identifier, tt.groups); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserIsolationQuotaConfig.ResolveLimit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResource_GetProviderResource(t *testing.T) {
+	authProviders := &AuthProviderConfig{
+		Basic: map[string]*BasicAuthConfig{"basic1": {Realm: "realm"}},
+		OIDC:  map[string]*OIDCAuthConfig{"oidc1": {}},
+		JWT:   map[string]*JWTAuthConfig{"jwt1": {}},
+	}
+	basic := &ResourceBasic{}
+	oidc := &ResourceHeaderOIDC{}
+	res := &Resource{
+		Path:      "/*",
+		Methods:   []string{"GET"},
+		Providers: []string{"oidc1", "basic1"},
+		Basic:     basic,
+		OIDC:      oidc,
+	}
+	tests := []struct {
+		authProviders *AuthProviderConfig
+		want          *Resource
+		name          string
+		provider      string
+	}{
+		{
+			name:          "Must return oidc resource",
+			authProviders: authProviders,
+			provider:      "oidc1",
+			want:          &Resource{Path: "/*", Methods: []string{"GET"}, Provider: "oidc1", OIDC: oidc},
+		},
+		{
+			name:          "Must return basic resource",
+			authProviders: authProviders,
+			provider:      "basic1",
+			want:          &Resource{Path: "/*", Methods: []string{"GET"}, Provider: "basic1", Basic: basic},
+		},
+		{
+			name:          "Must return nil without matching authentication configuration",
+			authProviders: authProviders,
+			provider:      "jwt1",
+		},
+		{
+			name:          "Must return nil with unknown provider",
+			authProviders: authProviders,
+			provider:      "unknown",
+		},
+		{
+			name:     "Must return nil without authentication providers",
+			provider: "oidc1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := res.GetProviderResource(tt.authProviders, tt.provider); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resource.GetProviderResource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortResourcesByPriority(t *testing.T) {
+	r1 := &Resource{Path: "/1"}
+	r2 := &Resource{Path: "/2", Priority: 10}
+	r3 := &Resource{Path: "/3"}
+	r4 := &Resource{Path: "/4", Priority: -1}
+	r5 := &Resource{Path: "/5", Priority: 10}
+	input := []*Resource{r1, r2, r3, r4, r5}
+
+	got := SortResourcesByPriority(input)
+
+	want := []*Resource{r2, r5, r1, r3, r4}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortResourcesByPriority() = %v, want %v", got, want)
+	}
+	// Input mustn't be modified
+	if !reflect.DeepEqual(input, []*Resource{r1, r2, r3, r4, r5}) {
+		t.Errorf("SortResourcesByPriority() modified input %v", input)
+	}
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// lintConfig will return warnings about configuration parts that are valid but probably wrong.
+func lintConfig(out *Config) []string {
+	// Initialize result
+	res := make([]string, 0)
+
+	// Get target keys in order to have a stable result
+	keys := make([]string, 0, len(out.Targets))
+	for key := range out.Targets {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	// Loop over targets
+	for _, key := range keys {
+		res = append(res, lintResources(fmt.Sprintf("target %s", key), out.Targets[key].Resources)...)
+	}
+
+	return res
+}
+
+// lintResources will return warnings about resources shadowed by other resources
+// with a higher priority or declared before with the same priority.
+func lintResources(beginMessage string, resources []*Resource) []string {
+	// Initialize result
+	res := make([]string, 0)
+	// Get resource indexes sorted by priority
+	indexes := make([]int, len(resources))
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	slices.SortStableFunc(indexes, func(a, b int) int {
+		return resources[b].Priority - resources[a].Priority
+	})
+
+	// Loop over resources
+	for j, bIdx := range indexes {
+		b := resources[bIdx]
+		// Methods not shadowed yet
+		remaining := slices.Clone(b.Methods)
+
+		// Loop over resources evaluated before
+		for _, aIdx := range indexes[:j] {
+			a := resources[aIdx]
+			// Check if resource covers the other one
+			if !isResourceCovering(a, b) {
+				continue
+			}
+
+			// Compute shadowed methods
+			shadowed := make([]string, 0)
+			// Loop over remaining methods
+			for _, m := range remaining {
+				if slices.Contains(a.Methods, m) {
+					shadowed = append(shadowed, m)
+				}
+			}
+
+			// Check if nothing is shadowed
+			if len(shadowed) == 0 {
+				continue
+			}
+
+			// Remove shadowed methods
+			remaining = slices.DeleteFunc(remaining, func(m string) bool { return slices.Contains(shadowed, m) })
+
+			res = append(res, fmt.Sprintf(
+				"resource %d from %s is shadowed by resource %d for methods %s",
+				bIdx, beginMessage, aIdx, strings.Join(shadowed, ", "),
+			))
+		}
+
+		// Check if all methods are shadowed
+		if len(remaining) == 0 && len(b.Methods) != 0 {
+			res = append(res, fmt.Sprintf("resource %d from %s is unreachable", bIdx, beginMessage))
+		}
+	}
+
+	return res
+}
+
+// isResourceCovering will check if all requests matching the second resource are matching the first one.
+// Methods aren't checked.
+func isResourceCovering(a, b *Resource) bool {
+	// Check host
+	if a.Host != "" && a.Host != b.Host {
+		return false
+	}
+
+	// Check query string
+	// A resource matching query string won't match all requests of a resource ignoring it
+	if !a.IgnoreQueryString && b.IgnoreQueryString {
+		return false
+	}
+
+	// Check same path
+	if a.Regexp == b.Regexp && a.Path == b.Path {
+		return true
+	}
+
+	// Regexp can't be compared
+	if a.Regexp || b.Regexp {
+		return false
+	}
+
+	// Check if first path is a prefix with super wildcard at the end
+	if prefix, ok := strings.CutSuffix(a.Path, "**"); ok && !strings.ContainsAny(prefix, "*?[]{}\\") &&
+		strings.HasPrefix(b.Path, prefix) {
+		return true
+	}
+
+	// Check if second path is a fixed path
+	if strings.ContainsAny(b.Path, "*?[]{}\\") {
+		return false
+	}
+
+	// Compile glob
+	g, err := glob.Compile(a.Path, '/')
+	// Check error
+	if err != nil {
+		return false
+	}
+
+	return g.Match(b.Path)
+}
@@ -0,0 +1,137 @@
+//go:build unit
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_lintResources(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []*Resource
+		want      []string
+	}{
+		{
+			name: "No shadowed resources",
+			resources: []*Resource{
+				{Path: "/mount/folder1/*", Methods: []string{"GET"}},
+				{Path: "/mount/folder2/*", Methods: []string{"GET"}},
+				{Path: "/mount/*", Methods: []string{"PUT"}},
+			},
+			want: []string{},
+		},
+		{
+			name: "Unreachable resource after super wildcard",
+			resources: []*Resource{
+				{Path: "/mount/**", Methods: []string{"GET", "PUT"}},
+				{Path: "/mount/folder/*", Methods: []string{"GET"}},
+			},
+			want: []string{
+				"resource 1 from target tgt is shadowed by resource 0 for methods GET",
+				"resource 1 from target tgt is unreachable",
+			},
+		},
+		{
+			name: "Partially shadowed resource",
+			resources: []*Resource{
+				{Path: "/mount/*", Methods: []string{"GET"}},
+				{Path: "/mount/file", Methods: []string{"GET", "PUT"}},
+			},
+			want: []string{"resource 1 from target tgt is shadowed by resource 0 for methods GET"},
+		},
+		{
+			name: "Unreachable resource shadowed by multiple resources",
+			resources: []*Resource{
+				{Path: "/mount/*", Methods: []string{"GET"}},
+				{Path: "/mount/file", Methods: []string{"PUT"}},
+				{Path: "/mount/file", Methods: []string{"GET", "PUT"}},
+			},
+			want: []string{
+				"resource 2 from target tgt is shadowed by resource 0 for methods GET",
+				"resource 2 from target tgt is shadowed by resource 1 for methods PUT",
+				"resource 2 from target tgt is unreachable",
+			},
+		},
+		{
+			name: "Priority avoids shadowing",
+			resources: []*Resource{
+				{Path: "/mount/**", Methods: []string{"GET"}},
+				{Path: "/mount/folder/*", Methods: []string{"GET"}, Priority: 10},
+			},
+			want: []string{},
+		},
+		{
+			name: "Priority creates shadowing",
+			resources: []*Resource{
+				{Path: "/mount/folder/*", Methods: []string{"GET"}},
+				{Path: "/mount/**", Methods: []string{"GET"}, Priority: 10},
+			},
+			want: []string{
+				"resource 0 from target tgt is shadowed by resource 1 for methods GET",
+				"resource 0 from target tgt is unreachable",
+			},
+		},
+		{
+			name: "Host restricted resource doesn't shadow",
+			resources: []*Resource{
+				{Path: "/mount/**", Host: "example.com", Methods: []string{"GET"}},
+				{Path: "/mount/folder/*", Methods: []string{"GET"}},
+			},
+			want: []string{},
+		},
+		{
+			name: "Resource matching query string doesn't shadow resource ignoring it",
+			resources: []*Resource{
+				{Path: "/mount/**", Methods: []string{"GET"}},
+				{Path: "/mount/folder/*", Methods: []string{"GET"}, IgnoreQueryString: true},
+			},
+			want: []string{},
+		},
+		{
+			name: "Same regexp",
+			resources: []*Resource{
+				{Path: "^/mount/.*$", Regexp: true, Methods: []string{"GET"}},
+				{Path: "^/mount/.*$", Regexp: true, Methods: []string{"GET"}},
+				{Path: "/mount/file", Methods: []string{"GET"}},
+			},
+			want: []string{
+				"resource 1 from target tgt is shadowed by resource 0 for methods GET",
+				"resource 1 from target tgt is unreachable",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lintResources("target tgt", tt.resources); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lintResources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_lintConfig(t *testing.T) {
+	cfg := &Config{
+		Targets: map[string]*TargetConfig{
+			"tgt2": {Resources: []*Resource{
+				{Path: "/mount/**", Methods: []string{"GET"}},
+				{Path: "/mount/file", Methods: []string{"GET"}},
+			}},
+			"tgt1": {Resources: []*Resource{
+				{Path: "/mount/*", Methods: []string{"GET"}},
+				{Path: "/mount/*", Methods: []string{"GET"}},
+			}},
+		},
+	}
+
+	want := []string{
+		"resource 1 from target tgt1 is shadowed by resource 0 for methods GET",
+		"resource 1 from target tgt1 is unreachable",
+		"resource 1 from target tgt2 is shadowed by resource 0 for methods GET",
+		"resource 1 from target tgt2 is unreachable",
+	}
+	if got := lintConfig(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("lintConfig() = %v, want %v", got, want)
+	}
+}
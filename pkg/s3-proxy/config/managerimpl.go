@@ -3,10 +3,12 @@ package config
 import (
 	"fmt"
 	"net/http"
+	"net/netip"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +21,7 @@ import (
 
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/utils/generalutils"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/utils/passwordhash"
 )
 
 var validate = validator.New()
@@ -182,6 +185,12 @@ func (*managerimpl) loadDefaultConfigurationValues(vip *viper.Viper) {
 	vip.SetDefault("templates.badRequestError.path", DefaultTemplateBadRequestErrorPath)
 	vip.SetDefault("templates.badRequestError.headers", DefaultTemplateHeaders)
 	vip.SetDefault("templates.badRequestError.status", DefaultTemplateStatusBadRequest)
+	vip.SetDefault("templates.quotaExceededError.path", DefaultTemplateQuotaExceededErrorPath)
+	vip.SetDefault("templates.quotaExceededError.headers", DefaultTemplateHeaders)
+	vip.SetDefault("templates.quotaExceededError.status", DefaultTemplateStatusQuotaExceeded)
+	vip.SetDefault("templates.serviceUnavailableError.path", DefaultTemplateServiceUnavailableErrorPath)
+	vip.SetDefault("templates.serviceUnavailableError.headers", DefaultTemplateHeaders)
+	vip.SetDefault("templates.serviceUnavailableError.status", DefaultTemplateStatusServiceUnavailable)
 	vip.SetDefault("templates.put.path", DefaultTemplatePutPath)
 	vip.SetDefault("templates.put.headers", DefaultEmptyTemplateHeaders)
 	vip.SetDefault("templates.put.status", DefaultTemplateStatusNoContent)
@@ -294,16 +303,217 @@ func (impl *managerimpl) loadConfiguration() error {
 		}
 	})
 
+	// Load all api keys files
+	err = impl.loadAllAPIKeysFiles(&out)
+	if err != nil {
+		return err
+	}
+
+	// Load all htpasswd files
+	err = impl.loadAllHtpasswdFiles(&out)
+	if err != nil {
+		return err
+	}
+
 	err = validateBusinessConfig(&out)
 	if err != nil {
 		return err
 	}
 
+	// Log configuration warnings
+	for _, w := range lintConfig(&out) {
+		impl.logger.Warn(w)
+	}
+
 	impl.cfg = &out
 
 	return nil
 }
 
+// loadAllAPIKeysFiles will load api keys files and watch them for changes.
+func (impl *managerimpl) loadAllAPIKeysFiles(out *Config) error {
+	// Check if api key providers exist
+	if out.AuthProviders == nil || out.AuthProviders.APIKey == nil {
+		return nil
+	}
+
+	for prov, apiKeyCfg := range out.AuthProviders.APIKey {
+		// Check if keys file is set
+		if apiKeyCfg.KeysFile == "" {
+			continue
+		}
+
+		// Load file
+		err := loadAPIKeysFile(apiKeyCfg)
+		if err != nil {
+			return err
+		}
+
+		// Create channel
+		ch := make(chan bool)
+		// Run the watch file
+		impl.watchInternalFile(apiKeyCfg.KeysFile, ch, func() {
+			// File change detected
+			impl.logger.Infof("Reload api keys file detected for path %s", apiKeyCfg.KeysFile)
+
+			// Reload keys
+			err2 := loadAPIKeysFile(apiKeyCfg)
+			if err2 == nil {
+				err2 = validateAPIKeyAuthConfig(prov, apiKeyCfg)
+			}
+
+			if err2 != nil {
+				impl.logger.Error(err2)
+				// Stop here and do not call hooks => configuration is unstable
+				return
+			}
+			// Call all hooks
+			funk.ForEach(impl.onChangeHooks, func(hook func()) { hook() })
+		})
+		// Add channel to list of channels
+		impl.internalFileWatchChannels = append(impl.internalFileWatchChannels, ch)
+	}
+
+	return nil
+}
+
+// loadAPIKeysFile will load keys from api keys file.
+func loadAPIKeysFile(apiKeyCfg *APIKeyAuthConfig) error {
+	// Create viper instance for file
+	vip := viper.New()
+	vip.SetConfigFile(apiKeyCfg.KeysFile)
+
+	// Read file
+	err := vip.ReadInConfig()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Unmarshal
+	var out APIKeysFileConfig
+
+	err = vip.Unmarshal(&out)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Validate structure
+	err = validate.Struct(out)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Load values
+	for _, k := range out.Keys {
+		err = loadAPIKeyValues(k)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Save keys
+	apiKeyCfg.FileKeys = out.Keys
+
+	return nil
+}
+
+// loadAllHtpasswdFiles will load htpasswd files declared in basic auth resources and watch them for changes.
+func (impl *managerimpl) loadAllHtpasswdFiles(out *Config) error {
+	// Get all basic auth resources
+	list := make([]*ResourceBasic, 0)
+
+	for _, item := range out.Targets {
+		for _, res := range item.Resources {
+			if res.Basic != nil {
+				list = append(list, res.Basic)
+			}
+		}
+	}
+
+	if out.ListTargets != nil && out.ListTargets.Resource != nil && out.ListTargets.Resource.Basic != nil {
+		list = append(list, out.ListTargets.Resource.Basic)
+	}
+
+	for _, basicCfg := range list {
+		// Check if htpasswd file is set
+		if basicCfg.HtpasswdFile == "" {
+			continue
+		}
+
+		// Load file
+		err := loadHtpasswdFile(basicCfg)
+		if err != nil {
+			return err
+		}
+
+		// Create channel
+		ch := make(chan bool)
+		// Run the watch file
+		impl.watchInternalFile(basicCfg.HtpasswdFile, ch, func() {
+			// File change detected
+			impl.logger.Infof("Reload htpasswd file detected for path %s", basicCfg.HtpasswdFile)
+
+			// Reload credentials
+			err2 := loadHtpasswdFile(basicCfg)
+			if err2 != nil {
+				impl.logger.Error(err2)
+				// Stop here and do not call hooks => configuration is unstable
+				return
+			}
+			// Call all hooks
+			funk.ForEach(impl.onChangeHooks, func(hook func()) { hook() })
+		})
+		// Add channel to list of channels
+		impl.internalFileWatchChannels = append(impl.internalFileWatchChannels, ch)
+	}
+
+	return nil
+}
+
+// loadHtpasswdFile will load credentials from htpasswd file.
+// Only bcrypt, argon2id and SHA-crypt hashes are supported.
+func loadHtpasswdFile(basicCfg *ResourceBasic) error {
+	// Read file
+	databytes, err := os.ReadFile(basicCfg.HtpasswdFile)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Initialize result
+	res := make([]*BasicAuthUserConfig, 0)
+
+	for i, line := range strings.Split(string(databytes), "\n") {
+		// Clean line
+		line = strings.TrimSpace(line)
+		// Ignore empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Split user and hash
+		user, hash, found := strings.Cut(line, ":")
+		if !found || user == "" || hash == "" {
+			return errors.Errorf("htpasswd file %s has an invalid line %d", basicCfg.HtpasswdFile, i+1)
+		}
+
+		// Validate hash format
+		err = passwordhash.Validate(hash)
+		if err != nil {
+			return errors.Wrapf(err, "htpasswd file %s has an invalid password hash for user %s", basicCfg.HtpasswdFile, user)
+		}
+
+		res = append(res, &BasicAuthUserConfig{
+			User:         user,
+			PasswordHash: &CredentialConfig{Value: hash},
+		})
+	}
+
+	// Save credentials
+	basicCfg.HtpasswdCredentials = res
+
+	return nil
+}
+
 // GetConfig allow to get configuration object.
 func (impl *managerimpl) GetConfig() *Config {
 	return impl.cfg
@@ -322,17 +532,13 @@ func loadAllCredentials(out *Config) ([]*CredentialConfig, error) {
 				res := item.Resources[j]
 				// Check if basic auth configuration exists
 				if res.Basic != nil && res.Basic.Credentials != nil {
-					// Loop over creds
-					for k := range len(res.Basic.Credentials) {
-						it := res.Basic.Credentials[k]
-						// Load credential
-						err := loadCredential(it.Password)
-						if err != nil {
-							return nil, err
-						}
-						// Save credential
-						result = append(result, it.Password)
+					// Load creds
+					creds, err := loadBasicAuthCredentials(res.Basic.Credentials)
+					if err != nil {
+						return nil, err
 					}
+					// Save credentials
+					result = append(result, creds...)
 				}
 			}
 		}
@@ -350,6 +556,18 @@ func loadAllCredentials(out *Config) ([]*CredentialConfig, error) {
 				result = append(result, res...)
 			}
 
+			// Check if HEAD actions are declared and webhook configs
+			if item.Actions.HEAD != nil && item.Actions.HEAD.Config != nil {
+				// Load webhook secrets
+				res, err := loadWebhookCfgCredentials(item.Actions.HEAD.Config.Webhooks)
+				// Check error
+				if err != nil {
+					return nil, err
+				}
+				// Save credential
+				result = append(result, res...)
+			}
+
 			// Check if PUT actions are declared and webhook configs
 			if item.Actions.PUT != nil && item.Actions.PUT.Config != nil {
 				// Load webhook secrets
@@ -360,6 +578,15 @@ func loadAllCredentials(out *Config) ([]*CredentialConfig, error) {
 				}
 				// Save credential
 				result = append(result, res...)
+
+				// Load pre-action hook secrets
+				res, err = loadPreActionHookCfgCredentials(item.Actions.PUT.Config.PreActionHooks)
+				// Check error
+				if err != nil {
+					return nil, err
+				}
+				// Save credential
+				result = append(result, res...)
 			}
 
 			// Check if DELETE actions are declared and webhook configs
@@ -372,6 +599,15 @@ func loadAllCredentials(out *Config) ([]*CredentialConfig, error) {
 				}
 				// Save credential
 				result = append(result, res...)
+
+				// Load pre-action hook secrets
+				res, err = loadPreActionHookCfgCredentials(item.Actions.DELETE.Config.PreActionHooks)
+				// Check error
+				if err != nil {
+					return nil, err
+				}
+				// Save credential
+				result = append(result, res...)
 			}
 		}
 		// Load credentials for access key and secret key
@@ -409,6 +645,84 @@ func loadAllCredentials(out *Config) ([]*CredentialConfig, error) {
 					// Save credential
 					result = append(result, v.ClientSecret)
 				}
+				// Check if login state secret exists
+				if v.LoginStateSecret != nil {
+					err := loadCredential(v.LoginStateSecret)
+					if err != nil {
+						return nil, err
+					}
+					// Save credential
+					result = append(result, v.LoginStateSecret)
+				}
+				// Check if session credentials exist
+				if v.Session != nil {
+					// Get session credentials
+					creds := []*CredentialConfig{v.Session.EncryptionKey}
+					if v.Session.Store != nil && v.Session.Store.Redis != nil {
+						creds = append(creds, v.Session.Store.Redis.Password)
+					}
+
+					for _, cred := range creds {
+						// Check if credential exists
+						if cred == nil {
+							continue
+						}
+
+						err := loadCredential(cred)
+						if err != nil {
+							return nil, err
+						}
+						// Save credential
+						result = append(result, cred)
+					}
+				}
+			}
+		}
+		// Load credentials for jwt static keys if needed
+		if out.AuthProviders.JWT != nil {
+			for _, v := range out.AuthProviders.JWT {
+				// Loop over static keys
+				for _, sk := range v.StaticKeys {
+					err := loadCredential(sk.Key)
+					if err != nil {
+						return nil, err
+					}
+					// Save credential
+					result = append(result, sk.Key)
+				}
+			}
+		}
+		// Load credentials for hmac auth if needed
+		if out.AuthProviders.HMAC != nil {
+			for _, v := range out.AuthProviders.HMAC {
+				// Loop over keys
+				for _, k := range v.Keys {
+					err := loadCredential(k.Secret)
+					if err != nil {
+						return nil, err
+					}
+					// Save credential
+					result = append(result, k.Secret)
+				}
+			}
+		}
+		// Load credentials for ldap auth if needed
+		if out.AuthProviders.LDAP != nil {
+			for _, v := range out.AuthProviders.LDAP {
+				// Loop over declared credentials
+				for _, cred := range []*CredentialConfig{v.BindPassword, v.CACertificate} {
+					// Check if credential exists
+					if cred == nil {
+						continue
+					}
+
+					err := loadCredential(cred)
+					if err != nil {
+						return nil, err
+					}
+					// Save credential
+					result = append(result, cred)
+				}
 			}
 		}
 	}
@@ -416,18 +730,23 @@ func loadAllCredentials(out *Config) ([]*CredentialConfig, error) {
 	// Load auth credentials from list targets with basic auth
 	if out.ListTargets != nil && out.ListTargets.Resource != nil &&
 		out.ListTargets.Resource.Basic != nil && out.ListTargets.Resource.Basic.Credentials != nil {
-		// Loop over credentials declared
-		for i := range len(out.ListTargets.Resource.Basic.Credentials) {
-			// Store item access
-			it := out.ListTargets.Resource.Basic.Credentials[i]
-			// Load credential
-			err := loadCredential(it.Password)
-			if err != nil {
-				return nil, err
-			}
-			// Save credential
-			result = append(result, it.Password)
+		// Load credentials declared
+		creds, err := loadBasicAuthCredentials(out.ListTargets.Resource.Basic.Credentials)
+		if err != nil {
+			return nil, err
+		}
+		// Save credentials
+		result = append(result, creds...)
+	}
+
+	// Load impersonation cookie secret
+	if out.Impersonation != nil && out.Impersonation.CookieSecret != nil {
+		err := loadCredential(out.Impersonation.CookieSecret)
+		if err != nil {
+			return nil, err
 		}
+		// Save credential
+		result = append(result, out.Impersonation.CookieSecret)
 	}
 
 	// Load SSL S3 credentials from server/internal server
@@ -499,6 +818,29 @@ func loadServerSSLCredentials(serverConfig *ServerConfig) ([]*CredentialConfig,
 		}
 	}
 
+	// Check if client CA is declared
+	if serverConfig.SSL.ClientCA != nil {
+		for _, cert := range serverConfig.SSL.ClientCA.Certificates {
+			if cert.CertificateURLConfig != nil && cert.CertificateURLConfig.AWSCredentials != nil {
+				s3Creds := cert.CertificateURLConfig.AWSCredentials
+
+				if s3Creds.AccessKey != nil {
+					err := loadCredential(s3Creds.AccessKey)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				if s3Creds.SecretKey != nil {
+					err := loadCredential(s3Creds.SecretKey)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+
 	return res, nil
 }
 
@@ -519,12 +861,87 @@ func loadWebhookCfgCredentials(cfgList []*WebhookConfig) ([]*CredentialConfig, e
 			// Save
 			res = append(res, secCre)
 		}
+
+		// Check if signature is enabled
+		if wbCfg.Signature != nil {
+			// Loop over the signature secrets
+			for _, secCre := range wbCfg.Signature.Secrets {
+				// Load credential
+				err := loadCredential(secCre)
+				// Check error
+				if err != nil {
+					return nil, err
+				}
+
+				// Save
+				res = append(res, secCre)
+			}
+		}
+	}
+
+	// Default
+	return res, nil
+}
+
+// loadPreActionHookCfgCredentials will load secret headers and signature secrets of pre-action hooks.
+func loadPreActionHookCfgCredentials(cfgList []*PreActionHookConfig) ([]*CredentialConfig, error) {
+	// Create result
+	res := make([]*CredentialConfig, 0)
+	// Loop over the list
+	for _, hCfg := range cfgList {
+		// Get all credentials
+		list := make([]*CredentialConfig, 0, len(hCfg.SecretHeaders))
+		for _, secCre := range hCfg.SecretHeaders {
+			list = append(list, secCre)
+		}
+		// Check if signature is enabled
+		if hCfg.Signature != nil {
+			list = append(list, hCfg.Signature.Secrets...)
+		}
+
+		// Loop over credentials
+		for _, secCre := range list {
+			// Load credential
+			err := loadCredential(secCre)
+			// Check error
+			if err != nil {
+				return nil, err
+			}
+
+			// Save
+			res = append(res, secCre)
+		}
 	}
 
 	// Default
 	return res, nil
 }
 
+// loadBasicAuthCredentials will load passwords and password hashes of basic auth users.
+func loadBasicAuthCredentials(list []*BasicAuthUserConfig) ([]*CredentialConfig, error) {
+	// Initialize answer
+	result := make([]*CredentialConfig, 0)
+
+	for _, it := range list {
+		// Loop over password and password hash
+		for _, cred := range []*CredentialConfig{it.Password, it.PasswordHash} {
+			// Ignore not declared ones
+			if cred == nil {
+				continue
+			}
+			// Load credential
+			err := loadCredential(cred)
+			if err != nil {
+				return nil, err
+			}
+			// Save credential
+			result = append(result, cred)
+		}
+	}
+
+	return result, nil
+}
+
 func loadCredential(credCfg *CredentialConfig) error {
 	if credCfg.Path != "" {
 		// Secret file
@@ -560,6 +977,28 @@ func loadResourceValues(res *Resource) error {
 		res.Methods = []string{http.MethodGet}
 	}
 
+	// Check if path is a regexp
+	if res.Regexp {
+		// Compile regexp
+		reg, err := regexp.Compile(res.Path)
+		// Check error
+		if err != nil {
+			return errors.Wrapf(err, "resource path %s isn't a valid regexp", res.Path)
+		}
+		// Save regexp
+		res.PathRegex = reg
+	}
+
+	// Check if ip filter is set
+	if res.IPFilter != nil {
+		// Load ip filter
+		err := loadIPFilterValues(res.IPFilter)
+		// Check error
+		if err != nil {
+			return errors.Wrapf(err, "resource path %s", res.Path)
+		}
+	}
+
 	// Check if regexp is enabled in OIDC Authorization groups
 	if res.OIDC != nil && res.OIDC.AuthorizationAccesses != nil {
 		for _, item := range res.OIDC.AuthorizationAccesses {
@@ -575,11 +1014,141 @@ func loadResourceValues(res *Resource) error {
 		res.OIDC.AuthorizationOPAServer.Tags = map[string]string{}
 	}
 
-	return nil
-}
-
-func loadBusinessDefaultValues(out *Config) error {
-	// Manage default values for targets
+	// Check if regexp is enabled in JWT Authorization groups
+	if res.JWT != nil && res.JWT.AuthorizationAccesses != nil {
+		for _, item := range res.JWT.AuthorizationAccesses {
+			err2 := loadRegexOIDCAuthorizationAccess(item)
+			if err2 != nil {
+				return err2
+			}
+		}
+	}
+
+	// Check if tags are set in JWT OPA server authorizations
+	if res.JWT != nil && res.JWT.AuthorizationOPAServer != nil && res.JWT.AuthorizationOPAServer.Tags == nil {
+		res.JWT.AuthorizationOPAServer.Tags = map[string]string{}
+	}
+
+	// Check if regexp is enabled in API key Authorization groups
+	if res.APIKey != nil && res.APIKey.AuthorizationAccesses != nil {
+		for _, item := range res.APIKey.AuthorizationAccesses {
+			err2 := loadRegexOIDCAuthorizationAccess(item)
+			if err2 != nil {
+				return err2
+			}
+		}
+	}
+
+	// Check if tags are set in API key OPA server authorizations
+	if res.APIKey != nil && res.APIKey.AuthorizationOPAServer != nil && res.APIKey.AuthorizationOPAServer.Tags == nil {
+		res.APIKey.AuthorizationOPAServer.Tags = map[string]string{}
+	}
+
+	// Check if regexp is enabled in mTLS Authorization groups
+	if res.MTLS != nil && res.MTLS.AuthorizationAccesses != nil {
+		for _, item := range res.MTLS.AuthorizationAccesses {
+			err2 := loadRegexOIDCAuthorizationAccess(item)
+			if err2 != nil {
+				return err2
+			}
+		}
+	}
+
+	// Check if tags are set in mTLS OPA server authorizations
+	if res.MTLS != nil && res.MTLS.AuthorizationOPAServer != nil && res.MTLS.AuthorizationOPAServer.Tags == nil {
+		res.MTLS.AuthorizationOPAServer.Tags = map[string]string{}
+	}
+
+	// Check if regexp is enabled in LDAP Authorization groups
+	if res.LDAP != nil && res.LDAP.AuthorizationAccesses != nil {
+		for _, item := range res.LDAP.AuthorizationAccesses {
+			err2 := loadRegexOIDCAuthorizationAccess(item)
+			if err2 != nil {
+				return err2
+			}
+		}
+	}
+
+	// Check if tags are set in LDAP OPA server authorizations
+	if res.LDAP != nil && res.LDAP.AuthorizationOPAServer != nil && res.LDAP.AuthorizationOPAServer.Tags == nil {
+		res.LDAP.AuthorizationOPAServer.Tags = map[string]string{}
+	}
+
+	// Check if regexp is enabled in HMAC Authorization groups
+	if res.HMAC != nil && res.HMAC.AuthorizationAccesses != nil {
+		for _, item := range res.HMAC.AuthorizationAccesses {
+			err2 := loadRegexOIDCAuthorizationAccess(item)
+			if err2 != nil {
+				return err2
+			}
+		}
+	}
+
+	// Check if tags are set in HMAC OPA server authorizations
+	if res.HMAC != nil && res.HMAC.AuthorizationOPAServer != nil && res.HMAC.AuthorizationOPAServer.Tags == nil {
+		res.HMAC.AuthorizationOPAServer.Tags = map[string]string{}
+	}
+
+	// Loop over resource types supporting authorization
+	for _, it := range []*ResourceHeaderOIDC{res.OIDC, res.Header, res.JWT, res.APIKey, res.MTLS, res.LDAP, res.HMAC} {
+		// Check if it is set
+		if it == nil {
+			continue
+		}
+
+		// Check if OPA server authorization is set
+		if it.AuthorizationOPAServer != nil {
+			// Parse decision cache duration
+			dur, err := parseDecisionCacheDuration(it.AuthorizationOPAServer.DecisionCacheDurationString)
+			// Check error
+			if err != nil {
+				return err
+			}
+			// Save
+			it.AuthorizationOPAServer.DecisionCacheDuration = dur
+		}
+
+		// Check if rego authorization is set
+		if it.AuthorizationRego != nil {
+			// Check if tags are set
+			if it.AuthorizationRego.Tags == nil {
+				it.AuthorizationRego.Tags = map[string]string{}
+			}
+
+			// Parse decision cache duration
+			dur, err := parseDecisionCacheDuration(it.AuthorizationRego.DecisionCacheDurationString)
+			// Check error
+			if err != nil {
+				return err
+			}
+			// Save
+			it.AuthorizationRego.DecisionCacheDuration = dur
+		}
+	}
+
+	return nil
+}
+
+// parseDecisionCacheDuration will parse an authorization decision cache duration.
+// Empty value means that cache is disabled.
+func parseDecisionCacheDuration(durStr string) (time.Duration, error) {
+	// Check if it is set
+	if durStr == "" {
+		return 0, nil
+	}
+
+	// Parse it
+	dur, err := time.ParseDuration(durStr)
+	// Check error
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return dur, nil
+}
+
+func loadBusinessDefaultValues(out *Config) error {
+	// Manage default values for targets
 	for key, item := range out.Targets {
 		// Put target name in structure with key as value
 		item.Name = key
@@ -604,6 +1173,14 @@ func loadBusinessDefaultValues(out *Config) error {
 		if item.Bucket != nil && item.Bucket.S3UploadConcurrency == 0 {
 			item.Bucket.S3UploadConcurrency = DefaultS3UploadConcurrency
 		}
+		// Manage default s3 download part size
+		if item.Bucket != nil && item.Bucket.S3DownloadPartSize == 0 {
+			item.Bucket.S3DownloadPartSize = DefaultS3DownloadPartSize
+		}
+		// Manage default s3 download concurrency
+		if item.Bucket != nil && item.Bucket.S3DownloadConcurrency == 0 {
+			item.Bucket.S3DownloadConcurrency = DefaultS3DownloadConcurrency
+		}
 		// Manage default s3 path-style addressing (nil = omitted in config)
 		if item.Bucket != nil && item.Bucket.S3ForcePathStyle == nil {
 			item.Bucket.S3ForcePathStyle = new(DefaultBucketS3ForcePathStyle)
@@ -628,6 +1205,74 @@ func loadBusinessDefaultValues(out *Config) error {
 				// Set default one
 				item.Actions.GET.Config.SignedURLExpiration = DefaultTargetActionsGETConfigSignedURLExpiration
 			}
+			// Manage values for user isolation quota
+			if item.Actions.GET.Config.UserIsolationQuota != nil {
+				// Store quota configuration
+				quotaCfg := item.Actions.GET.Config.UserIsolationQuota
+				// Check if reconcile interval is set
+				if quotaCfg.ReconcileIntervalString != "" {
+					// Parse it
+					dur, err := time.ParseDuration(quotaCfg.ReconcileIntervalString)
+					// Check error
+					if err != nil {
+						return errors.WithStack(err)
+					}
+					// Save
+					quotaCfg.ReconcileInterval = dur
+				} else {
+					// Set default one
+					quotaCfg.ReconcileInterval = DefaultUserIsolationQuotaReconcileInterval
+				}
+			}
+			// Manage values for precompressed variants
+			if item.Actions.GET.Config.Precompressed != nil {
+				// Store precompressed configuration
+				precompressedCfg := item.Actions.GET.Config.Precompressed
+				// Check if encodings are set
+				if len(precompressedCfg.Encodings) == 0 {
+					precompressedCfg.Encodings = slices.Clone(DefaultPrecompressedEncodings)
+				}
+				// Check if cache expiration is set
+				if precompressedCfg.CacheExpirationString != "" {
+					// Parse it
+					dur, err := time.ParseDuration(precompressedCfg.CacheExpirationString)
+					// Check error
+					if err != nil {
+						return errors.WithStack(err)
+					}
+					// Save
+					precompressedCfg.CacheExpiration = dur
+				} else {
+					// Set default one
+					precompressedCfg.CacheExpiration = DefaultPrecompressedCacheExpiration
+				}
+			}
+		}
+		// Manage webhook default values
+		if item.Actions != nil {
+			// Load webhook values
+			err := loadTargetWebhooksDefaultValues(item.Actions)
+			// Check error
+			if err != nil {
+				return errors.Wrapf(err, "target %s", key)
+			}
+		}
+		// Manage values for concurrency limits
+		if item.Concurrency != nil {
+			// Check if queue timeout is set
+			if item.Concurrency.QueueTimeoutString != "" {
+				// Parse it
+				dur, err := time.ParseDuration(item.Concurrency.QueueTimeoutString)
+				// Check error
+				if err != nil {
+					return errors.WithStack(err)
+				}
+				// Save
+				item.Concurrency.QueueTimeout = dur
+			} else {
+				// Set default one
+				item.Concurrency.QueueTimeout = DefaultTargetConcurrencyQueueTimeout
+			}
 		}
 		// Manage default for target templates configurations
 		// Else put default headers for template override
@@ -659,6 +1304,16 @@ func loadBusinessDefaultValues(out *Config) error {
 				item.Templates.BadRequestError.Headers = DefaultTemplateHeaders
 			}
 
+			// Check if quota exceeded error template have been override and not headers
+			if item.Templates.QuotaExceededError != nil && item.Templates.QuotaExceededError.Headers == nil {
+				item.Templates.QuotaExceededError.Headers = DefaultTemplateHeaders
+			}
+
+			// Check if service unavailable error template have been override and not headers
+			if item.Templates.ServiceUnavailableError != nil && item.Templates.ServiceUnavailableError.Headers == nil {
+				item.Templates.ServiceUnavailableError.Headers = DefaultTemplateHeaders
+			}
+
 			// Check if put template have been override and not headers
 			if item.Templates.Put != nil && item.Templates.Put.Headers == nil {
 				item.Templates.Put.Headers = DefaultEmptyTemplateHeaders
@@ -679,6 +1334,15 @@ func loadBusinessDefaultValues(out *Config) error {
 				}
 			}
 		}
+		// Manage ip filter
+		if item.IPFilter != nil {
+			// Load ip filter
+			err := loadIPFilterValues(item.IPFilter)
+			// Check error
+			if err != nil {
+				return errors.Wrapf(err, "target %s", key)
+			}
+		}
 		// Manage key write list
 		if item.KeyRewriteList != nil {
 			// Loop over keys
@@ -728,6 +1392,165 @@ func loadBusinessDefaultValues(out *Config) error {
 			if v.CallbackPath == "" {
 				v.CallbackPath = fmt.Sprintf(oidcCallbackPathTemplate, k)
 			}
+			// Check if logout path is defined
+			if v.LogoutPath == "" {
+				v.LogoutPath = fmt.Sprintf(oidcLogoutPathTemplate, k)
+			}
+			// Manage default session values
+			if v.Session != nil {
+				err := loadOIDCSessionDefaultValues(v.Session)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Manage default values for api key auth providers
+	if out.AuthProviders != nil && out.AuthProviders.APIKey != nil {
+		for _, v := range out.AuthProviders.APIKey {
+			// Manage default header
+			if v.Header == "" {
+				v.Header = DefaultAPIKeyHeader
+			}
+			// Load keys values
+			for _, k := range v.Keys {
+				err := loadAPIKeyValues(k)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Manage default values for mtls auth providers
+	if out.AuthProviders != nil && out.AuthProviders.MTLS != nil {
+		for _, v := range out.AuthProviders.MTLS {
+			// Manage default username field
+			if v.UsernameField == "" {
+				v.UsernameField = DefaultMTLSUsernameField
+			}
+			// Manage default groups fields
+			if v.GroupsFields == nil {
+				v.GroupsFields = slices.Clone(DefaultMTLSGroupsFields)
+			}
+		}
+	}
+
+	// Manage default values for ldap auth providers
+	if out.AuthProviders != nil && out.AuthProviders.LDAP != nil {
+		for _, v := range out.AuthProviders.LDAP {
+			err := loadLDAPAuthDefaultValues(v)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Manage default values for hmac auth providers
+	if out.AuthProviders != nil && out.AuthProviders.HMAC != nil {
+		for _, v := range out.AuthProviders.HMAC {
+			// Manage default max clock skew
+			if v.MaxClockSkewString != "" {
+				// Parse it
+				dur, err := time.ParseDuration(v.MaxClockSkewString)
+				// Check error
+				if err != nil {
+					return errors.WithStack(err)
+				}
+				// Save
+				v.MaxClockSkew = dur
+			} else {
+				// Set default one
+				v.MaxClockSkew = DefaultHMACMaxClockSkew
+			}
+
+			// Manage default max body size
+			if v.MaxBodySize == 0 {
+				v.MaxBodySize = DefaultHMACMaxBodySize
+			}
+
+			// Manage default replay rejection
+			if v.RejectReplay == nil {
+				v.RejectReplay = &DefaultHMACRejectReplay
+			}
+		}
+	}
+
+	// Manage default values for basic auth providers
+	if out.AuthProviders != nil && out.AuthProviders.Basic != nil {
+		for _, v := range out.AuthProviders.Basic {
+			// Check if brute force protection is enabled
+			if v.BruteForceProtection != nil && v.BruteForceProtection.Enabled {
+				err := loadBruteForceProtectionDefaultValues(v.BruteForceProtection)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Manage default values for audit sinks
+	if out.Audit != nil {
+		for _, v := range out.Audit.Sinks {
+			err := loadAuditSinkDefaultValues(v)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Manage default values for impersonation
+	if out.Impersonation != nil {
+		if out.Impersonation.Header == "" {
+			out.Impersonation.Header = DefaultImpersonationHeader
+		}
+
+		if out.Impersonation.GroupsHeader == "" {
+			out.Impersonation.GroupsHeader = DefaultImpersonationGroupsHeader
+		}
+
+		if out.Impersonation.CookieName == "" {
+			out.Impersonation.CookieName = DefaultImpersonationCookieName
+		}
+	}
+
+	// Manage default values for webhook queue
+	if out.WebhookQueue != nil {
+		err := loadWebhookQueueDefaultValues(out.WebhookQueue)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Manage default values for client CA in servers
+	for _, svr := range []*ServerConfig{out.Server, out.InternalServer} {
+		if svr != nil && svr.SSL != nil && svr.SSL.ClientCA != nil && svr.SSL.ClientCA.Mode == "" {
+			svr.SSL.ClientCA.Mode = SSLClientAuthModeVerifyIfGiven
+		}
+	}
+
+	// Manage trusted proxies in servers
+	for _, svr := range []*ServerConfig{out.Server, out.InternalServer} {
+		if svr != nil {
+			// Parse trusted proxies
+			prefixes, err := parseIPPrefixes(svr.TrustedProxies)
+			// Check error
+			if err != nil {
+				return errors.Wrap(err, "server trusted proxies")
+			}
+			// Save
+			svr.TrustedProxyPrefixes = prefixes
+		}
+	}
+
+	// Manage default values for jwt auth providers
+	if out.AuthProviders != nil && out.AuthProviders.JWT != nil {
+		for _, v := range out.AuthProviders.JWT {
+			err := loadJWTAuthDefaultValues(v)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -749,6 +1572,432 @@ func loadBusinessDefaultValues(out *Config) error {
 	return nil
 }
 
+func loadAPIKeyValues(k *APIKeyConfig) error {
+	// Check if expiration is set
+	if k.ExpiresAtString == "" {
+		return nil
+	}
+
+	// Parse it
+	t, err := time.Parse(time.RFC3339, k.ExpiresAtString)
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Save
+	k.ExpiresAt = t
+
+	return nil
+}
+
+func loadJWTAuthDefaultValues(v *JWTAuthConfig) error {
+	// Manage default allowed algorithms
+	if len(v.AllowedAlgorithms) == 0 {
+		v.AllowedAlgorithms = slices.Clone(DefaultJWTAllowedAlgorithms)
+	}
+	// Manage default clock skew
+	if v.ClockSkewString != "" {
+		// Parse it
+		dur, err := time.ParseDuration(v.ClockSkewString)
+		// Check error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		// Save
+		v.ClockSkew = dur
+	} else {
+		// Set default one
+		v.ClockSkew = DefaultJWTClockSkew
+	}
+	// Manage default jwks refresh interval
+	if v.JWKSRefreshIntervalString != "" {
+		// Parse it
+		dur, err := time.ParseDuration(v.JWKSRefreshIntervalString)
+		// Check error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		// Save
+		v.JWKSRefreshInterval = dur
+	} else {
+		// Set default one
+		v.JWKSRefreshInterval = DefaultJWTJWKSRefreshInterval
+	}
+	// Manage default claims
+	if v.Claims == nil {
+		v.Claims = &JWTClaimsConfig{}
+	}
+
+	if v.Claims.Username == "" {
+		v.Claims.Username = DefaultJWTUsernameClaim
+	}
+
+	if v.Claims.Email == "" {
+		v.Claims.Email = DefaultJWTEmailClaim
+	}
+
+	if v.Claims.Name == "" {
+		v.Claims.Name = DefaultJWTNameClaim
+	}
+
+	if v.Claims.Groups == "" {
+		v.Claims.Groups = DefaultJWTGroupsClaim
+	}
+
+	return nil
+}
+
+func loadOIDCSessionDefaultValues(v *OIDCSessionConfig) error {
+	// Manage default maximum duration
+	if v.MaxDurationString != "" {
+		// Parse it
+		dur, err := time.ParseDuration(v.MaxDurationString)
+		// Check error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		// Save
+		v.MaxDuration = dur
+	} else {
+		// Set default one
+		v.MaxDuration = DefaultOIDCSessionMaxDuration
+	}
+	// Manage default store
+	if v.Store == nil {
+		v.Store = &OIDCSessionStoreConfig{}
+	}
+
+	if v.Store.Type == "" {
+		v.Store.Type = OIDCSessionStoreMemoryType
+	}
+
+	if v.Store.Redis != nil && v.Store.Redis.KeyPrefix == "" {
+		v.Store.Redis.KeyPrefix = DefaultOIDCSessionRedisKeyPrefix
+	}
+
+	return nil
+}
+
+func loadIPFilterValues(f *IPFilterConfig) error {
+	// Parse allow list
+	allow, err := parseIPPrefixes(f.Allow)
+	// Check error
+	if err != nil {
+		return errors.Wrap(err, "ip filter allow list")
+	}
+
+	// Parse deny list
+	deny, err := parseIPPrefixes(f.Deny)
+	// Check error
+	if err != nil {
+		return errors.Wrap(err, "ip filter deny list")
+	}
+
+	f.AllowPrefixes = allow
+	f.DenyPrefixes = deny
+
+	// Country codes are compared in upper case
+	for i, c := range f.AllowCountries {
+		f.AllowCountries[i] = strings.ToUpper(c)
+	}
+
+	for i, c := range f.DenyCountries {
+		f.DenyCountries[i] = strings.ToUpper(c)
+	}
+
+	return nil
+}
+
+// parseIPPrefixes will parse CIDR list. A single ip is considered as a network with only this ip.
+func parseIPPrefixes(values []string) ([]netip.Prefix, error) {
+	// Check if list is empty
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	res := make([]netip.Prefix, 0, len(values))
+
+	for _, v := range values {
+		// Check if it is a single ip
+		if !strings.Contains(v, "/") {
+			// Parse ip
+			ip, err := netip.ParseAddr(v)
+			// Check error
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			res = append(res, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+
+			continue
+		}
+
+		// Parse CIDR
+		prefix, err := netip.ParsePrefix(v)
+		// Check error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		res = append(res, prefix.Masked())
+	}
+
+	return res, nil
+}
+
+func loadAuditSinkDefaultValues(v *AuditSinkConfig) error {
+	// Manage default file values
+	if v.File != nil {
+		if v.File.MaxSize == 0 {
+			v.File.MaxSize = DefaultAuditFileMaxSize
+		}
+
+		if v.File.MaxBackups == 0 {
+			v.File.MaxBackups = DefaultAuditFileMaxBackups
+		}
+	}
+
+	// Manage default s3 values
+	if v.S3 != nil {
+		if v.S3.Prefix == "" {
+			v.S3.Prefix = DefaultAuditS3Prefix
+		}
+
+		if v.S3.BatchSize == 0 {
+			v.S3.BatchSize = DefaultAuditS3BatchSize
+		}
+
+		// Manage default flush interval
+		if v.S3.FlushIntervalString != "" {
+			// Parse it
+			dur, err := time.ParseDuration(v.S3.FlushIntervalString)
+			// Check error
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			// Save
+			v.S3.FlushInterval = dur
+		} else {
+			// Set default one
+			v.S3.FlushInterval = DefaultAuditS3FlushInterval
+		}
+	}
+
+	return nil
+}
+
+// loadTargetWebhooksDefaultValues will set default values on webhooks and pre-action hooks of all target actions.
+func loadTargetWebhooksDefaultValues(actions *ActionsConfig) error {
+	// Get all webhook lists
+	lists := [][]*WebhookConfig{}
+	if actions.GET != nil && actions.GET.Config != nil {
+		lists = append(lists, actions.GET.Config.Webhooks)
+	}
+
+	if actions.HEAD != nil && actions.HEAD.Config != nil {
+		lists = append(lists, actions.HEAD.Config.Webhooks)
+	}
+
+	if actions.PUT != nil && actions.PUT.Config != nil {
+		lists = append(lists, actions.PUT.Config.Webhooks)
+	}
+
+	if actions.DELETE != nil && actions.DELETE.Config != nil {
+		lists = append(lists, actions.DELETE.Config.Webhooks)
+	}
+
+	for _, list := range lists {
+		for _, wbCfg := range list {
+			// Manage default signature header
+			if wbCfg.Signature != nil && wbCfg.Signature.Header == "" {
+				wbCfg.Signature.Header = DefaultWebhookSignatureHeader
+			}
+		}
+	}
+
+	// Get all pre-action hook lists
+	preLists := [][]*PreActionHookConfig{}
+	if actions.PUT != nil && actions.PUT.Config != nil {
+		preLists = append(preLists, actions.PUT.Config.PreActionHooks)
+	}
+
+	if actions.DELETE != nil && actions.DELETE.Config != nil {
+		preLists = append(preLists, actions.DELETE.Config.PreActionHooks)
+	}
+
+	for _, list := range preLists {
+		for _, hCfg := range list {
+			// Manage default signature header
+			if hCfg.Signature != nil && hCfg.Signature.Header == "" {
+				hCfg.Signature.Header = DefaultWebhookSignatureHeader
+			}
+
+			// Manage default failure policy
+			if hCfg.FailurePolicy == "" {
+				hCfg.FailurePolicy = DefaultPreActionHookFailurePolicy
+			}
+
+			// Check if timeout is set
+			if hCfg.TimeoutString == "" {
+				// Set default one
+				hCfg.Timeout = DefaultPreActionHookTimeout
+
+				continue
+			}
+
+			// Parse it
+			dur, err := time.ParseDuration(hCfg.TimeoutString)
+			// Check error
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			// Save
+			hCfg.Timeout = dur
+		}
+	}
+
+	return nil
+}
+
+func loadWebhookQueueDefaultValues(v *WebhookQueueConfig) error {
+	// Manage default workers
+	if v.Workers == 0 {
+		v.Workers = DefaultWebhookQueueWorkers
+	}
+
+	// Manage default max attempts
+	if v.MaxAttempts == 0 {
+		v.MaxAttempts = DefaultWebhookQueueMaxAttempts
+	}
+
+	// Manage durations
+	for _, it := range []struct {
+		res  *time.Duration
+		str  string
+		dflt time.Duration
+	}{
+		{res: &v.InitialBackoff, str: v.InitialBackoffString, dflt: DefaultWebhookQueueInitialBackoff},
+		{res: &v.MaxBackoff, str: v.MaxBackoffString, dflt: DefaultWebhookQueueMaxBackoff},
+	} {
+		// Check if value is set
+		if it.str == "" {
+			// Set default one
+			*it.res = it.dflt
+
+			continue
+		}
+
+		// Parse it
+		dur, err := time.ParseDuration(it.str)
+		// Check error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		// Save
+		*it.res = dur
+	}
+
+	return nil
+}
+
+func loadBruteForceProtectionDefaultValues(v *BruteForceProtectionConfig) error {
+	// Manage default max attempts
+	if v.MaxAttemptsPerUser == nil {
+		v.MaxAttemptsPerUser = new(int)
+		*v.MaxAttemptsPerUser = DefaultBruteForceMaxAttemptsPerUser
+	}
+
+	if v.MaxAttemptsPerIP == nil {
+		v.MaxAttemptsPerIP = new(int)
+		*v.MaxAttemptsPerIP = DefaultBruteForceMaxAttemptsPerIP
+	}
+
+	// Manage durations
+	for _, it := range []struct {
+		res  *time.Duration
+		str  string
+		dflt time.Duration
+	}{
+		{res: &v.AttemptsWindow, str: v.AttemptsWindowString, dflt: DefaultBruteForceAttemptsWindow},
+		{res: &v.LockoutDuration, str: v.LockoutDurationString, dflt: DefaultBruteForceLockoutDuration},
+		{res: &v.MaxLockoutDuration, str: v.MaxLockoutDurationString, dflt: DefaultBruteForceMaxLockoutDuration},
+	} {
+		// Check if value is set
+		if it.str == "" {
+			// Set default one
+			*it.res = it.dflt
+
+			continue
+		}
+
+		// Parse it
+		dur, err := time.ParseDuration(it.str)
+		// Check error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		// Save
+		*it.res = dur
+	}
+
+	return nil
+}
+
+func loadLDAPAuthDefaultValues(v *LDAPAuthConfig) error {
+	// Manage default timeout
+	if v.TimeoutString != "" {
+		// Parse it
+		dur, err := time.ParseDuration(v.TimeoutString)
+		// Check error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		// Save
+		v.Timeout = dur
+	} else {
+		// Set default one
+		v.Timeout = DefaultLDAPTimeout
+	}
+	// Manage default cache duration
+	if v.CacheDurationString != "" {
+		// Parse it
+		dur, err := time.ParseDuration(v.CacheDurationString)
+		// Check error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		// Save
+		v.CacheDuration = dur
+	} else {
+		// Set default one
+		v.CacheDuration = DefaultLDAPCacheDuration
+	}
+	// Manage default email attribute
+	if v.EmailAttribute == "" {
+		v.EmailAttribute = DefaultLDAPEmailAttribute
+	}
+	// Manage default user search filter
+	if v.UserSearch != nil && v.UserSearch.Filter == "" {
+		v.UserSearch.Filter = DefaultLDAPUserSearchFilter
+	}
+	// Manage default group search values
+	if v.GroupSearch != nil {
+		if v.GroupSearch.Filter == "" {
+			v.GroupSearch.Filter = DefaultLDAPGroupSearchFilter
+		}
+
+		if v.GroupSearch.MemberAttribute == "" {
+			v.GroupSearch.MemberAttribute = DefaultLDAPGroupMemberAttribute
+		}
+
+		if v.GroupSearch.NameAttribute == "" {
+			v.GroupSearch.NameAttribute = DefaultLDAPGroupNameAttribute
+		}
+	}
+
+	return nil
+}
+
 func loadKeyRewriteValues(item *TargetKeyRewriteConfig) error {
 	// Check if target type is set, if not, put REGEX type as default
 	if item.TargetType == "" {
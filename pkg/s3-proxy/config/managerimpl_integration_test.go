@@ -67,6 +67,20 @@ var defaultTemplateCfg = &TemplateConfig{
 		},
 		Status: "400",
 	},
+	QuotaExceededError: &TemplateConfigItem{
+		Path: "templates/quota-exceeded-error.tpl",
+		Headers: map[string]string{
+			"Content-Type": "{{ template \"main.headers.contentType\" . }}",
+		},
+		Status: "413",
+	},
+	ServiceUnavailableError: &TemplateConfigItem{
+		Path: "templates/service-unavailable-error.tpl",
+		Headers: map[string]string{
+			"Content-Type": "{{ template \"main.headers.contentType\" . }}",
+		},
+		Status: "503",
+	},
 	Put: &TemplateConfigItem{
 		Path:    "templates/put.tpl",
 		Headers: map[string]string{},
@@ -213,13 +227,15 @@ targets:
 							Path: []string{"/test/"},
 						},
 						Bucket: &BucketConfig{
-							Name:                "bucket1",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket1",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 						},
 						Actions: &ActionsConfig{
 							GET: &GetActionConfig{Enabled: true},
@@ -280,13 +296,15 @@ targets:
 							Path: []string{"/test/"},
 						},
 						Bucket: &BucketConfig{
-							Name:                "bucket1",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket1",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 						},
 						Actions: &ActionsConfig{
 							GET: &GetActionConfig{Enabled: true},
@@ -354,13 +372,15 @@ targets:
 							Path: []string{"/test/"},
 						},
 						Bucket: &BucketConfig{
-							Name:                "bucket1",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket1",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 						},
 						Actions: &ActionsConfig{
 							GET: &GetActionConfig{Enabled: true},
@@ -373,13 +393,15 @@ targets:
 							Path: []string{"/test2/"},
 						},
 						Bucket: &BucketConfig{
-							Name:                "bucket2",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket2",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 						},
 						Actions: &ActionsConfig{
 							GET: &GetActionConfig{Enabled: true},
@@ -438,13 +460,15 @@ targets:
 							Path: []string{"/test/"},
 						},
 						Bucket: &BucketConfig{
-							Name:                "bucket1",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket1",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 						},
 						Actions: &ActionsConfig{
 							GET: &GetActionConfig{Enabled: true},
@@ -515,13 +539,15 @@ targets:
 							Path: []string{"/test/"},
 						},
 						Bucket: &BucketConfig{
-							Name:                "bucket1",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket1",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 						},
 						Actions: &ActionsConfig{
 							GET: &GetActionConfig{Enabled: true},
@@ -675,6 +701,20 @@ targets:
 						},
 						Status: "400",
 					},
+					QuotaExceededError: &TemplateConfigItem{
+						Path: "templates/quota-exceeded-error.tpl",
+						Headers: map[string]string{
+							"Content-Type": "{{ template \"main.headers.contentType\" . }}",
+						},
+						Status: "413",
+					},
+					ServiceUnavailableError: &TemplateConfigItem{
+						Path: "templates/service-unavailable-error.tpl",
+						Headers: map[string]string{
+							"Content-Type": "{{ template \"main.headers.contentType\" . }}",
+						},
+						Status: "503",
+					},
 					Put: &TemplateConfigItem{
 						Path:    "templates/put.tpl",
 						Headers: map[string]string{},
@@ -698,13 +738,15 @@ targets:
 							Path: []string{"/test/"},
 						},
 						Bucket: &BucketConfig{
-							Name:                "bucket1",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket1",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 						},
 						Actions: &ActionsConfig{
 							GET: &GetActionConfig{Enabled: true},
@@ -840,13 +882,15 @@ targets:
 							Path: []string{"/test/"},
 						},
 						Bucket: &BucketConfig{
-							Name:                "bucket1",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket1",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 							Credentials: &BucketCredentialConfig{
 								AccessKey: &CredentialConfig{
 									Env:   "ENV1",
@@ -956,13 +1000,15 @@ targets:
 							Path: []string{"/test/"},
 						},
 						Bucket: &BucketConfig{
-							Name:                "bucket1",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket1",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 							Credentials: &BucketCredentialConfig{
 								AccessKey: &CredentialConfig{
 									Path:  secret1Filename,
@@ -1032,13 +1078,15 @@ VALUE1
 							Path: []string{"/test/"},
 						},
 						Bucket: &BucketConfig{
-							Name:                "bucket1",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket1",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 							Credentials: &BucketCredentialConfig{
 								AccessKey: &CredentialConfig{
 									Path:  secretWithNewLineFilename,
@@ -1137,13 +1185,15 @@ targets:
 							Path: []string{"/test/"},
 						},
 						Bucket: &BucketConfig{
-							Name:                "bucket1",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket1",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 							Credentials: &BucketCredentialConfig{
 								AccessKey: &CredentialConfig{
 									Value: "VALUE1",
@@ -1222,13 +1272,15 @@ targets:
 							Path: []string{"/test/"},
 						},
 						Bucket: &BucketConfig{
-							Name:                "bucket1",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket1",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 							Credentials: &BucketCredentialConfig{
 								AccessKey: &CredentialConfig{
 									Value: "value1",
@@ -1303,13 +1355,15 @@ targets:
 							TargetType:  RegexTargetKeyRewriteTargetType,
 						}},
 						Bucket: &BucketConfig{
-							Name:                "bucket1",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket1",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 							Credentials: &BucketCredentialConfig{
 								AccessKey: &CredentialConfig{
 									Value: "value1",
@@ -1381,13 +1435,15 @@ targets:
 							Path: []string{"/test/"},
 						},
 						Bucket: &BucketConfig{
-							Name:                "bucket1",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket1",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 							Credentials: &BucketCredentialConfig{
 								AccessKey: &CredentialConfig{
 									Value: "value1",
@@ -1465,13 +1521,15 @@ targets:
 							Path: []string{"/test/"},
 						},
 						Bucket: &BucketConfig{
-							Name:                "bucket1",
-							Region:              "us-east-1",
-							S3ListMaxKeys:       1000,
-							S3MaxUploadParts:    10000,
-							S3UploadPartSize:    5,
-							S3UploadConcurrency: 5,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Name:                  "bucket1",
+							Region:                "us-east-1",
+							S3ListMaxKeys:         1000,
+							S3MaxUploadParts:      10000,
+							S3UploadPartSize:      5,
+							S3UploadConcurrency:   5,
+							S3DownloadPartSize:    8,
+							S3DownloadConcurrency: 4,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 							Credentials: &BucketCredentialConfig{
 								AccessKey: &CredentialConfig{
 									Value: "value1",
@@ -1656,13 +1714,15 @@ targets:
 					Path: []string{"/test/"},
 				},
 				Bucket: &BucketConfig{
-					Name:                "bucket1",
-					Region:              "us-east-1",
-					S3ListMaxKeys:       1000,
-					S3MaxUploadParts:    10000,
-					S3UploadPartSize:    5,
-					S3UploadConcurrency: 5,
-					S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+					Name:                  "bucket1",
+					Region:                "us-east-1",
+					S3ListMaxKeys:         1000,
+					S3MaxUploadParts:      10000,
+					S3UploadPartSize:      5,
+					S3UploadConcurrency:   5,
+					S3DownloadPartSize:    8,
+					S3DownloadConcurrency: 4,
+					S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 					Credentials: &BucketCredentialConfig{
 						AccessKey: &CredentialConfig{
 							Value: "VALUE1",
@@ -1729,13 +1789,15 @@ log:
 						Path: []string{"/test/"},
 					},
 					Bucket: &BucketConfig{
-						Name:                "bucket1",
-						Region:              "us-east-1",
-						S3ListMaxKeys:       1000,
-						S3MaxUploadParts:    10000,
-						S3UploadPartSize:    5,
-						S3UploadConcurrency: 5,
-						S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+						Name:                  "bucket1",
+						Region:                "us-east-1",
+						S3ListMaxKeys:         1000,
+						S3MaxUploadParts:      10000,
+						S3UploadPartSize:      5,
+						S3UploadConcurrency:   5,
+						S3DownloadPartSize:    8,
+						S3DownloadConcurrency: 4,
+						S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 						Credentials: &BucketCredentialConfig{
 							AccessKey: &CredentialConfig{
 								Value: "VALUE1",
@@ -1860,13 +1922,15 @@ targets:
 					Path: []string{"/test/"},
 				},
 				Bucket: &BucketConfig{
-					Name:                "bucket1",
-					Region:              "us-east-1",
-					S3ListMaxKeys:       1000,
-					S3MaxUploadParts:    10000,
-					S3UploadPartSize:    5,
-					S3UploadConcurrency: 5,
-					S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+					Name:                  "bucket1",
+					Region:                "us-east-1",
+					S3ListMaxKeys:         1000,
+					S3MaxUploadParts:      10000,
+					S3UploadPartSize:      5,
+					S3UploadConcurrency:   5,
+					S3DownloadPartSize:    8,
+					S3DownloadConcurrency: 4,
+					S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 					Credentials: &BucketCredentialConfig{
 						AccessKey: &CredentialConfig{
 							Value: "VALUE1",
@@ -1930,13 +1994,15 @@ targets:
 						Path: []string{"/test/"},
 					},
 					Bucket: &BucketConfig{
-						Name:                "bucket1",
-						Region:              "us-east-1",
-						S3ListMaxKeys:       1000,
-						S3MaxUploadParts:    10000,
-						S3UploadPartSize:    5,
-						S3UploadConcurrency: 5,
-						S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+						Name:                  "bucket1",
+						Region:                "us-east-1",
+						S3ListMaxKeys:         1000,
+						S3MaxUploadParts:      10000,
+						S3UploadPartSize:      5,
+						S3UploadConcurrency:   5,
+						S3DownloadPartSize:    8,
+						S3DownloadConcurrency: 4,
+						S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 						Credentials: &BucketCredentialConfig{
 							AccessKey: &CredentialConfig{
 								Value: "SECRET1",
@@ -2061,13 +2127,15 @@ targets:
 					Path: []string{"/test/"},
 				},
 				Bucket: &BucketConfig{
-					Name:                "bucket1",
-					Region:              "us-east-1",
-					S3ListMaxKeys:       1000,
-					S3MaxUploadParts:    10000,
-					S3UploadPartSize:    5,
-					S3UploadConcurrency: 5,
-					S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+					Name:                  "bucket1",
+					Region:                "us-east-1",
+					S3ListMaxKeys:         1000,
+					S3MaxUploadParts:      10000,
+					S3UploadPartSize:      5,
+					S3UploadConcurrency:   5,
+					S3DownloadPartSize:    8,
+					S3DownloadConcurrency: 4,
+					S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 					Credentials: &BucketCredentialConfig{
 						AccessKey: &CredentialConfig{
 							Value: "VALUE1",
@@ -2134,13 +2202,15 @@ configuration with error
 						Path: []string{"/test/"},
 					},
 					Bucket: &BucketConfig{
-						Name:                "bucket1",
-						Region:              "us-east-1",
-						S3ListMaxKeys:       1000,
-						S3MaxUploadParts:    10000,
-						S3UploadPartSize:    5,
-						S3UploadConcurrency: 5,
-						S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+						Name:                  "bucket1",
+						Region:                "us-east-1",
+						S3ListMaxKeys:         1000,
+						S3MaxUploadParts:      10000,
+						S3UploadPartSize:      5,
+						S3UploadConcurrency:   5,
+						S3DownloadPartSize:    8,
+						S3DownloadConcurrency: 4,
+						S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 						Credentials: &BucketCredentialConfig{
 							AccessKey: &CredentialConfig{
 								Value: "VALUE1",
@@ -2275,13 +2345,15 @@ targets:
 					Path: []string{"/test/"},
 				},
 				Bucket: &BucketConfig{
-					Name:                "bucket1",
-					Region:              "us-east-1",
-					S3ListMaxKeys:       1000,
-					S3MaxUploadParts:    10000,
-					S3UploadPartSize:    5,
-					S3UploadConcurrency: 5,
-					S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+					Name:                  "bucket1",
+					Region:                "us-east-1",
+					S3ListMaxKeys:         1000,
+					S3MaxUploadParts:      10000,
+					S3UploadPartSize:      5,
+					S3UploadConcurrency:   5,
+					S3DownloadPartSize:    8,
+					S3DownloadConcurrency: 4,
+					S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 					Credentials: &BucketCredentialConfig{
 						AccessKey: &CredentialConfig{
 							Value: "VALUE1",
@@ -2354,13 +2426,15 @@ authProviders:
 						Path: []string{"/test/"},
 					},
 					Bucket: &BucketConfig{
-						Name:                "bucket1",
-						Region:              "us-east-1",
-						S3ListMaxKeys:       1000,
-						S3MaxUploadParts:    10000,
-						S3UploadPartSize:    5,
-						S3UploadConcurrency: 5,
-						S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+						Name:                  "bucket1",
+						Region:                "us-east-1",
+						S3ListMaxKeys:         1000,
+						S3MaxUploadParts:      10000,
+						S3UploadPartSize:      5,
+						S3UploadConcurrency:   5,
+						S3DownloadPartSize:    8,
+						S3DownloadConcurrency: 4,
+						S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 						Credentials: &BucketCredentialConfig{
 							AccessKey: &CredentialConfig{
 								Value: "VALUE1",
@@ -2508,13 +2582,15 @@ targets:
 					Path: []string{"/test/"},
 				},
 				Bucket: &BucketConfig{
-					Name:                "bucket1",
-					Region:              "us-east-1",
-					S3ListMaxKeys:       1000,
-					S3MaxUploadParts:    10000,
-					S3UploadPartSize:    5,
-					S3UploadConcurrency: 5,
-					S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+					Name:                  "bucket1",
+					Region:                "us-east-1",
+					S3ListMaxKeys:         1000,
+					S3MaxUploadParts:      10000,
+					S3UploadPartSize:      5,
+					S3UploadConcurrency:   5,
+					S3DownloadPartSize:    8,
+					S3DownloadConcurrency: 4,
+					S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 					Credentials: &BucketCredentialConfig{
 						AccessKey: &CredentialConfig{
 							Value: "VALUE1",
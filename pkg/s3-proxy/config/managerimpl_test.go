@@ -3,9 +3,13 @@
 package config
 
 import (
+	"net/netip"
+	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -99,6 +103,7 @@ func Test_loadBusinessDefaultValues(t *testing.T) {
 							CookieName:   DefaultOIDCCookieName,
 							LoginPath:    "/auth/provider1",
 							CallbackPath: "/auth/provider1/callback",
+							LogoutPath:   "/auth/provider1/logout",
 						},
 					},
 				},
@@ -124,6 +129,14 @@ func Test_loadBusinessDefaultValues(t *testing.T) {
 								CookieName:   "test",
 								LoginPath:    "/test",
 								CallbackPath: "/test/callback",
+								LogoutPath:   "/test/logout",
+								Session: &OIDCSessionConfig{
+									Enabled: true,
+									Store: &OIDCSessionStoreConfig{
+										Type:  OIDCSessionStoreRedisType,
+										Redis: &OIDCSessionRedisConfig{Address: "localhost:6379"},
+									},
+								},
 							},
 						},
 					},
@@ -146,6 +159,18 @@ func Test_loadBusinessDefaultValues(t *testing.T) {
 							CookieName:   "test",
 							LoginPath:    "/test",
 							CallbackPath: "/test/callback",
+							LogoutPath:   "/test/logout",
+							Session: &OIDCSessionConfig{
+								Enabled:     true,
+								MaxDuration: DefaultOIDCSessionMaxDuration,
+								Store: &OIDCSessionStoreConfig{
+									Type: OIDCSessionStoreRedisType,
+									Redis: &OIDCSessionRedisConfig{
+										Address:   "localhost:6379",
+										KeyPrefix: DefaultOIDCSessionRedisKeyPrefix,
+									},
+								},
+							},
 						},
 					},
 				},
@@ -292,9 +317,11 @@ func Test_loadBusinessDefaultValues(t *testing.T) {
 						"test": {
 							Actions: &ActionsConfig{GET: &GetActionConfig{Enabled: false}},
 							Bucket: &BucketConfig{
-								S3MaxUploadParts:    DefaultS3MaxUploadParts,
-								S3UploadPartSize:    DefaultS3UploadPartSize,
-								S3UploadConcurrency: DefaultS3UploadConcurrency,
+								S3MaxUploadParts:      DefaultS3MaxUploadParts,
+								S3UploadPartSize:      DefaultS3UploadPartSize,
+								S3UploadConcurrency:   DefaultS3UploadConcurrency,
+								S3DownloadPartSize:    DefaultS3DownloadPartSize,
+								S3DownloadConcurrency: DefaultS3DownloadConcurrency,
 							},
 							Templates: &TargetTemplateConfig{},
 						},
@@ -308,12 +335,14 @@ func Test_loadBusinessDefaultValues(t *testing.T) {
 						Name:    "test",
 						Actions: &ActionsConfig{GET: &GetActionConfig{Enabled: false}},
 						Bucket: &BucketConfig{
-							Region:              DefaultBucketRegion,
-							S3ListMaxKeys:       DefaultBucketS3ListMaxKeys,
-							S3MaxUploadParts:    DefaultS3MaxUploadParts,
-							S3UploadPartSize:    DefaultS3UploadPartSize,
-							S3UploadConcurrency: DefaultS3UploadConcurrency,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Region:                DefaultBucketRegion,
+							S3ListMaxKeys:         DefaultBucketS3ListMaxKeys,
+							S3MaxUploadParts:      DefaultS3MaxUploadParts,
+							S3UploadPartSize:      DefaultS3UploadPartSize,
+							S3UploadConcurrency:   DefaultS3UploadConcurrency,
+							S3DownloadPartSize:    DefaultS3DownloadPartSize,
+							S3DownloadConcurrency: DefaultS3DownloadConcurrency,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 						},
 						Templates: &TargetTemplateConfig{},
 					},
@@ -331,11 +360,13 @@ func Test_loadBusinessDefaultValues(t *testing.T) {
 						"test": {
 							Actions: &ActionsConfig{GET: &GetActionConfig{Enabled: false}},
 							Bucket: &BucketConfig{
-								Region:              "test",
-								S3ListMaxKeys:       100,
-								S3MaxUploadParts:    DefaultS3MaxUploadParts,
-								S3UploadPartSize:    DefaultS3UploadPartSize,
-								S3UploadConcurrency: DefaultS3UploadConcurrency,
+								Region:                "test",
+								S3ListMaxKeys:         100,
+								S3MaxUploadParts:      DefaultS3MaxUploadParts,
+								S3UploadPartSize:      DefaultS3UploadPartSize,
+								S3UploadConcurrency:   DefaultS3UploadConcurrency,
+								S3DownloadPartSize:    DefaultS3DownloadPartSize,
+								S3DownloadConcurrency: DefaultS3DownloadConcurrency,
 							},
 							Resources: []*Resource{
 								{
@@ -361,12 +392,14 @@ func Test_loadBusinessDefaultValues(t *testing.T) {
 						Name:    "test",
 						Actions: &ActionsConfig{GET: &GetActionConfig{Enabled: false}},
 						Bucket: &BucketConfig{
-							Region:              "test",
-							S3ListMaxKeys:       100,
-							S3MaxUploadParts:    DefaultS3MaxUploadParts,
-							S3UploadPartSize:    DefaultS3UploadPartSize,
-							S3UploadConcurrency: DefaultS3UploadConcurrency,
-							S3ForcePathStyle:    &DefaultBucketS3ForcePathStyle,
+							Region:                "test",
+							S3ListMaxKeys:         100,
+							S3MaxUploadParts:      DefaultS3MaxUploadParts,
+							S3UploadPartSize:      DefaultS3UploadPartSize,
+							S3UploadConcurrency:   DefaultS3UploadConcurrency,
+							S3DownloadPartSize:    DefaultS3DownloadPartSize,
+							S3DownloadConcurrency: DefaultS3DownloadConcurrency,
+							S3ForcePathStyle:      &DefaultBucketS3ForcePathStyle,
 						},
 						Resources: []*Resource{
 							{
@@ -398,12 +431,14 @@ func Test_loadBusinessDefaultValues(t *testing.T) {
 						"test": {
 							Actions: &ActionsConfig{GET: &GetActionConfig{Enabled: false}},
 							Bucket: &BucketConfig{
-								Region:              DefaultBucketRegion,
-								S3ListMaxKeys:       DefaultBucketS3ListMaxKeys,
-								S3MaxUploadParts:    DefaultS3MaxUploadParts,
-								S3UploadPartSize:    DefaultS3UploadPartSize,
-								S3UploadConcurrency: DefaultS3UploadConcurrency,
-								S3ForcePathStyle:    &trueValue,
+								Region:                DefaultBucketRegion,
+								S3ListMaxKeys:         DefaultBucketS3ListMaxKeys,
+								S3MaxUploadParts:      DefaultS3MaxUploadParts,
+								S3UploadPartSize:      DefaultS3UploadPartSize,
+								S3UploadConcurrency:   DefaultS3UploadConcurrency,
+								S3DownloadPartSize:    DefaultS3DownloadPartSize,
+								S3DownloadConcurrency: DefaultS3DownloadConcurrency,
+								S3ForcePathStyle:      &trueValue,
 							},
 							Templates: &TargetTemplateConfig{},
 						},
@@ -417,12 +452,14 @@ func Test_loadBusinessDefaultValues(t *testing.T) {
 						Name:    "test",
 						Actions: &ActionsConfig{GET: &GetActionConfig{Enabled: false}},
 						Bucket: &BucketConfig{
-							Region:              DefaultBucketRegion,
-							S3ListMaxKeys:       DefaultBucketS3ListMaxKeys,
-							S3MaxUploadParts:    DefaultS3MaxUploadParts,
-							S3UploadPartSize:    DefaultS3UploadPartSize,
-							S3UploadConcurrency: DefaultS3UploadConcurrency,
-							S3ForcePathStyle:    &trueValue,
+							Region:                DefaultBucketRegion,
+							S3ListMaxKeys:         DefaultBucketS3ListMaxKeys,
+							S3MaxUploadParts:      DefaultS3MaxUploadParts,
+							S3UploadPartSize:      DefaultS3UploadPartSize,
+							S3UploadConcurrency:   DefaultS3UploadConcurrency,
+							S3DownloadPartSize:    DefaultS3DownloadPartSize,
+							S3DownloadConcurrency: DefaultS3DownloadConcurrency,
+							S3ForcePathStyle:      &trueValue,
 						},
 						Templates: &TargetTemplateConfig{},
 					},
@@ -440,12 +477,14 @@ func Test_loadBusinessDefaultValues(t *testing.T) {
 						"test": {
 							Actions: &ActionsConfig{GET: &GetActionConfig{Enabled: false}},
 							Bucket: &BucketConfig{
-								Region:              DefaultBucketRegion,
-								S3ListMaxKeys:       DefaultBucketS3ListMaxKeys,
-								S3MaxUploadParts:    DefaultS3MaxUploadParts,
-								S3UploadPartSize:    DefaultS3UploadPartSize,
-								S3UploadConcurrency: DefaultS3UploadConcurrency,
-								S3ForcePathStyle:    &falseValue,
+								Region:                DefaultBucketRegion,
+								S3ListMaxKeys:         DefaultBucketS3ListMaxKeys,
+								S3MaxUploadParts:      DefaultS3MaxUploadParts,
+								S3UploadPartSize:      DefaultS3UploadPartSize,
+								S3UploadConcurrency:   DefaultS3UploadConcurrency,
+								S3DownloadPartSize:    DefaultS3DownloadPartSize,
+								S3DownloadConcurrency: DefaultS3DownloadConcurrency,
+								S3ForcePathStyle:      &falseValue,
 							},
 							Templates: &TargetTemplateConfig{},
 						},
@@ -459,12 +498,14 @@ func Test_loadBusinessDefaultValues(t *testing.T) {
 						Name:    "test",
 						Actions: &ActionsConfig{GET: &GetActionConfig{Enabled: false}},
 						Bucket: &BucketConfig{
-							Region:              DefaultBucketRegion,
-							S3ListMaxKeys:       DefaultBucketS3ListMaxKeys,
-							S3MaxUploadParts:    DefaultS3MaxUploadParts,
-							S3UploadPartSize:    DefaultS3UploadPartSize,
-							S3UploadConcurrency: DefaultS3UploadConcurrency,
-							S3ForcePathStyle:    &falseValue,
+							Region:                DefaultBucketRegion,
+							S3ListMaxKeys:         DefaultBucketS3ListMaxKeys,
+							S3MaxUploadParts:      DefaultS3MaxUploadParts,
+							S3UploadPartSize:      DefaultS3UploadPartSize,
+							S3UploadConcurrency:   DefaultS3UploadConcurrency,
+							S3DownloadPartSize:    DefaultS3DownloadPartSize,
+							S3DownloadConcurrency: DefaultS3DownloadConcurrency,
+							S3ForcePathStyle:      &falseValue,
 						},
 						Templates: &TargetTemplateConfig{},
 					},
@@ -702,6 +743,9 @@ func Test_loadAllCredentials(t *testing.T) {
 								ClientSecret: &CredentialConfig{
 									Value: "value1",
 								},
+								LoginStateSecret: &CredentialConfig{
+									Value: "value2",
+								},
 							},
 						},
 					},
@@ -715,6 +759,9 @@ func Test_loadAllCredentials(t *testing.T) {
 							ClientSecret: &CredentialConfig{
 								Value: "value1",
 							},
+							LoginStateSecret: &CredentialConfig{
+								Value: "value2",
+							},
 						},
 					},
 				},
@@ -723,6 +770,64 @@ func Test_loadAllCredentials(t *testing.T) {
 				{
 					Value: "value1",
 				},
+				{
+					Value: "value2",
+				},
+			},
+		},
+		{
+			name: "Load target webhook secret headers and signature secrets",
+			args: args{
+				out: &Config{
+					Targets: map[string]*TargetConfig{
+						"test": {
+							Actions: &ActionsConfig{
+								HEAD: &HeadActionConfig{
+									Config: &HeadActionConfigConfig{
+										Webhooks: []*WebhookConfig{
+											{
+												SecretHeaders: map[string]*CredentialConfig{
+													"Authorization": {Value: "value1"},
+												},
+												Signature: &WebhookSignatureConfig{
+													Secrets: []*CredentialConfig{{Value: "value2"}, {Value: "value3"}},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: false,
+			cfg: &Config{
+				Targets: map[string]*TargetConfig{
+					"test": {
+						Actions: &ActionsConfig{
+							HEAD: &HeadActionConfig{
+								Config: &HeadActionConfigConfig{
+									Webhooks: []*WebhookConfig{
+										{
+											SecretHeaders: map[string]*CredentialConfig{
+												"Authorization": {Value: "value1"},
+											},
+											Signature: &WebhookSignatureConfig{
+												Secrets: []*CredentialConfig{{Value: "value2"}, {Value: "value3"}},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			result: []*CredentialConfig{
+				{Value: "value1"},
+				{Value: "value2"},
+				{Value: "value3"},
 			},
 		},
 	}
@@ -762,6 +867,30 @@ func Test_loadResourceValues(t *testing.T) {
 				Methods: []string{"GET"},
 			},
 		},
+		{
+			name: "path regexp",
+			args: args{
+				res: &Resource{Path: "^/mount/.*\\.pdf$", Regexp: true},
+			},
+			out: &Resource{
+				Methods:   []string{"GET"},
+				Path:      "^/mount/.*\\.pdf$",
+				PathRegex: regexp.MustCompile("^/mount/.*\\.pdf$"),
+				Regexp:    true,
+			},
+		},
+		{
+			name: "invalid path regexp",
+			args: args{
+				res: &Resource{Path: "^/mount/(", Regexp: true},
+			},
+			wantErr: true,
+			out: &Resource{
+				Methods: []string{"GET"},
+				Path:    "^/mount/(",
+				Regexp:  true,
+			},
+		},
 		{
 			name: "default OPA tags",
 			args: args{
@@ -783,6 +912,68 @@ func Test_loadResourceValues(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "decision cache durations",
+			args: args{
+				res: &Resource{
+					JWT: &ResourceHeaderOIDC{
+						AuthorizationOPAServer: &OPAServerAuthorization{
+							URL:                         "http://opa",
+							DecisionCacheDurationString: "30s",
+						},
+					},
+					Header: &ResourceHeaderOIDC{
+						AuthorizationRego: &RegoAuthorization{
+							Policy:                      "p1",
+							DecisionCacheDurationString: "1m",
+						},
+					},
+				},
+			},
+			out: &Resource{
+				Methods: []string{"GET"},
+				JWT: &ResourceHeaderOIDC{
+					AuthorizationOPAServer: &OPAServerAuthorization{
+						URL:                         "http://opa",
+						Tags:                        map[string]string{},
+						DecisionCacheDurationString: "30s",
+						DecisionCacheDuration:       30 * time.Second,
+					},
+				},
+				Header: &ResourceHeaderOIDC{
+					AuthorizationRego: &RegoAuthorization{
+						Policy:                      "p1",
+						Tags:                        map[string]string{},
+						DecisionCacheDurationString: "1m",
+						DecisionCacheDuration:       time.Minute,
+					},
+				},
+			},
+		},
+		{
+			name: "invalid decision cache duration",
+			args: args{
+				res: &Resource{
+					OIDC: &ResourceHeaderOIDC{
+						AuthorizationRego: &RegoAuthorization{
+							Policy:                      "p1",
+							DecisionCacheDurationString: "fake",
+						},
+					},
+				},
+			},
+			wantErr: true,
+			out: &Resource{
+				Methods: []string{"GET"},
+				OIDC: &ResourceHeaderOIDC{
+					AuthorizationRego: &RegoAuthorization{
+						Policy:                      "p1",
+						Tags:                        map[string]string{},
+						DecisionCacheDurationString: "fake",
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -793,3 +984,452 @@ func Test_loadResourceValues(t *testing.T) {
 		})
 	}
 }
+
+func Test_loadAPIKeysFile(t *testing.T) {
+	dir := t.TempDir()
+
+	validFile := filepath.Join(dir, "keys.yaml")
+	err := os.WriteFile(validFile, []byte(`keys:
+  - id: key1
+    hash: sha256:salt:0000000000000000000000000000000000000000000000000000000000000000
+    owner: ci
+    groups: [ci]
+    expiresAt: "2030-01-02T03:04:05Z"
+    paths: ["/ci/**"]
+`), 0o600)
+	assert.NoError(t, err)
+
+	invalidFile := filepath.Join(dir, "invalid.yaml")
+	err = os.WriteFile(invalidFile, []byte("keys:\n  - id: key1\n"), 0o600)
+	assert.NoError(t, err)
+
+	invalidDateFile := filepath.Join(dir, "invalid-date.yaml")
+	err = os.WriteFile(invalidDateFile, []byte("keys:\n  - id: key1\n    hash: h\n    owner: ci\n    expiresAt: tomorrow\n"), 0o600)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		file    string
+		want    []*APIKeyConfig
+		wantErr bool
+	}{
+		{
+			name: "valid file",
+			file: validFile,
+			want: []*APIKeyConfig{{
+				ID:              "key1",
+				Hash:            "sha256:salt:0000000000000000000000000000000000000000000000000000000000000000",
+				Owner:           "ci",
+				Groups:          []string{"ci"},
+				ExpiresAtString: "2030-01-02T03:04:05Z",
+				ExpiresAt:       time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+				Paths:           []string{"/ci/**"},
+			}},
+		},
+		{name: "missing file", file: filepath.Join(dir, "missing.yaml"), wantErr: true},
+		{name: "invalid keys", file: invalidFile, wantErr: true},
+		{name: "invalid expiration", file: invalidDateFile, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiKeyCfg := &APIKeyAuthConfig{KeysFile: tt.file}
+
+			err := loadAPIKeysFile(apiKeyCfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, apiKeyCfg.FileKeys)
+		})
+	}
+}
+
+func Test_loadHtpasswdFile(t *testing.T) {
+	dir := t.TempDir()
+
+	bcryptHash := "$2a$05$0YWsx/1Ac9FaP8NjL.InL.dyE9hQLz..ScvpIXSwpMp45EQt8ywqC"
+	shaHash := "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5"
+
+	validFile := filepath.Join(dir, "valid.htpasswd")
+	err := os.WriteFile(validFile, []byte("# Users\nuser1:"+bcryptHash+"\n\n  user2:"+shaHash+"  \n"), 0o600)
+	assert.NoError(t, err)
+
+	invalidLineFile := filepath.Join(dir, "invalid-line.htpasswd")
+	err = os.WriteFile(invalidLineFile, []byte("user1:"+bcryptHash+"\nuser2\n"), 0o600)
+	assert.NoError(t, err)
+
+	unsupportedFile := filepath.Join(dir, "unsupported.htpasswd")
+	err = os.WriteFile(unsupportedFile, []byte("user1:$apr1$salt$hash\n"), 0o600)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		file    string
+		want    []*BasicAuthUserConfig
+		wantErr string
+	}{
+		{
+			name: "valid file",
+			file: validFile,
+			want: []*BasicAuthUserConfig{
+				{User: "user1", PasswordHash: &CredentialConfig{Value: bcryptHash}},
+				{User: "user2", PasswordHash: &CredentialConfig{Value: shaHash}},
+			},
+		},
+		{
+			name:    "missing file",
+			file:    filepath.Join(dir, "missing.htpasswd"),
+			wantErr: "open " + filepath.Join(dir, "missing.htpasswd") + ": no such file or directory",
+		},
+		{
+			name:    "invalid line",
+			file:    invalidLineFile,
+			wantErr: "htpasswd file " + invalidLineFile + " has an invalid line 2",
+		},
+		{
+			name:    "unsupported hash",
+			file:    unsupportedFile,
+			wantErr: "htpasswd file " + unsupportedFile + " has an invalid password hash for user user1: unsupported password hash format",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			basicCfg := &ResourceBasic{HtpasswdFile: tt.file}
+
+			err := loadHtpasswdFile(basicCfg)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, basicCfg.HtpasswdCredentials)
+		})
+	}
+}
+
+func Test_loadAuditSinkDefaultValues(t *testing.T) {
+	tests := []struct {
+		in      *AuditSinkConfig
+		want    *AuditSinkConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name: "stdout",
+			in:   &AuditSinkConfig{Type: AuditSinkTypeStdout},
+			want: &AuditSinkConfig{Type: AuditSinkTypeStdout},
+		},
+		{
+			name: "file default values",
+			in:   &AuditSinkConfig{Type: AuditSinkTypeFile, File: &AuditFileSinkConfig{Path: "/tmp/audit.log"}},
+			want: &AuditSinkConfig{
+				Type: AuditSinkTypeFile,
+				File: &AuditFileSinkConfig{
+					Path:       "/tmp/audit.log",
+					MaxSize:    DefaultAuditFileMaxSize,
+					MaxBackups: DefaultAuditFileMaxBackups,
+				},
+			},
+		},
+		{
+			name: "s3 default values",
+			in:   &AuditSinkConfig{Type: AuditSinkTypeS3, S3: &AuditS3SinkConfig{Target: "target1"}},
+			want: &AuditSinkConfig{
+				Type: AuditSinkTypeS3,
+				S3: &AuditS3SinkConfig{
+					Target:        "target1",
+					Prefix:        DefaultAuditS3Prefix,
+					BatchSize:     DefaultAuditS3BatchSize,
+					FlushInterval: DefaultAuditS3FlushInterval,
+				},
+			},
+		},
+		{
+			name: "s3 declared values",
+			in: &AuditSinkConfig{
+				Type: AuditSinkTypeS3,
+				S3: &AuditS3SinkConfig{
+					Target:              "target1",
+					Prefix:              "logs/",
+					BatchSize:           10,
+					FlushIntervalString: "10s",
+				},
+			},
+			want: &AuditSinkConfig{
+				Type: AuditSinkTypeS3,
+				S3: &AuditS3SinkConfig{
+					Target:              "target1",
+					Prefix:              "logs/",
+					BatchSize:           10,
+					FlushIntervalString: "10s",
+					FlushInterval:       10 * time.Second,
+				},
+			},
+		},
+		{
+			name:    "invalid duration",
+			in:      &AuditSinkConfig{Type: AuditSinkTypeS3, S3: &AuditS3SinkConfig{FlushIntervalString: "fake"}},
+			wantErr: `time: invalid duration "fake"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := loadAuditSinkDefaultValues(tt.in)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, tt.in)
+		})
+	}
+}
+
+func Test_loadIPFilterValues(t *testing.T) {
+	tests := []struct {
+		in      *IPFilterConfig
+		want    *IPFilterConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name: "empty",
+			in:   &IPFilterConfig{},
+			want: &IPFilterConfig{},
+		},
+		{
+			name: "cidr and single ips",
+			in: &IPFilterConfig{
+				Allow: []string{"10.0.0.0/8", "192.168.1.10", "2001:db8::/32", "::ffff:172.16.0.1"},
+				Deny:  []string{"10.1.2.3/16", "2001:db8::1"},
+			},
+			want: &IPFilterConfig{
+				Allow: []string{"10.0.0.0/8", "192.168.1.10", "2001:db8::/32", "::ffff:172.16.0.1"},
+				Deny:  []string{"10.1.2.3/16", "2001:db8::1"},
+				AllowPrefixes: []netip.Prefix{
+					netip.MustParsePrefix("10.0.0.0/8"),
+					netip.MustParsePrefix("192.168.1.10/32"),
+					netip.MustParsePrefix("2001:db8::/32"),
+					netip.MustParsePrefix("172.16.0.1/32"),
+				},
+				DenyPrefixes: []netip.Prefix{
+					netip.MustParsePrefix("10.1.0.0/16"),
+					netip.MustParsePrefix("2001:db8::1/128"),
+				},
+			},
+		},
+		{
+			name: "countries are upper cased",
+			in: &IPFilterConfig{
+				AllowCountries: []string{"fr", "De"},
+				DenyCountries:  []string{"us"},
+			},
+			want: &IPFilterConfig{
+				AllowCountries: []string{"FR", "DE"},
+				DenyCountries:  []string{"US"},
+			},
+		},
+		{
+			name:    "invalid allow ip",
+			in:      &IPFilterConfig{Allow: []string{"fake"}},
+			wantErr: `ip filter allow list: ParseAddr("fake"): unable to parse IP`,
+		},
+		{
+			name:    "invalid deny cidr",
+			in:      &IPFilterConfig{Deny: []string{"10.0.0.0/64"}},
+			wantErr: `ip filter deny list: netip.ParsePrefix("10.0.0.0/64"): prefix length out of range`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := loadIPFilterValues(tt.in)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, tt.in)
+		})
+	}
+}
+
+func Test_loadBruteForceProtectionDefaultValues(t *testing.T) {
+	zero := 0
+	defaultPerUser := DefaultBruteForceMaxAttemptsPerUser
+	defaultPerIP := DefaultBruteForceMaxAttemptsPerIP
+
+	tests := []struct {
+		in      *BruteForceProtectionConfig
+		want    *BruteForceProtectionConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name: "default values",
+			in:   &BruteForceProtectionConfig{Enabled: true},
+			want: &BruteForceProtectionConfig{
+				Enabled:            true,
+				MaxAttemptsPerUser: &defaultPerUser,
+				MaxAttemptsPerIP:   &defaultPerIP,
+				AttemptsWindow:     DefaultBruteForceAttemptsWindow,
+				LockoutDuration:    DefaultBruteForceLockoutDuration,
+				MaxLockoutDuration: DefaultBruteForceMaxLockoutDuration,
+			},
+		},
+		{
+			name: "declared values",
+			in: &BruteForceProtectionConfig{
+				Enabled:                  true,
+				MaxAttemptsPerUser:       &zero,
+				MaxAttemptsPerIP:         &zero,
+				AttemptsWindowString:     "1m",
+				LockoutDurationString:    "2m",
+				MaxLockoutDurationString: "3m",
+			},
+			want: &BruteForceProtectionConfig{
+				Enabled:                  true,
+				MaxAttemptsPerUser:       &zero,
+				MaxAttemptsPerIP:         &zero,
+				AttemptsWindowString:     "1m",
+				LockoutDurationString:    "2m",
+				MaxLockoutDurationString: "3m",
+				AttemptsWindow:           time.Minute,
+				LockoutDuration:          2 * time.Minute,
+				MaxLockoutDuration:       3 * time.Minute,
+			},
+		},
+		{
+			name:    "invalid duration",
+			in:      &BruteForceProtectionConfig{Enabled: true, LockoutDurationString: "fake"},
+			wantErr: `time: invalid duration "fake"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := loadBruteForceProtectionDefaultValues(tt.in)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, tt.in)
+		})
+	}
+}
+
+func Test_loadWebhookQueueDefaultValues(t *testing.T) {
+	tests := []struct {
+		in      *WebhookQueueConfig
+		want    *WebhookQueueConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name: "default values",
+			in:   &WebhookQueueConfig{Enabled: true, Directory: "/tmp/queue"},
+			want: &WebhookQueueConfig{
+				Enabled:        true,
+				Directory:      "/tmp/queue",
+				Workers:        DefaultWebhookQueueWorkers,
+				MaxAttempts:    DefaultWebhookQueueMaxAttempts,
+				InitialBackoff: DefaultWebhookQueueInitialBackoff,
+				MaxBackoff:     DefaultWebhookQueueMaxBackoff,
+			},
+		},
+		{
+			name: "declared values",
+			in: &WebhookQueueConfig{
+				Enabled:              true,
+				Directory:            "/tmp/queue",
+				Workers:              1,
+				MaxAttempts:          3,
+				InitialBackoffString: "2s",
+				MaxBackoffString:     "1m",
+			},
+			want: &WebhookQueueConfig{
+				Enabled:              true,
+				Directory:            "/tmp/queue",
+				Workers:              1,
+				MaxAttempts:          3,
+				InitialBackoffString: "2s",
+				MaxBackoffString:     "1m",
+				InitialBackoff:       2 * time.Second,
+				MaxBackoff:           time.Minute,
+			},
+		},
+		{
+			name:    "invalid duration",
+			in:      &WebhookQueueConfig{Enabled: true, MaxBackoffString: "fake"},
+			wantErr: `time: invalid duration "fake"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := loadWebhookQueueDefaultValues(tt.in)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, tt.in)
+		})
+	}
+}
+
+func Test_loadTargetWebhooksDefaultValues(t *testing.T) {
+	actions := &ActionsConfig{
+		GET: &GetActionConfig{Config: &GetActionConfigConfig{Webhooks: []*WebhookConfig{
+			{Signature: &WebhookSignatureConfig{}},
+		}}},
+		HEAD: &HeadActionConfig{},
+		PUT: &PutActionConfig{Config: &PutActionConfigConfig{
+			Webhooks: []*WebhookConfig{
+				{Signature: &WebhookSignatureConfig{Header: "X-Custom"}},
+				{},
+			},
+			PreActionHooks: []*PreActionHookConfig{
+				{Signature: &WebhookSignatureConfig{}},
+				{TimeoutString: "2s", FailurePolicy: PreActionHookFailurePolicyOpen},
+			},
+		}},
+		DELETE: &DeleteActionConfig{Config: &DeleteActionConfigConfig{Webhooks: []*WebhookConfig{
+			{Signature: &WebhookSignatureConfig{}},
+		}}},
+	}
+
+	err := loadTargetWebhooksDefaultValues(actions)
+	assert.NoError(t, err)
+
+	assert.Equal(t, DefaultWebhookSignatureHeader, actions.GET.Config.Webhooks[0].Signature.Header)
+	assert.Equal(t, "X-Custom", actions.PUT.Config.Webhooks[0].Signature.Header)
+	assert.Nil(t, actions.PUT.Config.Webhooks[1].Signature)
+	assert.Equal(t, DefaultWebhookSignatureHeader, actions.DELETE.Config.Webhooks[0].Signature.Header)
+
+	// Pre-action hooks
+	assert.Equal(t, DefaultWebhookSignatureHeader, actions.PUT.Config.PreActionHooks[0].Signature.Header)
+	assert.Equal(t, DefaultPreActionHookTimeout, actions.PUT.Config.PreActionHooks[0].Timeout)
+	assert.Equal(t, PreActionHookFailurePolicyClosed, actions.PUT.Config.PreActionHooks[0].FailurePolicy)
+	assert.Equal(t, 2*time.Second, actions.PUT.Config.PreActionHooks[1].Timeout)
+	assert.Equal(t, PreActionHookFailurePolicyOpen, actions.PUT.Config.PreActionHooks[1].FailurePolicy)
+
+	// Invalid timeout
+	err = loadTargetWebhooksDefaultValues(&ActionsConfig{
+		DELETE: &DeleteActionConfig{Config: &DeleteActionConfigConfig{PreActionHooks: []*PreActionHookConfig{
+			{TimeoutString: "fake"},
+		}}},
+	})
+	assert.EqualError(t, err, `time: invalid duration "fake"`)
+}
@@ -6,13 +6,16 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"slices"
 	"strings"
 	"time"
 
 	"emperror.dev/errors"
+	"github.com/gobwas/glob"
 	"github.com/thoas/go-funk"
 
 	utils "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/utils/generalutils"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/utils/passwordhash"
 )
 
 func validateBusinessConfig(out *Config) error {
@@ -28,6 +31,24 @@ func validateBusinessConfig(out *Config) error {
 				if err != nil {
 					return err
 				}
+				// Validate rego authorizations
+				err = validateRegoAuthorization(fmt.Sprintf("resource %d from target %s", j, key), res, out.RegoPolicies)
+				// Return error if exists
+				if err != nil {
+					return err
+				}
+				// Validate authorization decision caches
+				err = validateAuthorizationDecisionCache(fmt.Sprintf("resource %d from target %s", j, key), res)
+				// Return error if exists
+				if err != nil {
+					return err
+				}
+				// Validate rbac authorizations
+				err = validateRBACAuthorization(fmt.Sprintf("resource %d from target %s", j, key), res, out.RBAC)
+				// Return error if exists
+				if err != nil {
+					return err
+				}
 			}
 		}
 		// Check mount path items
@@ -66,6 +87,19 @@ func validateBusinessConfig(out *Config) error {
 		if err := validateUserIsolation(key, target); err != nil {
 			return err
 		}
+
+		if err := validateUserIsolationQuota(key, target); err != nil {
+			return err
+		}
+
+		if err := validatePreActionHooks(key, target); err != nil {
+			return err
+		}
+
+		// Check concurrency queue timeout
+		if target.Concurrency != nil && target.Concurrency.QueueTimeout <= 0 {
+			return errors.Errorf("target %s must have a positive concurrency queue timeout", key)
+		}
 	}
 
 	// Validate list targets object
@@ -79,6 +113,24 @@ func validateBusinessConfig(out *Config) error {
 			if err != nil {
 				return err
 			}
+			// Validate rego authorizations
+			err = validateRegoAuthorization("resource from list targets", res, out.RegoPolicies)
+			// Return error if exists
+			if err != nil {
+				return err
+			}
+			// Validate authorization decision caches
+			err = validateAuthorizationDecisionCache("resource from list targets", res)
+			// Return error if exists
+			if err != nil {
+				return err
+			}
+			// Validate rbac authorizations
+			err = validateRBACAuthorization("resource from list targets", res, out.RBAC)
+			// Return error if exists
+			if err != nil {
+				return err
+			}
 		}
 		// Check mount path items
 		pathList := out.ListTargets.Mount.Path
@@ -125,9 +177,128 @@ func validateBusinessConfig(out *Config) error {
 			if authProviderCfg.LoginPath == u.Path {
 				return errors.Errorf("provider %s can't have same login and callback path (to avoid redirect loop)", prov)
 			}
+
+			// Check logout path
+			if authProviderCfg.LogoutPath == authProviderCfg.LoginPath || authProviderCfg.LogoutPath == u.Path {
+				return errors.Errorf("provider %s can't have a logout path equal to login or callback path", prov)
+			}
+
+			// Check login state secret
+			if authProviderCfg.LoginStateSecret == nil ||
+				len(authProviderCfg.LoginStateSecret.Value) < OIDCLoginStateSecretMinLength {
+				return errors.Errorf(
+					"provider %s must have a login state secret with at least %d characters",
+					prov,
+					OIDCLoginStateSecretMinLength,
+				)
+			}
+
+			// Check session
+			err = validateOIDCSessionConfig(prov, authProviderCfg.Session)
+			// Check error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Validate api key authentication providers
+	if out.AuthProviders != nil && out.AuthProviders.APIKey != nil {
+		for prov, authProviderCfg := range out.AuthProviders.APIKey {
+			err := validateAPIKeyAuthConfig(prov, authProviderCfg)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Validate mtls authentication providers
+	if out.AuthProviders != nil && len(out.AuthProviders.MTLS) != 0 {
+		// Check that client certificates are verified by server
+		if out.Server == nil || out.Server.SSL == nil || !out.Server.SSL.Enabled || out.Server.SSL.ClientCA == nil {
+			return errors.New("mtls authentication providers require server.ssl to be enabled with a clientCA")
+		}
+	}
+
+	// Validate ldap authentication providers
+	if out.AuthProviders != nil && out.AuthProviders.LDAP != nil {
+		for prov, authProviderCfg := range out.AuthProviders.LDAP {
+			err := validateLDAPAuthConfig(prov, authProviderCfg)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Validate audit configuration
+	if out.Audit != nil {
+		err := validateAuditConfig(out.Audit, out.Targets)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Validate impersonation configuration
+	if out.Impersonation != nil {
+		err := validateImpersonationConfig(out.Impersonation)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Validate webhook queue configuration
+	if out.WebhookQueue != nil {
+		err := validateWebhookQueueConfig(out.WebhookQueue)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Validate ip filters
+	err := validateIPFilterCountries(out)
+	if err != nil {
+		return err
+	}
+
+	// Validate basic authentication providers
+	if out.AuthProviders != nil && out.AuthProviders.Basic != nil {
+		for prov, authProviderCfg := range out.AuthProviders.Basic {
+			err := validateBruteForceProtectionConfig(prov, authProviderCfg.BruteForceProtection)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
+	// Validate hmac authentication providers
+	if out.AuthProviders != nil && out.AuthProviders.HMAC != nil {
+		for prov, authProviderCfg := range out.AuthProviders.HMAC {
+			err := validateHMACAuthConfig(prov, authProviderCfg)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Validate jwt authentication providers
+	if out.AuthProviders != nil && out.AuthProviders.JWT != nil {
+		for prov, authProviderCfg := range out.AuthProviders.JWT {
+			err := validateJWTAuthConfig(prov, authProviderCfg)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Check client ip header configurations
+	if err := validateClientIPHeader(out.Server, "server"); err != nil {
+		return err
+	}
+
+	if err := validateClientIPHeader(out.InternalServer, "internalServer"); err != nil {
+		return err
+	}
+
 	if out.Server != nil && out.Server.SSL != nil {
 		err := validateSSLConfig(out.Server.SSL, "server")
 		if err != nil {
@@ -142,12 +313,146 @@ func validateBusinessConfig(out *Config) error {
 		}
 	}
 
+	// Validate rego policies
+	for name, policyCfg := range out.RegoPolicies {
+		// Check that policy has a source
+		if len(policyCfg.Files) == 0 && policyCfg.Bucket == nil {
+			return errors.Errorf("rego policy %s must have files or a bucket", name)
+		}
+		// Check that bucket target exists
+		if policyCfg.Bucket != nil && out.Targets[policyCfg.Bucket.Target] == nil {
+			return errors.Errorf("rego policy %s must use an existing target for bucket: %s not found", name, policyCfg.Bucket.Target)
+		}
+	}
+
+	// Validate rbac
+	if out.RBAC != nil {
+		err := validateRBACConfig(out.RBAC)
+		// Check error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateRBACConfig ensures that rbac bindings use declared roles and that permission globs are valid.
+func validateRBACConfig(rbacCfg *RBACConfig) error {
+	// Check roles
+	for name, role := range rbacCfg.Roles {
+		for i, perm := range role.Permissions {
+			// Check globs
+			for _, p := range append(append([]string{}, perm.Targets...), perm.Paths...) {
+				_, err := glob.Compile(p, '/')
+				// Check error
+				if err != nil {
+					return errors.Wrapf(err, "rbac role %s permission %d has an invalid glob %s", name, i, p)
+				}
+			}
+		}
+	}
+
+	// Check bindings
+	for i, binding := range rbacCfg.Bindings {
+		// Check that role exists
+		if rbacCfg.Roles[binding.Role] == nil {
+			return errors.Errorf("rbac binding %d must use an existing role: %s not found", i, binding.Role)
+		}
+		// Check that subjects are declared
+		if len(binding.Users) == 0 && len(binding.Groups) == 0 {
+			return errors.Errorf("rbac binding %d must have users or groups", i)
+		}
+		// Check that providers are declared
+		if len(binding.Providers) == 0 {
+			return errors.Errorf("rbac binding %d must have at least one provider", i)
+		}
+	}
+
+	return nil
+}
+
+// validateRBACAuthorization ensures that rbac authorizations are declared with a rbac configuration
+// and aren't mixed with other authorization modes.
+func validateRBACAuthorization(beginErrorMessage string, res *Resource, rbacCfg *RBACConfig) error {
+	// Check if basic resource uses rbac
+	enabled := res.Basic != nil && res.Basic.AuthorizationRBAC
+	// Loop over resource types supporting authorization
+	for _, it := range []*ResourceHeaderOIDC{res.OIDC, res.Header, res.JWT, res.APIKey, res.MTLS, res.LDAP, res.HMAC} {
+		// Check if rbac authorization is used
+		if it == nil || !it.AuthorizationRBAC {
+			continue
+		}
+		// Check that other authorization modes aren't used
+		if it.AuthorizationOPAServer != nil || it.AuthorizationRego != nil || len(it.AuthorizationAccesses) != 0 {
+			return errors.New(beginErrorMessage + " cannot contain rbac authorization with authorization accesses, OPA server or rego at the same time")
+		}
+
+		enabled = true
+	}
+	// Check that rbac configuration exists
+	if enabled && rbacCfg == nil {
+		return errors.New(beginErrorMessage + " cannot use rbac authorization without rbac configuration")
+	}
+
 	return nil
 }
 
+// validateRegoAuthorization ensures that rego authorizations use a declared policy
+// and aren't mixed with other authorization modes.
+func validateRegoAuthorization(beginErrorMessage string, res *Resource, regoPolicies map[string]*RegoPolicyConfig) error {
+	// Loop over resource types supporting authorization
+	for _, it := range []*ResourceHeaderOIDC{res.OIDC, res.Header, res.JWT, res.APIKey, res.MTLS, res.LDAP, res.HMAC} {
+		// Check if rego authorization is used
+		if it == nil || it.AuthorizationRego == nil {
+			continue
+		}
+		// Check that other authorization modes aren't used
+		if it.AuthorizationOPAServer != nil || len(it.AuthorizationAccesses) != 0 {
+			return errors.New(beginErrorMessage + " cannot contain rego authorization with authorization accesses or OPA server at the same time")
+		}
+		// Check that policy exists
+		if regoPolicies[it.AuthorizationRego.Policy] == nil {
+			return errors.Errorf("%s must use an existing rego policy: %s not found", beginErrorMessage, it.AuthorizationRego.Policy)
+		}
+	}
+
+	return nil
+}
+
+// validateAuthorizationDecisionCache ensures that authorization decision cache durations are valid.
+func validateAuthorizationDecisionCache(beginErrorMessage string, res *Resource) error {
+	// Loop over resource types supporting authorization
+	for _, it := range []*ResourceHeaderOIDC{res.OIDC, res.Header, res.JWT, res.APIKey, res.MTLS, res.LDAP, res.HMAC} {
+		// Check if it is set
+		if it == nil {
+			continue
+		}
+		// Check OPA server decision cache duration
+		if it.AuthorizationOPAServer != nil && it.AuthorizationOPAServer.DecisionCacheDuration < 0 {
+			return errors.New(beginErrorMessage + " must have a positive OPA server decision cache duration")
+		}
+		// Check rego decision cache duration
+		if it.AuthorizationRego != nil && it.AuthorizationRego.DecisionCacheDuration < 0 {
+			return errors.New(beginErrorMessage + " must have a positive rego decision cache duration")
+		}
+	}
+
+	return nil
+}
+
+// defaultUserIsolationPathTemplate is the equivalent template of the user folder
+// when userIsolationPathTemplate isn't declared.
+const defaultUserIsolationPathTemplate = "{{ .User.GetIdentifier }}"
+
 // validateUserIsolation enforces the wiring rules for the userIsolation
 // feature on a target and indexes the admin list into the O(1) lookup
-// set used at request time. Run only when userIsolation is enabled.
+// set used at request time.
+//
+// Path templates and groups can only be declared with userIsolation, and
+// userIsolationGroups only with userIsolationGroupPathTemplate. User and
+// group folders must not be able to contain each other, otherwise a user
+// with a crafted identifier could reach group folders (or the reverse).
 //
 // Rule: the target must declare at least one resource with basic, OIDC
 // or header authentication. Without an authenticated identity, the
@@ -155,15 +460,55 @@ func validateBusinessConfig(out *Config) error {
 // would 403; failing fast at config load is friendlier than shipping
 // a target that can never serve traffic.
 func validateUserIsolation(targetKey string, target *TargetConfig) error {
-	if target.Actions == nil || target.Actions.GET == nil ||
-		target.Actions.GET.Config == nil || !target.Actions.GET.Config.UserIsolation {
+	if target.Actions == nil || target.Actions.GET == nil || target.Actions.GET.Config == nil {
 		return nil
 	}
 
+	getCfg := target.Actions.GET.Config
+
+	// Check that path templates and groups are only declared with userIsolation
+	if !getCfg.UserIsolation {
+		if getCfg.UserIsolationPathTemplate != "" || getCfg.UserIsolationGroupPathTemplate != "" ||
+			len(getCfg.UserIsolationGroups) != 0 {
+			return errors.Errorf(
+				"target %s declares user isolation path templates or groups but userIsolation isn't enabled",
+				targetKey,
+			)
+		}
+
+		return nil
+	}
+
+	// Check that groups are only declared with group spaces
+	if len(getCfg.UserIsolationGroups) != 0 && getCfg.UserIsolationGroupPathTemplate == "" {
+		return errors.Errorf(
+			"target %s declares userIsolationGroups without userIsolationGroupPathTemplate",
+			targetKey,
+		)
+	}
+
+	// Check that user folders and group folders can't contain each other
+	if getCfg.UserIsolationGroupPathTemplate != "" {
+		// Get user folder template
+		userTpl := getCfg.UserIsolationPathTemplate
+		if userTpl == "" {
+			userTpl = defaultUserIsolationPathTemplate
+		}
+
+		if userIsolationTemplatesCanOverlap(userTpl, getCfg.UserIsolationGroupPathTemplate) {
+			return errors.Errorf(
+				"target %s has user and group isolation folders that can contain each other: "+
+					"userIsolationPathTemplate and userIsolationGroupPathTemplate must start with different static folders",
+				targetKey,
+			)
+		}
+	}
+
 	hasAuthResource := false
 
 	for _, res := range target.Resources {
-		if res.Basic != nil || res.OIDC != nil || res.Header != nil {
+		if res.Basic != nil || res.OIDC != nil || res.Header != nil || res.JWT != nil || res.APIKey != nil || res.MTLS != nil ||
+			res.LDAP != nil || res.HMAC != nil {
 			hasAuthResource = true
 
 			break
@@ -173,13 +518,466 @@ func validateUserIsolation(targetKey string, target *TargetConfig) error {
 	if !hasAuthResource {
 		return errors.Errorf(
 			"target %s has userIsolation enabled but no resource with authentication "+
-				"(basic, oidc or header) is declared; isolation requires an authenticated user",
+				"(basic, oidc, header, jwt, apiKey, mtls, ldap or hmac) is declared; isolation requires an authenticated user",
 			targetKey,
 		)
 	}
 
 	// Precompute admin lookup set so request-time checks are O(1).
-	target.Actions.GET.Config.indexUserIsolationAdmins()
+	getCfg.indexUserIsolationAdmins()
+
+	return nil
+}
+
+// userIsolationTemplatesCanOverlap checks if folders rendered by two user isolation templates
+// can contain each other. Templates are compared segment by segment on their common length:
+// they can't overlap as soon as two segments can't render the same value.
+// Identifiers and groups are rendered as exactly one segment.
+func userIsolationTemplatesCanOverlap(tpl1, tpl2 string) bool {
+	// Split templates
+	segs1 := splitUserIsolationTemplate(tpl1)
+	segs2 := splitUserIsolationTemplate(tpl2)
+
+	for i := range min(len(segs1), len(segs2)) {
+		if !userIsolationSegmentsCanMatch(segs1[i], segs2[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// splitUserIsolationTemplate will split a template on slashes outside of template actions and ignore empty segments.
+func splitUserIsolationTemplate(tpl string) []string {
+	res := []string{}
+	depth := 0
+	start := 0
+
+	for i := 0; i < len(tpl); i++ {
+		switch {
+		case strings.HasPrefix(tpl[i:], "{{"):
+			depth++
+			i++
+		case strings.HasPrefix(tpl[i:], "}}") && depth > 0:
+			depth--
+			i++
+		case tpl[i] == '/' && depth == 0:
+			res = appendUserIsolationSegment(res, tpl[start:i])
+			start = i + 1
+		}
+	}
+
+	return appendUserIsolationSegment(res, tpl[start:])
+}
+
+func appendUserIsolationSegment(list []string, seg string) []string {
+	// Ignore empty segments
+	if strings.TrimSpace(seg) == "" {
+		return list
+	}
+
+	return append(list, strings.TrimSpace(seg))
+}
+
+// userIsolationSegmentsCanMatch checks if two template segments can render the same value.
+// Static segments must be equal. Segments with template actions can render any value
+// starting and ending with their static parts.
+func userIsolationSegmentsCanMatch(seg1, seg2 string) bool {
+	// Check dynamic segments
+	dyn1 := strings.Contains(seg1, "{{")
+	dyn2 := strings.Contains(seg2, "{{")
+
+	switch {
+	case !dyn1 && !dyn2:
+		return seg1 == seg2
+	case dyn1 && dyn2:
+		p1, s1 := getUserIsolationSegmentStaticParts(seg1)
+		p2, s2 := getUserIsolationSegmentStaticParts(seg2)
+
+		return (strings.HasPrefix(p1, p2) || strings.HasPrefix(p2, p1)) &&
+			(strings.HasSuffix(s1, s2) || strings.HasSuffix(s2, s1))
+	case dyn1:
+		p, s := getUserIsolationSegmentStaticParts(seg1)
+
+		return strings.HasPrefix(seg2, p) && strings.HasSuffix(seg2, s)
+	default:
+		p, s := getUserIsolationSegmentStaticParts(seg2)
+
+		return strings.HasPrefix(seg1, p) && strings.HasSuffix(seg1, s)
+	}
+}
+
+// getUserIsolationSegmentStaticParts returns static text before the first action and after the last one.
+func getUserIsolationSegmentStaticParts(seg string) (string, string) {
+	prefix, _, _ := strings.Cut(seg, "{{")
+	// Get last action end
+	idx := strings.LastIndex(seg, "}}")
+	// Check if action isn't closed
+	if idx < 0 {
+		return prefix, ""
+	}
+
+	return prefix, seg[idx+len("}}"):]
+}
+
+// validateUserIsolationQuota ensures that quotas are only declared on
+// targets with userIsolation enabled. Quotas are tracked per isolation
+// folder, so they have no meaning without it.
+func validateUserIsolationQuota(targetKey string, target *TargetConfig) error {
+	if target.Actions == nil || target.Actions.GET == nil ||
+		target.Actions.GET.Config == nil || target.Actions.GET.Config.UserIsolationQuota == nil {
+		return nil
+	}
+
+	// Check that user isolation is enabled
+	if !target.Actions.GET.Config.UserIsolation {
+		return errors.Errorf("target %s has userIsolationQuota declared but userIsolation is disabled", targetKey)
+	}
+
+	// Check reconcile interval
+	if target.Actions.GET.Config.UserIsolationQuota.ReconcileInterval <= 0 {
+		return errors.Errorf("target %s must have a positive userIsolationQuota reconcile interval", targetKey)
+	}
+
+	return nil
+}
+
+// validatePreActionHooks ensures that pre-action hooks rewrite path prefixes are folders.
+// A prefix without a trailing / would also allow sibling folders starting with the same name.
+func validatePreActionHooks(targetKey string, target *TargetConfig) error {
+	// Get hooks per action
+	hooksPerAction := map[string][]*PreActionHookConfig{}
+	// Check put action
+	if target.Actions.PUT != nil && target.Actions.PUT.Config != nil {
+		hooksPerAction[http.MethodPut] = target.Actions.PUT.Config.PreActionHooks
+	}
+	// Check delete action
+	if target.Actions.DELETE != nil && target.Actions.DELETE.Config != nil {
+		hooksPerAction[http.MethodDelete] = target.Actions.DELETE.Config.PreActionHooks
+	}
+
+	for _, action := range []string{http.MethodPut, http.MethodDelete} {
+		for i, hCfg := range hooksPerAction[action] {
+			for _, p := range hCfg.RewritePathPrefixes {
+				// Check format
+				if !strings.HasPrefix(p, "/") || !strings.HasSuffix(p, "/") {
+					return errors.Errorf(
+						"target %s %s pre-action hook %d rewrite path prefix %s must start and end with /",
+						targetKey, action, i, p,
+					)
+				}
+				// Check dot segments
+				segments := strings.Split(p, "/")
+				if slices.Contains(segments, ".") || slices.Contains(segments, "..") {
+					return errors.Errorf(
+						"target %s %s pre-action hook %d rewrite path prefix %s can't contain dot segments",
+						targetKey, action, i, p,
+					)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateAPIKeyAuthConfig ensures that an API key provider has keys with valid
+// hashes and unique identifiers.
+func validateAPIKeyAuthConfig(prov string, apiKeyCfg *APIKeyAuthConfig) error {
+	// Check key sources
+	if len(apiKeyCfg.Keys) == 0 && apiKeyCfg.KeysFile == "" {
+		return errors.Errorf("apiKey provider %s must have keys or a keys file", prov)
+	}
+
+	// Store ids
+	ids := map[string]bool{}
+
+	for _, k := range apiKeyCfg.GetAllKeys() {
+		// Check hash format
+		if !APIKeyHashRegexp.MatchString(k.Hash) {
+			return errors.Errorf("apiKey provider %s key %s must have a hash following the sha256:SALT:HEX_DIGEST format", prov, k.ID)
+		}
+
+		// Check unicity
+		if ids[k.ID] {
+			return errors.Errorf("apiKey provider %s has a duplicated key id %s", prov, k.ID)
+		}
+
+		ids[k.ID] = true
+	}
+
+	return nil
+}
+
+// validateOIDCSessionConfig ensures that an enabled OIDC session has a strong encryption key,
+// a positive maximum duration and a complete store configuration.
+func validateOIDCSessionConfig(prov string, sessionCfg *OIDCSessionConfig) error {
+	// Check if session is enabled
+	if sessionCfg == nil || !sessionCfg.Enabled {
+		return nil
+	}
+
+	// Check encryption key
+	if sessionCfg.EncryptionKey == nil || len(sessionCfg.EncryptionKey.Value) < OIDCSessionEncryptionKeyMinLength {
+		return errors.Errorf(
+			"provider %s session must have an encryption key with at least %d characters",
+			prov,
+			OIDCSessionEncryptionKeyMinLength,
+		)
+	}
+
+	// Check maximum duration
+	if sessionCfg.MaxDuration <= 0 {
+		return errors.Errorf("provider %s session must have a positive maximum duration", prov)
+	}
+
+	// Check redis store
+	if sessionCfg.Store != nil && sessionCfg.Store.Type == OIDCSessionStoreRedisType && sessionCfg.Store.Redis == nil {
+		return errors.Errorf("provider %s session must have a redis configuration with redis store", prov)
+	}
+
+	return nil
+}
+
+// validateLDAPAuthConfig ensures that a LDAP provider has a supported URL,
+// exactly one way to find user DN and valid durations.
+func validateLDAPAuthConfig(prov string, ldapCfg *LDAPAuthConfig) error {
+	// Parse url
+	u, err := url.Parse(ldapCfg.URL)
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Check scheme
+	if u.Scheme != "ldap" && u.Scheme != "ldaps" {
+		return errors.Errorf("ldap provider %s must have an url with ldap or ldaps scheme", prov)
+	}
+	// Check start tls
+	if ldapCfg.StartTLS && u.Scheme == "ldaps" {
+		return errors.Errorf("ldap provider %s can't use startTLS with ldaps scheme", prov)
+	}
+
+	// Check user dn sources
+	if ldapCfg.UserDNTemplate == "" && ldapCfg.UserSearch == nil {
+		return errors.Errorf("ldap provider %s must have a userDNTemplate or a userSearch", prov)
+	}
+
+	if ldapCfg.UserDNTemplate != "" && ldapCfg.UserSearch != nil {
+		return errors.Errorf("ldap provider %s can't have a userDNTemplate and a userSearch together", prov)
+	}
+
+	// Check placeholders
+	if ldapCfg.UserDNTemplate != "" && !strings.Contains(ldapCfg.UserDNTemplate, LDAPUsernamePlaceholder) {
+		return errors.Errorf("ldap provider %s must have %s in userDNTemplate", prov, LDAPUsernamePlaceholder)
+	}
+
+	if ldapCfg.UserSearch != nil && !strings.Contains(ldapCfg.UserSearch.Filter, LDAPUsernamePlaceholder) {
+		return errors.Errorf("ldap provider %s must have %s in userSearch filter", prov, LDAPUsernamePlaceholder)
+	}
+
+	// Check durations
+	if ldapCfg.Timeout <= 0 {
+		return errors.Errorf("ldap provider %s must have a positive timeout", prov)
+	}
+
+	if ldapCfg.CacheDuration < 0 {
+		return errors.Errorf("ldap provider %s can't have a negative cache duration", prov)
+	}
+
+	return nil
+}
+
+// validateIPFilterCountries ensures that ip filters using country rules have a GeoIP database.
+func validateIPFilterCountries(out *Config) error {
+	// Check if GeoIP database is configured
+	if out.GeoIP != nil {
+		return nil
+	}
+
+	for key, target := range out.Targets {
+		// Check target ip filter
+		if target.IPFilter != nil && target.IPFilter.HasCountryRules() {
+			return errors.Errorf("target %s ip filter must have a geoIP database configured to use countries", key)
+		}
+
+		for _, res := range target.Resources {
+			// Check resource ip filter
+			if res.IPFilter != nil && res.IPFilter.HasCountryRules() {
+				return errors.Errorf("resource %s in target %s ip filter must have a geoIP database configured to use countries", res.Path, key)
+			}
+		}
+	}
+
+	// Check list targets resource ip filter
+	if out.ListTargets != nil && out.ListTargets.Resource != nil && out.ListTargets.Resource.IPFilter != nil &&
+		out.ListTargets.Resource.IPFilter.HasCountryRules() {
+		return errors.New("list targets resource ip filter must have a geoIP database configured to use countries")
+	}
+
+	return nil
+}
+
+// validateImpersonationConfig ensures that enabled impersonation declares who can impersonate
+// and a secret to sign switch tokens and cookies.
+func validateImpersonationConfig(impCfg *ImpersonationConfig) error {
+	// Check if impersonation is enabled
+	if !impCfg.Enabled {
+		return nil
+	}
+
+	// Check that admins are declared
+	if len(impCfg.Admins) == 0 && len(impCfg.AdminGroups) == 0 {
+		return errors.New("impersonation must have at least one admin or admin group when enabled")
+	}
+
+	// Check cookie secret
+	if impCfg.CookieSecret == nil || len(impCfg.CookieSecret.Value) < ImpersonationCookieSecretMinLength {
+		return errors.Errorf(
+			"impersonation must have a cookie secret with at least %d characters when enabled",
+			ImpersonationCookieSecretMinLength,
+		)
+	}
+
+	return nil
+}
+
+// validateWebhookQueueConfig ensures that enabled webhook queue has a directory and valid backoff durations.
+func validateWebhookQueueConfig(qCfg *WebhookQueueConfig) error {
+	// Check if queue is enabled
+	if !qCfg.Enabled {
+		return nil
+	}
+
+	// Check directory
+	if qCfg.Directory == "" {
+		return errors.New("webhook queue must have a directory when enabled")
+	}
+
+	// Check backoff durations
+	if qCfg.InitialBackoff <= 0 || qCfg.MaxBackoff < qCfg.InitialBackoff {
+		return errors.New("webhook queue must have a positive initial backoff lower or equal to max backoff")
+	}
+
+	return nil
+}
+
+// validateAuditConfig ensures that enabled audit has sinks with their type configuration
+// and that s3 sinks use declared targets.
+func validateAuditConfig(auditCfg *AuditConfig, targets map[string]*TargetConfig) error {
+	// Check if audit is enabled
+	if !auditCfg.Enabled {
+		return nil
+	}
+
+	// Check that sinks are declared
+	if len(auditCfg.Sinks) == 0 {
+		return errors.New("audit must have at least one sink when enabled")
+	}
+
+	for i, sink := range auditCfg.Sinks {
+		switch sink.Type {
+		case AuditSinkTypeFile:
+			// Check file configuration
+			if sink.File == nil {
+				return errors.Errorf("audit sink %d must have a file configuration", i)
+			}
+		case AuditSinkTypeS3:
+			// Check s3 configuration
+			if sink.S3 == nil {
+				return errors.Errorf("audit sink %d must have a s3 configuration", i)
+			}
+			// Check flush interval
+			if sink.S3.FlushInterval <= 0 {
+				return errors.Errorf("audit sink %d must have a positive flush interval", i)
+			}
+			// Check target
+			if targets[sink.S3.Target] == nil {
+				return errors.Errorf("audit sink %d must use a declared target: %s not found", i, sink.S3.Target)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateBruteForceProtectionConfig ensures that lockout durations are positive and ordered.
+func validateBruteForceProtectionConfig(prov string, bfCfg *BruteForceProtectionConfig) error {
+	// Check if protection is enabled
+	if bfCfg == nil || !bfCfg.Enabled {
+		return nil
+	}
+
+	// Check max attempts
+	if *bfCfg.MaxAttemptsPerUser < 0 || *bfCfg.MaxAttemptsPerIP < 0 {
+		return errors.Errorf("basic provider %s must have positive or zero brute force max attempts", prov)
+	}
+
+	// Check durations
+	if bfCfg.AttemptsWindow <= 0 || bfCfg.LockoutDuration <= 0 {
+		return errors.Errorf("basic provider %s must have a positive brute force attempts window and lockout duration", prov)
+	}
+
+	// Check max lockout duration
+	if bfCfg.MaxLockoutDuration < bfCfg.LockoutDuration {
+		return errors.Errorf("basic provider %s must have a brute force max lockout duration greater than or equal to lockout duration", prov)
+	}
+
+	return nil
+}
+
+// validateHMACAuthConfig ensures that a HMAC provider has unique key identifiers
+// and a positive maximum clock skew.
+func validateHMACAuthConfig(prov string, hmacCfg *HMACAuthConfig) error {
+	// Check max clock skew
+	if hmacCfg.MaxClockSkew <= 0 {
+		return errors.Errorf("hmac provider %s must have a positive maximum clock skew", prov)
+	}
+
+	// Store ids
+	ids := map[string]bool{}
+
+	for _, k := range hmacCfg.Keys {
+		// Check unicity
+		if ids[k.ID] {
+			return errors.Errorf("hmac provider %s has a duplicated key id %s", prov, k.ID)
+		}
+
+		ids[k.ID] = true
+	}
+
+	return nil
+}
+
+// validateJWTAuthConfig ensures that a JWT provider has at least one key source,
+// only supported algorithms and a positive refresh interval.
+func validateJWTAuthConfig(prov string, jwtCfg *JWTAuthConfig) error {
+	// Check key sources
+	if jwtCfg.JWKSURL == "" && jwtCfg.JWKSFile == "" && len(jwtCfg.StaticKeys) == 0 {
+		return errors.Errorf("jwt provider %s must have at least one key source (jwksUrl, jwksFile or staticKeys)", prov)
+	}
+
+	// Check audiences
+	// Without audience, tokens issued for any client of the issuer would be accepted
+	if len(jwtCfg.Audiences) == 0 {
+		return errors.Errorf("jwt provider %s must have at least one audience", prov)
+	}
+
+	// Check algorithms
+	for _, alg := range jwtCfg.AllowedAlgorithms {
+		if !funk.ContainsString(supportedJWTAlgorithms, alg) {
+			return errors.Errorf("jwt provider %s has an unsupported algorithm %s", prov, alg)
+		}
+	}
+
+	// Check clock skew
+	if jwtCfg.ClockSkew < 0 {
+		return errors.Errorf("jwt provider %s can't have a negative clock skew", prov)
+	}
+
+	// Check refresh interval
+	if jwtCfg.JWKSRefreshInterval <= 0 {
+		return errors.Errorf("jwt provider %s must have a positive jwks refresh interval", prov)
+	}
 
 	return nil
 }
@@ -194,17 +992,80 @@ func validateResource(beginErrorMessage string, res *Resource, authProviders *Au
 	if len(filtered) > 0 {
 		return errors.New(beginErrorMessage + " must have a HTTP method in HEAD, GET, PUT or DELETE")
 	}
+	// Check path glob pattern
+	if !res.Regexp {
+		_, err := glob.Compile(res.Path, '/')
+		// Check error
+		if err != nil {
+			return errors.Wrapf(err, "%s must have a valid path glob pattern", beginErrorMessage)
+		}
+	}
+	// Check host glob pattern
+	if res.Host != "" {
+		_, err := glob.Compile(res.Host)
+		// Check error
+		if err != nil {
+			return errors.Wrapf(err, "%s must have a valid host glob pattern", beginErrorMessage)
+		}
+	}
+	// Check deny resource
+	if res.Deny {
+		// Check that deny resource doesn't declare authentication
+		if res.WhiteList != nil || res.Provider != "" || len(res.Providers) != 0 || res.Basic != nil || res.OIDC != nil || res.Header != nil ||
+			res.JWT != nil || res.APIKey != nil || res.MTLS != nil || res.LDAP != nil || res.HMAC != nil {
+			return errors.New(beginErrorMessage + " cannot have whitelist, provider or authentication configuration with deny")
+		}
+
+		return validateResourceMountPath(beginErrorMessage, res, mountPathList)
+	}
 	// Check resource not valid
-	if res.WhiteList == nil && res.Basic == nil && res.OIDC == nil && res.Header == nil {
-		return errors.New(beginErrorMessage + " must have whitelist, basic, header or oidc configuration")
+	if res.WhiteList == nil && res.Basic == nil && res.OIDC == nil && res.Header == nil && res.JWT == nil && res.APIKey == nil &&
+		res.MTLS == nil && res.LDAP == nil && res.HMAC == nil {
+		return errors.New(beginErrorMessage + " must have whitelist, basic, header, oidc, jwt, apiKey, mtls, ldap or hmac configuration")
+	}
+	// Check basic auth password hashes
+	if res.Basic != nil {
+		for _, cred := range res.Basic.Credentials {
+			// Check if password hash is declared
+			if cred.PasswordHash == nil {
+				continue
+			}
+			// Validate hash format
+			err := passwordhash.Validate(cred.PasswordHash.Value)
+			if err != nil {
+				return errors.Wrapf(err, "%s has an invalid password hash for user %s", beginErrorMessage, cred.User)
+			}
+		}
 	}
 	// Check if provider exists
-	if res.Provider == "" && (res.WhiteList == nil || (res.WhiteList != nil && !*res.WhiteList)) {
+	if res.Provider == "" && len(res.Providers) == 0 && (res.WhiteList == nil || (res.WhiteList != nil && !*res.WhiteList)) {
 		return errors.New(beginErrorMessage + " must have a provider")
 	}
+	// Check that provider and providers aren't used together
+	if res.Provider != "" && len(res.Providers) != 0 {
+		return errors.New(beginErrorMessage + " cannot have provider and providers at the same time")
+	}
 	// Check auth logins are provided in case of no whitelist
-	if res.WhiteList != nil && !*res.WhiteList && res.Basic == nil && res.OIDC == nil && res.Header == nil {
-		return errors.New(beginErrorMessage + " must have authentication configuration declared (oidc, header or basic)")
+	if res.WhiteList != nil && !*res.WhiteList && res.Basic == nil && res.OIDC == nil && res.Header == nil && res.JWT == nil &&
+		res.APIKey == nil && res.MTLS == nil && res.LDAP == nil && res.HMAC == nil {
+		return errors.New(beginErrorMessage + " must have authentication configuration declared (oidc, header, jwt, apiKey, mtls, ldap, hmac or basic)")
+	}
+	// Check that providers are declared in auth providers and linked to an authentication
+	if len(res.Providers) != 0 {
+		// Check if auth providers exists
+		if authProviders == nil {
+			return errors.New(beginErrorMessage + " has declared providers but authentication providers aren't declared")
+		}
+		// Loop over providers
+		for _, prov := range res.Providers {
+			// Check that provider is linked to an authentication configuration of the resource
+			if res.GetProviderResource(authProviders, prov) == nil {
+				return errors.Errorf(
+					"%s must have a valid provider declared in authentication providers with its authentication configuration: %s not allowed",
+					beginErrorMessage, prov,
+				)
+			}
+		}
 	}
 	// Check that provider is declared is auth providers and correctly linked
 	if res.Provider != "" {
@@ -215,7 +1076,12 @@ func validateResource(beginErrorMessage string, res *Resource, authProviders *Au
 		// Check that auth provider exists for target provider
 		exists := (authProviders.Basic != nil && authProviders.Basic[res.Provider] != nil) ||
 			(authProviders.OIDC != nil && authProviders.OIDC[res.Provider] != nil) ||
-			(authProviders.Header != nil && authProviders.Header[res.Provider] != nil)
+			(authProviders.Header != nil && authProviders.Header[res.Provider] != nil) ||
+			(authProviders.JWT != nil && authProviders.JWT[res.Provider] != nil) ||
+			(authProviders.APIKey != nil && authProviders.APIKey[res.Provider] != nil) ||
+			(authProviders.MTLS != nil && authProviders.MTLS[res.Provider] != nil) ||
+			(authProviders.LDAP != nil && authProviders.LDAP[res.Provider] != nil) ||
+			(authProviders.HMAC != nil && authProviders.HMAC[res.Provider] != nil)
 		if !exists {
 			return errors.New(beginErrorMessage + " must have a valid provider declared in authentication providers")
 		}
@@ -242,6 +1108,58 @@ func validateResource(beginErrorMessage string, res *Resource, authProviders *Au
 		if res.Header != nil && res.Header.AuthorizationOPAServer != nil && len(res.Header.AuthorizationAccesses) != 0 {
 			return errors.New(beginErrorMessage + " cannot contain header authorization accesses and OPA server together at the same time")
 		}
+		// Check jwt
+		if res.JWT != nil && authProviders.JWT[res.Provider] == nil {
+			return errors.New(beginErrorMessage + " must use a valid authentication configuration with selected authentication provider: jwt not allowed")
+		}
+		// Check that jwt authorization is valid
+		if res.JWT != nil && res.JWT.AuthorizationOPAServer != nil && len(res.JWT.AuthorizationAccesses) != 0 {
+			return errors.New(beginErrorMessage + " cannot contain jwt authorization accesses and OPA server together at the same time")
+		}
+		// Check api key
+		if res.APIKey != nil && authProviders.APIKey[res.Provider] == nil {
+			return errors.New(beginErrorMessage + " must use a valid authentication configuration with selected authentication provider: apiKey not allowed")
+		}
+		// Check that api key authorization is valid
+		if res.APIKey != nil && res.APIKey.AuthorizationOPAServer != nil && len(res.APIKey.AuthorizationAccesses) != 0 {
+			return errors.New(beginErrorMessage + " cannot contain apiKey authorization accesses and OPA server together at the same time")
+		}
+		// Check mtls
+		if res.MTLS != nil && authProviders.MTLS[res.Provider] == nil {
+			return errors.New(beginErrorMessage + " must use a valid authentication configuration with selected authentication provider: mtls not allowed")
+		}
+		// Check that mtls authorization is valid
+		if res.MTLS != nil && res.MTLS.AuthorizationOPAServer != nil && len(res.MTLS.AuthorizationAccesses) != 0 {
+			return errors.New(beginErrorMessage + " cannot contain mtls authorization accesses and OPA server together at the same time")
+		}
+		// Check ldap
+		if res.LDAP != nil && authProviders.LDAP[res.Provider] == nil {
+			return errors.New(beginErrorMessage + " must use a valid authentication configuration with selected authentication provider: ldap not allowed")
+		}
+		// Check that ldap authorization is valid
+		if res.LDAP != nil && res.LDAP.AuthorizationOPAServer != nil && len(res.LDAP.AuthorizationAccesses) != 0 {
+			return errors.New(beginErrorMessage + " cannot contain ldap authorization accesses and OPA server together at the same time")
+		}
+		// Check hmac
+		if res.HMAC != nil && authProviders.HMAC[res.Provider] == nil {
+			return errors.New(beginErrorMessage + " must use a valid authentication configuration with selected authentication provider: hmac not allowed")
+		}
+		// Check that hmac authorization is valid
+		if res.HMAC != nil && res.HMAC.AuthorizationOPAServer != nil && len(res.HMAC.AuthorizationAccesses) != 0 {
+			return errors.New(beginErrorMessage + " cannot contain hmac authorization accesses and OPA server together at the same time")
+		}
+	}
+
+	return validateResourceMountPath(beginErrorMessage, res, mountPathList)
+}
+
+// validateResourceMountPath ensures that resource path starts with a mount path.
+func validateResourceMountPath(beginErrorMessage string, res *Resource, mountPathList []string) error {
+	// Get path
+	p := res.Path
+	// Check if path is a regexp to remove start anchor
+	if res.Regexp {
+		p = strings.TrimPrefix(p, "^")
 	}
 	// Check if resource path contains mount path item
 	pathMatch := false
@@ -249,7 +1167,7 @@ func validateResource(beginErrorMessage string, res *Resource, authProviders *Au
 	for i := range len(mountPathList) {
 		mountPath := mountPathList[i]
 		// Check
-		if strings.HasPrefix(res.Path, mountPath) {
+		if strings.HasPrefix(p, mountPath) {
 			pathMatch = true
 			// Stop loop now
 			break
@@ -277,6 +1195,15 @@ func validatePath(beginErrorMessage, fpath string) error {
 	return nil
 }
 
+func validateClientIPHeader(svr *ServerConfig, name string) error {
+	// Check that client ip header is only read from trusted proxies
+	if svr != nil && svr.ClientIPHeader != "" && len(svr.TrustedProxies) == 0 {
+		return errors.Errorf("%s clientIPHeader requires trustedProxies", name)
+	}
+
+	return nil
+}
+
 func validateSSLConfig(serverSSL *ServerSSLConfig, section string) error {
 	if serverSSL.Enabled {
 		if len(serverSSL.Certificates) == 0 && len(serverSSL.SelfSignedHostnames) == 0 {
@@ -331,6 +1258,23 @@ func validateSSLConfig(serverSSL *ServerSSLConfig, section string) error {
 		}
 	}
 
+	// Check client CA
+	if serverSSL.ClientCA != nil {
+		if len(serverSSL.ClientCA.Certificates) == 0 {
+			return errors.Errorf("%s.ssl.clientCA.certificates must have values", section)
+		}
+
+		for i, cert := range serverSSL.ClientCA.Certificates {
+			err := validateSSLCertificateComponentConfig(
+				cert.Certificate, cert.CertificateURL, cert.CertificateURLConfig,
+				fmt.Sprintf("%s.ssl.clientCA.certificates[%d].certificate", section, i),
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -5,6 +5,7 @@ package config
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 func Test_validatePath(t *testing.T) {
@@ -54,7 +55,7 @@ func Test_validatePath(t *testing.T) {
 
 func Test_validateResource(t *testing.T) {
 	falseValue := false
-	// trueValue := true
+	trueValue := true
 	type args struct {
 		beginErrorMessage string
 		res               *Resource
@@ -105,7 +106,7 @@ func Test_validateResource(t *testing.T) {
 				mountPathList: []string{"/"},
 			},
 			wantErr:     true,
-			errorString: "begin error must have whitelist, basic, header or oidc configuration",
+			errorString: "begin error must have whitelist, basic, header, oidc, jwt, apiKey, mtls, ldap or hmac configuration",
 		},
 		{
 			name: "Resource don't have any whitelist, no provider is set, an authorization system is set and path",
@@ -149,7 +150,193 @@ func Test_validateResource(t *testing.T) {
 				mountPathList: []string{"/"},
 			},
 			wantErr:     true,
-			errorString: "begin error must have authentication configuration declared (oidc, header or basic)",
+			errorString: "begin error must have authentication configuration declared (oidc, header, jwt, apiKey, mtls, ldap, hmac or basic)",
+		},
+		{
+			name: "Resource with hmac configuration and another provider",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Path:     "/test/*",
+					Methods:  []string{"GET"},
+					Provider: "test",
+					HMAC:     &ResourceHeaderOIDC{},
+				},
+				authProviders: &AuthProviderConfig{
+					Basic: map[string]*BasicAuthConfig{"test": {}},
+				},
+				mountPathList: []string{"/"},
+			},
+			wantErr:     true,
+			errorString: "begin error must use a valid authentication configuration with selected authentication provider: hmac not allowed",
+		},
+		{
+			name: "Resource with hmac configuration",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Path:     "/test/*",
+					Methods:  []string{"GET"},
+					Provider: "test",
+					HMAC:     &ResourceHeaderOIDC{},
+				},
+				authProviders: &AuthProviderConfig{
+					HMAC: map[string]*HMACAuthConfig{"test": {}},
+				},
+				mountPathList: []string{"/"},
+			},
+		},
+		{
+			name: "Resource with invalid path glob pattern",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Path:      "/test/[a",
+					Methods:   []string{"GET"},
+					WhiteList: &trueValue,
+				},
+				mountPathList: []string{"/"},
+			},
+			wantErr:     true,
+			errorString: "begin error must have a valid path glob pattern: unexpected end of input",
+		},
+		{
+			name: "Resource with invalid host glob pattern",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Path:      "/test/*",
+					Host:      "[a",
+					Methods:   []string{"GET"},
+					WhiteList: &trueValue,
+				},
+				mountPathList: []string{"/"},
+			},
+			wantErr:     true,
+			errorString: "begin error must have a valid host glob pattern: unexpected end of input",
+		},
+		{
+			name: "Deny resource with authentication",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Path:     "/test/*",
+					Methods:  []string{"GET"},
+					Deny:     true,
+					Provider: "test",
+					Basic:    &ResourceBasic{},
+				},
+				mountPathList: []string{"/"},
+			},
+			wantErr:     true,
+			errorString: "begin error cannot have whitelist, provider or authentication configuration with deny",
+		},
+		{
+			name: "Deny resource outside mount path",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Path:    "/test/*",
+					Methods: []string{"GET"},
+					Deny:    true,
+				},
+				mountPathList: []string{"/mount/"},
+			},
+			wantErr:     true,
+			errorString: "begin error must start with path declared in mount path section",
+		},
+		{
+			name: "Valid deny resource",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Path:    "/mount/*",
+					Methods: []string{"GET"},
+					Deny:    true,
+				},
+				mountPathList: []string{"/mount/"},
+			},
+		},
+		{
+			name: "Valid regexp resource with start anchor",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Path:      "^/mount/.*\\.pdf$",
+					Regexp:    true,
+					Methods:   []string{"GET"},
+					WhiteList: &trueValue,
+				},
+				mountPathList: []string{"/mount/"},
+			},
+		},
+		{
+			name: "Resource declare provider and providers",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Methods:   []string{"GET"},
+					Provider:  "test",
+					Providers: []string{"test"},
+					Basic:     &ResourceBasic{},
+				},
+				authProviders: &AuthProviderConfig{},
+				mountPathList: []string{"/"},
+			},
+			wantErr:     true,
+			errorString: "begin error cannot have provider and providers at the same time",
+		},
+		{
+			name: "Resource declare providers but authorization providers are nil",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Methods:   []string{"GET"},
+					Providers: []string{"test"},
+					Basic:     &ResourceBasic{},
+				},
+				authProviders: nil,
+				mountPathList: []string{"/"},
+			},
+			wantErr:     true,
+			errorString: "begin error has declared providers but authentication providers aren't declared",
+		},
+		{
+			name: "Resource declare providers without matching authentication configuration",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Methods:   []string{"GET"},
+					Providers: []string{"basic1", "oidc1"},
+					Basic:     &ResourceBasic{},
+				},
+				authProviders: &AuthProviderConfig{
+					Basic: map[string]*BasicAuthConfig{"basic1": {Realm: "realm"}},
+					OIDC:  map[string]*OIDCAuthConfig{"oidc1": {}},
+				},
+				mountPathList: []string{"/"},
+			},
+			wantErr: true,
+			errorString: "begin error must have a valid provider declared in authentication providers " +
+				"with its authentication configuration: oidc1 not allowed",
+		},
+		{
+			name: "Resource declare valid providers",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Path:      "/*",
+					Methods:   []string{"GET"},
+					Providers: []string{"oidc1", "basic1"},
+					Basic:     &ResourceBasic{},
+					OIDC:      &ResourceHeaderOIDC{},
+				},
+				authProviders: &AuthProviderConfig{
+					Basic: map[string]*BasicAuthConfig{"basic1": {Realm: "realm"}},
+					OIDC:  map[string]*OIDCAuthConfig{"oidc1": {}},
+				},
+				mountPathList: []string{"/"},
+			},
 		},
 		{
 			name: "Resource declare a provider but authorization providers are nil",
@@ -220,104 +407,864 @@ func Test_validateResource(t *testing.T) {
 			errorString: "begin error must use a valid authentication configuration with selected authentication provider: basic auth not allowed",
 		},
 		{
-			name: "Resource use a not declared provider (OIDC auth case)",
-			args: args{
-				beginErrorMessage: "begin error",
-				res: &Resource{
-					Methods:   []string{"GET"},
-					WhiteList: &falseValue,
-					Provider:  "test",
-					OIDC:      &ResourceHeaderOIDC{},
-				},
-				authProviders: &AuthProviderConfig{
-					Basic: map[string]*BasicAuthConfig{
-						"test": {},
-					},
-				},
-				mountPathList: []string{"/"},
-			},
-			wantErr:     true,
-			errorString: "begin error must use a valid authentication configuration with selected authentication provider: oidc not allowed",
+			name: "Resource use a not declared provider (OIDC auth case)",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Methods:   []string{"GET"},
+					WhiteList: &falseValue,
+					Provider:  "test",
+					OIDC:      &ResourceHeaderOIDC{},
+				},
+				authProviders: &AuthProviderConfig{
+					Basic: map[string]*BasicAuthConfig{
+						"test": {},
+					},
+				},
+				mountPathList: []string{"/"},
+			},
+			wantErr:     true,
+			errorString: "begin error must use a valid authentication configuration with selected authentication provider: oidc not allowed",
+		},
+		{
+			name: "Resource with invalid oidc authorization methods",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Methods:   []string{"GET"},
+					WhiteList: &falseValue,
+					Provider:  "test",
+					OIDC: &ResourceHeaderOIDC{
+						AuthorizationAccesses: []*HeaderOIDCAuthorizationAccess{
+							{Email: "fake@fake.com"},
+						},
+						AuthorizationOPAServer: &OPAServerAuthorization{
+							URL: "http://fake.com",
+						},
+					},
+				},
+				authProviders: &AuthProviderConfig{
+					OIDC: map[string]*OIDCAuthConfig{
+						"test": {},
+					},
+				},
+				mountPathList: []string{"/"},
+			},
+			wantErr:     true,
+			errorString: "begin error cannot contain oidc authorization accesses and OPA server together at the same time",
+		},
+		{
+			name: "Resource have an invalid basic auth password hash",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Methods:  []string{"GET"},
+					Provider: "test",
+					Basic: &ResourceBasic{
+						Credentials: []*BasicAuthUserConfig{
+							{User: "user1", PasswordHash: &CredentialConfig{Value: "$apr1$salt$hash"}},
+						},
+					},
+					Path: "/",
+				},
+				authProviders: &AuthProviderConfig{
+					Basic: map[string]*BasicAuthConfig{
+						"test": {},
+					},
+				},
+				mountPathList: []string{"/"},
+			},
+			wantErr:     true,
+			errorString: "begin error has an invalid password hash for user user1: unsupported password hash format",
+		},
+		{
+			name: "Resource path must begin by mount path",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Methods:   []string{"GET"},
+					WhiteList: &falseValue,
+					Provider:  "test",
+					Basic:     &ResourceBasic{},
+					Path:      "/test/",
+				},
+				authProviders: &AuthProviderConfig{
+					Basic: map[string]*BasicAuthConfig{
+						"test": {},
+					},
+				},
+				mountPathList: []string{"/v1/"},
+			},
+			wantErr:     true,
+			errorString: "begin error must start with path declared in mount path section",
+		},
+		{
+			name: "Resource have jwt configuration with a non jwt provider",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Methods:  []string{"GET"},
+					Provider: "test",
+					JWT:      &ResourceHeaderOIDC{},
+					Path:     "/",
+				},
+				authProviders: &AuthProviderConfig{
+					Basic: map[string]*BasicAuthConfig{
+						"test": {},
+					},
+				},
+				mountPathList: []string{"/"},
+			},
+			wantErr:     true,
+			errorString: "begin error must use a valid authentication configuration with selected authentication provider: jwt not allowed",
+		},
+		{
+			name: "Resource have jwt authorization accesses and opa server",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Methods:  []string{"GET"},
+					Provider: "test",
+					JWT: &ResourceHeaderOIDC{
+						AuthorizationAccesses:  []*HeaderOIDCAuthorizationAccess{{Group: "group"}},
+						AuthorizationOPAServer: &OPAServerAuthorization{URL: "http://opa"},
+					},
+					Path: "/",
+				},
+				authProviders: &AuthProviderConfig{
+					JWT: map[string]*JWTAuthConfig{
+						"test": {},
+					},
+				},
+				mountPathList: []string{"/"},
+			},
+			wantErr:     true,
+			errorString: "begin error cannot contain jwt authorization accesses and OPA server together at the same time",
+		},
+		{
+			name: "Resource with jwt is valid",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Methods:  []string{"GET"},
+					Provider: "test",
+					JWT: &ResourceHeaderOIDC{
+						AuthorizationAccesses: []*HeaderOIDCAuthorizationAccess{{Group: "group"}},
+					},
+					Path: "/",
+				},
+				authProviders: &AuthProviderConfig{
+					JWT: map[string]*JWTAuthConfig{
+						"test": {},
+					},
+				},
+				mountPathList: []string{"/"},
+			},
+		},
+		{
+			name: "Resource is valid",
+			args: args{
+				beginErrorMessage: "begin error",
+				res: &Resource{
+					Methods:   []string{"GET"},
+					WhiteList: &falseValue,
+					Provider:  "test",
+					Basic:     &ResourceBasic{},
+					Path:      "/v1/test/",
+				},
+				authProviders: &AuthProviderConfig{
+					Basic: map[string]*BasicAuthConfig{
+						"test": {},
+					},
+				},
+				mountPathList: []string{"/v1/"},
+			},
+			wantErr:     false,
+			errorString: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateResource(tt.args.beginErrorMessage, tt.args.res, tt.args.authProviders, tt.args.mountPathList)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateResource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && err.Error() != tt.errorString {
+				t.Errorf("validateResource() error = %v, wantErr %v", err.Error(), tt.errorString)
+			}
+		})
+	}
+}
+
+func Test_validateAPIKeyAuthConfig(t *testing.T) {
+	validHash := "sha256:salt:0000000000000000000000000000000000000000000000000000000000000000"
+
+	tests := []struct {
+		cfg     *APIKeyAuthConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name:    "No keys",
+			cfg:     &APIKeyAuthConfig{},
+			wantErr: "apiKey provider p1 must have keys or a keys file",
+		},
+		{
+			name: "Invalid hash",
+			cfg: &APIKeyAuthConfig{
+				Keys: []*APIKeyConfig{{ID: "k1", Hash: "plain-key"}},
+			},
+			wantErr: "apiKey provider p1 key k1 must have a hash following the sha256:SALT:HEX_DIGEST format",
+		},
+		{
+			name: "Duplicated id between configuration and file",
+			cfg: &APIKeyAuthConfig{
+				KeysFile: "keys.yaml",
+				Keys:     []*APIKeyConfig{{ID: "k1", Hash: validHash}},
+				FileKeys: []*APIKeyConfig{{ID: "k1", Hash: validHash}},
+			},
+			wantErr: "apiKey provider p1 has a duplicated key id k1",
+		},
+		{
+			name: "Valid",
+			cfg: &APIKeyAuthConfig{
+				Keys: []*APIKeyConfig{{ID: "k1", Hash: validHash}, {ID: "k2", Hash: validHash}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAPIKeyAuthConfig("p1", tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateAPIKeyAuthConfig() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateAPIKeyAuthConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateJWTAuthConfig(t *testing.T) {
+	tests := []struct {
+		cfg     *JWTAuthConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name:    "No key source",
+			cfg:     &JWTAuthConfig{JWKSRefreshInterval: time.Hour},
+			wantErr: "jwt provider p1 must have at least one key source (jwksUrl, jwksFile or staticKeys)",
+		},
+		{
+			name:    "No audience",
+			cfg:     &JWTAuthConfig{JWKSFile: "jwks.json", JWKSRefreshInterval: time.Hour},
+			wantErr: "jwt provider p1 must have at least one audience",
+		},
+		{
+			name: "Unsupported algorithm",
+			cfg: &JWTAuthConfig{
+				JWKSURL:             "http://localhost/jwks",
+				Audiences:           []string{"s3-proxy"},
+				AllowedAlgorithms:   []string{"RS256", "none"},
+				JWKSRefreshInterval: time.Hour,
+			},
+			wantErr: "jwt provider p1 has an unsupported algorithm none",
+		},
+		{
+			name: "Negative clock skew",
+			cfg: &JWTAuthConfig{
+				JWKSFile:            "jwks.json",
+				Audiences:           []string{"s3-proxy"},
+				ClockSkew:           -time.Second,
+				JWKSRefreshInterval: time.Hour,
+			},
+			wantErr: "jwt provider p1 can't have a negative clock skew",
+		},
+		{
+			name:    "No refresh interval",
+			cfg:     &JWTAuthConfig{JWKSFile: "jwks.json", Audiences: []string{"s3-proxy"}},
+			wantErr: "jwt provider p1 must have a positive jwks refresh interval",
+		},
+		{
+			name: "Valid",
+			cfg: &JWTAuthConfig{
+				StaticKeys:          []*JWTStaticKeyConfig{{Key: &CredentialConfig{Value: "secret"}}},
+				Audiences:           []string{"s3-proxy"},
+				AllowedAlgorithms:   []string{"HS256"},
+				JWKSRefreshInterval: time.Hour,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateJWTAuthConfig("p1", tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateJWTAuthConfig() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateJWTAuthConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateLDAPAuthConfig(t *testing.T) {
+	userSearch := &LDAPUserSearchConfig{BaseDN: "dc=example,dc=com", Filter: "(uid={username})"}
+
+	tests := []struct {
+		cfg     *LDAPAuthConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name:    "Unsupported scheme",
+			cfg:     &LDAPAuthConfig{URL: "http://ldap.example.com", UserSearch: userSearch, Timeout: time.Second},
+			wantErr: "ldap provider p1 must have an url with ldap or ldaps scheme",
+		},
+		{
+			name:    "StartTLS with ldaps",
+			cfg:     &LDAPAuthConfig{URL: "ldaps://ldap.example.com", StartTLS: true, UserSearch: userSearch, Timeout: time.Second},
+			wantErr: "ldap provider p1 can't use startTLS with ldaps scheme",
+		},
+		{
+			name:    "No user dn source",
+			cfg:     &LDAPAuthConfig{URL: "ldap://ldap.example.com", Timeout: time.Second},
+			wantErr: "ldap provider p1 must have a userDNTemplate or a userSearch",
+		},
+		{
+			name: "Both user dn sources",
+			cfg: &LDAPAuthConfig{
+				URL:            "ldap://ldap.example.com",
+				UserDNTemplate: "uid={username},dc=example,dc=com",
+				UserSearch:     userSearch,
+				Timeout:        time.Second,
+			},
+			wantErr: "ldap provider p1 can't have a userDNTemplate and a userSearch together",
+		},
+		{
+			name:    "User dn template without placeholder",
+			cfg:     &LDAPAuthConfig{URL: "ldap://ldap.example.com", UserDNTemplate: "uid=user,dc=example,dc=com", Timeout: time.Second},
+			wantErr: "ldap provider p1 must have {username} in userDNTemplate",
+		},
+		{
+			name: "User search filter without placeholder",
+			cfg: &LDAPAuthConfig{
+				URL:        "ldap://ldap.example.com",
+				UserSearch: &LDAPUserSearchConfig{BaseDN: "dc=example,dc=com", Filter: "(uid=user)"},
+				Timeout:    time.Second,
+			},
+			wantErr: "ldap provider p1 must have {username} in userSearch filter",
+		},
+		{
+			name:    "No timeout",
+			cfg:     &LDAPAuthConfig{URL: "ldap://ldap.example.com", UserSearch: userSearch},
+			wantErr: "ldap provider p1 must have a positive timeout",
+		},
+		{
+			name:    "Negative cache duration",
+			cfg:     &LDAPAuthConfig{URL: "ldap://ldap.example.com", UserSearch: userSearch, Timeout: time.Second, CacheDuration: -time.Second},
+			wantErr: "ldap provider p1 can't have a negative cache duration",
+		},
+		{
+			name: "Valid",
+			cfg: &LDAPAuthConfig{
+				URL:            "ldap://ldap.example.com",
+				StartTLS:       true,
+				UserDNTemplate: "{username}@example.com",
+				Timeout:        time.Second,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateLDAPAuthConfig("p1", tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateLDAPAuthConfig() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateLDAPAuthConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateHMACAuthConfig(t *testing.T) {
+	secret := &CredentialConfig{Value: "secret"}
+
+	tests := []struct {
+		cfg     *HMACAuthConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name:    "No max clock skew",
+			cfg:     &HMACAuthConfig{Keys: []*HMACKeyConfig{{ID: "k1", Secret: secret}}},
+			wantErr: "hmac provider p1 must have a positive maximum clock skew",
+		},
+		{
+			name: "Duplicated id",
+			cfg: &HMACAuthConfig{
+				Keys:         []*HMACKeyConfig{{ID: "k1", Secret: secret}, {ID: "k1", Secret: secret}},
+				MaxClockSkew: time.Minute,
+			},
+			wantErr: "hmac provider p1 has a duplicated key id k1",
+		},
+		{
+			name: "Valid",
+			cfg: &HMACAuthConfig{
+				Keys:         []*HMACKeyConfig{{ID: "k1", Secret: secret}, {ID: "k2", Secret: secret}},
+				MaxClockSkew: time.Minute,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateHMACAuthConfig("p1", tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateHMACAuthConfig() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateHMACAuthConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateBruteForceProtectionConfig(t *testing.T) {
+	five := 5
+	negative := -1
+
+	tests := []struct {
+		cfg     *BruteForceProtectionConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name: "Nil",
+		},
+		{
+			name: "Disabled",
+			cfg:  &BruteForceProtectionConfig{},
+		},
+		{
+			name: "Negative max attempts",
+			cfg: &BruteForceProtectionConfig{
+				Enabled:            true,
+				MaxAttemptsPerUser: &negative,
+				MaxAttemptsPerIP:   &five,
+				AttemptsWindow:     time.Minute,
+				LockoutDuration:    time.Minute,
+				MaxLockoutDuration: time.Hour,
+			},
+			wantErr: "basic provider p1 must have positive or zero brute force max attempts",
+		},
+		{
+			name: "No lockout duration",
+			cfg: &BruteForceProtectionConfig{
+				Enabled:            true,
+				MaxAttemptsPerUser: &five,
+				MaxAttemptsPerIP:   &five,
+				AttemptsWindow:     time.Minute,
+				MaxLockoutDuration: time.Hour,
+			},
+			wantErr: "basic provider p1 must have a positive brute force attempts window and lockout duration",
+		},
+		{
+			name: "Max lockout duration lower than lockout duration",
+			cfg: &BruteForceProtectionConfig{
+				Enabled:            true,
+				MaxAttemptsPerUser: &five,
+				MaxAttemptsPerIP:   &five,
+				AttemptsWindow:     time.Minute,
+				LockoutDuration:    time.Hour,
+				MaxLockoutDuration: time.Minute,
+			},
+			wantErr: "basic provider p1 must have a brute force max lockout duration greater than or equal to lockout duration",
+		},
+		{
+			name: "Valid",
+			cfg: &BruteForceProtectionConfig{
+				Enabled:            true,
+				MaxAttemptsPerUser: &five,
+				MaxAttemptsPerIP:   &five,
+				AttemptsWindow:     time.Minute,
+				LockoutDuration:    time.Minute,
+				MaxLockoutDuration: time.Hour,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBruteForceProtectionConfig("p1", tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateBruteForceProtectionConfig() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateBruteForceProtectionConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateIPFilterCountries(t *testing.T) {
+	countryFilter := &IPFilterConfig{AllowCountries: []string{"FR"}}
+	ipFilter := &IPFilterConfig{Allow: []string{"10.0.0.0/8"}}
+
+	tests := []struct {
+		cfg     *Config
+		name    string
+		wantErr string
+	}{
+		{
+			name: "No ip filter",
+			cfg:  &Config{Targets: map[string]*TargetConfig{"target1": {}}},
+		},
+		{
+			name: "Ip filters without countries",
+			cfg: &Config{
+				Targets: map[string]*TargetConfig{"target1": {
+					IPFilter:  ipFilter,
+					Resources: []*Resource{{Path: "/*", IPFilter: ipFilter}},
+				}},
+				ListTargets: &ListTargetsConfig{Resource: &Resource{IPFilter: ipFilter}},
+			},
+		},
+		{
+			name: "Target countries without geoIP",
+			cfg: &Config{
+				Targets: map[string]*TargetConfig{"target1": {IPFilter: countryFilter}},
+			},
+			wantErr: "target target1 ip filter must have a geoIP database configured to use countries",
+		},
+		{
+			name: "Resource countries without geoIP",
+			cfg: &Config{
+				Targets: map[string]*TargetConfig{"target1": {
+					Resources: []*Resource{{Path: "/*", IPFilter: &IPFilterConfig{DenyCountries: []string{"FR"}}}},
+				}},
+			},
+			wantErr: "resource /* in target target1 ip filter must have a geoIP database configured to use countries",
+		},
+		{
+			name: "List targets resource countries without geoIP",
+			cfg: &Config{
+				ListTargets: &ListTargetsConfig{Resource: &Resource{IPFilter: countryFilter}},
+			},
+			wantErr: "list targets resource ip filter must have a geoIP database configured to use countries",
+		},
+		{
+			name: "Countries with geoIP",
+			cfg: &Config{
+				GeoIP: &GeoIPConfig{DatabasePath: "/tmp/country.mmdb"},
+				Targets: map[string]*TargetConfig{"target1": {
+					IPFilter:  countryFilter,
+					Resources: []*Resource{{Path: "/*", IPFilter: countryFilter}},
+				}},
+				ListTargets: &ListTargetsConfig{Resource: &Resource{IPFilter: countryFilter}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateIPFilterCountries(tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateIPFilterCountries() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateIPFilterCountries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateAuditConfig(t *testing.T) {
+	targets := map[string]*TargetConfig{"target1": {Name: "target1"}}
+
+	tests := []struct {
+		cfg     *AuditConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name: "Disabled",
+			cfg:  &AuditConfig{},
+		},
+		{
+			name:    "No sink",
+			cfg:     &AuditConfig{Enabled: true},
+			wantErr: "audit must have at least one sink when enabled",
+		},
+		{
+			name: "File sink without configuration",
+			cfg: &AuditConfig{
+				Enabled: true,
+				Sinks:   []*AuditSinkConfig{{Type: AuditSinkTypeStdout}, {Type: AuditSinkTypeFile}},
+			},
+			wantErr: "audit sink 1 must have a file configuration",
+		},
+		{
+			name: "S3 sink without configuration",
+			cfg: &AuditConfig{
+				Enabled: true,
+				Sinks:   []*AuditSinkConfig{{Type: AuditSinkTypeS3}},
+			},
+			wantErr: "audit sink 0 must have a s3 configuration",
+		},
+		{
+			name: "S3 sink with negative flush interval",
+			cfg: &AuditConfig{
+				Enabled: true,
+				Sinks: []*AuditSinkConfig{{
+					Type: AuditSinkTypeS3,
+					S3:   &AuditS3SinkConfig{Target: "target1", FlushInterval: -time.Second},
+				}},
+			},
+			wantErr: "audit sink 0 must have a positive flush interval",
+		},
+		{
+			name: "S3 sink with unknown target",
+			cfg: &AuditConfig{
+				Enabled: true,
+				Sinks: []*AuditSinkConfig{{
+					Type: AuditSinkTypeS3,
+					S3:   &AuditS3SinkConfig{Target: "target2", FlushInterval: time.Minute},
+				}},
+			},
+			wantErr: "audit sink 0 must use a declared target: target2 not found",
+		},
+		{
+			name: "Valid",
+			cfg: &AuditConfig{
+				Enabled: true,
+				Sinks: []*AuditSinkConfig{
+					{Type: AuditSinkTypeStdout},
+					{Type: AuditSinkTypeFile, File: &AuditFileSinkConfig{Path: "/tmp/audit.log"}},
+					{Type: AuditSinkTypeS3, S3: &AuditS3SinkConfig{Target: "target1", FlushInterval: time.Minute}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAuditConfig(tt.cfg, targets)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateAuditConfig() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateAuditConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateOIDCSessionConfig(t *testing.T) {
+	key := &CredentialConfig{Value: "0123456789abcdef0123456789abcdef"}
+
+	tests := []struct {
+		cfg     *OIDCSessionConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name: "No session",
+		},
+		{
+			name: "Disabled session",
+			cfg:  &OIDCSessionConfig{},
+		},
+		{
+			name:    "No encryption key",
+			cfg:     &OIDCSessionConfig{Enabled: true, MaxDuration: time.Hour},
+			wantErr: "provider p1 session must have an encryption key with at least 32 characters",
+		},
+		{
+			name: "Short encryption key",
+			cfg: &OIDCSessionConfig{
+				Enabled:       true,
+				EncryptionKey: &CredentialConfig{Value: "short"},
+				MaxDuration:   time.Hour,
+			},
+			wantErr: "provider p1 session must have an encryption key with at least 32 characters",
+		},
+		{
+			name:    "No maximum duration",
+			cfg:     &OIDCSessionConfig{Enabled: true, EncryptionKey: key},
+			wantErr: "provider p1 session must have a positive maximum duration",
+		},
+		{
+			name: "Redis store without redis configuration",
+			cfg: &OIDCSessionConfig{
+				Enabled:       true,
+				EncryptionKey: key,
+				MaxDuration:   time.Hour,
+				Store:         &OIDCSessionStoreConfig{Type: OIDCSessionStoreRedisType},
+			},
+			wantErr: "provider p1 session must have a redis configuration with redis store",
+		},
+		{
+			name: "Valid",
+			cfg: &OIDCSessionConfig{
+				Enabled:       true,
+				EncryptionKey: key,
+				MaxDuration:   time.Hour,
+				Store: &OIDCSessionStoreConfig{
+					Type:  OIDCSessionStoreRedisType,
+					Redis: &OIDCSessionRedisConfig{Address: "localhost:6379"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOIDCSessionConfig("p1", tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateOIDCSessionConfig() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateOIDCSessionConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateRegoAuthorization(t *testing.T) {
+	policies := map[string]*RegoPolicyConfig{"p1": {Query: "data.s3proxy.allowed", Files: []string{"policy.rego"}}}
+
+	tests := []struct {
+		res     *Resource
+		name    string
+		wantErr string
+	}{
+		{
+			name: "No rego authorization",
+			res:  &Resource{OIDC: &ResourceHeaderOIDC{AuthorizationOPAServer: &OPAServerAuthorization{URL: "http://opa"}}},
+		},
+		{
+			name: "Valid",
+			res:  &Resource{OIDC: &ResourceHeaderOIDC{AuthorizationRego: &RegoAuthorization{Policy: "p1"}}},
+		},
+		{
+			name:    "Unknown policy",
+			res:     &Resource{JWT: &ResourceHeaderOIDC{AuthorizationRego: &RegoAuthorization{Policy: "p2"}}},
+			wantErr: "resource 0 must use an existing rego policy: p2 not found",
 		},
 		{
-			name: "Resource with invalid oidc authorization methods",
-			args: args{
-				beginErrorMessage: "begin error",
-				res: &Resource{
-					Methods:   []string{"GET"},
-					WhiteList: &falseValue,
-					Provider:  "test",
-					OIDC: &ResourceHeaderOIDC{
-						AuthorizationAccesses: []*HeaderOIDCAuthorizationAccess{
-							{Email: "fake@fake.com"},
-						},
-						AuthorizationOPAServer: &OPAServerAuthorization{
-							URL: "http://fake.com",
-						},
-					},
-				},
-				authProviders: &AuthProviderConfig{
-					OIDC: map[string]*OIDCAuthConfig{
-						"test": {},
-					},
-				},
-				mountPathList: []string{"/"},
-			},
-			wantErr:     true,
-			errorString: "begin error cannot contain oidc authorization accesses and OPA server together at the same time",
+			name: "Rego and OPA server",
+			res: &Resource{Header: &ResourceHeaderOIDC{
+				AuthorizationRego:      &RegoAuthorization{Policy: "p1"},
+				AuthorizationOPAServer: &OPAServerAuthorization{URL: "http://opa"},
+			}},
+			wantErr: "resource 0 cannot contain rego authorization with authorization accesses or OPA server at the same time",
 		},
 		{
-			name: "Resource path must begin by mount path",
-			args: args{
-				beginErrorMessage: "begin error",
-				res: &Resource{
-					Methods:   []string{"GET"},
-					WhiteList: &falseValue,
-					Provider:  "test",
-					Basic:     &ResourceBasic{},
-					Path:      "/test/",
-				},
-				authProviders: &AuthProviderConfig{
-					Basic: map[string]*BasicAuthConfig{
-						"test": {},
-					},
-				},
-				mountPathList: []string{"/v1/"},
-			},
-			wantErr:     true,
-			errorString: "begin error must start with path declared in mount path section",
+			name: "Rego and authorization accesses",
+			res: &Resource{LDAP: &ResourceHeaderOIDC{
+				AuthorizationRego:     &RegoAuthorization{Policy: "p1"},
+				AuthorizationAccesses: []*HeaderOIDCAuthorizationAccess{{Group: "group1"}},
+			}},
+			wantErr: "resource 0 cannot contain rego authorization with authorization accesses or OPA server at the same time",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRegoAuthorization("resource 0", tt.res, policies)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateRegoAuthorization() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateRegoAuthorization() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateAuthorizationDecisionCache(t *testing.T) {
+	tests := []struct {
+		res     *Resource
+		name    string
+		wantErr string
+	}{
+		{
+			name: "No policy authorization",
+			res:  &Resource{OIDC: &ResourceHeaderOIDC{}},
 		},
 		{
-			name: "Resource is valid",
-			args: args{
-				beginErrorMessage: "begin error",
-				res: &Resource{
-					Methods:   []string{"GET"},
-					WhiteList: &falseValue,
-					Provider:  "test",
-					Basic:     &ResourceBasic{},
-					Path:      "/v1/test/",
-				},
-				authProviders: &AuthProviderConfig{
-					Basic: map[string]*BasicAuthConfig{
-						"test": {},
-					},
-				},
-				mountPathList: []string{"/v1/"},
+			name: "Valid",
+			res: &Resource{
+				OIDC: &ResourceHeaderOIDC{AuthorizationRego: &RegoAuthorization{Policy: "p1", DecisionCacheDuration: time.Minute}},
 			},
-			wantErr:     false,
-			errorString: "",
+		},
+		{
+			name:    "Negative OPA server duration",
+			res:     &Resource{JWT: &ResourceHeaderOIDC{AuthorizationOPAServer: &OPAServerAuthorization{DecisionCacheDuration: -time.Minute}}},
+			wantErr: "resource 0 must have a positive OPA server decision cache duration",
+		},
+		{
+			name:    "Negative rego duration",
+			res:     &Resource{Header: &ResourceHeaderOIDC{AuthorizationRego: &RegoAuthorization{DecisionCacheDuration: -time.Minute}}},
+			wantErr: "resource 0 must have a positive rego decision cache duration",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateResource(tt.args.beginErrorMessage, tt.args.res, tt.args.authProviders, tt.args.mountPathList)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("validateResource() error = %v, wantErr %v", err, tt.wantErr)
+			err := validateAuthorizationDecisionCache("resource 0", tt.res)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateAuthorizationDecisionCache() unexpected error = %v", err)
+				}
+
+				return
 			}
 
-			if err != nil && err.Error() != tt.errorString {
-				t.Errorf("validateResource() error = %v, wantErr %v", err.Error(), tt.errorString)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateAuthorizationDecisionCache() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
@@ -387,7 +1334,7 @@ func Test_validateBusinessConfig(t *testing.T) {
 				},
 			},
 			wantErr:     true,
-			errorString: "resource 0 from target test1 must have whitelist, basic, header or oidc configuration",
+			errorString: "resource 0 from target test1 must have whitelist, basic, header, oidc, jwt, apiKey, mtls, ldap or hmac configuration",
 		},
 		{
 			name: "No actions are present in target",
@@ -470,7 +1417,7 @@ func Test_validateBusinessConfig(t *testing.T) {
 			},
 			wantErr: true,
 			errorString: "target test1 has userIsolation enabled but no resource with authentication " +
-				"(basic, oidc or header) is declared; isolation requires an authenticated user",
+				"(basic, oidc, header, jwt, apiKey, mtls, ldap or hmac) is declared; isolation requires an authenticated user",
 		},
 		{
 			name: "userIsolation enabled with basic auth resource is accepted",
@@ -599,7 +1546,7 @@ func Test_validateBusinessConfig(t *testing.T) {
 				},
 			},
 			wantErr:     true,
-			errorString: "resource from list targets must have whitelist, basic, header or oidc configuration",
+			errorString: "resource from list targets must have whitelist, basic, header, oidc, jwt, apiKey, mtls, ldap or hmac configuration",
 		},
 		{
 			name: "List targets path is invalid",
@@ -800,6 +1747,135 @@ func Test_validateBusinessConfig(t *testing.T) {
 			wantErr:     true,
 			errorString: "provider provider1 can't have same login and callback path (to avoid redirect loop)",
 		},
+		{
+			name: "OIDC provider without login state secret",
+			args: args{
+				out: &Config{
+					AuthProviders: &AuthProviderConfig{
+						OIDC: map[string]*OIDCAuthConfig{
+							"provider1": {
+								LoginPath:    "/auth/login",
+								CallbackPath: "/auth/callback",
+							},
+						},
+					},
+					Targets: map[string]*TargetConfig{
+						"test1": {
+							Name: "test1",
+							Bucket: &BucketConfig{
+								Name:   "bucket1",
+								Region: "region1",
+							},
+							Mount: &MountConfig{
+								Path: []string{"/mount1/"},
+							},
+							Resources: nil,
+							Actions: &ActionsConfig{
+								GET:    &GetActionConfig{Enabled: true},
+								PUT:    &PutActionConfig{Enabled: false},
+								DELETE: &DeleteActionConfig{Enabled: false},
+							},
+						},
+					},
+					ListTargets: &ListTargetsConfig{
+						Enabled: true,
+						Mount: &MountConfig{
+							Path: []string{"/"},
+						},
+						Resource: nil,
+					},
+				},
+			},
+			wantErr:     true,
+			errorString: "provider provider1 must have a login state secret with at least 32 characters",
+		},
+		{
+			name: "OIDC provider with too short login state secret",
+			args: args{
+				out: &Config{
+					AuthProviders: &AuthProviderConfig{
+						OIDC: map[string]*OIDCAuthConfig{
+							"provider1": {
+								LoginPath:        "/auth/login",
+								CallbackPath:     "/auth/callback",
+								LoginStateSecret: &CredentialConfig{Value: "short"},
+							},
+						},
+					},
+					Targets: map[string]*TargetConfig{
+						"test1": {
+							Name: "test1",
+							Bucket: &BucketConfig{
+								Name:   "bucket1",
+								Region: "region1",
+							},
+							Mount: &MountConfig{
+								Path: []string{"/mount1/"},
+							},
+							Resources: nil,
+							Actions: &ActionsConfig{
+								GET:    &GetActionConfig{Enabled: true},
+								PUT:    &PutActionConfig{Enabled: false},
+								DELETE: &DeleteActionConfig{Enabled: false},
+							},
+						},
+					},
+					ListTargets: &ListTargetsConfig{
+						Enabled: true,
+						Mount: &MountConfig{
+							Path: []string{"/"},
+						},
+						Resource: nil,
+					},
+				},
+			},
+			wantErr:     true,
+			errorString: "provider provider1 must have a login state secret with at least 32 characters",
+		},
+		{
+			name: "OIDC provider with login state secret",
+			args: args{
+				out: &Config{
+					AuthProviders: &AuthProviderConfig{
+						OIDC: map[string]*OIDCAuthConfig{
+							"provider1": {
+								State:            "state",
+								LoginPath:        "/auth/login",
+								CallbackPath:     "/auth/callback",
+								LoginStateSecret: &CredentialConfig{Value: "01234567890123456789012345678901"},
+							},
+						},
+					},
+					Targets: map[string]*TargetConfig{
+						"test1": {
+							Name: "test1",
+							Bucket: &BucketConfig{
+								Name:   "bucket1",
+								Region: "region1",
+							},
+							Mount: &MountConfig{
+								Path: []string{"/mount1/"},
+							},
+							Resources: nil,
+							Actions: &ActionsConfig{
+								GET:    &GetActionConfig{Enabled: true},
+								PUT:    &PutActionConfig{Enabled: false},
+								DELETE: &DeleteActionConfig{Enabled: false},
+							},
+						},
+					},
+					ListTargets: &ListTargetsConfig{
+						Enabled: true,
+						Mount: &MountConfig{
+							Path: []string{"/"},
+						},
+						Resource: nil,
+					},
+				},
+			},
+			wantErr:     false,
+			errorString: "",
+		},
 		{
 			name: "Configuration with list target and target is valid",
 			args: args{
@@ -831,18 +1907,102 @@ func Test_validateBusinessConfig(t *testing.T) {
 					},
 				},
 			},
-			wantErr:     false,
-			errorString: "",
+			wantErr:     false,
+			errorString: "",
+		},
+		{
+			name: "Rego policy without source",
+			args: args{
+				out: &Config{
+					RegoPolicies: map[string]*RegoPolicyConfig{
+						"policy1": {Query: "data.s3proxy.allowed"},
+					},
+				},
+			},
+			wantErr:     true,
+			errorString: "rego policy policy1 must have files or a bucket",
+		},
+		{
+			name: "Rego policy with unknown bucket target",
+			args: args{
+				out: &Config{
+					RegoPolicies: map[string]*RegoPolicyConfig{
+						"policy1": {
+							Query:  "data.s3proxy.allowed",
+							Bucket: &RegoPolicyBucketConfig{Target: "unknown"},
+						},
+					},
+				},
+			},
+			wantErr:     true,
+			errorString: "rego policy policy1 must use an existing target for bucket: unknown not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBusinessConfig(tt.args.out)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateBusinessConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && err.Error() != tt.errorString {
+				t.Errorf("validateBusinessConfig() error = %v, wantErr %v", err.Error(), tt.errorString)
+			}
+		})
+	}
+}
+
+func Test_validateClientIPHeader(t *testing.T) {
+	tests := []struct {
+		serverConfig         *ServerConfig
+		internalServerConfig *ServerConfig
+		name                 string
+		errorString          string
+		wantErr              bool
+	}{
+		{
+			name: "Valid server config with client ip header and trusted proxies",
+			serverConfig: &ServerConfig{
+				TrustedProxies: []string{"10.0.0.0/8"},
+				ClientIPHeader: "X-Real-IP",
+			},
+		},
+		{
+			name: "Server config with client ip header without trusted proxies",
+			serverConfig: &ServerConfig{
+				ClientIPHeader: "X-Real-IP",
+			},
+			wantErr:     true,
+			errorString: "server clientIPHeader requires trustedProxies",
+		},
+		{
+			name: "Internal server config with client ip header without trusted proxies",
+			internalServerConfig: &ServerConfig{
+				ClientIPHeader: "X-Real-IP",
+			},
+			wantErr:     true,
+			errorString: "internalServer clientIPHeader requires trustedProxies",
 		},
 	}
-	for _, tt := range tests {
+
+	for _, currentTest := range tests {
+		// Capture the current test for parallel processing. Otherwise currentTest will be modified during our test run.
+		tt := currentTest
+
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateBusinessConfig(tt.args.out)
+			t.Parallel()
+
+			cfg := &Config{
+				Server:         tt.serverConfig,
+				InternalServer: tt.internalServerConfig,
+			}
+
+			err := validateBusinessConfig(cfg)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("validateBusinessConfig() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("%s validateBusinessConfig() error = %v, wantErr %v", tt.name, err, tt.wantErr)
 			}
 
-			if err != nil && err.Error() != tt.errorString {
+			if err != nil && tt.errorString != err.Error() {
 				t.Errorf("validateBusinessConfig() error = %v, wantErr %v", err.Error(), tt.errorString)
 			}
 		})
@@ -1658,6 +2818,250 @@ func Test_validateUserIsolation_NoOpWhenDisabled(t *testing.T) {
 	}
 }
 
+// Test_validateUserIsolation_Templates verifies that path templates and
+// groups are only accepted on isolated targets.
+func Test_validateUserIsolation_Templates(t *testing.T) {
+	authResources := []*Resource{{Path: "/*", Basic: &ResourceBasic{}}}
+
+	tests := []struct {
+		name    string
+		cfg     *GetActionConfigConfig
+		wantErr string
+	}{
+		{
+			name:    "path template without isolation",
+			cfg:     &GetActionConfigConfig{UserIsolationPathTemplate: "users/{{ .User.GetIdentifier }}"},
+			wantErr: "target t1 declares user isolation path templates or groups but userIsolation isn't enabled",
+		},
+		{
+			name:    "group path template without isolation",
+			cfg:     &GetActionConfigConfig{UserIsolationGroupPathTemplate: "teams/{{ .Group }}"},
+			wantErr: "target t1 declares user isolation path templates or groups but userIsolation isn't enabled",
+		},
+		{
+			name: "groups without group path template",
+			cfg: &GetActionConfigConfig{
+				UserIsolation:       true,
+				UserIsolationGroups: []string{"dev"},
+			},
+			wantErr: "target t1 declares userIsolationGroups without userIsolationGroupPathTemplate",
+		},
+		{
+			name: "group folders inside identifier folders",
+			cfg: &GetActionConfigConfig{
+				UserIsolation:                  true,
+				UserIsolationGroupPathTemplate: "teams/{{ .Group }}",
+			},
+			wantErr: "target t1 has user and group isolation folders that can contain each other: " +
+				"userIsolationPathTemplate and userIsolationGroupPathTemplate must start with different static folders",
+		},
+		{
+			name: "group folders inside user folders",
+			cfg: &GetActionConfigConfig{
+				UserIsolation:                  true,
+				UserIsolationPathTemplate:      "spaces/{{ .User.GetIdentifier }}",
+				UserIsolationGroupPathTemplate: "spaces/{{ .Group }}/shared",
+			},
+			wantErr: "target t1 has user and group isolation folders that can contain each other: " +
+				"userIsolationPathTemplate and userIsolationGroupPathTemplate must start with different static folders",
+		},
+		{
+			name: "user folders inside group folders",
+			cfg: &GetActionConfigConfig{
+				UserIsolation:                  true,
+				UserIsolationPathTemplate:      "teams/{{ .User.GetIdentifier }}",
+				UserIsolationGroupPathTemplate: "teams",
+			},
+			wantErr: "target t1 has user and group isolation folders that can contain each other: " +
+				"userIsolationPathTemplate and userIsolationGroupPathTemplate must start with different static folders",
+		},
+		{
+			name: "same static folder with different static parts",
+			cfg: &GetActionConfigConfig{
+				UserIsolation:                  true,
+				UserIsolationPathTemplate:      "spaces/user-{{ .User.GetIdentifier }}",
+				UserIsolationGroupPathTemplate: "spaces/group-{{ .Group }}",
+			},
+		},
+		{
+			name: "valid",
+			cfg: &GetActionConfigConfig{
+				UserIsolation:                  true,
+				UserIsolationPathTemplate:      "users/{{ .User.GetIdentifier }}",
+				UserIsolationGroupPathTemplate: "teams/{{ .Group }}",
+				UserIsolationGroups:            []string{"dev"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &TargetConfig{
+				Resources: authResources,
+				Actions:   &ActionsConfig{GET: &GetActionConfig{Enabled: true, Config: tt.cfg}},
+			}
+
+			err := validateUserIsolation("t1", target)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateUserIsolation() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateUserIsolation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_userIsolationTemplatesCanOverlap(t *testing.T) {
+	tests := []struct {
+		tpl1 string
+		tpl2 string
+		want bool
+	}{
+		{tpl1: "{{ .User.GetIdentifier }}", tpl2: "teams/{{ .Group }}", want: true},
+		{tpl1: "users/{{ .User.GetIdentifier }}", tpl2: "teams/{{ .Group }}", want: false},
+		{tpl1: "/users//{{ .User.GetIdentifier }}/", tpl2: "users/{{ .Group }}", want: true},
+		{tpl1: "u-{{ .User.GetIdentifier }}", tpl2: "g-{{ .Group }}", want: false},
+		{tpl1: "{{ .User.GetIdentifier }}-u", tpl2: "{{ .Group }}-g", want: false},
+		{tpl1: "{{ .User.GetIdentifier }}", tpl2: "teams", want: true},
+		{tpl1: "u-{{ .User.GetIdentifier }}", tpl2: "teams", want: false},
+		{tpl1: `users/{{ .User.GetIdentifier | replace "/" "-" }}`, tpl2: "teams/{{ .Group }}", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tpl1+" "+tt.tpl2, func(t *testing.T) {
+			if got := userIsolationTemplatesCanOverlap(tt.tpl1, tt.tpl2); got != tt.want {
+				t.Errorf("userIsolationTemplatesCanOverlap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_validateUserIsolationQuota verifies that quotas are only accepted on
+// isolated targets with a usable reconcile interval.
+func Test_validateUserIsolationQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *GetActionConfigConfig
+		wantErr string
+	}{
+		{
+			name: "No quota",
+			cfg:  &GetActionConfigConfig{},
+		},
+		{
+			name: "Quota without user isolation",
+			cfg: &GetActionConfigConfig{
+				UserIsolationQuota: &UserIsolationQuotaConfig{ReconcileInterval: time.Hour},
+			},
+			wantErr: "target t1 has userIsolationQuota declared but userIsolation is disabled",
+		},
+		{
+			name: "Quota without reconcile interval",
+			cfg: &GetActionConfigConfig{
+				UserIsolation:      true,
+				UserIsolationQuota: &UserIsolationQuotaConfig{},
+			},
+			wantErr: "target t1 must have a positive userIsolationQuota reconcile interval",
+		},
+		{
+			name: "Valid quota",
+			cfg: &GetActionConfigConfig{
+				UserIsolation: true,
+				UserIsolationQuota: &UserIsolationQuotaConfig{
+					Default:           &QuotaLimitConfig{MaxBytes: 10},
+					ReconcileInterval: time.Hour,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &TargetConfig{
+				Actions: &ActionsConfig{
+					GET: &GetActionConfig{Enabled: true, Config: tt.cfg},
+				},
+			}
+
+			err := validateUserIsolationQuota("t1", target)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateUserIsolationQuota() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateUserIsolationQuota() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validatePreActionHooks(t *testing.T) {
+	tests := []struct {
+		name    string
+		put     []string
+		delete  []string
+		wantErr string
+	}{
+		{
+			name: "No rewrite path prefix",
+		},
+		{
+			name:   "Valid rewrite path prefixes",
+			put:    []string{"/scanned/", "/"},
+			delete: []string{"/trash/"},
+		},
+		{
+			name:    "Rewrite path prefix without trailing /",
+			put:     []string{"/scanned"},
+			wantErr: "target t1 PUT pre-action hook 0 rewrite path prefix /scanned must start and end with /",
+		},
+		{
+			name:    "Rewrite path prefix without leading /",
+			delete:  []string{"trash/"},
+			wantErr: "target t1 DELETE pre-action hook 0 rewrite path prefix trash/ must start and end with /",
+		},
+		{
+			name:    "Rewrite path prefix with dot segments",
+			put:     []string{"/scanned/../"},
+			wantErr: "target t1 PUT pre-action hook 0 rewrite path prefix /scanned/../ can't contain dot segments",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &TargetConfig{
+				Actions: &ActionsConfig{
+					PUT: &PutActionConfig{Enabled: true, Config: &PutActionConfigConfig{
+						PreActionHooks: []*PreActionHookConfig{{RewritePathPrefixes: tt.put}},
+					}},
+					DELETE: &DeleteActionConfig{Enabled: true, Config: &DeleteActionConfigConfig{
+						PreActionHooks: []*PreActionHookConfig{{RewritePathPrefixes: tt.delete}},
+					}},
+				},
+			}
+
+			err := validatePreActionHooks("t1", target)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validatePreActionHooks() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validatePreActionHooks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 var (
 	// Test certificate, self-signed, for testhost.example.com.
 	testCertificate = `-----BEGIN CERTIFICATE-----
@@ -1710,3 +3114,254 @@ So0HeYWx9ixaRgxZ8yxGmB/CVOka/M5w06i0cwofTMWsiFYzPd6uPe2Mz6hcIPuE
 csZ8PbpqNkbcznkfy8BDRhwanNsvzsXWyX/0LxU+CdZGQ9jDOZwItyY=
 -----END RSA PRIVATE KEY-----`
 )
+
+func Test_validateRBACAuthorization(t *testing.T) {
+	rbacCfg := &RBACConfig{}
+
+	tests := []struct {
+		res     *Resource
+		rbacCfg *RBACConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name: "No rbac authorization",
+			res:  &Resource{OIDC: &ResourceHeaderOIDC{}},
+		},
+		{
+			name:    "Valid",
+			res:     &Resource{OIDC: &ResourceHeaderOIDC{AuthorizationRBAC: true}},
+			rbacCfg: rbacCfg,
+		},
+		{
+			name:    "Valid basic",
+			res:     &Resource{Basic: &ResourceBasic{AuthorizationRBAC: true}},
+			rbacCfg: rbacCfg,
+		},
+		{
+			name:    "Basic without rbac configuration",
+			res:     &Resource{Basic: &ResourceBasic{AuthorizationRBAC: true}},
+			wantErr: "resource 0 cannot use rbac authorization without rbac configuration",
+		},
+		{
+			name:    "Without rbac configuration",
+			res:     &Resource{JWT: &ResourceHeaderOIDC{AuthorizationRBAC: true}},
+			wantErr: "resource 0 cannot use rbac authorization without rbac configuration",
+		},
+		{
+			name: "Rbac and rego",
+			res: &Resource{Header: &ResourceHeaderOIDC{
+				AuthorizationRBAC: true,
+				AuthorizationRego: &RegoAuthorization{Policy: "p1"},
+			}},
+			rbacCfg: rbacCfg,
+			wantErr: "resource 0 cannot contain rbac authorization with authorization accesses, OPA server or rego at the same time",
+		},
+		{
+			name: "Rbac and authorization accesses",
+			res: &Resource{LDAP: &ResourceHeaderOIDC{
+				AuthorizationRBAC:     true,
+				AuthorizationAccesses: []*HeaderOIDCAuthorizationAccess{{Group: "group1"}},
+			}},
+			rbacCfg: rbacCfg,
+			wantErr: "resource 0 cannot contain rbac authorization with authorization accesses, OPA server or rego at the same time",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRBACAuthorization("resource 0", tt.res, tt.rbacCfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateRBACAuthorization() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateRBACAuthorization() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateRBACConfig(t *testing.T) {
+	roles := map[string]*RBACRoleConfig{
+		"reader": {Permissions: []*RBACPermissionConfig{{Targets: []string{"*"}, Paths: []string{"/**"}, Actions: []string{"read"}}}},
+	}
+
+	tests := []struct {
+		rbacCfg *RBACConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name:    "Valid",
+			rbacCfg: &RBACConfig{Roles: roles, Bindings: []*RBACBindingConfig{{Role: "reader", Groups: []string{"devs"}, Providers: []string{"provider1"}}}},
+		},
+		{
+			name:    "Unknown role",
+			rbacCfg: &RBACConfig{Roles: roles, Bindings: []*RBACBindingConfig{{Role: "writer", Groups: []string{"devs"}, Providers: []string{"provider1"}}}},
+			wantErr: "rbac binding 0 must use an existing role: writer not found",
+		},
+		{
+			name:    "Binding without subject",
+			rbacCfg: &RBACConfig{Roles: roles, Bindings: []*RBACBindingConfig{{Role: "reader", Providers: []string{"provider1"}}}},
+			wantErr: "rbac binding 0 must have users or groups",
+		},
+		{
+			name:    "Binding without provider",
+			rbacCfg: &RBACConfig{Roles: roles, Bindings: []*RBACBindingConfig{{Role: "reader", Users: []string{"alice@corp.com"}}}},
+			wantErr: "rbac binding 0 must have at least one provider",
+		},
+		{
+			name: "Invalid glob",
+			rbacCfg: &RBACConfig{Roles: map[string]*RBACRoleConfig{
+				"reader": {Permissions: []*RBACPermissionConfig{{Targets: []string{"*"}, Paths: []string{"/[a"}, Actions: []string{"read"}}}},
+			}},
+			wantErr: "rbac role reader permission 0 has an invalid glob /[a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRBACConfig(tt.rbacCfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateRBACConfig() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Fatalf("validateRBACConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateImpersonationConfig(t *testing.T) {
+	tests := []struct {
+		impCfg  *ImpersonationConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name:   "Disabled without admins",
+			impCfg: &ImpersonationConfig{},
+		},
+		{
+			name:    "Enabled without admins",
+			impCfg:  &ImpersonationConfig{Enabled: true},
+			wantErr: "impersonation must have at least one admin or admin group when enabled",
+		},
+		{
+			name:    "Enabled without cookie secret",
+			impCfg:  &ImpersonationConfig{Enabled: true, Admins: []string{"admin"}},
+			wantErr: "impersonation must have a cookie secret with at least 32 characters when enabled",
+		},
+		{
+			name: "Enabled with too short cookie secret",
+			impCfg: &ImpersonationConfig{
+				Enabled:      true,
+				Admins:       []string{"admin"},
+				CookieSecret: &CredentialConfig{Value: "short"},
+			},
+			wantErr: "impersonation must have a cookie secret with at least 32 characters when enabled",
+		},
+		{
+			name: "Enabled with admins",
+			impCfg: &ImpersonationConfig{
+				Enabled:      true,
+				Admins:       []string{"admin"},
+				CookieSecret: &CredentialConfig{Value: "01234567890123456789012345678901"},
+			},
+		},
+		{
+			name: "Enabled with admin groups",
+			impCfg: &ImpersonationConfig{
+				Enabled:      true,
+				AdminGroups:  []string{"support"},
+				CookieSecret: &CredentialConfig{Value: "01234567890123456789012345678901"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateImpersonationConfig(tt.impCfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateImpersonationConfig() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateImpersonationConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateWebhookQueueConfig(t *testing.T) {
+	tests := []struct {
+		qCfg    *WebhookQueueConfig
+		name    string
+		wantErr string
+	}{
+		{
+			name: "Disabled without directory",
+			qCfg: &WebhookQueueConfig{},
+		},
+		{
+			name:    "Enabled without directory",
+			qCfg:    &WebhookQueueConfig{Enabled: true, InitialBackoff: time.Second, MaxBackoff: time.Minute},
+			wantErr: "webhook queue must have a directory when enabled",
+		},
+		{
+			name: "Enabled with initial backoff greater than max backoff",
+			qCfg: &WebhookQueueConfig{
+				Enabled:        true,
+				Directory:      "/tmp/queue",
+				InitialBackoff: time.Minute,
+				MaxBackoff:     time.Second,
+			},
+			wantErr: "webhook queue must have a positive initial backoff lower or equal to max backoff",
+		},
+		{
+			name: "Enabled with zero initial backoff",
+			qCfg: &WebhookQueueConfig{
+				Enabled:    true,
+				Directory:  "/tmp/queue",
+				MaxBackoff: time.Second,
+			},
+			wantErr: "webhook queue must have a positive initial backoff lower or equal to max backoff",
+		},
+		{
+			name: "Enabled and valid",
+			qCfg: &WebhookQueueConfig{
+				Enabled:        true,
+				Directory:      "/tmp/queue",
+				InitialBackoff: time.Second,
+				MaxBackoff:     time.Minute,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWebhookQueueConfig(tt.qCfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateWebhookQueueConfig() unexpected error = %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("validateWebhookQueueConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package geoip
+
+import (
+	"net/netip"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+)
+
+// Manager will find countries of ips with the GeoIP database declared in configuration.
+//
+//go:generate mockgen -destination=./mocks/mock_Manager.go -package=mocks github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/geoip Manager
+type Manager interface {
+	// Load will open the GeoIP database if it has changed in configuration.
+	Load() error
+	// Country will return the ISO country code of an ip.
+	// Empty string is returned if no database is loaded or if the ip isn't found.
+	Country(ip netip.Addr) (string, error)
+	// Close will close the GeoIP database.
+	Close() error
+}
+
+// NewManager will return a new GeoIP manager.
+func NewManager(cfgManager config.Manager, logger log.Logger) Manager {
+	return &manager{
+		cfgManager: cfgManager,
+		logger:     logger,
+	}
+}
@@ -0,0 +1,53 @@
+package geoip
+
+import (
+	"context"
+	"net/http"
+
+	"emperror.dev/errors"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+)
+
+type contextKey struct {
+	name string
+}
+
+var countryCtxKey = &contextKey{name: "geoip-country"}
+
+// GetCountryFromContext will return the client country found by the HTTP middleware.
+func GetCountryFromContext(ctx context.Context) string {
+	// Get country
+	country, _ := ctx.Value(countryCtxKey).(string)
+
+	return country
+}
+
+// SetCountryInContext will add the client country in context.
+func SetCountryInContext(ctx context.Context, country string) context.Context {
+	return context.WithValue(ctx, countryCtxKey, country)
+}
+
+// HTTPMiddleware will find the client country from the client ip and add it in request context.
+// It must be placed after client ip middlewares.
+func HTTPMiddleware(geoipManager Manager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Find country
+			country, err := geoipManager.Country(middleware.GetClientIPAddr(r.Context()))
+			// Check error
+			if err != nil {
+				// Country rules will be considered as not matching
+				log.GetLoggerFromContext(r.Context()).Error(errors.Wrap(err, "cannot find client country"))
+			}
+
+			// Check if country is found
+			if country != "" {
+				r = r.WithContext(SetCountryInContext(r.Context(), country))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,3 @@
+package geoip
+
+// This package will manage GeoIP database used to find client country
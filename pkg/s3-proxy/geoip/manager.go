@@ -0,0 +1,121 @@
+package geoip
+
+import (
+	"net/netip"
+	"sync"
+
+	"emperror.dev/errors"
+	"github.com/oschwald/maxminddb-golang/v2"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+)
+
+type manager struct {
+	cfgManager config.Manager
+	logger     log.Logger
+	reader     *maxminddb.Reader
+	path       string
+	mutex      sync.RWMutex
+}
+
+// countryRecord is the part of GeoIP2 and GeoLite2 Country and City records used.
+type countryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
+
+func (m *manager) Load() error {
+	// Get configuration
+	cfg := m.cfgManager.GetConfig()
+
+	// Get database path
+	path := ""
+	// Check if GeoIP is configured
+	if cfg.GeoIP != nil {
+		path = cfg.GeoIP.DatabasePath
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Check if database has changed
+	if path == m.path {
+		return nil
+	}
+
+	var reader *maxminddb.Reader
+	// Check if a database must be opened
+	if path != "" {
+		m.logger.Infof("Loading GeoIP database %s", path)
+
+		// Open database
+		r, err := maxminddb.Open(path)
+		// Check error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		reader = r
+	}
+
+	// Close old database
+	if m.reader != nil {
+		err := m.reader.Close()
+		// Check error
+		if err != nil {
+			m.logger.Error(errors.Wrap(err, "cannot close old GeoIP database"))
+		}
+	}
+
+	m.reader = reader
+	m.path = path
+
+	return nil
+}
+
+func (m *manager) Country(ip netip.Addr) (string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	// Check if database is loaded and ip is valid
+	if m.reader == nil || !ip.IsValid() {
+		return "", nil
+	}
+
+	var rec countryRecord
+	// Lookup ip
+	err := m.reader.Lookup(ip.Unmap()).Decode(&rec)
+	// Check error
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	// Check if country is found
+	if rec.Country.ISOCode != "" {
+		return rec.Country.ISOCode, nil
+	}
+
+	// Fallback on registered country (anonymous proxies, satellite providers...)
+	return rec.RegisteredCountry.ISOCode, nil
+}
+
+func (m *manager) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Check if database is loaded
+	if m.reader == nil {
+		return nil
+	}
+
+	err := m.reader.Close()
+	m.reader = nil
+	m.path = ""
+
+	return errors.WithStack(err)
+}
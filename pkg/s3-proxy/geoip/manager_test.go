@@ -0,0 +1,227 @@
+//go:build unit
+
+package geoip
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+)
+
+// writeTestDatabase will write a GeoIP country database with the given records.
+func writeTestDatabase(t *testing.T, records map[string]mmdbtype.Map) string {
+	t.Helper()
+
+	tree, err := mmdbwriter.New(mmdbwriter.Options{
+		DatabaseType:            "GeoIP2-Country",
+		IncludeReservedNetworks: true,
+	})
+	require.NoError(t, err)
+
+	for cidr, rec := range records {
+		_, network, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		require.NoError(t, tree.Insert(network, rec))
+	}
+
+	path := filepath.Join(t.TempDir(), "country.mmdb")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	_, err = tree.WriteTo(f)
+	require.NoError(t, err)
+
+	return path
+}
+
+func testRecord(key, isoCode string) mmdbtype.Map {
+	return mmdbtype.Map{
+		mmdbtype.String(key): mmdbtype.Map{
+			"iso_code": mmdbtype.String(isoCode),
+		},
+	}
+}
+
+func newTestManager(t *testing.T, cfg *config.Config) *manager {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	m, ok := NewManager(cfgManagerMock, log.NewLogger()).(*manager)
+	require.True(t, ok)
+
+	return m
+}
+
+func Test_manager_Country(t *testing.T) {
+	path := writeTestDatabase(t, map[string]mmdbtype.Map{
+		"10.0.0.0/8":     testRecord("country", "FR"),
+		"192.168.0.0/16": testRecord("registered_country", "US"),
+		"2001:db8::/32":  testRecord("country", "DE"),
+	})
+
+	m := newTestManager(t, &config.Config{GeoIP: &config.GeoIPConfig{DatabasePath: path}})
+	require.NoError(t, m.Load())
+
+	defer m.Close()
+
+	tests := []struct {
+		name string
+		ip   netip.Addr
+		want string
+	}{
+		{
+			name: "country found",
+			ip:   netip.MustParseAddr("10.1.2.3"),
+			want: "FR",
+		},
+		{
+			name: "ipv4 mapped ipv6 address",
+			ip:   netip.MustParseAddr("::ffff:10.1.2.3"),
+			want: "FR",
+		},
+		{
+			name: "registered country fallback",
+			ip:   netip.MustParseAddr("192.168.1.1"),
+			want: "US",
+		},
+		{
+			name: "ipv6 country found",
+			ip:   netip.MustParseAddr("2001:db8::1"),
+			want: "DE",
+		},
+		{
+			name: "not found",
+			ip:   netip.MustParseAddr("172.16.0.1"),
+			want: "",
+		},
+		{
+			name: "invalid ip",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Country(tt.ip)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_manager_Load(t *testing.T) {
+	path1 := writeTestDatabase(t, map[string]mmdbtype.Map{
+		"10.0.0.0/8": testRecord("country", "FR"),
+	})
+	path2 := writeTestDatabase(t, map[string]mmdbtype.Map{
+		"10.0.0.0/8": testRecord("country", "US"),
+	})
+
+	cfg := &config.Config{}
+	m := newTestManager(t, cfg)
+	ip := netip.MustParseAddr("10.0.0.1")
+
+	// Without configuration
+	require.NoError(t, m.Load())
+	got, err := m.Country(ip)
+	assert.NoError(t, err)
+	assert.Equal(t, "", got)
+
+	// With first database
+	cfg.GeoIP = &config.GeoIPConfig{DatabasePath: path1}
+	require.NoError(t, m.Load())
+	reader := m.reader
+	got, err = m.Country(ip)
+	assert.NoError(t, err)
+	assert.Equal(t, "FR", got)
+
+	// Same path must keep the reader
+	require.NoError(t, m.Load())
+	assert.Same(t, reader, m.reader)
+
+	// With second database
+	cfg.GeoIP = &config.GeoIPConfig{DatabasePath: path2}
+	require.NoError(t, m.Load())
+	got, err = m.Country(ip)
+	assert.NoError(t, err)
+	assert.Equal(t, "US", got)
+
+	// With a wrong path, old database must be kept
+	cfg.GeoIP = &config.GeoIPConfig{DatabasePath: filepath.Join(t.TempDir(), "missing.mmdb")}
+	assert.Error(t, m.Load())
+	got, err = m.Country(ip)
+	assert.NoError(t, err)
+	assert.Equal(t, "US", got)
+
+	// Remove configuration
+	cfg.GeoIP = nil
+	require.NoError(t, m.Load())
+	assert.Nil(t, m.reader)
+
+	assert.NoError(t, m.Close())
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	path := writeTestDatabase(t, map[string]mmdbtype.Map{
+		"10.0.0.0/8": testRecord("country", "FR"),
+	})
+
+	m := newTestManager(t, &config.Config{GeoIP: &config.GeoIPConfig{DatabasePath: path}})
+	require.NoError(t, m.Load())
+
+	defer m.Close()
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       string
+	}{
+		{
+			name:       "country found",
+			remoteAddr: "10.0.0.1:1234",
+			want:       "FR",
+		},
+		{
+			name:       "country not found",
+			remoteAddr: "172.16.0.1:1234",
+			want:       "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+
+			h := middleware.ClientIPFromRemoteAddr(HTTPMiddleware(m)(
+				http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+					got = GetCountryFromContext(r.Context())
+				}),
+			))
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+			req.RemoteAddr = tt.remoteAddr
+
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,3 @@
+package ipfilter
+
+// This package will filter requests with ip and country allow and deny lists
@@ -0,0 +1,117 @@
+package ipfilter
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+
+	"emperror.dev/errors"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/audit"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/geoip"
+	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
+)
+
+// Evaluate will check if a client ip and country are allowed by an ip filter.
+// Deny lists are checked first. Then, if allow lists are declared, the client must match one of them.
+// A reason is returned when the client isn't allowed.
+func Evaluate(f *config.IPFilterConfig, ip netip.Addr, country string) (bool, string) {
+	// Check if filter exists
+	if f == nil {
+		return true, ""
+	}
+
+	// Check if ip is denied
+	if ip.IsValid() && containsIP(f.DenyPrefixes, ip) {
+		return false, fmt.Sprintf("client ip %s is denied", ip)
+	}
+
+	// Check if country is denied
+	if country != "" && slices.Contains(f.DenyCountries, country) {
+		return false, fmt.Sprintf("client country %s is denied", country)
+	}
+
+	// Check if there isn't any allow list
+	if len(f.AllowPrefixes) == 0 && len(f.AllowCountries) == 0 {
+		return true, ""
+	}
+
+	// Check if ip is allowed
+	if ip.IsValid() && containsIP(f.AllowPrefixes, ip) {
+		return true, ""
+	}
+
+	// Check if country is allowed
+	if country != "" && slices.Contains(f.AllowCountries, country) {
+		return true, ""
+	}
+
+	// Check if ip is unknown
+	if !ip.IsValid() {
+		return false, "client ip is unknown"
+	}
+
+	return false, fmt.Sprintf("client ip %s is not allowed", ip)
+}
+
+// CheckRequest will evaluate an ip filter with the client ip and country found in request context.
+// An error is returned when the client isn't allowed.
+func CheckRequest(r *http.Request, f *config.IPFilterConfig) error {
+	// Evaluate
+	allowed, reason := Evaluate(
+		f,
+		middleware.GetClientIPAddr(r.Context()),
+		geoip.GetCountryFromContext(r.Context()),
+	)
+	// Check if allowed
+	if allowed {
+		return nil
+	}
+
+	// Save audit decision
+	audit.SetDecision(r.Context(), audit.DecisionDeny, "ip filter: "+reason)
+
+	return errors.WithStack(fmt.Errorf("ip filter: %s => Forbidden access", reason))
+}
+
+// HTTPMiddleware will reject requests not allowed by the target ip filter.
+// It must be placed after the bucket middleware.
+func HTTPMiddleware(tgt *config.TargetConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Check request
+			err := CheckRequest(r, tgt.IPFilter)
+			// Check error
+			if err != nil {
+				// Get response handler
+				resHan := responsehandler.GetResponseHandlerFromContext(r.Context())
+				// Get bucket request context
+				brctx := bucket.GetBucketRequestContextFromContext(r.Context())
+				// Answer
+				resHan.ForbiddenError(brctx.LoadFileContent, err)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// containsIP returns true if ip is in one of the prefixes.
+func containsIP(prefixes []netip.Prefix, ip netip.Addr) bool {
+	// Remove zone as prefixes don't have any
+	ip = ip.WithZone("")
+
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,175 @@
+//go:build unit
+
+package ipfilter
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     *config.IPFilterConfig
+		ip         netip.Addr
+		country    string
+		wantResult bool
+		wantReason string
+	}{
+		{
+			name:       "no filter",
+			ip:         netip.MustParseAddr("10.0.0.1"),
+			wantResult: true,
+		},
+		{
+			name:       "empty filter",
+			filter:     &config.IPFilterConfig{},
+			ip:         netip.MustParseAddr("10.0.0.1"),
+			wantResult: true,
+		},
+		{
+			name: "ip in deny list",
+			filter: &config.IPFilterConfig{
+				DenyPrefixes: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			},
+			ip:         netip.MustParseAddr("10.0.0.1"),
+			wantResult: false,
+			wantReason: "client ip 10.0.0.1 is denied",
+		},
+		{
+			name: "ip not in deny list",
+			filter: &config.IPFilterConfig{
+				DenyPrefixes: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			},
+			ip:         netip.MustParseAddr("192.168.0.1"),
+			wantResult: true,
+		},
+		{
+			name: "deny list is checked before allow list",
+			filter: &config.IPFilterConfig{
+				AllowPrefixes: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+				DenyPrefixes:  []netip.Prefix{netip.MustParsePrefix("10.0.0.1/32")},
+			},
+			ip:         netip.MustParseAddr("10.0.0.1"),
+			wantResult: false,
+			wantReason: "client ip 10.0.0.1 is denied",
+		},
+		{
+			name: "ip in allow list",
+			filter: &config.IPFilterConfig{
+				AllowPrefixes: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			},
+			ip:         netip.MustParseAddr("10.1.2.3"),
+			wantResult: true,
+		},
+		{
+			name: "ip not in allow list",
+			filter: &config.IPFilterConfig{
+				AllowPrefixes: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			},
+			ip:         netip.MustParseAddr("192.168.0.1"),
+			wantResult: false,
+			wantReason: "client ip 192.168.0.1 is not allowed",
+		},
+		{
+			name: "ipv6 in allow list",
+			filter: &config.IPFilterConfig{
+				AllowPrefixes: []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")},
+			},
+			ip:         netip.MustParseAddr("2001:db8::1"),
+			wantResult: true,
+		},
+		{
+			name: "ipv6 with zone in allow list",
+			filter: &config.IPFilterConfig{
+				AllowPrefixes: []netip.Prefix{netip.MustParsePrefix("fe80::/10")},
+			},
+			ip:         netip.MustParseAddr("fe80::1%eth0"),
+			wantResult: true,
+		},
+		{
+			name: "unknown ip with allow list",
+			filter: &config.IPFilterConfig{
+				AllowPrefixes: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			},
+			wantResult: false,
+			wantReason: "client ip is unknown",
+		},
+		{
+			name: "unknown ip with deny list only",
+			filter: &config.IPFilterConfig{
+				DenyPrefixes: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			},
+			wantResult: true,
+		},
+		{
+			name: "country in deny list",
+			filter: &config.IPFilterConfig{
+				DenyCountries: []string{"FR"},
+			},
+			ip:         netip.MustParseAddr("10.0.0.1"),
+			country:    "FR",
+			wantResult: false,
+			wantReason: "client country FR is denied",
+		},
+		{
+			name: "country in allow list",
+			filter: &config.IPFilterConfig{
+				AllowCountries: []string{"FR"},
+			},
+			ip:         netip.MustParseAddr("10.0.0.1"),
+			country:    "FR",
+			wantResult: true,
+		},
+		{
+			name: "country not in allow list",
+			filter: &config.IPFilterConfig{
+				AllowCountries: []string{"FR"},
+			},
+			ip:         netip.MustParseAddr("10.0.0.1"),
+			country:    "US",
+			wantResult: false,
+			wantReason: "client ip 10.0.0.1 is not allowed",
+		},
+		{
+			name: "unknown country with country allow list",
+			filter: &config.IPFilterConfig{
+				AllowCountries: []string{"FR"},
+			},
+			ip:         netip.MustParseAddr("10.0.0.1"),
+			wantResult: false,
+			wantReason: "client ip 10.0.0.1 is not allowed",
+		},
+		{
+			name: "ip allowed with country not in allow list",
+			filter: &config.IPFilterConfig{
+				AllowPrefixes:  []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+				AllowCountries: []string{"FR"},
+			},
+			ip:         netip.MustParseAddr("10.0.0.1"),
+			country:    "US",
+			wantResult: true,
+		},
+		{
+			name: "country allowed with ip not in allow list",
+			filter: &config.IPFilterConfig{
+				AllowPrefixes:  []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+				AllowCountries: []string{"FR"},
+			},
+			ip:         netip.MustParseAddr("192.168.0.1"),
+			country:    "FR",
+			wantResult: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := Evaluate(tt.filter, tt.ip, tt.country)
+			assert.Equal(t, tt.wantResult, got)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package limiter
+
+import (
+	"context"
+
+	"emperror.dev/errors"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics"
+)
+
+// ErrQueueFull will be raised when the target queue is full.
+var ErrQueueFull = errors.New("too many requests in progress on target")
+
+// ErrQueueTimeout will be raised when no slot have been available in the target queue timeout.
+var ErrQueueTimeout = errors.New("timeout while waiting for a free slot on target")
+
+// Manager will limit the number of in flight operations per target.
+//
+//go:generate mockgen -destination=./mocks/mock_Manager.go -package=mocks github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/limiter Manager
+type Manager interface {
+	// Acquire will wait for a free slot on target.
+	// The returned release function must be called once the operation is done.
+	// ErrQueueFull or ErrQueueTimeout are returned when no slot can be given.
+	Acquire(ctx context.Context, targetKey string) (func(), error)
+}
+
+// NewManager will return a new limiter manager.
+func NewManager(cfgManager config.Manager, metricsCl metrics.Client) Manager {
+	return &manager{
+		cfgManager: cfgManager,
+		metricsCl:  metricsCl,
+		limiters:   map[string]*targetLimiter{},
+	}
+}
@@ -0,0 +1,3 @@
+package limiter
+
+// This package will manage per target concurrency limits
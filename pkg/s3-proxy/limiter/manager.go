@@ -0,0 +1,174 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics"
+)
+
+const (
+	rejectReasonQueueFull    = "queue_full"
+	rejectReasonQueueTimeout = "queue_timeout"
+	rejectReasonCanceled     = "canceled"
+)
+
+type targetLimiter struct {
+	slots        chan struct{}
+	maxQueueSize int
+	queueTimeout time.Duration
+	queued       int
+}
+
+type manager struct {
+	cfgManager config.Manager
+	metricsCl  metrics.Client
+	limiters   map[string]*targetLimiter
+	mutex      sync.Mutex
+}
+
+func (m *manager) Acquire(ctx context.Context, targetKey string) (func(), error) {
+	// Get limiter
+	l := m.getLimiter(targetKey)
+	// Check if there is a limit on target
+	if l == nil {
+		return func() {}, nil
+	}
+
+	// Try to get a slot without waiting
+	select {
+	case l.slots <- struct{}{}:
+		// Update metrics
+		m.updateMetrics(targetKey, l)
+
+		return m.releaseFunc(targetKey, l), nil
+	default:
+	}
+
+	m.mutex.Lock()
+
+	// Check if queue is full
+	if l.queued >= l.maxQueueSize {
+		m.mutex.Unlock()
+
+		// Update metrics
+		m.metricsCl.IncTargetRejectedRequests(targetKey, rejectReasonQueueFull)
+
+		return nil, errors.WithStack(ErrQueueFull)
+	}
+
+	// Enter queue
+	l.queued++
+
+	m.mutex.Unlock()
+
+	// Update metrics
+	m.updateMetrics(targetKey, l)
+
+	// Create timer
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	// Wait
+	select {
+	case l.slots <- struct{}{}:
+		// Leave queue
+		m.leaveQueue(targetKey, l)
+
+		return m.releaseFunc(targetKey, l), nil
+	case <-timer.C:
+		// Leave queue
+		m.leaveQueue(targetKey, l)
+		// Update metrics
+		m.metricsCl.IncTargetRejectedRequests(targetKey, rejectReasonQueueTimeout)
+
+		return nil, errors.WithStack(ErrQueueTimeout)
+	case <-ctx.Done():
+		// Leave queue
+		m.leaveQueue(targetKey, l)
+		// Update metrics
+		m.metricsCl.IncTargetRejectedRequests(targetKey, rejectReasonCanceled)
+
+		return nil, errors.WithStack(ctx.Err())
+	}
+}
+
+func (m *manager) releaseFunc(targetKey string, l *targetLimiter) func() {
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			// Free slot
+			<-l.slots
+			// Update metrics
+			m.updateMetrics(targetKey, l)
+		})
+	}
+}
+
+func (m *manager) leaveQueue(targetKey string, l *targetLimiter) {
+	m.mutex.Lock()
+	l.queued--
+	m.mutex.Unlock()
+
+	// Update metrics
+	m.updateMetrics(targetKey, l)
+}
+
+// updateMetrics will update gauges for target.
+// Limiters replaced after a configuration reload are ignored to avoid overriding
+// values coming from the new one.
+func (m *manager) updateMetrics(targetKey string, l *targetLimiter) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Check if limiter is still the current one
+	if m.limiters[targetKey] != l {
+		return
+	}
+
+	m.metricsCl.SetTargetInFlightRequests(targetKey, len(l.slots))
+	m.metricsCl.SetTargetQueueDepth(targetKey, l.queued)
+}
+
+// getLimiter will return the limiter for target.
+// A new limiter is created when the target configuration have changed.
+func (m *manager) getLimiter(targetKey string) *targetLimiter {
+	// Get target configuration
+	tgt := m.cfgManager.GetConfig().Targets[targetKey]
+	// Check if concurrency is configured
+	if tgt == nil || tgt.Concurrency == nil {
+		return nil
+	}
+
+	// Store concurrency configuration
+	ccCfg := tgt.Concurrency
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Get limiter
+	l := m.limiters[targetKey]
+	// Check if it is still matching the configuration
+	if l != nil &&
+		cap(l.slots) == ccCfg.MaxInFlight &&
+		l.maxQueueSize == ccCfg.MaxQueueSize &&
+		l.queueTimeout == ccCfg.QueueTimeout {
+		return l
+	}
+
+	// Create new limiter
+	l = &targetLimiter{
+		slots:        make(chan struct{}, ccCfg.MaxInFlight),
+		maxQueueSize: ccCfg.MaxQueueSize,
+		queueTimeout: ccCfg.QueueTimeout,
+	}
+	// Save it
+	m.limiters[targetKey] = l
+
+	return l
+}
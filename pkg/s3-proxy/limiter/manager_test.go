@@ -0,0 +1,179 @@
+//go:build unit
+
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	mmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics/mocks"
+)
+
+func newTestManager(t *testing.T, cfg *config.Config) (*manager, *mmocks.MockClient) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	metricsCl := mmocks.NewMockClient(ctrl)
+	metricsCl.EXPECT().SetTargetInFlightRequests("target1", gomock.Any()).AnyTimes()
+	metricsCl.EXPECT().SetTargetQueueDepth("target1", gomock.Any()).AnyTimes()
+
+	m, ok := NewManager(cfgManagerMock, metricsCl).(*manager)
+	require.True(t, ok)
+
+	return m, metricsCl
+}
+
+func newTestConfig(ccCfg *config.TargetConcurrencyConfig) *config.Config {
+	return &config.Config{
+		Targets: map[string]*config.TargetConfig{
+			"target1": {Name: "target1", Concurrency: ccCfg},
+		},
+	}
+}
+
+func Test_manager_Acquire_NoLimit(t *testing.T) {
+	m, _ := newTestManager(t, newTestConfig(nil))
+
+	release, err := m.Acquire(context.TODO(), "target1")
+	require.NoError(t, err)
+	release()
+
+	// Unknown target
+	release, err = m.Acquire(context.TODO(), "target2")
+	require.NoError(t, err)
+	release()
+}
+
+func Test_manager_Acquire_QueueFull(t *testing.T) {
+	m, metricsCl := newTestManager(t, newTestConfig(&config.TargetConcurrencyConfig{
+		MaxInFlight:  1,
+		MaxQueueSize: 0,
+		QueueTimeout: time.Second,
+	}))
+
+	metricsCl.EXPECT().IncTargetRejectedRequests("target1", "queue_full").Times(1)
+
+	release, err := m.Acquire(context.TODO(), "target1")
+	require.NoError(t, err)
+
+	_, err = m.Acquire(context.TODO(), "target1")
+	assert.ErrorIs(t, err, ErrQueueFull)
+
+	// Release twice must free only one slot
+	release()
+	release()
+
+	release, err = m.Acquire(context.TODO(), "target1")
+	require.NoError(t, err)
+	release()
+
+	assert.Empty(t, m.limiters["target1"].slots)
+}
+
+func Test_manager_Acquire_QueueTimeout(t *testing.T) {
+	m, metricsCl := newTestManager(t, newTestConfig(&config.TargetConcurrencyConfig{
+		MaxInFlight:  1,
+		MaxQueueSize: 1,
+		QueueTimeout: 10 * time.Millisecond,
+	}))
+
+	metricsCl.EXPECT().IncTargetRejectedRequests("target1", "queue_timeout").Times(1)
+
+	release, err := m.Acquire(context.TODO(), "target1")
+	require.NoError(t, err)
+
+	defer release()
+
+	_, err = m.Acquire(context.TODO(), "target1")
+	assert.ErrorIs(t, err, ErrQueueTimeout)
+	assert.Equal(t, 0, m.limiters["target1"].queued)
+}
+
+func Test_manager_Acquire_Canceled(t *testing.T) {
+	m, metricsCl := newTestManager(t, newTestConfig(&config.TargetConcurrencyConfig{
+		MaxInFlight:  1,
+		MaxQueueSize: 1,
+		QueueTimeout: time.Minute,
+	}))
+
+	metricsCl.EXPECT().IncTargetRejectedRequests("target1", "canceled").Times(1)
+
+	release, err := m.Acquire(context.TODO(), "target1")
+	require.NoError(t, err)
+
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	_, err = m.Acquire(ctx, "target1")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_manager_Acquire_WaitInQueue(t *testing.T) {
+	m, _ := newTestManager(t, newTestConfig(&config.TargetConcurrencyConfig{
+		MaxInFlight:  1,
+		MaxQueueSize: 1,
+		QueueTimeout: time.Minute,
+	}))
+
+	release, err := m.Acquire(context.TODO(), "target1")
+	require.NoError(t, err)
+
+	done := make(chan error)
+
+	go func() {
+		release2, err2 := m.Acquire(context.TODO(), "target1")
+		if err2 == nil {
+			release2()
+		}
+
+		done <- err2
+	}()
+
+	// Wait for the second request to be queued
+	assert.Eventually(t, func() bool {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		return m.limiters["target1"].queued == 1
+	}, time.Second, time.Millisecond)
+
+	release()
+
+	assert.NoError(t, <-done)
+}
+
+func Test_manager_getLimiter_Reload(t *testing.T) {
+	cfg := newTestConfig(&config.TargetConcurrencyConfig{
+		MaxInFlight:  1,
+		QueueTimeout: time.Second,
+	})
+	m, _ := newTestManager(t, cfg)
+
+	l1 := m.getLimiter("target1")
+	require.NotNil(t, l1)
+	// Same configuration must keep the same limiter
+	assert.Same(t, l1, m.getLimiter("target1"))
+
+	// Change configuration
+	cfg.Targets["target1"].Concurrency = &config.TargetConcurrencyConfig{
+		MaxInFlight:  2,
+		QueueTimeout: time.Second,
+	}
+
+	l2 := m.getLimiter("target1")
+	require.NotNil(t, l2)
+	assert.NotSame(t, l1, l2)
+	assert.Equal(t, 2, cap(l2.slots))
+}
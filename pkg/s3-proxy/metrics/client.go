@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
 )
@@ -20,10 +21,28 @@ type Client interface {
 	IncAuthenticated(providerType, providerName string)
 	// Will increase counter of authorized user.
 	IncAuthorized(providerType string)
+	// Will increase counter of failed authentications.
+	IncAuthenticationFailures(providerType, providerName string)
+	// Will increase counter of lockouts started by brute force protection
+	IncLockouts(providerName, scope string)
+	// Will increase counter of requests rejected because of an active lockout
+	IncLockedOutRequests(providerName, scope string)
 	// Will increase counter of succeed webhooks
 	IncSucceedWebhooks(targetName, actionName string)
 	// Will increase counter of failed webhooks
 	IncFailedWebhooks(targetName, actionName string)
+	// Will set the number of webhook deliveries waiting in the durable queue
+	SetWebhookQueueDepth(value int)
+	// Will set the number of webhook deliveries stored in the dead letter store
+	SetWebhookDeadLetters(value int)
+	// Will observe the latency between a webhook delivery creation and its success
+	ObserveWebhookDeliveryLatency(targetName, actionName string, latency time.Duration)
+	// Will set the number of in flight requests on a target
+	SetTargetInFlightRequests(targetName string, value int)
+	// Will set the number of requests waiting in a target queue
+	SetTargetQueueDepth(targetName string, value int)
+	// Will increase counter of rejected requests on a target because of concurrency limits
+	IncTargetRejectedRequests(targetName, reason string)
 }
 
 // NewClient will generate a new client instance.
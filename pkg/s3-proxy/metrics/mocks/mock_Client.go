@@ -12,6 +12,7 @@ package mocks
 import (
 	http "net/http"
 	reflect "reflect"
+	time "time"
 
 	config "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
 	gomock "go.uber.org/mock/gomock"
@@ -67,6 +68,18 @@ func (mr *MockClientMockRecorder) IncAuthenticated(providerType, providerName an
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncAuthenticated", reflect.TypeOf((*MockClient)(nil).IncAuthenticated), providerType, providerName)
 }
 
+// IncAuthenticationFailures mocks base method.
+func (m *MockClient) IncAuthenticationFailures(providerType, providerName string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "IncAuthenticationFailures", providerType, providerName)
+}
+
+// IncAuthenticationFailures indicates an expected call of IncAuthenticationFailures.
+func (mr *MockClientMockRecorder) IncAuthenticationFailures(providerType, providerName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncAuthenticationFailures", reflect.TypeOf((*MockClient)(nil).IncAuthenticationFailures), providerType, providerName)
+}
+
 // IncAuthorized mocks base method.
 func (m *MockClient) IncAuthorized(providerType string) {
 	m.ctrl.T.Helper()
@@ -91,6 +104,30 @@ func (mr *MockClientMockRecorder) IncFailedWebhooks(targetName, actionName any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncFailedWebhooks", reflect.TypeOf((*MockClient)(nil).IncFailedWebhooks), targetName, actionName)
 }
 
+// IncLockedOutRequests mocks base method.
+func (m *MockClient) IncLockedOutRequests(providerName, scope string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "IncLockedOutRequests", providerName, scope)
+}
+
+// IncLockedOutRequests indicates an expected call of IncLockedOutRequests.
+func (mr *MockClientMockRecorder) IncLockedOutRequests(providerName, scope any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncLockedOutRequests", reflect.TypeOf((*MockClient)(nil).IncLockedOutRequests), providerName, scope)
+}
+
+// IncLockouts mocks base method.
+func (m *MockClient) IncLockouts(providerName, scope string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "IncLockouts", providerName, scope)
+}
+
+// IncLockouts indicates an expected call of IncLockouts.
+func (mr *MockClientMockRecorder) IncLockouts(providerName, scope any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncLockouts", reflect.TypeOf((*MockClient)(nil).IncLockouts), providerName, scope)
+}
+
 // IncS3Operations mocks base method.
 func (m *MockClient) IncS3Operations(targetName, bucketName, operation string) {
 	m.ctrl.T.Helper()
@@ -115,6 +152,18 @@ func (mr *MockClientMockRecorder) IncSucceedWebhooks(targetName, actionName any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncSucceedWebhooks", reflect.TypeOf((*MockClient)(nil).IncSucceedWebhooks), targetName, actionName)
 }
 
+// IncTargetRejectedRequests mocks base method.
+func (m *MockClient) IncTargetRejectedRequests(targetName, reason string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "IncTargetRejectedRequests", targetName, reason)
+}
+
+// IncTargetRejectedRequests indicates an expected call of IncTargetRejectedRequests.
+func (mr *MockClientMockRecorder) IncTargetRejectedRequests(targetName, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncTargetRejectedRequests", reflect.TypeOf((*MockClient)(nil).IncTargetRejectedRequests), targetName, reason)
+}
+
 // Instrument mocks base method.
 func (m *MockClient) Instrument(serverLabel string, metricsCfg *config.MetricsConfig) func(http.Handler) http.Handler {
 	m.ctrl.T.Helper()
@@ -128,3 +177,63 @@ func (mr *MockClientMockRecorder) Instrument(serverLabel, metricsCfg any) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Instrument", reflect.TypeOf((*MockClient)(nil).Instrument), serverLabel, metricsCfg)
 }
+
+// ObserveWebhookDeliveryLatency mocks base method.
+func (m *MockClient) ObserveWebhookDeliveryLatency(targetName, actionName string, latency time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ObserveWebhookDeliveryLatency", targetName, actionName, latency)
+}
+
+// ObserveWebhookDeliveryLatency indicates an expected call of ObserveWebhookDeliveryLatency.
+func (mr *MockClientMockRecorder) ObserveWebhookDeliveryLatency(targetName, actionName, latency any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObserveWebhookDeliveryLatency", reflect.TypeOf((*MockClient)(nil).ObserveWebhookDeliveryLatency), targetName, actionName, latency)
+}
+
+// SetTargetInFlightRequests mocks base method.
+func (m *MockClient) SetTargetInFlightRequests(targetName string, value int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTargetInFlightRequests", targetName, value)
+}
+
+// SetTargetInFlightRequests indicates an expected call of SetTargetInFlightRequests.
+func (mr *MockClientMockRecorder) SetTargetInFlightRequests(targetName, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTargetInFlightRequests", reflect.TypeOf((*MockClient)(nil).SetTargetInFlightRequests), targetName, value)
+}
+
+// SetTargetQueueDepth mocks base method.
+func (m *MockClient) SetTargetQueueDepth(targetName string, value int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTargetQueueDepth", targetName, value)
+}
+
+// SetTargetQueueDepth indicates an expected call of SetTargetQueueDepth.
+func (mr *MockClientMockRecorder) SetTargetQueueDepth(targetName, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTargetQueueDepth", reflect.TypeOf((*MockClient)(nil).SetTargetQueueDepth), targetName, value)
+}
+
+// SetWebhookDeadLetters mocks base method.
+func (m *MockClient) SetWebhookDeadLetters(value int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetWebhookDeadLetters", value)
+}
+
+// SetWebhookDeadLetters indicates an expected call of SetWebhookDeadLetters.
+func (mr *MockClientMockRecorder) SetWebhookDeadLetters(value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWebhookDeadLetters", reflect.TypeOf((*MockClient)(nil).SetWebhookDeadLetters), value)
+}
+
+// SetWebhookQueueDepth mocks base method.
+func (m *MockClient) SetWebhookQueueDepth(value int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetWebhookQueueDepth", value)
+}
+
+// SetWebhookQueueDepth indicates an expected call of SetWebhookQueueDepth.
+func (mr *MockClientMockRecorder) SetWebhookQueueDepth(value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWebhookQueueDepth", reflect.TypeOf((*MockClient)(nil).SetWebhookQueueDepth), value)
+}
@@ -20,8 +20,17 @@ type prometheusClient struct {
 	s3OperationsTotal  *prometheus.CounterVec
 	authenticatedTotal *prometheus.CounterVec
 	authorizedTotal    *prometheus.CounterVec
+	authFailuresTotal  *prometheus.CounterVec
+	lockoutsTotal      *prometheus.CounterVec
+	lockedOutTotal     *prometheus.CounterVec
 	succeedWebhooks    *prometheus.CounterVec
 	failedWebhooks     *prometheus.CounterVec
+	webhookQueueDepth  prometheus.Gauge
+	webhookDeadLetters prometheus.Gauge
+	webhookLatency     *prometheus.HistogramVec
+	targetInFlight     *prometheus.GaugeVec
+	targetQueueDepth   *prometheus.GaugeVec
+	targetRejected     *prometheus.CounterVec
 }
 
 // Instrument will instrument gin routes.
@@ -80,6 +89,19 @@ func (cl *prometheusClient) IncAuthorized(providerType string) {
 	cl.authorizedTotal.WithLabelValues(providerType).Inc()
 }
 
+// Will increase counter of failed authentications.
+func (cl *prometheusClient) IncAuthenticationFailures(providerType, providerName string) {
+	cl.authFailuresTotal.WithLabelValues(providerType, providerName).Inc()
+}
+
+func (cl *prometheusClient) IncLockouts(providerName, scope string) {
+	cl.lockoutsTotal.WithLabelValues(providerName, scope).Inc()
+}
+
+func (cl *prometheusClient) IncLockedOutRequests(providerName, scope string) {
+	cl.lockedOutTotal.WithLabelValues(providerName, scope).Inc()
+}
+
 func (cl *prometheusClient) IncSucceedWebhooks(targetName, actionName string) {
 	cl.succeedWebhooks.WithLabelValues(targetName, actionName).Inc()
 }
@@ -88,6 +110,30 @@ func (cl *prometheusClient) IncFailedWebhooks(targetName, actionName string) {
 	cl.failedWebhooks.WithLabelValues(targetName, actionName).Inc()
 }
 
+func (cl *prometheusClient) SetWebhookQueueDepth(value int) {
+	cl.webhookQueueDepth.Set(float64(value))
+}
+
+func (cl *prometheusClient) SetWebhookDeadLetters(value int) {
+	cl.webhookDeadLetters.Set(float64(value))
+}
+
+func (cl *prometheusClient) ObserveWebhookDeliveryLatency(targetName, actionName string, latency time.Duration) {
+	cl.webhookLatency.WithLabelValues(targetName, actionName).Observe(latency.Seconds())
+}
+
+func (cl *prometheusClient) SetTargetInFlightRequests(targetName string, value int) {
+	cl.targetInFlight.WithLabelValues(targetName).Set(float64(value))
+}
+
+func (cl *prometheusClient) SetTargetQueueDepth(targetName string, value int) {
+	cl.targetQueueDepth.WithLabelValues(targetName).Set(float64(value))
+}
+
+func (cl *prometheusClient) IncTargetRejectedRequests(targetName, reason string) {
+	cl.targetRejected.WithLabelValues(targetName, reason).Inc()
+}
+
 func (cl *prometheusClient) register() {
 	cl.reqCnt = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -162,6 +208,33 @@ func (cl *prometheusClient) register() {
 	)
 	prometheus.MustRegister(cl.authorizedTotal)
 
+	cl.authFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "authentication_failures_total",
+			Help: "How many authentications have failed ?",
+		},
+		[]string{"provider_type", "provider_name"},
+	)
+	prometheus.MustRegister(cl.authFailuresTotal)
+
+	cl.lockoutsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "authentication_lockouts_total",
+			Help: "How many lockouts have been started by brute force protection ?",
+		},
+		[]string{"provider_name", "scope"},
+	)
+	prometheus.MustRegister(cl.lockoutsTotal)
+
+	cl.lockedOutTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "authentication_locked_out_requests_total",
+			Help: "How many requests have been rejected because of an active lockout ?",
+		},
+		[]string{"provider_name", "scope"},
+	)
+	prometheus.MustRegister(cl.lockedOutTotal)
+
 	cl.succeedWebhooks = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "succeed_webhooks_total",
@@ -179,4 +252,57 @@ func (cl *prometheusClient) register() {
 		[]string{"target_name", "action_name"},
 	)
 	prometheus.MustRegister(cl.failedWebhooks)
+
+	cl.webhookQueueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "webhook_queue_depth",
+			Help: "How many webhook deliveries are waiting in the durable queue ?",
+		},
+	)
+	prometheus.MustRegister(cl.webhookQueueDepth)
+
+	cl.webhookDeadLetters = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "webhook_dead_letters",
+			Help: "How many webhook deliveries are stored in the dead letter store ?",
+		},
+	)
+	prometheus.MustRegister(cl.webhookDeadLetters)
+
+	cl.webhookLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "webhook_delivery_latency_seconds",
+			Help:    "The latency between webhook delivery creation in the durable queue and its success.",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 16),
+		},
+		[]string{"target_name", "action_name"},
+	)
+	prometheus.MustRegister(cl.webhookLatency)
+
+	cl.targetInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "target_in_flight_requests",
+			Help: "How many requests are in flight on target ?",
+		},
+		[]string{"target_name"},
+	)
+	prometheus.MustRegister(cl.targetInFlight)
+
+	cl.targetQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "target_queue_depth",
+			Help: "How many requests are waiting for a slot on target ?",
+		},
+		[]string{"target_name"},
+	)
+	prometheus.MustRegister(cl.targetQueueDepth)
+
+	cl.targetRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "target_rejected_requests_total",
+			Help: "How many requests have been rejected by target concurrency limits ?",
+		},
+		[]string{"target_name", "reason"},
+	)
+	prometheus.MustRegister(cl.targetRejected)
 }
@@ -0,0 +1,60 @@
+package quota
+
+import (
+	"context"
+
+	"emperror.dev/errors"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+)
+
+// ErrQuotaExceeded will be raised when an operation would exceed the user quota.
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// Usage represents the storage usage of a user isolation folder.
+type Usage struct {
+	Bytes   int64
+	Objects int64
+}
+
+// Manager will track storage usage of user isolation folders.
+// Folders are identified by their path relative to the bucket root prefix, without trailing slash
+// (the user identifier unless a user isolation path template is declared).
+//
+//go:generate mockgen -destination=./mocks/mock_Manager.go -package=mocks github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/quota Manager
+type Manager interface {
+	// GetUsage will return the current usage of a user isolation folder.
+	// The first call for a folder will compute it by listing the bucket.
+	GetUsage(ctx context.Context, targetKey, identifier string) (*Usage, error)
+	// Reserve will record the given delta if it fits in the limit.
+	// ErrQuotaExceeded is returned with the current usage otherwise.
+	Reserve(
+		ctx context.Context,
+		targetKey, identifier string,
+		limit *config.QuotaLimitConfig,
+		deltaBytes, deltaObjects int64,
+	) (*Usage, error)
+	// Update will record the given delta without any limit check.
+	// Nothing is done if the usage isn't tracked yet.
+	Update(targetKey, identifier string, deltaBytes, deltaObjects int64) *Usage
+	// Start will start the periodic reconciliation of tracked usages.
+	Start()
+	// Stop will stop the periodic reconciliation.
+	Stop()
+}
+
+// NewManager will return a new quota manager.
+func NewManager(
+	cfgManager config.Manager,
+	s3clientManager s3client.Manager,
+	logger log.Logger,
+) Manager {
+	return &manager{
+		cfgManager:      cfgManager,
+		s3clientManager: s3clientManager,
+		logger:          logger,
+		entries:         map[entryKey]*usageEntry{},
+	}
+}
@@ -0,0 +1,3 @@
+package quota
+
+// This package will manage user isolation storage quotas
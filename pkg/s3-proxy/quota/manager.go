@@ -0,0 +1,385 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/opentracing/opentracing-go"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+)
+
+// reconcileTickInterval is the interval used to check if some tracked usages must be reconciled.
+const reconcileTickInterval = 10 * time.Second
+
+type entryKey struct {
+	targetKey  string
+	identifier string
+}
+
+type usageEntry struct {
+	reconciledAt time.Time
+	lastAccessAt time.Time
+	usage        Usage
+	// drift stores deltas recorded while a reconciliation listing is running
+	// in order to apply them again on top of the listed usage.
+	drift       Usage
+	reconciling bool
+}
+
+type manager struct {
+	cfgManager      config.Manager
+	s3clientManager s3client.Manager
+	logger          log.Logger
+	entries         map[entryKey]*usageEntry
+	stopCh          chan struct{}
+	sfGroup         singleflight.Group
+	mutex           sync.Mutex
+}
+
+func (m *manager) GetUsage(ctx context.Context, targetKey, identifier string) (*Usage, error) {
+	// Get entry
+	e, err := m.getEntry(ctx, entryKey{targetKey: targetKey, identifier: identifier})
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Update last access
+	e.lastAccessAt = time.Now()
+
+	// Return a copy
+	return &Usage{Bytes: e.usage.Bytes, Objects: e.usage.Objects}, nil
+}
+
+func (m *manager) Reserve(
+	ctx context.Context,
+	targetKey, identifier string,
+	limit *config.QuotaLimitConfig,
+	deltaBytes, deltaObjects int64,
+) (*Usage, error) {
+	// Get entry
+	e, err := m.getEntry(ctx, entryKey{targetKey: targetKey, identifier: identifier})
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Update last access
+	e.lastAccessAt = time.Now()
+
+	// Check limit
+	err = checkLimit(&e.usage, limit, deltaBytes, deltaObjects)
+	// Check error
+	if err != nil {
+		return &Usage{Bytes: e.usage.Bytes, Objects: e.usage.Objects}, err
+	}
+
+	// Apply delta
+	applyDelta(e, deltaBytes, deltaObjects)
+
+	// Return a copy
+	return &Usage{Bytes: e.usage.Bytes, Objects: e.usage.Objects}, nil
+}
+
+func (m *manager) Update(targetKey, identifier string, deltaBytes, deltaObjects int64) *Usage {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Get entry
+	e := m.entries[entryKey{targetKey: targetKey, identifier: identifier}]
+	// Check if entry exists
+	// If not, it will be computed on next access from bucket listing
+	if e == nil {
+		return nil
+	}
+
+	// Apply delta
+	applyDelta(e, deltaBytes, deltaObjects)
+
+	// Return a copy
+	return &Usage{Bytes: e.usage.Bytes, Objects: e.usage.Objects}
+}
+
+func (m *manager) Start() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Check if already started
+	if m.stopCh != nil {
+		return
+	}
+
+	// Create stop channel
+	m.stopCh = make(chan struct{})
+
+	// Start loop
+	go m.run(m.stopCh)
+}
+
+func (m *manager) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Check if started
+	if m.stopCh == nil {
+		return
+	}
+
+	// Stop loop
+	close(m.stopCh)
+	m.stopCh = nil
+}
+
+func (m *manager) run(stopCh chan struct{}) {
+	// Create ticker
+	ticker := time.NewTicker(reconcileTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			m.reconcileAll()
+		}
+	}
+}
+
+// reconcileAll will reconcile all tracked usages that have reached their target reconcile interval.
+// Usages that haven't been accessed since their last reconciliation are forgotten instead.
+// They will be computed again from the bucket on their next access.
+func (m *manager) reconcileAll() {
+	// Get configuration
+	cfg := m.cfgManager.GetConfig()
+	// Get now
+	now := time.Now()
+	// Store keys to reconcile
+	keys := make([]entryKey, 0)
+
+	m.mutex.Lock()
+
+	// Loop over entries
+	for k, e := range m.entries {
+		// Get quota configuration
+		quotaCfg := getQuotaConfig(cfg, k.targetKey)
+		// Check if quota is still configured on target
+		if quotaCfg == nil {
+			delete(m.entries, k)
+
+			continue
+		}
+
+		// Check if reconcile is needed
+		if e.reconciling || now.Sub(e.reconciledAt) < quotaCfg.ReconcileInterval {
+			continue
+		}
+
+		// Check if entry have been accessed since last reconcile
+		if e.lastAccessAt.Before(e.reconciledAt) {
+			delete(m.entries, k)
+
+			continue
+		}
+
+		// Flag it
+		e.reconciling = true
+		e.drift = Usage{}
+		// Save key
+		keys = append(keys, k)
+	}
+
+	m.mutex.Unlock()
+
+	// Loop over keys
+	for _, k := range keys {
+		m.reconcile(k)
+	}
+}
+
+func (m *manager) reconcile(k entryKey) {
+	// Create span
+	span := opentracing.GlobalTracer().StartSpan("quota.reconcile")
+	defer span.Finish()
+
+	// Create logger
+	logger := m.logger.WithFields(map[string]any{
+		"target":     k.targetKey,
+		"identifier": k.identifier,
+	})
+	// Create context
+	ctx := log.SetLoggerInContext(opentracing.ContextWithSpan(context.Background(), span), logger)
+
+	// Compute usage
+	u, err := m.computeUsage(ctx, k)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Get entry
+	e := m.entries[k]
+	// Check if entry still exists
+	if e == nil {
+		return
+	}
+
+	// Remove flag
+	e.reconciling = false
+
+	// Check error
+	if err != nil {
+		logger.Error(err)
+
+		return
+	}
+
+	// Save usage with deltas recorded during listing
+	e.usage = Usage{
+		Bytes:   max(u.Bytes+e.drift.Bytes, 0),
+		Objects: max(u.Objects+e.drift.Objects, 0),
+	}
+	e.drift = Usage{}
+	e.reconciledAt = time.Now()
+
+	// Log
+	logger.Debugf("Quota usage reconciled: %d bytes and %d objects", e.usage.Bytes, e.usage.Objects)
+}
+
+func (m *manager) getEntry(ctx context.Context, k entryKey) (*usageEntry, error) {
+	m.mutex.Lock()
+	e := m.entries[k]
+	m.mutex.Unlock()
+
+	// Check if entry exists
+	if e != nil {
+		return e, nil
+	}
+
+	// Compute it only once for concurrent requests
+	res, err, _ := m.sfGroup.Do(k.targetKey+"\x00"+k.identifier, func() (any, error) {
+		m.mutex.Lock()
+		e2 := m.entries[k]
+		m.mutex.Unlock()
+
+		// Check if entry have been created in the meantime
+		if e2 != nil {
+			return e2, nil
+		}
+
+		// Compute usage
+		u, err := m.computeUsage(ctx, k)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+
+		// Get now
+		now := time.Now()
+		// Create entry
+		e2 = &usageEntry{
+			usage:        *u,
+			reconciledAt: now,
+			lastAccessAt: now,
+		}
+
+		m.mutex.Lock()
+		m.entries[k] = e2
+		m.mutex.Unlock()
+
+		return e2, nil
+	})
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Cast
+	e, _ = res.(*usageEntry)
+
+	return e, nil
+}
+
+// computeUsage will compute usage from the user isolation folder in bucket.
+// The folder is the same as the one injected in keys by the bucket requests:
+// bucket root prefix + user folder.
+func (m *manager) computeUsage(ctx context.Context, k entryKey) (*Usage, error) {
+	// Get target configuration
+	tgt := m.cfgManager.GetConfig().Targets[k.targetKey]
+	// Check if target exists
+	if tgt == nil {
+		return nil, errors.Errorf("target %s not found", k.targetKey)
+	}
+
+	// Get usage from bucket
+	out, _, err := m.s3clientManager.
+		GetClientForTarget(k.targetKey).
+		GetPrefixUsage(ctx, tgt.Bucket.GetRootPrefix()+k.identifier+"/")
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	return &Usage{Bytes: out.Size, Objects: out.Objects}, nil
+}
+
+func checkLimit(usage *Usage, limit *config.QuotaLimitConfig, deltaBytes, deltaObjects int64) error {
+	// Check if there is a limit
+	if limit == nil {
+		return nil
+	}
+
+	// Check bytes limit
+	// Only increases are checked to always allow to reduce the usage
+	if deltaBytes > 0 && limit.MaxBytes > 0 && usage.Bytes+deltaBytes > limit.MaxBytes {
+		return errors.Wrapf(
+			ErrQuotaExceeded,
+			"%d bytes requested while %d bytes are already used out of %d allowed",
+			deltaBytes, usage.Bytes, limit.MaxBytes,
+		)
+	}
+
+	// Check objects limit
+	if deltaObjects > 0 && limit.MaxObjects > 0 && usage.Objects+deltaObjects > limit.MaxObjects {
+		return errors.Wrapf(
+			ErrQuotaExceeded,
+			"%d objects are already stored out of %d allowed",
+			usage.Objects, limit.MaxObjects,
+		)
+	}
+
+	return nil
+}
+
+func applyDelta(e *usageEntry, deltaBytes, deltaObjects int64) {
+	// Apply delta
+	e.usage.Bytes = max(e.usage.Bytes+deltaBytes, 0)
+	e.usage.Objects = max(e.usage.Objects+deltaObjects, 0)
+
+	// Check if a reconciliation is running
+	if e.reconciling {
+		e.drift.Bytes += deltaBytes
+		e.drift.Objects += deltaObjects
+	}
+}
+
+func getQuotaConfig(cfg *config.Config, targetKey string) *config.UserIsolationQuotaConfig {
+	// Get target
+	tgt := cfg.Targets[targetKey]
+	// Check if quota is configured
+	if tgt == nil || tgt.Actions == nil || tgt.Actions.GET == nil || tgt.Actions.GET.Config == nil {
+		return nil
+	}
+
+	return tgt.Actions.GET.Config.UserIsolationQuota
+}
@@ -0,0 +1,230 @@
+//go:build unit
+
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	s3clientmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client/mocks"
+)
+
+func newTestQuotaConfig(quotaCfg *config.UserIsolationQuotaConfig) *config.Config {
+	return &config.Config{
+		Targets: map[string]*config.TargetConfig{
+			"target1": {
+				Name:   "target1",
+				Bucket: &config.BucketConfig{Name: "bucket", Prefix: "data/"},
+				Actions: &config.ActionsConfig{
+					GET: &config.GetActionConfig{
+						Enabled: true,
+						Config: &config.GetActionConfigConfig{
+							UserIsolation:      true,
+							UserIsolationQuota: quotaCfg,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newTestManager(
+	t *testing.T,
+	cfg *config.Config,
+) (*manager, *s3clientmocks.MockClient) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	s3clientMock := s3clientmocks.NewMockClient(ctrl)
+	s3clientManagerMock := s3clientmocks.NewMockManager(ctrl)
+	s3clientManagerMock.EXPECT().GetClientForTarget("target1").AnyTimes().Return(s3clientMock)
+
+	m, ok := NewManager(cfgManagerMock, s3clientManagerMock, log.NewLogger()).(*manager)
+	require.True(t, ok)
+
+	return m, s3clientMock
+}
+
+func Test_manager_Reserve(t *testing.T) {
+	tests := []struct {
+		wantErr      error
+		limit        *config.QuotaLimitConfig
+		want         *Usage
+		name         string
+		deltaBytes   int64
+		deltaObjects int64
+	}{
+		{
+			name:         "should accept when no limit is set",
+			deltaBytes:   1000,
+			deltaObjects: 1,
+			want:         &Usage{Bytes: 1010, Objects: 3},
+		},
+		{
+			name:         "should accept when delta fits in limit",
+			limit:        &config.QuotaLimitConfig{MaxBytes: 20, MaxObjects: 3},
+			deltaBytes:   10,
+			deltaObjects: 1,
+			want:         &Usage{Bytes: 20, Objects: 3},
+		},
+		{
+			name:         "should reject when bytes limit is exceeded",
+			limit:        &config.QuotaLimitConfig{MaxBytes: 15},
+			deltaBytes:   10,
+			deltaObjects: 1,
+			want:         &Usage{Bytes: 10, Objects: 2},
+			wantErr:      ErrQuotaExceeded,
+		},
+		{
+			name:         "should reject when objects limit is exceeded",
+			limit:        &config.QuotaLimitConfig{MaxObjects: 2},
+			deltaBytes:   1,
+			deltaObjects: 1,
+			want:         &Usage{Bytes: 10, Objects: 2},
+			wantErr:      ErrQuotaExceeded,
+		},
+		{
+			name:         "should always accept reductions",
+			limit:        &config.QuotaLimitConfig{MaxBytes: 1, MaxObjects: 1},
+			deltaBytes:   -5,
+			deltaObjects: -1,
+			want:         &Usage{Bytes: 5, Objects: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, s3clientMock := newTestManager(t, newTestQuotaConfig(&config.UserIsolationQuotaConfig{ReconcileInterval: time.Hour}))
+
+			s3clientMock.EXPECT().
+				GetPrefixUsage(gomock.Any(), "data/alice/").
+				Times(1).
+				Return(&s3client.PrefixUsageOutput{Size: 10, Objects: 2}, nil, nil)
+
+			got, err := m.Reserve(context.TODO(), "target1", "alice", tt.limit, tt.deltaBytes, tt.deltaObjects)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_manager_GetUsage_ComputedOnce(t *testing.T) {
+	m, s3clientMock := newTestManager(t, newTestQuotaConfig(&config.UserIsolationQuotaConfig{ReconcileInterval: time.Hour}))
+
+	s3clientMock.EXPECT().
+		GetPrefixUsage(gomock.Any(), "data/alice/").
+		Times(1).
+		Return(&s3client.PrefixUsageOutput{Size: 10, Objects: 2}, nil, nil)
+
+	got, err := m.GetUsage(context.TODO(), "target1", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, &Usage{Bytes: 10, Objects: 2}, got)
+
+	// Update must be applied on tracked usage without any listing
+	assert.Equal(t, &Usage{Bytes: 15, Objects: 3}, m.Update("target1", "alice", 5, 1))
+
+	got, err = m.GetUsage(context.TODO(), "target1", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, &Usage{Bytes: 15, Objects: 3}, got)
+
+	// Usage must never be negative
+	assert.Equal(t, &Usage{Bytes: 0, Objects: 0}, m.Update("target1", "alice", -100, -100))
+}
+
+func Test_manager_GetUsage_Error(t *testing.T) {
+	m, s3clientMock := newTestManager(t, newTestQuotaConfig(&config.UserIsolationQuotaConfig{ReconcileInterval: time.Hour}))
+
+	s3clientMock.EXPECT().
+		GetPrefixUsage(gomock.Any(), "data/alice/").
+		Times(2).
+		Return(nil, nil, errors.New("fake"))
+
+	_, err := m.GetUsage(context.TODO(), "target1", "alice")
+	assert.EqualError(t, err, "fake")
+
+	// Failed computations mustn't be cached
+	_, err = m.GetUsage(context.TODO(), "target1", "alice")
+	assert.EqualError(t, err, "fake")
+}
+
+func Test_manager_Update_Untracked(t *testing.T) {
+	m, _ := newTestManager(t, newTestQuotaConfig(&config.UserIsolationQuotaConfig{ReconcileInterval: time.Hour}))
+
+	assert.Nil(t, m.Update("target1", "alice", 5, 1))
+}
+
+func Test_manager_reconcileAll(t *testing.T) {
+	m, s3clientMock := newTestManager(t, newTestQuotaConfig(&config.UserIsolationQuotaConfig{ReconcileInterval: time.Minute}))
+
+	now := time.Now()
+	m.entries[entryKey{targetKey: "target1", identifier: "alice"}] = &usageEntry{
+		usage:        Usage{Bytes: 50, Objects: 5},
+		reconciledAt: now.Add(-2 * time.Minute),
+		lastAccessAt: now.Add(-time.Minute),
+	}
+	m.entries[entryKey{targetKey: "target1", identifier: "bob"}] = &usageEntry{
+		usage:        Usage{Bytes: 50, Objects: 5},
+		reconciledAt: now.Add(-2 * time.Minute),
+		lastAccessAt: now.Add(-3 * time.Minute),
+	}
+	m.entries[entryKey{targetKey: "target1", identifier: "charlie"}] = &usageEntry{
+		usage:        Usage{Bytes: 50, Objects: 5},
+		reconciledAt: now,
+		lastAccessAt: now,
+	}
+	m.entries[entryKey{targetKey: "target2", identifier: "alice"}] = &usageEntry{
+		reconciledAt: now,
+		lastAccessAt: now,
+	}
+
+	s3clientMock.EXPECT().
+		GetPrefixUsage(gomock.Any(), "data/alice/").
+		Times(1).
+		Return(&s3client.PrefixUsageOutput{Size: 10, Objects: 2}, nil, nil)
+
+	m.reconcileAll()
+
+	// alice has been reconciled
+	alice := m.entries[entryKey{targetKey: "target1", identifier: "alice"}]
+	require.NotNil(t, alice)
+	assert.Equal(t, Usage{Bytes: 10, Objects: 2}, alice.usage)
+	assert.False(t, alice.reconciling)
+	// bob wasn't accessed since last reconcile and is forgotten
+	assert.NotContains(t, m.entries, entryKey{targetKey: "target1", identifier: "bob"})
+	// charlie doesn't need a reconcile yet
+	assert.Equal(t, Usage{Bytes: 50, Objects: 5}, m.entries[entryKey{targetKey: "target1", identifier: "charlie"}].usage)
+	// target2 doesn't exist anymore
+	assert.NotContains(t, m.entries, entryKey{targetKey: "target2", identifier: "alice"})
+}
+
+func Test_manager_StartStop(t *testing.T) {
+	m, _ := newTestManager(t, newTestQuotaConfig(nil))
+
+	m.Start()
+	assert.NotNil(t, m.stopCh)
+	// Calling it twice must be a no-op
+	m.Start()
+
+	m.Stop()
+	assert.Nil(t, m.stopCh)
+	// Calling it twice must be a no-op
+	m.Stop()
+}
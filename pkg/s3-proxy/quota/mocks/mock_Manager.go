@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/quota (interfaces: Manager)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_Manager.go -package=mocks github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/quota Manager
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	config "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	quota "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/quota"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockManager is a mock of Manager interface.
+type MockManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockManagerMockRecorder
+	isgomock struct{}
+}
+
+// MockManagerMockRecorder is the mock recorder for MockManager.
+type MockManagerMockRecorder struct {
+	mock *MockManager
+}
+
+// NewMockManager creates a new mock instance.
+func NewMockManager(ctrl *gomock.Controller) *MockManager {
+	mock := &MockManager{ctrl: ctrl}
+	mock.recorder = &MockManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockManager) EXPECT() *MockManagerMockRecorder {
+	return m.recorder
+}
+
+// GetUsage mocks base method.
+func (m *MockManager) GetUsage(ctx context.Context, targetKey, identifier string) (*quota.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsage", ctx, targetKey, identifier)
+	ret0, _ := ret[0].(*quota.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsage indicates an expected call of GetUsage.
+func (mr *MockManagerMockRecorder) GetUsage(ctx, targetKey, identifier any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsage", reflect.TypeOf((*MockManager)(nil).GetUsage), ctx, targetKey, identifier)
+}
+
+// Reserve mocks base method.
+func (m *MockManager) Reserve(ctx context.Context, targetKey, identifier string, limit *config.QuotaLimitConfig, deltaBytes, deltaObjects int64) (*quota.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, targetKey, identifier, limit, deltaBytes, deltaObjects)
+	ret0, _ := ret[0].(*quota.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reserve indicates an expected call of Reserve.
+func (mr *MockManagerMockRecorder) Reserve(ctx, targetKey, identifier, limit, deltaBytes, deltaObjects any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockManager)(nil).Reserve), ctx, targetKey, identifier, limit, deltaBytes, deltaObjects)
+}
+
+// Start mocks base method.
+func (m *MockManager) Start() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Start")
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockManagerMockRecorder) Start() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockManager)(nil).Start))
+}
+
+// Stop mocks base method.
+func (m *MockManager) Stop() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Stop")
+}
+
+// Stop indicates an expected call of Stop.
+func (mr *MockManagerMockRecorder) Stop() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockManager)(nil).Stop))
+}
+
+// Update mocks base method.
+func (m *MockManager) Update(targetKey, identifier string, deltaBytes, deltaObjects int64) *quota.Usage {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", targetKey, identifier, deltaBytes, deltaObjects)
+	ret0, _ := ret[0].(*quota.Usage)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockManagerMockRecorder) Update(targetKey, identifier, deltaBytes, deltaObjects any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockManager)(nil).Update), targetKey, identifier, deltaBytes, deltaObjects)
+}
@@ -62,6 +62,16 @@ type ResponseHandler interface {
 		loadFileContent func(ctx context.Context, path string) (string, error),
 		err error,
 	)
+	// QuotaExceededError will answer for quota exceeded error.
+	QuotaExceededError(
+		loadFileContent func(ctx context.Context, path string) (string, error),
+		err error,
+	)
+	// ServiceUnavailableError will answer for service unavailable error.
+	ServiceUnavailableError(
+		loadFileContent func(ctx context.Context, path string) (string, error),
+		err error,
+	)
 	// InternalServerError will answer for internal server error.
 	InternalServerError(
 		loadFileContent func(ctx context.Context, path string) (string, error),
@@ -72,6 +82,8 @@ type ResponseHandler interface {
 	UpdateRequestAndResponse(req *http.Request, res http.ResponseWriter)
 	// GetRequest will return the actual request object.
 	GetRequest() *http.Request
+	// SetQuotaUsage will save the user isolation quota usage in order to expose it in templates.
+	SetQuotaUsage(usage *models.QuotaUsage)
 }
 
 // NewHandler will return a new response handler object.
@@ -12,7 +12,6 @@ import (
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler/models"
-	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler/models/converter"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/utils/templateutils"
 )
 
@@ -154,6 +153,80 @@ func (h *handler) ForbiddenError(
 	)
 }
 
+func (h *handler) QuotaExceededError(
+	loadFileContent func(ctx context.Context, path string) (string, error),
+	err error,
+) {
+	// Get configuration
+	cfg := h.cfgManager.GetConfig()
+
+	// Variable to save target template configuration item override
+	var tplCfgItem *config.TargetTemplateConfigItem
+
+	// Store helpers template configs
+	var helpersCfgItems []*config.TargetHelperConfigItem
+
+	// Check if a target has been involve in this request
+	if h.targetKey != "" {
+		// Get target from key
+		targetCfg := cfg.Targets[h.targetKey]
+		// Check if have a template override
+		if targetCfg != nil &&
+			targetCfg.Templates != nil {
+			// Save override
+			tplCfgItem = targetCfg.Templates.QuotaExceededError
+			helpersCfgItems = targetCfg.Templates.Helpers
+		}
+	}
+
+	// Call generic template handler
+	h.handleGenericErrorTemplate(
+		loadFileContent,
+		err,
+		tplCfgItem,
+		helpersCfgItems,
+		cfg.Templates.QuotaExceededError,
+		cfg.Templates.Helpers,
+	)
+}
+
+func (h *handler) ServiceUnavailableError(
+	loadFileContent func(ctx context.Context, path string) (string, error),
+	err error,
+) {
+	// Get configuration
+	cfg := h.cfgManager.GetConfig()
+
+	// Variable to save target template configuration item override
+	var tplCfgItem *config.TargetTemplateConfigItem
+
+	// Store helpers template configs
+	var helpersCfgItems []*config.TargetHelperConfigItem
+
+	// Check if a target has been involve in this request
+	if h.targetKey != "" {
+		// Get target from key
+		targetCfg := cfg.Targets[h.targetKey]
+		// Check if have a template override
+		if targetCfg != nil &&
+			targetCfg.Templates != nil {
+			// Save override
+			tplCfgItem = targetCfg.Templates.ServiceUnavailableError
+			helpersCfgItems = targetCfg.Templates.Helpers
+		}
+	}
+
+	// Call generic template handler
+	h.handleGenericErrorTemplate(
+		loadFileContent,
+		err,
+		tplCfgItem,
+		helpersCfgItems,
+		cfg.Templates.ServiceUnavailableError,
+		cfg.Templates.Helpers,
+	)
+}
+
 func (h *handler) NotFoundError(
 	loadFileContent func(ctx context.Context, path string) (string, error),
 ) {
@@ -247,9 +320,10 @@ func (h *handler) handleGenericErrorTemplate(
 
 	// Create data
 	data := &models.ErrorData{
-		Request: converter.ConvertAndSanitizeHTTPRequest(h.req),
+		Request: h.sanitizedRequest(),
 		User:    authxmodels.GetAuthenticatedUserFromContext(h.req.Context()),
 		Error:   err,
+		Quota:   h.quotaUsage,
 	}
 
 	h.handleGenericAnswer(
@@ -307,9 +381,10 @@ func (h *handler) InternalServerError(
 	)
 	// Create data
 	data := &models.ErrorData{
-		Request: converter.ConvertAndSanitizeHTTPRequest(h.req),
+		Request: h.sanitizedRequest(),
 		User:    authxmodels.GetAuthenticatedUserFromContext(h.req.Context()),
 		Error:   err,
+		Quota:   h.quotaUsage,
 	}
 
 	// Store headers
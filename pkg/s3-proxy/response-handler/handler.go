@@ -7,9 +7,11 @@ import (
 	"strings"
 
 	"emperror.dev/errors"
+	"github.com/go-chi/chi/v5/middleware"
 
 	authxmodels "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/geoip"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler/models"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler/models/converter"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/utils/templateutils"
@@ -19,6 +21,7 @@ type handler struct {
 	req            *http.Request
 	res            http.ResponseWriter
 	cfgManager     config.Manager
+	quotaUsage     *models.QuotaUsage
 	targetKey      string
 	headAnswerMode bool
 }
@@ -38,6 +41,25 @@ func (h *handler) UpdateRequestAndResponse(req *http.Request, res http.ResponseW
 	h.res = res
 }
 
+func (h *handler) SetQuotaUsage(usage *models.QuotaUsage) {
+	h.quotaUsage = usage
+}
+
+// sanitizedRequest will return the sanitized request with client ip and country.
+func (h *handler) sanitizedRequest() *models.LightSanitizedRequest {
+	// Convert request
+	req := converter.ConvertAndSanitizeHTTPRequest(h.req)
+	// Check if request exists
+	if req == nil {
+		return nil
+	}
+	// Add client ip and country from request context
+	req.ClientIP = middleware.GetClientIP(h.req.Context())
+	req.Country = geoip.GetCountryFromContext(h.req.Context())
+
+	return req
+}
+
 func (h *handler) PreconditionFailed() {
 	h.res.WriteHeader(http.StatusPreconditionFailed)
 }
@@ -75,9 +97,10 @@ func (h *handler) Put(
 
 	// Create data
 	data := &models.PutData{
-		Request: converter.ConvertAndSanitizeHTTPRequest(h.req),
+		Request: h.sanitizedRequest(),
 		User:    authxmodels.GetAuthenticatedUserFromContext(h.req.Context()),
 		PutData: input,
+		Quota:   h.quotaUsage,
 	}
 
 	// Call generic template handler
@@ -120,9 +143,10 @@ func (h *handler) Delete(
 
 	// Create data
 	data := &models.DeleteData{
-		Request:    converter.ConvertAndSanitizeHTTPRequest(h.req),
+		Request:    h.sanitizedRequest(),
 		User:       authxmodels.GetAuthenticatedUserFromContext(h.req.Context()),
 		DeleteData: input,
+		Quota:      h.quotaUsage,
 	}
 
 	// Call generic template handler
@@ -148,7 +172,7 @@ func (h *handler) TargetList() {
 
 	// Create data structure
 	data := &models.TargetListData{
-		Request: converter.ConvertAndSanitizeHTTPRequest(h.req),
+		Request: h.sanitizedRequest(),
 		User:    authxmodels.GetAuthenticatedUserFromContext(h.req.Context()),
 		Targets: targets,
 	}
@@ -218,7 +242,7 @@ func (h *handler) StreamFile(
 
 		// Create data structure
 		data := &models.StreamFileHeaderData{
-			Request:    converter.ConvertAndSanitizeHTTPRequest(h.req),
+			Request:    h.sanitizedRequest(),
 			User:       authxmodels.GetAuthenticatedUserFromContext(h.req.Context()),
 			StreamFile: input,
 		}
@@ -276,11 +300,12 @@ func (h *handler) FoldersFilesList(
 
 	// Create bucket list data for templating
 	data := &models.FolderListingData{
-		Request:    converter.ConvertAndSanitizeHTTPRequest(h.req),
+		Request:    h.sanitizedRequest(),
 		User:       authxmodels.GetAuthenticatedUserFromContext(h.req.Context()),
 		Entries:    entries,
 		BucketName: targetCfg.Bucket.Name,
 		Name:       targetCfg.Name,
+		Quota:      h.quotaUsage,
 	}
 
 	h.handleGenericAnswer(
@@ -164,6 +164,18 @@ func (mr *MockResponseHandlerMockRecorder) Put(loadFileContent, input any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockResponseHandler)(nil).Put), loadFileContent, input)
 }
 
+// QuotaExceededError mocks base method.
+func (m *MockResponseHandler) QuotaExceededError(loadFileContent func(context.Context, string) (string, error), err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "QuotaExceededError", loadFileContent, err)
+}
+
+// QuotaExceededError indicates an expected call of QuotaExceededError.
+func (mr *MockResponseHandlerMockRecorder) QuotaExceededError(loadFileContent, err any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuotaExceededError", reflect.TypeOf((*MockResponseHandler)(nil).QuotaExceededError), loadFileContent, err)
+}
+
 // RedirectTo mocks base method.
 func (m *MockResponseHandler) RedirectTo(url string) {
 	m.ctrl.T.Helper()
@@ -188,6 +200,30 @@ func (mr *MockResponseHandlerMockRecorder) RedirectWithTrailingSlash() *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedirectWithTrailingSlash", reflect.TypeOf((*MockResponseHandler)(nil).RedirectWithTrailingSlash))
 }
 
+// ServiceUnavailableError mocks base method.
+func (m *MockResponseHandler) ServiceUnavailableError(loadFileContent func(context.Context, string) (string, error), err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ServiceUnavailableError", loadFileContent, err)
+}
+
+// ServiceUnavailableError indicates an expected call of ServiceUnavailableError.
+func (mr *MockResponseHandlerMockRecorder) ServiceUnavailableError(loadFileContent, err any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServiceUnavailableError", reflect.TypeOf((*MockResponseHandler)(nil).ServiceUnavailableError), loadFileContent, err)
+}
+
+// SetQuotaUsage mocks base method.
+func (m *MockResponseHandler) SetQuotaUsage(usage *models.QuotaUsage) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetQuotaUsage", usage)
+}
+
+// SetQuotaUsage indicates an expected call of SetQuotaUsage.
+func (mr *MockResponseHandlerMockRecorder) SetQuotaUsage(usage any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetQuotaUsage", reflect.TypeOf((*MockResponseHandler)(nil).SetQuotaUsage), usage)
+}
+
 // StreamFile mocks base method.
 func (m *MockResponseHandler) StreamFile(loadFileContent func(context.Context, string) (string, error), input *models.StreamInput) error {
 	m.ctrl.T.Helper()
@@ -17,6 +17,9 @@ import (
 // goverter:output:package github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler/models/converter
 // goverter:extend sanitizeString
 var (
+	// Client ip and country are added from request context after conversion.
+	// goverter:ignore ClientIP
+	// goverter:ignore Country
 	ConvertAndSanitizeHTTPRequest func(source *http.Request) *models.LightSanitizedRequest
 	// goverter:map User | sanitizeURLUserInfo
 	sanitizeURL func(source *url.URL) *url.URL
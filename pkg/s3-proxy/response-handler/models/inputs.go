@@ -30,6 +30,7 @@ type StreamInput struct {
 	ContentType        string
 	ContentDigest      string
 	ETag               string
+	Vary               string
 	ContentLength      int64
 }
 
@@ -47,3 +48,12 @@ type PutInput struct {
 type DeleteInput struct {
 	Key string
 }
+
+// QuotaUsage represents the user isolation quota usage.
+// Max values equal to 0 mean unlimited.
+type QuotaUsage struct {
+	UsedBytes   int64
+	UsedObjects int64
+	MaxBytes    int64
+	MaxObjects  int64
+}
@@ -8,6 +8,7 @@ import (
 type FolderListingData struct {
 	User       authxmodels.GenericUser
 	Request    *LightSanitizedRequest
+	Quota      *QuotaUsage
 	BucketName string
 	Name       string
 	Entries    []*Entry
@@ -18,6 +19,7 @@ type ErrorData struct {
 	Request *LightSanitizedRequest
 	User    authxmodels.GenericUser
 	Error   error
+	Quota   *QuotaUsage
 }
 
 // targetListData represents the structure used by target list templating.
@@ -39,6 +41,7 @@ type PutData struct {
 	Request *LightSanitizedRequest
 	User    authxmodels.GenericUser
 	PutData *PutInput
+	Quota   *QuotaUsage
 }
 
 // deleteData represents the structure used by delete templating.
@@ -46,4 +49,5 @@ type DeleteData struct {
 	Request    *LightSanitizedRequest
 	User       authxmodels.GenericUser
 	DeleteData *DeleteInput
+	Quota      *QuotaUsage
 }
@@ -10,6 +10,8 @@ type LightSanitizedRequest struct {
 	Header           http.Header
 	Trailer          http.Header
 	RemoteAddr       string
+	ClientIP         string
+	Country          string
 	Method           string
 	Proto            string
 	Pattern          string
@@ -113,6 +113,7 @@ func setHeadersFromObjectOutput(w http.ResponseWriter, obj *models.StreamInput)
 	setStrHeader(w, "Content-Range", obj.ContentRange)
 	setStrHeader(w, "Content-Type", obj.ContentType)
 	setStrHeader(w, "ETag", obj.ETag)
+	setStrHeader(w, "Vary", obj.Vary)
 	setStrHeader(w, "Accept-Ranges", "bytes")
 	setTimeHeader(w, "Last-Modified", obj.LastModified)
 
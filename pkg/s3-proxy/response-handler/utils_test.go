@@ -168,6 +168,7 @@ func Test_setHeadersFromObjectOutput(t *testing.T) {
 	headerFullInput.Add("Content-Range", "bytes 0-199/200")
 	headerFullInput.Add("Content-Type", "contenttype")
 	headerFullInput.Add("ETag", "etag")
+	headerFullInput.Add("Vary", "Accept-Encoding")
 	headerFullInput.Add("Accept-Ranges", "bytes")
 	headerFullInput.Add("Last-Modified", now.UTC().Format(http.TimeFormat))
 	headerPartialInput := http.Header{}
@@ -213,6 +214,7 @@ func Test_setHeadersFromObjectOutput(t *testing.T) {
 					ContentRange:       "bytes 0-199/200",
 					ContentType:        "contenttype",
 					ETag:               "etag",
+					Vary:               "Accept-Encoding",
 					LastModified:       now,
 				},
 			},
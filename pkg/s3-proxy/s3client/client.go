@@ -37,6 +37,8 @@ type Client interface {
 	DeleteObject(ctx context.Context, key string) (*ResultInfo, error)
 	// GetObjectSignedURL will return a signed url for a get object.
 	GetObjectSignedURL(ctx context.Context, input *GetInput, expiration time.Duration) (string, error)
+	// GetPrefixUsage will compute the total size and number of objects under a prefix (recursively).
+	GetPrefixUsage(ctx context.Context, prefix string) (*PrefixUsageOutput, *ResultInfo, error)
 }
 
 // ResultInfo ResultInfo structure.
@@ -63,6 +65,12 @@ type ListElementOutput struct {
 	Size         int64
 }
 
+// PrefixUsageOutput represents the storage usage under a prefix.
+type PrefixUsageOutput struct {
+	Size    int64
+	Objects int64
+}
+
 type BaseFileOutput struct {
 	LastModified       time.Time
 	Metadata           map[string]string
@@ -88,6 +88,22 @@ func (mr *MockClientMockRecorder) GetObjectSignedURL(ctx, input, expiration any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetObjectSignedURL", reflect.TypeOf((*MockClient)(nil).GetObjectSignedURL), ctx, input, expiration)
 }
 
+// GetPrefixUsage mocks base method.
+func (m *MockClient) GetPrefixUsage(ctx context.Context, prefix string) (*s3client.PrefixUsageOutput, *s3client.ResultInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPrefixUsage", ctx, prefix)
+	ret0, _ := ret[0].(*s3client.PrefixUsageOutput)
+	ret1, _ := ret[1].(*s3client.ResultInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetPrefixUsage indicates an expected call of GetPrefixUsage.
+func (mr *MockClientMockRecorder) GetPrefixUsage(ctx, prefix any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrefixUsage", reflect.TypeOf((*MockClient)(nil).GetPrefixUsage), ctx, prefix)
+}
+
 // HeadObject mocks base method.
 func (m *MockClient) HeadObject(ctx context.Context, key string) (*s3client.HeadOutput, *s3client.ResultInfo, error) {
 	m.ctrl.T.Helper()
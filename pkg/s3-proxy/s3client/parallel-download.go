@@ -0,0 +1,398 @@
+package s3client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"emperror.dev/errors"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const invalidRangeErrorCode = "InvalidRange"
+
+// getObjectParallel Get object from S3 bucket using concurrent ranged requests.
+// The first part is requested with all client conditional headers and is used to discover
+// the object size and ETag. Other parts are requested in parallel with an If-Match on this ETag
+// and reassembled in order. Memory is bounded to concurrency * part size.
+func (s3cl *s3client) getObjectParallel(
+	ctx context.Context,
+	input *GetInput,
+	s3Input *s3.GetObjectInput,
+	requestHeaders map[string]string,
+) (*GetOutput, error) {
+	// Compute part size
+	partSize := max(s3cl.target.Bucket.S3DownloadPartSize, 1) * oneMega
+	// Init range
+	var start, end int64 = 0, -1
+	// Check if a range was asked
+	if input.Range != "" {
+		var ok bool
+		// Parse range
+		start, end, ok = parseSingleByteRange(input.Range)
+		// Check if range can be managed in parallel
+		// Suffix and multiple ranges are forwarded as is
+		if !ok {
+			return s3cl.getObjectSingleStream(ctx, s3Input, requestHeaders)
+		}
+	}
+
+	// Compute first part end
+	firstEnd := start + partSize - 1
+	if end >= 0 && firstEnd > end {
+		firstEnd = end
+	}
+
+	// Copy input to request first part
+	firstInput := *s3Input
+	firstInput.Range = new(formatByteRange(start, firstEnd))
+
+	obj, err := s3cl.doGetObject(ctx, &firstInput, requestHeaders)
+	// Check error
+	if err != nil {
+		// Check if it is an invalid range without any client range
+		// This means that object is empty, so let single stream manage it
+		//nolint: errorlint // Cast
+		aerr, ok := err.(awserr.Error)
+		if ok && aerr.Code() == invalidRangeErrorCode && input.Range == "" {
+			return s3cl.getObjectSingleStream(ctx, s3Input, requestHeaders)
+		}
+
+		return nil, transformGetObjectError(err)
+	}
+
+	// Build output
+	output := buildGetOutput(obj)
+	// Checksums are computed on the whole object and cannot be validated on reassembled parts
+	output.ContentDigest = ""
+
+	// Get total size from content range
+	total, ok := parseContentRangeTotal(output.ContentRange)
+	// Check if size is known
+	// If not, S3 have ignored the range and sent the whole object
+	if !ok {
+		return output, nil
+	}
+
+	// Compute last byte to send
+	lastByte := total - 1
+	if end >= 0 && end < lastByte {
+		lastByte = end
+	}
+
+	// Fix content length and range
+	output.ContentLength = lastByte - start + 1
+	// Check if client asked a range
+	if input.Range == "" {
+		output.ContentRange = ""
+	} else {
+		output.ContentRange = fmt.Sprintf("bytes %d-%d/%d", start, lastByte, total)
+	}
+
+	// Check if first part is enough
+	if firstEnd >= lastByte {
+		return output, nil
+	}
+
+	// Build parts list
+	parts := []*byteRange{}
+	for partStart := firstEnd + 1; partStart <= lastByte; partStart += partSize {
+		parts = append(parts, &byteRange{
+			start: partStart,
+			end:   min(partStart+partSize-1, lastByte),
+		})
+	}
+
+	// Build part input
+	partInput := &s3.GetObjectInput{
+		Bucket: s3Input.Bucket,
+		Key:    s3Input.Key,
+	}
+	// Ensure that object haven't changed between parts
+	if obj.ETag != nil {
+		partInput.IfMatch = obj.ETag
+	}
+
+	// Create parallel reader
+	output.Body = newParallelReader(
+		ctx,
+		obj.Body,
+		parts,
+		max(s3cl.target.Bucket.S3DownloadConcurrency, 1),
+		func(ctx context.Context, br *byteRange) (io.ReadCloser, error) {
+			// Copy input
+			inp := *partInput
+			inp.Range = new(formatByteRange(br.start, br.end))
+
+			// Get part
+			res, err := s3cl.doGetObject(ctx, &inp, requestHeaders)
+			// Check error
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			return res.Body, nil
+		},
+	)
+
+	return output, nil
+}
+
+// byteRange represents an inclusive byte range.
+type byteRange struct {
+	start int64
+	end   int64
+}
+
+// size returns the number of bytes in range.
+func (br *byteRange) size() int64 {
+	return br.end - br.start + 1
+}
+
+// formatByteRange will format a byte range for a Range header.
+func formatByteRange(start, end int64) string {
+	return fmt.Sprintf("bytes=%d-%d", start, end)
+}
+
+// parseSingleByteRange will parse a single "bytes=start-end" or "bytes=start-" range.
+// End will be -1 when range is open.
+// Suffix ranges, multiple ranges and invalid values won't be parsed.
+func parseSingleByteRange(rg string) (start, end int64, ok bool) {
+	// Check prefix
+	spec, found := strings.CutPrefix(strings.TrimSpace(rg), "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+
+	// Split
+	startStr, endStr, found := strings.Cut(spec, "-")
+	if !found || startStr == "" {
+		return 0, 0, false
+	}
+
+	// Parse start
+	start, err := strconv.ParseInt(strings.TrimSpace(startStr), 10, 64)
+	// Check error
+	if err != nil || start < 0 {
+		return 0, 0, false
+	}
+
+	// Check if range is open
+	if strings.TrimSpace(endStr) == "" {
+		return start, -1, true
+	}
+
+	// Parse end
+	end, err = strconv.ParseInt(strings.TrimSpace(endStr), 10, 64)
+	// Check error
+	if err != nil || end < start {
+		return 0, 0, false
+	}
+
+	return start, end, true
+}
+
+// parseContentRangeTotal will parse the total size from a "bytes start-end/total" content range.
+func parseContentRangeTotal(cr string) (int64, bool) {
+	// Get total part
+	_, totalStr, found := strings.Cut(cr, "/")
+	if !found || totalStr == "*" {
+		return 0, false
+	}
+
+	// Parse
+	total, err := strconv.ParseInt(totalStr, 10, 64)
+	// Check error
+	if err != nil || total < 0 {
+		return 0, false
+	}
+
+	return total, true
+}
+
+// partFetcher will open a stream on a byte range.
+type partFetcher func(ctx context.Context, br *byteRange) (io.ReadCloser, error)
+
+// partResult is a part downloaded in background.
+type partResult struct {
+	done chan struct{}
+	err  error
+	data []byte
+}
+
+// parallelReader will read a first stream and then parts downloaded in background, in order.
+type parallelReader struct {
+	ctx       context.Context //nolint:containedctx // Needed to stop background downloads
+	cancel    context.CancelFunc
+	first     io.ReadCloser
+	current   *partResult
+	futures   chan *partResult
+	sem       chan struct{}
+	closeOnce sync.Once
+	offset    int
+}
+
+func newParallelReader(
+	ctx context.Context,
+	first io.ReadCloser,
+	parts []*byteRange,
+	concurrency int,
+	fetch partFetcher,
+) *parallelReader {
+	// Create cancellable context
+	ctx, cancel := context.WithCancel(ctx)
+
+	pr := &parallelReader{
+		ctx:     ctx,
+		cancel:  cancel,
+		first:   first,
+		futures: make(chan *partResult, concurrency),
+		sem:     make(chan struct{}, concurrency),
+	}
+
+	// Start producer
+	go pr.produce(parts, fetch)
+
+	return pr
+}
+
+// produce will start downloads in order, waiting for a free slot before each one.
+// A slot is freed only when part has been read.
+func (pr *parallelReader) produce(parts []*byteRange, fetch partFetcher) {
+	defer close(pr.futures)
+
+	for _, br := range parts {
+		// Wait for a free slot
+		select {
+		case pr.sem <- struct{}{}:
+		case <-pr.ctx.Done():
+			return
+		}
+
+		res := &partResult{done: make(chan struct{})}
+
+		// Start download
+		go func(br *byteRange, res *partResult) {
+			defer close(res.done)
+
+			res.data, res.err = downloadPart(pr.ctx, br, fetch)
+		}(br, res)
+
+		// Register future
+		select {
+		case pr.futures <- res:
+		case <-pr.ctx.Done():
+			return
+		}
+	}
+}
+
+// downloadPart will download a part fully in memory.
+func downloadPart(ctx context.Context, br *byteRange, fetch partFetcher) ([]byte, error) {
+	body, err := fetch(ctx, br)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	defer body.Close()
+
+	// Read exactly the part size
+	data := make([]byte, br.size())
+
+	_, err = io.ReadFull(body, data)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return data, nil
+}
+
+// Read implements io.Reader.
+func (pr *parallelReader) Read(p []byte) (int, error) {
+	// Read first stream until its end
+	if pr.first != nil {
+		n, err := pr.first.Read(p)
+		// Check if first stream is fully read
+		if errors.Is(err, io.EOF) {
+			// Close it
+			_ = pr.first.Close()
+			pr.first = nil
+
+			// Return read data
+			if n > 0 {
+				return n, nil
+			}
+		} else {
+			return n, err
+		}
+	}
+
+	for {
+		// Check if current part still have data
+		if pr.current != nil && pr.offset < len(pr.current.data) {
+			n := copy(p, pr.current.data[pr.offset:])
+			pr.offset += n
+
+			return n, nil
+		}
+
+		// Current part is fully read, release it
+		if pr.current != nil {
+			pr.current = nil
+			<-pr.sem
+		}
+
+		// Get next part
+		var res *partResult
+
+		select {
+		case r, ok := <-pr.futures:
+			// Check if all parts are read
+			if !ok {
+				return 0, io.EOF
+			}
+
+			res = r
+		case <-pr.ctx.Done():
+			return 0, errors.WithStack(pr.ctx.Err())
+		}
+
+		// Wait for download end
+		select {
+		case <-res.done:
+		case <-pr.ctx.Done():
+			return 0, errors.WithStack(pr.ctx.Err())
+		}
+
+		// Check error
+		if res.err != nil {
+			return 0, res.err
+		}
+
+		pr.current = res
+		pr.offset = 0
+	}
+}
+
+// Close implements io.Closer and stops all background downloads.
+func (pr *parallelReader) Close() error {
+	var err error
+
+	pr.closeOnce.Do(func() {
+		pr.cancel()
+
+		// Close first stream if not fully read
+		if pr.first != nil {
+			err = pr.first.Close()
+			pr.first = nil
+		}
+	})
+
+	return err
+}
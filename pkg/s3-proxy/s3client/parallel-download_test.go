@@ -0,0 +1,275 @@
+//go:build unit
+
+package s3client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	mmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics/mocks"
+)
+
+func Test_parseSingleByteRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		rg        string
+		wantStart int64
+		wantEnd   int64
+		wantOk    bool
+	}{
+		{name: "closed range", rg: "bytes=10-20", wantStart: 10, wantEnd: 20, wantOk: true},
+		{name: "open range", rg: "bytes=10-", wantStart: 10, wantEnd: -1, wantOk: true},
+		{name: "single byte", rg: "bytes=0-0", wantStart: 0, wantEnd: 0, wantOk: true},
+		{name: "suffix range", rg: "bytes=-10"},
+		{name: "multiple ranges", rg: "bytes=0-10,20-30"},
+		{name: "end before start", rg: "bytes=20-10"},
+		{name: "invalid unit", rg: "items=0-10"},
+		{name: "invalid start", rg: "bytes=a-10"},
+		{name: "invalid end", rg: "bytes=0-b"},
+		{name: "empty", rg: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := parseSingleByteRange(tt.rg)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantStart, start)
+			assert.Equal(t, tt.wantEnd, end)
+		})
+	}
+}
+
+func Test_parseContentRangeTotal(t *testing.T) {
+	tests := []struct {
+		name   string
+		cr     string
+		want   int64
+		wantOk bool
+	}{
+		{name: "valid", cr: "bytes 0-9/100", want: 100, wantOk: true},
+		{name: "unknown size", cr: "bytes 0-9/*"},
+		{name: "empty", cr: ""},
+		{name: "invalid", cr: "bytes 0-9/abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseContentRangeTotal(tt.cr)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_parallelReader(t *testing.T) {
+	data := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+
+	fetch := func(_ context.Context, br *byteRange) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data[br.start : br.end+1])), nil
+	}
+
+	parts := []*byteRange{}
+	for i := int64(4); i < int64(len(data)); i += 5 {
+		parts = append(parts, &byteRange{start: i, end: min(i+4, int64(len(data))-1)})
+	}
+
+	pr := newParallelReader(context.TODO(), io.NopCloser(bytes.NewReader(data[:4])), parts, 2, fetch)
+
+	got, err := io.ReadAll(pr)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.NoError(t, pr.Close())
+	// Close twice must be a no-op
+	assert.NoError(t, pr.Close())
+}
+
+func Test_parallelReader_Error(t *testing.T) {
+	fetch := func(_ context.Context, br *byteRange) (io.ReadCloser, error) {
+		if br.start == 10 {
+			return nil, errors.New("fake")
+		}
+
+		return io.NopCloser(bytes.NewReader(make([]byte, br.size()))), nil
+	}
+
+	parts := []*byteRange{{start: 5, end: 9}, {start: 10, end: 14}, {start: 15, end: 19}}
+
+	pr := newParallelReader(context.TODO(), io.NopCloser(bytes.NewReader(make([]byte, 5))), parts, 1, fetch)
+
+	defer pr.Close()
+
+	got, err := io.ReadAll(pr)
+	assert.EqualError(t, err, "fake")
+	assert.Len(t, got, 10)
+}
+
+func Test_parallelReader_ShortPart(t *testing.T) {
+	fetch := func(_ context.Context, _ *byteRange) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader([]byte("ab"))), nil
+	}
+
+	pr := newParallelReader(context.TODO(), io.NopCloser(bytes.NewReader(nil)), []*byteRange{{start: 0, end: 4}}, 1, fetch)
+
+	defer pr.Close()
+
+	_, err := io.ReadAll(pr)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func Test_s3client_GetObject_Parallel(t *testing.T) {
+	// Create fake S3
+	backend := s3mem.New()
+	faker := gofakes3.New(backend)
+	ts := httptest.NewServer(faker.Server())
+
+	defer ts.Close()
+
+	// Object on 3 parts of 1MB
+	data := make([]byte, 2*oneMega+oneMega/2)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	ctrl := gomock.NewController(t)
+	metricsMock := mmocks.NewMockClient(ctrl)
+	metricsMock.EXPECT().IncS3Operations("target", "bucket", gomock.Any()).AnyTimes()
+
+	cl, err := newClient(&config.TargetConfig{
+		Name: "target",
+		Bucket: &config.BucketConfig{
+			Name:       "bucket",
+			Region:     "us-east-1",
+			S3Endpoint: ts.URL,
+			Credentials: &config.BucketCredentialConfig{
+				AccessKey: &config.CredentialConfig{Value: "access"},
+				SecretKey: &config.CredentialConfig{Value: "secret"},
+			},
+			S3DownloadParallel:    true,
+			S3DownloadPartSize:    1,
+			S3DownloadConcurrency: 2,
+		},
+	}, metricsMock)
+	require.NoError(t, err)
+
+	s3cl, ok := cl.(*s3client)
+	require.True(t, ok)
+
+	_, err = s3cl.svcClient.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String("bucket")})
+	require.NoError(t, err)
+
+	putRes, err := s3cl.svcClient.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("file"),
+		Body:   bytes.NewReader(data),
+	})
+	require.NoError(t, err)
+
+	_, err = s3cl.svcClient.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("empty"),
+		Body:   bytes.NewReader(nil),
+	})
+	require.NoError(t, err)
+
+	size := int64(len(data))
+
+	// Create context with logger and trace
+	ctx := log.SetLoggerInContext(context.TODO(), log.NewLogger())
+	ctx = opentracing.ContextWithSpan(ctx, opentracing.NoopTracer{}.StartSpan("test"))
+
+	tests := []struct {
+		wantErr          error
+		input            *GetInput
+		name             string
+		wantContentRange string
+		wantBody         []byte
+	}{
+		{
+			name:     "should download the whole object",
+			input:    &GetInput{Key: "file"},
+			wantBody: data,
+		},
+		{
+			name:             "should download a closed range across parts",
+			input:            &GetInput{Key: "file", Range: "bytes=100-2097251"},
+			wantBody:         data[100:2097252],
+			wantContentRange: fmt.Sprintf("bytes 100-2097251/%d", size),
+		},
+		{
+			name:             "should download an open range",
+			input:            &GetInput{Key: "file", Range: "bytes=1048570-"},
+			wantBody:         data[1048570:],
+			wantContentRange: fmt.Sprintf("bytes 1048570-%d/%d", size-1, size),
+		},
+		{
+			name:             "should download a range in the first part",
+			input:            &GetInput{Key: "file", Range: "bytes=0-9"},
+			wantBody:         data[:10],
+			wantContentRange: fmt.Sprintf("bytes 0-9/%d", size),
+		},
+		{
+			name:             "should forward a suffix range",
+			input:            &GetInput{Key: "file", Range: "bytes=-10"},
+			wantBody:         data[size-10:],
+			wantContentRange: fmt.Sprintf("bytes %d-%d/%d", size-10, size-1, size),
+		},
+		{
+			name:     "should download an empty object",
+			input:    &GetInput{Key: "empty"},
+			wantBody: []byte{},
+		},
+		{
+			name:     "should honor matching if-match",
+			input:    &GetInput{Key: "file", IfMatch: aws.StringValue(putRes.ETag)},
+			wantBody: data,
+		},
+		{
+			name:    "should honor if-none-match",
+			input:   &GetInput{Key: "file", IfNoneMatch: aws.StringValue(putRes.ETag)},
+			wantErr: ErrNotModified,
+		},
+		{
+			name:    "should return not found",
+			input:   &GetInput{Key: "not-found"},
+			wantErr: ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := s3cl.GetObject(ctx, tt.input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			defer got.Body.Close()
+
+			body, err := io.ReadAll(got.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBody, body)
+			assert.Equal(t, int64(len(tt.wantBody)), got.ContentLength)
+			assert.Equal(t, tt.wantContentRange, got.ContentRange)
+
+			if tt.input.Key == "file" {
+				assert.Equal(t, aws.StringValue(putRes.ETag), got.ETag)
+			}
+		})
+	}
+}
@@ -288,42 +288,108 @@ func (s3cl *s3client) ListFilesAndDirectories(ctx context.Context, key string) (
 	return all, info, nil
 }
 
-// GetObject Get object from S3 bucket.
-func (s3cl *s3client) GetObject(ctx context.Context, input *GetInput) (*GetOutput, *ResultInfo, error) {
-	// Build input
-	s3Input := s3cl.buildGetObjectInputFromInput(input)
-
+// GetPrefixUsage will compute the storage usage under a prefix.
+// Unlike ListFilesAndDirectories, this won't use any delimiter and won't
+// be limited by the S3 list max keys option as all objects must be counted.
+func (s3cl *s3client) GetPrefixUsage(ctx context.Context, prefix string) (*PrefixUsageOutput, *ResultInfo, error) {
 	// Get trace
 	parentTrace := tracing.GetTraceFromContext(ctx)
 	// Create child trace
-	childTrace := parentTrace.GetChildTrace("s3-bucket.get-object-request")
+	childTrace := parentTrace.GetChildTrace("s3-bucket.prefix-usage-request")
 	childTrace.SetTag("s3-bucket.bucket-name", s3cl.target.Bucket.Name)
 	childTrace.SetTag("s3-bucket.bucket-region", s3cl.target.Bucket.Region)
 	childTrace.SetTag("s3-bucket.bucket-prefix", s3cl.target.Bucket.Prefix)
 	childTrace.SetTag("s3-bucket.bucket-s3-endpoint", s3cl.target.Bucket.S3Endpoint)
-	childTrace.SetTag("s3-bucket.bucket-key", *s3Input.Key)
+	childTrace.SetTag("s3-bucket.bucket-key", prefix)
 	childTrace.SetTag("s3-proxy.target-name", s3cl.target.Name)
 	childTrace.SetTag("s3-bucket.bucket-s3-force-path-style", aws.BoolValue(s3cl.target.Bucket.S3ForcePathStyle))
 
 	defer childTrace.Finish()
 
-	// Init & get request headers
-	var requestHeaders map[string]string
-	if s3cl.target.Bucket.RequestConfig != nil {
-		requestHeaders = s3cl.target.Bucket.RequestConfig.GetHeaders
-	}
-
 	// Get logger
 	logger := log.GetLoggerFromContext(ctx)
 	// Build logger
 	logger = logger.WithFields(map[string]any{
 		"bucket": s3cl.target.Bucket.Name,
-		"key":    *s3Input.Key,
+		"key":    prefix,
 		"region": s3cl.target.Bucket.Region,
 	})
 	// Log
-	logger.Debugf("Trying to get object")
+	logger.Debugf("Trying to compute prefix usage")
+
+	// Init & get request headers
+	var requestHeaders map[string]string
+	if s3cl.target.Bucket.RequestConfig != nil {
+		requestHeaders = s3cl.target.Bucket.RequestConfig.ListHeaders
+	}
+
+	// Initialize output
+	output := &PrefixUsageOutput{}
+
+	// Request S3
+	err := s3cl.svcClient.ListObjectsV2PagesWithContext(
+		ctx,
+		&s3.ListObjectsV2Input{
+			Bucket:  new(s3cl.target.Bucket.Name),
+			Prefix:  new(prefix),
+			MaxKeys: new(s3MaxKeys),
+		},
+		func(page *s3.ListObjectsV2Output, _ bool) bool {
+			// Loop over objects
+			for _, item := range page.Contents {
+				output.Objects++
+				output.Size += aws.Int64Value(item.Size)
+			}
+
+			// Continue to next page
+			return true
+		},
+		addHeadersToRequest(requestHeaders),
+	)
 
+	// Metrics
+	s3cl.metricsCtx.IncS3Operations(s3cl.target.Name, s3cl.target.Bucket.Name, ListObjectsOperation)
+
+	// Check error
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	// Create info
+	info := &ResultInfo{
+		Bucket:     s3cl.target.Bucket.Name,
+		S3Endpoint: s3cl.target.Bucket.S3Endpoint,
+		Region:     s3cl.target.Bucket.Region,
+		Key:        prefix,
+	}
+
+	// Log
+	logger.Debugf("Prefix usage computed with success")
+
+	return output, info, nil
+}
+
+// getObjectSingleStream Get object from S3 bucket with one request.
+func (s3cl *s3client) getObjectSingleStream(
+	ctx context.Context,
+	s3Input *s3.GetObjectInput,
+	requestHeaders map[string]string,
+) (*GetOutput, error) {
+	obj, err := s3cl.doGetObject(ctx, s3Input, requestHeaders)
+	// Check error
+	if err != nil {
+		return nil, transformGetObjectError(err)
+	}
+
+	return buildGetOutput(obj), nil
+}
+
+// doGetObject Call S3 get object and count it.
+func (s3cl *s3client) doGetObject(
+	ctx context.Context,
+	s3Input *s3.GetObjectInput,
+	requestHeaders map[string]string,
+) (*s3.GetObjectOutput, error) {
 	obj, err := s3cl.svcClient.GetObjectWithContext(
 		ctx,
 		s3Input,
@@ -331,25 +397,32 @@ func (s3cl *s3client) GetObject(ctx context.Context, input *GetInput) (*GetOutpu
 	)
 	// Metrics
 	s3cl.metricsCtx.IncS3Operations(s3cl.target.Name, s3cl.target.Bucket.Name, GetObjectOperation)
-	// Check if error exists
-	if err != nil {
-		// Try to cast error into an AWS Error if possible
-		//nolint: errorlint // Cast
-		aerr, ok := err.(awserr.Error)
-		if ok {
-			// Check if it is a not found case
-			//nolint: gocritic // Because don't want to write a switch for the moment
-			if aerr.Code() == s3.ErrCodeNoSuchKey {
-				return nil, nil, ErrNotFound
-			} else if aerr.Code() == "NotModified" {
-				return nil, nil, ErrNotModified
-			} else if aerr.Code() == "PreconditionFailed" {
-				return nil, nil, ErrPreconditionFailed
-			}
-		}
 
-		return nil, nil, errors.WithStack(err)
+	return obj, err
+}
+
+// transformGetObjectError Transform S3 get object error into a s3client one.
+func transformGetObjectError(err error) error {
+	// Try to cast error into an AWS Error if possible
+	//nolint: errorlint // Cast
+	aerr, ok := err.(awserr.Error)
+	if ok {
+		// Check if it is a not found case
+		//nolint: gocritic // Because don't want to write a switch for the moment
+		if aerr.Code() == s3.ErrCodeNoSuchKey {
+			return ErrNotFound
+		} else if aerr.Code() == "NotModified" {
+			return ErrNotModified
+		} else if aerr.Code() == "PreconditionFailed" {
+			return ErrPreconditionFailed
+		}
 	}
+
+	return errors.WithStack(err)
+}
+
+// buildGetOutput Build get output from S3 get object output.
+func buildGetOutput(obj *s3.GetObjectOutput) *GetOutput {
 	// Build output
 	output := &GetOutput{
 		BaseFileOutput: &BaseFileOutput{},
@@ -403,6 +476,63 @@ func (s3cl *s3client) GetObject(ctx context.Context, input *GetInput) (*GetOutpu
 
 	output.ContentDigest = formatContentDigestFromS3Checksums(obj.ChecksumSHA256, obj.ChecksumSHA1, obj.ChecksumCRC32C, obj.ChecksumCRC32)
 
+	return output
+}
+
+// GetObject Get object from S3 bucket.
+func (s3cl *s3client) GetObject(ctx context.Context, input *GetInput) (*GetOutput, *ResultInfo, error) {
+	// Build input
+	s3Input := s3cl.buildGetObjectInputFromInput(input)
+
+	// Get trace
+	parentTrace := tracing.GetTraceFromContext(ctx)
+	// Create child trace
+	childTrace := parentTrace.GetChildTrace("s3-bucket.get-object-request")
+	childTrace.SetTag("s3-bucket.bucket-name", s3cl.target.Bucket.Name)
+	childTrace.SetTag("s3-bucket.bucket-region", s3cl.target.Bucket.Region)
+	childTrace.SetTag("s3-bucket.bucket-prefix", s3cl.target.Bucket.Prefix)
+	childTrace.SetTag("s3-bucket.bucket-s3-endpoint", s3cl.target.Bucket.S3Endpoint)
+	childTrace.SetTag("s3-bucket.bucket-key", *s3Input.Key)
+	childTrace.SetTag("s3-proxy.target-name", s3cl.target.Name)
+	childTrace.SetTag("s3-bucket.bucket-s3-force-path-style", aws.BoolValue(s3cl.target.Bucket.S3ForcePathStyle))
+
+	defer childTrace.Finish()
+
+	// Init & get request headers
+	var requestHeaders map[string]string
+	if s3cl.target.Bucket.RequestConfig != nil {
+		requestHeaders = s3cl.target.Bucket.RequestConfig.GetHeaders
+	}
+
+	// Get logger
+	logger := log.GetLoggerFromContext(ctx)
+	// Build logger
+	logger = logger.WithFields(map[string]any{
+		"bucket": s3cl.target.Bucket.Name,
+		"key":    *s3Input.Key,
+		"region": s3cl.target.Bucket.Region,
+	})
+	// Log
+	logger.Debugf("Trying to get object")
+
+	var (
+		output *GetOutput
+		err    error
+	)
+	// Check if parallel download is enabled
+	if s3cl.target.Bucket.S3DownloadParallel {
+		// Log
+		logger.Debugf("Parallel download enabled")
+
+		output, err = s3cl.getObjectParallel(ctx, input, s3Input, requestHeaders)
+	} else {
+		output, err = s3cl.getObjectSingleStream(ctx, s3Input, requestHeaders)
+	}
+	// Check error
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Create info
 	info := &ResultInfo{
 		Bucket:     s3cl.target.Bucket.Name,
@@ -0,0 +1,134 @@
+//go:build integration
+
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/audit"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
+)
+
+func TestAuditLog(t *testing.T) {
+	accessKey := "YOUR-ACCESSKEYID"
+	secretAccessKey := "YOUR-SECRETACCESSKEY"
+	region := "eu-central-1"
+	bucket := "test-bucket"
+
+	_, s3server, err := setupIsolationFakeS3(t, accessKey, secretAccessKey, region, bucket)
+	require.NoError(t, err)
+
+	defer s3server.Close()
+
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+
+	cfg := userIsolationConfig(s3server.URL, accessKey, secretAccessKey, region, bucket, &config.ServerConfig{}, &config.TracingConfig{})
+	cfg.Audit = &config.AuditConfig{
+		Enabled: true,
+		Sinks: []*config.AuditSinkConfig{
+			{
+				Type: config.AuditSinkTypeFile,
+				File: &config.AuditFileSinkConfig{Path: auditPath},
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	logger := log.NewLogger()
+
+	tsvc, err := tracing.New(cfgManagerMock, logger)
+	require.NoError(t, err)
+
+	s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
+	require.NoError(t, s3Manager.Load())
+
+	auditManager := audit.NewManager(cfgManagerMock, s3Manager, logger)
+	require.NoError(t, auditManager.Load())
+
+	svr := &Server{
+		logger:          logger,
+		cfgManager:      cfgManagerMock,
+		metricsCl:       metricsCtx,
+		tracingSvc:      tsvc,
+		s3clientManager: s3Manager,
+		webhookManager:  webhook.NewManager(cfgManagerMock, metricsCtx, logger),
+		auditManager:    auditManager,
+	}
+	router, err := svr.generateRouter()
+	require.NoError(t, err)
+
+	send := func(method, url, user, pass string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, nil)
+		req.SetBasicAuth(user, pass)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "http://localhost/mount/", "alice", "pw-alice").Code)
+	assert.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "http://localhost/mount/", "alice", "wrong").Code)
+	assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, "http://localhost/mount/file.txt", "bob", "pw-bob").Code)
+
+	// Flush and close sinks
+	require.NoError(t, auditManager.Close())
+
+	f, err := os.Open(auditPath)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	var events []*audit.Event
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		ev := &audit.Event{}
+		require.NoError(t, json.Unmarshal(sc.Bytes(), ev))
+
+		events = append(events, ev)
+	}
+
+	require.Len(t, events, 3)
+
+	assert.Equal(t, "alice", events[0].User)
+	assert.Equal(t, "provider1", events[0].Provider)
+	assert.Equal(t, "target1", events[0].Target)
+	assert.Equal(t, audit.DecisionAllow, events[0].Decision)
+	assert.Equal(t, "basic-auth", events[0].Reason)
+	assert.Equal(t, audit.ActionList, events[0].Action)
+	assert.Equal(t, bucket, events[0].Bucket)
+	assert.Equal(t, "data/alice/", events[0].Key)
+	assert.Equal(t, http.StatusOK, events[0].Status)
+	assert.Positive(t, events[0].BytesSent)
+
+	assert.Empty(t, events[1].User)
+	assert.Equal(t, audit.DecisionDeny, events[1].Decision)
+	assert.Equal(t, "authentication failed", events[1].Reason)
+	assert.Empty(t, events[1].Action)
+	assert.Equal(t, http.StatusUnauthorized, events[1].Status)
+
+	assert.Equal(t, "bob", events[2].User)
+	assert.Equal(t, audit.DecisionAllow, events[2].Decision)
+	assert.Equal(t, audit.ActionDelete, events[2].Action)
+	assert.Equal(t, "data/bob/file.txt", events[2].Key)
+	assert.Equal(t, http.StatusNoContent, events[2].Status)
+}
@@ -0,0 +1,323 @@
+//go:build integration
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/authorization"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
+)
+
+// testRegoObjectPolicy only allows users to read existing objects in their own folder
+// and answers with structured decisions.
+const testRegoObjectPolicy = `package s3proxy.authz
+
+default decision := {"allowed": false, "reasons": ["object not allowed"], "headers": {"X-Policy": "denied"}}
+
+decision := {"allowed": true, "headers": {"X-Policy-Key": input.object.key}} if {
+	input.target == "target1"
+	input.action == "GET"
+	input.object.exists
+	input.object.contentLength > 0
+	startswith(input.object.key, concat("", ["data/", input.user.username, "/"]))
+}
+`
+
+// TestRegoAuthorization_BucketInput verifies that embedded Rego policies receive the
+// resolved object after user isolation and that structured decisions are applied.
+func TestRegoAuthorization_BucketInput(t *testing.T) {
+	accessKey := "YOUR-ACCESSKEYID"
+	secretAccessKey := "YOUR-SECRETACCESSKEY"
+	region := "eu-central-1"
+	bucket := "test-bucket"
+
+	_, s3server, err := setupIsolationFakeS3(t, accessKey, secretAccessKey, region, bucket)
+	require.NoError(t, err)
+
+	defer s3server.Close()
+
+	// Write policy
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "policy.rego"), []byte(testRegoObjectPolicy), 0o600))
+
+	cfg := userIsolationConfig(s3server.URL, accessKey, secretAccessKey, region, bucket, &config.ServerConfig{}, &config.TracingConfig{})
+	cfg.AuthProviders = &config.AuthProviderConfig{
+		Header: map[string]*config.HeaderAuthConfig{
+			"provider1": {UsernameHeader: "X-Username", EmailHeader: "X-Email"},
+		},
+	}
+	cfg.RegoPolicies = map[string]*config.RegoPolicyConfig{
+		"policy1": {Query: "data.s3proxy.authz.decision", Files: []string{dir}},
+	}
+	cfg.Targets["target1"].Resources = []*config.Resource{
+		{
+			Path:     "/mount/**",
+			Methods:  []string{"GET"},
+			Provider: "provider1",
+			Header: &config.ResourceHeaderOIDC{
+				AuthorizationRego: &config.RegoAuthorization{
+					Policy:                "policy1",
+					Tags:                  map[string]string{},
+					IncludeObjectMetadata: true,
+				},
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	logger := log.NewLogger()
+
+	tsvc, err := tracing.New(cfgManagerMock, logger)
+	require.NoError(t, err)
+
+	s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
+	require.NoError(t, s3Manager.Load())
+
+	regoManager := authorization.NewRegoManager(cfgManagerMock, s3Manager, logger)
+	require.NoError(t, regoManager.Load())
+
+	svr := &Server{
+		logger:          logger,
+		cfgManager:      cfgManagerMock,
+		metricsCl:       metricsCtx,
+		tracingSvc:      tsvc,
+		s3clientManager: s3Manager,
+		webhookManager:  webhook.NewManager(cfgManagerMock, metricsCtx, logger),
+		regoManager:     regoManager,
+	}
+	router, err := svr.generateRouter()
+	require.NoError(t, err)
+
+	do := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("X-Username", "alice")
+		req.Header.Set("X-Email", "alice@example.com")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// Own existing file
+	w := do("http://localhost/mount/secret.txt")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice-secret", w.Body.String())
+	assert.Equal(t, "data/alice/secret.txt", w.Header().Get("X-Policy-Key"))
+
+	// Nested file with escaped path
+	w = do("http://localhost/mount/sub/nested%2Etxt")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "data/alice/sub/nested.txt", w.Header().Get("X-Policy-Key"))
+
+	// Missing file
+	w = do("http://localhost/mount/missing.txt")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "forbidden user alice: object not allowed")
+	assert.Equal(t, "denied", w.Header().Get("X-Policy"))
+
+	// Folder listing
+	w = do("http://localhost/mount/")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// TestRBACAuthorization verifies that rbac bindings are evaluated on request path and action.
+func TestRBACAuthorization(t *testing.T) {
+	accessKey := "YOUR-ACCESSKEYID"
+	secretAccessKey := "YOUR-SECRETACCESSKEY"
+	region := "eu-central-1"
+	bucket := "test-bucket"
+
+	_, s3server, err := setupIsolationFakeS3(t, accessKey, secretAccessKey, region, bucket)
+	require.NoError(t, err)
+
+	defer s3server.Close()
+
+	cfg := userIsolationConfig(s3server.URL, accessKey, secretAccessKey, region, bucket, &config.ServerConfig{}, &config.TracingConfig{})
+	cfg.AuthProviders = &config.AuthProviderConfig{
+		Header: map[string]*config.HeaderAuthConfig{
+			"provider1": {UsernameHeader: "X-Username", EmailHeader: "X-Email"},
+		},
+	}
+	cfg.RBAC = &config.RBACConfig{
+		Roles: map[string]*config.RBACRoleConfig{
+			"sub-reader": {Permissions: []*config.RBACPermissionConfig{
+				{Targets: []string{"target*"}, Paths: []string{"/sub/**"}, Actions: []string{config.RBACReadAction}},
+			}},
+		},
+		Bindings: []*config.RBACBindingConfig{
+			{Role: "sub-reader", Users: []string{"alice"}, Providers: []string{"provider1"}},
+			{Role: "sub-reader", Users: []string{"mallory@example.com"}, Providers: []string{"provider1"}},
+		},
+	}
+	cfg.Targets["target1"].Resources = []*config.Resource{
+		{
+			Path:     "/mount/**",
+			Methods:  []string{"GET"},
+			Provider: "provider1",
+			Header:   &config.ResourceHeaderOIDC{AuthorizationRBAC: true},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	logger := log.NewLogger()
+
+	tsvc, err := tracing.New(cfgManagerMock, logger)
+	require.NoError(t, err)
+
+	s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
+	require.NoError(t, s3Manager.Load())
+
+	svr := &Server{
+		logger:          logger,
+		cfgManager:      cfgManagerMock,
+		metricsCl:       metricsCtx,
+		tracingSvc:      tsvc,
+		s3clientManager: s3Manager,
+		webhookManager:  webhook.NewManager(cfgManagerMock, metricsCtx, logger),
+		regoManager:     authorization.NewRegoManager(cfgManagerMock, s3Manager, logger),
+	}
+	router, err := svr.generateRouter()
+	require.NoError(t, err)
+
+	do := func(url, username, email string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("X-Username", username)
+		req.Header.Set("X-Email", email)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// Allowed path
+	w := do("http://localhost/mount/sub/nested%2Etxt", "alice", "alice@example.com")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Path outside permissions
+	w = do("http://localhost/mount/secret.txt", "alice", "alice@example.com")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "forbidden user alice")
+
+	// Folder listing isn't allowed
+	w = do("http://localhost/mount/sub/", "alice", "alice@example.com")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Header emails aren't verified and cannot match bindings
+	w = do("http://localhost/mount/sub/nested%2Etxt", "mallory", "mallory@example.com")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+// TestMultipleProvidersAuthentication verifies that a resource with multiple providers
+// selects the provider from request credentials and applies its authorization.
+func TestMultipleProvidersAuthentication(t *testing.T) {
+	accessKey := "YOUR-ACCESSKEYID"
+	secretAccessKey := "YOUR-SECRETACCESSKEY"
+	region := "eu-central-1"
+	bucket := "test-bucket"
+
+	_, s3server, err := setupIsolationFakeS3(t, accessKey, secretAccessKey, region, bucket)
+	require.NoError(t, err)
+
+	defer s3server.Close()
+
+	cfg := userIsolationConfig(s3server.URL, accessKey, secretAccessKey, region, bucket, &config.ServerConfig{}, &config.TracingConfig{})
+	cfg.AuthProviders.Header = map[string]*config.HeaderAuthConfig{
+		"provider2": {UsernameHeader: "X-Username", EmailHeader: "X-Email", GroupsHeader: "X-Groups"},
+	}
+	res := cfg.Targets["target1"].Resources[0]
+	res.Provider = ""
+	res.Providers = []string{"provider2", "provider1"}
+	res.Header = &config.ResourceHeaderOIDC{
+		AuthorizationAccesses: []*config.HeaderOIDCAuthorizationAccess{{Group: "admins"}},
+	}
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	logger := log.NewLogger()
+
+	tsvc, err := tracing.New(cfgManagerMock, logger)
+	require.NoError(t, err)
+
+	s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
+	require.NoError(t, s3Manager.Load())
+
+	svr := &Server{
+		logger:          logger,
+		cfgManager:      cfgManagerMock,
+		metricsCl:       metricsCtx,
+		tracingSvc:      tsvc,
+		s3clientManager: s3Manager,
+		webhookManager:  webhook.NewManager(cfgManagerMock, metricsCtx, logger),
+		regoManager:     authorization.NewRegoManager(cfgManagerMock, s3Manager, logger),
+	}
+	router, err := svr.generateRouter()
+	require.NoError(t, err)
+
+	do := func(setup func(req *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/mount/secret.txt", nil)
+		setup(req)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// Header user in allowed group
+	w := do(func(req *http.Request) {
+		req.Header.Set("X-Username", "alice")
+		req.Header.Set("X-Email", "alice@example.com")
+		req.Header.Set("X-Groups", "admins")
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice-secret", w.Body.String())
+
+	// Header user without allowed group
+	w = do(func(req *http.Request) {
+		req.Header.Set("X-Username", "alice")
+		req.Header.Set("X-Email", "alice@example.com")
+	})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Basic user doesn't need any group
+	w = do(func(req *http.Request) {
+		req.SetBasicAuth("bob", "pw-bob")
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "bob-secret", w.Body.String())
+
+	// Invalid basic credentials
+	w = do(func(req *http.Request) {
+		req.SetBasicAuth("bob", "invalid")
+	})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// No credentials must send a basic challenge
+	w = do(func(_ *http.Request) {})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Basic realm="realm1"`, w.Header().Get("WWW-Authenticate"))
+}
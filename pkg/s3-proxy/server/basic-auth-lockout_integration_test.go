@@ -0,0 +1,159 @@
+//go:build integration
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/lockout"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
+)
+
+func TestBasicAuthLockout(t *testing.T) {
+	accessKey := "YOUR-ACCESSKEYID"
+	secretAccessKey := "YOUR-SECRETACCESSKEY"
+	region := "eu-central-1"
+	bucket := "test-bucket"
+
+	_, s3server, err := setupIsolationFakeS3(t, accessKey, secretAccessKey, region, bucket)
+	require.NoError(t, err)
+
+	defer s3server.Close()
+
+	maxAttemptsPerUser := 2
+	maxAttemptsPerIP := 4
+
+	cfg := userIsolationConfig(s3server.URL, accessKey, secretAccessKey, region, bucket, &config.ServerConfig{}, &config.TracingConfig{})
+	cfg.InternalServer = &config.ServerConfig{
+		Compress: &config.ServerCompressConfig{
+			Enabled: &config.DefaultServerCompressEnabled,
+			Level:   config.DefaultServerCompressLevel,
+			Types:   config.DefaultServerCompressTypes,
+		},
+	}
+	cfg.AuthProviders.Basic["provider1"].BruteForceProtection = &config.BruteForceProtectionConfig{
+		Enabled:            true,
+		MaxAttemptsPerUser: &maxAttemptsPerUser,
+		MaxAttemptsPerIP:   &maxAttemptsPerIP,
+		AttemptsWindow:     time.Minute,
+		LockoutDuration:    time.Minute,
+		MaxLockoutDuration: time.Hour,
+	}
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	logger := log.NewLogger()
+
+	tsvc, err := tracing.New(cfgManagerMock, logger)
+	require.NoError(t, err)
+
+	s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
+	require.NoError(t, s3Manager.Load())
+
+	lockoutManager := lockout.NewManager()
+
+	svr := &Server{
+		logger:          logger,
+		cfgManager:      cfgManagerMock,
+		metricsCl:       metricsCtx,
+		tracingSvc:      tsvc,
+		s3clientManager: s3Manager,
+		webhookManager:  webhook.NewManager(cfgManagerMock, metricsCtx, logger),
+		lockoutManager:  lockoutManager,
+	}
+	router, err := svr.generateRouter()
+	require.NoError(t, err)
+
+	intSvr := NewInternalServer(logger, cfgManagerMock, metricsCtx, lockoutManager, svr.webhookManager)
+	intRouter := intSvr.generateInternalRouter()
+
+	send := func(user, pass, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/mount/", nil)
+		req.RemoteAddr = ip + ":1234"
+		req.SetBasicAuth(user, pass)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+	sendInternal := func(method, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		intRouter.ServeHTTP(w, httptest.NewRequest(method, url, nil))
+
+		return w
+	}
+	listLockouts := func() []*lockout.Lockout {
+		w := sendInternal(http.MethodGet, "http://localhost/auth/lockouts")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var res struct {
+			Lockouts []*lockout.Lockout `json:"lockouts"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+
+		return res.Lockouts
+	}
+
+	// Valid credentials
+	assert.Equal(t, http.StatusOK, send("alice", "pw-alice", "10.0.0.1").Code)
+
+	// Failures until user lockout
+	assert.Equal(t, http.StatusUnauthorized, send("alice", "wrong", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusUnauthorized, send("alice", "wrong", "10.0.0.2").Code)
+
+	// Valid credentials are rejected during lockout
+	w := send("alice", "pw-alice", "10.0.0.3")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	// Other users aren't locked
+	assert.Equal(t, http.StatusOK, send("bob", "pw-bob", "10.0.0.3").Code)
+
+	lcks := listLockouts()
+	require.Len(t, lcks, 1)
+	assert.Equal(t, "provider1", lcks[0].Provider)
+	assert.Equal(t, lockout.ScopeUser, lcks[0].Scope)
+	assert.Equal(t, "alice", lcks[0].Value)
+
+	// Failures from an ip with unknown users until ip lockout
+	for _, user := range []string{"u1", "u2", "u3", "u4"} {
+		assert.Equal(t, http.StatusUnauthorized, send(user, "wrong", "10.0.0.9").Code)
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send("bob", "pw-bob", "10.0.0.9").Code)
+	assert.Equal(t, http.StatusOK, send("bob", "pw-bob", "10.0.0.8").Code)
+
+	// Invalid scope
+	assert.Equal(t, http.StatusBadRequest, sendInternal(http.MethodDelete, "http://localhost/auth/lockouts?scope=fake").Code)
+
+	// Clear user lockout
+	w = sendInternal(http.MethodDelete, "http://localhost/auth/lockouts?provider=provider1&scope=user&value=alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"cleared":1}`, w.Body.String())
+	assert.Equal(t, http.StatusOK, send("alice", "pw-alice", "10.0.0.3").Code)
+
+	lcks = listLockouts()
+	require.Len(t, lcks, 1)
+	assert.Equal(t, lockout.ScopeIP, lcks[0].Scope)
+	assert.Equal(t, "10.0.0.9", lcks[0].Value)
+
+	// Clear all
+	assert.Equal(t, http.StatusOK, sendInternal(http.MethodDelete, "http://localhost/auth/lockouts").Code)
+	assert.Empty(t, listLockouts())
+	assert.Equal(t, http.StatusOK, send("bob", "pw-bob", "10.0.0.9").Code)
+}
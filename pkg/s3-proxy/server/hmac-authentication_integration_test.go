@@ -0,0 +1,127 @@
+//go:build integration
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/hmacsign"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
+)
+
+func TestHMACAuthentication(t *testing.T) {
+	accessKey := "YOUR-ACCESSKEYID"
+	secretAccessKey := "YOUR-SECRETACCESSKEY"
+	region := "eu-central-1"
+	bucket := "test-bucket"
+
+	_, s3server, err := setupIsolationFakeS3(t, accessKey, secretAccessKey, region, bucket)
+	require.NoError(t, err)
+
+	defer s3server.Close()
+
+	cfg := userIsolationConfig(s3server.URL, accessKey, secretAccessKey, region, bucket, &config.ServerConfig{}, &config.TracingConfig{})
+	cfg.AuthProviders.HMAC = map[string]*config.HMACAuthConfig{
+		"provider2": {
+			Keys: []*config.HMACKeyConfig{
+				{ID: "alice-key", Owner: "alice", Groups: []string{"backup"}, Secret: &config.CredentialConfig{Value: "alice-secret-key"}},
+			},
+			SignedHeaders: []string{"Content-Type"},
+			MaxClockSkew:  time.Minute,
+			MaxBodySize:   config.DefaultHMACMaxBodySize,
+			RejectReplay:  new(true),
+		},
+	}
+	res := cfg.Targets["target1"].Resources[0]
+	res.Provider = "provider2"
+	res.Basic = nil
+	res.HMAC = &config.ResourceHeaderOIDC{
+		AuthorizationAccesses: []*config.HeaderOIDCAuthorizationAccess{{Group: "backup"}},
+	}
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	logger := log.NewLogger()
+
+	tsvc, err := tracing.New(cfgManagerMock, logger)
+	require.NoError(t, err)
+
+	s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
+	require.NoError(t, s3Manager.Load())
+
+	svr := &Server{
+		logger:          logger,
+		cfgManager:      cfgManagerMock,
+		metricsCl:       metricsCtx,
+		tracingSvc:      tsvc,
+		s3clientManager: s3Manager,
+		webhookManager:  webhook.NewManager(cfgManagerMock, metricsCtx, logger),
+	}
+	router, err := svr.generateRouter()
+	require.NoError(t, err)
+
+	send := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+	sign := func(req *http.Request, keyID, secret string) *http.Request {
+		s := hmacsign.NewSigner(keyID, secret)
+		s.Headers = []string{"Content-Type"}
+
+		require.NoError(t, s.Sign(req))
+
+		return req
+	}
+
+	// Signed upload
+	req := httptest.NewRequest(http.MethodPut, "http://localhost/mount/", strings.NewReader(multipartBody(t, "signed.txt", "signed-content")))
+	req.Header.Set("Content-Type", multipartContentType())
+	w := send(sign(req, "alice-key", "alice-secret-key"))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// Signed download
+	req = sign(httptest.NewRequest(http.MethodGet, "http://localhost/mount/signed.txt", nil), "alice-key", "alice-secret-key")
+	w = send(req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "signed-content", w.Body.String())
+
+	// Replayed request
+	req2 := httptest.NewRequest(http.MethodGet, "http://localhost/mount/signed.txt", nil)
+	req2.Header = req.Header.Clone()
+	w = send(req2)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Wrong secret
+	w = send(sign(httptest.NewRequest(http.MethodGet, "http://localhost/mount/secret.txt", nil), "alice-key", "wrong"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Body modified after signature
+	req = httptest.NewRequest(http.MethodPut, "http://localhost/mount/", strings.NewReader(multipartBody(t, "other.txt", "content")))
+	req.Header.Set("Content-Type", multipartContentType())
+	req = sign(req, "alice-key", "alice-secret-key")
+	req.Body = io.NopCloser(strings.NewReader(multipartBody(t, "other.txt", "modified")))
+	w = send(req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// No signature
+	w = send(httptest.NewRequest(http.MethodGet, "http://localhost/mount/secret.txt", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,224 @@
+//go:build integration
+
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/audit"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
+)
+
+func TestImpersonation(t *testing.T) {
+	accessKey := "YOUR-ACCESSKEYID"
+	secretAccessKey := "YOUR-SECRETACCESSKEY"
+	region := "eu-central-1"
+	bucket := "test-bucket"
+
+	tests := []struct {
+		name           string
+		allowMutations bool
+	}{
+		{name: "mutations forbidden"},
+		{name: "mutations allowed", allowMutations: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, s3server, err := setupIsolationFakeS3(t, accessKey, secretAccessKey, region, bucket)
+			require.NoError(t, err)
+
+			defer s3server.Close()
+
+			auditPath := filepath.Join(t.TempDir(), "audit.log")
+
+			cfg := userIsolationConfig(s3server.URL, accessKey, secretAccessKey, region, bucket, &config.ServerConfig{}, &config.TracingConfig{})
+			cfg.Impersonation = &config.ImpersonationConfig{
+				Enabled:        true,
+				Header:         config.DefaultImpersonationHeader,
+				GroupsHeader:   config.DefaultImpersonationGroupsHeader,
+				CookieName:     config.DefaultImpersonationCookieName,
+				CookieSecret:   &config.CredentialConfig{Value: "01234567890123456789012345678901"},
+				Admins:         []string{"admin"},
+				AllowMutations: tt.allowMutations,
+			}
+			cfg.Audit = &config.AuditConfig{
+				Enabled: true,
+				Sinks: []*config.AuditSinkConfig{
+					{
+						Type: config.AuditSinkTypeFile,
+						File: &config.AuditFileSinkConfig{Path: auditPath},
+					},
+				},
+			}
+
+			ctrl := gomock.NewController(t)
+			cfgManagerMock := cmocks.NewMockManager(ctrl)
+			cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+			logger := log.NewLogger()
+
+			tsvc, err := tracing.New(cfgManagerMock, logger)
+			require.NoError(t, err)
+
+			s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
+			require.NoError(t, s3Manager.Load())
+
+			auditManager := audit.NewManager(cfgManagerMock, s3Manager, logger)
+			require.NoError(t, auditManager.Load())
+
+			svr := &Server{
+				logger:          logger,
+				cfgManager:      cfgManagerMock,
+				metricsCl:       metricsCtx,
+				tracingSvc:      tsvc,
+				s3clientManager: s3Manager,
+				webhookManager:  webhook.NewManager(cfgManagerMock, metricsCtx, logger),
+				auditManager:    auditManager,
+			}
+			router, err := svr.generateRouter()
+			require.NoError(t, err)
+
+			var csrfToken string
+
+			send := func(method, url, user, pass, impersonated string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(method, url, nil)
+				req.SetBasicAuth(user, pass)
+				// Check if it is a session switch confirmation
+				if method == http.MethodPost && csrfToken != "" {
+					req = httptest.NewRequest(method, url, strings.NewReader("csrfToken="+csrfToken))
+					req.SetBasicAuth(user, pass)
+					req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				}
+				// Check if impersonation header must be set
+				if impersonated != "" {
+					req.Header.Set("X-Impersonate-User", impersonated)
+				}
+
+				for _, c := range cookies {
+					req.AddCookie(c)
+				}
+
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				return w
+			}
+
+			// Admin sees alice files with header
+			w := send(http.MethodGet, "http://localhost/mount/secret.txt", "admin", "pw-admin", "alice")
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "alice-secret", w.Body.String())
+
+			// Non admin cannot impersonate
+			w = send(http.MethodGet, "http://localhost/mount/secret.txt", "bob", "pw-bob", "alice")
+			assert.Equal(t, http.StatusForbidden, w.Code)
+
+			// Session switch confirmation page
+			w = send(http.MethodGet, "http://localhost/mount/?impersonate=bob", "admin", "pw-admin", "")
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Result().Cookies())
+
+			matches := regexp.MustCompile(`name="csrfToken" value="([^"]+)"`).FindStringSubmatch(w.Body.String())
+			require.Len(t, matches, 2)
+
+			// Session switch without token
+			w = send(http.MethodPost, "http://localhost/mount/?impersonate=bob", "admin", "pw-admin", "")
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Empty(t, w.Result().Cookies())
+
+			// Session switch
+			csrfToken = matches[1]
+			w = send(http.MethodPost, "http://localhost/mount/?impersonate=bob", "admin", "pw-admin", "")
+			assert.Equal(t, http.StatusSeeOther, w.Code)
+			assert.Equal(t, "/mount/", w.Header().Get("Location"))
+
+			cookies := w.Result().Cookies()
+			require.Len(t, cookies, 1)
+
+			w = send(http.MethodGet, "http://localhost/mount/secret.txt", "admin", "pw-admin", "", cookies[0])
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "bob-secret", w.Body.String())
+
+			// Mutating action
+			w = send(http.MethodDelete, "http://localhost/mount/secret.txt", "admin", "pw-admin", "charlie")
+			if tt.allowMutations {
+				assert.Equal(t, http.StatusNoContent, w.Code)
+			} else {
+				assert.Equal(t, http.StatusForbidden, w.Code)
+			}
+
+			// Flush and close sinks
+			require.NoError(t, auditManager.Close())
+
+			f, err := os.Open(auditPath)
+			require.NoError(t, err)
+
+			defer f.Close()
+
+			var events []*audit.Event
+
+			sc := bufio.NewScanner(f)
+			for sc.Scan() {
+				ev := &audit.Event{}
+				require.NoError(t, json.Unmarshal(sc.Bytes(), ev))
+
+				events = append(events, ev)
+			}
+
+			require.Len(t, events, 7)
+
+			assert.Equal(t, "alice", events[0].User)
+			assert.Equal(t, "IMPERSONATED", events[0].UserType)
+			assert.Equal(t, "admin", events[0].Impersonator)
+			assert.Equal(t, "data/alice/secret.txt", events[0].Key)
+
+			assert.Equal(t, "bob", events[1].User)
+			assert.Empty(t, events[1].Impersonator)
+			assert.Equal(t, audit.DecisionDeny, events[1].Decision)
+			assert.Equal(t, "impersonation: user isn't an impersonation admin", events[1].Reason)
+
+			assert.Equal(t, "admin", events[2].User)
+			assert.Equal(t, http.MethodGet, events[2].Method)
+			assert.Equal(t, "impersonation: session switch confirmation", events[2].Reason)
+
+			assert.Equal(t, "admin", events[3].User)
+			assert.Equal(t, audit.DecisionDeny, events[3].Decision)
+			assert.Equal(t, "impersonation: invalid session switch token", events[3].Reason)
+
+			assert.Equal(t, "admin", events[4].User)
+			assert.Equal(t, "impersonation: start impersonating bob", events[4].Reason)
+
+			assert.Equal(t, "bob", events[5].User)
+			assert.Equal(t, "admin", events[5].Impersonator)
+			assert.Equal(t, "data/bob/secret.txt", events[5].Key)
+
+			assert.Equal(t, "charlie", events[6].User)
+			assert.Equal(t, "admin", events[6].Impersonator)
+			// Check if mutations are allowed
+			if tt.allowMutations {
+				assert.Equal(t, audit.DecisionAllow, events[6].Decision)
+				assert.Equal(t, "data/charlie/secret.txt", events[6].Key)
+			} else {
+				assert.Equal(t, audit.DecisionDeny, events[6].Decision)
+				assert.Equal(t, "impersonation: mutating actions aren't allowed", events[6].Reason)
+			}
+		})
+	}
+}
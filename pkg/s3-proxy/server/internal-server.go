@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,25 +12,38 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/authorization"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/lockout"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/server/middlewares"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
 )
 
 type InternalServer struct {
-	logger     log.Logger
-	cfgManager config.Manager
-	metricsCl  metrics.Client
-	server     *http.Server
+	logger         log.Logger
+	cfgManager     config.Manager
+	metricsCl      metrics.Client
+	lockoutManager lockout.Manager
+	webhookManager webhook.Manager
+	server         *http.Server
 }
 
-func NewInternalServer(logger log.Logger, cfgManager config.Manager, metricsCl metrics.Client) *InternalServer {
+func NewInternalServer(
+	logger log.Logger,
+	cfgManager config.Manager,
+	metricsCl metrics.Client,
+	lockoutManager lockout.Manager,
+	webhookManager webhook.Manager,
+) *InternalServer {
 	return &InternalServer{
-		logger:     logger,
-		cfgManager: cfgManager,
-		metricsCl:  metricsCl,
+		logger:         logger,
+		cfgManager:     cfgManager,
+		metricsCl:      metricsCl,
+		lockoutManager: lockoutManager,
+		webhookManager: webhookManager,
 	}
 }
 
@@ -46,7 +60,8 @@ func (svr *InternalServer) Listen() error {
 	}
 
 	// Check error
-	if err != nil {
+	// Server closed error is raised on graceful shutdown.
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return errors.WithStack(err)
 	}
 
@@ -54,6 +69,11 @@ func (svr *InternalServer) Listen() error {
 	return nil
 }
 
+// Shutdown will gracefully stop the internal server.
+func (svr *InternalServer) Shutdown(ctx context.Context) error {
+	return errors.WithStack(svr.server.Shutdown(ctx))
+}
+
 func (svr *InternalServer) GenerateServer() error {
 	// Get configuration
 	cfg := svr.cfgManager.GetConfig()
@@ -111,9 +131,7 @@ func (svr *InternalServer) generateInternalRouter() http.Handler {
 	}
 
 	r.Use(middleware.RequestID)
-	r.Use(middleware.ClientIPFromXFF())
-	r.Use(middleware.ClientIPFromRemoteAddr)
-	r.Use(middleware.ClientIPFromHeader("X-Real-IP"))
+	r.Use(middlewares.ClientIP(cfg.InternalServer.TrustedProxyPrefixes, cfg.InternalServer.ClientIPHeader))
 	r.Use(log.NewStructuredLogger(
 		svr.logger,
 		tracing.GetTraceIDFromRequest,
@@ -127,6 +145,11 @@ func (svr *InternalServer) generateInternalRouter() http.Handler {
 	r.Handle("/metrics", svr.metricsCl.GetExposeHandler())
 	r.Handle("/health", healthHandler)
 	r.Handle("/config", configHandler(svr.cfgManager))
+	r.Handle("/rbac/permissions", rbacPermissionsHandler(svr.cfgManager))
+	r.Method(http.MethodGet, "/auth/lockouts", lockoutsListHandler(svr.lockoutManager))
+	r.Method(http.MethodDelete, "/auth/lockouts", lockoutsClearHandler(svr.lockoutManager))
+	r.Method(http.MethodGet, "/webhooks/dead-letters", webhookDeadLettersListHandler(svr.webhookManager))
+	r.Method(http.MethodPost, "/webhooks/dead-letters/replay", webhookDeadLettersReplayHandler(svr.webhookManager))
 
 	return r
 }
@@ -157,3 +180,215 @@ func configHandler(cfgManager config.Manager) http.Handler {
 		_, _ = w.Write(bb)
 	})
 }
+
+func rbacPermissionsHandler(cfgManager config.Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Get query parameters
+		q := r.URL.Query()
+		// Create subject
+		subject := &authorization.RBACSubject{
+			Provider: q.Get("provider"),
+			Users:    q["user"],
+			Groups:   q["group"],
+		}
+		// Check that subject is valid
+		if len(subject.Users) == 0 && len(subject.Groups) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("user or group query parameter must be set"))
+
+			// Stop
+			return
+		}
+		// Check that provider is set as bindings are scoped to providers
+		if subject.Provider == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("provider query parameter must be set"))
+
+			// Stop
+			return
+		}
+
+		// Get effective permissions
+		perms := authorization.GetRBACEffectivePermissions(cfgManager.GetConfig().RBAC, subject)
+
+		// Create output answer
+		type resp struct {
+			Permissions []*authorization.EffectivePermission `json:"permissions"`
+		}
+		// json marshal
+		bb, err := json.Marshal(&resp{Permissions: perms})
+		// Check error
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(err.Error()))
+
+			// Stop
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		_, _ = w.Write(bb)
+	})
+}
+
+func lockoutsListHandler(lockoutManager lockout.Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// Create output answer
+		type resp struct {
+			Lockouts []*lockout.Lockout `json:"lockouts"`
+		}
+		// json marshal
+		bb, err := json.Marshal(&resp{Lockouts: lockoutManager.List()})
+		// Check error
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(err.Error()))
+
+			// Stop
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		_, _ = w.Write(bb)
+	})
+}
+
+func lockoutsClearHandler(lockoutManager lockout.Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Get logger from request
+		logEntry := log.GetLoggerFromContext(r.Context())
+		// Get query parameters
+		q := r.URL.Query()
+		// Get scope
+		scope := q.Get("scope")
+		// Check scope
+		if scope != "" && scope != lockout.ScopeUser && scope != lockout.ScopeIP {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("scope query parameter must be user or ip"))
+
+			// Stop
+			return
+		}
+
+		// Clear lockouts
+		cleared := lockoutManager.Clear(q.Get("provider"), scope, q.Get("value"))
+
+		logEntry.WithFields(map[string]any{
+			"security_event": "lockout_cleared",
+			"provider":       q.Get("provider"),
+			"lockout_scope":  scope,
+			"value":          q.Get("value"),
+		}).Infof("%d brute force protection entries cleared", cleared)
+
+		// Create output answer
+		type resp struct {
+			Cleared int `json:"cleared"`
+		}
+		// json marshal
+		bb, err := json.Marshal(&resp{Cleared: cleared})
+		// Check error
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(err.Error()))
+
+			// Stop
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		_, _ = w.Write(bb)
+	})
+}
+
+func webhookDeadLettersListHandler(webhookManager webhook.Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// List dead letters
+		list, err := webhookManager.ListDeadLetters()
+		// Check error
+		if err != nil {
+			writeWebhookQueueError(w, err)
+
+			// Stop
+			return
+		}
+
+		// Create output answer
+		type resp struct {
+			DeadLetters []*webhook.Delivery `json:"deadLetters"`
+		}
+		// json marshal
+		bb, err := json.Marshal(&resp{DeadLetters: list})
+		// Check error
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(err.Error()))
+
+			// Stop
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		_, _ = w.Write(bb)
+	})
+}
+
+func webhookDeadLettersReplayHandler(webhookManager webhook.Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Get logger from request
+		logEntry := log.GetLoggerFromContext(r.Context())
+		// Get id
+		id := r.URL.Query().Get("id")
+
+		// Replay dead letters
+		replayed, err := webhookManager.ReplayDeadLetters(id)
+		// Check error
+		if err != nil {
+			writeWebhookQueueError(w, err)
+
+			// Stop
+			return
+		}
+
+		logEntry.WithField("webhook_delivery_id", id).Infof("%d webhook dead letters replayed", replayed)
+
+		// Create output answer
+		type resp struct {
+			Replayed int `json:"replayed"`
+		}
+		// json marshal
+		bb, err := json.Marshal(&resp{Replayed: replayed})
+		// Check error
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(err.Error()))
+
+			// Stop
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		_, _ = w.Write(bb)
+	})
+}
+
+// writeWebhookQueueError will answer with a not found status when queue is disabled or dead letter doesn't exist.
+func writeWebhookQueueError(w http.ResponseWriter, err error) {
+	// Check if error is a not found one
+	if errors.Is(err, webhook.ErrQueueDisabled) || errors.Is(err, webhook.ErrDeadLetterNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+	} else {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	_, _ = w.Write([]byte(err.Error()))
+}
@@ -12,9 +12,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/lockout"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
 	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
 )
 
 func TestInternalServer_generateInternalRouter(t *testing.T) {
@@ -112,7 +114,13 @@ func TestInternal_Server_Listen(t *testing.T) {
 		},
 	})
 
-	svr := NewInternalServer(log.NewLogger(), cfgManagerMock, metricsCtx)
+	svr := NewInternalServer(
+		log.NewLogger(),
+		cfgManagerMock,
+		metricsCtx,
+		lockout.NewManager(),
+		webhook.NewManager(cfgManagerMock, metricsCtx, log.NewLogger()),
+	)
 	// Generate server
 	svr.GenerateServer()
 
@@ -164,6 +172,7 @@ func TestInternalServer_config_endpoint(t *testing.T) {
 				"log":null,
 				"tracing":null,
 				"metrics":null,
+				"regoPolicies":null,
 				"server":null,
 				"internalServer":{
 					"timeouts":null,
@@ -175,13 +184,19 @@ func TestInternalServer_config_endpoint(t *testing.T) {
 						"level":5
 					},
 					"ssl":null,
+					"trustedProxies":null,
+					"clientIPHeader":"",
 					"listenAddr":"",
 					"port":8080
 				},
 				"targets":null,
 				"templates":null,
 				"authProviders":null,
-				"listTargets":null
+				"listTargets":null,
+				"rbac":null,
+				"audit":null,
+				"geoIP":null,
+				"impersonation":null,"webhookQueue":null
 			}}`,
 		},
 		{
@@ -326,6 +341,12 @@ func TestInternalServer_config_endpoint(t *testing.T) {
       "logSpan": false
     },
     "metrics": { "disableRouterPath": false },
+    "regoPolicies": null,
+    "rbac": null,
+    "audit": null,
+    "geoIP": null,
+    "impersonation": null,
+    "webhookQueue": null,
     "server": {
       "timeouts": {
         "readTimeout": "",
@@ -352,6 +373,8 @@ func TestInternalServer_config_endpoint(t *testing.T) {
         "level": 5
       },
       "ssl": null,
+      "trustedProxies": null,
+      "clientIPHeader": "",
       "listenAddr": "",
       "port": 8080
     },
@@ -381,6 +404,8 @@ func TestInternalServer_config_endpoint(t *testing.T) {
         "level": 5
       },
       "ssl": null,
+      "trustedProxies": null,
+      "clientIPHeader": "",
       "listenAddr": "",
       "port": 9090
     },
@@ -398,6 +423,9 @@ func TestInternalServer_config_endpoint(t *testing.T) {
           "s3UploadPartSize": 5,
           "s3UploadConcurrency": 5,
           "s3UploadLeavePartsOnError": false,
+          "s3DownloadParallel": false,
+          "s3DownloadPartSize": 0,
+          "s3DownloadConcurrency": 0,
 		  "s3ForcePathStyle": true,
           "disableSSL": false,
 		  "credentials": {
@@ -418,12 +446,16 @@ func TestInternalServer_config_endpoint(t *testing.T) {
           "notFoundError": null,
           "internalServerError": null,
           "forbiddenError": null,
+          "quotaExceededError": null,
+          "serviceUnavailableError": null,
           "unauthorizedError": null,
           "badRequestError": null,
           "put": null,
           "delete": null,
           "helpers": null
         },
+        "concurrency": null,
+        "ipFilter": null,
         "keyRewriteList": null
       }
     },
@@ -470,6 +502,8 @@ func TestInternalServer_config_endpoint(t *testing.T) {
         },
         "status": "403"
       },
+      "quotaExceededError": null,
+      "serviceUnavailableError": null,
       "badRequestError": {
         "path": "templates/bad-request-error.tpl",
         "headers": {
@@ -528,3 +562,108 @@ func TestInternalServer_config_endpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestInternalServer_rbac_permissions_endpoint(t *testing.T) {
+	rbacCfg := &config.RBACConfig{
+		Roles: map[string]*config.RBACRoleConfig{
+			"reader": {Permissions: []*config.RBACPermissionConfig{
+				{Targets: []string{"*"}, Paths: []string{"/**"}, Actions: []string{"read", "list"}},
+			}},
+			"writer": {Permissions: []*config.RBACPermissionConfig{
+				{Targets: []string{"team-*"}, Paths: []string{"/uploads/**"}, Actions: []string{"write"}},
+			}},
+		},
+		Bindings: []*config.RBACBindingConfig{
+			{Role: "reader", Groups: []string{"devs"}, Providers: []string{"provider1"}},
+			{Role: "writer", Users: []string{"user1"}, Providers: []string{"provider1"}},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		cfg          *config.Config
+		inputURL     string
+		expectedBody string
+		expectedCode int
+	}{
+		{
+			name:         "no subject",
+			cfg:          &config.Config{RBAC: rbacCfg},
+			inputURL:     "http://localhost/rbac/permissions",
+			expectedCode: 400,
+			expectedBody: "user or group query parameter must be set",
+		},
+		{
+			name:         "no provider",
+			cfg:          &config.Config{RBAC: rbacCfg},
+			inputURL:     "http://localhost/rbac/permissions?user=user1&group=devs",
+			expectedCode: 400,
+			expectedBody: "provider query parameter must be set",
+		},
+		{
+			name:         "no rbac configuration",
+			cfg:          &config.Config{},
+			inputURL:     "http://localhost/rbac/permissions?user=user1&provider=provider1",
+			expectedCode: 200,
+			expectedBody: `{"permissions":[]}`,
+		},
+		{
+			name:         "user and group",
+			cfg:          &config.Config{RBAC: rbacCfg},
+			inputURL:     "http://localhost/rbac/permissions?user=user1&group=devs&provider=provider1",
+			expectedCode: 200,
+			expectedBody: `{"permissions":[
+				{"role":"reader","targets":["*"],"paths":["/**"],"actions":["read","list"]},
+				{"role":"writer","targets":["team-*"],"paths":["/uploads/**"],"actions":["write"]}
+			]}`,
+		},
+		{
+			name:         "binding restricted to another provider",
+			cfg:          &config.Config{RBAC: rbacCfg},
+			inputURL:     "http://localhost/rbac/permissions?user=user1&provider=provider2",
+			expectedCode: 200,
+			expectedBody: `{"permissions":[]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create go mock controller
+			ctrl := gomock.NewController(t)
+			cfgManagerMock := cmocks.NewMockManager(ctrl)
+
+			// Load configuration in manager
+			tt.cfg.InternalServer = &config.ServerConfig{
+				Compress: &config.ServerCompressConfig{
+					Enabled: &config.DefaultServerCompressEnabled,
+					Level:   config.DefaultServerCompressLevel,
+					Types:   config.DefaultServerCompressTypes,
+				},
+			}
+			cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(tt.cfg)
+
+			svr := &InternalServer{
+				logger:     log.NewLogger(),
+				cfgManager: cfgManagerMock,
+				metricsCl:  metricsCtx,
+			}
+			got := svr.generateInternalRouter()
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", tt.inputURL, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			got.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedCode == 200 {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			} else {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+//go:build integration
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	gmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/geoip/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
+)
+
+func TestIPFilter(t *testing.T) {
+	accessKey := "YOUR-ACCESSKEYID"
+	secretAccessKey := "YOUR-SECRETACCESSKEY"
+	region := "eu-central-1"
+	bucket := "test-bucket"
+
+	_, s3server, err := setupIsolationFakeS3(t, accessKey, secretAccessKey, region, bucket)
+	require.NoError(t, err)
+
+	defer s3server.Close()
+
+	svrCfg := &config.ServerConfig{
+		TrustedProxyPrefixes: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+	}
+
+	cfg := userIsolationConfig(s3server.URL, accessKey, secretAccessKey, region, bucket, svrCfg, &config.TracingConfig{})
+	cfg.GeoIP = &config.GeoIPConfig{DatabasePath: "/fake.mmdb"}
+	// Only private networks are allowed on target
+	cfg.Targets["target1"].IPFilter = &config.IPFilterConfig{
+		AllowPrefixes: []netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/8"),
+			netip.MustParsePrefix("192.168.0.0/16"),
+		},
+	}
+	// Clients from FR and from 10.0.0.66 are denied on resource
+	cfg.Targets["target1"].Resources[0].IPFilter = &config.IPFilterConfig{
+		DenyPrefixes:  []netip.Prefix{netip.MustParsePrefix("10.0.0.66/32")},
+		DenyCountries: []string{"FR"},
+	}
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	geoipManagerMock := gmocks.NewMockManager(ctrl)
+	geoipManagerMock.EXPECT().Country(gomock.Any()).AnyTimes().DoAndReturn(func(ip netip.Addr) (string, error) {
+		// Check if ip is in the fake FR network
+		if netip.MustParsePrefix("192.168.0.0/16").Contains(ip) {
+			return "FR", nil
+		}
+
+		return "", nil
+	})
+
+	logger := log.NewLogger()
+
+	tsvc, err := tracing.New(cfgManagerMock, logger)
+	require.NoError(t, err)
+
+	s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
+	require.NoError(t, s3Manager.Load())
+
+	svr := &Server{
+		logger:          logger,
+		cfgManager:      cfgManagerMock,
+		metricsCl:       metricsCtx,
+		tracingSvc:      tsvc,
+		s3clientManager: s3Manager,
+		webhookManager:  webhook.NewManager(cfgManagerMock, metricsCtx, logger),
+		geoipManager:    geoipManagerMock,
+	}
+	router, err := svr.generateRouter()
+	require.NoError(t, err)
+
+	tests := []struct {
+		headers      map[string]string
+		name         string
+		remoteAddr   string
+		expectedCode int
+	}{
+		{
+			name:         "allowed remote address",
+			remoteAddr:   "10.0.0.1:1234",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "remote address not allowed on target",
+			remoteAddr:   "172.16.0.1:1234",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "forwarded header ignored from untrusted remote address",
+			remoteAddr:   "172.16.0.1:1234",
+			headers:      map[string]string{"X-Forwarded-For": "10.0.0.1"},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "forged x-real-ip header ignored from untrusted remote address",
+			remoteAddr:   "172.16.0.1:1234",
+			headers:      map[string]string{"X-Real-IP": "10.0.0.1"},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "forwarded header used from trusted proxy",
+			remoteAddr:   "127.0.0.1:1234",
+			headers:      map[string]string{"X-Forwarded-For": "10.0.0.1"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "forwarded client not allowed on target",
+			remoteAddr:   "127.0.0.1:1234",
+			headers:      map[string]string{"X-Forwarded-For": "172.16.0.1"},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "ip denied on resource",
+			remoteAddr:   "10.0.0.66:1234",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "country denied on resource",
+			remoteAddr:   "192.168.1.1:1234",
+			expectedCode: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://localhost/mount/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.SetBasicAuth("alice", "pw-alice")
+
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+
+	// Without trusted proxies, forged headers must be ignored
+	svrCfg.TrustedProxyPrefixes = nil
+
+	router, err = svr.generateRouter()
+	require.NoError(t, err)
+
+	for _, h := range []string{"X-Real-IP", "X-Forwarded-For"} {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/mount/", nil)
+		req.RemoteAddr = "172.16.0.1:1234"
+		req.SetBasicAuth("alice", "pw-alice")
+		req.Header.Set(h, "10.0.0.1")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code, "forged %s header must be ignored without trusted proxies", h)
+	}
+}
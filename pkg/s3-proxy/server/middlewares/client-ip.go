@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/netip"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ClientIP returns a middleware that stores the client ip in request context (read it with middleware.GetClientIP).
+//
+// Without trusted proxies, only the connection remote address is used because X-Real-IP and
+// X-Forwarded-For headers can be forged by clients.
+//
+// With trusted proxies, the connection remote address is used unless it is a trusted proxy.
+// In this case, the X-Forwarded-For header is walked from right to left and the first ip which
+// isn't a trusted proxy is the client ip. When clientIPHeader is set (for example X-Real-IP),
+// this header is used instead: it must be set by trusted proxies as many proxies only append to
+// X-Forwarded-For and forward other headers sent by clients unchanged.
+func ClientIP(trustedProxies []netip.Prefix, clientIPHeader string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		// Check if trusted proxies are declared
+		if len(trustedProxies) == 0 {
+			return middleware.ClientIPFromRemoteAddr(next)
+		}
+
+		// Build trusted proxy prefixes for chi middleware
+		prefixes := make([]string, 0, len(trustedProxies))
+		for _, p := range trustedProxies {
+			prefixes = append(prefixes, p.String())
+		}
+
+		// Handler used when request comes from a trusted proxy
+		fromProxy := middleware.ClientIPFromXFF(prefixes...)(next)
+		// Check if client ip is read from a header set by trusted proxies
+		if clientIPHeader != "" {
+			fromProxy = middleware.ClientIPFromHeader(clientIPHeader)(next)
+		}
+
+		return middleware.ClientIPFromRemoteAddr(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get remote address ip
+			remoteIP := middleware.GetClientIPAddr(r.Context())
+			// Check if remote address is a trusted proxy
+			if remoteIP.IsValid() && isInPrefixes(remoteIP, trustedProxies) {
+				fromProxy.ServeHTTP(w, r)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
+
+// isInPrefixes returns true if ip is in one of the prefixes.
+func isInPrefixes(ip netip.Addr, prefixes []netip.Prefix) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,139 @@
+//go:build unit
+
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []netip.Prefix
+		clientIPHeader string
+		remoteAddr     string
+		headers        map[string]string
+		want           string
+	}{
+		{
+			name:       "no trusted proxies and no header",
+			remoteAddr: "10.0.0.1:1234",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "no trusted proxies and forged x-real-ip header",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Real-IP": "1.2.3.4"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "no trusted proxies and x-forwarded-for header",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:           "untrusted remote address with x-real-ip header",
+			trustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			remoteAddr:     "192.168.0.1:1234",
+			headers:        map[string]string{"X-Real-IP": "1.2.3.4"},
+			want:           "192.168.0.1",
+		},
+		{
+			name:           "untrusted remote address with x-forwarded-for header",
+			trustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			remoteAddr:     "192.168.0.1:1234",
+			headers:        map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			want:           "192.168.0.1",
+		},
+		{
+			name:           "trusted remote address without header",
+			trustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			remoteAddr:     "10.0.0.1:1234",
+			want:           "10.0.0.1",
+		},
+		{
+			name:           "trusted remote address with x-real-ip header without client ip header",
+			trustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			remoteAddr:     "10.0.0.1:1234",
+			headers:        map[string]string{"X-Real-IP": "1.2.3.4"},
+			want:           "10.0.0.1",
+		},
+		{
+			name:           "trusted proxy forwarding a spoofed x-real-ip header",
+			trustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			remoteAddr:     "10.0.0.1:1234",
+			headers: map[string]string{
+				"X-Real-IP":       "5.6.7.8",
+				"X-Forwarded-For": "1.2.3.4",
+			},
+			want: "1.2.3.4",
+		},
+		{
+			name:           "trusted remote address with x-real-ip client ip header",
+			trustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			clientIPHeader: "X-Real-IP",
+			remoteAddr:     "10.0.0.1:1234",
+			headers: map[string]string{
+				"X-Real-IP":       "1.2.3.4",
+				"X-Forwarded-For": "5.6.7.8",
+			},
+			want: "1.2.3.4",
+		},
+		{
+			name:           "untrusted remote address with x-real-ip client ip header",
+			trustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			clientIPHeader: "X-Real-IP",
+			remoteAddr:     "192.168.0.1:1234",
+			headers:        map[string]string{"X-Real-IP": "1.2.3.4"},
+			want:           "192.168.0.1",
+		},
+		{
+			name:           "trusted remote address without configured client ip header",
+			trustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			clientIPHeader: "X-Real-IP",
+			remoteAddr:     "10.0.0.1:1234",
+			want:           "10.0.0.1",
+		},
+		{
+			name:           "trusted remote address with x-forwarded-for header",
+			trustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			remoteAddr:     "10.0.0.1:1234",
+			headers:        map[string]string{"X-Forwarded-For": "5.6.7.8, 1.2.3.4, 10.0.0.2"},
+			want:           "1.2.3.4",
+		},
+		{
+			name:           "trusted remote address with ipv6 x-forwarded-for header",
+			trustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			remoteAddr:     "10.0.0.1:1234",
+			headers:        map[string]string{"X-Forwarded-For": "2001:db8::1"},
+			want:           "2001:db8::1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+
+			h := ClientIP(tt.trustedProxies, tt.clientIPHeader)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = middleware.GetClientIP(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+			req.RemoteAddr = tt.remoteAddr
+
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+//go:build integration
+
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
+)
+
+func TestPreActionHooks(t *testing.T) {
+	accessKey := "YOUR-ACCESSKEYID"
+	secretAccessKey := "YOUR-SECRETACCESSKEY"
+	region := "eu-central-1"
+	bucket := "test-bucket"
+
+	s3Client, s3server, err := setupIsolationFakeS3(t, accessKey, secretAccessKey, region, bucket)
+	require.NoError(t, err)
+
+	defer s3server.Close()
+
+	// Pre-action hook server deciding on the key
+	var (
+		mutex  sync.Mutex
+		bodies []*webhook.PreActionHookBody
+	)
+
+	hookServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		by, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		b := &webhook.PreActionHookBody{}
+		assert.NoError(t, json.Unmarshal(by, b))
+
+		mutex.Lock()
+		bodies = append(bodies, b)
+		mutex.Unlock()
+
+		var answer string
+
+		switch {
+		case strings.HasSuffix(b.Path, "escape.txt"):
+			answer = `{"allowed":true,"path":"/scanned/../../bob/secret.txt"}`
+		case strings.HasSuffix(b.Path, "unscoped.txt"):
+			answer = `{"allowed":true,"path":"/private/unscoped.txt"}`
+		case b.Action == webhook.DELETEAction:
+			answer = `{"allowed":false,"reason":"object is locked"}`
+		case strings.HasSuffix(b.Path, "denied.txt"):
+			answer = `{"allowed":false,"reason":"virus detected"}`
+		case strings.HasSuffix(b.Path, "rewrite.txt"):
+			answer = `{"allowed":true,"path":"/scanned/renamed.txt","metadata":{"scanned":"true"}}`
+		default:
+			answer = `{"allowed":true}`
+		}
+
+		_, _ = rw.Write([]byte(answer))
+	}))
+	defer hookServer.Close()
+
+	lastBody := func() *webhook.PreActionHookBody {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return bodies[len(bodies)-1]
+	}
+
+	cfg := userIsolationConfig(s3server.URL, accessKey, secretAccessKey, region, bucket, defaultIsolationServerConfig(), &config.TracingConfig{})
+	cfg.Targets["target1"].Actions.PUT.Config.PreActionHooks = []*config.PreActionHookConfig{
+		{
+			Method:              http.MethodPost,
+			URL:                 hookServer.URL,
+			Timeout:             config.DefaultPreActionHookTimeout,
+			RewritePathPrefixes: []string{"/scanned/"},
+		},
+	}
+	cfg.Targets["target1"].Actions.DELETE.Config = &config.DeleteActionConfigConfig{
+		PreActionHooks: []*config.PreActionHookConfig{
+			{
+				Method:              http.MethodPost,
+				URL:                 hookServer.URL,
+				Timeout:             config.DefaultPreActionHookTimeout,
+				RewritePathPrefixes: []string{"/scanned/"},
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	logger := log.NewLogger()
+
+	tsvc, err := tracing.New(cfgManagerMock, logger)
+	require.NoError(t, err)
+
+	s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
+	require.NoError(t, s3Manager.Load())
+
+	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
+	require.NoError(t, webhookManager.Load())
+
+	svr := &Server{
+		logger:          logger,
+		cfgManager:      cfgManagerMock,
+		metricsCl:       metricsCtx,
+		tracingSvc:      tsvc,
+		s3clientManager: s3Manager,
+		webhookManager:  webhookManager,
+	}
+	router, err := svr.generateRouter()
+	require.NoError(t, err)
+
+	do := func(method, url, body, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.SetBasicAuth("alice", "pw-alice")
+
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// Denied upload
+	w := do(http.MethodPut, "http://localhost/mount/", multipartBody(t, "denied.txt", "content"), multipartContentType())
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, webhook.PUTAction, lastBody().Action)
+	assert.Equal(t, "/denied.txt", lastBody().Path)
+	assert.Equal(t, "data/alice/denied.txt", lastBody().Key)
+	assert.Equal(t, &webhook.UserHookBody{Type: "BASIC", Identifier: "alice"}, lastBody().User)
+
+	_, err = s3Client.HeadObject(&s3.HeadObjectInput{Bucket: new(bucket), Key: new("data/alice/denied.txt")})
+	assert.Error(t, err, "denied upload must not be stored")
+
+	// Upload with rewritten key and metadata
+	w = do(http.MethodPut, "http://localhost/mount/", multipartBody(t, "rewrite.txt", "content"), multipartContentType())
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	_, err = s3Client.HeadObject(&s3.HeadObjectInput{Bucket: new(bucket), Key: new("data/alice/rewrite.txt")})
+	assert.Error(t, err, "upload must be stored on the rewritten key")
+
+	out, err := s3Client.HeadObject(&s3.HeadObjectInput{Bucket: new(bucket), Key: new("data/alice/scanned/renamed.txt")})
+	require.NoError(t, err)
+	assert.Equal(t, "true", *out.Metadata["Scanned"])
+
+	// Upload rewritten outside of rewrite path prefixes
+	w = do(http.MethodPut, "http://localhost/mount/", multipartBody(t, "unscoped.txt", "content"), multipartContentType())
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	_, err = s3Client.HeadObject(&s3.HeadObjectInput{Bucket: new(bucket), Key: new("data/alice/private/unscoped.txt")})
+	assert.Error(t, err, "upload rewritten outside of rewrite path prefixes must not be stored")
+
+	_, err = s3Client.HeadObject(&s3.HeadObjectInput{Bucket: new(bucket), Key: new("data/alice/unscoped.txt")})
+	assert.Error(t, err, "upload rewritten outside of rewrite path prefixes must not be stored")
+
+	// Upload rewritten outside of user folder
+	w = do(http.MethodPut, "http://localhost/mount/", multipartBody(t, "escape.txt", "content"), multipartContentType())
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	out, err = s3Client.HeadObject(&s3.HeadObjectInput{Bucket: new(bucket), Key: new("data/bob/secret.txt")})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("bob-secret")), *out.ContentLength, "escaping upload must not override other user objects")
+
+	_, err = s3Client.HeadObject(&s3.HeadObjectInput{Bucket: new(bucket), Key: new("data/alice/escape.txt")})
+	assert.Error(t, err, "escaping upload must not be stored")
+
+	// Denied removal
+	w = do(http.MethodDelete, "http://localhost/mount/secret.txt", "", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, webhook.DELETEAction, lastBody().Action)
+	assert.Equal(t, "/secret.txt", lastBody().Path)
+	assert.Equal(t, "data/alice/secret.txt", lastBody().Key)
+
+	_, err = s3Client.HeadObject(&s3.HeadObjectInput{Bucket: new(bucket), Key: new("data/alice/secret.txt")})
+	assert.NoError(t, err, "denied removal must keep the object")
+
+	// Removal rewritten outside of user folder
+	w = do(http.MethodDelete, "http://localhost/mount/escape.txt", "", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	_, err = s3Client.HeadObject(&s3.HeadObjectInput{Bucket: new(bucket), Key: new("data/bob/secret.txt")})
+	assert.NoError(t, err, "escaping removal must keep other user objects")
+}
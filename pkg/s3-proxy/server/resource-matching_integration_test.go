@@ -0,0 +1,161 @@
+//go:build integration
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
+)
+
+func TestResourceMatching(t *testing.T) {
+	accessKey := "YOUR-ACCESSKEYID"
+	secretAccessKey := "YOUR-SECRETACCESSKEY"
+	region := "eu-central-1"
+	bucket := "test-bucket"
+
+	_, s3server, err := setupIsolationFakeS3(t, accessKey, secretAccessKey, region, bucket)
+	require.NoError(t, err)
+
+	defer s3server.Close()
+
+	cfg := userIsolationConfig(s3server.URL, accessKey, secretAccessKey, region, bucket, &config.ServerConfig{}, &config.TracingConfig{})
+	tgt := cfg.Targets["target1"]
+	// Deny resources are declared after the catch-all resource and rely on priority
+	tgt.Resources = append(tgt.Resources,
+		&config.Resource{
+			Path:     "/mount/sub/**",
+			Methods:  []string{"GET"},
+			Priority: 10,
+			Deny:     true,
+		},
+		&config.Resource{
+			Path:     "/mount/**",
+			Host:     "*.example.com",
+			Methods:  []string{"GET"},
+			Priority: 10,
+			Deny:     true,
+		},
+	)
+
+	do := buildIsolationRouter(t, cfg)
+
+	// Catch-all resource is still used outside of deny resources
+	w := do(http.MethodGet, "http://localhost/mount/secret.txt", "alice", "pw-alice", "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice-secret", w.Body.String())
+
+	// Deny resource has a higher priority than the catch-all resource
+	w = do(http.MethodGet, "http://localhost/mount/sub/nested.txt", "alice", "pw-alice", "", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Deny resource restricted to a host
+	w = do(http.MethodGet, "http://files.example.com/mount/secret.txt", "alice", "pw-alice", "", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestResourceMatchingForgedHost(t *testing.T) {
+	accessKey := "YOUR-ACCESSKEYID"
+	secretAccessKey := "YOUR-SECRETACCESSKEY"
+	region := "eu-central-1"
+	bucket := "test-bucket"
+
+	_, s3server, err := setupIsolationFakeS3(t, accessKey, secretAccessKey, region, bucket)
+	require.NoError(t, err)
+
+	defer s3server.Close()
+
+	svrCfg := &config.ServerConfig{
+		TrustedProxyPrefixes: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+	}
+
+	cfg := userIsolationConfig(s3server.URL, accessKey, secretAccessKey, region, bucket, svrCfg, &config.TracingConfig{})
+	tgt := cfg.Targets["target1"]
+	// Public vhost doesn't need authentication
+	tgt.Resources = append(tgt.Resources,
+		&config.Resource{
+			Path:      "/mount/**",
+			Host:      "public.example.com",
+			Methods:   []string{"GET"},
+			Priority:  10,
+			WhiteList: new(true),
+		},
+	)
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	logger := log.NewLogger()
+
+	tsvc, err := tracing.New(cfgManagerMock, logger)
+	require.NoError(t, err)
+
+	s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
+	require.NoError(t, s3Manager.Load())
+
+	svr := &Server{
+		logger:          logger,
+		cfgManager:      cfgManagerMock,
+		metricsCl:       metricsCtx,
+		tracingSvc:      tsvc,
+		s3clientManager: s3Manager,
+		webhookManager:  webhook.NewManager(cfgManagerMock, metricsCtx, logger),
+	}
+	router, err := svr.generateRouter()
+	require.NoError(t, err)
+
+	tests := []struct {
+		headers      map[string]string
+		name         string
+		remoteAddr   string
+		expectedCode int
+	}{
+		{
+			name:         "forged x-forwarded-host from client",
+			remoteAddr:   "192.168.0.1:1234",
+			headers:      map[string]string{"X-Forwarded-Host": "public.example.com"},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "forged forwarded from client",
+			remoteAddr:   "192.168.0.1:1234",
+			headers:      map[string]string{"Forwarded": "host=public.example.com"},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			// Whitelist resource is selected and user isolation rejects the anonymous user
+			name:         "x-forwarded-host from trusted proxy",
+			remoteAddr:   "127.0.0.1:1234",
+			headers:      map[string]string{"X-Forwarded-Host": "public.example.com"},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://localhost/mount/secret.txt", nil)
+			req.RemoteAddr = tt.remoteAddr
+
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
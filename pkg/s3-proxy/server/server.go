@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -13,12 +14,20 @@ import (
 	"github.com/go-chi/httptracer"
 	"github.com/thoas/go-funk"
 
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/audit"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/authentication"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/authorization"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/impersonation"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/lockout"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/session"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/bucket"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/geoip"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/ipfilter"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/limiter"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/quota"
 	responsehandler "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/response-handler"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/server/middlewares"
@@ -35,6 +44,13 @@ type Server struct {
 	tracingSvc      tracing.Service
 	s3clientManager s3client.Manager
 	webhookManager  webhook.Manager
+	quotaManager    quota.Manager
+	limiterManager  limiter.Manager
+	sessionManager  session.Manager
+	regoManager     authorization.RegoManager
+	lockoutManager  lockout.Manager
+	auditManager    audit.Manager
+	geoipManager    geoip.Manager
 }
 
 func NewServer(
@@ -44,6 +60,13 @@ func NewServer(
 	tracingSvc tracing.Service,
 	s3clientManager s3client.Manager,
 	webhookManager webhook.Manager,
+	quotaManager quota.Manager,
+	limiterManager limiter.Manager,
+	sessionManager session.Manager,
+	regoManager authorization.RegoManager,
+	lockoutManager lockout.Manager,
+	auditManager audit.Manager,
+	geoipManager geoip.Manager,
 ) *Server {
 	return &Server{
 		logger:          logger,
@@ -52,6 +75,13 @@ func NewServer(
 		tracingSvc:      tracingSvc,
 		s3clientManager: s3clientManager,
 		webhookManager:  webhookManager,
+		quotaManager:    quotaManager,
+		limiterManager:  limiterManager,
+		sessionManager:  sessionManager,
+		regoManager:     regoManager,
+		lockoutManager:  lockoutManager,
+		auditManager:    auditManager,
+		geoipManager:    geoipManager,
 	}
 }
 
@@ -68,7 +98,8 @@ func (svr *Server) Listen() error {
 	}
 
 	// Check error
-	if err != nil {
+	// Server closed error is raised on graceful shutdown.
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return errors.WithStack(err)
 	}
 
@@ -76,6 +107,11 @@ func (svr *Server) Listen() error {
 	return nil
 }
 
+// Shutdown will gracefully stop the server and wait for in flight requests.
+func (svr *Server) Shutdown(ctx context.Context) error {
+	return errors.WithStack(svr.server.Shutdown(ctx))
+}
+
 func (svr *Server) GenerateServer() error {
 	// Get configuration
 	cfg := svr.cfgManager.GetConfig()
@@ -132,7 +168,10 @@ func (svr *Server) generateRouter() (http.Handler, error) {
 	cfg := svr.cfgManager.GetConfig()
 
 	// Create authentication service
-	authenticationSvc := authentication.NewAuthenticationService(cfg, svr.cfgManager, svr.metricsCl)
+	authenticationSvc := authentication.NewAuthenticationService(cfg, svr.cfgManager, svr.metricsCl, svr.sessionManager, svr.lockoutManager)
+
+	// Check if audit is enabled
+	auditEnabled := svr.auditManager != nil && cfg.Audit != nil && cfg.Audit.Enabled
 
 	// Create router
 	r := chi.NewRouter()
@@ -155,9 +194,7 @@ func (svr *Server) generateRouter() (http.Handler, error) {
 	}
 
 	r.Use(middleware.RequestID)
-	r.Use(middleware.ClientIPFromXFF())
-	r.Use(middleware.ClientIPFromRemoteAddr)
-	r.Use(middleware.ClientIPFromHeader("X-Real-IP"))
+	r.Use(middlewares.ClientIP(cfg.Server.TrustedProxyPrefixes, cfg.Server.ClientIPHeader))
 	// Manage tracing
 	// Create http tracer configuration
 	httptraCfg := httptracer.Config{
@@ -175,6 +212,11 @@ func (svr *Server) generateRouter() (http.Handler, error) {
 		tracing.GetTraceIDFromRequest,
 	))
 	r.Use(log.HTTPAddLoggerToContextMiddleware())
+	// Check if GeoIP manager exists
+	if svr.geoipManager != nil {
+		// Add client country in context
+		r.Use(geoip.HTTPMiddleware(svr.geoipManager))
+	}
 	r.Use(svr.metricsCl.Instrument("business", cfg.Metrics))
 	// Recover panic
 	r.Use(middleware.Recoverer)
@@ -202,6 +244,38 @@ func (svr *Server) generateRouter() (http.Handler, error) {
 		}
 	}
 
+	// Check if auth if enabled and jwt enabled
+	if cfg.AuthProviders != nil && cfg.AuthProviders.JWT != nil {
+		for k, v := range cfg.AuthProviders.JWT {
+			// Load jwt provider
+			err := authenticationSvc.LoadJWTProvider(k, v)
+			// Check error
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Check if auth if enabled and ldap enabled
+	if cfg.AuthProviders != nil && cfg.AuthProviders.LDAP != nil {
+		for k, v := range cfg.AuthProviders.LDAP {
+			// Load ldap provider
+			err := authenticationSvc.LoadLDAPProvider(k, v)
+			// Check error
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Check if auth if enabled and hmac enabled
+	if cfg.AuthProviders != nil && cfg.AuthProviders.HMAC != nil {
+		for k, v := range cfg.AuthProviders.HMAC {
+			// Load hmac provider
+			authenticationSvc.LoadHMACProvider(k, v)
+		}
+	}
+
 	notFoundHandler := func(w http.ResponseWriter, r *http.Request) {
 		// Answer with general not found handler
 		responsehandler.GeneralNotFoundError(r, w, svr.cfgManager)
@@ -226,6 +300,11 @@ func (svr *Server) generateRouter() (http.Handler, error) {
 	if cfg.ListTargets.Enabled {
 		// Create new router
 		rt := chi.NewRouter()
+		// Check if audit is enabled
+		if auditEnabled {
+			// Add audit middleware first to see final status
+			rt.Use(audit.HTTPMiddleware(svr.auditManager, ""))
+		}
 		// Add middleware in order to add response handler
 		rt.Use(responsehandler.HTTPMiddleware(svr.cfgManager, ""))
 		// Make list of resources from resource
@@ -240,8 +319,11 @@ func (svr *Server) generateRouter() (http.Handler, error) {
 				// Add authentication middleware to router
 				rt2 = rt2.With(authenticationSvc.Middleware(resources))
 
+				// Add impersonation middleware to router
+				rt2 = rt2.With(impersonation.HTTPMiddleware(svr.cfgManager))
+
 				// Add authorization middleware to router
-				rt2 = rt2.With(authorization.Middleware(svr.cfgManager, svr.metricsCl))
+				rt2 = rt2.With(authorization.Middleware(svr.cfgManager, svr.metricsCl, svr.regoManager, ""))
 
 				rt2.Get("/", func(_ http.ResponseWriter, req *http.Request) {
 					// Get response handler
@@ -281,17 +363,38 @@ func (svr *Server) generateRouter() (http.Handler, error) {
 		// Loop over path list
 		funk.ForEach(tgt.Mount.Path, func(path string) {
 			rt.Route(path, func(rt2 chi.Router) {
+				// Check if audit is enabled
+				if auditEnabled {
+					// Add audit middleware first to see final status
+					rt2.Use(audit.HTTPMiddleware(svr.auditManager, targetKey))
+				}
 				// Add middleware in order to add response handler
 				rt2.Use(responsehandler.HTTPMiddleware(svr.cfgManager, targetKey))
 
 				// Add Bucket request context middleware to initialize it
-				rt2.Use(bucket.HTTPMiddleware(tgt, path, svr.s3clientManager, svr.webhookManager))
+				rt2.Use(bucket.HTTPMiddleware(
+					tgt,
+					path,
+					svr.s3clientManager,
+					svr.webhookManager,
+					svr.quotaManager,
+					svr.limiterManager,
+				))
+
+				// Check if target has an ip filter
+				if tgt.IPFilter != nil {
+					// Add ip filter middleware before authentication
+					rt2.Use(ipfilter.HTTPMiddleware(tgt))
+				}
 
 				// Add authentication middleware to router
 				rt2.Use(authenticationSvc.Middleware(tgt.Resources))
 
+				// Add impersonation middleware to router
+				rt2.Use(impersonation.HTTPMiddleware(svr.cfgManager))
+
 				// Add authorization middleware to router
-				rt2.Use(authorization.Middleware(svr.cfgManager, svr.metricsCl))
+				rt2.Use(authorization.Middleware(svr.cfgManager, svr.metricsCl, svr.regoManager, targetKey))
 
 				// Check if HEAD action is enabled
 				if tgt.Actions.HEAD != nil && tgt.Actions.HEAD.Enabled { //nolint:dupl
@@ -450,6 +553,7 @@ func (svr *Server) generateRouter() (http.Handler, error) {
 							IfNoneMatch:       ifNoneMatch,
 							IfUnmodifiedSince: ifUnmodifiedSince,
 							Range:             byteRange,
+							AcceptEncoding:    req.Header.Get("Accept-Encoding"),
 						})
 					})
 				}
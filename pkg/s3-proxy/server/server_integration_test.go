@@ -4,10 +4,14 @@ package server
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
@@ -24,9 +28,16 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/audit"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/authorization"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/lockout"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/session"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
 	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/geoip"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/limiter"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/quota"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
@@ -59,6 +70,17 @@ func TestPublicRouter(t *testing.T) {
 			Types:   config.DefaultServerCompressTypes,
 		},
 	}
+	mtlsCert := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:         "user1",
+			OrganizationalUnit: []string{"Platform Team"},
+		},
+		SerialNumber: big.NewInt(1),
+	}
+	mtlsConnectionState := &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{mtlsCert},
+		VerifiedChains:   [][]*x509.Certificate{{mtlsCert}},
+	}
 
 	type args struct {
 		cfg *config.Config
@@ -74,6 +96,7 @@ func TestPublicRouter(t *testing.T) {
 		inputFileName                      string
 		inputFileKey                       string
 		inputHeaders                       map[string]string
+		inputTLS                           *tls.ConnectionState
 		expectedCode                       int
 		expectedBody                       string
 		expectedBodyRegex                  string
@@ -1150,6 +1173,270 @@ func TestPublicRouter(t *testing.T) {
 				// "Content-Type":  "text/plain; charset=utf-8", // Testing implementation don't support it...
 			},
 		},
+		{
+			name: "HEAD a file with success in case of valid basic auth with password hash",
+			args: args{
+				cfg: &config.Config{
+					Server:      svrCfg,
+					ListTargets: &config.ListTargetsConfig{},
+					Tracing:     tracingConfig,
+					Templates:   testsDefaultGeneralTemplateConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						Basic: map[string]*config.BasicAuthConfig{
+							"provider1": {
+								Realm: "realm1",
+							},
+						},
+					},
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Resources: []*config.Resource{
+								{
+									Path:     "/mount/folder1/*",
+									Methods:  []string{"HEAD"},
+									Provider: "provider1",
+									Basic: &config.ResourceBasic{
+										Credentials: []*config.BasicAuthUserConfig{
+											{
+												User: "user1",
+												PasswordHash: &config.CredentialConfig{
+													Value: "$2a$05$0YWsx/1Ac9FaP8NjL.InL.dyE9hQLz..ScvpIXSwpMp45EQt8ywqC",
+												},
+											},
+										},
+									},
+								},
+							},
+							Actions: &config.ActionsConfig{
+								HEAD: &config.HeadActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod:        "HEAD",
+			inputURL:           "http://localhost/mount/folder1/test.txt",
+			inputBasicUser:     "user1",
+			inputBasicPassword: "pass",
+			expectedCode:       200,
+			expectedEmptyBody:  true,
+			expectedHeaders: map[string]string{
+				"Cache-Control": "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+			},
+		},
+		{
+			name: "HEAD a file with unauthorized error in case of wrong basic auth password with password hash",
+			args: args{
+				cfg: &config.Config{
+					Server:      svrCfg,
+					ListTargets: &config.ListTargetsConfig{},
+					Tracing:     tracingConfig,
+					Templates:   testsDefaultGeneralTemplateConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						Basic: map[string]*config.BasicAuthConfig{
+							"provider1": {
+								Realm: "realm1",
+							},
+						},
+					},
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Resources: []*config.Resource{
+								{
+									Path:     "/mount/folder1/*",
+									Methods:  []string{"HEAD"},
+									Provider: "provider1",
+									Basic: &config.ResourceBasic{
+										Credentials: []*config.BasicAuthUserConfig{
+											{
+												User: "user1",
+												PasswordHash: &config.CredentialConfig{
+													Value: "$2a$05$0YWsx/1Ac9FaP8NjL.InL.dyE9hQLz..ScvpIXSwpMp45EQt8ywqC",
+												},
+											},
+										},
+									},
+								},
+							},
+							Actions: &config.ActionsConfig{
+								HEAD: &config.HeadActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod:        "HEAD",
+			inputURL:           "http://localhost/mount/folder1/test.txt",
+			inputBasicUser:     "user1",
+			inputBasicPassword: "pass1",
+			expectedCode:       401,
+			expectedEmptyBody:  true,
+			expectedHeaders: map[string]string{
+				"Cache-Control":    "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+				"Content-Type":     "text/html; charset=utf-8",
+				"Www-Authenticate": "Basic realm=\"realm1\"",
+			},
+		},
+		{
+			name: "HEAD a file with success in case of valid basic auth with htpasswd credentials",
+			args: args{
+				cfg: &config.Config{
+					Server:      svrCfg,
+					ListTargets: &config.ListTargetsConfig{},
+					Tracing:     tracingConfig,
+					Templates:   testsDefaultGeneralTemplateConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						Basic: map[string]*config.BasicAuthConfig{
+							"provider1": {
+								Realm: "realm1",
+							},
+						},
+					},
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Resources: []*config.Resource{
+								{
+									Path:     "/mount/folder1/*",
+									Methods:  []string{"HEAD"},
+									Provider: "provider1",
+									Basic: &config.ResourceBasic{
+										HtpasswdCredentials: []*config.BasicAuthUserConfig{
+											{
+												User: "user1",
+												PasswordHash: &config.CredentialConfig{
+													Value: "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5",
+												},
+											},
+										},
+									},
+								},
+							},
+							Actions: &config.ActionsConfig{
+								HEAD: &config.HeadActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod:        "HEAD",
+			inputURL:           "http://localhost/mount/folder1/test.txt",
+			inputBasicUser:     "user1",
+			inputBasicPassword: "Hello world!",
+			expectedCode:       200,
+			expectedEmptyBody:  true,
+			expectedHeaders: map[string]string{
+				"Cache-Control": "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+			},
+		},
+		{
+			name: "HEAD a file with unauthorized error in case of wrong basic auth password with htpasswd credentials",
+			args: args{
+				cfg: &config.Config{
+					Server:      svrCfg,
+					ListTargets: &config.ListTargetsConfig{},
+					Tracing:     tracingConfig,
+					Templates:   testsDefaultGeneralTemplateConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						Basic: map[string]*config.BasicAuthConfig{
+							"provider1": {
+								Realm: "realm1",
+							},
+						},
+					},
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Resources: []*config.Resource{
+								{
+									Path:     "/mount/folder1/*",
+									Methods:  []string{"HEAD"},
+									Provider: "provider1",
+									Basic: &config.ResourceBasic{
+										HtpasswdCredentials: []*config.BasicAuthUserConfig{
+											{
+												User: "user1",
+												PasswordHash: &config.CredentialConfig{
+													Value: "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5",
+												},
+											},
+										},
+									},
+								},
+							},
+							Actions: &config.ActionsConfig{
+								HEAD: &config.HeadActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod:        "HEAD",
+			inputURL:           "http://localhost/mount/folder1/test.txt",
+			inputBasicUser:     "user1",
+			inputBasicPassword: "Hello world",
+			expectedCode:       401,
+			expectedEmptyBody:  true,
+			expectedHeaders: map[string]string{
+				"Cache-Control":    "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+				"Content-Type":     "text/html; charset=utf-8",
+				"Www-Authenticate": "Basic realm=\"realm1\"",
+			},
+		},
 		{
 			name: "HEAD a file with success in case of whitelist",
 			args: args{
@@ -3969,14 +4256,865 @@ func TestPublicRouter(t *testing.T) {
 			},
 		},
 		{
-			name: "GET index document with index document enabled with success",
+			name: "GET target list protected with api key authentication with success with groups authorization",
 			args: args{
 				cfg: &config.Config{
-					Server:      svrCfg,
-					ListTargets: &config.ListTargetsConfig{},
-					Tracing:     tracingConfig,
-					Templates:   testsDefaultGeneralTemplateConfig,
-					Targets: map[string]*config.TargetConfig{
+					Server: svrCfg,
+					ListTargets: &config.ListTargetsConfig{
+						Enabled: true,
+						Mount: &config.MountConfig{
+							Path: []string{"/"},
+						},
+						Resource: &config.Resource{
+							Path:     "/*",
+							Methods:  []string{"GET"},
+							Provider: "provider1",
+							APIKey: &config.ResourceHeaderOIDC{
+								AuthorizationAccesses: []*config.HeaderOIDCAuthorizationAccess{{
+									Group: "ci",
+								}},
+							},
+						},
+					},
+					Tracing: tracingConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						APIKey: map[string]*config.APIKeyAuthConfig{
+							"provider1": {
+								Header:     "X-API-Key",
+								QueryParam: "api_key",
+								Keys: []*config.APIKeyConfig{
+									{
+										ID:     "ci",
+										Hash:   "sha256:c0ffee:845fbf724a36baee82ee28731541c0d9536ed6f155d7736de0b939545d03d958",
+										Owner:  "ci-pipeline",
+										Groups: []string{"ci"},
+									},
+								},
+							},
+						},
+					},
+					Templates: testsDefaultGeneralTemplateConfig,
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Actions: &config.ActionsConfig{
+								GET: &config.GetActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod: "GET",
+			inputURL:    "http://localhost/",
+			inputHeaders: map[string]string{
+				"X-API-Key": "ci-secret-key",
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `<!DOCTYPE html>
+<html>
+  <body>
+    <h1>Target buckets list</h1>
+    <ul>
+        <li>target1:
+          <ul>
+            <li><a href="http://localhost/mount/">http://localhost/mount/</a></li>
+          </ul>
+        </li>
+    </ul>
+  </body>
+</html>`,
+			expectedHeaders: map[string]string{
+				"Cache-Control": "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+				"Content-Type":  "text/html; charset=utf-8",
+			},
+		},
+		{
+			name: "GET target list protected with api key authentication with success with query parameter",
+			args: args{
+				cfg: &config.Config{
+					Server: svrCfg,
+					ListTargets: &config.ListTargetsConfig{
+						Enabled: true,
+						Mount: &config.MountConfig{
+							Path: []string{"/"},
+						},
+						Resource: &config.Resource{
+							Path:     "/*",
+							Methods:  []string{"GET"},
+							Provider: "provider1",
+							APIKey: &config.ResourceHeaderOIDC{
+								AuthorizationAccesses: []*config.HeaderOIDCAuthorizationAccess{{
+									Group: "ci",
+								}},
+							},
+						},
+					},
+					Tracing: tracingConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						APIKey: map[string]*config.APIKeyAuthConfig{
+							"provider1": {
+								Header:     "X-API-Key",
+								QueryParam: "api_key",
+								Keys: []*config.APIKeyConfig{
+									{
+										ID:     "ci",
+										Hash:   "sha256:c0ffee:845fbf724a36baee82ee28731541c0d9536ed6f155d7736de0b939545d03d958",
+										Owner:  "ci-pipeline",
+										Groups: []string{"ci"},
+									},
+								},
+							},
+						},
+					},
+					Templates: testsDefaultGeneralTemplateConfig,
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Actions: &config.ActionsConfig{
+								GET: &config.GetActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod:  "GET",
+			inputURL:     "http://localhost/?api_key=ci-secret-key",
+			inputHeaders: map[string]string{},
+			expectedCode: http.StatusOK,
+			expectedBody: `<!DOCTYPE html>
+<html>
+  <body>
+    <h1>Target buckets list</h1>
+    <ul>
+        <li>target1:
+          <ul>
+            <li><a href="http://localhost/mount/">http://localhost/mount/</a></li>
+          </ul>
+        </li>
+    </ul>
+  </body>
+</html>`,
+			expectedHeaders: map[string]string{
+				"Cache-Control": "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+				"Content-Type":  "text/html; charset=utf-8",
+			},
+		},
+		{
+			name: "GET target list protected with api key authentication without key",
+			args: args{
+				cfg: &config.Config{
+					Server: svrCfg,
+					ListTargets: &config.ListTargetsConfig{
+						Enabled: true,
+						Mount: &config.MountConfig{
+							Path: []string{"/"},
+						},
+						Resource: &config.Resource{
+							Path:     "/*",
+							Methods:  []string{"GET"},
+							Provider: "provider1",
+							APIKey: &config.ResourceHeaderOIDC{
+								AuthorizationAccesses: []*config.HeaderOIDCAuthorizationAccess{{
+									Group: "ci",
+								}},
+							},
+						},
+					},
+					Tracing: tracingConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						APIKey: map[string]*config.APIKeyAuthConfig{
+							"provider1": {
+								Header:     "X-API-Key",
+								QueryParam: "api_key",
+								Keys: []*config.APIKeyConfig{
+									{
+										ID:     "ci",
+										Hash:   "sha256:c0ffee:845fbf724a36baee82ee28731541c0d9536ed6f155d7736de0b939545d03d958",
+										Owner:  "ci-pipeline",
+										Groups: []string{"ci"},
+									},
+								},
+							},
+						},
+					},
+					Templates: testsDefaultGeneralTemplateConfig,
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Actions: &config.ActionsConfig{
+								GET: &config.GetActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod:  "GET",
+			inputURL:     "http://localhost/",
+			inputHeaders: map[string]string{},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `<!DOCTYPE html>
+<html>
+  <body>
+    <h1>Unauthorized</h1>
+    <p>no api key detected in request</p>
+  </body>
+</html>`,
+			expectedHeaders: map[string]string{
+				"Cache-Control": "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+				"Content-Type":  "text/html; charset=utf-8",
+			},
+		},
+		{
+			name: "GET target list protected with api key authentication with invalid key",
+			args: args{
+				cfg: &config.Config{
+					Server: svrCfg,
+					ListTargets: &config.ListTargetsConfig{
+						Enabled: true,
+						Mount: &config.MountConfig{
+							Path: []string{"/"},
+						},
+						Resource: &config.Resource{
+							Path:     "/*",
+							Methods:  []string{"GET"},
+							Provider: "provider1",
+							APIKey: &config.ResourceHeaderOIDC{
+								AuthorizationAccesses: []*config.HeaderOIDCAuthorizationAccess{{
+									Group: "ci",
+								}},
+							},
+						},
+					},
+					Tracing: tracingConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						APIKey: map[string]*config.APIKeyAuthConfig{
+							"provider1": {
+								Header:     "X-API-Key",
+								QueryParam: "api_key",
+								Keys: []*config.APIKeyConfig{
+									{
+										ID:     "ci",
+										Hash:   "sha256:c0ffee:845fbf724a36baee82ee28731541c0d9536ed6f155d7736de0b939545d03d958",
+										Owner:  "ci-pipeline",
+										Groups: []string{"ci"},
+									},
+								},
+							},
+						},
+					},
+					Templates: testsDefaultGeneralTemplateConfig,
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Actions: &config.ActionsConfig{
+								GET: &config.GetActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod: "GET",
+			inputURL:    "http://localhost/",
+			inputHeaders: map[string]string{
+				"X-API-Key": "fake-key",
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `<!DOCTYPE html>
+<html>
+  <body>
+    <h1>Unauthorized</h1>
+    <p>invalid api key</p>
+  </body>
+</html>`,
+			expectedHeaders: map[string]string{
+				"Cache-Control": "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+				"Content-Type":  "text/html; charset=utf-8",
+			},
+		},
+		{
+			name: "GET target list protected with api key authentication with expired key",
+			args: args{
+				cfg: &config.Config{
+					Server: svrCfg,
+					ListTargets: &config.ListTargetsConfig{
+						Enabled: true,
+						Mount: &config.MountConfig{
+							Path: []string{"/"},
+						},
+						Resource: &config.Resource{
+							Path:     "/*",
+							Methods:  []string{"GET"},
+							Provider: "provider1",
+							APIKey: &config.ResourceHeaderOIDC{
+								AuthorizationAccesses: []*config.HeaderOIDCAuthorizationAccess{{
+									Group: "ci",
+								}},
+							},
+						},
+					},
+					Tracing: tracingConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						APIKey: map[string]*config.APIKeyAuthConfig{
+							"provider1": {
+								Header:     "X-API-Key",
+								QueryParam: "api_key",
+								Keys: []*config.APIKeyConfig{
+									{
+										ID:        "ci",
+										Hash:      "sha256:c0ffee:845fbf724a36baee82ee28731541c0d9536ed6f155d7736de0b939545d03d958",
+										Owner:     "ci-pipeline",
+										Groups:    []string{"ci"},
+										ExpiresAt: time.Now().Add(-time.Hour),
+									},
+								},
+							},
+						},
+					},
+					Templates: testsDefaultGeneralTemplateConfig,
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Actions: &config.ActionsConfig{
+								GET: &config.GetActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod: "GET",
+			inputURL:    "http://localhost/",
+			inputHeaders: map[string]string{
+				"X-API-Key": "ci-secret-key",
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `<!DOCTYPE html>
+<html>
+  <body>
+    <h1>Unauthorized</h1>
+    <p>api key ci expired</p>
+  </body>
+</html>`,
+			expectedHeaders: map[string]string{
+				"Cache-Control": "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+				"Content-Type":  "text/html; charset=utf-8",
+			},
+		},
+		{
+			name: "GET target list protected with api key authentication with forbidden path scope",
+			args: args{
+				cfg: &config.Config{
+					Server: svrCfg,
+					ListTargets: &config.ListTargetsConfig{
+						Enabled: true,
+						Mount: &config.MountConfig{
+							Path: []string{"/"},
+						},
+						Resource: &config.Resource{
+							Path:     "/*",
+							Methods:  []string{"GET"},
+							Provider: "provider1",
+							APIKey: &config.ResourceHeaderOIDC{
+								AuthorizationAccesses: []*config.HeaderOIDCAuthorizationAccess{{
+									Group: "ci",
+								}},
+							},
+						},
+					},
+					Tracing: tracingConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						APIKey: map[string]*config.APIKeyAuthConfig{
+							"provider1": {
+								Header:     "X-API-Key",
+								QueryParam: "api_key",
+								Keys: []*config.APIKeyConfig{
+									{
+										ID:     "ci",
+										Hash:   "sha256:c0ffee:845fbf724a36baee82ee28731541c0d9536ed6f155d7736de0b939545d03d958",
+										Owner:  "ci-pipeline",
+										Groups: []string{"ci"},
+										Paths:  []string{"/mount/**"},
+									},
+								},
+							},
+						},
+					},
+					Templates: testsDefaultGeneralTemplateConfig,
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Actions: &config.ActionsConfig{
+								GET: &config.GetActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod: "GET",
+			inputURL:    "http://localhost/",
+			inputHeaders: map[string]string{
+				"X-API-Key": "ci-secret-key",
+			},
+			expectedCode: http.StatusForbidden,
+			expectedBody: `<!DOCTYPE html>
+<html>
+  <body>
+    <h1>Forbidden</h1>
+    <p>api key ci not allowed on path /</p>
+  </body>
+</html>`,
+			expectedHeaders: map[string]string{
+				"Cache-Control": "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+				"Content-Type":  "text/html; charset=utf-8",
+			},
+		},
+		{
+			name: "GET target list protected with mtls authentication with success with groups authorization",
+			args: args{
+				cfg: &config.Config{
+					Server: svrCfg,
+					ListTargets: &config.ListTargetsConfig{
+						Enabled: true,
+						Mount: &config.MountConfig{
+							Path: []string{"/"},
+						},
+						Resource: &config.Resource{
+							Path:     "/*",
+							Methods:  []string{"GET"},
+							Provider: "provider1",
+							MTLS: &config.ResourceHeaderOIDC{
+								AuthorizationAccesses: []*config.HeaderOIDCAuthorizationAccess{{
+									Group: "admins",
+								}},
+							},
+						},
+					},
+					Tracing: tracingConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						MTLS: map[string]*config.MTLSAuthConfig{
+							"provider1": {
+								UsernameField: config.MTLSFieldCommonName,
+								GroupsFields:  []string{config.MTLSFieldOrganizationalUnit},
+								GroupMappings: []*config.MTLSGroupMappingConfig{
+									{Value: "Platform Team", Group: "admins"},
+								},
+							},
+						},
+					},
+					Templates: testsDefaultGeneralTemplateConfig,
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Actions: &config.ActionsConfig{
+								GET: &config.GetActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod:  "GET",
+			inputURL:     "http://localhost/",
+			inputTLS:     mtlsConnectionState,
+			expectedCode: http.StatusOK,
+			expectedBody: `<!DOCTYPE html>
+<html>
+  <body>
+    <h1>Target buckets list</h1>
+    <ul>
+        <li>target1:
+          <ul>
+            <li><a href="http://localhost/mount/">http://localhost/mount/</a></li>
+          </ul>
+        </li>
+    </ul>
+  </body>
+</html>`,
+			expectedHeaders: map[string]string{
+				"Cache-Control": "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+				"Content-Type":  "text/html; charset=utf-8",
+			},
+		},
+		{
+			name: "GET target list protected with mtls authentication without client certificate",
+			args: args{
+				cfg: &config.Config{
+					Server: svrCfg,
+					ListTargets: &config.ListTargetsConfig{
+						Enabled: true,
+						Mount: &config.MountConfig{
+							Path: []string{"/"},
+						},
+						Resource: &config.Resource{
+							Path:     "/*",
+							Methods:  []string{"GET"},
+							Provider: "provider1",
+							MTLS: &config.ResourceHeaderOIDC{
+								AuthorizationAccesses: []*config.HeaderOIDCAuthorizationAccess{{
+									Group: "admins",
+								}},
+							},
+						},
+					},
+					Tracing: tracingConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						MTLS: map[string]*config.MTLSAuthConfig{
+							"provider1": {
+								UsernameField: config.MTLSFieldCommonName,
+								GroupsFields:  []string{config.MTLSFieldOrganizationalUnit},
+								GroupMappings: []*config.MTLSGroupMappingConfig{
+									{Value: "Platform Team", Group: "admins"},
+								},
+							},
+						},
+					},
+					Templates: testsDefaultGeneralTemplateConfig,
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Actions: &config.ActionsConfig{
+								GET: &config.GetActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod:  "GET",
+			inputURL:     "http://localhost/",
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `<!DOCTYPE html>
+<html>
+  <body>
+    <h1>Unauthorized</h1>
+    <p>no verified client certificate detected in request</p>
+  </body>
+</html>`,
+			expectedHeaders: map[string]string{
+				"Cache-Control": "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+				"Content-Type":  "text/html; charset=utf-8",
+			},
+		},
+		{
+			name: "GET target list protected with mtls authentication with forbidden group",
+			args: args{
+				cfg: &config.Config{
+					Server: svrCfg,
+					ListTargets: &config.ListTargetsConfig{
+						Enabled: true,
+						Mount: &config.MountConfig{
+							Path: []string{"/"},
+						},
+						Resource: &config.Resource{
+							Path:     "/*",
+							Methods:  []string{"GET"},
+							Provider: "provider1",
+							MTLS: &config.ResourceHeaderOIDC{
+								AuthorizationAccesses: []*config.HeaderOIDCAuthorizationAccess{{
+									Group: "devops",
+								}},
+							},
+						},
+					},
+					Tracing: tracingConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						MTLS: map[string]*config.MTLSAuthConfig{
+							"provider1": {
+								UsernameField: config.MTLSFieldCommonName,
+								GroupsFields:  []string{config.MTLSFieldOrganizationalUnit},
+								GroupMappings: []*config.MTLSGroupMappingConfig{
+									{Value: "Platform Team", Group: "admins"},
+								},
+							},
+						},
+					},
+					Templates: testsDefaultGeneralTemplateConfig,
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Actions: &config.ActionsConfig{
+								GET: &config.GetActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod:  "GET",
+			inputURL:     "http://localhost/",
+			inputTLS:     mtlsConnectionState,
+			expectedCode: http.StatusForbidden,
+			expectedBody: `<!DOCTYPE html>
+<html>
+  <body>
+    <h1>Forbidden</h1>
+    <p>forbidden user user1</p>
+  </body>
+</html>`,
+			expectedHeaders: map[string]string{
+				"Cache-Control": "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+				"Content-Type":  "text/html; charset=utf-8",
+			},
+		},
+		{
+			name: "GET target list protected with ldap authentication without basic auth",
+			args: args{
+				cfg: &config.Config{
+					Server: svrCfg,
+					ListTargets: &config.ListTargetsConfig{
+						Enabled: true,
+						Mount: &config.MountConfig{
+							Path: []string{"/"},
+						},
+						Resource: &config.Resource{
+							Path:     "/*",
+							Methods:  []string{"GET"},
+							Provider: "provider1",
+							LDAP: &config.ResourceHeaderOIDC{
+								AuthorizationAccesses: []*config.HeaderOIDCAuthorizationAccess{{
+									Group: "developers",
+								}},
+							},
+						},
+					},
+					Tracing: tracingConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						LDAP: map[string]*config.LDAPAuthConfig{
+							"provider1": {
+								Realm:          "ldap-realm",
+								URL:            "ldap://127.0.0.1:1",
+								UserDNTemplate: "uid={username},dc=example,dc=com",
+								EmailAttribute: config.DefaultLDAPEmailAttribute,
+								Timeout:        time.Second,
+							},
+						},
+					},
+					Templates: testsDefaultGeneralTemplateConfig,
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Actions: &config.ActionsConfig{
+								GET: &config.GetActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod:  "GET",
+			inputURL:     "http://localhost/",
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `<!DOCTYPE html>
+<html>
+  <body>
+    <h1>Unauthorized</h1>
+    <p>no basic auth detected in request</p>
+  </body>
+</html>`,
+			expectedHeaders: map[string]string{
+				"Cache-Control":    "no-cache, no-store, no-transform, must-revalidate, private, max-age=0",
+				"Content-Type":     "text/html; charset=utf-8",
+				"Www-Authenticate": `Basic realm="ldap-realm"`,
+			},
+		},
+		{
+			name: "GET target list protected with ldap authentication with unreachable directory",
+			args: args{
+				cfg: &config.Config{
+					Server: svrCfg,
+					ListTargets: &config.ListTargetsConfig{
+						Enabled: true,
+						Mount: &config.MountConfig{
+							Path: []string{"/"},
+						},
+						Resource: &config.Resource{
+							Path:     "/*",
+							Methods:  []string{"GET"},
+							Provider: "provider1",
+							LDAP: &config.ResourceHeaderOIDC{
+								AuthorizationAccesses: []*config.HeaderOIDCAuthorizationAccess{{
+									Group: "developers",
+								}},
+							},
+						},
+					},
+					Tracing: tracingConfig,
+					AuthProviders: &config.AuthProviderConfig{
+						LDAP: map[string]*config.LDAPAuthConfig{
+							"provider1": {
+								Realm:          "ldap-realm",
+								URL:            "ldap://127.0.0.1:1",
+								UserDNTemplate: "uid={username},dc=example,dc=com",
+								EmailAttribute: config.DefaultLDAPEmailAttribute,
+								Timeout:        time.Second,
+							},
+						},
+					},
+					Templates: testsDefaultGeneralTemplateConfig,
+					Targets: map[string]*config.TargetConfig{
+						"target1": {
+							Name: "target1",
+							Bucket: &config.BucketConfig{
+								Name:       bucket,
+								Region:     region,
+								S3Endpoint: s3server.URL,
+								Credentials: &config.BucketCredentialConfig{
+									AccessKey: &config.CredentialConfig{Value: accessKey},
+									SecretKey: &config.CredentialConfig{Value: secretAccessKey},
+								},
+								DisableSSL: true,
+							},
+							Mount: &config.MountConfig{
+								Path: []string{"/mount/"},
+							},
+							Actions: &config.ActionsConfig{
+								GET: &config.GetActionConfig{Enabled: true},
+							},
+						},
+					},
+				},
+			},
+			inputMethod:        "GET",
+			inputURL:           "http://localhost/",
+			inputBasicUser:     "user1",
+			inputBasicPassword: "pass1",
+			expectedCode:       http.StatusInternalServerError,
+			expectedBodyRegex:  "<h1>Internal Server Error</h1>",
+		},
+		{
+			name: "GET index document with index document enabled with success",
+			args: args{
+				cfg: &config.Config{
+					Server:      svrCfg,
+					ListTargets: &config.ListTargetsConfig{},
+					Tracing:     tracingConfig,
+					Templates:   testsDefaultGeneralTemplateConfig,
+					Targets: map[string]*config.TargetConfig{
 						"target1": {
 							Name: "target1",
 							Bucket: &config.BucketConfig{
@@ -5241,7 +6379,7 @@ func TestPublicRouter(t *testing.T) {
 			assert.NoError(t, err)
 
 			// Create webhook manager
-			webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx)
+			webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
 
 			svr := &Server{
 				logger:          logger,
@@ -5305,6 +6443,9 @@ func TestPublicRouter(t *testing.T) {
 				req.SetBasicAuth(tt.inputBasicUser, tt.inputBasicPassword)
 			}
 
+			// Add client certificates
+			req.TLS = tt.inputTLS
+
 			// Add headers
 			if tt.inputHeaders != nil {
 				for key, value := range tt.inputHeaders {
@@ -5511,7 +6652,7 @@ func TestTracing(t *testing.T) {
 			assert.NoError(t, err)
 
 			// Create webhook manager
-			webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx)
+			webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
 
 			svr := &Server{
 				logger:          logger,
@@ -5816,12 +6957,13 @@ func TestOIDCAuthentication(t *testing.T) {
 					AuthProviders: &config.AuthProviderConfig{
 						OIDC: map[string]*config.OIDCAuthConfig{
 							"provider1": {
-								ClientID:     "fake-client-id",
-								CookieName:   "oidc",
-								RedirectURL:  "http://fake-s3-proxy/",
-								CallbackPath: "/auth/provider1/callback",
-								IssuerURL:    "https://fake-idp/",
-								LoginPath:    "/auth/provider1/",
+								ClientID:         "fake-client-id",
+								LoginStateSecret: &config.CredentialConfig{Value: "01234567890123456789012345678901"},
+								CookieName:       "oidc",
+								RedirectURL:      "http://fake-s3-proxy/",
+								CallbackPath:     "/auth/provider1/callback",
+								IssuerURL:        "https://fake-idp/",
+								LoginPath:        "/auth/provider1/",
 							},
 						},
 					},
@@ -5841,13 +6983,14 @@ func TestOIDCAuthentication(t *testing.T) {
 					AuthProviders: &config.AuthProviderConfig{
 						OIDC: map[string]*config.OIDCAuthConfig{
 							"provider1": {
-								ClientID:     "client-with-secret",
-								ClientSecret: &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
-								CookieName:   "oidc",
-								RedirectURL:  "http://localhost:8080/",
-								CallbackPath: "/auth/provider1/callback",
-								IssuerURL:    "http://localhost:8088/auth/realms/integration",
-								LoginPath:    "/auth/provider1/",
+								ClientID:         "client-with-secret",
+								LoginStateSecret: &config.CredentialConfig{Value: "01234567890123456789012345678901"},
+								ClientSecret:     &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
+								CookieName:       "oidc",
+								RedirectURL:      "http://localhost:8080/",
+								CallbackPath:     "/auth/provider1/callback",
+								IssuerURL:        "http://localhost:8088/auth/realms/integration",
+								LoginPath:        "/auth/provider1/",
 							},
 						},
 					},
@@ -5875,13 +7018,14 @@ func TestOIDCAuthentication(t *testing.T) {
 					AuthProviders: &config.AuthProviderConfig{
 						OIDC: map[string]*config.OIDCAuthConfig{
 							"provider1": {
-								ClientID:     "client-with-secret",
-								ClientSecret: &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
-								CookieName:   "oidc",
-								RedirectURL:  "http://localhost:8080/",
-								CallbackPath: "/auth/provider1/callback",
-								IssuerURL:    "http://localhost:8088/auth/realms/integration",
-								LoginPath:    "/auth/provider1/",
+								ClientID:         "client-with-secret",
+								LoginStateSecret: &config.CredentialConfig{Value: "01234567890123456789012345678901"},
+								ClientSecret:     &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
+								CookieName:       "oidc",
+								RedirectURL:      "http://localhost:8080/",
+								CallbackPath:     "/auth/provider1/callback",
+								IssuerURL:        "http://localhost:8088/auth/realms/integration",
+								LoginPath:        "/auth/provider1/",
 							},
 						},
 					},
@@ -5913,14 +7057,15 @@ func TestOIDCAuthentication(t *testing.T) {
 					AuthProviders: &config.AuthProviderConfig{
 						OIDC: map[string]*config.OIDCAuthConfig{
 							"provider1": {
-								ClientID:      "client-with-secret",
-								ClientSecret:  &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
-								CookieName:    "oidc",
-								RedirectURL:   "http://localhost:8080/",
-								CallbackPath:  "/auth/provider1/callback",
-								IssuerURL:     "http://localhost:8088/auth/realms/integration",
-								LoginPath:     "/auth/provider1/",
-								EmailVerified: true,
+								ClientID:         "client-with-secret",
+								LoginStateSecret: &config.CredentialConfig{Value: "01234567890123456789012345678901"},
+								ClientSecret:     &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
+								CookieName:       "oidc",
+								RedirectURL:      "http://localhost:8080/",
+								CallbackPath:     "/auth/provider1/callback",
+								IssuerURL:        "http://localhost:8088/auth/realms/integration",
+								LoginPath:        "/auth/provider1/",
+								EmailVerified:    true,
 							},
 						},
 					},
@@ -5951,14 +7096,15 @@ func TestOIDCAuthentication(t *testing.T) {
 					AuthProviders: &config.AuthProviderConfig{
 						OIDC: map[string]*config.OIDCAuthConfig{
 							"provider1": {
-								ClientID:      "client-with-secret",
-								ClientSecret:  &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
-								CookieName:    "oidc",
-								RedirectURL:   "http://localhost:8080/",
-								CallbackPath:  "/auth/provider1/callback",
-								IssuerURL:     "http://localhost:8088/auth/realms/integration",
-								LoginPath:     "/auth/provider1/",
-								EmailVerified: true,
+								ClientID:         "client-with-secret",
+								LoginStateSecret: &config.CredentialConfig{Value: "01234567890123456789012345678901"},
+								ClientSecret:     &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
+								CookieName:       "oidc",
+								RedirectURL:      "http://localhost:8080/",
+								CallbackPath:     "/auth/provider1/callback",
+								IssuerURL:        "http://localhost:8088/auth/realms/integration",
+								LoginPath:        "/auth/provider1/",
+								EmailVerified:    true,
 							},
 						},
 					},
@@ -5989,15 +7135,16 @@ func TestOIDCAuthentication(t *testing.T) {
 					AuthProviders: &config.AuthProviderConfig{
 						OIDC: map[string]*config.OIDCAuthConfig{
 							"provider1": {
-								ClientID:      "client-with-secret",
-								ClientSecret:  &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
-								CookieName:    "oidc",
-								RedirectURL:   "http://localhost:8080/",
-								CallbackPath:  "/auth/provider1/callback",
-								IssuerURL:     "http://localhost:8088/auth/realms/integration",
-								LoginPath:     "/auth/provider1/",
-								EmailVerified: true,
-								GroupClaim:    "groups",
+								ClientID:         "client-with-secret",
+								LoginStateSecret: &config.CredentialConfig{Value: "01234567890123456789012345678901"},
+								ClientSecret:     &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
+								CookieName:       "oidc",
+								RedirectURL:      "http://localhost:8080/",
+								CallbackPath:     "/auth/provider1/callback",
+								IssuerURL:        "http://localhost:8088/auth/realms/integration",
+								LoginPath:        "/auth/provider1/",
+								EmailVerified:    true,
+								GroupClaim:       "groups",
 							},
 						},
 					},
@@ -6028,15 +7175,16 @@ func TestOIDCAuthentication(t *testing.T) {
 					AuthProviders: &config.AuthProviderConfig{
 						OIDC: map[string]*config.OIDCAuthConfig{
 							"provider1": {
-								ClientID:      "client-with-secret",
-								ClientSecret:  &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
-								CookieName:    "oidc",
-								RedirectURL:   "http://localhost:8080/",
-								CallbackPath:  "/auth/provider1/callback",
-								IssuerURL:     "http://localhost:8088/auth/realms/integration",
-								LoginPath:     "/auth/provider1/",
-								EmailVerified: true,
-								GroupClaim:    "groups",
+								ClientID:         "client-with-secret",
+								LoginStateSecret: &config.CredentialConfig{Value: "01234567890123456789012345678901"},
+								ClientSecret:     &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
+								CookieName:       "oidc",
+								RedirectURL:      "http://localhost:8080/",
+								CallbackPath:     "/auth/provider1/callback",
+								IssuerURL:        "http://localhost:8088/auth/realms/integration",
+								LoginPath:        "/auth/provider1/",
+								EmailVerified:    true,
+								GroupClaim:       "groups",
 							},
 						},
 					},
@@ -6067,15 +7215,16 @@ func TestOIDCAuthentication(t *testing.T) {
 					AuthProviders: &config.AuthProviderConfig{
 						OIDC: map[string]*config.OIDCAuthConfig{
 							"provider1": {
-								ClientID:      "client-with-secret",
-								ClientSecret:  &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
-								CookieName:    "oidc",
-								RedirectURL:   "http://localhost:8080/",
-								CallbackPath:  "/auth/provider1/callback",
-								IssuerURL:     "http://localhost:8088/auth/realms/integration",
-								LoginPath:     "/auth/provider1/",
-								EmailVerified: true,
-								GroupClaim:    "groups",
+								ClientID:         "client-with-secret",
+								LoginStateSecret: &config.CredentialConfig{Value: "01234567890123456789012345678901"},
+								ClientSecret:     &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
+								CookieName:       "oidc",
+								RedirectURL:      "http://localhost:8080/",
+								CallbackPath:     "/auth/provider1/callback",
+								IssuerURL:        "http://localhost:8088/auth/realms/integration",
+								LoginPath:        "/auth/provider1/",
+								EmailVerified:    true,
+								GroupClaim:       "groups",
 							},
 						},
 					},
@@ -6106,15 +7255,16 @@ func TestOIDCAuthentication(t *testing.T) {
 					AuthProviders: &config.AuthProviderConfig{
 						OIDC: map[string]*config.OIDCAuthConfig{
 							"provider1": {
-								ClientID:      "client-with-secret",
-								ClientSecret:  &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
-								CookieName:    "oidc",
-								RedirectURL:   "http://localhost:8080/",
-								CallbackPath:  "/auth/provider1/callback",
-								IssuerURL:     "http://localhost:8088/auth/realms/integration",
-								LoginPath:     "/auth/provider1/",
-								EmailVerified: true,
-								GroupClaim:    "groups",
+								ClientID:         "client-with-secret",
+								LoginStateSecret: &config.CredentialConfig{Value: "01234567890123456789012345678901"},
+								ClientSecret:     &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
+								CookieName:       "oidc",
+								RedirectURL:      "http://localhost:8080/",
+								CallbackPath:     "/auth/provider1/callback",
+								IssuerURL:        "http://localhost:8088/auth/realms/integration",
+								LoginPath:        "/auth/provider1/",
+								EmailVerified:    true,
+								GroupClaim:       "groups",
 							},
 						},
 					},
@@ -6145,15 +7295,16 @@ func TestOIDCAuthentication(t *testing.T) {
 					AuthProviders: &config.AuthProviderConfig{
 						OIDC: map[string]*config.OIDCAuthConfig{
 							"provider1": {
-								ClientID:      "client-with-secret",
-								ClientSecret:  &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
-								CookieName:    "oidc",
-								RedirectURL:   "http://localhost:8080/",
-								CallbackPath:  "/auth/provider1/callback",
-								IssuerURL:     "http://localhost:8088/auth/realms/integration",
-								LoginPath:     "/auth/provider1/",
-								EmailVerified: true,
-								GroupClaim:    "groups",
+								ClientID:         "client-with-secret",
+								LoginStateSecret: &config.CredentialConfig{Value: "01234567890123456789012345678901"},
+								ClientSecret:     &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
+								CookieName:       "oidc",
+								RedirectURL:      "http://localhost:8080/",
+								CallbackPath:     "/auth/provider1/callback",
+								IssuerURL:        "http://localhost:8088/auth/realms/integration",
+								LoginPath:        "/auth/provider1/",
+								EmailVerified:    true,
+								GroupClaim:       "groups",
 							},
 						},
 					},
@@ -6184,22 +7335,24 @@ func TestOIDCAuthentication(t *testing.T) {
 					AuthProviders: &config.AuthProviderConfig{
 						OIDC: map[string]*config.OIDCAuthConfig{
 							"provider1": {
-								ClientID:     "client-with-secret",
-								ClientSecret: &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
-								CookieName:   "oidc",
-								RedirectURL:  "http://localhost:8080/",
-								CallbackPath: "/auth/provider1/callback",
-								IssuerURL:    "http://localhost:8088/auth/realms/integration",
-								LoginPath:    "/auth/provider1/",
+								ClientID:         "client-with-secret",
+								LoginStateSecret: &config.CredentialConfig{Value: "01234567890123456789012345678901"},
+								ClientSecret:     &config.CredentialConfig{Value: "565f78f2-a706-41cd-a1a0-431d7df29443"},
+								CookieName:       "oidc",
+								RedirectURL:      "http://localhost:8080/",
+								CallbackPath:     "/auth/provider1/callback",
+								IssuerURL:        "http://localhost:8088/auth/realms/integration",
+								LoginPath:        "/auth/provider1/",
 							},
 							"provider2": {
-								ClientID:     "client-without-secret",
-								ClientSecret: nil,
-								CookieName:   "oidc",
-								RedirectURL:  "http://localhost:8080/",
-								CallbackPath: "/auth/provider2/callback",
-								IssuerURL:    "http://localhost:8088/auth/realms/integration",
-								LoginPath:    "/auth/provider2/",
+								ClientID:         "client-without-secret",
+								LoginStateSecret: &config.CredentialConfig{Value: "01234567890123456789012345678901"},
+								ClientSecret:     nil,
+								CookieName:       "oidc",
+								RedirectURL:      "http://localhost:8080/",
+								CallbackPath:     "/auth/provider2/callback",
+								IssuerURL:        "http://localhost:8088/auth/realms/integration",
+								LoginPath:        "/auth/provider2/",
 							},
 						},
 					},
@@ -6238,14 +7391,28 @@ func TestOIDCAuthentication(t *testing.T) {
 			assert.NoError(t, err)
 
 			// Create webhook manager
-			webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx)
+			webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
 
 			// Create S3 Manager
 			s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
 			err = s3Manager.Load()
 			assert.NoError(t, err)
 
-			ssvr := NewServer(logger, cfgManagerMock, metricsCtx, tsvc, s3Manager, webhookManager)
+			ssvr := NewServer(
+				logger,
+				cfgManagerMock,
+				metricsCtx,
+				tsvc,
+				s3Manager,
+				webhookManager,
+				quota.NewManager(cfgManagerMock, s3Manager, logger),
+				limiter.NewManager(cfgManagerMock, metricsCtx),
+				session.NewManager(cfgManagerMock),
+				authorization.NewRegoManager(cfgManagerMock, s3Manager, logger),
+				lockout.NewManager(),
+				audit.NewManager(cfgManagerMock, s3Manager, logger),
+				geoip.NewManager(cfgManagerMock, logger),
+			)
 			err = ssvr.GenerateServer()
 			if (err != nil) != tt.wantErr {
 				t.Errorf("generateServer() error = %v, wantErr %v", err, tt.wantErr)
@@ -6420,14 +7587,28 @@ func TestStartWithOnlyDefaultConfiguration(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Create webhook manager
-	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx)
+	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
 
 	// Create S3 Manager
 	s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
 	err = s3Manager.Load()
 	assert.NoError(t, err)
 
-	ssvr := NewServer(logger, cfgManagerMock, metricsCtx, tsvc, s3Manager, webhookManager)
+	ssvr := NewServer(
+		logger,
+		cfgManagerMock,
+		metricsCtx,
+		tsvc,
+		s3Manager,
+		webhookManager,
+		quota.NewManager(cfgManagerMock, s3Manager, logger),
+		limiter.NewManager(cfgManagerMock, metricsCtx),
+		session.NewManager(cfgManagerMock),
+		authorization.NewRegoManager(cfgManagerMock, s3Manager, logger),
+		lockout.NewManager(),
+		audit.NewManager(cfgManagerMock, s3Manager, logger),
+		geoip.NewManager(cfgManagerMock, logger),
+	)
 	err = ssvr.GenerateServer()
 	if err != nil {
 		t.Errorf("generateServer() error = %v", err)
@@ -6849,7 +8030,7 @@ func TestCORS(t *testing.T) {
 			assert.NoError(t, err)
 
 			// Create webhook manager
-			webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx)
+			webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
 
 			svr := &Server{
 				logger:          logger,
@@ -6984,7 +8165,7 @@ func TestIndexLargeBucket(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Create webhook manager
-	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx)
+	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
 
 	svr := &Server{
 		logger:          logger,
@@ -7092,7 +8273,7 @@ func TestListLargeBucketAndSmallMaxKeys(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Create webhook manager
-	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx)
+	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
 
 	svr := &Server{
 		logger:          logger,
@@ -7196,7 +8377,7 @@ func TestListLargeBucketAndMaxKeysGreaterThanS3MaxKeys(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Create webhook manager
-	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx)
+	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
 
 	svr := &Server{
 		logger:          logger,
@@ -7298,7 +8479,7 @@ func TestFolderWithSubFolders(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Create webhook manager
-	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx)
+	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
 
 	svr := &Server{
 		logger:          logger,
@@ -7410,7 +8591,7 @@ func TestGlobSeparatorSingleStar(t *testing.T) {
 	err = s3Manager.Load()
 	assert.NoError(t, err)
 
-	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx)
+	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
 
 	svr := &Server{
 		logger:          logger,
@@ -7552,7 +8733,7 @@ func TestPathTraversalPrevention(t *testing.T) {
 	err = s3Manager.Load()
 	assert.NoError(t, err)
 
-	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx)
+	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
 
 	svr := &Server{
 		logger:          logger,
@@ -7889,7 +9070,7 @@ func TestTrailingSlashRedirect(t *testing.T) {
 			assert.NoError(t, err)
 
 			// Create webhook manager
-			webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx)
+			webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
 
 			svr := &Server{
 				logger:          logger,
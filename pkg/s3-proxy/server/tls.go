@@ -111,9 +111,67 @@ func generateTLSConfig(sslConfig *config.ServerSSLConfig, logger log.Logger) (*t
 		result.Certificates = append(result.Certificates, *cert)
 	}
 
+	// Configure client certificates verification
+	if sslConfig.ClientCA != nil {
+		pool, err := getClientCAPoolFromConfig(sslConfig.ClientCA, logger)
+		if err != nil {
+			logger.Errorf("unable to load client CA: %v", err)
+
+			return nil, errors.Wrap(err, "unable to load client CA")
+		}
+
+		result.ClientCAs = pool
+		// Client certificates are optional by default to allow other authentication methods on same server
+		result.ClientAuth = tls.VerifyClientCertIfGiven
+
+		if sslConfig.ClientCA.Mode == config.SSLClientAuthModeRequire {
+			result.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
 	return &result, nil
 }
 
+// getClientCAPoolFromConfig creates a certificate pool from client CA configuration, performing any
+// network accesses (S3, SSM, Secrets Manager) necessary.
+func getClientCAPoolFromConfig(clientCAConfig *config.ServerSSLClientCAConfig, logger log.Logger) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+
+	for _, caConfig := range clientCAConfig.Certificates {
+		var certificate []byte
+
+		certificateURLOptions, err := getURLOptions(caConfig.CertificateURLConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid certificateUrlConfig")
+		}
+
+		switch {
+		// Certificate supplied directly; just copy it.
+		case caConfig.Certificate != nil:
+			certificate = []byte(*caConfig.Certificate)
+
+		// Certificate supplied as a URL.
+		case caConfig.CertificateURL != nil:
+			certificate, err = utils.GetDocumentFromURL(*caConfig.CertificateURL, certificateURLOptions...)
+			if err != nil {
+				logger.Errorf("Failed to get client CA certificate from URL %s: %v", *caConfig.CertificateURL, err)
+
+				return nil, errors.Wrap(err, "failed to get client CA certificate from URL: "+*caConfig.CertificateURL)
+			}
+
+		default:
+			return nil, errors.New("expected either certificate or certificateUrl to be set")
+		}
+
+		// Add certificates
+		if !pool.AppendCertsFromPEM(certificate) {
+			return nil, errors.New("no valid PEM certificate found in client CA")
+		}
+	}
+
+	return pool, nil
+}
+
 // getCertificateFromConfig creates a crypto/tls.Certificate from a certificate configuration, performing any
 // network accesses (S3, SSM, Secrets Manager) necessary.
 func getCertificateFromConfig(certConfig *config.ServerSSLCertificate, logger log.Logger) (*tls.Certificate, error) {
@@ -547,7 +547,7 @@ func TestTLSServer(t *testing.T) {
 			}
 
 			// Create webhook manager
-			webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx)
+			webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
 
 			// Create S3 Manager
 			s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
@@ -393,3 +393,67 @@ func TestGenerateTLSConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateTLSConfig_ClientCA(t *testing.T) {
+	tests := []struct {
+		name           string
+		clientCA       *config.ServerSSLClientCAConfig
+		wantClientAuth tls.ClientAuthType
+		errorString    string
+	}{
+		{
+			name: "default mode verifies client certificates if given",
+			clientCA: &config.ServerSSLClientCAConfig{
+				Certificates: []*config.ServerSSLCACertificate{{Certificate: aws.String(testCertificate)}},
+			},
+			wantClientAuth: tls.VerifyClientCertIfGiven,
+		},
+		{
+			name: "require mode requires client certificates",
+			clientCA: &config.ServerSSLClientCAConfig{
+				Mode:         config.SSLClientAuthModeRequire,
+				Certificates: []*config.ServerSSLCACertificate{{Certificate: aws.String(testCertificate)}},
+			},
+			wantClientAuth: tls.RequireAndVerifyClientCert,
+		},
+		{
+			name: "invalid PEM certificate",
+			clientCA: &config.ServerSSLClientCAConfig{
+				Certificates: []*config.ServerSSLCACertificate{{Certificate: aws.String("not a certificate")}},
+			},
+			errorString: "unable to load client CA: no valid PEM certificate found in client CA",
+		},
+		{
+			name: "neither certificate nor certificateUrl set",
+			clientCA: &config.ServerSSLClientCAConfig{
+				Certificates: []*config.ServerSSLCACertificate{{}},
+			},
+			errorString: "unable to load client CA: expected either certificate or certificateUrl to be set",
+		},
+		{
+			name: "unsupported certificate URL scheme",
+			clientCA: &config.ServerSSLClientCAConfig{
+				Certificates: []*config.ServerSSLCACertificate{{CertificateURL: aws.String("ftp://ftp.example.com")}},
+			},
+			errorString: "unable to load client CA: failed to get client CA certificate from URL: ftp://ftp.example.com: unsupported URL scheme: ftp",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := generateTLSConfig(&config.ServerSSLConfig{
+				Enabled:             true,
+				SelfSignedHostnames: []string{"localhost"},
+				ClientCA:            tt.clientCA,
+			}, log.NewLogger())
+			if tt.errorString != "" {
+				assert.EqualError(t, err, tt.errorString)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantClientAuth, got.ClientAuth)
+			assert.NotNil(t, got.ClientCAs)
+		})
+	}
+}
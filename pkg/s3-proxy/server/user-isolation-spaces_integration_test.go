@@ -0,0 +1,140 @@
+//go:build integration
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
+)
+
+func TestUserIsolationGroupSpaces(t *testing.T) {
+	accessKey := "YOUR-ACCESSKEYID"
+	secretAccessKey := "YOUR-SECRETACCESSKEY"
+	region := "eu-central-1"
+	bucket := "test-bucket"
+
+	cli, s3server, err := newIsolationFakeS3(accessKey, secretAccessKey, region, bucket)
+	require.NoError(t, err)
+
+	defer s3server.Close()
+
+	seedIsolationFakeS3(t, cli, bucket, map[string]string{
+		"data/users/alice/secret.txt": "alice-secret",
+		"data/users/bob/secret.txt":   "bob-secret",
+		"data/teams/dev/shared.txt":   "dev-shared",
+		"data/teams/ops/shared.txt":   "ops-shared",
+	})
+
+	cfg := userIsolationConfig(s3server.URL, accessKey, secretAccessKey, region, bucket, &config.ServerConfig{}, &config.TracingConfig{})
+	cfg.AuthProviders.Header = map[string]*config.HeaderAuthConfig{
+		"provider2": {UsernameHeader: "X-Username", EmailHeader: "X-Email", GroupsHeader: "X-Groups"},
+	}
+	res := cfg.Targets["target1"].Resources[0]
+	res.Provider = "provider2"
+	res.Basic = nil
+	res.Header = &config.ResourceHeaderOIDC{}
+
+	getCfg := cfg.Targets["target1"].Actions.GET.Config
+	getCfg.UserIsolationPathTemplate = "users/{{ .User.GetIdentifier }}"
+	getCfg.UserIsolationGroupPathTemplate = "teams/{{ .Group }}"
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	logger := log.NewLogger()
+
+	tsvc, err := tracing.New(cfgManagerMock, logger)
+	require.NoError(t, err)
+
+	s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
+	require.NoError(t, s3Manager.Load())
+
+	svr := &Server{
+		logger:          logger,
+		cfgManager:      cfgManagerMock,
+		metricsCl:       metricsCtx,
+		tracingSvc:      tsvc,
+		s3clientManager: s3Manager,
+		webhookManager:  webhook.NewManager(cfgManagerMock, metricsCtx, logger),
+	}
+	router, err := svr.generateRouter()
+	require.NoError(t, err)
+
+	send := func(method, url, user, groups, body string) *httptest.ResponseRecorder {
+		var reqBody io.Reader = http.NoBody
+		if body != "" {
+			reqBody = strings.NewReader(body)
+		}
+
+		req := httptest.NewRequest(method, url, reqBody)
+		req.Header.Set("X-Username", user)
+		req.Header.Set("X-Email", user+"@example.com")
+		req.Header.Set("X-Groups", groups)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// Root virtual folder
+	w := send(http.MethodGet, "http://localhost/mount/", "alice", "dev", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "/mount/teams/")
+	assert.Contains(t, w.Body.String(), "/mount/users/")
+
+	// Intermediate virtual folder only lists accessible spaces
+	w = send(http.MethodGet, "http://localhost/mount/teams/", "alice", "dev", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "/mount/teams/dev/")
+	assert.NotContains(t, w.Body.String(), "/mount/teams/ops/")
+
+	w = send(http.MethodGet, "http://localhost/mount/users/", "alice", "dev", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "/mount/users/alice/")
+	assert.NotContains(t, w.Body.String(), "/mount/users/bob/")
+
+	// Spaces listing is a real bucket listing
+	w = send(http.MethodGet, "http://localhost/mount/teams/dev/", "alice", "dev", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "shared.txt")
+
+	// Files in spaces
+	w = send(http.MethodGet, "http://localhost/mount/users/alice/secret.txt", "alice", "dev", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice-secret", w.Body.String())
+
+	w = send(http.MethodGet, "http://localhost/mount/teams/dev/shared.txt", "alice", "dev", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "dev-shared", w.Body.String())
+
+	// Files outside spaces
+	assert.Equal(t, http.StatusForbidden, send(http.MethodGet, "http://localhost/mount/users/bob/secret.txt", "alice", "dev", "").Code)
+	assert.Equal(t, http.StatusForbidden, send(http.MethodGet, "http://localhost/mount/teams/ops/shared.txt", "alice", "dev", "").Code)
+	assert.Equal(t, http.StatusForbidden, send(http.MethodGet, "http://localhost/mount/teams/ops/", "alice", "dev", "").Code)
+
+	// Group membership gives access
+	w = send(http.MethodGet, "http://localhost/mount/teams/ops/shared.txt", "bob", "ops", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ops-shared", w.Body.String())
+
+	// Delete is only allowed in spaces
+	assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, "http://localhost/mount/teams/dev/shared.txt", "alice", "dev", "").Code)
+	assert.Equal(t, http.StatusForbidden, send(http.MethodDelete, "http://localhost/mount/teams/ops/shared.txt", "alice", "dev", "").Code)
+	assert.Equal(t, http.StatusForbidden, send(http.MethodDelete, "http://localhost/mount/teams/", "alice", "dev", "").Code)
+}
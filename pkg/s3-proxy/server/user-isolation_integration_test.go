@@ -16,6 +16,7 @@ import (
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
 	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/quota"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
@@ -138,7 +139,7 @@ func buildIsolationRouter(t *testing.T, cfg *config.Config) func(method, url, us
 	err = s3Manager.Load()
 	require.NoError(t, err)
 
-	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx)
+	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
 
 	svr := &Server{
 		logger:          logger,
@@ -147,6 +148,7 @@ func buildIsolationRouter(t *testing.T, cfg *config.Config) func(method, url, us
 		tracingSvc:      tsvc,
 		s3clientManager: s3Manager,
 		webhookManager:  webhookManager,
+		quotaManager:    quota.NewManager(cfgManagerMock, s3Manager, logger),
 	}
 	router, err := svr.generateRouter()
 	assert.NoError(t, err)
@@ -462,6 +464,73 @@ func TestUserIsolation_UnauthenticatedForbidden(t *testing.T) {
 	assert.Equal(t, 403, w.Code, "isolation enabled + no authenticated user must be 403")
 }
 
+// TestUserIsolation_Quota verifies that uploads are rejected with a 413 once
+// the user's folder reaches its storage quota, that other users keep their own
+// budget, and that deleting an object frees the corresponding space.
+func TestUserIsolation_Quota(t *testing.T) {
+	accessKey := "YOUR-ACCESSKEYID"
+	secretAccessKey := "YOUR-SECRETACCESSKEY"
+	region := "eu-central-1"
+	bucket := "test-bucket"
+
+	s3Client, s3server, err := setupIsolationFakeS3(t, accessKey, secretAccessKey, region, bucket)
+	assert.NoError(t, err)
+
+	defer s3server.Close()
+
+	svrCfg := defaultIsolationServerConfig()
+	cfg := userIsolationConfig(s3server.URL, accessKey, secretAccessKey, region, bucket, svrCfg, &config.TracingConfig{})
+	// alice already owns 2 objects (secret.txt and sub/nested.txt).
+	cfg.Targets["target1"].Actions.GET.Config.UserIsolationQuota = &config.UserIsolationQuotaConfig{
+		Default:           &config.QuotaLimitConfig{MaxObjects: 3},
+		ReconcileInterval: config.DefaultUserIsolationQuotaReconcileInterval,
+	}
+	cfg.Targets["target1"].Actions.PUT.Config.AllowOverride = true
+	do := buildIsolationRouter(t, cfg)
+
+	// First upload fits in the remaining budget.
+	w := do("PUT", "http://localhost/mount/", "alice", "pw-alice",
+		multipartBody(t, "first.txt", "first"), multipartContentType())
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// Second upload goes over the limit.
+	w = do("PUT", "http://localhost/mount/", "alice", "pw-alice",
+		multipartBody(t, "second.txt", "second"), multipartContentType())
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "Quota Exceeded")
+
+	_, err = s3Client.HeadObject(&s3.HeadObjectInput{Bucket: new(bucket), Key: new("data/alice/second.txt")})
+	assert.Error(t, err, "rejected upload must not be stored")
+
+	// Replacing an existing object does not consume an extra slot.
+	w = do("PUT", "http://localhost/mount/", "alice", "pw-alice",
+		multipartBody(t, "first.txt", "first-v2"), multipartContentType())
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// bob has his own budget.
+	w = do("PUT", "http://localhost/mount/", "bob", "pw-bob",
+		multipartBody(t, "second.txt", "second"), multipartContentType())
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// Deleting frees a slot for alice.
+	w = do("DELETE", "http://localhost/mount/first.txt", "alice", "pw-alice", "", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = do("PUT", "http://localhost/mount/", "alice", "pw-alice",
+		multipartBody(t, "second.txt", "second"), multipartContentType())
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// Admins bypass quotas.
+	w = do("PUT", "http://localhost/mount/", "admin", "pw-admin",
+		multipartBody(t, "admin.txt", "admin"), multipartContentType())
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// Listing exposes the current usage.
+	w = do("GET", "http://localhost/mount/", "alice", "pw-alice", "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Quota:")
+}
+
 // --- multipart helpers ---------------------------------------------------
 
 func multipartContentType() string {
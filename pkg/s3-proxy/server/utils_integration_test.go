@@ -74,6 +74,22 @@ var testsDefaultForbiddenErrorTemplateConfig = &config.TemplateConfigItem{
 	Status: "403",
 }
 
+var testsDefaultQuotaExceededErrorTemplateConfig = &config.TemplateConfigItem{
+	Path: "../../../templates/quota-exceeded-error.tpl",
+	Headers: map[string]string{
+		"Content-Type": "{{ template \"main.headers.contentType\" . }}",
+	},
+	Status: "413",
+}
+
+var testsDefaultServiceUnavailableErrorTemplateConfig = &config.TemplateConfigItem{
+	Path: "../../../templates/service-unavailable-error.tpl",
+	Headers: map[string]string{
+		"Content-Type": "{{ template \"main.headers.contentType\" . }}",
+	},
+	Status: "503",
+}
+
 var testsDefaultPutTemplateConfig = &config.TemplateConfigItem{
 	Path:    "../../../templates/put.tpl",
 	Headers: map[string]string{},
@@ -91,16 +107,18 @@ var testsDefaultHelpersTemplateConfig = []string{
 }
 
 var testsDefaultGeneralTemplateConfig = &config.TemplateConfig{
-	Helpers:             testsDefaultHelpersTemplateConfig,
-	FolderList:          testsDefaultFolderListTemplateConfig,
-	TargetList:          testsDefaultTargetListTemplateConfig,
-	BadRequestError:     testsDefaultBadRequestErrorTemplateConfig,
-	NotFoundError:       testsDefaultNotFoundErrorTemplateConfig,
-	InternalServerError: testsDefaultInternalServerErrorTemplateConfig,
-	UnauthorizedError:   testsDefaultUnauthorizedErrorTemplateConfig,
-	ForbiddenError:      testsDefaultForbiddenErrorTemplateConfig,
-	Put:                 testsDefaultPutTemplateConfig,
-	Delete:              testsDefaultDeleteTemplateConfig,
+	Helpers:                 testsDefaultHelpersTemplateConfig,
+	FolderList:              testsDefaultFolderListTemplateConfig,
+	TargetList:              testsDefaultTargetListTemplateConfig,
+	BadRequestError:         testsDefaultBadRequestErrorTemplateConfig,
+	NotFoundError:           testsDefaultNotFoundErrorTemplateConfig,
+	InternalServerError:     testsDefaultInternalServerErrorTemplateConfig,
+	UnauthorizedError:       testsDefaultUnauthorizedErrorTemplateConfig,
+	ForbiddenError:          testsDefaultForbiddenErrorTemplateConfig,
+	QuotaExceededError:      testsDefaultQuotaExceededErrorTemplateConfig,
+	ServiceUnavailableError: testsDefaultServiceUnavailableErrorTemplateConfig,
+	Put:                     testsDefaultPutTemplateConfig,
+	Delete:                  testsDefaultDeleteTemplateConfig,
 }
 
 // Generate metrics instance
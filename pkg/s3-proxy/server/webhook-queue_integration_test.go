@@ -0,0 +1,175 @@
+//go:build integration
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/lockout"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/s3client"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook"
+)
+
+func TestWebhookQueueDeadLetters(t *testing.T) {
+	accessKey := "YOUR-ACCESSKEYID"
+	secretAccessKey := "YOUR-SECRETACCESSKEY"
+	region := "eu-central-1"
+	bucket := "test-bucket"
+
+	_, s3server, err := setupIsolationFakeS3(t, accessKey, secretAccessKey, region, bucket)
+	require.NoError(t, err)
+
+	defer s3server.Close()
+
+	// Webhook server failing until fixed
+	var (
+		mutex      sync.Mutex
+		calls      int
+		statusCode = http.StatusInternalServerError
+	)
+
+	hookServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		calls++
+
+		rw.WriteHeader(statusCode)
+	}))
+	defer hookServer.Close()
+
+	getCalls := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return calls
+	}
+
+	cfg := userIsolationConfig(s3server.URL, accessKey, secretAccessKey, region, bucket, &config.ServerConfig{}, &config.TracingConfig{})
+	cfg.InternalServer = &config.ServerConfig{
+		Compress: &config.ServerCompressConfig{
+			Enabled: &config.DefaultServerCompressEnabled,
+			Level:   config.DefaultServerCompressLevel,
+			Types:   config.DefaultServerCompressTypes,
+		},
+	}
+	cfg.Targets["target1"].Actions.GET.Config.Webhooks = []*config.WebhookConfig{
+		{Method: http.MethodPost, URL: hookServer.URL},
+	}
+	cfg.WebhookQueue = &config.WebhookQueueConfig{
+		Enabled:        true,
+		Directory:      t.TempDir(),
+		Workers:        1,
+		MaxAttempts:    2,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+	}
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(cfg)
+
+	logger := log.NewLogger()
+
+	tsvc, err := tracing.New(cfgManagerMock, logger)
+	require.NoError(t, err)
+
+	s3Manager := s3client.NewManager(cfgManagerMock, metricsCtx)
+	require.NoError(t, s3Manager.Load())
+
+	webhookManager := webhook.NewManager(cfgManagerMock, metricsCtx, logger)
+	require.NoError(t, webhookManager.Load())
+
+	defer webhookManager.Close()
+
+	svr := &Server{
+		logger:          logger,
+		cfgManager:      cfgManagerMock,
+		metricsCl:       metricsCtx,
+		tracingSvc:      tsvc,
+		s3clientManager: s3Manager,
+		webhookManager:  webhookManager,
+	}
+	router, err := svr.generateRouter()
+	require.NoError(t, err)
+
+	intSvr := NewInternalServer(logger, cfgManagerMock, metricsCtx, lockout.NewManager(), webhookManager)
+	intRouter := intSvr.generateInternalRouter()
+
+	sendInternal := func(method, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		intRouter.ServeHTTP(w, httptest.NewRequest(method, url, nil))
+
+		return w
+	}
+	listDeadLetters := func() []*webhook.Delivery {
+		w := sendInternal(http.MethodGet, "http://localhost/webhooks/dead-letters")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var res struct {
+			DeadLetters []*webhook.Delivery `json:"deadLetters"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+
+		return res.DeadLetters
+	}
+
+	// Request answered without waiting for webhook
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/mount/secret.txt", nil)
+	req.SetBasicAuth("alice", "pw-alice")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Wait for dead letter
+	var list []*webhook.Delivery
+
+	require.Eventually(t, func() bool {
+		list = listDeadLetters()
+
+		return len(list) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, getCalls())
+	assert.Equal(t, "target1", list[0].Target)
+	assert.Equal(t, webhook.GETAction, list[0].Action)
+	assert.Equal(t, 2, list[0].Attempts)
+
+	// Replay unknown dead letter
+	w = sendInternal(http.MethodPost, "http://localhost/webhooks/dead-letters/replay?id=unknown")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Fix webhook and replay all
+	mutex.Lock()
+	statusCode = http.StatusOK
+	mutex.Unlock()
+
+	w = sendInternal(http.MethodPost, "http://localhost/webhooks/dead-letters/replay")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"replayed":1}`, w.Body.String())
+
+	assert.Eventually(t, func() bool { return getCalls() == 3 }, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, listDeadLetters())
+
+	// Queue disabled
+	require.NoError(t, webhookManager.Close())
+
+	cfg.WebhookQueue = nil
+	require.NoError(t, webhookManager.Load())
+
+	w = sendInternal(http.MethodGet, "http://localhost/webhooks/dead-letters")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
 	"strings"
@@ -95,6 +96,43 @@ func GetRequestHost(r *http.Request) string {
 	return host
 }
 
+// GetTrustedRequestHost will return request host like GetRequestHost only when the request comes from a trusted proxy.
+// Otherwise, the Host header is used because X-Forwarded-Host and Forwarded headers can be forged by clients.
+func GetTrustedRequestHost(r *http.Request, trustedProxies []netip.Prefix) string {
+	// Check if request comes from a trusted proxy
+	if IsFromTrustedProxy(r, trustedProxies) {
+		return GetRequestHost(r)
+	}
+
+	return r.Host
+}
+
+// IsFromTrustedProxy will return true if the connection remote address is in trusted proxies.
+func IsFromTrustedProxy(r *http.Request, trustedProxies []netip.Prefix) bool {
+	// Check if trusted proxies are declared
+	if len(trustedProxies) == 0 {
+		return false
+	}
+
+	// Parse remote address
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	// Check error
+	if err != nil {
+		return false
+	}
+
+	// Remove ipv4 in ipv6 prefix
+	ip := addrPort.Addr().Unmap()
+
+	for _, p := range trustedProxies {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func parseForwarded(forwarded string) (proto, host string) {
 	if forwarded == "" {
 		return proto, host
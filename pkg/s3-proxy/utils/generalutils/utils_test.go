@@ -6,6 +6,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"net/netip"
 	"testing"
 	"time"
 
@@ -100,6 +101,75 @@ func Test_GetRequestHost(t *testing.T) {
 	}
 }
 
+func TestGetTrustedRequestHost(t *testing.T) {
+	trustedProxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		headers        map[string]string
+		trustedProxies []netip.Prefix
+		want           string
+	}{
+		{
+			name:       "no trusted proxies and forged x-forwarded-host",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-Host": "forged.host"},
+			want:       "request.host",
+		},
+		{
+			name:           "untrusted remote address and forged x-forwarded-host",
+			remoteAddr:     "192.168.0.1:1234",
+			headers:        map[string]string{"X-Forwarded-Host": "forged.host"},
+			trustedProxies: trustedProxies,
+			want:           "request.host",
+		},
+		{
+			name:           "untrusted remote address and forged forwarded",
+			remoteAddr:     "192.168.0.1:1234",
+			headers:        map[string]string{"Forwarded": "host=forged.host"},
+			trustedProxies: trustedProxies,
+			want:           "request.host",
+		},
+		{
+			name:           "trusted remote address and x-forwarded-host",
+			remoteAddr:     "10.0.0.1:1234",
+			headers:        map[string]string{"X-Forwarded-Host": "proxied.host"},
+			trustedProxies: trustedProxies,
+			want:           "proxied.host",
+		},
+		{
+			name:           "trusted ipv4 mapped remote address and x-forwarded-host",
+			remoteAddr:     "[::ffff:10.0.0.1]:1234",
+			headers:        map[string]string{"X-Forwarded-Host": "proxied.host"},
+			trustedProxies: trustedProxies,
+			want:           "proxied.host",
+		},
+		{
+			name:           "invalid remote address",
+			remoteAddr:     "fake",
+			headers:        map[string]string{"X-Forwarded-Host": "forged.host"},
+			trustedProxies: trustedProxies,
+			want:           "request.host",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "http://request.host/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			assert.Equal(t, tt.want, GetTrustedRequestHost(req, tt.trustedProxies))
+		})
+	}
+}
+
 func TestGetRequestScheme(t *testing.T) {
 	hForwardedHttps := http.Header{
 		"Forwarded": []string{"for=192.0.2.60;proto=https;by=203.0.113.43;host=fake.host:9090"},
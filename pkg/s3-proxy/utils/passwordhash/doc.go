@@ -0,0 +1,3 @@
+package passwordhash
+
+// Password hash utils
@@ -0,0 +1,232 @@
+package passwordhash
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"emperror.dev/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// AlgorithmBcrypt bcrypt algorithm.
+	AlgorithmBcrypt = "bcrypt"
+	// AlgorithmArgon2id argon2id algorithm.
+	AlgorithmArgon2id = "argon2id"
+	// AlgorithmSHA256Crypt SHA-256 crypt algorithm.
+	AlgorithmSHA256Crypt = "sha256-crypt"
+	// AlgorithmSHA512Crypt SHA-512 crypt algorithm.
+	AlgorithmSHA512Crypt = "sha512-crypt"
+)
+
+// SupportedAlgorithms Supported algorithms for hash generation.
+var SupportedAlgorithms = []string{AlgorithmBcrypt, AlgorithmArgon2id, AlgorithmSHA256Crypt, AlgorithmSHA512Crypt}
+
+// ErrUnsupportedHash is returned when hash format isn't supported.
+var ErrUnsupportedHash = errors.New("unsupported password hash format")
+
+const (
+	argon2idPrefix     = "$argon2id$"
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+	argon2idMemory     = 64 * 1024
+	argon2idTime       = 3
+	argon2idThreads    = 4
+)
+
+// Hash will generate a hash of password with the given algorithm.
+func Hash(algorithm, password string) (string, error) {
+	switch algorithm {
+	case AlgorithmBcrypt:
+		res, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		// Check error
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+
+		return string(res), nil
+	case AlgorithmArgon2id:
+		// Generate salt
+		salt := make([]byte, argon2idSaltLength)
+
+		_, err := rand.Read(salt)
+		// Check error
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+
+		key := argon2.IDKey([]byte(password), salt, argon2idTime, argon2idMemory, argon2idThreads, argon2idKeyLength)
+
+		return fmt.Sprintf(
+			"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2idPrefix,
+			argon2.Version,
+			argon2idMemory,
+			argon2idTime,
+			argon2idThreads,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key),
+		), nil
+	case AlgorithmSHA256Crypt, AlgorithmSHA512Crypt:
+		// Get variant
+		variant := sha256CryptVariant
+		if algorithm == AlgorithmSHA512Crypt {
+			variant = sha512CryptVariant
+		}
+
+		// Generate salt
+		salt, err := generateSHACryptSalt()
+		// Check error
+		if err != nil {
+			return "", err
+		}
+
+		return shaCrypt(variant, []byte(password), salt, shaCryptDefaultRounds, false), nil
+	default:
+		return "", errors.Errorf("unsupported algorithm %s", algorithm)
+	}
+}
+
+// Validate will check that hash is in a supported format.
+func Validate(hash string) error {
+	switch {
+	case isBcrypt(hash):
+		_, err := bcrypt.Cost([]byte(hash))
+		// Check error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	case strings.HasPrefix(hash, argon2idPrefix):
+		_, err := parseArgon2id(hash)
+
+		return err
+	case strings.HasPrefix(hash, sha256CryptVariant.prefix), strings.HasPrefix(hash, sha512CryptVariant.prefix):
+		_, err := parseSHACrypt(hash)
+
+		return err
+	default:
+		return errors.WithStack(ErrUnsupportedHash)
+	}
+}
+
+// Verify will check in constant time that password matches the hash.
+func Verify(hash, password string) (bool, error) {
+	switch {
+	case isBcrypt(hash):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		// Check if password mismatch
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		// Check error
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+
+		return true, nil
+	case strings.HasPrefix(hash, argon2idPrefix):
+		// Parse hash
+		a, err := parseArgon2id(hash)
+		// Check error
+		if err != nil {
+			return false, err
+		}
+
+		key := argon2.IDKey([]byte(password), a.salt, a.time, a.memory, a.threads, uint32(len(a.key))) //nolint:gosec // Key length is small
+
+		return subtle.ConstantTimeCompare(key, a.key) == 1, nil
+	case strings.HasPrefix(hash, sha256CryptVariant.prefix), strings.HasPrefix(hash, sha512CryptVariant.prefix):
+		// Parse hash
+		s, err := parseSHACrypt(hash)
+		// Check error
+		if err != nil {
+			return false, err
+		}
+
+		res := shaCrypt(s.variant, []byte(password), s.salt, s.rounds, s.roundsCustom)
+
+		return subtle.ConstantTimeCompare([]byte(res), []byte(hash)) == 1, nil
+	default:
+		return false, errors.WithStack(ErrUnsupportedHash)
+	}
+}
+
+// VerifyPlain will check in constant time that password matches the plain text reference.
+func VerifyPlain(reference, password string) bool {
+	// Hash both values to avoid leaking reference length
+	r := sha256.Sum256([]byte(reference))
+	p := sha256.Sum256([]byte(password))
+
+	return subtle.ConstantTimeCompare(r[:], p[:]) == 1
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+type argon2idHash struct {
+	salt    []byte
+	key     []byte
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+// parseArgon2id will parse an argon2id hash in PHC string format.
+func parseArgon2id(hash string) (*argon2idHash, error) {
+	// Split parts
+	// Expected format is $argon2id$v=19$m=65536,t=3,p=4$salt$key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return nil, errors.New("invalid argon2id hash format")
+	}
+
+	var version int
+
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Check version
+	if version != argon2.Version {
+		return nil, errors.Errorf("unsupported argon2id version %d", version)
+	}
+
+	res := &argon2idHash{}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &res.memory, &res.time, &res.threads)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Check parameters
+	if res.memory == 0 || res.time == 0 || res.threads == 0 {
+		return nil, errors.New("invalid argon2id parameters")
+	}
+
+	res.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res.key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Check key
+	if len(res.key) == 0 {
+		return nil, errors.New("invalid argon2id hash format")
+	}
+
+	return res, nil
+}
@@ -0,0 +1,142 @@
+//go:build unit
+
+package passwordhash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_shaCrypt_Vectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+	}{
+		{
+			name:     "sha256 default rounds",
+			password: "Hello world!",
+			hash:     "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5",
+		},
+		{
+			name:     "sha256 custom rounds and truncated salt",
+			password: "Hello world!",
+			hash:     "$5$rounds=10000$saltstringsaltst$3xv.VbSHBb41AL9AvLeujZkZRBAwqFMz2.opqey6IcA",
+		},
+		{
+			name:     "sha512 default rounds",
+			password: "Hello world!",
+			hash:     "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1",
+		},
+		{
+			name:     "sha512 minimum rounds",
+			password: "Hello world!",
+			hash:     "$6$rounds=1000$short$BM3/GjEUABzKYsQf5Qb5BAuG3hyEj/vNWgdM4jw3CWiBptFRQUVDqFjOd2yMP3RtngUR3G9paxHlcOBgWVNJw.",
+		},
+		{
+			name:     "sha512 long password",
+			password: "a very much longer text to encrypt.  This one even stretches over morethan one line.",
+			hash:     "$6$rounds=1400$anotherlongsalts$POfYwTEok97VWcjxIiSOjiykti.o/pQs.wPvMxQ6Fm7I6IoYN3CmLs66x9t0oSwbtEW7o7UmJEiDwGqd8p4ur1",
+		},
+		{
+			name:     "sha512 empty password",
+			password: "",
+			hash:     "$6$abc$mJP3a6FyA8uCnzRtlnNypPwjnvpi5TP9qOrInzrfDmwxUQG38PkpCPdqfTb8JQfAngapMxeim4AZ..hSdRRzD.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, Validate(tt.hash))
+
+			ok, err := Verify(tt.hash, tt.password)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = Verify(tt.hash, tt.password+"x")
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestHashAndVerify(t *testing.T) {
+	prefixes := map[string]string{
+		AlgorithmBcrypt:      "$2a$",
+		AlgorithmArgon2id:    "$argon2id$v=19$m=65536,t=3,p=4$",
+		AlgorithmSHA256Crypt: "$5$",
+		AlgorithmSHA512Crypt: "$6$",
+	}
+
+	for _, algorithm := range SupportedAlgorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			h, err := Hash(algorithm, "s3cr3t")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(h, prefixes[algorithm]), h)
+			require.NoError(t, Validate(h))
+
+			ok, err := Verify(h, "s3cr3t")
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = Verify(h, "wrong")
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			// Salt must be random
+			h2, err := Hash(algorithm, "s3cr3t")
+			require.NoError(t, err)
+			assert.NotEqual(t, h, h2)
+		})
+	}
+}
+
+func TestHash_UnsupportedAlgorithm(t *testing.T) {
+	_, err := Hash("md5", "s3cr3t")
+	assert.EqualError(t, err, "unsupported algorithm md5")
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		hash    string
+		wantErr string
+	}{
+		{name: "plain text", hash: "password", wantErr: "unsupported password hash format"},
+		{name: "apache md5", hash: "$apr1$salt$hash", wantErr: "unsupported password hash format"},
+		{name: "sha1", hash: "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", wantErr: "unsupported password hash format"},
+		{name: "invalid bcrypt", hash: "$2y$10$short", wantErr: "crypto/bcrypt: hashedSecret too short to be a bcrypted password"},
+		{name: "invalid argon2id parts", hash: "$argon2id$v=19$m=65536,t=3,p=4$salt", wantErr: "invalid argon2id hash format"},
+		{name: "invalid argon2id version", hash: "$argon2id$v=16$m=65536,t=3,p=4$c2FsdA$a2V5", wantErr: "unsupported argon2id version 16"},
+		{name: "invalid argon2id parameters", hash: "$argon2id$v=19$m=0,t=3,p=4$c2FsdA$a2V5", wantErr: "invalid argon2id parameters"},
+		{name: "invalid sha-crypt format", hash: "$6$saltonly", wantErr: "invalid sha-crypt hash format"},
+		{name: "invalid sha-crypt rounds", hash: "$5$rounds=10$salt$hash", wantErr: "sha-crypt rounds must be between 1000 and 999999999"},
+		{name: "valid argon2id", hash: "$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$a2V5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.hash)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestVerify_UnsupportedHash(t *testing.T) {
+	ok, err := Verify("password", "password")
+	assert.False(t, ok)
+	assert.ErrorIs(t, err, ErrUnsupportedHash)
+}
+
+func TestVerifyPlain(t *testing.T) {
+	assert.True(t, VerifyPlain("s3cr3t", "s3cr3t"))
+	assert.False(t, VerifyPlain("s3cr3t", "s3cr3"))
+	assert.False(t, VerifyPlain("s3cr3t", ""))
+}
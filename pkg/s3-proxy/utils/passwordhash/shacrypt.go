@@ -0,0 +1,246 @@
+package passwordhash
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// SHA-crypt implementation following https://www.akkadia.org/drepper/SHA-crypt.txt.
+
+const (
+	shaCryptAlphabet      = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	shaCryptRoundsPrefix  = "rounds="
+	shaCryptSaltMaxLength = 16
+	shaCryptDefaultRounds = 5000
+	shaCryptMinRounds     = 1000
+	shaCryptMaxRounds     = 999999999
+)
+
+type shaCryptVariantCfg struct {
+	newHash func() hash.Hash
+	prefix  string
+	// Digest bytes order used for encoding, by groups of 3 bytes.
+	// Last group can be incomplete.
+	encodeOrder [][]int
+}
+
+var sha256CryptVariant = &shaCryptVariantCfg{
+	prefix:  "$5$",
+	newHash: sha256.New,
+	encodeOrder: [][]int{
+		{0, 10, 20}, {21, 1, 11}, {12, 22, 2}, {3, 13, 23}, {24, 4, 14},
+		{15, 25, 5}, {6, 16, 26}, {27, 7, 17}, {18, 28, 8}, {9, 19, 29},
+		{31, 30},
+	},
+}
+
+var sha512CryptVariant = &shaCryptVariantCfg{
+	prefix:  "$6$",
+	newHash: sha512.New,
+	encodeOrder: [][]int{
+		{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4},
+		{47, 5, 26}, {6, 27, 48}, {28, 49, 7}, {50, 8, 29}, {9, 30, 51},
+		{31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13}, {56, 14, 35},
+		{15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19},
+		{62, 20, 41}, {63},
+	},
+}
+
+type shaCryptHash struct {
+	variant      *shaCryptVariantCfg
+	salt         []byte
+	rounds       int
+	roundsCustom bool
+}
+
+// parseSHACrypt will parse a SHA-crypt hash ($5$ or $6$).
+func parseSHACrypt(h string) (*shaCryptHash, error) {
+	res := &shaCryptHash{rounds: shaCryptDefaultRounds}
+
+	// Get variant
+	var rest string
+
+	switch {
+	case strings.HasPrefix(h, sha256CryptVariant.prefix):
+		res.variant = sha256CryptVariant
+		rest = strings.TrimPrefix(h, sha256CryptVariant.prefix)
+	case strings.HasPrefix(h, sha512CryptVariant.prefix):
+		res.variant = sha512CryptVariant
+		rest = strings.TrimPrefix(h, sha512CryptVariant.prefix)
+	default:
+		return nil, errors.WithStack(ErrUnsupportedHash)
+	}
+
+	// Check if rounds are customized
+	if strings.HasPrefix(rest, shaCryptRoundsPrefix) {
+		roundsStr, after, found := strings.Cut(strings.TrimPrefix(rest, shaCryptRoundsPrefix), "$")
+		if !found {
+			return nil, errors.New("invalid sha-crypt hash format")
+		}
+
+		rounds, err := strconv.Atoi(roundsStr)
+		// Check error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		// Check rounds
+		if rounds < shaCryptMinRounds || rounds > shaCryptMaxRounds {
+			return nil, errors.Errorf("sha-crypt rounds must be between %d and %d", shaCryptMinRounds, shaCryptMaxRounds)
+		}
+
+		res.rounds = rounds
+		res.roundsCustom = true
+		rest = after
+	}
+
+	// Get salt
+	salt, digest, found := strings.Cut(rest, "$")
+	if !found || digest == "" {
+		return nil, errors.New("invalid sha-crypt hash format")
+	}
+
+	res.salt = []byte(salt)
+
+	return res, nil
+}
+
+// generateSHACryptSalt will generate a random salt of maximum length.
+func generateSHACryptSalt() ([]byte, error) {
+	buf := make([]byte, shaCryptSaltMaxLength)
+
+	_, err := rand.Read(buf)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for i := range buf {
+		buf[i] = shaCryptAlphabet[int(buf[i])%len(shaCryptAlphabet)]
+	}
+
+	return buf, nil
+}
+
+// shaCrypt will compute the SHA-crypt hash string of a password.
+func shaCrypt(variant *shaCryptVariantCfg, password, salt []byte, rounds int, roundsCustom bool) string {
+	// Clamp parameters
+	if len(salt) > shaCryptSaltMaxLength {
+		salt = salt[:shaCryptSaltMaxLength]
+	}
+
+	rounds = min(max(rounds, shaCryptMinRounds), shaCryptMaxRounds)
+
+	// Compute digest B
+	h := variant.newHash()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(password)
+	digestB := h.Sum(nil)
+
+	// Compute digest A
+	h = variant.newHash()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(repeatBytes(digestB, len(password)))
+
+	for cnt := len(password); cnt > 0; cnt >>= 1 {
+		if cnt&1 != 0 {
+			h.Write(digestB)
+		} else {
+			h.Write(password)
+		}
+	}
+
+	digestA := h.Sum(nil)
+
+	// Compute sequence P
+	h = variant.newHash()
+	for range len(password) {
+		h.Write(password)
+	}
+
+	seqP := repeatBytes(h.Sum(nil), len(password))
+
+	// Compute sequence S
+	h = variant.newHash()
+	for range 16 + int(digestA[0]) {
+		h.Write(salt)
+	}
+
+	seqS := repeatBytes(h.Sum(nil), len(salt))
+
+	// Compute rounds
+	digestC := digestA
+
+	for i := range rounds {
+		h = variant.newHash()
+
+		if i%2 != 0 {
+			h.Write(seqP)
+		} else {
+			h.Write(digestC)
+		}
+
+		if i%3 != 0 {
+			h.Write(seqS)
+		}
+
+		if i%7 != 0 {
+			h.Write(seqP)
+		}
+
+		if i%2 != 0 {
+			h.Write(digestC)
+		} else {
+			h.Write(seqP)
+		}
+
+		digestC = h.Sum(nil)
+	}
+
+	// Build result
+	var sb strings.Builder
+
+	sb.WriteString(variant.prefix)
+
+	if roundsCustom {
+		sb.WriteString(shaCryptRoundsPrefix)
+		sb.WriteString(strconv.Itoa(rounds))
+		sb.WriteString("$")
+	}
+
+	sb.Write(salt)
+	sb.WriteString("$")
+
+	for _, group := range variant.encodeOrder {
+		// Build 24 bits word with first index as most significant byte
+		w := 0
+		for _, idx := range group {
+			w = w<<8 | int(digestC[idx])
+		}
+		// Number of characters is the number of bits rounded up to 6 bits characters
+		for range (len(group)*8 + 5) / 6 {
+			sb.WriteByte(shaCryptAlphabet[w&0x3f])
+			w >>= 6
+		}
+	}
+
+	return sb.String()
+}
+
+// repeatBytes will repeat input until length is reached.
+func repeatBytes(in []byte, length int) []byte {
+	res := make([]byte, 0, length)
+	for len(res) < length {
+		res = append(res, in[:min(len(in), length-len(res))]...)
+	}
+
+	return res
+}
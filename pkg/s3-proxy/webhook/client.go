@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics"
 )
 
@@ -40,6 +41,33 @@ type S3Metadata struct {
 	Key        string
 }
 
+// PreActionInput Pre-action hooks input.
+type PreActionInput struct {
+	// ResolveKey returns the object key in bucket for a path relative to the target.
+	// It returns an error when the path isn't allowed for the request.
+	ResolveKey func(path string) (string, error)
+	// RequestPath is the request path.
+	RequestPath string
+	// Path is the object path relative to the target.
+	Path string
+	// Key is the object key in bucket.
+	Key string
+}
+
+// PreActionResult Pre-action hooks result.
+type PreActionResult struct {
+	// Metadata contains metadata to add to the object.
+	Metadata map[string]string
+	// Key is the object key to use. It can be rewritten by hooks.
+	Key string
+	// Path is the object path relative to the target. It can be rewritten by hooks.
+	Path string
+	// Reason is the deny reason.
+	Reason string
+	// Allowed is true when the action is allowed.
+	Allowed bool
+}
+
 // Manager client manager.
 //
 //go:generate mockgen -destination=./mocks/mock_Manager.go -package=mocks github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook Manager
@@ -52,14 +80,35 @@ type Manager interface {
 	ManagePUTHooks(ctx context.Context, targetKey, requestPath string, inputMetadata *PutInputMetadata, s3Metadata *S3Metadata)
 	// ManageGETHooks will manage DELETE hooks.
 	ManageDELETEHooks(ctx context.Context, targetKey, requestPath string, s3Metadata *S3Metadata)
-	// Load will load all webhooks clients.
+	// ExecutePUTPreActionHooks will call PUT pre-action hooks synchronously and return the decision.
+	// Hooks are called in order and each hook receives the path rewritten by the previous ones.
+	ExecutePUTPreActionHooks(
+		ctx context.Context,
+		targetKey string,
+		input *PreActionInput,
+		inputMetadata *PutInputMetadata,
+	) *PreActionResult
+	// ExecuteDELETEPreActionHooks will call DELETE pre-action hooks synchronously and return the decision.
+	ExecuteDELETEPreActionHooks(ctx context.Context, targetKey string, input *PreActionInput) *PreActionResult
+	// Load will load all webhooks clients and the durable queue.
+	// Queue is restarted when its configuration changes.
 	Load() error
+	// ListDeadLetters will return deliveries stored in the dead letter store.
+	// ErrQueueDisabled is returned when the durable queue isn't enabled.
+	ListDeadLetters() ([]*Delivery, error)
+	// ReplayDeadLetters will move a dead letter, or all of them when id is empty, back to the queue.
+	// It returns the number of replayed deliveries.
+	// ErrQueueDisabled is returned when the durable queue isn't enabled and ErrDeadLetterNotFound when id doesn't exist.
+	ReplayDeadLetters(id string) (int, error)
+	// Close will stop the durable queue workers. Pending deliveries are kept on disk.
+	Close() error
 }
 
-func NewManager(cfgManager config.Manager, metricsSvc metrics.Client) Manager {
+func NewManager(cfgManager config.Manager, metricsSvc metrics.Client, logger log.Logger) Manager {
 	return &manager{
 		cfgManager: cfgManager,
 		storageMap: map[string]*hooksCfgStorage{},
 		metricsSvc: metricsSvc,
+		logger:     logger,
 	}
 }
@@ -2,9 +2,12 @@ package webhook
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"emperror.dev/errors"
@@ -15,6 +18,7 @@ import (
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook/signature"
 )
 
 // HookNumberOfRedirect will contains the number of redirect that a hook can follow.
@@ -24,13 +28,20 @@ type manager struct {
 	cfgManager config.Manager
 	storageMap map[string]*hooksCfgStorage
 	metricsSvc metrics.Client
+	logger     log.Logger
+	queue      *queue
+	// Storage map is read by requests and queue workers while it is updated on reload.
+	storageMutex sync.RWMutex
+	queueMutex   sync.RWMutex
 }
 
 type hooksCfgStorage struct {
-	Get    []*hookStorage
-	Head   []*hookStorage
-	Put    []*hookStorage
-	Delete []*hookStorage
+	Get             []*hookStorage
+	Head            []*hookStorage
+	Put             []*hookStorage
+	Delete          []*hookStorage
+	PutPreAction    []*preActionHookStorage
+	DeletePreAction []*preActionHookStorage
 }
 
 type hookStorage struct {
@@ -44,6 +55,8 @@ func (m *manager) Load() error {
 
 	// Store target keys
 	tgtKeys := []string{}
+	// Store new entries
+	entries := map[string]*hooksCfgStorage{}
 
 	// Loop over the target map
 	for k, targetCfg := range cfg.Targets {
@@ -52,10 +65,12 @@ func (m *manager) Load() error {
 
 		// Create storage structure
 		entry := &hooksCfgStorage{
-			Get:    []*hookStorage{},
-			Head:   []*hookStorage{},
-			Put:    []*hookStorage{},
-			Delete: []*hookStorage{},
+			Get:             []*hookStorage{},
+			Head:            []*hookStorage{},
+			Put:             []*hookStorage{},
+			Delete:          []*hookStorage{},
+			PutPreAction:    []*preActionHookStorage{},
+			DeletePreAction: []*preActionHookStorage{},
 		}
 
 		// Check if actions are present
@@ -94,6 +109,7 @@ func (m *manager) Load() error {
 				}
 				// Store
 				entry.Put = list
+				entry.PutPreAction = createPreActionRestClients(targetCfg.Actions.PUT.Config.PreActionHooks)
 			}
 
 			// Check if DELETE action is present and have a config
@@ -106,13 +122,18 @@ func (m *manager) Load() error {
 				}
 				// Store
 				entry.Delete = list
+				entry.DeletePreAction = createPreActionRestClients(targetCfg.Actions.DELETE.Config.PreActionHooks)
 			}
 		}
 
 		// Save new entry
-		m.storageMap[k] = entry
+		entries[k] = entry
 	}
 
+	m.storageMutex.Lock()
+	// Save new entries
+	maps.Copy(m.storageMap, entries)
+
 	// Get all keys from current object
 	actualKeysInt := funk.Keys(m.storageMap)
 	// Check if result exists or not
@@ -128,8 +149,10 @@ func (m *manager) Load() error {
 		}
 	}
 
-	// Default return
-	return nil
+	m.storageMutex.Unlock()
+
+	// Load durable queue
+	return m.loadQueue(cfg.WebhookQueue)
 }
 
 func (*manager) createRestClients(list []*config.WebhookConfig) ([]*hookStorage, error) {
@@ -202,7 +225,7 @@ func (m *manager) manageDELETEHooksInternal(
 	logger := log.GetLoggerFromContext(ctx)
 
 	// Get target storage
-	sto := m.storageMap[targetKey]
+	sto := m.getStorage(targetKey)
 
 	// Check if storage is empty
 	if sto == nil || len(sto.Delete) == 0 {
@@ -255,7 +278,7 @@ func (m *manager) managePUTHooksInternal(
 	logger := log.GetLoggerFromContext(ctx)
 
 	// Get target storage
-	sto := m.storageMap[targetKey]
+	sto := m.getStorage(targetKey)
 
 	// Check if storage is empty
 	if sto == nil || len(sto.Put) == 0 {
@@ -315,7 +338,7 @@ func (m *manager) manageGETHooksInternal(
 	logger := log.GetLoggerFromContext(ctx)
 
 	// Get target storage
-	sto := m.storageMap[targetKey]
+	sto := m.getStorage(targetKey)
 
 	// Check if storage is empty
 	if sto == nil || len(sto.Get) == 0 {
@@ -383,7 +406,7 @@ func (m *manager) manageHEADHooksInternal(
 	logger := log.GetLoggerFromContext(ctx)
 
 	// Get target storage
-	sto := m.storageMap[targetKey]
+	sto := m.getStorage(targetKey)
 
 	// Check if storage is empty
 	if sto == nil || len(sto.Head) == 0 {
@@ -455,6 +478,13 @@ func (m *manager) runHooks(
 		},
 	}
 
+	// Get durable queue
+	// Queue is kept locked during enqueue to avoid losing deliveries on queue reload.
+	m.queueMutex.RLock()
+	defer m.queueMutex.RUnlock()
+
+	q := m.queue
+
 	// Need to create an intermediate function to manage defer properly
 	executeOne := func(i int, st *hookStorage) {
 		// Create specific logger
@@ -470,24 +500,10 @@ func (m *manager) runHooks(
 
 		defer childTrace.Finish()
 
-		// Save client
-		cl := st.Client.R()
-		// Add all fixed headers
-		for k, val := range st.Config.Headers {
-			// Add header
-			cl = cl.SetHeader(k, val)
-		}
-		// Add all secret headers
-		for k, val := range st.Config.SecretHeaders {
-			// Add header
-			cl = cl.SetHeader(k, val.Value)
-		}
-		// Add content-type
-		cl = cl.SetHeader("Content-Type", "application/json")
-		// Add body
-		cl = cl.SetBody(body)
-		// Add trace to http header for forwarding
-		err := childTrace.InjectInHTTPHeader(cl.Header)
+		// Create trace header for forwarding
+		traceHeader := http.Header{}
+		// Add trace to http header
+		err := childTrace.InjectInHTTPHeader(traceHeader)
 		// Check error
 		if err != nil {
 			spLogger.Error(errors.WithStack(err))
@@ -495,29 +511,41 @@ func (m *manager) runHooks(
 			// Stop here
 			return
 		}
-		// Log
-		spLogger.Info("Executing webhook")
-		// Execute request
-		res, err := cl.Execute(st.Config.Method, st.Config.URL)
-		// Check error
-		if err != nil {
-			// Log
-			spLogger.Error(errors.WithStack(err))
 
-			// Increase failed webhooks
-			m.metricsSvc.IncFailedWebhooks(targetName, action)
+		// Check if durable queue is enabled
+		if q != nil {
+			// Add delivery to queue
+			err = q.enqueue(targetName, action, i, st.Config, body, traceHeader)
+			// Check error
+			if err != nil {
+				spLogger.Error(err)
+
+				// Increase failed webhooks
+				m.metricsSvc.IncFailedWebhooks(targetName, action)
+
+				// Stop here
+				return
+			}
+
+			spLogger.Info("Webhook added to durable queue")
 
 			// Stop here
 			return
 		}
-		// Add status code to logger
-		spLogger = spLogger.WithField("webhook_status_code", strconv.Itoa(res.StatusCode()))
-		// Check status code
-		if res.StatusCode() >= http.StatusBadRequest {
-			// Create error
-			err := fmt.Errorf("%d - %s", res.StatusCode(), string(res.Body()))
+
+		// Log
+		spLogger.Info("Executing webhook")
+		// Execute request
+		statusCode, err := callHook(st, body, traceHeader)
+		// Check if status code exists
+		if statusCode != 0 {
+			// Add status code to logger
+			spLogger = spLogger.WithField("webhook_status_code", strconv.Itoa(statusCode))
+		}
+		// Check error
+		if err != nil {
 			// Log
-			spLogger.Error(errors.WithStack(err))
+			spLogger.Error(err)
 
 			// Increase failed webhooks
 			m.metricsSvc.IncFailedWebhooks(targetName, action)
@@ -537,3 +565,195 @@ func (m *manager) runHooks(
 		executeOne(i, st)
 	}
 }
+
+// callHook will call a webhook with body and trace header.
+// Body is signed when a signature is configured.
+// Status code is returned when the webhook has answered, and an error when the call fails
+// or when the webhook answers with an error status code.
+func callHook(st *hookStorage, body any, traceHeader http.Header) (int, error) {
+	// Create request
+	cl, err := newHookRequest(st, body, traceHeader)
+	// Check error
+	if err != nil {
+		return 0, err
+	}
+
+	// Execute request
+	res, err := cl.Execute(st.Config.Method, st.Config.URL)
+	// Check error
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	// Check status code
+	if res.StatusCode() >= http.StatusBadRequest {
+		return res.StatusCode(), errors.WithStack(fmt.Errorf("%d - %s", res.StatusCode(), string(res.Body())))
+	}
+
+	return res.StatusCode(), nil
+}
+
+// newHookRequest will create a hook request with headers, secret headers, trace header and signature.
+func newHookRequest(st *hookStorage, body any, traceHeader http.Header) (*resty.Request, error) {
+	// Marshal body to sign the exact sent payload
+	bb, err := json.Marshal(body)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Save client
+	cl := st.Client.R()
+	// Add all fixed headers
+	for k, val := range st.Config.Headers {
+		// Add header
+		cl = cl.SetHeader(k, val)
+	}
+	// Add all secret headers
+	for k, val := range st.Config.SecretHeaders {
+		// Add header
+		cl = cl.SetHeader(k, val.Value)
+	}
+	// Add content-type
+	cl = cl.SetHeader("Content-Type", "application/json")
+	// Add body
+	cl = cl.SetBody(bb)
+	// Add trace header
+	for k, val := range traceHeader {
+		cl.Header[k] = val
+	}
+
+	// Check if signature is enabled
+	if st.Config.Signature != nil {
+		// Get secrets
+		secrets := make([]string, 0, len(st.Config.Signature.Secrets))
+		for _, it := range st.Config.Signature.Secrets {
+			secrets = append(secrets, it.Value)
+		}
+		// Add signature header with a new timestamp for each attempt
+		cl = cl.SetHeader(st.Config.Signature.Header, signature.Header(time.Now(), bb, secrets...))
+	}
+
+	return cl, nil
+}
+
+// loadQueue will start, restart or stop the durable queue depending on configuration.
+func (m *manager) loadQueue(cfg *config.WebhookQueueConfig) error {
+	m.queueMutex.Lock()
+	defer m.queueMutex.Unlock()
+
+	enabled := cfg != nil && cfg.Enabled
+
+	// Check if queue is already running with the same configuration
+	if enabled && m.queue != nil && *m.queue.cfg == *cfg {
+		return nil
+	}
+
+	// Stop current queue
+	if m.queue != nil {
+		m.queue.close()
+		m.queue = nil
+	}
+
+	// Check if queue is disabled
+	if !enabled {
+		return nil
+	}
+
+	// Copy configuration to detect changes on reload
+	qCfg := *cfg
+
+	// Create queue
+	q, err := newQueue(&qCfg, m.metricsSvc, m.logger, m.findHook)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	m.queue = q
+
+	return nil
+}
+
+// getStorage will return the hooks storage of a target or nil if it doesn't exist.
+func (m *manager) getStorage(targetKey string) *hooksCfgStorage {
+	m.storageMutex.RLock()
+	defer m.storageMutex.RUnlock()
+
+	return m.storageMap[targetKey]
+}
+
+// findHook will return the hook storage matching a delivery.
+// Hook is searched at the same position first and then by url and method in case of configuration change.
+func (m *manager) findHook(d *Delivery) *hookStorage {
+	// Get target storage
+	entry := m.getStorage(d.Target)
+	// Check if target doesn't exist
+	if entry == nil {
+		return nil
+	}
+
+	var list []*hookStorage
+
+	switch d.Action {
+	case GETAction:
+		list = entry.Get
+	case HEADAction:
+		list = entry.Head
+	case PUTAction:
+		list = entry.Put
+	case DELETEAction:
+		list = entry.Delete
+	}
+
+	// Check hook at same position
+	if d.HookIndex >= 0 && d.HookIndex < len(list) &&
+		list[d.HookIndex].Config.URL == d.URL && list[d.HookIndex].Config.Method == d.Method {
+		return list[d.HookIndex]
+	}
+
+	// Search by url and method
+	for _, st := range list {
+		if st.Config.URL == d.URL && st.Config.Method == d.Method {
+			return st
+		}
+	}
+
+	return nil
+}
+
+func (m *manager) ListDeadLetters() ([]*Delivery, error) {
+	m.queueMutex.RLock()
+	defer m.queueMutex.RUnlock()
+
+	// Check if queue is disabled
+	if m.queue == nil {
+		return nil, errors.WithStack(ErrQueueDisabled)
+	}
+
+	return m.queue.listDeadLetters()
+}
+
+func (m *manager) ReplayDeadLetters(id string) (int, error) {
+	m.queueMutex.RLock()
+	defer m.queueMutex.RUnlock()
+
+	// Check if queue is disabled
+	if m.queue == nil {
+		return 0, errors.WithStack(ErrQueueDisabled)
+	}
+
+	return m.queue.replay(id)
+}
+
+func (m *manager) Close() error {
+	m.queueMutex.Lock()
+	defer m.queueMutex.Unlock()
+
+	// Check if queue exists
+	if m.queue != nil {
+		m.queue.close()
+		m.queue = nil
+	}
+
+	return nil
+}
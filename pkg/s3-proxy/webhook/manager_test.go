@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
@@ -19,6 +20,7 @@ import (
 	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
 	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
 	mmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/webhook/signature"
 )
 
 func Test_manager_createRestClients(t *testing.T) {
@@ -3099,3 +3101,50 @@ func Test_manager_manageHEADHooksInternal(t *testing.T) {
 		})
 	}
 }
+
+func Test_callHook_signature(t *testing.T) {
+	var (
+		body   []byte
+		header string
+	)
+
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		by, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		body = by
+		header = r.Header.Get("X-Signature")
+
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+
+	st := &hookStorage{
+		Client: resty.New(),
+		Config: &config.WebhookConfig{
+			Method: http.MethodPost,
+			URL:    s.URL,
+			Signature: &config.WebhookSignatureConfig{
+				Header:  "X-Signature",
+				Secrets: []*config.CredentialConfig{{Value: "new"}, {Value: "old"}},
+			},
+		},
+	}
+
+	code, err := callHook(st, &HookBody{Action: PUTAction, RequestPath: "/file"}, http.Header{})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"action":"PUT","requestPath":"/file","target":null}`, string(body))
+
+	// Receivers knowing only one of the secrets must accept the request
+	assert.NoError(t, signature.Verify(header, body, []string{"old"}, time.Minute, time.Now()))
+	assert.NoError(t, signature.Verify(header, body, []string{"new"}, time.Minute, time.Now()))
+	assert.ErrorIs(t, signature.Verify(header, body, []string{"other"}, time.Minute, time.Now()), signature.ErrMismatch)
+
+	// Without signature configuration
+	st.Config.Signature = nil
+
+	_, err = callHook(st, &HookBody{Action: PUTAction}, http.Header{})
+	assert.NoError(t, err)
+	assert.Empty(t, header)
+}
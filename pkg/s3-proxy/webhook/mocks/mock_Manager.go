@@ -41,6 +41,63 @@ func (m *MockManager) EXPECT() *MockManagerMockRecorder {
 	return m.recorder
 }
 
+// Close mocks base method.
+func (m *MockManager) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockManagerMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockManager)(nil).Close))
+}
+
+// ExecuteDELETEPreActionHooks mocks base method.
+func (m *MockManager) ExecuteDELETEPreActionHooks(ctx context.Context, targetKey string, input *webhook.PreActionInput) *webhook.PreActionResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteDELETEPreActionHooks", ctx, targetKey, input)
+	ret0, _ := ret[0].(*webhook.PreActionResult)
+	return ret0
+}
+
+// ExecuteDELETEPreActionHooks indicates an expected call of ExecuteDELETEPreActionHooks.
+func (mr *MockManagerMockRecorder) ExecuteDELETEPreActionHooks(ctx, targetKey, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteDELETEPreActionHooks", reflect.TypeOf((*MockManager)(nil).ExecuteDELETEPreActionHooks), ctx, targetKey, input)
+}
+
+// ExecutePUTPreActionHooks mocks base method.
+func (m *MockManager) ExecutePUTPreActionHooks(ctx context.Context, targetKey string, input *webhook.PreActionInput, inputMetadata *webhook.PutInputMetadata) *webhook.PreActionResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecutePUTPreActionHooks", ctx, targetKey, input, inputMetadata)
+	ret0, _ := ret[0].(*webhook.PreActionResult)
+	return ret0
+}
+
+// ExecutePUTPreActionHooks indicates an expected call of ExecutePUTPreActionHooks.
+func (mr *MockManagerMockRecorder) ExecutePUTPreActionHooks(ctx, targetKey, input, inputMetadata any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecutePUTPreActionHooks", reflect.TypeOf((*MockManager)(nil).ExecutePUTPreActionHooks), ctx, targetKey, input, inputMetadata)
+}
+
+// ListDeadLetters mocks base method.
+func (m *MockManager) ListDeadLetters() ([]*webhook.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeadLetters")
+	ret0, _ := ret[0].([]*webhook.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeadLetters indicates an expected call of ListDeadLetters.
+func (mr *MockManagerMockRecorder) ListDeadLetters() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeadLetters", reflect.TypeOf((*MockManager)(nil).ListDeadLetters))
+}
+
 // Load mocks base method.
 func (m *MockManager) Load() error {
 	m.ctrl.T.Helper()
@@ -102,3 +159,18 @@ func (mr *MockManagerMockRecorder) ManagePUTHooks(ctx, targetKey, requestPath, i
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ManagePUTHooks", reflect.TypeOf((*MockManager)(nil).ManagePUTHooks), ctx, targetKey, requestPath, inputMetadata, s3Metadata)
 }
+
+// ReplayDeadLetters mocks base method.
+func (m *MockManager) ReplayDeadLetters(id string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayDeadLetters", id)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplayDeadLetters indicates an expected call of ReplayDeadLetters.
+func (mr *MockManagerMockRecorder) ReplayDeadLetters(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayDeadLetters", reflect.TypeOf((*MockManager)(nil).ReplayDeadLetters), id)
+}
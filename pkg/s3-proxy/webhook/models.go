@@ -53,3 +53,28 @@ type BucketHookBody struct {
 	S3Endpoint string `json:"s3Endpoint"`
 	Key        string `json:"key"`
 }
+
+type PreActionHookBody struct {
+	InputMetadata any             `json:"inputMetadata,omitempty"`
+	User          *UserHookBody   `json:"user,omitempty"`
+	Target        *TargetHookBody `json:"target"`
+	Action        string          `json:"action"`
+	RequestPath   string          `json:"requestPath"`
+	Path          string          `json:"path"`
+	Key           string          `json:"key"`
+	ClientIP      string          `json:"clientIP,omitempty"`
+}
+
+type UserHookBody struct {
+	Type       string   `json:"type"`
+	Identifier string   `json:"identifier"`
+	Email      string   `json:"email,omitempty"`
+	Groups     []string `json:"groups,omitempty"`
+}
+
+type PreActionHookResponse struct {
+	Metadata map[string]string `json:"metadata"`
+	Allowed  *bool             `json:"allowed"`
+	Reason   string            `json:"reason"`
+	Path     string            `json:"path"`
+}
@@ -0,0 +1,311 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-resty/resty/v2"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/authx/models"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/tracing"
+)
+
+// PreActionHookFailureReason is the deny reason used when a hook fails with the closed failure policy.
+const PreActionHookFailureReason = "pre-action hook failed"
+
+// PreActionHookDefaultDenyReason is the deny reason used when a hook denies without reason.
+const PreActionHookDefaultDenyReason = "denied by pre-action hook"
+
+type preActionHookStorage struct {
+	hook   *hookStorage
+	Config *config.PreActionHookConfig
+}
+
+func createPreActionRestClients(list []*config.PreActionHookConfig) []*preActionHookStorage {
+	// Create result
+	res := []*preActionHookStorage{}
+
+	// Loop over the list
+	for _, it := range list {
+		// Get timeout
+		timeout := it.Timeout
+		// Check if timeout is set
+		if timeout == 0 {
+			timeout = config.DefaultPreActionHookTimeout
+		}
+
+		// Create client
+		// No retry is done here because the client request is waiting.
+		cli := resty.New().
+			SetTimeout(timeout).
+			SetRedirectPolicy(resty.FlexibleRedirectPolicy(HookNumberOfRedirect))
+
+		// Append
+		res = append(res, &preActionHookStorage{
+			hook: &hookStorage{
+				Client: cli,
+				Config: &config.WebhookConfig{
+					Headers:       it.Headers,
+					SecretHeaders: it.SecretHeaders,
+					Signature:     it.Signature,
+					Method:        it.Method,
+					URL:           it.URL,
+				},
+			},
+			Config: it,
+		})
+	}
+
+	return res
+}
+
+func (m *manager) ExecutePUTPreActionHooks(
+	ctx context.Context,
+	targetKey string,
+	input *PreActionInput,
+	metadata *PutInputMetadata,
+) *PreActionResult {
+	// Get target storage
+	sto := m.getStorage(targetKey)
+
+	// Check if storage is empty
+	if sto == nil || len(sto.PutPreAction) == 0 {
+		return &PreActionResult{Allowed: true, Key: input.Key, Path: input.Path}
+	}
+
+	// Create input metadata
+	inputMetadata := &PutInputMetadataHookBody{
+		Filename:    metadata.Filename,
+		ContentType: metadata.ContentType,
+		ContentSize: metadata.ContentSize,
+	}
+
+	return m.runPreActionHooks(ctx, input, inputMetadata, PUTAction, targetKey, sto.PutPreAction)
+}
+
+func (m *manager) ExecuteDELETEPreActionHooks(
+	ctx context.Context,
+	targetKey string,
+	input *PreActionInput,
+) *PreActionResult {
+	// Get target storage
+	sto := m.getStorage(targetKey)
+
+	// Check if storage is empty
+	if sto == nil || len(sto.DeletePreAction) == 0 {
+		return &PreActionResult{Allowed: true, Key: input.Key, Path: input.Path}
+	}
+
+	return m.runPreActionHooks(ctx, input, nil, DELETEAction, targetKey, sto.DeletePreAction)
+}
+
+func (*manager) runPreActionHooks(
+	ctx context.Context,
+	input *PreActionInput,
+	inputMetadata any,
+	action, targetName string,
+	hooks []*preActionHookStorage,
+) *PreActionResult {
+	// Get logger
+	logger := log.GetLoggerFromContext(ctx)
+
+	// Create result
+	res := &PreActionResult{Allowed: true, Key: input.Key, Path: input.Path}
+
+	// Create body
+	body := &PreActionHookBody{
+		Action:        action,
+		RequestPath:   input.RequestPath,
+		InputMetadata: inputMetadata,
+		Target: &TargetHookBody{
+			Name: targetName,
+		},
+	}
+
+	// Add authenticated user
+	if user := models.GetAuthenticatedUserFromContext(ctx); user != nil {
+		body.User = &UserHookBody{
+			Type:       user.GetType(),
+			Identifier: user.GetIdentifier(),
+			Email:      user.GetEmail(),
+			Groups:     user.GetGroups(),
+		}
+	}
+
+	// Add client ip
+	if ip := middleware.GetClientIPAddr(ctx); ip.IsValid() {
+		body.ClientIP = ip.String()
+	}
+
+	// Loop over hooks
+	for i, st := range hooks {
+		// Create specific logger
+		spLogger := logger.WithFields(map[string]any{
+			"pre_action_hook_action": action,
+			"pre_action_hook_number": i,
+		})
+
+		// Send current path and key
+		body.Path = res.Path
+		body.Key = res.Key
+
+		// Log
+		spLogger.Debug("Executing pre-action hook")
+		// Call hook
+		hres, statusCode, err := callPreActionHook(ctx, st, body)
+		// Check if status code exists
+		if statusCode != 0 {
+			// Add status code to logger
+			spLogger = spLogger.WithField("pre_action_hook_status_code", strconv.Itoa(statusCode))
+		}
+
+		// Resolved key of rewritten path
+		var newKey string
+		// Check if path is rewritten
+		if err == nil && *hres.Allowed && hres.Path != "" && hres.Path != res.Path {
+			// Check rewrite path prefixes
+			// Authorization was done on the request path, so hooks can only rewrite it to folders reserved to them.
+			if !isRewritePathAllowed(st.Config.RewritePathPrefixes, hres.Path) {
+				err = errors.Errorf("invalid pre-action hook answer: path %s isn't in rewrite path prefixes", hres.Path)
+			} else {
+				// Resolve key
+				// This ensures that hooks can't put the object outside of the roots allowed for the request.
+				newKey, err = input.ResolveKey(hres.Path)
+				// Check error
+				if err != nil {
+					err = errors.WithMessagef(err, "invalid pre-action hook answer: path %s isn't allowed", hres.Path)
+				}
+			}
+		}
+
+		// Check error
+		if err != nil {
+			// Check if failure policy is open
+			if st.Config.FailurePolicy == config.PreActionHookFailurePolicyOpen {
+				spLogger.Warn(errors.WithMessage(err, "pre-action hook failed, ignoring it because failure policy is open"))
+
+				continue
+			}
+
+			spLogger.Error(errors.WithMessage(err, "pre-action hook failed, denying action because failure policy is closed"))
+
+			return &PreActionResult{Key: res.Key, Path: res.Path, Reason: PreActionHookFailureReason}
+		}
+
+		// Check if action is denied
+		if !*hres.Allowed {
+			// Get reason
+			reason := hres.Reason
+			// Check if reason is set
+			if reason == "" {
+				reason = PreActionHookDefaultDenyReason
+			}
+
+			spLogger.Infof("Action denied by pre-action hook: %s", reason)
+
+			return &PreActionResult{Key: res.Key, Path: res.Path, Reason: reason}
+		}
+
+		// Check if path is rewritten
+		if newKey != "" {
+			spLogger.Debugf("Path rewritten by pre-action hook from %s to %s (key %s)", res.Path, hres.Path, newKey)
+
+			res.Path = hres.Path
+			res.Key = newKey
+		}
+
+		// Merge metadata
+		for k, v := range hres.Metadata {
+			// Check if map exists
+			if res.Metadata == nil {
+				res.Metadata = map[string]string{}
+			}
+
+			res.Metadata[k] = v
+		}
+	}
+
+	return res
+}
+
+// isRewritePathAllowed checks if a rewritten path is inside one of the rewrite path prefixes.
+// Without prefixes, rewrites aren't allowed.
+func isRewritePathAllowed(prefixes []string, p string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// callPreActionHook will call a pre-action hook and parse its answer.
+// Status code is returned when the hook has answered, and an error when the call fails,
+// when the hook answers with a non 2xx status code or with an invalid body.
+func callPreActionHook(
+	ctx context.Context,
+	st *preActionHookStorage,
+	body *PreActionHookBody,
+) (*PreActionHookResponse, int, error) {
+	// Create child trace
+	childTrace := tracing.GetTraceFromContext(ctx).GetChildTrace("pre-action-hook")
+	childTrace.SetTag("pre-action-hook-url", st.Config.URL)
+	childTrace.SetTag("pre-action-hook-method", st.Config.Method)
+
+	defer childTrace.Finish()
+
+	// Create trace header for forwarding
+	traceHeader := http.Header{}
+	// Add trace to http header
+	err := childTrace.InjectInHTTPHeader(traceHeader)
+	// Check error
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+
+	// Create request
+	cl, err := newHookRequest(st.hook, body, traceHeader)
+	// Check error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Execute request
+	// Request context is used to stop the call when the client leaves.
+	res, err := cl.SetContext(ctx).Execute(st.Config.Method, st.Config.URL)
+	// Check error
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+	// Check status code
+	if res.StatusCode() < http.StatusOK || res.StatusCode() >= http.StatusMultipleChoices {
+		return nil, res.StatusCode(), errors.WithStack(fmt.Errorf("%d - %s", res.StatusCode(), string(res.Body())))
+	}
+
+	// Parse answer
+	hres := &PreActionHookResponse{}
+	err = json.Unmarshal(res.Body(), hres)
+	// Check error
+	if err != nil {
+		return nil, res.StatusCode(), errors.Wrap(err, "invalid pre-action hook answer")
+	}
+	// Check that decision is present
+	if hres.Allowed == nil {
+		return nil, res.StatusCode(), errors.New("invalid pre-action hook answer: allowed field is missing")
+	}
+	// Check that path isn't a folder
+	if strings.HasSuffix(hres.Path, "/") {
+		return nil, res.StatusCode(), errors.Errorf("invalid pre-action hook answer: path %s is a folder", hres.Path)
+	}
+
+	return hres, res.StatusCode(), nil
+}
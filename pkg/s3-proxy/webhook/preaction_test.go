@@ -0,0 +1,343 @@
+//go:build unit
+
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+)
+
+// preActionTestServer is a pre-action hook server saving received bodies.
+type preActionTestServer struct {
+	*httptest.Server
+	bodies []*PreActionHookBody
+	mutex  sync.Mutex
+}
+
+func newPreActionTestServer(t *testing.T, statusCode int, answer string, delay time.Duration) *preActionTestServer {
+	t.Helper()
+
+	s := &preActionTestServer{bodies: []*PreActionHookBody{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		by, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		b := &PreActionHookBody{}
+		assert.NoError(t, json.Unmarshal(by, b))
+
+		s.mutex.Lock()
+		s.bodies = append(s.bodies, b)
+		s.mutex.Unlock()
+
+		time.Sleep(delay)
+
+		rw.WriteHeader(statusCode)
+		_, _ = rw.Write([]byte(answer))
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *preActionTestServer) calls() []*PreActionHookBody {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]*PreActionHookBody{}, s.bodies...)
+}
+
+// newPreActionTestInput returns an input with a resolver adding a prefix and refusing paths outside of it.
+func newPreActionTestInput() *PreActionInput {
+	return &PreActionInput{
+		ResolveKey: func(p string) (string, error) {
+			// Check escaping path
+			if strings.Contains(p, "..") {
+				return "", errors.New("forbidden path")
+			}
+
+			return "prefix/" + strings.TrimPrefix(p, "/"), nil
+		},
+		RequestPath: "/",
+		Path:        "/file",
+		Key:         "prefix/file",
+	}
+}
+
+func Test_manager_ExecutePUTPreActionHooks(t *testing.T) {
+	type hook struct {
+		answer              string
+		failurePolicy       string
+		rewritePathPrefixes []string
+		statusCode          int
+		delay               time.Duration
+	}
+
+	tests := []struct {
+		name          string
+		hooks         []hook
+		want          *PreActionResult
+		wantPathsSent []string
+	}{
+		{
+			name:          "no hook",
+			want:          &PreActionResult{Allowed: true, Key: "prefix/file", Path: "/file"},
+			wantPathsSent: []string{},
+		},
+		{
+			name: "allowed",
+			hooks: []hook{
+				{statusCode: http.StatusOK, answer: `{"allowed":true}`},
+			},
+			want:          &PreActionResult{Allowed: true, Key: "prefix/file", Path: "/file"},
+			wantPathsSent: []string{"/file"},
+		},
+		{
+			name: "denied with reason",
+			hooks: []hook{
+				{statusCode: http.StatusOK, answer: `{"allowed":false,"reason":"virus detected"}`},
+				{statusCode: http.StatusOK, answer: `{"allowed":true}`},
+			},
+			want:          &PreActionResult{Key: "prefix/file", Path: "/file", Reason: "virus detected"},
+			wantPathsSent: []string{"/file"},
+		},
+		{
+			name: "denied without reason",
+			hooks: []hook{
+				{statusCode: http.StatusOK, answer: `{"allowed":false}`},
+			},
+			want:          &PreActionResult{Key: "prefix/file", Path: "/file", Reason: PreActionHookDefaultDenyReason},
+			wantPathsSent: []string{"/file"},
+		},
+		{
+			name: "key rewritten and metadata merged",
+			hooks: []hook{
+				{
+					statusCode:          http.StatusOK,
+					answer:              `{"allowed":true,"path":"/scanned/new-file","metadata":{"a":"1","b":"1"}}`,
+					rewritePathPrefixes: []string{"/quarantine/", "/scanned/"},
+				},
+				{statusCode: http.StatusOK, answer: `{"allowed":true,"metadata":{"b":"2"}}`},
+			},
+			want: &PreActionResult{
+				Allowed:  true,
+				Key:      "prefix/scanned/new-file",
+				Path:     "/scanned/new-file",
+				Metadata: map[string]string{"a": "1", "b": "2"},
+			},
+			wantPathsSent: []string{"/file", "/scanned/new-file"},
+		},
+		{
+			name: "path rewritten without rewrite path prefixes",
+			hooks: []hook{
+				{statusCode: http.StatusOK, answer: `{"allowed":true,"path":"/new-file"}`, failurePolicy: config.PreActionHookFailurePolicyClosed},
+			},
+			want:          &PreActionResult{Key: "prefix/file", Path: "/file", Reason: PreActionHookFailureReason},
+			wantPathsSent: []string{"/file"},
+		},
+		{
+			name: "path rewritten outside rewrite path prefixes",
+			hooks: []hook{
+				{
+					statusCode:          http.StatusOK,
+					answer:              `{"allowed":true,"path":"/scanned-private/file"}`,
+					failurePolicy:       config.PreActionHookFailurePolicyClosed,
+					rewritePathPrefixes: []string{"/scanned/"},
+				},
+			},
+			want:          &PreActionResult{Key: "prefix/file", Path: "/file", Reason: PreActionHookFailureReason},
+			wantPathsSent: []string{"/file"},
+		},
+		{
+			name: "error status with closed policy",
+			hooks: []hook{
+				{statusCode: http.StatusInternalServerError, failurePolicy: config.PreActionHookFailurePolicyClosed},
+			},
+			want:          &PreActionResult{Key: "prefix/file", Path: "/file", Reason: PreActionHookFailureReason},
+			wantPathsSent: []string{"/file"},
+		},
+		{
+			name: "error status with open policy",
+			hooks: []hook{
+				{statusCode: http.StatusInternalServerError, failurePolicy: config.PreActionHookFailurePolicyOpen},
+				{statusCode: http.StatusOK, answer: `{"allowed":true}`},
+			},
+			want:          &PreActionResult{Allowed: true, Key: "prefix/file", Path: "/file"},
+			wantPathsSent: []string{"/file", "/file"},
+		},
+		{
+			name: "redirect status is a failure",
+			hooks: []hook{
+				{statusCode: http.StatusNotModified, failurePolicy: config.PreActionHookFailurePolicyClosed},
+			},
+			want:          &PreActionResult{Key: "prefix/file", Path: "/file", Reason: PreActionHookFailureReason},
+			wantPathsSent: []string{"/file"},
+		},
+		{
+			name: "invalid answer",
+			hooks: []hook{
+				{statusCode: http.StatusOK, answer: `fake`, failurePolicy: config.PreActionHookFailurePolicyClosed},
+			},
+			want:          &PreActionResult{Key: "prefix/file", Path: "/file", Reason: PreActionHookFailureReason},
+			wantPathsSent: []string{"/file"},
+		},
+		{
+			name: "missing allowed field",
+			hooks: []hook{
+				{statusCode: http.StatusOK, answer: `{"reason":"fake"}`, failurePolicy: config.PreActionHookFailurePolicyClosed},
+			},
+			want:          &PreActionResult{Key: "prefix/file", Path: "/file", Reason: PreActionHookFailureReason},
+			wantPathsSent: []string{"/file"},
+		},
+		{
+			name: "folder key",
+			hooks: []hook{
+				{statusCode: http.StatusOK, answer: `{"allowed":true,"path":"folder/"}`, failurePolicy: config.PreActionHookFailurePolicyClosed},
+			},
+			want:          &PreActionResult{Key: "prefix/file", Path: "/file", Reason: PreActionHookFailureReason},
+			wantPathsSent: []string{"/file"},
+		},
+		{
+			name: "escaping path with closed policy",
+			hooks: []hook{
+				{
+					statusCode:          http.StatusOK,
+					answer:              `{"allowed":true,"path":"/scanned/../other/file"}`,
+					failurePolicy:       config.PreActionHookFailurePolicyClosed,
+					rewritePathPrefixes: []string{"/scanned/"},
+				},
+			},
+			want:          &PreActionResult{Key: "prefix/file", Path: "/file", Reason: PreActionHookFailureReason},
+			wantPathsSent: []string{"/file"},
+		},
+		{
+			name: "escaping path with open policy",
+			hooks: []hook{
+				{
+					statusCode:          http.StatusOK,
+					answer:              `{"allowed":true,"path":"/scanned/../other/file","metadata":{"a":"1"}}`,
+					failurePolicy:       config.PreActionHookFailurePolicyOpen,
+					rewritePathPrefixes: []string{"/scanned/"},
+				},
+				{statusCode: http.StatusOK, answer: `{"allowed":true}`},
+			},
+			want:          &PreActionResult{Allowed: true, Key: "prefix/file", Path: "/file"},
+			wantPathsSent: []string{"/file", "/file"},
+		},
+		{
+			name: "timeout with closed policy",
+			hooks: []hook{
+				{
+					statusCode:    http.StatusOK,
+					answer:        `{"allowed":true}`,
+					delay:         200 * time.Millisecond,
+					failurePolicy: config.PreActionHookFailurePolicyClosed,
+				},
+			},
+			want:          &PreActionResult{Key: "prefix/file", Path: "/file", Reason: PreActionHookFailureReason},
+			wantPathsSent: []string{"/file"},
+		},
+		{
+			name: "timeout with open policy",
+			hooks: []hook{
+				{
+					statusCode:    http.StatusOK,
+					answer:        `{"allowed":false}`,
+					delay:         200 * time.Millisecond,
+					failurePolicy: config.PreActionHookFailurePolicyOpen,
+				},
+			},
+			want:          &PreActionResult{Allowed: true, Key: "prefix/file", Path: "/file"},
+			wantPathsSent: []string{"/file"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooksCfg := []*config.PreActionHookConfig{}
+			servers := []*preActionTestServer{}
+
+			for _, h := range tt.hooks {
+				s := newPreActionTestServer(t, h.statusCode, h.answer, h.delay)
+
+				servers = append(servers, s)
+				hooksCfg = append(hooksCfg, &config.PreActionHookConfig{
+					Method:              http.MethodPost,
+					URL:                 s.URL,
+					FailurePolicy:       h.failurePolicy,
+					RewritePathPrefixes: h.rewritePathPrefixes,
+					Timeout:             50 * time.Millisecond,
+				})
+			}
+
+			m := &manager{
+				storageMap: map[string]*hooksCfgStorage{
+					"target1": {PutPreAction: createPreActionRestClients(hooksCfg)},
+				},
+			}
+
+			got := m.ExecutePUTPreActionHooks(
+				newQueueTestContext(),
+				"target1",
+				newPreActionTestInput(),
+				&PutInputMetadata{Filename: "file", ContentType: "text/plain", ContentSize: 1},
+			)
+			assert.Equal(t, tt.want, got)
+
+			// Check sent bodies
+			paths := []string{}
+
+			for _, s := range servers {
+				for _, b := range s.calls() {
+					paths = append(paths, b.Path)
+
+					assert.Equal(t, "prefix"+b.Path, b.Key)
+					assert.Equal(t, PUTAction, b.Action)
+					assert.Equal(t, "/", b.RequestPath)
+					assert.Equal(t, &TargetHookBody{Name: "target1"}, b.Target)
+					assert.NotNil(t, b.InputMetadata)
+				}
+			}
+
+			assert.Equal(t, tt.wantPathsSent, paths)
+		})
+	}
+}
+
+func Test_manager_ExecuteDELETEPreActionHooks(t *testing.T) {
+	s := newPreActionTestServer(t, http.StatusOK, `{"allowed":false,"reason":"locked"}`, 0)
+
+	m := &manager{
+		storageMap: map[string]*hooksCfgStorage{
+			"target1": {DeletePreAction: createPreActionRestClients([]*config.PreActionHookConfig{
+				{Method: http.MethodPost, URL: s.URL},
+			})},
+		},
+	}
+
+	// Unknown target
+	got := m.ExecuteDELETEPreActionHooks(newQueueTestContext(), "target2", newPreActionTestInput())
+	assert.Equal(t, &PreActionResult{Allowed: true, Key: "prefix/file", Path: "/file"}, got)
+	assert.Empty(t, s.calls())
+
+	got = m.ExecuteDELETEPreActionHooks(newQueueTestContext(), "target1", newPreActionTestInput())
+	assert.Equal(t, &PreActionResult{Key: "prefix/file", Path: "/file", Reason: "locked"}, got)
+	require.Len(t, s.calls(), 1)
+	assert.Equal(t, &PreActionHookBody{
+		Target:      &TargetHookBody{Name: "target1"},
+		Action:      DELETEAction,
+		RequestPath: "/",
+		Path:        "/file",
+		Key:         "prefix/file",
+	}, s.calls()[0])
+}
@@ -0,0 +1,600 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics"
+)
+
+const (
+	queuePendingDirectory  = "pending"
+	queueDeadDirectory     = "dead"
+	queueFileExtension     = ".json"
+	queueTmpFileExtension  = ".tmp"
+	queueCorruptExtension  = ".corrupt"
+	queueFilePermissions   = 0o600
+	queueDirPermissions    = 0o750
+	queueRandomBytes       = 4
+	queueJobsBufferPerWork = 16
+)
+
+// ErrQueueDisabled is returned when the durable queue isn't enabled.
+var ErrQueueDisabled = errors.New("webhook durable queue is disabled")
+
+// ErrDeadLetterNotFound is returned when a dead letter doesn't exist.
+var ErrDeadLetterNotFound = errors.New("webhook dead letter not found")
+
+// Delivery is a webhook delivery saved in the durable queue.
+// Headers, secret headers and signature secrets aren't saved and are taken from the configuration when the delivery is sent.
+type Delivery struct {
+	CreatedAt     time.Time           `json:"createdAt"`
+	NextAttemptAt time.Time           `json:"nextAttemptAt"`
+	TraceHeaders  map[string][]string `json:"traceHeaders,omitempty"`
+	ID            string              `json:"id"`
+	Target        string              `json:"target"`
+	Action        string              `json:"action"`
+	URL           string              `json:"url"`
+	Method        string              `json:"method"`
+	LastError     string              `json:"lastError,omitempty"`
+	Body          json.RawMessage     `json:"body"`
+	HookIndex     int                 `json:"hookIndex"`
+	Attempts      int                 `json:"attempts"`
+}
+
+// hookFinder will return the hook storage matching a delivery or nil if it doesn't exist anymore.
+type hookFinder func(d *Delivery) *hookStorage
+
+// queue is a durable webhook delivery queue.
+// Deliveries are saved as files in a pending directory and moved to a dead directory
+// when they reach the maximum number of attempts.
+type queue struct {
+	cfg         *config.WebhookQueueConfig
+	metricsSvc  metrics.Client
+	logger      log.Logger
+	findHook    hookFinder
+	jobs        chan string
+	stopCh      chan struct{}
+	timers      map[string]*time.Timer
+	pendingDir  string
+	deadDir     string
+	wg          sync.WaitGroup
+	mutex       sync.Mutex
+	pending     int
+	deadLetters int
+	closed      bool
+}
+
+func newQueue(
+	cfg *config.WebhookQueueConfig,
+	metricsSvc metrics.Client,
+	logger log.Logger,
+	findHook hookFinder,
+) (*queue, error) {
+	q := &queue{
+		cfg:        cfg,
+		metricsSvc: metricsSvc,
+		logger:     logger.WithField("component", "webhook-queue"),
+		findHook:   findHook,
+		jobs:       make(chan string, cfg.Workers*queueJobsBufferPerWork),
+		stopCh:     make(chan struct{}),
+		timers:     map[string]*time.Timer{},
+		pendingDir: filepath.Join(cfg.Directory, queuePendingDirectory),
+		deadDir:    filepath.Join(cfg.Directory, queueDeadDirectory),
+	}
+
+	// Ensure directories exist
+	for _, dir := range []string{q.pendingDir, q.deadDir} {
+		err := os.MkdirAll(dir, queueDirPermissions)
+		// Check error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	// Load pending deliveries left by a previous run
+	pendingList, err := q.readAll(q.pendingDir)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Count dead letters
+	deadIDs, err := listIDs(q.deadDir)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	q.pending = len(pendingList)
+	q.deadLetters = len(deadIDs)
+	q.updateGauges()
+
+	// Start workers
+	for range cfg.Workers {
+		q.wg.Add(1)
+
+		go q.work()
+	}
+
+	// Schedule pending deliveries
+	for _, d := range pendingList {
+		q.schedule(d)
+	}
+
+	// Check if some deliveries have been recovered
+	if len(pendingList) != 0 {
+		q.logger.Infof("%d pending webhook deliveries recovered", len(pendingList))
+	}
+
+	return q, nil
+}
+
+// enqueue will save a new delivery and schedule it immediately.
+func (q *queue) enqueue(
+	targetName, action string,
+	hookIndex int,
+	hookCfg *config.WebhookConfig,
+	body any,
+	traceHeader http.Header,
+) error {
+	// Marshal body
+	bb, err := json.Marshal(body)
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Generate random suffix to avoid collisions
+	rb := make([]byte, queueRandomBytes)
+	_, err = rand.Read(rb)
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	now := time.Now()
+	d := &Delivery{
+		ID:            fmt.Sprintf("%020d-%s", now.UnixNano(), hex.EncodeToString(rb)),
+		Target:        targetName,
+		Action:        action,
+		HookIndex:     hookIndex,
+		URL:           hookCfg.URL,
+		Method:        hookCfg.Method,
+		Body:          bb,
+		TraceHeaders:  traceHeader,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+
+	// Save delivery
+	err = writeDelivery(q.pendingDir, d)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	q.pending++
+	q.updateGauges()
+	q.mutex.Unlock()
+
+	// Schedule
+	q.schedule(d)
+
+	return nil
+}
+
+// schedule will push delivery to workers when its next attempt date is reached.
+func (q *queue) schedule(d *Delivery) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	// Check if queue is closed
+	if q.closed {
+		return
+	}
+
+	id := d.ID
+	q.timers[id] = time.AfterFunc(time.Until(d.NextAttemptAt), func() {
+		q.mutex.Lock()
+		delete(q.timers, id)
+		q.mutex.Unlock()
+
+		select {
+		case q.jobs <- id:
+		case <-q.stopCh:
+		}
+	})
+}
+
+// work will deliver jobs until the queue is closed.
+func (q *queue) work() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.stopCh:
+			return
+		case id := <-q.jobs:
+			q.deliver(id)
+		}
+	}
+}
+
+// deliver will send a pending delivery and reschedule it or move it to dead letters on error.
+func (q *queue) deliver(id string) {
+	// Create specific logger
+	logger := q.logger.WithField("webhook_delivery_id", id)
+
+	// Read delivery
+	d, err := readDelivery(q.pendingDir, id)
+	// Check error
+	if err != nil {
+		// Ignore deliveries removed in the meantime
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Error(err)
+		}
+
+		return
+	}
+
+	logger = logger.WithFields(map[string]any{
+		"webhook_action": d.Action,
+		"webhook_target": d.Target,
+		"webhook_number": d.HookIndex,
+	})
+
+	// Find hook in configuration
+	st := q.findHook(d)
+	// Check if hook doesn't exist anymore
+	if st == nil {
+		d.LastError = "webhook not found in configuration"
+		logger.Error(d.LastError)
+		q.moveToDeadLetters(d, logger)
+
+		return
+	}
+
+	// Execute request
+	_, err = callHook(st, d.Body, http.Header(d.TraceHeaders))
+	// Check error
+	if err == nil {
+		// Remove delivery
+		err = os.Remove(filepath.Join(q.pendingDir, id+queueFileExtension))
+		// Check error
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Error(errors.WithStack(err))
+		}
+
+		q.mutex.Lock()
+		q.pending--
+		q.updateGauges()
+		q.mutex.Unlock()
+
+		q.metricsSvc.IncSucceedWebhooks(d.Target, d.Action)
+		q.metricsSvc.ObserveWebhookDeliveryLatency(d.Target, d.Action, time.Since(d.CreatedAt))
+
+		logger.Info("Webhook succeed")
+
+		return
+	}
+
+	// Increase failed webhooks
+	q.metricsSvc.IncFailedWebhooks(d.Target, d.Action)
+
+	d.Attempts++
+	d.LastError = err.Error()
+
+	logger.WithField("webhook_attempts", d.Attempts).Error(err)
+
+	// Check if max attempts is reached
+	if d.Attempts >= q.cfg.MaxAttempts {
+		q.moveToDeadLetters(d, logger)
+
+		return
+	}
+
+	// Compute next attempt date
+	d.NextAttemptAt = time.Now().Add(q.backoff(d.Attempts))
+
+	// Save delivery
+	err = writeDelivery(q.pendingDir, d)
+	// Check error
+	if err != nil {
+		logger.Error(err)
+
+		return
+	}
+
+	// Reschedule
+	q.schedule(d)
+}
+
+// backoff returns the exponential backoff duration for a number of attempts.
+func (q *queue) backoff(attempts int) time.Duration {
+	res := q.cfg.InitialBackoff
+
+	for i := 1; i < attempts; i++ {
+		res *= 2
+		// Check if max backoff is reached
+		if res >= q.cfg.MaxBackoff {
+			return q.cfg.MaxBackoff
+		}
+	}
+
+	return min(res, q.cfg.MaxBackoff)
+}
+
+// moveToDeadLetters will move a pending delivery to dead letters.
+func (q *queue) moveToDeadLetters(d *Delivery, logger log.Logger) {
+	// Save delivery in dead letters
+	err := writeDelivery(q.deadDir, d)
+	// Check error
+	if err != nil {
+		logger.Error(err)
+
+		return
+	}
+
+	// Remove pending delivery
+	err = os.Remove(filepath.Join(q.pendingDir, d.ID+queueFileExtension))
+	// Check error
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Error(errors.WithStack(err))
+	}
+
+	q.mutex.Lock()
+	q.pending--
+	q.deadLetters++
+	q.updateGauges()
+	q.mutex.Unlock()
+
+	logger.Warn("Webhook delivery moved to dead letters")
+}
+
+// listDeadLetters returns all dead letters sorted by creation.
+func (q *queue) listDeadLetters() ([]*Delivery, error) {
+	return q.readAll(q.deadDir)
+}
+
+// replay will move a dead letter, or all of them when id is empty, back to pending deliveries.
+func (q *queue) replay(id string) (int, error) {
+	ids := []string{id}
+
+	// Check if all dead letters must be replayed
+	if id == "" {
+		var err error
+
+		ids, err = listIDs(q.deadDir)
+		// Check error
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	count := 0
+
+	for _, it := range ids {
+		// Read dead letter
+		d, err := readDelivery(q.deadDir, it)
+		// Check error
+		if err != nil {
+			// Check if dead letter doesn't exist
+			if errors.Is(err, os.ErrNotExist) {
+				return count, errors.WithStack(ErrDeadLetterNotFound)
+			}
+
+			return count, err
+		}
+
+		// Reset delivery
+		d.Attempts = 0
+		d.LastError = ""
+		d.NextAttemptAt = time.Now()
+
+		// Save delivery in pending
+		err = writeDelivery(q.pendingDir, d)
+		// Check error
+		if err != nil {
+			return count, err
+		}
+
+		// Remove dead letter
+		err = os.Remove(filepath.Join(q.deadDir, it+queueFileExtension))
+		// Check error
+		if err != nil {
+			return count, errors.WithStack(err)
+		}
+
+		q.mutex.Lock()
+		q.pending++
+		q.deadLetters--
+		q.updateGauges()
+		q.mutex.Unlock()
+
+		// Schedule
+		q.schedule(d)
+
+		count++
+	}
+
+	return count, nil
+}
+
+// close will stop workers and timers. Pending deliveries are kept on disk.
+func (q *queue) close() {
+	q.mutex.Lock()
+	// Check if already closed
+	if q.closed {
+		q.mutex.Unlock()
+
+		return
+	}
+
+	q.closed = true
+	// Stop timers
+	for _, t := range q.timers {
+		t.Stop()
+	}
+
+	q.timers = map[string]*time.Timer{}
+	q.mutex.Unlock()
+
+	close(q.stopCh)
+	// Wait for deliveries in progress
+	q.wg.Wait()
+}
+
+// updateGauges must be called with mutex locked.
+func (q *queue) updateGauges() {
+	q.metricsSvc.SetWebhookQueueDepth(q.pending)
+	q.metricsSvc.SetWebhookDeadLetters(q.deadLetters)
+}
+
+// readAll will read all deliveries of a directory sorted by creation.
+// Unreadable deliveries are logged and skipped to avoid blocking the queue.
+// Pending ones are also moved to the dead directory with a specific extension to keep them for investigation.
+func (q *queue) readAll(dir string) ([]*Delivery, error) {
+	// List ids
+	ids, err := listIDs(dir)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*Delivery, 0, len(ids))
+
+	for _, id := range ids {
+		// Read delivery
+		d, err := readDelivery(dir, id)
+		// Check error
+		if err != nil {
+			// Ignore deliveries removed in the meantime
+			if !errors.Is(err, os.ErrNotExist) {
+				q.logger.WithField("webhook_delivery_id", id).Error(errors.Wrap(err, "webhook delivery can't be read and is ignored"))
+				// Check if it is a pending delivery
+				if dir == q.pendingDir {
+					q.moveCorruptDelivery(id)
+				}
+			}
+
+			continue
+		}
+
+		res = append(res, d)
+	}
+
+	return res, nil
+}
+
+// moveCorruptDelivery will move an unreadable pending delivery file to the dead directory.
+// Its extension is changed in order to be ignored by dead letters listing and replay.
+func (q *queue) moveCorruptDelivery(id string) {
+	// Move file
+	err := os.Rename(
+		filepath.Join(q.pendingDir, id+queueFileExtension),
+		filepath.Join(q.deadDir, id+queueFileExtension+queueCorruptExtension),
+	)
+	// Check error
+	if err != nil {
+		q.logger.WithField("webhook_delivery_id", id).Error(errors.WithStack(err))
+
+		return
+	}
+
+	q.logger.WithField("webhook_delivery_id", id).Warn("Corrupted webhook delivery moved to dead directory")
+}
+
+// listIDs will list delivery ids of a directory sorted by creation.
+func listIDs(dir string) ([]string, error) {
+	// Read directory
+	entries, err := os.ReadDir(dir)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := []string{}
+
+	for _, e := range entries {
+		// Ignore directories and temporary files
+		if e.IsDir() || !strings.HasSuffix(e.Name(), queueFileExtension) {
+			continue
+		}
+
+		res = append(res, strings.TrimSuffix(e.Name(), queueFileExtension))
+	}
+
+	// Ids start with creation date
+	sort.Strings(res)
+
+	return res, nil
+}
+
+// readDelivery will read a delivery file.
+func readDelivery(dir, id string) (*Delivery, error) {
+	// Avoid path traversal with ids coming from requests
+	if id == "" || filepath.Base(id) != id {
+		return nil, errors.WithStack(os.ErrNotExist)
+	}
+
+	// Read file
+	b, err := os.ReadFile(filepath.Join(dir, id+queueFileExtension))
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := &Delivery{}
+	// Parse
+	err = json.Unmarshal(b, res)
+	// Check error
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return res, nil
+}
+
+// writeDelivery will write a delivery file atomically.
+func writeDelivery(dir string, d *Delivery) error {
+	// Marshal
+	b, err := json.Marshal(d)
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	path := filepath.Join(dir, d.ID+queueFileExtension)
+	tmpPath := path + queueTmpFileExtension
+
+	// Write temporary file
+	err = os.WriteFile(tmpPath, b, queueFilePermissions)
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Rename it to avoid partial files on crash
+	err = os.Rename(tmpPath, path)
+	// Check error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,354 @@
+//go:build unit
+
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config"
+	cmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/config/mocks"
+	"github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/log"
+	mmocks "github.com/oxyno-zeta/s3-proxy/pkg/s3-proxy/metrics/mocks"
+)
+
+// queueTestServer is a webhook server whose status code can be changed during tests.
+type queueTestServer struct {
+	*httptest.Server
+	bodies     []string
+	statusCode int
+	mutex      sync.Mutex
+}
+
+func newQueueTestServer(t *testing.T, statusCode int) *queueTestServer {
+	t.Helper()
+
+	s := &queueTestServer{statusCode: statusCode}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		by, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.bodies = append(s.bodies, string(by))
+		rw.WriteHeader(s.statusCode)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *queueTestServer) setStatusCode(statusCode int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.statusCode = statusCode
+}
+
+func (s *queueTestServer) calls() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string{}, s.bodies...)
+}
+
+func newQueueTestManager(t *testing.T, url string, qCfg *config.WebhookQueueConfig) *manager {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	cfgManagerMock := cmocks.NewMockManager(ctrl)
+	metricsSvcMock := mmocks.NewMockClient(ctrl)
+
+	cfgManagerMock.EXPECT().GetConfig().AnyTimes().Return(&config.Config{
+		Targets: map[string]*config.TargetConfig{
+			"target1": {
+				Actions: &config.ActionsConfig{
+					DELETE: &config.DeleteActionConfig{
+						Enabled: true,
+						Config: &config.DeleteActionConfigConfig{
+							Webhooks: []*config.WebhookConfig{{Method: http.MethodPost, URL: url}},
+						},
+					},
+				},
+			},
+		},
+		WebhookQueue: qCfg,
+	})
+	metricsSvcMock.EXPECT().SetWebhookQueueDepth(gomock.Any()).AnyTimes()
+	metricsSvcMock.EXPECT().SetWebhookDeadLetters(gomock.Any()).AnyTimes()
+	metricsSvcMock.EXPECT().IncFailedWebhooks(gomock.Any(), gomock.Any()).AnyTimes()
+	metricsSvcMock.EXPECT().IncSucceedWebhooks(gomock.Any(), gomock.Any()).AnyTimes()
+	metricsSvcMock.EXPECT().ObserveWebhookDeliveryLatency(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	m, _ := NewManager(cfgManagerMock, metricsSvcMock, log.NewLogger()).(*manager)
+	t.Cleanup(func() { _ = m.Close() })
+
+	return m
+}
+
+func newQueueTestConfig(dir string) *config.WebhookQueueConfig {
+	return &config.WebhookQueueConfig{
+		Enabled:        true,
+		Directory:      dir,
+		Workers:        2,
+		MaxAttempts:    2,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+	}
+}
+
+func newQueueTestContext() context.Context {
+	ctx := log.SetLoggerInContext(context.TODO(), log.NewLogger())
+
+	return opentracing.ContextWithSpan(ctx, opentracing.StartSpan("fake"))
+}
+
+func countQueueFiles(t *testing.T, dir string) int {
+	t.Helper()
+
+	ids, err := listIDs(dir)
+	require.NoError(t, err)
+
+	return len(ids)
+}
+
+func Test_queue_delivery(t *testing.T) {
+	s := newQueueTestServer(t, http.StatusOK)
+	dir := t.TempDir()
+	m := newQueueTestManager(t, s.URL, newQueueTestConfig(dir))
+
+	require.NoError(t, m.Load())
+
+	m.manageDELETEHooksInternal(newQueueTestContext(), "target1", "/file", &S3Metadata{Bucket: "bucket", Key: "file"})
+
+	assert.Eventually(t, func() bool { return len(s.calls()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.JSONEq(t, `{
+		"action":"DELETE",
+		"requestPath":"/file",
+		"outputMetadata":{"bucket":"bucket","region":"","key":"file"},
+		"target":{"name":"target1"}
+	}`, s.calls()[0])
+	assert.Eventually(t, func() bool {
+		return countQueueFiles(t, filepath.Join(dir, queuePendingDirectory)) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func Test_queue_deadLettersAndReplay(t *testing.T) {
+	s := newQueueTestServer(t, http.StatusInternalServerError)
+	dir := t.TempDir()
+	m := newQueueTestManager(t, s.URL, newQueueTestConfig(dir))
+
+	require.NoError(t, m.Load())
+
+	m.manageDELETEHooksInternal(newQueueTestContext(), "target1", "/file", &S3Metadata{Bucket: "bucket", Key: "file"})
+
+	// Wait for dead letter
+	var list []*Delivery
+
+	assert.Eventually(t, func() bool {
+		var err error
+
+		list, err = m.ListDeadLetters()
+		require.NoError(t, err)
+
+		return len(list) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Len(t, s.calls(), 2)
+	assert.Equal(t, 2, list[0].Attempts)
+	assert.Equal(t, "target1", list[0].Target)
+	assert.Equal(t, DELETEAction, list[0].Action)
+	assert.Contains(t, list[0].LastError, "500")
+	assert.Equal(t, 0, countQueueFiles(t, filepath.Join(dir, queuePendingDirectory)))
+
+	// Replay unknown dead letter
+	_, err := m.ReplayDeadLetters("unknown")
+	assert.ErrorIs(t, err, ErrDeadLetterNotFound)
+
+	// Replay after webhook is fixed
+	s.setStatusCode(http.StatusOK)
+
+	count, err := m.ReplayDeadLetters(list[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	assert.Eventually(t, func() bool { return len(s.calls()) == 3 }, 5*time.Second, 10*time.Millisecond)
+	assert.JSONEq(t, s.calls()[0], s.calls()[2])
+
+	list, err = m.ListDeadLetters()
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func Test_queue_recovery(t *testing.T) {
+	s := newQueueTestServer(t, http.StatusOK)
+	dir := t.TempDir()
+	pendingDir := filepath.Join(dir, queuePendingDirectory)
+
+	// Save deliveries left by a previous run
+	require.NoError(t, os.MkdirAll(pendingDir, queueDirPermissions))
+	require.NoError(t, writeDelivery(pendingDir, &Delivery{
+		ID:            "00000000000000000001-aa",
+		Target:        "target1",
+		Action:        DELETEAction,
+		URL:           s.URL,
+		Method:        http.MethodPost,
+		Body:          []byte(`{"action":"DELETE"}`),
+		NextAttemptAt: time.Now(),
+	}))
+	require.NoError(t, writeDelivery(pendingDir, &Delivery{
+		ID:            "00000000000000000002-bb",
+		Target:        "removed-target",
+		Action:        DELETEAction,
+		URL:           s.URL,
+		Method:        http.MethodPost,
+		Body:          []byte(`{"action":"DELETE"}`),
+		NextAttemptAt: time.Now(),
+	}))
+
+	m := newQueueTestManager(t, s.URL, newQueueTestConfig(dir))
+
+	require.NoError(t, m.Load())
+
+	assert.Eventually(t, func() bool { return len(s.calls()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.JSONEq(t, `{"action":"DELETE"}`, s.calls()[0])
+
+	// Delivery for a removed webhook must be moved to dead letters
+	assert.Eventually(t, func() bool {
+		list, err := m.ListDeadLetters()
+		require.NoError(t, err)
+
+		return len(list) == 1 && list[0].ID == "00000000000000000002-bb"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, countQueueFiles(t, pendingDir))
+}
+
+func Test_queue_corruptedPendingDelivery(t *testing.T) {
+	s := newQueueTestServer(t, http.StatusOK)
+	dir := t.TempDir()
+	pendingDir := filepath.Join(dir, queuePendingDirectory)
+	deadDir := filepath.Join(dir, queueDeadDirectory)
+
+	// Save a valid delivery and a corrupted one left by a previous run
+	require.NoError(t, os.MkdirAll(pendingDir, queueDirPermissions))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(pendingDir, "00000000000000000001-aa"+queueFileExtension),
+		[]byte(`{"id":`),
+		queueFilePermissions,
+	))
+	require.NoError(t, writeDelivery(pendingDir, &Delivery{
+		ID:            "00000000000000000002-bb",
+		Target:        "target1",
+		Action:        DELETEAction,
+		URL:           s.URL,
+		Method:        http.MethodPost,
+		Body:          []byte(`{"action":"DELETE"}`),
+		NextAttemptAt: time.Now(),
+	}))
+
+	m := newQueueTestManager(t, s.URL, newQueueTestConfig(dir))
+
+	// Load must not fail because of the corrupted delivery
+	require.NoError(t, m.Load())
+
+	assert.Eventually(t, func() bool { return len(s.calls()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.JSONEq(t, `{"action":"DELETE"}`, s.calls()[0])
+
+	// Corrupted delivery must be kept in dead directory without being a dead letter
+	assert.FileExists(t, filepath.Join(deadDir, "00000000000000000001-aa"+queueFileExtension+queueCorruptExtension))
+	assert.Eventually(t, func() bool {
+		return countQueueFiles(t, pendingDir) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	list, err := m.ListDeadLetters()
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func Test_queue_findHookDuringReload(t *testing.T) {
+	s := newQueueTestServer(t, http.StatusOK)
+	m := newQueueTestManager(t, s.URL, newQueueTestConfig(t.TempDir()))
+
+	require.NoError(t, m.Load())
+
+	d := &Delivery{Target: "target1", Action: DELETEAction, URL: s.URL, Method: http.MethodPost}
+
+	// Read hooks like workers while configuration is reloaded
+	var wg sync.WaitGroup
+
+	for range 4 {
+		wg.Go(func() {
+			for range 100 {
+				assert.NotNil(t, m.findHook(d))
+			}
+		})
+	}
+
+	for range 20 {
+		require.NoError(t, m.Load())
+	}
+
+	wg.Wait()
+}
+
+func Test_queue_disabled(t *testing.T) {
+	s := newQueueTestServer(t, http.StatusOK)
+	dir := t.TempDir()
+	qCfg := newQueueTestConfig(dir)
+	m := newQueueTestManager(t, s.URL, qCfg)
+
+	require.NoError(t, m.Load())
+	assert.NotNil(t, m.queue)
+
+	// Disable on reload
+	qCfg.Enabled = false
+
+	require.NoError(t, m.Load())
+	assert.Nil(t, m.queue)
+
+	_, err := m.ListDeadLetters()
+	assert.ErrorIs(t, err, ErrQueueDisabled)
+
+	_, err = m.ReplayDeadLetters("")
+	assert.ErrorIs(t, err, ErrQueueDisabled)
+
+	// Webhooks are sent directly
+	m.manageDELETEHooksInternal(newQueueTestContext(), "target1", "/file", &S3Metadata{Bucket: "bucket", Key: "file"})
+
+	assert.Len(t, s.calls(), 1)
+	assert.Equal(t, 0, countQueueFiles(t, filepath.Join(dir, queuePendingDirectory)))
+}
+
+func Test_queue_backoff(t *testing.T) {
+	q := &queue{cfg: &config.WebhookQueueConfig{
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Second,
+	}}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 3, want: 4 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		{attempts: 5, want: 10 * time.Second},
+		{attempts: 100, want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, q.backoff(tt.attempts))
+	}
+}
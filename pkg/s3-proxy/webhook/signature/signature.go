@@ -0,0 +1,190 @@
+// Package signature contains the webhook payload signing used by S3-Proxy.
+//
+// Webhook receivers can import this package to verify that requests come from S3-Proxy:
+//
+//	v := signature.NewVerifier("secret")
+//	body, err := v.VerifyRequest(req)
+//
+// The signature header has the format "t=TIMESTAMP,v1=HEX[,v1=HEX...]" where TIMESTAMP is the unix time
+// of the delivery and each HEX is the HMAC-SHA256 of "TIMESTAMP.BODY" with one of the active secrets.
+package signature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+const (
+	// DefaultHeader is the default header containing the signature.
+	DefaultHeader = "X-S3P-Webhook-Signature"
+	// DefaultTolerance is the default maximum difference between signature timestamp and receiver time.
+	DefaultTolerance = 5 * time.Minute
+	// TimestampKey is the header key containing the unix timestamp.
+	TimestampKey = "t"
+	// SchemeKey is the header key containing a HMAC-SHA256 signature.
+	SchemeKey = "v1"
+)
+
+// ErrInvalidHeader is returned when signature header can't be parsed.
+var ErrInvalidHeader = errors.New("invalid webhook signature header")
+
+// ErrExpired is returned when signature timestamp is outside the tolerance.
+var ErrExpired = errors.New("webhook signature timestamp outside of tolerance")
+
+// ErrMismatch is returned when no signature matches the secrets.
+var ErrMismatch = errors.New("webhook signature mismatch")
+
+// Compute will return the hex encoded HMAC-SHA256 of "TIMESTAMP.BODY" with a secret.
+func Compute(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	// Hash.Write never returns an error
+	_, _ = mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	_, _ = mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Header will build a signature header value with one signature per secret.
+// Signing with several secrets allows key rotation: receivers accept the request when one signature matches.
+func Header(timestamp time.Time, body []byte, secrets ...string) string {
+	ts := timestamp.Unix()
+	parts := make([]string, 0, len(secrets)+1)
+	parts = append(parts, TimestampKey+"="+strconv.FormatInt(ts, 10))
+
+	for _, s := range secrets {
+		parts = append(parts, SchemeKey+"="+Compute(s, ts, body))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// Parse will parse a signature header value and return the timestamp and the signatures.
+// Unknown keys are ignored to allow new schemes.
+func Parse(header string) (int64, []string, error) {
+	var (
+		ts     int64
+		tsSet  bool
+		values []string
+	)
+
+	// Loop over parts
+	for _, it := range strings.Split(header, ",") {
+		// Split key and value
+		k, v, found := strings.Cut(strings.TrimSpace(it), "=")
+		if !found {
+			return 0, nil, errors.WithStack(ErrInvalidHeader)
+		}
+
+		switch k {
+		case TimestampKey:
+			var err error
+			// Parse timestamp
+			ts, err = strconv.ParseInt(v, 10, 64)
+			// Check error
+			if err != nil {
+				return 0, nil, errors.WithStack(ErrInvalidHeader)
+			}
+
+			tsSet = true
+		case SchemeKey:
+			values = append(values, v)
+		}
+	}
+
+	// Check that all parameters are set
+	if !tsSet || len(values) == 0 {
+		return 0, nil, errors.WithStack(ErrInvalidHeader)
+	}
+
+	return ts, values, nil
+}
+
+// Verify will check that a signature header matches a body with one of the secrets
+// and that its timestamp isn't older or newer than tolerance. A zero tolerance disables the timestamp check.
+func Verify(header string, body []byte, secrets []string, tolerance time.Duration, now time.Time) error {
+	// Parse header
+	ts, values, err := Parse(header)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Check timestamp
+	if tolerance > 0 && now.Sub(time.Unix(ts, 0)).Abs() > tolerance {
+		return errors.WithStack(ErrExpired)
+	}
+
+	// Loop over secrets
+	for _, s := range secrets {
+		expected := []byte(Compute(s, ts, body))
+
+		for _, v := range values {
+			// Constant time comparison
+			if hmac.Equal(expected, []byte(v)) {
+				return nil
+			}
+		}
+	}
+
+	return errors.WithStack(ErrMismatch)
+}
+
+// Verifier will verify webhook requests.
+type Verifier struct {
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+	// Header is the header containing the signature. Defaults to DefaultHeader.
+	Header string
+	// Secrets are the accepted secrets. Several secrets can be set during key rotation.
+	Secrets []string
+	// Tolerance is the maximum difference between signature timestamp and current time. Defaults to DefaultTolerance.
+	Tolerance time.Duration
+}
+
+// NewVerifier will create a new verifier with default values.
+func NewVerifier(secrets ...string) *Verifier {
+	return &Verifier{
+		Now:       time.Now,
+		Header:    DefaultHeader,
+		Secrets:   secrets,
+		Tolerance: DefaultTolerance,
+	}
+}
+
+// VerifyRequest will verify the request signature and return its body.
+// Request body is read and replaced so it can be read again by handlers.
+func (v *Verifier) VerifyRequest(req *http.Request) ([]byte, error) {
+	var body []byte
+	// Check if body exists
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		// Read body
+		body, err = io.ReadAll(req.Body)
+		// Check error
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		_ = req.Body.Close()
+		// Replace body
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	// Verify
+	err := Verify(req.Header.Get(v.Header), body, v.Secrets, v.Tolerance, v.Now())
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
@@ -0,0 +1,214 @@
+//go:build unit
+
+package signature
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompute(t *testing.T) {
+	// echo -n '1700000000.{"action":"PUT"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(
+		t,
+		"8d1b4fadd6727adaea5c79eee6842dd30d8c841b8dc2554ad297cd452a604fc7",
+		Compute("secret", 1700000000, []byte(`{"action":"PUT"}`)),
+	)
+	assert.NotEqual(t, Compute("secret", 1700000000, []byte("body")), Compute("secret", 1700000001, []byte("body")))
+	assert.NotEqual(t, Compute("secret", 1700000000, []byte("body")), Compute("other", 1700000000, []byte("body")))
+}
+
+func TestHeader(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	body := []byte("body")
+
+	assert.Equal(
+		t,
+		"t=1700000000,v1="+Compute("s1", 1700000000, body)+",v1="+Compute("s2", 1700000000, body),
+		Header(ts, body, "s1", "s2"),
+	)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		wantTS     int64
+		wantValues []string
+		wantErr    bool
+	}{
+		{
+			name:       "valid",
+			header:     "t=1700000000,v1=abcd,v1=efgh",
+			wantTS:     1700000000,
+			wantValues: []string{"abcd", "efgh"},
+		},
+		{
+			name:       "unknown scheme ignored",
+			header:     "t=1700000000, v0=old, v1=abcd",
+			wantTS:     1700000000,
+			wantValues: []string{"abcd"},
+		},
+		{
+			name:    "empty",
+			header:  "",
+			wantErr: true,
+		},
+		{
+			name:    "missing timestamp",
+			header:  "v1=abcd",
+			wantErr: true,
+		},
+		{
+			name:    "invalid timestamp",
+			header:  "t=fake,v1=abcd",
+			wantErr: true,
+		},
+		{
+			name:    "missing signature",
+			header:  "t=1700000000",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, values, err := Parse(tt.header)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidHeader)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTS, ts)
+			assert.Equal(t, tt.wantValues, values)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"action":"PUT"}`)
+
+	tests := []struct {
+		wantErr   error
+		name      string
+		header    string
+		body      []byte
+		secrets   []string
+		tolerance time.Duration
+	}{
+		{
+			name:      "valid",
+			header:    Header(now, body, "secret"),
+			body:      body,
+			secrets:   []string{"secret"},
+			tolerance: time.Minute,
+		},
+		{
+			name:      "valid with old secret during rotation",
+			header:    Header(now, body, "old"),
+			body:      body,
+			secrets:   []string{"new", "old"},
+			tolerance: time.Minute,
+		},
+		{
+			name:      "valid with new secret during rotation",
+			header:    Header(now, body, "new", "old"),
+			body:      body,
+			secrets:   []string{"new"},
+			tolerance: time.Minute,
+		},
+		{
+			name:      "modified body",
+			header:    Header(now, body, "secret"),
+			body:      []byte(`{"action":"DELETE"}`),
+			secrets:   []string{"secret"},
+			tolerance: time.Minute,
+			wantErr:   ErrMismatch,
+		},
+		{
+			name:      "wrong secret",
+			header:    Header(now, body, "secret"),
+			body:      body,
+			secrets:   []string{"other"},
+			tolerance: time.Minute,
+			wantErr:   ErrMismatch,
+		},
+		{
+			name:      "modified timestamp",
+			header:    strings.Replace(Header(now, body, "secret"), "t=1700000000", "t=1700000001", 1),
+			body:      body,
+			secrets:   []string{"secret"},
+			tolerance: time.Minute,
+			wantErr:   ErrMismatch,
+		},
+		{
+			name:      "expired",
+			header:    Header(now.Add(-2*time.Minute), body, "secret"),
+			body:      body,
+			secrets:   []string{"secret"},
+			tolerance: time.Minute,
+			wantErr:   ErrExpired,
+		},
+		{
+			name:    "old timestamp without tolerance check",
+			header:  Header(now.Add(-2*time.Hour), body, "secret"),
+			body:    body,
+			secrets: []string{"secret"},
+		},
+		{
+			name:      "invalid header",
+			header:    "fake",
+			body:      body,
+			secrets:   []string{"secret"},
+			tolerance: time.Minute,
+			wantErr:   ErrInvalidHeader,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.header, tt.body, tt.secrets, tt.tolerance, now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestVerifier_VerifyRequest(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := `{"action":"PUT"}`
+
+	v := NewVerifier("secret")
+	v.Now = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/hook", strings.NewReader(body))
+	req.Header.Set(DefaultHeader, Header(now, []byte(body), "secret"))
+
+	got, err := v.VerifyRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(got))
+
+	// Body can be read again
+	again, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(again))
+
+	// Missing header
+	req = httptest.NewRequest(http.MethodPost, "http://localhost/hook", strings.NewReader(body))
+
+	_, err = v.VerifyRequest(req)
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+}
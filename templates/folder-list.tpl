@@ -17,6 +17,9 @@
 <html>
   <body>
     <h1>Index of {{ .Request.URL.Path }}</h1>
+    {{- if .Quota }}
+    <p>Quota: {{ .Quota.UsedBytes | humanSize }}{{ if .Quota.MaxBytes }} / {{ .Quota.MaxBytes | humanSize }}{{ end }} - {{ .Quota.UsedObjects }}{{ if .Quota.MaxObjects }} / {{ .Quota.MaxObjects }}{{ end }} objects</p>
+    {{- end }}
     <table style="width:100%">
         <thead>
             <tr>
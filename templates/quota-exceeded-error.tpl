@@ -0,0 +1,14 @@
+{{- if contains "application/json" (.Request.Header.Get "Accept") -}}
+{{ template "main.body.errorJsonBody" . }}
+{{- else -}}
+<!DOCTYPE html>
+<html>
+  <body>
+    <h1>Quota Exceeded</h1>
+    <p>{{ .Error }}</p>
+    {{- if .Quota }}
+    <p>Used: {{ .Quota.UsedBytes | humanSize }}{{ if .Quota.MaxBytes }} / {{ .Quota.MaxBytes | humanSize }}{{ end }} - {{ .Quota.UsedObjects }}{{ if .Quota.MaxObjects }} / {{ .Quota.MaxObjects }}{{ end }} objects</p>
+    {{- end }}
+  </body>
+</html>
+{{- end -}}